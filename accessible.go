@@ -0,0 +1,20 @@
+package main
+
+import "strings"
+
+// accessibleOutput replaces color-only signaling and box-drawing separators with plain text, set by --accessible
+var accessibleOutput = false
+
+// progressMarker returns a textual marker for a progress message in accessible mode
+func progressMarker(message string) string {
+	switch {
+	case strings.HasPrefix(message, "Warning"):
+		return "WARNING: "
+	case strings.Contains(message, "Error") || strings.Contains(message, "Failed"):
+		return "ERROR: "
+	case strings.Contains(message, "expired") || strings.Contains(message, "not valid"):
+		return "WARNING: "
+	default:
+		return "INFO: "
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/report"
+)
+
+func TestProgressMarker(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"Reading prompt from stdin", "INFO: "},
+		{"Warning: skipping empty prompt a.md", "WARNING: "},
+		{"Warning: input is not valid UTF-8, decoding it as Windows-1252", "WARNING: "},
+		{"Warning: Error rate of the judge is high", "WARNING: "},
+		{"Error loading dismissals", "ERROR: "},
+		{"Failed to write cache", "ERROR: "},
+		{"Dismissal of a.md has expired", "WARNING: "},
+	}
+	for _, tt := range tests {
+		if got := progressMarker(tt.message); got != tt.want {
+			t.Errorf("progressMarker(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestAccessibleReportHeadline(t *testing.T) {
+	out := report.Accessible([]Issue{
+		{RuleName: "A", Description: "Vague instruction", Severity: "error"},
+		{RuleName: "B", Description: "Missing persona"},
+		{RuleName: "C", Description: "Long prompt", Severity: "info", Dismissed: true, DismissReason: "accepted"},
+	})
+	for _, want := range []string{
+		"ISSUE 1 of 3, ERROR: Vague instruction\n",
+		"ISSUE 2 of 3: Missing persona\n",
+		"DISMISSED 3 of 3, INFO: Long prompt\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("accessible report misses %q:\n%s", want, out)
+		}
+	}
+}
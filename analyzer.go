@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+func init() {
+	analyzers.Configure(analyzers.Settings{UserAgent: appName + "/" + appVersion, Progress: printProgress})
+}
+
+// ParseDocument builds the prompt model of a loaded document
+func ParseDocument(doc *Document) *PromptModel {
+	model := linter.ParsePrompt(doc.Text)
+	model.Format = doc.Format
+	model.Messages = doc.Messages
+	model.Frontmatter = doc.Frontmatter
+	return model
+}
+
+// analyzerNames returns sorted names of registered analyzers
+func analyzerNames() []string {
+	return linter.AnalyzerNames()
+}
+
+// runAnalyzers runs the registered analyzers, skipping those disabled for the linted file
+func runAnalyzers(model *PromptModel) []Issue {
+	analyzerSettingsMu.RLock()
+	issues := linter.RunAnalyzers(model, disabledAnalyzers)
+	analyzerSettingsMu.RUnlock()
+	for i := range issues {
+		if issues[i].Category == "" {
+			issues[i].Category = analyzerCategory(issues[i].RuleName)
+		}
+	}
+	if len(issues) > 0 {
+		linter.AssignFingerprints(issues)
+		printProgress(fmt.Sprintf("Static analyzers found %d issues", len(issues)))
+	}
+	return issues
+}
+
+// locateIssues sets the line of issues by their original snippet
+func locateIssues(issues []Issue, model *PromptModel) {
+	for i := range issues {
+		if issues[i].Line == 0 {
+			issues[i].Line = model.LineOf(issues[i].OriginalSnippet)
+		}
+	}
+}
+
+// updateAnalyzerSettings changes the settings of the built-in analyzers under analyzerSettingsMu
+func updateAnalyzerSettings(update func(settings *analyzers.Settings)) {
+	analyzerSettingsMu.Lock()
+	defer analyzerSettingsMu.Unlock()
+	settings := analyzers.Current()
+	update(&settings)
+	analyzers.Configure(settings)
+}
+
+// configsFor returns the configuration files that apply to a file path and their paths
+func configsFor(path string) ([]string, []*ProjectConfig, error) {
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+	return findConfigFiles(dir)
+}
+
+// loadSectionOrder returns the configured canonical section order for a file path
+func loadSectionOrder(path string) ([]string, error) {
+	_, configs, err := configsFor(path)
+	if err != nil {
+		return nil, err
+	}
+	if order := mergeConfigs(configs).SectionOrder; len(order) > 0 {
+		return order, nil
+	}
+	return analyzers.DefaultSectionOrder, nil
+}
+
+// loadEmojiPolicy returns the configured emoji policy for a file path, roles without one keep the default policy
+func loadEmojiPolicy(path string) (map[string]string, error) {
+	_, configs, err := configsFor(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := map[string]string{}
+	for role, value := range mergeConfigs(configs).Emoji {
+		switch value {
+		case analyzers.EmojiAllow, analyzers.EmojiWarn, analyzers.EmojiForbid:
+			policy[role] = value
+		default:
+			return nil, fmt.Errorf("invalid emoji policy %q for role %s, use allow, warn or forbid", value, role)
+		}
+	}
+	return policy, nil
+}
+
+// loadToneWordsPattern returns the pattern of words configured in tone.words for a file path
+func loadToneWordsPattern(path string) (*regexp.Regexp, error) {
+	_, configs, err := configsFor(path)
+	if err != nil {
+		return nil, err
+	}
+	words := mergeConfigs(configs).Tone.Words
+	if len(words) == 0 {
+		return nil, nil
+	}
+	return analyzers.WordsPattern(words), nil
+}
+
+// loadReadingLevel returns the configured reading level target for a file path
+func loadReadingLevel(path string) (ReadingLevelConfig, error) {
+	_, configs, err := configsFor(path)
+	if err != nil {
+		return ReadingLevelConfig{}, err
+	}
+	config := mergeConfigs(configs).ReadingLevel
+	if config.Grade < 0 || config.Tolerance < 0 {
+		return ReadingLevelConfig{}, fmt.Errorf("reading_level grade and tolerance must not be negative")
+	}
+	return config, nil
+}
+
+// loadCognitiveLoad returns the configured cognitive load limits for a file path
+func loadCognitiveLoad(path string) (CognitiveLoadConfig, error) {
+	_, configs, err := configsFor(path)
+	if err != nil {
+		return CognitiveLoadConfig{}, err
+	}
+	config := mergeConfigs(configs).CognitiveLoad
+	if config.MaxDensity < 0 || config.MaxNesting < 0 {
+		return CognitiveLoadConfig{}, fmt.Errorf("cognitive_load limits must not be negative")
+	}
+	return config, nil
+}
+
+// loadTokenBudget returns the budget of the first pattern matching the file, configurations nearer to the file
+// are checked first. Patterns are relative to the directory of their configuration file.
+func loadTokenBudget(path string) (*TokenBudget, error) {
+	paths, configs, err := configsFor(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, config := range configs {
+		for _, budget := range config.Budgets {
+			if err := budget.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: %w", paths[i], err)
+			}
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+	file, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	for i := len(configs) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(filepath.Dir(paths[i]), file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		for _, budget := range configs[i].Budgets {
+			if matchCodeOwners(budget.Path, filepath.ToSlash(rel)) {
+				budget := budget
+				return &budget, nil
+			}
+		}
+	}
+	return nil, nil
+}
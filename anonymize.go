@@ -0,0 +1,472 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+	"github.com/korchasa/promptlint/pkg/llm"
+)
+
+// AnonymizeConfig lists domain-specific entities `anonymize` replaces in addition to the ones the LLM recognizes
+type AnonymizeConfig struct {
+	// Names, Products and Organizations are replaced wherever they appear as whole words, case-insensitively
+	Names         []string `yaml:"names,omitempty"`
+	Products      []string `yaml:"products,omitempty"`
+	Organizations []string `yaml:"organizations,omitempty"`
+	// Terms are other words to hide, e.g. project code names
+	Terms []string `yaml:"terms,omitempty"`
+	// Domains are internal hosts, URLs on them and their subdomains are replaced
+	Domains []string `yaml:"domains,omitempty"`
+	// Keep lists words that are never replaced, e.g. public product names the LLM reports
+	Keep []string `yaml:"keep,omitempty"`
+}
+
+// Entity kinds, the upper-cased kind is the placeholder prefix: [PERSON_1]
+var entityKinds = []string{"person", "organization", "product", "url", "email", "ip", "phone", "secret", "term"}
+
+// emailPattern matches email addresses, they identify people and are always replaced
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// ipPattern matches IPv4 and IPv6 address candidates, net.ParseIP decides
+var ipPattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?i:\b[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}\b)`)
+
+// phonePattern matches phone number candidates: an optional country code and area code followed by groups of digits
+var phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{1,4}\)[\s.-]?)?\d{2,4}(?:[\s.-]?\d{2,4}){2,4}`)
+
+// datePattern matches dates at the start of phone candidates, they are not phone numbers
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+
+// secretPatterns match API keys, tokens and private keys of common providers
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{30,}`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{30,}`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// secretAssignment matches values assigned to secret-like names, e.g. password: hunter2hunter2
+var secretAssignment = regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|token|password|passwd)\b["']?\s*[:=]\s*["']?([^\s"']{8,})`)
+
+// internalTLDs are top-level domains of private networks
+var internalTLDs = map[string]bool{"internal": true, "local": true, "localhost": true, "corp": true, "lan": true, "intranet": true, "home": true, "private": true}
+
+// anonymizeTool is the tool the LLM reports named entities with
+var anonymizeTool = ToolSpec{
+	Name:        "report_entities",
+	Description: "Reports domain-specific entities of the prompt that must be hidden before sharing it",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"entities": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"text": map[string]interface{}{"type": "string", "description": "The entity exactly as written in the prompt"},
+						"kind": map[string]interface{}{"type": "string", "enum": entityKinds},
+					},
+					"required": []string{"text", "kind"},
+				},
+			},
+		},
+		"required": []string{"entities"},
+	},
+}
+
+// anonymizeInstruction tells the LLM which entities to report
+const anonymizeInstruction = `Find the domain-specific entities of the prompt that must be hidden before it is shared with third parties: ` +
+	`names of people (person), companies and teams (organization), products, services and internal systems (product), ` +
+	`internal URLs and hostnames (url), email addresses (email) and project code names or other confidential terms (term). ` +
+	`Don't report generic words, well-known public technologies, programming languages or placeholders like {{name}}. ` +
+	`Report every entity once, exactly as written.`
+
+// Entity is a text to hide and its kind
+type Entity struct {
+	Text string `json:"text"`
+	Kind string `json:"kind"`
+}
+
+// Anonymizer replaces entities with numbered placeholders, the same entity gets the same placeholder in all files
+type Anonymizer struct {
+	entities     []Entity
+	keep         map[string]bool
+	domains      []string
+	placeholders map[string]string
+	counts       map[string]int
+	// Mapping holds the original text of every placeholder
+	Mapping map[string]string
+}
+
+// newAnonymizer creates an anonymizer with the configured entities
+func newAnonymizer(config AnonymizeConfig) *Anonymizer {
+	a := &Anonymizer{keep: map[string]bool{}, placeholders: map[string]string{}, counts: map[string]int{}, Mapping: map[string]string{}}
+	for _, word := range config.Keep {
+		a.keep[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+	for _, domain := range config.Domains {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."); domain != "" {
+			a.domains = append(a.domains, domain)
+			a.add(Entity{Text: domain, Kind: "url"})
+		}
+	}
+	lists := []struct {
+		kind  string
+		texts []string
+	}{{"person", config.Names}, {"organization", config.Organizations}, {"product", config.Products}, {"term", config.Terms}}
+	for _, list := range lists {
+		for _, text := range list.texts {
+			a.add(Entity{Text: text, Kind: list.kind})
+		}
+	}
+	return a
+}
+
+// add records an entity unless it is kept or already known, kinds outside entityKinds become terms
+func (a *Anonymizer) add(entity Entity) {
+	entity.Text = strings.TrimSpace(entity.Text)
+	entity.Kind = strings.ToLower(strings.TrimSpace(entity.Kind))
+	if entity.Text == "" || a.keep[strings.ToLower(entity.Text)] {
+		return
+	}
+	if !slices.Contains(entityKinds, entity.Kind) {
+		entity.Kind = "term"
+	}
+	for _, known := range a.entities {
+		if strings.EqualFold(known.Text, entity.Text) {
+			return
+		}
+	}
+	a.entities = append(a.entities, entity)
+}
+
+// internalHost reports whether the host belongs to a configured domain or a private network
+func (a *Anonymizer) internalHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range a.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+	}
+	labels := strings.Split(host, ".")
+	return len(labels) == 1 || internalTLDs[labels[len(labels)-1]]
+}
+
+// detect adds the secrets, emails, internal URLs, IP addresses and phone numbers of the text, they are found
+// without the LLM
+func (a *Anonymizer) detect(text string) {
+	for _, pattern := range secretPatterns {
+		for _, secret := range pattern.FindAllString(text, -1) {
+			a.add(Entity{Text: secret, Kind: "secret"})
+		}
+	}
+	for _, match := range secretAssignment.FindAllStringSubmatch(text, -1) {
+		// Placeholders like {{api_key}} are not secrets
+		if value := strings.TrimRight(match[1], ".,;:!?)"); !strings.ContainsAny(value, "{}<>") {
+			a.add(Entity{Text: value, Kind: "secret"})
+		}
+	}
+	for _, email := range emailPattern.FindAllString(text, -1) {
+		a.add(Entity{Text: email, Kind: "email"})
+	}
+	for _, link := range analyzers.URLPattern.FindAllString(text, -1) {
+		link = strings.TrimRight(link, ".,;:!?")
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" && a.internalHost(u.Hostname()) {
+			a.add(Entity{Text: link, Kind: "url"})
+		}
+	}
+	for _, candidate := range ipPattern.FindAllString(text, -1) {
+		if net.ParseIP(candidate) != nil {
+			a.add(Entity{Text: candidate, Kind: "ip"})
+		}
+	}
+	for _, match := range phonePattern.FindAllStringIndex(text, -1) {
+		// Digits ending a word like the ones of a key are no phone number
+		if before, _ := utf8.DecodeLastRuneInString(text[:match[0]]); match[0] > 0 && (isWordRune(before) || before == '-') {
+			continue
+		}
+		if candidate := text[match[0]:match[1]]; isPhoneNumber(candidate) {
+			a.add(Entity{Text: candidate, Kind: "phone"})
+		}
+	}
+}
+
+// isPhoneNumber reports whether a phone candidate has the digits of a phone number: 9 to 15 like E.164,
+// at least 10 without a country or area code, and is neither a date nor an IP address
+func isPhoneNumber(candidate string) bool {
+	if datePattern.MatchString(candidate) || net.ParseIP(candidate) != nil {
+		return false
+	}
+	digits := 0
+	for _, r := range candidate {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if strings.HasPrefix(candidate, "+") || strings.HasPrefix(candidate, "(") {
+		return digits >= 9 && digits <= 15
+	}
+	return digits >= 10 && digits <= 15
+}
+
+// recognize asks the LLM for named entities of the text
+func (a *Anonymizer) recognize(text string, config *LLMConfig) error {
+	response, err := llm.Send(config, ToolRequest{
+		System:   anonymizeInstruction,
+		Messages: []string{text},
+		Tool:     anonymizeTool,
+	})
+	if err != nil {
+		return err
+	}
+	if len(response.Calls) == 0 {
+		return fmt.Errorf("the model answered without calling %s", anonymizeTool.Name)
+	}
+	for _, call := range response.Calls {
+		entities, _ := call.Arguments["entities"].([]interface{})
+		for _, item := range entities {
+			if entity, ok := item.(map[string]interface{}); ok {
+				a.add(Entity{Text: getStringValue(entity, "text"), Kind: getStringValue(entity, "kind")})
+			}
+		}
+	}
+	return nil
+}
+
+// placeholder returns the placeholder of the entity, numbered per kind in order of first use
+func (a *Anonymizer) placeholder(entity Entity) string {
+	key := strings.ToLower(entity.Text)
+	if placeholder, ok := a.placeholders[key]; ok {
+		return placeholder
+	}
+	a.counts[entity.Kind]++
+	placeholder := fmt.Sprintf("[%s_%d]", strings.ToUpper(entity.Kind), a.counts[entity.Kind])
+	a.placeholders[key] = placeholder
+	a.Mapping[placeholder] = entity.Text
+	return placeholder
+}
+
+// isWordRune reports whether the rune continues a word, entities must not start or end inside a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Replace replaces all known entities of the text, longer entities first so that "Acme Cloud" wins over "Acme"
+func (a *Anonymizer) Replace(text string) string {
+	type span struct {
+		start, end int
+		entity     Entity
+	}
+	entities := append([]Entity(nil), a.entities...)
+	sort.SliceStable(entities, func(i, j int) bool { return len(entities[i].Text) > len(entities[j].Text) })
+
+	var spans []span
+	taken := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && s.start < end {
+				return true
+			}
+		}
+		return false
+	}
+	for _, entity := range entities {
+		pattern := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(entity.Text))
+		for _, match := range pattern.FindAllStringIndex(text, -1) {
+			if before, _ := utf8.DecodeLastRuneInString(text[:match[0]]); match[0] > 0 && isWordRune(before) && isWordRune([]rune(entity.Text)[0]) {
+				continue
+			}
+			if after, _ := utf8.DecodeRuneInString(text[match[1]:]); match[1] < len(text) && isWordRune(after) && isWordRune([]rune(entity.Text)[utf8.RuneCountInString(entity.Text)-1]) {
+				continue
+			}
+			if !taken(match[0], match[1]) {
+				spans = append(spans, span{match[0], match[1], entity})
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		sb.WriteString(text[last:s.start])
+		sb.WriteString(a.placeholder(s.entity))
+		last = s.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// loadAnonymizeConfig returns the merged anonymize settings of the configuration files that apply to the path
+func loadAnonymizeConfig(path string) (AnonymizeConfig, error) {
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+	_, configs, err := findConfigFiles(dir)
+	if err != nil {
+		return AnonymizeConfig{}, err
+	}
+	return mergeConfigs(configs).Anonymize, nil
+}
+
+// anonymizeOutputPath returns where the anonymized copy of the file is written in the output directory:
+// relative paths are kept, other files are written by their base name
+func anonymizeOutputPath(dir, file string) string {
+	clean := filepath.Clean(file)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		clean = filepath.Base(clean)
+	}
+	return filepath.Join(dir, clean)
+}
+
+// runAnonymizeCommand implements `promptlint anonymize [--output=dir] [--map=map.json] [file...]`
+func runAnonymizeCommand(args []string) error {
+	fs := flag.NewFlagSet("anonymize", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	output := fs.String("output", "", "Directory to write the anonymized copies to (required for several files, default: stdout)")
+	mapFile := fs.String("map", "", "Write the JSON mapping of placeholders to the original texts to the path, keep it private")
+	ner := fs.String("ner", "llm", "Named entity recognition: llm (entities reported by the LLM, needs an API key), off (configured lists and detected entities only)")
+	allowPartial := fs.Bool("allow-partial", false, "Write the copies without entity recognition when no API key is set instead of failing")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s anonymize [--output=dir] [--map=map.json] [--ner=llm|off] [--allow-partial] [file...]
+
+Replaces names, organizations, products, internal URLs, emails, IP
+addresses, phone numbers, secrets and confidential terms with placeholders
+like [PERSON_1], so prompts can be shared with consultants or attached to
+bug reports. Entities come from the LLM, from the anonymize section of
+%s (names, products, organizations, terms, domains, keep) and from
+local detectors of emails, internal URLs, IP addresses, phone numbers, API
+keys and tokens. The same entity gets the same placeholder in all files.
+Without files the prompt is read from stdin.
+
+Without an API key the LLM can't recognize names and products, so the
+command fails unless --allow-partial or --ner=off accepts copies that
+only hide the configured and detected entities.
+
+Options:
+`, appName, configFileName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *ner != "llm" && *ner != "off" {
+		return fmt.Errorf("--ner must be llm or off")
+	}
+	if len(files) > 1 && *output == "" {
+		return fmt.Errorf("--output is required for several files")
+	}
+	if *output != "" && len(files) == 0 {
+		return fmt.Errorf("--output requires files")
+	}
+
+	type anonymizeInput struct {
+		name, content string
+		doc           *Document
+	}
+	var inputs []anonymizeInput
+	if len(files) == 0 {
+		content, err := readFromStdin()
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, anonymizeInput{content: content})
+	}
+	for _, file := range files {
+		content, err := readFromFile(file)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, anonymizeInput{name: file, content: content})
+	}
+
+	config, err := loadAnonymizeConfig("")
+	if err != nil {
+		return err
+	}
+	anonymizer := newAnonymizer(config)
+
+	var llmConfig LLMConfig
+	if *ner == "llm" {
+		if llmConfig, err = setupLLMConfig(); err != nil {
+			return err
+		}
+		if llmConfig.Heuristic {
+			if !*allowPartial {
+				return fmt.Errorf("entity recognition needs an API key and network access, names and products missing from the anonymize section of %s would stay in the copies; set a key, or accept partial anonymization with --allow-partial or --ner=off", configFileName)
+			}
+			printProgress("Warning: no API key, entity recognition is off, only configured and detected entities are replaced")
+		}
+	}
+	for i := range inputs {
+		doc, err := loadDocument(inputs[i].name, []byte(inputs[i].content), "auto")
+		if err != nil {
+			return err
+		}
+		inputs[i].doc = doc
+		anonymizer.detect(inputs[i].content)
+		if *ner == "llm" && !llmConfig.Heuristic && strings.TrimSpace(doc.Text) != "" {
+			name := inputs[i].name
+			if name == "" {
+				name = "stdin"
+			}
+			printProgress("Recognizing entities in " + name)
+			if err := anonymizer.recognize(doc.Text, &llmConfig); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	written := map[string]string{}
+	for _, input := range inputs {
+		anonymized := anonymizer.Replace(input.content)
+		if *output == "" {
+			fmt.Print(anonymized)
+			continue
+		}
+		target := anonymizeOutputPath(*output, input.name)
+		if previous, ok := written[target]; ok {
+			return fmt.Errorf("%s and %s would both be written to %s", previous, input.name, target)
+		}
+		written[target] = input.name
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, []byte(anonymized), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		printProgress(fmt.Sprintf("Wrote %s", target))
+	}
+	printProgress(fmt.Sprintf("Replaced %d entities", len(anonymizer.Mapping)))
+
+	if *mapFile != "" {
+		data, err := json.MarshalIndent(anonymizer.Mapping, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode mapping: %w", err)
+		}
+		if err := os.WriteFile(*mapFile, append(data, '\n'), 0o600); err != nil {
+			return fmt.Errorf("failed to write mapping: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// loadAWSConfig reads the credentials of the SQS queue and the S3 sink with the default chain of the AWS SDK
+// (AWS_ACCESS_KEY_ID, shared profiles, instance roles). The region comes from the ?region= parameter, the
+// service host or the SDK configuration.
+func loadAWSConfig(u *url.URL, service string) (aws.Config, error) {
+	var options []func(*awsconfig.LoadOptions) error
+	if region := u.Query().Get("region"); region != "" {
+		options = append(options, awsconfig.WithRegion(region))
+	} else if parts := strings.Split(u.Hostname(), "."); len(parts) == 4 && parts[0] == service && parts[2] == "amazonaws" {
+		// sqs.<region>.amazonaws.com
+		options = append(options, awsconfig.WithRegion(parts[1]))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load the AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return cfg, fmt.Errorf("AWS region is missing, set ?region= or AWS_REGION")
+	}
+	return cfg, nil
+}
+
+// awsEndpoint returns the ?endpoint= override of the URL, e.g. a LocalStack or MinIO server
+func awsEndpoint(u *url.URL) (*string, error) {
+	endpoint := u.Query().Get("endpoint")
+	if endpoint == "" {
+		return nil, nil
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q, use an http(s) URL", endpoint)
+	}
+	return aws.String(endpoint), nil
+}
+
+// sqsQueue receives jobs from an Amazon SQS queue. Messages are deleted by Ack, unacknowledged ones are
+// delivered again after the visibility timeout.
+type sqsQueue struct {
+	client     *sqs.Client
+	queueURL   string
+	visibility int32
+}
+
+// newSQSQueue connects to sqs://sqs.<region>.amazonaws.com/<account>/<queue>?visibility=<seconds>&endpoint=<url>
+func newSQSQueue(u *url.URL) (*sqsQueue, error) {
+	if offline {
+		return nil, fmt.Errorf("sqs queue %s: %s", u.Host, errOffline)
+	}
+	if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid sqs queue URL, use sqs://sqs.<region>.amazonaws.com/<account>/<queue>")
+	}
+	q := &sqsQueue{queueURL: "https://" + u.Host + u.Path}
+	if visibility := u.Query().Get("visibility"); visibility != "" {
+		seconds, err := strconv.ParseInt(visibility, 10, 32)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid sqs visibility timeout %q, use seconds", visibility)
+		}
+		q.visibility = int32(seconds)
+	}
+	cfg, err := loadAWSConfig(u, "sqs")
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := awsEndpoint(u)
+	if err != nil {
+		return nil, err
+	}
+	q.client = sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		o.BaseEndpoint = endpoint
+	})
+	return q, nil
+}
+
+func (q *sqsQueue) Receive(ctx context.Context) (QueueMessage, error) {
+	input := &sqs.ReceiveMessageInput{QueueUrl: aws.String(q.queueURL), MaxNumberOfMessages: 1, WaitTimeSeconds: 5}
+	if q.visibility > 0 {
+		input.VisibilityTimeout = q.visibility
+	}
+	for {
+		output, err := q.client.ReceiveMessage(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return QueueMessage{}, ctx.Err()
+			}
+			return QueueMessage{}, fmt.Errorf("sqs ReceiveMessage failed: %w", err)
+		}
+		if len(output.Messages) > 0 {
+			message := output.Messages[0]
+			return QueueMessage{Body: []byte(aws.ToString(message.Body)), receipt: aws.ToString(message.ReceiptHandle)}, nil
+		}
+	}
+}
+
+// Ack deletes the message from the queue
+func (q *sqsQueue) Ack(ctx context.Context, message QueueMessage) error {
+	if _, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: aws.String(q.queueURL), ReceiptHandle: aws.String(message.receipt)}); err != nil {
+		return fmt.Errorf("sqs DeleteMessage failed: %w", err)
+	}
+	return nil
+}
+
+func (q *sqsQueue) Close() error {
+	return nil
+}
+
+// s3Names are the characters of object names the S3 sink derives from job IDs, other IDs could name paths
+var s3Names = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// s3Sink stores every result as the JSON object <prefix>/<job id>.json in an S3 bucket,
+// a job delivered again overwrites its result
+type s3Sink struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// newS3Sink creates the sink of s3://<bucket>[/<prefix>]?region=<region>&endpoint=<url>, with an endpoint
+// the bucket is addressed in the path
+func newS3Sink(u *url.URL) (*s3Sink, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 sink, use s3://<bucket>[/<prefix>]")
+	}
+	s := &s3Sink{bucket: u.Host, prefix: strings.Trim(u.Path, "/")}
+	if s.prefix != "" {
+		s.prefix += "/"
+	}
+	cfg, err := loadAWSConfig(u, "s3")
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := awsEndpoint(u)
+	if err != nil {
+		return nil, err
+	}
+	s.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.BaseEndpoint = endpoint
+		o.UsePathStyle = endpoint != nil
+	})
+	return s, nil
+}
+
+// objectName names the object of a result by its job, results of invalid jobs by their time
+func objectName(result LintJobResult) string {
+	if s3Names.MatchString(result.ID) && result.ID != "." && result.ID != ".." {
+		return result.ID + ".json"
+	}
+	return "invalid-" + result.FinishedAt.UTC().Format("20060102T150405.000000000Z") + ".json"
+}
+
+func (s *s3Sink) Write(ctx context.Context, result LintJobResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.prefix + objectName(result)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 PutObject failed: %w", err)
+	}
+	return nil
+}
+
+func (s *s3Sink) Close() error {
+	return nil
+}
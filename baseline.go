@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// baselineVersion is the format version of baseline files
+const baselineVersion = 1
+
+// Baseline records the issues of a legacy prompt repository, later runs report only issues missing from it
+type Baseline struct {
+	Version int             `json:"version"`
+	Issues  []BaselineIssue `json:"issues"`
+	// used counts the entries matched in this run by file and fingerprint, checked and recorded hold the files
+	// of this run
+	used     map[string]int
+	checked  map[string]bool
+	recorded map[string]bool
+}
+
+// BaselineIssue is an accepted issue of a file, Rule and Description only help reviewing the file
+type BaselineIssue struct {
+	File        string `json:"file"`
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	Description string `json:"description,omitempty"`
+}
+
+// baselinePath cleans the path of a file and uses forward slashes, so baselines work across platforms
+func baselinePath(file string) string {
+	if file == "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(file))
+}
+
+// baselineKey identifies an issue of a file
+func baselineKey(file, fingerprint string) string {
+	return baselinePath(file) + "\x00" + fingerprint
+}
+
+// LoadBaseline reads the baseline file, nil without the file
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %w", path, err)
+	}
+	if baseline.Version > baselineVersion {
+		return nil, fmt.Errorf("baseline %s has version %d, this %s supports up to %d", path, baseline.Version, appName, baselineVersion)
+	}
+	return &baseline, nil
+}
+
+// Add records the active issues of the file, replacing the entries of earlier runs. Entries of files
+// outside this run are kept, so the baseline can be updated from a part of the repository.
+func (b *Baseline) Add(file string, issues []Issue) {
+	path := baselinePath(file)
+	if b.recorded == nil {
+		b.recorded = map[string]bool{}
+	}
+	if !b.recorded[path] {
+		b.recorded[path] = true
+		kept := b.Issues[:0]
+		for _, entry := range b.Issues {
+			if baselinePath(entry.File) != path {
+				kept = append(kept, entry)
+			}
+		}
+		b.Issues = kept
+	}
+	for _, issue := range issues {
+		if issue.Dismissed {
+			continue
+		}
+		b.Issues = append(b.Issues, BaselineIssue{File: baselinePath(file), Fingerprint: issue.Fingerprint, Rule: issue.RuleName, Description: issue.Description})
+	}
+}
+
+// Filter drops the active issues of the file recorded in the baseline and returns how many were dropped.
+// Every entry matches one issue, so a second occurrence of a baselined issue is reported as new.
+func (b *Baseline) Filter(file string, issues []Issue) ([]Issue, int) {
+	if b.used == nil {
+		b.used, b.checked = map[string]int{}, map[string]bool{}
+	}
+	b.checked[baselinePath(file)] = true
+	recorded := map[string]int{}
+	for _, entry := range b.Issues {
+		recorded[baselineKey(entry.File, entry.Fingerprint)]++
+	}
+
+	kept := issues[:0]
+	dropped := 0
+	for _, issue := range issues {
+		key := baselineKey(file, issue.Fingerprint)
+		if !issue.Dismissed && b.used[key] < recorded[key] {
+			b.used[key]++
+			dropped++
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept, dropped
+}
+
+// Stale returns the number of entries of the checked files no issue matched in this run, they were fixed
+func (b *Baseline) Stale() int {
+	stale := 0
+	remaining := map[string]int{}
+	for key, count := range b.used {
+		remaining[key] = count
+	}
+	for _, entry := range b.Issues {
+		if !b.checked[baselinePath(entry.File)] {
+			continue
+		}
+		if key := baselineKey(entry.File, entry.Fingerprint); remaining[key] > 0 {
+			remaining[key]--
+		} else {
+			stale++
+		}
+	}
+	return stale
+}
+
+// Save writes the baseline sorted by file and rule so that updates produce small diffs
+func (b *Baseline) Save(path string) error {
+	b.Version = baselineVersion
+	if b.Issues == nil {
+		b.Issues = []BaselineIssue{}
+	}
+	sort.SliceStable(b.Issues, func(i, j int) bool {
+		if b.Issues[i].File != b.Issues[j].File {
+			return b.Issues[i].File < b.Issues[j].File
+		}
+		if b.Issues[i].Rule != b.Issues[j].Rule {
+			return b.Issues[i].Rule < b.Issues[j].Rule
+		}
+		return b.Issues[i].Fingerprint < b.Issues[j].Fingerprint
+	})
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("baseline serialization error: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBaselineFilter(t *testing.T) {
+	baseline := &Baseline{Issues: []BaselineIssue{
+		{File: "prompts/a.md", Fingerprint: "f1", Rule: "Be Specific"},
+		{File: "prompts/a.md", Fingerprint: "f2", Rule: "Avoid Jargon"},
+		{File: "prompts/b.md", Fingerprint: "f1", Rule: "Be Specific"},
+		{File: "prompts/c.md", Fingerprint: "f3", Rule: "Be Specific"},
+	}}
+	tests := []struct {
+		name        string
+		file        string
+		issues      []Issue
+		want        []string
+		wantDropped int
+	}{
+		{
+			name:        "baselined issues are dropped, paths are cleaned",
+			file:        "prompts/./a.md",
+			issues:      []Issue{{Fingerprint: "f1"}, {Fingerprint: "new"}},
+			want:        []string{"new"},
+			wantDropped: 1,
+		},
+		{
+			name:        "a second occurrence is new",
+			file:        "prompts/b.md",
+			issues:      []Issue{{Fingerprint: "f1"}, {Fingerprint: "f1"}},
+			want:        []string{"f1"},
+			wantDropped: 1,
+		},
+		{
+			name:   "dismissed issues are kept",
+			file:   "prompts/c.md",
+			issues: []Issue{{Fingerprint: "f3", Dismissed: true}},
+			want:   []string{"f3"},
+		},
+		{
+			name:   "fingerprints of another file",
+			file:   "prompts/d.md",
+			issues: []Issue{{Fingerprint: "f1"}},
+			want:   []string{"f1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := baseline.Filter(tt.file, tt.issues)
+			var got []string
+			for _, issue := range kept {
+				got = append(got, issue.Fingerprint)
+			}
+			if !reflect.DeepEqual(got, tt.want) || dropped != tt.wantDropped {
+				t.Errorf("Filter() = %q, %d, want %q, %d", got, dropped, tt.want, tt.wantDropped)
+			}
+		})
+	}
+	// f2 of a.md and f3 of c.md matched no active issue
+	if stale := baseline.Stale(); stale != 2 {
+		t.Errorf("Stale() = %d, want 2", stale)
+	}
+}
+
+func TestBaselineAdd(t *testing.T) {
+	baseline := &Baseline{Issues: []BaselineIssue{
+		{File: "a.md", Fingerprint: "old"},
+		{File: "b.md", Fingerprint: "kept"},
+	}}
+	baseline.Add("./a.md", []Issue{{Fingerprint: "f1", RuleName: "Be Specific"}, {Fingerprint: "f2", Dismissed: true}})
+	baseline.Add("a.md", []Issue{{Fingerprint: "f3", RuleName: "Avoid Jargon"}})
+	want := []BaselineIssue{
+		{File: "b.md", Fingerprint: "kept"},
+		{File: "a.md", Fingerprint: "f1", Rule: "Be Specific"},
+		{File: "a.md", Fingerprint: "f3", Rule: "Avoid Jargon"},
+	}
+	if !reflect.DeepEqual(baseline.Issues, want) {
+		t.Errorf("Add() = %+v, want %+v", baseline.Issues, want)
+	}
+}
+
+func TestBaselineSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if baseline, err := LoadBaseline(path); err != nil || baseline != nil {
+		t.Fatalf("LoadBaseline() of a missing file = %+v, %v, want nil", baseline, err)
+	}
+
+	saved := &Baseline{Issues: []BaselineIssue{
+		{File: "b.md", Fingerprint: "f1", Rule: "Be Specific"},
+		{File: "a.md", Fingerprint: "f2", Rule: "Be Specific"},
+		{File: "a.md", Fingerprint: "f1", Rule: "Avoid Jargon"},
+	}}
+	if err := saved.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BaselineIssue{
+		{File: "a.md", Fingerprint: "f1", Rule: "Avoid Jargon"},
+		{File: "a.md", Fingerprint: "f2", Rule: "Be Specific"},
+		{File: "b.md", Fingerprint: "f1", Rule: "Be Specific"},
+	}
+	if loaded.Version != baselineVersion || !reflect.DeepEqual(loaded.Issues, want) {
+		t.Errorf("LoadBaseline() = version %d, %+v, want version %d, %+v", loaded.Version, loaded.Issues, baselineVersion, want)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"invalid JSON", "{", "error parsing baseline"},
+		{"newer version", `{"version": 2, "issues": []}`, "has version 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadBaseline(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadBaseline() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+)
+
+// defaultBenchDuration is how long the static analysis is repeated when --iterations isn't set
+const defaultBenchDuration = 2 * time.Second
+
+// BenchReport holds the static analysis throughput and the end-to-end latency of every benchmarked provider
+type BenchReport struct {
+	Version   string          `json:"version"`
+	GoVersion string          `json:"goVersion"`
+	Prompts   int             `json:"prompts"`
+	Bytes     int             `json:"bytes"`
+	Static    StaticBench     `json:"static"`
+	Providers []ProviderBench `json:"providers,omitempty"`
+}
+
+// StaticBench measures parsing, static rules and analyzers without LLM calls, an operation is one prompt
+type StaticBench struct {
+	Iterations       int     `json:"iterations"`
+	Ops              int     `json:"ops"`
+	DurationMs       int64   `json:"durationMs"`
+	PromptsPerSecond float64 `json:"promptsPerSecond"`
+	MBPerSecond      float64 `json:"mbPerSecond"`
+	NsPerOp          int64   `json:"nsPerOp"`
+	AllocsPerOp      uint64  `json:"allocsPerOp"`
+	BytesPerOp       uint64  `json:"bytesPerOp"`
+	// Issues found in one pass over the prompts, a change without rule changes hints at a behavior regression
+	Issues int `json:"issues"`
+}
+
+// ProviderBench is the latency of full prompt checks through a provider, Skipped explains why it wasn't measured
+type ProviderBench struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	Requests int    `json:"requests"`
+	Errors   int    `json:"errors"`
+	MinMs    int64  `json:"minMs"`
+	P50Ms    int64  `json:"p50Ms"`
+	P95Ms    int64  `json:"p95Ms"`
+	MaxMs    int64  `json:"maxMs"`
+	MeanMs   int64  `json:"meanMs"`
+	// Error is the first failed request
+	Error   string `json:"error,omitempty"`
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// benchPrompt is a loaded prompt with the rules resolved for its path
+type benchPrompt struct {
+	file  string
+	text  string
+	rules *Rules
+}
+
+// benchStatic repeats the local analysis of the prompts for the number of iterations or, when it is 0, for the duration
+func benchStatic(prompts []benchPrompt, iterations int, duration time.Duration) (StaticBench, error) {
+	saved := ruleEngine
+	ruleEngine = "static"
+	defer func() { ruleEngine = saved }()
+
+	var bytes int
+	for _, prompt := range prompts {
+		bytes += len(prompt.text)
+	}
+
+	// Static engine never calls the API, the config only has to be valid
+	config := LLMConfig{Heuristic: true}
+	result := StaticBench{}
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for {
+		if iterations > 0 && result.Iterations == iterations || iterations == 0 && result.Iterations > 0 && time.Since(start) >= duration {
+			break
+		}
+		for _, prompt := range prompts {
+			issues, err := checkPromptWithLLM(prompt.text, prompt.rules, &config)
+			if err != nil {
+				return StaticBench{}, fmt.Errorf("%s: %w", prompt.file, err)
+			}
+			if result.Iterations == 0 {
+				result.Issues += len(issues)
+			}
+		}
+		result.Iterations++
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	result.Ops = result.Iterations * len(prompts)
+	result.DurationMs = elapsed.Milliseconds()
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		result.PromptsPerSecond = float64(result.Ops) / seconds
+		result.MBPerSecond = float64(bytes*result.Iterations) / seconds / (1 << 20)
+	}
+	result.NsPerOp = elapsed.Nanoseconds() / int64(result.Ops)
+	result.AllocsPerOp = (after.Mallocs - before.Mallocs) / uint64(result.Ops)
+	result.BytesPerOp = (after.TotalAlloc - before.TotalAlloc) / uint64(result.Ops)
+	return result, nil
+}
+
+// benchProvider checks every prompt runs times through the provider and records the latency of each check
+func benchProvider(name string, prompts []benchPrompt, runs int) (ProviderBench, error) {
+	result := ProviderBench{Provider: name}
+	providerName = name
+	config, err := setupLLMConfig()
+	if err != nil {
+		return result, err
+	}
+	result.Provider = config.Provider.Name()
+	if config.Heuristic {
+		result.Skipped = "no API key, set " + llm.KeyHint(config.Provider)
+		return result, nil
+	}
+	result.Model = config.ModelName
+	// Retries would add their backoff to the measured latency, failures are counted instead
+	config.Retry = llm.RetryPolicy{}
+
+	var latencies []time.Duration
+	for run := 0; run < runs; run++ {
+		for _, prompt := range prompts {
+			printProgress(fmt.Sprintf("Checking %s with %s (run %d/%d)", prompt.file, result.Provider, run+1, runs))
+			start := time.Now()
+			_, err := checkContentWithLLM(promptCheckInstruction, prompt.text, prompt.rules, &config)
+			elapsed := time.Since(start)
+			result.Requests++
+			if err != nil {
+				result.Errors++
+				if result.Error == "" {
+					result.Error = fmt.Sprintf("%s: %v", prompt.file, err)
+				}
+				continue
+			}
+			latencies = append(latencies, elapsed)
+		}
+	}
+	if len(latencies) == 0 {
+		return result, nil
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	result.MinMs = latencies[0].Milliseconds()
+	result.P50Ms = latencyPercentile(latencies, 50).Milliseconds()
+	result.P95Ms = latencyPercentile(latencies, 95).Milliseconds()
+	result.MaxMs = latencies[len(latencies)-1].Milliseconds()
+	result.MeanMs = (total / time.Duration(len(latencies))).Milliseconds()
+	return result, nil
+}
+
+// latencyPercentile returns the nearest-rank percentile of sorted latencies
+func latencyPercentile(sorted []time.Duration, percent int) time.Duration {
+	rank := (percent*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// FormatBenchReport formats the benchmark for humans
+func FormatBenchReport(report BenchReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s (%s), %d prompts, %d bytes\n\n", appName, report.Version, report.GoVersion, report.Prompts, report.Bytes))
+	static := report.Static
+	sb.WriteString("Static analysis\n")
+	sb.WriteString(fmt.Sprintf("  %d iterations, %d prompts in %s\n", static.Iterations, static.Ops, time.Duration(static.DurationMs)*time.Millisecond))
+	sb.WriteString(fmt.Sprintf("  %.1f prompts/s, %.2f MB/s\n", static.PromptsPerSecond, static.MBPerSecond))
+	sb.WriteString(fmt.Sprintf("  %d ns/op, %d allocs/op, %d B/op, %d issues per pass\n", static.NsPerOp, static.AllocsPerOp, static.BytesPerOp, static.Issues))
+	if len(report.Providers) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\nEnd-to-end latency\n")
+	sb.WriteString(fmt.Sprintf("  %-10s %-24s %8s %6s %8s %8s %8s %8s\n", "Provider", "Model", "Requests", "Errors", "Min", "p50", "p95", "Max"))
+	for _, provider := range report.Providers {
+		if provider.Skipped != "" {
+			sb.WriteString(fmt.Sprintf("  %-10s skipped: %s\n", provider.Provider, provider.Skipped))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %-10s %-24s %8d %6d %6dms %6dms %6dms %6dms\n", provider.Provider, provider.Model,
+			provider.Requests, provider.Errors, provider.MinMs, provider.P50Ms, provider.P95Ms, provider.MaxMs))
+		if provider.Error != "" {
+			sb.WriteString(fmt.Sprintf("  %-10s first error: %s\n", "", provider.Error))
+		}
+	}
+	return sb.String()
+}
+
+// runBenchCommand implements `promptlint bench <file|dir>...`
+func runBenchCommand(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	format := fs.String("format", "text", "Output format: text, json")
+	extensions := fs.String("ext", defaultInventoryExtensions, "Comma-separated extensions of prompt files in directories")
+	iterations := fs.Int("iterations", 0, "Passes of the static analysis over the prompts (default: repeat for --duration)")
+	duration := fs.Duration("duration", defaultBenchDuration, "Time to repeat the static analysis for when --iterations isn't set")
+	providers := fs.String("providers", "", "Comma-separated providers to measure end-to-end latency of, every check is a paid API call: "+strings.Join(llm.Names(), ", "))
+	runs := fs.Int("runs", 3, "Checks of every prompt per provider")
+	cpuProfile := fs.String("cpuprofile", "", "Write a CPU profile of the benchmark to the path")
+	memProfile := fs.String("memprofile", "", "Write a heap profile at the end of the benchmark to the path")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s bench [--iterations=N|--duration=2s] [--providers=openai,anthropic] [--runs=3] <file|dir>...
+
+Measures the throughput of the static analysis (parsing, static rules and
+analyzers) over the prompts and, with --providers, the end-to-end latency of
+full checks through every provider, to track performance regressions.
+Analyzer settings come from the configuration of the working directory.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one file or directory is required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	if *iterations < 0 {
+		return fmt.Errorf("--iterations must not be negative")
+	}
+	if *iterations == 0 && *duration <= 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+	if *runs < 1 {
+		return fmt.Errorf("--runs must be at least 1")
+	}
+
+	if err := startProfiling(*cpuProfile, *memProfile); err != nil {
+		return err
+	}
+	defer stopProfiling()
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	if err := loadAnalyzerSettings(""); err != nil {
+		return err
+	}
+
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		found, err := scanPrompts(path, parseExtensions(*extensions))
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	report := BenchReport{Version: appVersion, GoVersion: runtime.Version()}
+	var prompts []benchPrompt
+	for _, file := range files {
+		_, doc, ok, err := loadPromptFile(file)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		fileRules, err := rulesForPath(rules, file)
+		if err != nil {
+			return err
+		}
+		if err := validateStaticRules(fileRules); err != nil {
+			return err
+		}
+		prompts = append(prompts, benchPrompt{file: file, text: doc.Text, rules: fileRules})
+		report.Bytes += len(doc.Text)
+	}
+	if len(prompts) == 0 {
+		return fmt.Errorf("no prompts found in %s", strings.Join(paths, ", "))
+	}
+	report.Prompts = len(prompts)
+
+	printProgress(fmt.Sprintf("Benchmarking static analysis of %d prompts", len(prompts)))
+	if report.Static, err = benchStatic(prompts, *iterations, *duration); err != nil {
+		return err
+	}
+
+	for _, name := range strings.Split(*providers, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		result, err := benchProvider(name, prompts, *runs)
+		if err != nil {
+			return err
+		}
+		report.Providers = append(report.Providers, result)
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode benchmark: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Print(FormatBenchReport(report))
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// Instructions of the role-aware checks of chat prompts
+const (
+	systemMessageInstruction = "Analyze the following system message of a chat prompt against the specified rules. " +
+		"The user messages sent after it are checked separately:"
+	userMessageInstruction = "Analyze the following user message of a chat prompt against the specified rules. " +
+		"It is sent after a system message that is checked separately, so report only problems of this message, " +
+		"not a missing persona, examples, edge cases or steps that the system message provides:"
+)
+
+// conversationRules are judged on the conversation as a whole, the system message satisfies them for the user
+// messages after it
+var conversationRules = map[string]bool{
+	"assign persona":            true,
+	"include examples":          true,
+	"include edge cases":        true,
+	"use step-by-step approach": true,
+	"balance length":            true,
+}
+
+// isSystemRole reports whether the message instructs the model, newer OpenAI models call the system role "developer"
+func isSystemRole(role string) bool {
+	return role == "system" || role == "developer"
+}
+
+// checkDocumentWithLLM checks a loaded prompt, chat prompts message by message
+func checkDocumentWithLLM(doc *Document, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	if len(doc.Messages) == 0 {
+		return checkPromptWithLLM(doc.Text, rules, config)
+	}
+	return checkChatWithLLM(doc.Text, doc.Messages, rules, config)
+}
+
+// checkChatWithLLM checks the system and user messages of a chat prompt separately with role-aware instructions,
+// assistant and tool messages are examples and history. Static rules and analyzers run on the whole text.
+// Issues are attributed to the message they are found in.
+func checkChatWithLLM(text string, messages []ChatMessage, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	hasSystem := false
+	for _, message := range messages {
+		hasSystem = hasSystem || isSystemRole(message.Role)
+	}
+	model := linter.ParsePrompt(text)
+	starts := messageOffsets(text, messages)
+
+	var issues []Issue
+	if ruleEngine != "static" && len(rules.PromptRules) > 0 {
+		for i, message := range messages {
+			var instruction string
+			switch {
+			case isSystemRole(message.Role):
+				instruction = systemMessageInstruction
+			case message.Role == "user" && hasSystem:
+				instruction = userMessageInstruction
+			case message.Role == "user":
+				instruction = promptCheckInstruction
+			default:
+				continue
+			}
+			if strings.TrimSpace(message.Content) == "" {
+				continue
+			}
+			printProgress(fmt.Sprintf("Checking message %d/%d (%s)", i+1, len(messages), message.Role))
+			messageIssues, err := checkContentWithLLM(instruction, message.Content, rules, config)
+			if err != nil {
+				return nil, fmt.Errorf("message %d (%s): %w", i+1, message.Role, err)
+			}
+			for _, issue := range messageIssues {
+				if hasSystem && !isSystemRole(message.Role) && conversationRules[strings.ToLower(issue.RuleName)] {
+					continue
+				}
+				issue.Message, issue.Role = i+1, message.Role
+				issue.Line, issue.Column = 0, 0
+				if start := starts[i]; start >= 0 && issue.OriginalSnippet != "" {
+					end := start + len(strings.TrimSpace(message.Content))
+					if j := strings.Index(text[start:end], strings.TrimSpace(issue.OriginalSnippet)); j >= 0 {
+						position := model.Position(start + j)
+						issue.Line, issue.Column = position.Line, position.Column
+					}
+				}
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	local := localIssues(model, rules)
+	locateIssues(local, model)
+	for i := range local {
+		if local[i].Line == 0 || local[i].Line > len(model.Lines) {
+			continue
+		}
+		offset := model.LineOffset(local[i].Line - 1)
+		for j, message := range messages {
+			start := starts[j]
+			if start < 0 {
+				continue
+			}
+			// Local issues are located by lines, the line the content starts on belongs to the message
+			lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+			if offset >= lineStart && offset <= start+len(strings.TrimSpace(message.Content)) {
+				local[i].Message, local[i].Role = j+1, message.Role
+				break
+			}
+		}
+	}
+	locateIssues(issues, model)
+	issues = append(issues, local...)
+	return linter.ApplySuppressions(issues, model), nil
+}
+
+// messageOffsets returns the offsets of the message contents in the rendered text, -1 for messages without content
+func messageOffsets(text string, messages []ChatMessage) []int {
+	starts := make([]int, len(messages))
+	pos := 0
+	for i, message := range messages {
+		content := strings.TrimSpace(message.Content)
+		starts[i] = -1
+		if content == "" {
+			continue
+		}
+		if j := strings.Index(text[pos:], content); j >= 0 {
+			starts[i] = pos + j
+			pos += j + len(content)
+		}
+	}
+	return starts
+}
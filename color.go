@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+)
+
+// isTerminal reports whether the file is a terminal
+func isTerminal(f *os.File) bool {
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (fileInfo.Mode() & os.ModeCharDevice) != 0
+}
+
+// envEnabled reports whether the environment variable is set to a value other than "0"
+func envEnabled(name string) bool {
+	value, ok := os.LookupEnv(name)
+	return ok && value != "0"
+}
+
+// hyperlinksEnabled reports whether colored reports to the file may contain OSC 8 hyperlinks: PROMPTLINT_HYPERLINKS
+// decides when set, otherwise only terminals get them, forced colors in CI logs don't
+func hyperlinksEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv("PROMPTLINT_HYPERLINKS"); ok {
+		return envEnabled("PROMPTLINT_HYPERLINKS")
+	}
+	return isTerminal(f) && os.Getenv("TERM") != "dumb"
+}
+
+// colorEnabled returns true if colored output should be used for the file.
+// Honors NO_COLOR, FORCE_COLOR, CLICOLOR_FORCE and CLICOLOR conventions.
+func colorEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if envEnabled("FORCE_COLOR") || envEnabled("CLICOLOR_FORCE") {
+		enableVirtualTerminal(f)
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" {
+		return false
+	}
+
+	// The file is not a terminal (probably a pipe or a file)
+	if !isTerminal(f) {
+		return false
+	}
+
+	// Check if TERM environment variable indicates color support
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	// Windows consoles need virtual terminal processing for ANSI sequences
+	return enableVirtualTerminal(f)
+}
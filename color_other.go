@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// enableVirtualTerminal is a no-op, terminals on this platform support ANSI escape sequences
+func enableVirtualTerminal(*os.File) bool {
+	return true
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Command is a command of the CLI. A command with subcommands dispatches on its first argument,
+// Run then handles arguments naming no subcommand and may be nil.
+type Command struct {
+	Name string
+	// Args is the synopsis printed after the name, Summary the description in command lists
+	Args    string
+	Summary string
+	Run     func(args []string) error
+	// Subcommands like `rules update`
+	Subcommands []*Command
+}
+
+// commands are the top-level commands in the order of the help
+var commands []*Command
+
+// RegisterCommand adds a top-level command, registering a name twice replaces the previous command
+func RegisterCommand(command *Command) {
+	for i, registered := range commands {
+		if registered.Name == command.Name {
+			commands[i] = command
+			return
+		}
+	}
+	commands = append(commands, command)
+}
+
+// findCommand returns the command with the name, nil without one
+func findCommand(list []*Command, name string) *Command {
+	for _, command := range list {
+		if command.Name == name {
+			return command
+		}
+	}
+	return nil
+}
+
+// dispatch runs the subcommand named by the first argument, Run otherwise. Path is the command line
+// up to the command, like "rules" for the subcommands of rules.
+func (c *Command) dispatch(path string, args []string) error {
+	if len(c.Subcommands) > 0 && len(args) > 0 {
+		if sub := findCommand(c.Subcommands, args[0]); sub != nil {
+			return sub.dispatch(path+" "+sub.Name, args[1:])
+		}
+	}
+	if c.Run != nil {
+		return c.Run(args)
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s %s <command> [options]\n\nCommands:\n", appName, path)
+	printCommandList(os.Stderr, path+" ", c.Subcommands)
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s requires a command", path)
+	}
+	return fmt.Errorf("unknown command %q%s", path+" "+args[0], suggestCommand(args[0], c.Subcommands))
+}
+
+// commandColumn is the column of command summaries in command lists
+const commandColumn = 29
+
+// printCommandList prints the synopsis and summary of the commands, subcommands are listed with their parent
+func printCommandList(w io.Writer, prefix string, list []*Command) {
+	for _, command := range list {
+		if command.Summary == "" {
+			printCommandList(w, prefix+command.Name+" ", command.Subcommands)
+			continue
+		}
+		synopsis := "  " + appName + " " + prefix + command.Name
+		if command.Args != "" {
+			synopsis += " " + command.Args
+		}
+		if len(synopsis) < commandColumn-1 {
+			fmt.Fprintf(w, "%-*s%s\n", commandColumn, synopsis, command.Summary)
+		} else {
+			fmt.Fprintf(w, "%s\n%s%s\n", synopsis, strings.Repeat(" ", commandColumn), command.Summary)
+		}
+		printCommandList(w, prefix+command.Name+" ", command.Subcommands)
+	}
+}
+
+// suggestCommand returns ", did you mean X?" for a command name with a typo, empty when no name is close
+func suggestCommand(name string, list []*Command) string {
+	best, bestDistance := "", 3
+	for _, command := range list {
+		if distance := editDistance(name, command.Name); distance < bestDistance {
+			best, bestDistance = command.Name, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// editDistance is the Levenshtein distance of the strings in bytes
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// unknownCommandError reports a bare argument that is neither a file nor a command but close to a command name,
+// so `promptlint chek x.md` doesn't fail with a missing file "chek"
+func unknownCommandError(arg string) error {
+	if strings.ContainsAny(arg, "./\\*?") || strings.HasPrefix(arg, "-") {
+		return nil
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return nil
+	}
+	if suggestion := suggestCommand(arg, commands); suggestion != "" {
+		return fmt.Errorf("unknown command %q%s", arg, suggestion)
+	}
+	return nil
+}
+
+// runHelpCommand implements `promptlint help [command...]`
+func runHelpCommand(args []string) error {
+	if len(args) == 0 {
+		printUsage()
+		return nil
+	}
+	command := findCommand(commands, args[0])
+	if command == nil {
+		return fmt.Errorf("unknown command %q%s", args[0], suggestCommand(args[0], commands))
+	}
+	path := command.Name
+	for _, name := range args[1:] {
+		sub := findCommand(command.Subcommands, name)
+		if sub == nil {
+			return fmt.Errorf("unknown command %q%s", path+" "+name, suggestCommand(name, command.Subcommands))
+		}
+		command, path = sub, path+" "+name
+	}
+	if command.Run == nil {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s <command> [options]\n\nCommands:\n", appName, path)
+		printCommandList(os.Stderr, path+" ", command.Subcommands)
+		return nil
+	}
+	// Commands print their usage and exit on -h
+	return command.Run([]string{"-h"})
+}
+
+// runFixCommand implements `promptlint fix`, a lint run with --fix
+func runFixCommand(args []string) error {
+	return runLintCommand(append([]string{"--fix"}, args...))
+}
+
+func init() {
+	for _, command := range []*Command{
+		{Name: "check", Args: "[options] [file|glob|langsmith://…|promptlayer://…|langfuse://…]...", Summary: "Check prompts from files, stdin or prompt stores, the default command", Run: runLintCommand},
+		{Name: "fix", Args: "[--until-clean] [--interactive] [options] [file...]", Summary: "Apply the suggested fixes to the prompts, like check --fix", Run: runFixCommand},
+		{Name: "version", Args: "[--json]", Summary: "Show version, commit, build date, rules and built-in features", Run: runVersionCommand},
+		{Name: "rules", Subcommands: []*Command{
+			{Name: "list", Args: "[--format=text|json] [--rules=pack.yaml] [dir]", Summary: "List rules and analyzers with their status in the directory", Run: runRulesListCommand},
+			{Name: "update", Args: "[--version=X.Y.Z] [--reset]", Summary: "Download the latest curated rule set without upgrading the binary", Run: runRulesUpdateCommand},
+			{Name: "coverage", Args: "[--rules=pack.yaml] <dir>", Summary: "Report rules that never trigger or dominate across a prompt corpus", Run: runRulesCoverageCommand},
+			{Name: "lint", Args: "[pack.yaml...]", Summary: "Check that good examples of rules violate no rule and bad ones their own", Run: runRulesLintCommand},
+			{Name: "calibrate", Args: "[--feedback=file] [--format=text|json|yaml]", Summary: "Suggest severity overrides from issues labeled with --collect-feedback", Run: runRulesCalibrateCommand},
+		}},
+		{Name: "serve", Args: "[--port=8080] [--token=secret]", Summary: "Serve a lint API over HTTP: POST /v1/lint, GET /healthz", Run: runServeCommand},
+		{Name: "tokens", Args: "[--models=gpt-4o,o3-mini] <file>...", Summary: "Count prompt tokens per model and estimate the cost of linting them", Run: runTokensCommand},
+		{Name: "dismiss", Args: "<fingerprint> --reason=\"...\" [--expires=YYYY-MM-DD]", Summary: "Accept an issue, recorded in .promptlint-dismissals.yaml", Run: runDismissCommand},
+		{Name: "diff", Args: "<old> <new> | --staged", Summary: "Check only issues introduced by the changes or by staged prompt files", Run: runDiffCommand},
+		{Name: "worker", Args: "--queue=<url> [--sink=<dest>]", Summary: "Consume lint jobs from a Redis or NATS queue", Run: runWorkerCommand},
+		{Name: "export-eval", Args: "[--output=evals.jsonl] <file>...", Summary: "Export graded examples per prompt and rule as an evals dataset", Run: runExportEvalCommand},
+		{Name: "fmt", Args: "[-w|-l|-d] [--reorder] [file...]", Summary: "Normalize prompt formatting and section order without LLM calls", Run: runFmtCommand},
+		{Name: "similar", Args: "[--threshold=0.9] <file>...", Summary: "Find duplicate prompts by embeddings similarity", Run: runSimilarCommand},
+		{Name: "cron", Args: "--catalog=catalog.yaml [--once]", Summary: "Periodically re-lint a prompt catalog and alert on regressions", Run: runCronCommand},
+		{Name: "expand", Args: "--matrix=vars.yaml <file>", Summary: "Lint every combination of template variable values", Run: runExpandCommand},
+		{Name: "doctor", Summary: "Validate API key, endpoint, tool calling, rules and cache", Run: runDoctorCommand},
+		{Name: "judge-ab", Args: "[--a=v1] [--b=v2] [--feedback=file]", Summary: "Compare judge prompt versions by agreement with labeled issues", Run: runJudgeABCommand},
+		{Name: "regression", Args: "--corpus=dir [--min-precision=0.8] [--min-recall=0.8]", Summary: "Report precision and recall per rule over prompts with expected issues", Run: runRegressionCommand},
+		{Name: "snapshot", Args: "[--update] [--dir=.promptlint/snapshots] <file|dir>...", Summary: "Fail when lint results differ from the golden snapshots of the prompts", Run: runSnapshotCommand},
+		{Name: "migrate-config", Args: "[-w]", Summary: "Replace deprecated rule names in configs and dismissals", Run: runMigrateConfigCommand},
+		{Name: "inventory", Args: "[--format=json|csv] [dir]", Summary: "List prompts with hash, tokens, model, owners and last score", Run: runInventoryCommand},
+		{Name: "score", Args: "[--explain] [--format=text|json] <file>", Summary: "Show the quality score, --explain breaks it down by category", Run: runScoreCommand},
+		{Name: "lsp", Args: "[--stdio] [--rules=pack.yaml]", Summary: "Language server: diagnostics and quick fixes in editors", Run: runLSPCommand},
+		{Name: "bench", Args: "[--providers=openai,anthropic] <file|dir>...", Summary: "Measure static analysis throughput and provider latency", Run: runBenchCommand},
+		{Name: "smoke", Args: "[--vars=vars.yaml] [--schema=schema.json] <file>", Summary: "Send the prompt to the target model once and validate the answer against its output contract", Run: runSmokeCommand},
+		{Name: "simulate", Args: "[--personas=personas.yaml] [--turns=4] <file>", Summary: "Run simulated conversations against the prompt and report the turn it broke at", Run: runSimulateCommand},
+		{Name: "graph", Args: "[--format=dot|mermaid] [--results=result.json] [dir|file...]", Summary: "Print the dependency graph of prompts, included fragments and workflow steps colored by lint status", Run: runGraphCommand},
+		{Name: "merge", Args: "[--format=text|json|sarif|github|markdown|junit] <result.json>...", Summary: "Combine JSON results of shards, repos or runs, duplicates removed", Run: runMergeCommand},
+		{Name: "anonymize", Args: "[--output=dir] [--map=map.json] [file...]", Summary: "Replace names, products and internal URLs with placeholders for sharing", Run: runAnonymizeCommand},
+		{Name: "convert", Args: "--to=prompty [--from=markdown] [-o file|--out-dir=dir] [file...]", Summary: "Convert prompts between text, markdown, chat-json/yaml, prompty and dotprompt", Run: runConvertCommand},
+		{Name: "help", Args: "[command...]", Summary: "Show the usage of the CLI or of a command", Run: runHelpCommand},
+	} {
+		RegisterCommand(command)
+	}
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the name of project configuration files
+const configFileName = ".promptlint.yaml"
+
+// ProjectConfig is the content of a .promptlint.yaml file.
+// Files in nested directories inherit settings from parent directories and override them.
+type ProjectConfig struct {
+	// Root stops the upward search for parent configuration files
+	Root bool `yaml:"root,omitempty"`
+	// Disable lists names of rules that are not checked
+	Disable []string `yaml:"disable,omitempty"`
+	// Enable re-enables rules disabled by a parent configuration
+	Enable []string `yaml:"enable,omitempty"`
+	// EnableOnly limits the checked rules and analyzers to the listed ones, the innermost configuration setting it wins
+	EnableOnly []string `yaml:"enable_only,omitempty"`
+	// Rules adds new rules or overrides fields of existing rules with the same name
+	Rules []PromptRule `yaml:"rules,omitempty"`
+	// SectionOrder is the canonical order of section kinds: role, context, instructions, constraints, examples, output
+	SectionOrder []string `yaml:"section_order,omitempty"`
+	// Emoji maps message roles (system, user, assistant, default) to allow, warn or forbid
+	Emoji map[string]string `yaml:"emoji,omitempty"`
+	// Tone configures the brand voice and additional words reported by the tone analyzer
+	Tone ToneConfig `yaml:"tone,omitempty"`
+	// ReadingLevel sets the target reading level of user-facing text
+	ReadingLevel ReadingLevelConfig `yaml:"reading_level,omitempty"`
+	// CognitiveLoad sets limits of instruction density and condition nesting
+	CognitiveLoad CognitiveLoadConfig `yaml:"cognitive_load,omitempty"`
+	// RulesVersion pins the rule set version installed by `rules update`
+	RulesVersion string `yaml:"rules_version,omitempty"`
+	// FailIf is a gate expression over the run results, e.g. "score < 80 || new_issues > 0"
+	FailIf string `yaml:"fail_if,omitempty"`
+	// Provider is the LLM API: openai (default), anthropic or azure; --provider and PROMPTLINT_PROVIDER take precedence
+	Provider string `yaml:"provider,omitempty"`
+	// Model is the LLM model name, PROMPTLINT_MODEL_NAME takes precedence
+	Model string `yaml:"model,omitempty"`
+	// Endpoint is the URL of the provider API, PROMPTLINT_API_ENDPOINT takes precedence
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Timeout limits a single LLM request, e.g. "90s"
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Retries configures retries of LLM requests failed by transient errors
+	Retries RetryConfig `yaml:"retries,omitempty"`
+	// Format is the default output format, --format takes precedence
+	Format string `yaml:"format,omitempty"`
+	// Files are glob patterns of prompts linted when neither a file nor stdin is given,
+	// relative to the directory of the configuration file; "**" matches any number of directories
+	Files []string `yaml:"files,omitempty"`
+	// Locale is the language code rule reasons and fixes are rendered in, --locale takes precedence
+	Locale string `yaml:"locale,omitempty"`
+	// JudgePrompt is the version of the judge system message, --judge-prompt takes precedence
+	JudgePrompt string `yaml:"judge_prompt,omitempty"`
+	// Anonymize lists entities `anonymize` replaces with placeholders
+	Anonymize AnonymizeConfig `yaml:"anonymize,omitempty"`
+	// Offline forbids network access like --offline, PROMPTLINT_OFFLINE takes precedence
+	Offline bool `yaml:"offline,omitempty"`
+	// Pricing overrides the prices of models in USD per million tokens used by cost estimates
+	Pricing []ModelPrice `yaml:"pricing,omitempty"`
+	// Budgets limit estimated tokens of prompts by path pattern, the first match of the nearest configuration applies
+	Budgets []TokenBudget `yaml:"budgets,omitempty"`
+}
+
+// ToneConfig configures the tone analysis in .promptlint.yaml
+type ToneConfig struct {
+	// Voice describes the brand voice, the LLM review checks the prompt against it
+	Voice string `yaml:"voice,omitempty"`
+	// Words are additional words that don't fit the brand voice
+	Words []string `yaml:"words,omitempty"`
+}
+
+// Analyzer settings configured in .promptlint.yaml
+type (
+	ReadingLevelConfig  = analyzers.ReadingLevelConfig
+	CognitiveLoadConfig = analyzers.CognitiveLoadConfig
+	TokenBudget         = analyzers.TokenBudget
+)
+
+// loadProjectConfig reads a single configuration file
+func loadProjectConfig(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config ProjectConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// findConfigFiles returns configuration files that apply to dir, from the outermost to the innermost
+func findConfigFiles(dir string) ([]string, []*ProjectConfig, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	var paths []string
+	var configs []*ProjectConfig
+	for {
+		path := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(path); err == nil {
+			config, err := loadProjectConfig(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			paths = append([]string{path}, paths...)
+			configs = append([]*ProjectConfig{config}, configs...)
+			if config.Root {
+				break
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, fmt.Errorf("failed to check config file: %w", err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return paths, configs, nil
+}
+
+// mergeConfigs merges configurations from the outermost to the innermost, inner values win
+func mergeConfigs(configs []*ProjectConfig) *ProjectConfig {
+	merged := &ProjectConfig{}
+	disabled := map[string]bool{}
+	var disabledOrder []string
+
+	for _, config := range configs {
+		for _, name := range config.Disable {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if !disabled[key] {
+				disabledOrder = append(disabledOrder, name)
+			}
+			disabled[key] = true
+		}
+		for _, name := range config.Enable {
+			disabled[strings.ToLower(strings.TrimSpace(name))] = false
+		}
+		for _, rule := range config.Rules {
+			merged.Rules = overrideRule(merged.Rules, rule)
+		}
+		if len(config.EnableOnly) > 0 {
+			merged.EnableOnly = config.EnableOnly
+		}
+		if len(config.SectionOrder) > 0 {
+			merged.SectionOrder = config.SectionOrder
+		}
+		for role, policy := range config.Emoji {
+			if merged.Emoji == nil {
+				merged.Emoji = map[string]string{}
+			}
+			merged.Emoji[strings.ToLower(role)] = policy
+		}
+		if config.Tone.Voice != "" {
+			merged.Tone.Voice = config.Tone.Voice
+		}
+		merged.Tone.Words = append(merged.Tone.Words, config.Tone.Words...)
+		merged.Pricing = append(merged.Pricing, config.Pricing...)
+		merged.Anonymize.Names = append(merged.Anonymize.Names, config.Anonymize.Names...)
+		merged.Anonymize.Products = append(merged.Anonymize.Products, config.Anonymize.Products...)
+		merged.Anonymize.Organizations = append(merged.Anonymize.Organizations, config.Anonymize.Organizations...)
+		merged.Anonymize.Terms = append(merged.Anonymize.Terms, config.Anonymize.Terms...)
+		merged.Anonymize.Domains = append(merged.Anonymize.Domains, config.Anonymize.Domains...)
+		merged.Anonymize.Keep = append(merged.Anonymize.Keep, config.Anonymize.Keep...)
+		if config.ReadingLevel.Grade != 0 {
+			merged.ReadingLevel.Grade = config.ReadingLevel.Grade
+		}
+		if config.ReadingLevel.Tolerance != 0 {
+			merged.ReadingLevel.Tolerance = config.ReadingLevel.Tolerance
+		}
+		if len(config.ReadingLevel.Sections) > 0 {
+			merged.ReadingLevel.Sections = config.ReadingLevel.Sections
+		}
+		if config.CognitiveLoad.MaxDensity != 0 {
+			merged.CognitiveLoad.MaxDensity = config.CognitiveLoad.MaxDensity
+		}
+		if config.CognitiveLoad.MaxNesting != 0 {
+			merged.CognitiveLoad.MaxNesting = config.CognitiveLoad.MaxNesting
+		}
+		if config.RulesVersion != "" {
+			merged.RulesVersion = config.RulesVersion
+		}
+		if config.FailIf != "" {
+			merged.FailIf = config.FailIf
+		}
+		if config.Provider != "" {
+			merged.Provider = config.Provider
+		}
+		if config.Model != "" {
+			merged.Model = config.Model
+		}
+		if config.Endpoint != "" {
+			merged.Endpoint = config.Endpoint
+		}
+		if config.Timeout != 0 {
+			merged.Timeout = config.Timeout
+		}
+		if config.Retries.Max != nil {
+			merged.Retries.Max = config.Retries.Max
+		}
+		if config.Retries.InitialBackoff != 0 {
+			merged.Retries.InitialBackoff = config.Retries.InitialBackoff
+		}
+		if config.Retries.MaxBackoff != 0 {
+			merged.Retries.MaxBackoff = config.Retries.MaxBackoff
+		}
+		if config.Format != "" {
+			merged.Format = config.Format
+		}
+		if len(config.Files) > 0 {
+			merged.Files = config.Files
+		}
+		if config.Locale != "" {
+			merged.Locale = config.Locale
+		}
+		if config.JudgePrompt != "" {
+			merged.JudgePrompt = config.JudgePrompt
+		}
+		// A parent configuration of an air-gapped project can't be overridden by a nested one
+		merged.Offline = merged.Offline || config.Offline
+	}
+
+	for _, name := range disabledOrder {
+		if disabled[strings.ToLower(strings.TrimSpace(name))] {
+			merged.Disable = append(merged.Disable, name)
+		}
+	}
+	return merged
+}
+
+// overrideRule merges the rule into the list: non-empty fields override the rule with the same name,
+// a rule with a new name is appended
+func overrideRule(rules []PromptRule, override PromptRule) []PromptRule {
+	for i := range rules {
+		if !strings.EqualFold(rules[i].Name, override.Name) {
+			continue
+		}
+		if override.Rule != "" {
+			rules[i].Rule = override.Rule
+		}
+		if override.Severity != "" {
+			rules[i].Severity = override.Severity
+		}
+		if override.Category != "" {
+			rules[i].Category = override.Category
+		}
+		if override.Reason != "" {
+			rules[i].Reason, rules[i].ReasonTranslations = overrideLocalized(rules[i].Reason, rules[i].ReasonTranslations, override.Reason, override.ReasonTranslations)
+		}
+		if override.Fix != "" {
+			rules[i].Fix, rules[i].FixTranslations = overrideLocalized(rules[i].Fix, rules[i].FixTranslations, override.Fix, override.FixTranslations)
+		}
+		if override.BadExample != "" {
+			rules[i].BadExample = override.BadExample
+		}
+		if override.GoodExample != "" {
+			rules[i].GoodExample = override.GoodExample
+		}
+		if override.Pattern != "" {
+			rules[i].Pattern = override.Pattern
+		}
+		if override.MinLength != 0 {
+			rules[i].MinLength = override.MinLength
+		}
+		if override.MaxLength != 0 {
+			rules[i].MaxLength = override.MaxLength
+		}
+		if override.Deprecated {
+			rules[i].Deprecated = true
+		}
+		if override.ReplacedBy != "" {
+			rules[i].ReplacedBy = override.ReplacedBy
+		}
+		if override.DocsURL != "" {
+			rules[i].DocsURL = override.DocsURL
+		}
+		return rules
+	}
+	return append(rules, override)
+}
+
+// applyProjectConfig returns a copy of the rules adjusted by the configuration
+func applyProjectConfig(base *Rules, config *ProjectConfig) *Rules {
+	result := &Rules{Version: base.Version, PromptRules: append([]PromptRule(nil), base.PromptRules...)}
+	for _, rule := range config.Rules {
+		result.PromptRules = overrideRule(result.PromptRules, rule)
+	}
+	if config.Tone.Voice != "" {
+		result.PromptRules = overrideRule(result.PromptRules, analyzers.BrandVoiceRule(config.Tone.Voice))
+	}
+	if config.ReadingLevel.Grade > 0 {
+		result.PromptRules = overrideRule(result.PromptRules, analyzers.ReadingLevelRule(config.ReadingLevel))
+	}
+
+	disabled := map[string]bool{}
+	for _, name := range config.Disable {
+		disabled[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	only := map[string]bool{}
+	for _, name := range config.EnableOnly {
+		only[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	enabled := result.PromptRules[:0]
+	for _, rule := range result.PromptRules {
+		name := strings.ToLower(rule.Name)
+		if !disabled[name] && (len(only) == 0 || only[name]) {
+			enabled = append(enabled, rule)
+		}
+	}
+	result.PromptRules = enabled
+	return result
+}
+
+// loadRunSettings returns the merged configuration of the directory for the model, endpoint, timeout and output format
+func loadRunSettings(dir string) (*ProjectConfig, error) {
+	_, configs, err := findConfigFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeConfigs(configs)
+	if merged.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must be positive, got %s", merged.Timeout)
+	}
+	if err := merged.Retries.validate(); err != nil {
+		return nil, err
+	}
+	if merged.Provider != "" {
+		if _, err := llm.Lookup(merged.Provider); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := linter.JudgePrompt(merged.JudgePrompt); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// configuredFiles expands the files patterns of the innermost configuration that sets them, nil when none does
+func configuredFiles(dir string) ([]string, error) {
+	paths, configs, err := findConfigFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(configs) - 1; i >= 0; i-- {
+		if len(configs[i].Files) > 0 {
+			return expandGlobs(filepath.Dir(paths[i]), configs[i].Files)
+		}
+	}
+	return nil, nil
+}
+
+// analyzerSettingsMu guards the analyzer settings, serve and worker reload them while prompts are checked
+var analyzerSettingsMu sync.RWMutex
+
+// analyzerSettings are the analyzer settings of the configuration files that apply to a path
+type analyzerSettings struct {
+	sectionOrder      []string
+	emojiPolicy       map[string]string
+	toneWordsPattern  *regexp.Regexp
+	readingLevel      ReadingLevelConfig
+	cognitiveLoad     CognitiveLoadConfig
+	tokenBudget       *TokenBudget
+	disabledAnalyzers map[string]bool
+}
+
+// readAnalyzerSettings reads the analyzer settings from the configuration files that apply to the path
+func readAnalyzerSettings(path string) (analyzerSettings, error) {
+	var settings analyzerSettings
+	var err error
+	if settings.sectionOrder, err = loadSectionOrder(path); err != nil {
+		return settings, err
+	}
+	if settings.emojiPolicy, err = loadEmojiPolicy(path); err != nil {
+		return settings, err
+	}
+	if settings.toneWordsPattern, err = loadToneWordsPattern(path); err != nil {
+		return settings, err
+	}
+	if settings.readingLevel, err = loadReadingLevel(path); err != nil {
+		return settings, err
+	}
+	if settings.cognitiveLoad, err = loadCognitiveLoad(path); err != nil {
+		return settings, err
+	}
+	if settings.tokenBudget, err = loadTokenBudget(path); err != nil {
+		return settings, err
+	}
+	settings.disabledAnalyzers, err = loadDisabledAnalyzers(path)
+	return settings, err
+}
+
+// apply replaces the analyzer settings at once, analyzers running meanwhile finish with the previous ones
+func (settings analyzerSettings) apply() {
+	updateAnalyzerSettings(func(configured *analyzers.Settings) {
+		configured.SectionOrder = settings.sectionOrder
+		configured.EmojiPolicy = settings.emojiPolicy
+		configured.ToneWords = settings.toneWordsPattern
+		configured.ReadingLevel = settings.readingLevel
+		configured.CognitiveLoad = settings.cognitiveLoad
+		configured.TokenBudget = settings.tokenBudget
+		disabledAnalyzers = settings.disabledAnalyzers
+	})
+}
+
+// loadAnalyzerSettings sets the analyzer settings from the configuration files that apply to the path,
+// settings that fail to load leave the previous ones in place
+func loadAnalyzerSettings(path string) error {
+	settings, err := readAnalyzerSettings(path)
+	if err != nil {
+		return err
+	}
+	settings.apply()
+	return nil
+}
+
+// rulesForPath resolves the rules for a linted file using configuration files of its directory and parents.
+// An empty path resolves the configuration for the current directory.
+func rulesForPath(base *Rules, path string) (*Rules, error) {
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+
+	paths, configs, err := findConfigFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		// --disable and --enable-only apply without configuration files too
+		return resolvedRulesFor(base, nil, nil)
+	}
+	for i, config := range configs {
+		for _, migration := range migrateRuleNames(config, base) {
+			printProgress(fmt.Sprintf("Warning: %s %s, run `%s migrate-config`", paths[i], migration, appName))
+		}
+		for _, rule := range config.Rules {
+			if err := checkDocsURL(rule.DocsURL); err != nil {
+				return nil, fmt.Errorf("%s: rule %q: %w", paths[i], rule.Name, err)
+			}
+		}
+	}
+
+	rules, err := resolvedRulesFor(base, paths, configs)
+	if err != nil {
+		return nil, err
+	}
+	printProgress(fmt.Sprintf("Applied config %s (%d rules active)", strings.Join(paths, ", "), len(rules.PromptRules)))
+	return rules, nil
+}
+
+// maxResolvedRules limits the cached rule sets, every reload of the base rules adds new ones
+const maxResolvedRules = 256
+
+// resolvedRules caches rules adjusted by configuration files: files under the same unchanged configuration files
+// share one rule set, so its description for the LLM is built once. The cache is reset when it is full.
+var resolvedRules = struct {
+	sync.Mutex
+	entries map[resolvedRulesKey]*Rules
+}{entries: map[resolvedRulesKey]*Rules{}}
+
+// resolvedRulesKey identifies base rules and the versions of the configuration files applied to them
+type resolvedRulesKey struct {
+	base  *Rules
+	files string
+}
+
+// resolvedRulesFor returns the base rules adjusted by the configuration files and --disable/--enable-only,
+// cached by the files and their versions
+func resolvedRulesFor(base *Rules, paths []string, configs []*ProjectConfig) (*Rules, error) {
+	key := resolvedRulesKey{base: base}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check config file: %w", err)
+		}
+		key.files += fmt.Sprintf("\x00%s\x00%d\x00%d", path, info.ModTime().UnixNano(), info.Size())
+	}
+
+	resolvedRules.Lock()
+	defer resolvedRules.Unlock()
+	if rules, ok := resolvedRules.entries[key]; ok {
+		return rules, nil
+	}
+	for i, config := range configs {
+		_, unknown := newRuleSelection(base, config.Disable, append(append([]string(nil), config.Enable...), config.EnableOnly...))
+		for _, name := range unknown {
+			printProgress(fmt.Sprintf("Warning: %s references unknown rule %q", paths[i], name))
+		}
+	}
+	rules := policy.enforceRules(ruleSelection.apply(applyProjectConfig(base, mergeConfigs(configs))))
+	if len(resolvedRules.entries) >= maxResolvedRules {
+		resolvedRules.entries = map[resolvedRulesKey]*Rules{}
+	}
+	resolvedRules.entries[key] = rules
+	return rules, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/report"
+)
+
+// judgeReport is an issue as reported by one judge model
+type judgeReport struct {
+	Judge string
+	Issue Issue
+}
+
+// consensusGroup is an issue reported by one or more judges
+type consensusGroup struct {
+	Reports []judgeReport
+}
+
+// Disagreement is an issue only one judge reported in consensus mode
+type Disagreement struct {
+	Judge string `json:"judge"`
+	Issue Issue  `json:"issue"`
+	// Silent lists the judges that didn't report the issue
+	Silent []string `json:"silentJudges"`
+}
+
+// parseJudges splits the --judges list, duplicates are removed
+func parseJudges(value string) []string {
+	var judges []string
+	seen := map[string]bool{}
+	for _, judge := range strings.Split(value, ",") {
+		judge = strings.TrimSpace(judge)
+		if judge != "" && !seen[judge] {
+			seen[judge] = true
+			judges = append(judges, judge)
+		}
+	}
+	return judges
+}
+
+// normalizedSnippet lowercases the snippet and collapses whitespace for comparison across judges
+func normalizedSnippet(snippet string) string {
+	return strings.ToLower(strings.Join(strings.Fields(snippet), " "))
+}
+
+// sameProblem reports whether two judges describe the same problem: the same rule quoting the same text,
+// text of one quote containing the other or, without quotes, the same line
+func sameProblem(a, b Issue) bool {
+	if !strings.EqualFold(a.RuleName, b.RuleName) {
+		return false
+	}
+	if a.Fingerprint == b.Fingerprint {
+		return true
+	}
+	sa, sb := normalizedSnippet(a.OriginalSnippet), normalizedSnippet(b.OriginalSnippet)
+	if sa != "" && sb != "" {
+		return strings.Contains(sa, sb) || strings.Contains(sb, sa)
+	}
+	return a.Line > 0 && a.Line == b.Line
+}
+
+// groupJudgeReports groups issues of all judges by the problem they describe, every judge counts once per group
+func groupJudgeReports(judges []string, issues map[string][]Issue) []consensusGroup {
+	var groups []consensusGroup
+	for _, judge := range judges {
+		for _, issue := range issues[judge] {
+			matched := false
+			for i := range groups {
+				group := &groups[i]
+				if group.reportedBy(judge) || !sameProblem(group.Reports[0].Issue, issue) {
+					continue
+				}
+				group.Reports = append(group.Reports, judgeReport{Judge: judge, Issue: issue})
+				matched = true
+				break
+			}
+			if !matched {
+				groups = append(groups, consensusGroup{Reports: []judgeReport{{Judge: judge, Issue: issue}}})
+			}
+		}
+	}
+	return groups
+}
+
+// reportedBy reports whether the judge is among the reporters of the group
+func (g consensusGroup) reportedBy(judge string) bool {
+	for _, report := range g.Reports {
+		if report.Judge == judge {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultQuorum is the majority of the judges
+func defaultQuorum(judges int) int {
+	return judges/2 + 1
+}
+
+// resolveConsensus keeps issues reported by at least quorum judges and lists issues of a single judge as disagreements
+func resolveConsensus(judges []string, groups []consensusGroup, quorum int) ([]Issue, []Disagreement) {
+	var accepted []Issue
+	var disagreements []Disagreement
+	for _, group := range groups {
+		if len(group.Reports) >= quorum {
+			accepted = append(accepted, group.Reports[0].Issue)
+			continue
+		}
+		if len(group.Reports) == 1 {
+			report := group.Reports[0]
+			var silent []string
+			for _, judge := range judges {
+				if judge != report.Judge {
+					silent = append(silent, judge)
+				}
+			}
+			disagreements = append(disagreements, Disagreement{Judge: report.Judge, Issue: report.Issue, Silent: silent})
+		}
+	}
+	return accepted, disagreements
+}
+
+// checkPromptWithJudges lints the prompt with every judge model and keeps the issues a quorum of them agrees on.
+// Static rules and analyzers are deterministic and run once, their issues are always kept.
+func checkPromptWithJudges(prompt string, rules *Rules, config *LLMConfig, judges []string, quorum int) ([]Issue, []Disagreement, error) {
+	model := linter.ParsePrompt(prompt)
+	byJudge := map[string][]Issue{}
+	served := make([]string, 0, len(judges))
+	for i, judge := range judges {
+		printProgress(fmt.Sprintf("Judge %d/%d: %s", i+1, len(judges), judge))
+		judgeConfig := *config
+		judgeConfig.ModelName = judge
+		issues, err := checkContentWithLLM(promptCheckInstruction, prompt, rules, &judgeConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("judge %s: %w", judge, err)
+		}
+		locateIssues(issues, model)
+		byJudge[judge] = linter.ApplySuppressions(issues, model)
+		if judgeConfig.ServedModel != "" {
+			served = append(served, judgeConfig.ServedModel)
+		}
+	}
+	config.ServedModel = strings.Join(served, ",")
+
+	groups := groupJudgeReports(judges, byJudge)
+	issues, disagreements := resolveConsensus(judges, groups, quorum)
+	if dropped := len(groups) - len(issues) - len(disagreements); dropped > 0 {
+		printProgress(fmt.Sprintf("Dropped %d issues reported by fewer than %d judges", dropped, quorum))
+	}
+
+	local := localIssues(model, rules)
+	locateIssues(local, model)
+	return append(issues, linter.ApplySuppressions(local, model)...), disagreements, nil
+}
+
+// ReportDisagreements formats the issues only one judge reported
+func ReportDisagreements(disagreements []Disagreement, forceColor, noColor bool) string {
+	useColor := !accessibleOutput && (forceColor || (!noColor && isColorTerminal()))
+	var sb strings.Builder
+	title := fmt.Sprintf("Disagreements: %d issues reported by a single judge", len(disagreements))
+	if len(disagreements) == 0 {
+		title = "Disagreements: none, every issue was reported by more than one judge"
+	}
+	if useColor {
+		sb.WriteString(colorBold + title + colorReset + "\n")
+	} else {
+		sb.WriteString(title + "\n")
+	}
+	for _, d := range disagreements {
+		sb.WriteString("\n")
+		location := ""
+		if d.Issue.Line > 0 {
+			location = fmt.Sprintf(" (line %s)", issueLocation(d.Issue))
+		}
+		sb.WriteString(fmt.Sprintf("- %s%s\n", d.Issue.RuleName, location))
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", d.Judge, d.Issue.Description))
+		if d.Issue.OriginalSnippet != "" {
+			sb.WriteString(report.OriginalSnippet(indentSnippet(d.Issue.OriginalSnippet), useColor) + "\n")
+		}
+		sb.WriteString(fmt.Sprintf("  Not reported by: %s\n", strings.Join(d.Silent, ", ")))
+	}
+	return sb.String()
+}
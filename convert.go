@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// Writer serializes a document in a prompt storage format, the counterpart of Loader
+type Writer interface {
+	// Name returns the format name, the same as the name of the loader that reads it back
+	Name() string
+	// Extension returns the file extension of the format
+	Extension() string
+	// Write serializes the document
+	Write(doc *Document) ([]byte, error)
+}
+
+// writers contains registered writers by format name
+var writers = map[string]Writer{}
+
+// RegisterWriter makes a format available for convert --to
+func RegisterWriter(writer Writer) {
+	writers[writer.Name()] = writer
+}
+
+func init() {
+	RegisterWriter(textWriter{})
+	RegisterWriter(markdownWriter{})
+	RegisterWriter(chatJSONWriter{})
+	RegisterWriter(chatYAMLWriter{})
+	RegisterWriter(promptyWriter{})
+	RegisterWriter(dotpromptWriter{})
+}
+
+// writerNames returns sorted names of all registered writers
+func writerNames() []string {
+	names := make([]string, 0, len(writers))
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writerForPath returns the writer whose extension the path has
+func writerForPath(path string) (Writer, bool) {
+	for _, name := range writerNames() {
+		if hasExtension(path, writers[name].Extension()) {
+			return writers[name], true
+		}
+	}
+	return nil, false
+}
+
+// templatePlaceholderSyntax contains the placeholder syntax substituted by the template engine of a format,
+// other syntaxes stay literal text there
+var templatePlaceholderSyntax = map[string]string{
+	"prompty":   "{{}}",
+	"dotprompt": "{{}}",
+}
+
+// documentMessages returns the chat messages of the document, a prompt without roles is a system message
+func documentMessages(doc *Document) []ChatMessage {
+	if len(doc.Messages) > 0 {
+		return doc.Messages
+	}
+	return []ChatMessage{{Role: "system", Content: strings.TrimSpace(doc.Text)}}
+}
+
+// documentBody returns the prompt as plain text, messages other than a single system message keep "role:" headers
+func documentBody(doc *Document) string {
+	if len(doc.Messages) == 0 {
+		return doc.Text
+	}
+	if len(doc.Messages) == 1 && doc.Messages[0].Role == "system" {
+		return doc.Messages[0].Content + "\n"
+	}
+	return renderMessages(doc.Messages) + "\n"
+}
+
+// frontmatterBlock renders the frontmatter as a YAML block delimited by "---" lines, empty without fields
+func frontmatterBlock(frontmatter map[string]interface{}) (string, error) {
+	if len(frontmatter) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(frontmatter); err != nil {
+		return "", fmt.Errorf("failed to encode frontmatter: %w", err)
+	}
+	return "---\n" + buf.String() + "---\n", nil
+}
+
+// textWriter writes the prompt body, plain text has no frontmatter
+type textWriter struct{}
+
+func (textWriter) Name() string      { return "text" }
+func (textWriter) Extension() string { return ".txt" }
+func (textWriter) Write(doc *Document) ([]byte, error) {
+	return []byte(documentBody(doc)), nil
+}
+
+// markdownWriter writes the prompt body after the frontmatter
+type markdownWriter struct{}
+
+func (markdownWriter) Name() string      { return "markdown" }
+func (markdownWriter) Extension() string { return ".md" }
+func (markdownWriter) Write(doc *Document) ([]byte, error) {
+	header, err := frontmatterBlock(doc.Frontmatter)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(header + documentBody(doc)), nil
+}
+
+// chatJSONWriter writes a chat request object, frontmatter fields become fields next to the messages
+type chatJSONWriter struct{}
+
+func (chatJSONWriter) Name() string      { return "chat-json" }
+func (chatJSONWriter) Extension() string { return ".json" }
+func (chatJSONWriter) Write(doc *Document) ([]byte, error) {
+	request := map[string]interface{}{}
+	for key, value := range doc.Frontmatter {
+		request[key] = value
+	}
+	request["messages"] = documentMessages(doc)
+	data, err := json.MarshalIndent(request, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chat JSON: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// chatYAMLWriter writes a mapping with the frontmatter fields and the messages
+type chatYAMLWriter struct{}
+
+func (chatYAMLWriter) Name() string      { return "chat-yaml" }
+func (chatYAMLWriter) Extension() string { return ".yaml" }
+func (chatYAMLWriter) Write(doc *Document) ([]byte, error) {
+	request := map[string]interface{}{}
+	for key, value := range doc.Frontmatter {
+		request[key] = value
+	}
+	request["messages"] = documentMessages(doc)
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(request); err != nil {
+		return nil, fmt.Errorf("failed to encode chat YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// promptyWriter writes the frontmatter and the messages after "role:" marker lines
+type promptyWriter struct{}
+
+func (promptyWriter) Name() string      { return "prompty" }
+func (promptyWriter) Extension() string { return ".prompty" }
+func (promptyWriter) Write(doc *Document) ([]byte, error) {
+	header, err := frontmatterBlock(doc.Frontmatter)
+	if err != nil {
+		return nil, err
+	}
+	messages := documentMessages(doc)
+	for _, message := range messages {
+		if message.Role != "system" && message.Role != "user" && message.Role != "assistant" {
+			return nil, fmt.Errorf("prompty supports system, user and assistant messages, got %s", message.Role)
+		}
+	}
+	return []byte(header + renderMessages(messages) + "\n"), nil
+}
+
+// dotpromptWriter writes the frontmatter and the messages after {{role "..."}} markers
+type dotpromptWriter struct{}
+
+func (dotpromptWriter) Name() string      { return "dotprompt" }
+func (dotpromptWriter) Extension() string { return ".prompt" }
+func (dotpromptWriter) Write(doc *Document) ([]byte, error) {
+	header, err := frontmatterBlock(doc.Frontmatter)
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	sb.WriteString(header)
+	for i, message := range documentMessages(doc) {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("{{role %q}}\n%s\n", message.Role, message.Content))
+	}
+	return []byte(sb.String()), nil
+}
+
+// placeholderNames returns the sorted names of placeholders of all syntaxes in the messages, with repetitions
+func placeholderNames(messages []ChatMessage) []string {
+	var names []string
+	for _, message := range messages {
+		for _, placeholder := range linter.ParsePrompt(message.Content).Placeholders {
+			names = append(names, placeholder.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// frontmatterLosses compares the frontmatter fields by their JSON encoding, numbers of YAML and JSON differ in type only
+func frontmatterLosses(before, after map[string]interface{}) []string {
+	var changed []string
+	for key, value := range before {
+		other, ok := after[key]
+		if !ok {
+			changed = append(changed, key)
+			continue
+		}
+		a, errA := json.Marshal(value)
+		b, errB := json.Marshal(other)
+		if errA != nil || errB != nil || string(a) != string(b) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// conversionLosses compares the source document with the converted one read back and describes what didn't survive
+func conversionLosses(source, converted *Document) []string {
+	var losses []string
+	if keys := frontmatterLosses(source.Frontmatter, converted.Frontmatter); len(keys) > 0 {
+		losses = append(losses, "frontmatter fields "+strings.Join(keys, ", "))
+	}
+
+	before, after := documentMessages(source), documentMessages(converted)
+	roles := func(messages []ChatMessage) string {
+		names := make([]string, len(messages))
+		for i, message := range messages {
+			names[i] = message.Role
+		}
+		return strings.Join(names, ", ")
+	}
+	if roles(before) != roles(after) {
+		losses = append(losses, fmt.Sprintf("message roles (%s became %s)", roles(before), roles(after)))
+	} else {
+		for i := range before {
+			if strings.TrimSpace(before[i].Content) != strings.TrimSpace(after[i].Content) {
+				losses = append(losses, fmt.Sprintf("content of message %d (%s)", i+1, before[i].Role))
+			}
+		}
+	}
+
+	if a, b := placeholderNames(before), placeholderNames(after); strings.Join(a, ",") != strings.Join(b, ",") {
+		losses = append(losses, fmt.Sprintf("placeholders (%s became %s)", strings.Join(a, ", "), strings.Join(b, ", ")))
+	}
+	return losses
+}
+
+// normalizeTextPlaceholders rewrites the placeholders of every line to the syntax
+func normalizeTextPlaceholders(text, syntax string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = normalizePlaceholders(line, syntax)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// convertDocument serializes the document in the target format and reads the result back to validate it,
+// the losses are returned without an error so the caller decides whether they are acceptable
+func convertDocument(doc *Document, writer Writer, placeholders string) ([]byte, []string, error) {
+	if placeholders != "" {
+		converted := *doc
+		converted.Text = normalizeTextPlaceholders(doc.Text, placeholders)
+		converted.Messages = make([]ChatMessage, len(doc.Messages))
+		for i, message := range doc.Messages {
+			converted.Messages[i] = ChatMessage{Role: message.Role, Content: normalizeTextPlaceholders(message.Content, placeholders)}
+		}
+		doc = &converted
+	}
+
+	output, err := writer.Write(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	reloaded, err := loadDocument("", output, writer.Name())
+	if err != nil {
+		return nil, nil, fmt.Errorf("converted %s doesn't load back: %w", writer.Name(), err)
+	}
+	return output, conversionLosses(doc, reloaded), nil
+}
+
+// runConvertCommand implements `promptlint convert --to=<format> [--from=<format>] [-o file|--out-dir=dir] [file...]`
+func runConvertCommand(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	from := fs.String("from", "auto", "Source format: auto, "+strings.Join(loaderNames(), ", "))
+	to := fs.String("to", "", "Target format: "+strings.Join(writerNames(), ", ")+" (default: from the -o extension)")
+	output := fs.String("o", "", "Write the converted prompt to the file instead of stdout")
+	outDir := fs.String("out-dir", "", "Write every converted file to the directory with the extension of the target format")
+	placeholders := fs.String("placeholders", "", "Rewrite placeholders to the syntax: {{}}, {}, ${} or %() (default: keep them as is)")
+	allowLoss := fs.Bool("allow-loss", false, "Write the result even if frontmatter, roles or placeholders don't survive the conversion")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s convert --to=<format> [--from=<format>] [-o file|--out-dir=dir] [file...]
+
+Converts prompts between storage formats, keeping frontmatter, chat roles and
+placeholders. The result is read back with the loader of the target format and
+the conversion fails when anything was lost, unless --allow-loss is set.
+Without files the prompt is read from stdin.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *output != "" && *outDir != "" {
+		return fmt.Errorf("-o and --out-dir are mutually exclusive")
+	}
+	if len(files) > 1 && *outDir == "" {
+		return fmt.Errorf("converting several files requires --out-dir")
+	}
+	if _, ok := linter.PlaceholderStyles[*placeholders]; *placeholders != "" && !ok {
+		return fmt.Errorf("unsupported placeholder syntax %q, use {{}}, {}, ${} or %%()", *placeholders)
+	}
+
+	var writer Writer
+	if *to != "" {
+		var ok bool
+		if writer, ok = writers[*to]; !ok {
+			return fmt.Errorf("unknown target format %q, supported: %s", *to, strings.Join(writerNames(), ", "))
+		}
+	} else if *output != "" {
+		var ok bool
+		if writer, ok = writerForPath(*output); !ok {
+			return fmt.Errorf("can't detect the target format from %s, use --to", *output)
+		}
+	} else {
+		fs.Usage()
+		return fmt.Errorf("--to is required")
+	}
+
+	if len(files) == 0 {
+		input, err := readFromStdin()
+		if err != nil {
+			return err
+		}
+		return convertFile("stdin", "", []byte(input), *from, writer, *placeholders, *output, *allowLoss)
+	}
+	for _, file := range files {
+		input, err := readFromFile(file)
+		if err != nil {
+			return err
+		}
+		target := *output
+		if *outDir != "" {
+			base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			target = filepath.Join(*outDir, base+writer.Extension())
+		}
+		if err := convertFile(file, file, []byte(input), *from, writer, *placeholders, target, *allowLoss); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertFile converts one input and writes the result to the target file or stdout when target is empty
+func convertFile(name, path string, data []byte, from string, writer Writer, placeholders, target string, allowLoss bool) error {
+	doc, err := loadDocument(path, data, from)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	converted, losses, err := convertDocument(doc, writer, placeholders)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if len(losses) > 0 {
+		if !allowLoss {
+			return fmt.Errorf("%s: converting %s to %s loses %s, use --allow-loss to convert anyway",
+				name, doc.Format, writer.Name(), strings.Join(losses, "; "))
+		}
+		printProgress(fmt.Sprintf("%s: converting %s to %s lost %s", name, doc.Format, writer.Name(), strings.Join(losses, "; ")))
+	}
+
+	if syntax, ok := templatePlaceholderSyntax[writer.Name()]; ok {
+		for _, placeholder := range linter.ParsePrompt(string(converted)).Placeholders {
+			if placeholder.Syntax != syntax {
+				printProgress(fmt.Sprintf("%s: %s substitutes only %s placeholders, use --placeholders=%s to rewrite %s",
+					name, writer.Name(), syntax, syntax, fmt.Sprintf(linter.PlaceholderStyles[placeholder.Syntax], placeholder.Name)))
+				break
+			}
+		}
+	}
+
+	if target == "" {
+		_, err := os.Stdout.Write(converted)
+		return err
+	}
+	if detected, err := loadDocument(target, converted, "auto"); err == nil && detected.Format != writer.Name() {
+		printProgress(fmt.Sprintf("%s is detected as %s, lint it with --input-format=%s", target, detected.Format, writer.Name()))
+	}
+	if dir := filepath.Dir(target); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(target, converted, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	printProgress(fmt.Sprintf("Converted %s (%s) to %s (%s)", name, doc.Format, target, writer.Name()))
+	return nil
+}
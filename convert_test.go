@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvertRoundTrip(t *testing.T) {
+	chat := `{"model": "gpt-4o", "temperature": 0.2, "messages": [` +
+		`{"role": "system", "content": "You are a support agent for {{product}}."}, ` +
+		`{"role": "user", "content": "Answer {question}."}]}`
+	markdown := "---\ntitle: Support\n---\nYou help with {{product}}.\n"
+	tests := []struct {
+		name   string
+		path   string
+		source string
+		to     string
+		want   []string
+	}{
+		{"chat to chat-json", "a.json", chat, "chat-json", nil},
+		{"chat to chat-yaml", "a.json", chat, "chat-yaml", nil},
+		{"chat to prompty", "a.json", chat, "prompty", nil},
+		{"chat to dotprompt", "a.json", chat, "dotprompt", nil},
+		{"chat to markdown", "a.json", chat, "markdown", []string{"message roles (system, user became system)"}},
+		{"chat to text", "a.json", chat, "text", []string{"frontmatter fields model, temperature", "message roles (system, user became system)"}},
+		{"markdown to chat-json", "a.md", markdown, "chat-json", nil},
+		{"markdown to chat-yaml", "a.md", markdown, "chat-yaml", nil},
+		{"markdown to prompty", "a.md", markdown, "prompty", nil},
+		{"markdown to dotprompt", "a.md", markdown, "dotprompt", nil},
+		{"markdown to text", "a.md", markdown, "text", []string{"frontmatter fields title"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := loadDocument(tt.path, []byte(tt.source), "auto")
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, losses, err := convertDocument(doc, writers[tt.to], "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(losses, tt.want) {
+				t.Errorf("losses = %q, want %q", losses, tt.want)
+			}
+			// The output converts back to the same document
+			reloaded, err := loadDocument("", output, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, again, err := convertDocument(reloaded, writers[tt.to], ""); err != nil || len(again) > 0 {
+				t.Errorf("second conversion to %s lost %q, error %v", tt.to, again, err)
+			}
+		})
+	}
+}
+
+func TestConvertPlaceholders(t *testing.T) {
+	doc, err := loadDocument("a.json", []byte(`{"messages": [{"role": "user", "content": "Answer {question} about ${product}."}]}`), "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, losses, err := convertDocument(doc, writers["dotprompt"], "{{}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(losses) > 0 {
+		t.Errorf("losses = %q, want none", losses)
+	}
+	if !strings.Contains(string(output), "Answer {{question}} about {{product}}.") {
+		t.Errorf("placeholders are not rewritten:\n%s", output)
+	}
+}
+
+func TestConversionLosses(t *testing.T) {
+	source := &Document{
+		Frontmatter: map[string]interface{}{"model": "gpt-4o", "temperature": 1},
+		Messages: []ChatMessage{
+			{Role: "system", Content: "You help with {{product}}."},
+			{Role: "user", Content: "Answer {{question}}."},
+		},
+	}
+	tests := []struct {
+		name      string
+		converted *Document
+		want      []string
+	}{
+		{
+			name: "nothing lost, numbers compare by value",
+			converted: &Document{
+				Frontmatter: map[string]interface{}{"model": "gpt-4o", "temperature": 1.0, "extra": true},
+				Messages:    []ChatMessage{{Role: "system", Content: "You help with {{product}}.\n"}, {Role: "user", Content: "Answer {{question}}."}},
+			},
+		},
+		{
+			name: "frontmatter field changed and dropped",
+			converted: &Document{
+				Frontmatter: map[string]interface{}{"model": "gpt-4o-mini"},
+				Messages:    source.Messages,
+			},
+			want: []string{"frontmatter fields model, temperature"},
+		},
+		{
+			name: "message content changed",
+			converted: &Document{
+				Frontmatter: source.Frontmatter,
+				Messages:    []ChatMessage{source.Messages[0], {Role: "user", Content: "Answer {{question}} briefly."}},
+			},
+			want: []string{"content of message 2 (user)"},
+		},
+		{
+			name: "placeholder renamed",
+			converted: &Document{
+				Frontmatter: source.Frontmatter,
+				Messages:    []ChatMessage{{Role: "system", Content: "You help with {{product_name}}."}, source.Messages[1]},
+			},
+			want: []string{"content of message 1 (system)", "placeholders (product, question became product_name, question)"},
+		},
+		{
+			name:      "roles merged into the text",
+			converted: &Document{Frontmatter: source.Frontmatter, Text: "system:\nYou help with {{product}}.\n\nuser:\nAnswer {{question}}."},
+			want:      []string{"message roles (system, user became system)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conversionLosses(source, tt.converted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conversionLosses() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriterForPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"out/prompt.md", "markdown"},
+		{"prompt.JSON", "chat-json"},
+		{"prompt.prompty", "prompty"},
+		{"prompt.prompt", "dotprompt"},
+		{"prompt.yaml", "chat-yaml"},
+		{"prompt.txt", "text"},
+		{"prompt.html", ""},
+	}
+	for _, tt := range tests {
+		writer, ok := writerForPath(tt.path)
+		got := ""
+		if ok {
+			got = writer.Name()
+		}
+		if got != tt.want {
+			t.Errorf("writerForPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultDominantShare is the share of files a rule must trigger in to be reported as dominant
+const defaultDominantShare = 0.8
+
+// CoverageReport tells how often every rule triggers across a prompt corpus
+type CoverageReport struct {
+	Root           string         `json:"root"`
+	Files          int            `json:"files"`
+	Issues         int            `json:"issues"`
+	Rules          []RuleCoverage `json:"rules"`
+	NeverTriggered []string       `json:"neverTriggered"`
+	Dominant       []string       `json:"dominant"`
+}
+
+// RuleCoverage counts the files a rule was checked and triggered in and the issues it reported
+type RuleCoverage struct {
+	Rule string `json:"rule"`
+	// Source is "rule" for the rule set, "analyzer" for analyzers and "adhoc" for names reported by the LLM outside the rule set
+	Source         string  `json:"source"`
+	CheckedFiles   int     `json:"checkedFiles"`
+	TriggeredFiles int     `json:"triggeredFiles"`
+	Issues         int     `json:"issues"`
+	Share          float64 `json:"share"`
+}
+
+// ruleCoverageCounter collects coverage by lowercased rule name
+type ruleCoverageCounter struct {
+	order []string
+	rules map[string]*RuleCoverage
+}
+
+// entry returns the counters of the rule, adding it on first use
+func (c *ruleCoverageCounter) entry(name, source string) *RuleCoverage {
+	key := strings.ToLower(name)
+	if rule, ok := c.rules[key]; ok {
+		return rule
+	}
+	rule := &RuleCoverage{Rule: name, Source: source}
+	c.rules[key] = rule
+	c.order = append(c.order, key)
+	return rule
+}
+
+// add records the rules checked in a file and the issues they reported
+func (c *ruleCoverageCounter) add(rules *Rules, issues []Issue) {
+	for _, rule := range rules.Active() {
+		c.entry(rule.Name, "rule").CheckedFiles++
+	}
+	for _, name := range analyzerNames() {
+		c.entry(name, "analyzer").CheckedFiles++
+	}
+	triggered := map[string]bool{}
+	for _, issue := range issues {
+		rule := c.entry(issue.RuleName, "adhoc")
+		rule.Issues++
+		if key := strings.ToLower(issue.RuleName); !triggered[key] {
+			triggered[key] = true
+			rule.TriggeredFiles++
+			if rule.Source == "adhoc" && rule.CheckedFiles < rule.TriggeredFiles {
+				rule.CheckedFiles = rule.TriggeredFiles
+			}
+		}
+	}
+}
+
+// report sorts the rules by issues and lists rules that never trigger and rules that trigger in most files
+func (c *ruleCoverageCounter) report(root string, files int, dominantShare float64) CoverageReport {
+	report := CoverageReport{Root: root, Files: files, Rules: []RuleCoverage{}, NeverTriggered: []string{}, Dominant: []string{}}
+	for _, key := range c.order {
+		report.Issues += c.rules[key].Issues
+	}
+	for _, key := range c.order {
+		rule := *c.rules[key]
+		if report.Issues > 0 {
+			rule.Share = float64(rule.Issues) / float64(report.Issues)
+		}
+		report.Rules = append(report.Rules, rule)
+	}
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		if report.Rules[i].Issues != report.Rules[j].Issues {
+			return report.Rules[i].Issues > report.Rules[j].Issues
+		}
+		return report.Rules[i].Rule < report.Rules[j].Rule
+	})
+	for _, rule := range report.Rules {
+		switch {
+		case rule.TriggeredFiles == 0:
+			report.NeverTriggered = append(report.NeverTriggered, rule.Rule)
+		case rule.CheckedFiles > 0 && float64(rule.TriggeredFiles) >= dominantShare*float64(rule.CheckedFiles):
+			report.Dominant = append(report.Dominant, rule.Rule)
+		}
+	}
+	sort.Strings(report.NeverTriggered)
+	return report
+}
+
+// FormatCoverageReport formats the coverage for humans
+func FormatCoverageReport(report CoverageReport, dominantShare float64) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Rule coverage of %s: %d prompts, %d issues\n\n", report.Root, report.Files, report.Issues))
+	sb.WriteString(fmt.Sprintf("%-36s %-9s %9s %7s %6s\n", "Rule", "Source", "Files", "Issues", "Share"))
+	for _, rule := range report.Rules {
+		sb.WriteString(fmt.Sprintf("%-36s %-9s %9s %7d %5.0f%%\n", rule.Rule, rule.Source,
+			fmt.Sprintf("%d/%d", rule.TriggeredFiles, rule.CheckedFiles), rule.Issues, rule.Share*100))
+	}
+	sb.WriteString(fmt.Sprintf("\nNever triggered (%d): ", len(report.NeverTriggered)))
+	if len(report.NeverTriggered) == 0 {
+		sb.WriteString("none")
+	}
+	sb.WriteString(strings.Join(report.NeverTriggered, ", ") + "\n")
+	sb.WriteString(fmt.Sprintf("Dominant, in at least %.0f%% of files (%d): ", dominantShare*100, len(report.Dominant)))
+	if len(report.Dominant) == 0 {
+		sb.WriteString("none")
+	}
+	sb.WriteString(strings.Join(report.Dominant, ", ") + "\n")
+	return sb.String()
+}
+
+// runRulesCoverageCommand implements `promptlint rules coverage <dir>`
+func runRulesCoverageCommand(args []string) error {
+	fs := flag.NewFlagSet("rules coverage", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	format := fs.String("format", "text", "Output format: text, json")
+	extensions := fs.String("ext", defaultInventoryExtensions, "Comma-separated extensions of prompt files")
+	dominant := fs.Float64("dominant", defaultDominantShare, "Share of checked files from which a rule is reported as dominant")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s rules coverage [--format=text|json] [--rules=pack.yaml] [--dominant=0.8] <dir>
+
+Lints every prompt of the directory and reports how many files each rule and
+analyzer triggered in, which rules never trigger and which dominate, to find
+dead or miscalibrated rules in custom rule packs.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	dirs, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(dirs) != 1 {
+		fs.Usage()
+		return fmt.Errorf("exactly one directory is required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	if *dominant <= 0 || *dominant > 1 {
+		return fmt.Errorf("--dominant must be greater than 0 and at most 1")
+	}
+	root := dirs[0]
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	files, err := scanPrompts(root, parseExtensions(*extensions))
+	if err != nil {
+		return err
+	}
+
+	counter := &ruleCoverageCounter{rules: map[string]*RuleCoverage{}}
+	linted := 0
+	for _, file := range files {
+		_, doc, ok, err := loadPromptFile(file)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		fileRules, err := rulesForPath(rules, file)
+		if err != nil {
+			return err
+		}
+		if err := validateStaticRules(fileRules); err != nil {
+			return err
+		}
+		if err := loadAnalyzerSettings(file); err != nil {
+			return err
+		}
+		printProgress(fmt.Sprintf("Checking %s", filepath.ToSlash(file)))
+		issues, err := checkDocumentWithLLM(doc, fileRules, &config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		counter.add(fileRules, issues)
+		linted++
+	}
+	if linted == 0 {
+		return fmt.Errorf("no prompts found in %s", root)
+	}
+	printHeuristicNotice(&config)
+
+	report := counter.report(root, linted, *dominant)
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode coverage: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Print(FormatCoverageReport(report, *dominant))
+	return nil
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultHistoryFile  = ".promptlint/history.jsonl"
+	defaultCronInterval = 24 * time.Hour
+)
+
+// CatalogPrompt is a registered prompt of a catalog
+type CatalogPrompt struct {
+	// Name identifies the prompt in history and alerts
+	Name string `yaml:"name"`
+	// Source is a file path, an http(s) URL or a prompt store reference like langfuse://name@production
+	Source string `yaml:"source"`
+	// Headers are sent with HTTP requests, e.g. for authorization of a prompt store API
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Field is a dot-separated path to the prompt in a JSON response
+	Field string `yaml:"field,omitempty"`
+	// Format overrides the input format detection
+	Format string `yaml:"format,omitempty"`
+}
+
+// Catalog is the content of a catalog file for scheduled linting
+type Catalog struct {
+	// Interval between runs, e.g. "6h" (default 24h)
+	Interval string `yaml:"interval,omitempty"`
+	// History is the path of the JSONL file with results of previous runs
+	History string `yaml:"history,omitempty"`
+	// Webhook receives a JSON POST request for every regression
+	Webhook string          `yaml:"webhook,omitempty"`
+	Prompts []CatalogPrompt `yaml:"prompts"`
+}
+
+// HistoryEntry is the result of linting one catalog prompt in one run
+type HistoryEntry struct {
+	Prompt       string    `json:"prompt"`
+	CheckedAt    time.Time `json:"checkedAt"`
+	Model        string    `json:"model,omitempty"`
+	Issues       int       `json:"issues"`
+	Fingerprints []string  `json:"fingerprints"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Regression describes issues that appeared since the previous run of a prompt
+type Regression struct {
+	Prompt    string    `json:"prompt"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Previous  int       `json:"previousIssues"`
+	Current   int       `json:"currentIssues"`
+	NewIssues []Issue   `json:"newIssues"`
+}
+
+// loadCatalog reads and validates a catalog file
+func loadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	var catalog Catalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("error parsing catalog %s: %w", path, err)
+	}
+	if len(catalog.Prompts) == 0 {
+		return nil, fmt.Errorf("catalog %s has no prompts", path)
+	}
+
+	// Relative file sources are resolved against the catalog directory
+	base := filepath.Dir(path)
+	for i, prompt := range catalog.Prompts {
+		if prompt.Source == "" {
+			return nil, fmt.Errorf("catalog prompt %d has no source", i)
+		}
+		if prompt.Name == "" {
+			catalog.Prompts[i].Name = prompt.Source
+		}
+		if !strings.Contains(prompt.Source, "://") && !filepath.IsAbs(prompt.Source) {
+			catalog.Prompts[i].Source = filepath.Join(base, prompt.Source)
+		}
+	}
+	return &catalog, nil
+}
+
+// fetchPrompt reads the prompt of a catalog entry from a file or an HTTP endpoint
+func fetchPrompt(ctx context.Context, prompt CatalogPrompt) ([]byte, error) {
+	if !strings.HasPrefix(prompt.Source, "http://") && !strings.HasPrefix(prompt.Source, "https://") {
+		text, err := readFromFile(prompt.Source)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(text), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", prompt.Source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range prompt.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", prompt.Source, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxInputSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned status %d", prompt.Source, resp.StatusCode)
+	}
+	if err := checkInputSize(int64(len(body))); err != nil {
+		return nil, err
+	}
+	if prompt.Field == "" {
+		return body, nil
+	}
+	return extractJSONField(body, prompt.Field)
+}
+
+// extractJSONField returns the string at a dot-separated path of a JSON document
+func extractJSONField(data []byte, field string) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	for _, key := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %q not found in response", field)
+		}
+		if value, ok = object[key]; !ok {
+			return nil, fmt.Errorf("field %q not found in response", field)
+		}
+	}
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("field %q is not a string", field)
+	}
+	return []byte(text), nil
+}
+
+// loadHistory returns the latest successful entry of every prompt
+func loadHistory(path string) (map[string]HistoryEntry, error) {
+	latest := map[string]HistoryEntry{}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return latest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("error parsing history %s: %w", path, err)
+		}
+		if entry.Error == "" {
+			latest[entry.Prompt] = entry
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return latest, nil
+}
+
+// appendHistory appends entries to the history file
+func appendHistory(path string, entries []HistoryEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("history serialization error: %w", err)
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write history: %w", err)
+		}
+	}
+	return nil
+}
+
+// findRegression returns issues whose fingerprints were not reported by the previous run
+func findRegression(previous, current HistoryEntry, issues []Issue) *Regression {
+	known := map[string]bool{}
+	for _, fingerprint := range previous.Fingerprints {
+		known[fingerprint] = true
+	}
+
+	var newIssues []Issue
+	for _, issue := range issues {
+		if !known[issue.Fingerprint] {
+			newIssues = append(newIssues, issue)
+		}
+	}
+	if len(newIssues) == 0 {
+		return nil
+	}
+	return &Regression{
+		Prompt:    current.Prompt,
+		CheckedAt: current.CheckedAt,
+		Previous:  previous.Issues,
+		Current:   current.Issues,
+		NewIssues: newIssues,
+	}
+}
+
+// sendAlert posts a regression to the webhook
+func sendAlert(ctx context.Context, webhook string, regression *Regression) error {
+	data, err := json.Marshal(regression)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// lintCatalog lints every catalog prompt once, records history and reports regressions
+func lintCatalog(ctx context.Context, catalog *Catalog, historyPath string, rules *Rules, config *LLMConfig) (int, error) {
+	previous, err := loadHistory(historyPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var entries []HistoryEntry
+	regressions := 0
+	for _, prompt := range catalog.Prompts {
+		if ctx.Err() != nil {
+			break
+		}
+		entry := HistoryEntry{Prompt: prompt.Name, CheckedAt: time.Now().UTC(), Model: config.ModelName, Fingerprints: []string{}}
+		issues, err := lintCatalogPrompt(ctx, prompt, rules, config)
+		if err != nil {
+			entry.Error = err.Error()
+			entries = append(entries, entry)
+			printProgress(fmt.Sprintf("%s: %v", prompt.Name, err))
+			continue
+		}
+		entry.Issues = len(issues)
+		for _, issue := range issues {
+			entry.Fingerprints = append(entry.Fingerprints, issue.Fingerprint)
+		}
+		entries = append(entries, entry)
+
+		last, ok := previous[prompt.Name]
+		if !ok {
+			printProgress(fmt.Sprintf("%s: %d issues (first run)", prompt.Name, len(issues)))
+			continue
+		}
+		regression := findRegression(last, entry, issues)
+		if regression == nil {
+			printProgress(fmt.Sprintf("%s: %d issues, no regressions", prompt.Name, len(issues)))
+			continue
+		}
+
+		regressions++
+		fmt.Printf("Regression in %s: %d new issues (was %d, now %d)\n", prompt.Name, len(regression.NewIssues), regression.Previous, regression.Current)
+		for _, issue := range regression.NewIssues {
+			fmt.Printf("  - %s [%s]: %s\n", issue.RuleName, issue.Fingerprint, issue.Description)
+		}
+		if catalog.Webhook != "" {
+			if err := sendAlert(ctx, os.ExpandEnv(catalog.Webhook), regression); err != nil {
+				printProgress(fmt.Sprintf("Failed to send alert for %s: %v", prompt.Name, err))
+			}
+		}
+	}
+
+	if err := appendHistory(historyPath, entries); err != nil {
+		return regressions, err
+	}
+	return regressions, nil
+}
+
+// lintCatalogPrompt fetches and lints a single catalog prompt
+func lintCatalogPrompt(ctx context.Context, prompt CatalogPrompt, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	if ref, ok := parsePromptRef(prompt.Source); ok {
+		doc, err := fetchFromStore(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		return checkDocumentWithLLM(doc, rules, config)
+	}
+
+	data, err := fetchPrompt(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	text, err := normalizeInput(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := loadDocument(prompt.Source, []byte(text), prompt.Format)
+	if err != nil {
+		return nil, err
+	}
+	promptRules := rules
+	if !strings.Contains(prompt.Source, "://") {
+		if promptRules, err = rulesForPath(rules, prompt.Source); err != nil {
+			return nil, err
+		}
+	}
+	return checkDocumentWithLLM(doc, promptRules, config)
+}
+
+// runCronCommand implements `promptlint cron --catalog=catalog.yaml`
+func runCronCommand(args []string) error {
+	fs := flag.NewFlagSet("cron", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	catalogPath := fs.String("catalog", "", "Path to the catalog file with registered prompts")
+	interval := fs.String("interval", "", "Interval between runs, overrides the catalog value (default 24h)")
+	historyPath := fs.String("history", "", "Path to the history file (default "+defaultHistoryFile+")")
+	once := fs.Bool("once", false, "Run once and exit with code 1 on regressions, e.g. from system cron or CI")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s cron --catalog=catalog.yaml [--once]\n\nOptions:\n", appName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *catalogPath == "" {
+		fs.Usage()
+		return fmt.Errorf("--catalog is required")
+	}
+
+	catalog, err := loadCatalog(*catalogPath)
+	if err != nil {
+		return err
+	}
+	// Alerts carry the snippets of the new issues
+	if catalog.Webhook != "" {
+		if err := checkWebhook("the alert webhook of the catalog"); err != nil {
+			return err
+		}
+	}
+	if *interval != "" {
+		catalog.Interval = *interval
+	}
+	period := defaultCronInterval
+	if catalog.Interval != "" {
+		if period, err = time.ParseDuration(catalog.Interval); err != nil || period <= 0 {
+			return fmt.Errorf("invalid interval %q", catalog.Interval)
+		}
+	}
+	if *historyPath != "" {
+		catalog.History = *historyPath
+	}
+	if catalog.History == "" {
+		catalog.History = defaultHistoryFile
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		printProgress(fmt.Sprintf("Linting %d catalog prompts", len(catalog.Prompts)))
+		regressions, err := lintCatalog(ctx, catalog, catalog.History, rules, &config)
+		if err != nil {
+			return err
+		}
+		if *once {
+			if regressions > 0 {
+				return fmt.Errorf("found regressions in %d prompts", regressions)
+			}
+			return nil
+		}
+
+		printProgress(fmt.Sprintf("Next run in %s", period))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(period):
+		}
+	}
+}
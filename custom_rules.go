@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// stringsFlag collects the values of a repeatable flag
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// CustomRulesFile is a user rules file in the format of prompt_rules.yaml
+type CustomRulesFile struct {
+	// Replace discards the embedded rules and rules of previous files instead of merging into them
+	Replace     bool         `yaml:"replace,omitempty"`
+	Version     string       `yaml:"version,omitempty"`
+	PromptRules []PromptRule `yaml:"prompt_rules"`
+}
+
+// loadCustomRulesFile reads a rules file, new rules need a description while overrides only need a name
+func loadCustomRulesFile(path string, base *Rules) (*CustomRulesFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	var file CustomRulesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing rules file %s: %w", path, err)
+	}
+	if len(file.PromptRules) == 0 {
+		return nil, fmt.Errorf("rules file %s has no prompt_rules", path)
+	}
+	for i, rule := range file.PromptRules {
+		if err := checkCustomRule(rule, i, base, file.Replace); err != nil {
+			return nil, fmt.Errorf("rules file %s: %w", path, err)
+		}
+	}
+	return &file, nil
+}
+
+// checkCustomRule validates the i-th user rule, new rules need a description while overrides only need a name.
+// Every rule is new when the user rules replace the base rules.
+func checkCustomRule(rule PromptRule, i int, base *Rules, replace bool) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("rule %d has no name", i+1)
+	}
+	if strings.TrimSpace(rule.Rule) == "" && (replace || base.FindExact(rule.Name) == nil) {
+		return fmt.Errorf("new rule %q has no rule description", rule.Name)
+	}
+	if err := checkDocsURL(rule.DocsURL); err != nil {
+		return fmt.Errorf("rule %q: %w", rule.Name, err)
+	}
+	return nil
+}
+
+// mergeRules validates the user rules and merges them into a copy of the base rules like a rules file
+func mergeRules(base *Rules, rules []PromptRule) (*Rules, error) {
+	result := &Rules{Version: base.Version, PromptRules: append([]PromptRule(nil), base.PromptRules...)}
+	for i, rule := range rules {
+		if err := checkCustomRule(rule, i, result, false); err != nil {
+			return nil, err
+		}
+		result.PromptRules = overrideRule(result.PromptRules, rule)
+	}
+	return policy.enforceRules(result), nil
+}
+
+// applyCustomRules merges the rules files in order into a copy of the base rules
+func applyCustomRules(base *Rules, paths []string) (*Rules, error) {
+	if len(paths) == 0 {
+		return base, nil
+	}
+	result := &Rules{Version: base.Version, PromptRules: append([]PromptRule(nil), base.PromptRules...)}
+	for _, path := range paths {
+		file, err := loadCustomRulesFile(path, result)
+		if err != nil {
+			return nil, err
+		}
+		if file.Replace {
+			result.PromptRules = nil
+			if file.Version != "" {
+				result.Version = file.Version
+			}
+		}
+		for _, rule := range file.PromptRules {
+			result.PromptRules = overrideRule(result.PromptRules, rule)
+		}
+		action := "Merged"
+		if file.Replace {
+			action = "Replaced rules with"
+		}
+		printProgress(fmt.Sprintf("%s %d rules from %s", action, len(file.PromptRules), path))
+	}
+	return policy.enforceRules(result), nil
+}
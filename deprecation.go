@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleMigration is a reference to a deprecated rule, To is empty when the rule has no replacement
+type ruleMigration struct {
+	Field string
+	From  string
+	To    string
+}
+
+// String describes the migration for warnings
+func (m ruleMigration) String() string {
+	if m.To == "" {
+		return fmt.Sprintf("%s references deprecated rule %q, it will be removed", m.Field, m.From)
+	}
+	return fmt.Sprintf("%s references deprecated rule %q, replaced by %q", m.Field, m.From, m.To)
+}
+
+// migrateName returns the migration of a rule reference, ok is false for current rules
+func migrateName(rules *Rules, field, name string) (ruleMigration, bool) {
+	replacement, deprecated := rules.Replacement(name)
+	if !deprecated {
+		return ruleMigration{}, false
+	}
+	migration := ruleMigration{Field: field, From: name}
+	if !strings.EqualFold(replacement, name) {
+		migration.To = replacement
+	}
+	return migration, true
+}
+
+// migrateRuleNames rewrites references to deprecated rules in the configuration to their replacements
+func migrateRuleNames(config *ProjectConfig, rules *Rules) []ruleMigration {
+	var migrations []ruleMigration
+	migrate := func(field string, name *string) {
+		if migration, ok := migrateName(rules, field, *name); ok {
+			migrations = append(migrations, migration)
+			if migration.To != "" {
+				*name = migration.To
+			}
+		}
+	}
+	for i := range config.Disable {
+		migrate("disable", &config.Disable[i])
+	}
+	for i := range config.Enable {
+		migrate("enable", &config.Enable[i])
+	}
+	for i := range config.EnableOnly {
+		migrate("enable_only", &config.EnableOnly[i])
+	}
+	for i := range config.Rules {
+		migrate("rules", &config.Rules[i].Name)
+	}
+	return migrations
+}
+
+// yamlRename is a scalar of a YAML file to replace with a new value
+type yamlRename struct {
+	Node  *yaml.Node
+	Value string
+}
+
+// ruleNameNode is a scalar holding a rule name and the top-level field it belongs to
+type ruleNameNode struct {
+	Field string
+	Node  *yaml.Node
+}
+
+// ruleNameNodes returns scalars holding rule names in document order: disable/enable/enable_only items and names of rules
+// of a configuration, rule fields of dismissals
+func ruleNameNodes(root *yaml.Node) []ruleNameNode {
+	var nodes []ruleNameNode
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nodes
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range value.Content {
+			switch {
+			case (key == "disable" || key == "enable" || key == "enable_only") && item.Kind == yaml.ScalarNode:
+				nodes = append(nodes, ruleNameNode{Field: key, Node: item})
+			case (key == "rules" || key == "dismissals") && item.Kind == yaml.MappingNode:
+				field := "name"
+				if key == "dismissals" {
+					field = "rule"
+				}
+				for j := 0; j+1 < len(item.Content); j += 2 {
+					if item.Content[j].Value == field && item.Content[j+1].Kind == yaml.ScalarNode {
+						nodes = append(nodes, ruleNameNode{Field: key, Node: item.Content[j+1]})
+					}
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+// yamlScalar returns the YAML representation of the value in the style of the original node
+func yamlScalar(value string, style yaml.Style) string {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		return strconv.Quote(value)
+	case style&yaml.SingleQuotedStyle != 0:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case strings.ContainsAny(value, ":#{}[],&*!|>'\"%@`") || strings.TrimSpace(value) != value:
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// applyYAMLRenames replaces scalars in the source text in place, so comments and formatting are kept
+func applyYAMLRenames(source string, renames []yamlRename) (string, error) {
+	// Later scalars of a line go first, so replacements don't shift columns of the others
+	sort.Slice(renames, func(i, j int) bool {
+		a, b := renames[i].Node, renames[j].Node
+		return a.Line < b.Line || (a.Line == b.Line && a.Column > b.Column)
+	})
+	lines := strings.SplitAfter(source, "\n")
+	for _, rename := range renames {
+		node := rename.Node
+		if node.Line < 1 || node.Line > len(lines) {
+			return "", fmt.Errorf("line %d is out of range", node.Line)
+		}
+		line := lines[node.Line-1]
+		start := node.Column - 1
+		old := yamlScalar(node.Value, node.Style)
+		if start < 0 || start > len(line) || !strings.HasPrefix(line[start:], old) {
+			return "", fmt.Errorf("line %d: can't locate %q", node.Line, node.Value)
+		}
+		lines[node.Line-1] = line[:start] + yamlScalar(rename.Value, node.Style) + line[start+len(old):]
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// migrateYAMLFile rewrites rule references of a configuration or dismissals file, the file is written only with write
+func migrateYAMLFile(path string, rules *Rules, write bool) ([]ruleMigration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	var migrations []ruleMigration
+	var renames []yamlRename
+	for _, n := range ruleNameNodes(&root) {
+		migration, ok := migrateName(rules, n.Field, n.Node.Value)
+		if !ok {
+			continue
+		}
+		migrations = append(migrations, migration)
+		if migration.To != "" {
+			renames = append(renames, yamlRename{Node: n.Node, Value: migration.To})
+		}
+	}
+	if !write || len(renames) == 0 {
+		return migrations, nil
+	}
+
+	migrated, err := applyYAMLRenames(string(data), renames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(migrated), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return migrations, nil
+}
+
+// runMigrateConfigCommand implements `promptlint migrate-config [-w] [file...]`
+func runMigrateConfigCommand(args []string) error {
+	fs := flag.NewFlagSet("migrate-config", flag.ExitOnError)
+	write := fs.Bool("w", false, "Rewrite the files instead of listing the changes")
+	dismissalsFile := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s migrate-config [-w] [file...]
+
+Replaces references to deprecated rules with their replacements in configuration
+and dismissals files. Without files, the %s files that apply to the current
+directory and the dismissals file are migrated.
+
+Options:
+`, appName, configFileName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		paths, _, err := findConfigFiles(".")
+		if err != nil {
+			return err
+		}
+		files = paths
+		if _, err := os.Stat(*dismissalsFile); err == nil {
+			files = append(files, *dismissalsFile)
+		}
+	}
+	if len(files) == 0 {
+		printProgress("No configuration files found")
+		return nil
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, file := range files {
+		migrations, err := migrateYAMLFile(file, rules, *write)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			fmt.Printf("%s: %s\n", file, migration)
+			if migration.To != "" {
+				pending++
+			}
+		}
+	}
+	switch {
+	case pending == 0:
+		fmt.Println("No references to replace")
+	case *write:
+		fmt.Printf("Replaced %d references\n", pending)
+	default:
+		fmt.Printf("%d references can be replaced, run with -w to rewrite the files\n", pending)
+	}
+	return nil
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// diffInstruction tells the LLM to evaluate only the changed lines of a prompt
+const diffInstruction = `The following is a diff of a prompt. Lines starting with "+" were added, lines starting with "-" were removed, other lines are unchanged context.
+Analyze only the added lines against the specified rules and report only issues introduced by the change. Do not report issues that exist only in unchanged or removed lines. Use text without the diff markers in originalSnippet.`
+
+// diffOpKind is the type of a line in a diff
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is a single line of a line-based diff
+type diffOp struct {
+	Kind diffOpKind
+	Line string
+}
+
+// DiffHunk is a group of changed lines with surrounding context
+type DiffHunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Ops                []diffOp
+}
+
+// diffLines computes a line diff of a and b using the longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	// Skip common prefix and suffix to keep the LCS table small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{Kind: diffEqual, Line: line})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	// lcs[i][j] is the LCS length of midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			ops = append(ops, diffOp{Kind: diffEqual, Line: midA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{Kind: diffDelete, Line: midA[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: diffInsert, Line: midB[j]})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		ops = append(ops, diffOp{Kind: diffDelete, Line: midA[i]})
+	}
+	for ; j < len(midB); j++ {
+		ops = append(ops, diffOp{Kind: diffInsert, Line: midB[j]})
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{Kind: diffEqual, Line: line})
+	}
+	return ops
+}
+
+// splitLines splits text into lines without the trailing empty line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// buildHunks groups changed lines into hunks with the given number of context lines
+func buildHunks(ops []diffOp, context int) []DiffHunk {
+	var hunks []DiffHunk
+
+	// Find ranges of operations covered by hunks, merging changes with overlapping context
+	type opRange struct{ start, end int }
+	var ranges []opRange
+	for idx, op := range ops {
+		if op.Kind == diffEqual {
+			continue
+		}
+		start := idx - context
+		if start < 0 {
+			start = 0
+		}
+		end := idx + context
+		if end > len(ops)-1 {
+			end = len(ops) - 1
+		}
+		if len(ranges) > 0 && start <= ranges[len(ranges)-1].end+1 {
+			ranges[len(ranges)-1].end = end
+			continue
+		}
+		ranges = append(ranges, opRange{start: start, end: end})
+	}
+
+	oldLine, newLine := 1, 1
+	next := 0
+	for idx, op := range ops {
+		if next < len(ranges) && idx == ranges[next].start {
+			hunks = append(hunks, DiffHunk{OldStart: oldLine, NewStart: newLine})
+		}
+		if next < len(ranges) && idx >= ranges[next].start && idx <= ranges[next].end {
+			hunk := &hunks[len(hunks)-1]
+			hunk.Ops = append(hunk.Ops, op)
+			if op.Kind != diffInsert {
+				hunk.OldLines++
+			}
+			if op.Kind != diffDelete {
+				hunk.NewLines++
+			}
+			if idx == ranges[next].end {
+				next++
+			}
+		}
+
+		if op.Kind != diffInsert {
+			oldLine++
+		}
+		if op.Kind != diffDelete {
+			newLine++
+		}
+	}
+	return hunks
+}
+
+// String renders the hunk in unified diff format
+func (h DiffHunk) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines))
+	for _, op := range h.Ops {
+		switch op.Kind {
+		case diffEqual:
+			sb.WriteString(" " + op.Line + "\n")
+		case diffDelete:
+			sb.WriteString("-" + op.Line + "\n")
+		case diffInsert:
+			sb.WriteString("+" + op.Line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// addedLine is an added line with its number in the new version
+type addedLine struct {
+	Number int
+	Text   string
+}
+
+// addedLines returns the added lines of the hunks with their line numbers in the new version
+func addedLines(hunks []DiffHunk) []addedLine {
+	var lines []addedLine
+	for _, h := range hunks {
+		number := h.NewStart
+		for _, op := range h.Ops {
+			switch op.Kind {
+			case diffInsert:
+				lines = append(lines, addedLine{Number: number, Text: op.Line})
+				number++
+			case diffEqual:
+				number++
+			}
+		}
+	}
+	return lines
+}
+
+// addedText returns all added lines joined with newlines
+func addedText(lines []addedLine) string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// normalizeSpaces collapses whitespace for loose text comparison
+func normalizeSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// stripDiffMarkers removes the "+", "-" and " " markers the judge copies from the diff into snippets. Only
+// snippets with a marker on every line and at least one added line are copied, others like indented lines
+// or Markdown bullets are returned as they are.
+func stripDiffMarkers(snippet string) string {
+	lines := strings.Split(snippet, "\n")
+	added := false
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if !strings.ContainsRune("+- ", rune(line[0])) {
+			return snippet
+		}
+		added = added || line[0] == '+'
+	}
+	if !added {
+		return snippet
+	}
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = line[1:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addedLineOf returns the new line number of the first added line holding the start of the snippet, 0 when none does
+func addedLineOf(lines []addedLine, snippet string) int {
+	for _, first := range strings.Split(snippet, "\n") {
+		if first = normalizeSpaces(first); first == "" {
+			continue
+		}
+		for _, line := range lines {
+			if normalized := normalizeSpaces(line.Text); normalized != "" && (strings.Contains(normalized, first) || strings.Contains(first, normalized)) {
+				return line.Number
+			}
+		}
+		break
+	}
+	return 0
+}
+
+// filterIntroducedIssues drops issues whose snippet doesn't come from added lines and locates the others
+// in the new version. Snippets copied with diff markers are matched and reported without them.
+func filterIntroducedIssues(issues []Issue, lines []addedLine) []Issue {
+	normalizedAdded := normalizeSpaces(addedText(lines))
+	var result []Issue
+	for _, issue := range issues {
+		if snippet := normalizeSpaces(issue.OriginalSnippet); snippet != "" && !strings.Contains(normalizedAdded, snippet) {
+			stripped := stripDiffMarkers(issue.OriginalSnippet)
+			if !strings.Contains(normalizedAdded, normalizeSpaces(stripped)) {
+				continue
+			}
+			// The fingerprint matches the issue reported by check, so dismissals apply to both
+			issue.OriginalSnippet, issue.Fingerprint = stripped, linter.Fingerprint(Issue{RuleName: issue.RuleName, OriginalSnippet: stripped})
+		}
+		if issue.OriginalSnippet != "" {
+			issue.Line, issue.Column = addedLineOf(lines, issue.OriginalSnippet), 0
+		}
+		result = append(result, issue)
+	}
+	return result
+}
+
+// introducedLocalIssues keeps the local issues of the new version on added lines. Issues of the whole prompt
+// have no line, they are introduced when the old version doesn't have them.
+func introducedLocalIssues(issues []Issue, oldText string, rules *Rules, lines []addedLine) []Issue {
+	added := map[int]bool{}
+	for _, line := range lines {
+		added[line.Number] = true
+	}
+	var old map[string]bool
+	var result []Issue
+	for _, issue := range issues {
+		if issue.Line > 0 {
+			if added[issue.Line] {
+				result = append(result, issue)
+			}
+			continue
+		}
+		if old == nil {
+			old = map[string]bool{}
+			for _, previous := range localIssues(linter.ParsePrompt(oldText), rules) {
+				old[issueKey(previous)] = true
+			}
+		}
+		if !old[issueKey(issue)] {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
+
+// lintDiff lints only the changes between the old and the new version of a prompt: the LLM sees the changed
+// hunks, static rules and analyzers the whole new version
+func lintDiff(oldText, newText string, context int, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	hunks := buildHunks(diffLines(splitLines(oldText), splitLines(newText)), context)
+	added := addedLines(hunks)
+	if strings.TrimSpace(addedText(added)) == "" {
+		printProgress("No added lines to lint")
+		return nil, nil
+	}
+
+	var issues []Issue
+	if ruleEngine != "static" && len(rules.PromptRules) > 0 {
+		var content strings.Builder
+		for _, h := range hunks {
+			content.WriteString(h.String())
+		}
+		printProgress(fmt.Sprintf("Processing %d changed hunks", len(hunks)))
+
+		judged, err := checkContentWithLLM(diffInstruction, content.String(), rules, config)
+		if err != nil {
+			return nil, err
+		}
+		issues = filterIntroducedIssues(judged, added)
+	}
+
+	model := linter.ParsePrompt(newText)
+	local := localIssues(model, rules)
+	locateIssues(local, model)
+	issues = append(issues, introducedLocalIssues(local, oldText, rules, added)...)
+	// Suppression comments of the new version apply like in check
+	return linter.ApplySuppressions(issues, model), nil
+}
+
+// gitOutput runs git with the given arguments and returns its stdout
+func gitOutput(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// stagedFile is a staged prompt file, Path is relative to the root of the repository
+type stagedFile struct {
+	Path  string
+	Added bool
+}
+
+// stagedPromptFiles returns staged added or modified files with the given extensions
+func stagedPromptFiles(extensions []string) ([]stagedFile, error) {
+	// -z keeps unusual file names unquoted: status and path are NUL-terminated fields
+	out, err := gitOutput("diff", "--cached", "--name-status", "-z", "--diff-filter=AM")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []stagedFile
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, file := fields[i], fields[i+1]
+		ext := strings.ToLower(filepath.Ext(file))
+		for _, allowed := range extensions {
+			if ext == strings.ToLower(strings.TrimSpace(allowed)) {
+				files = append(files, stagedFile{Path: file, Added: status == "A"})
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
+// stagedVersions returns the HEAD and the staged version of the file, an added file has no HEAD version
+func stagedVersions(file stagedFile) (string, string, error) {
+	var oldText string
+	if !file.Added {
+		var err error
+		if oldText, err = gitOutput("show", "HEAD:"+file.Path); err != nil {
+			return "", "", err
+		}
+	}
+	newText, err := gitOutput("show", ":"+file.Path)
+	if err != nil {
+		return "", "", err
+	}
+	return oldText, newText, nil
+}
+
+// worktreePath resolves a path relative to the root of the repository against the working directory,
+// config files and the report see the same path as for files given on the command line
+func worktreePath(root, file string) string {
+	path := filepath.Join(root, filepath.FromSlash(file))
+	// git resolves symlinks in the root, so the working directory is resolved too
+	if wd, err := os.Getwd(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(wd); err == nil {
+			wd = resolved
+		}
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+// runDiffCommand implements `promptlint diff <old> <new>` and `promptlint diff --staged`
+func runDiffCommand(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	staged := fs.Bool("staged", false, "Lint changes of staged prompt files against HEAD")
+	extensions := fs.String("ext", ".md,.txt,.prompt,.prompty", "Comma-separated extensions of prompt files for --staged")
+	contextLines := fs.Int("context", 2, "Number of unchanged context lines around changes")
+	format := fs.String("format", "text", "Output format: text, json, sarif (SARIF 2.1.0 for code scanning), github (GitHub Actions annotations), markdown (pull request comment), junit (JUnit XML for CI test views), vscode")
+	failOnFlag := fs.String("fail-on", "", "Exit with status 1 when introduced active issues reach the severity: info, warning, error")
+	dismissalsFile := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	forceColor := fs.Bool("force-color", false, "Force colored output even when stdout is not a terminal")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff [--format=text|json|sarif|github|markdown|junit|vscode] [--fail-on=error] <old> <new>\n       %s diff --staged [options]\n\nOptions:\n", appName, appName)
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *staged != (len(positional) == 0) || (!*staged && len(positional) != 2) {
+		fs.Usage()
+		return fmt.Errorf("either two files or --staged are required")
+	}
+	reporter, err := lookupReporter(*format)
+	if err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+	failOn, err := parseFailOn(*failOnFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --fail-on: %w", err)
+	}
+	if *forceColor {
+		useColorForProgress = true
+	} else if *noColor {
+		useColorForProgress = false
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	dismissals, err := LoadDismissals(*dismissalsFile)
+	if err != nil {
+		return err
+	}
+
+	// Collect old/new versions of every changed prompt
+	type change struct{ name, oldText, newText string }
+	var changes []change
+	if *staged {
+		files, err := stagedPromptFiles(strings.Split(*extensions, ","))
+		if err != nil {
+			return err
+		}
+		root, err := gitOutput("rev-parse", "--show-toplevel")
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			oldText, newText, err := stagedVersions(file)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change{name: worktreePath(strings.TrimSpace(root), file.Path), oldText: oldText, newText: newText})
+		}
+		if len(changes) == 0 {
+			printProgress("No staged prompt files")
+			return nil
+		}
+	} else {
+		oldText, err := readFromFile(positional[0])
+		if err != nil {
+			return err
+		}
+		newText, err := readFromFile(positional[1])
+		if err != nil {
+			return err
+		}
+		changes = append(changes, change{name: positional[1], oldText: oldText, newText: newText})
+	}
+
+	var linted []LintedFile
+	for _, c := range changes {
+		changeRules, err := rulesForPath(rules, c.name)
+		if err != nil {
+			return err
+		}
+		if err := loadAnalyzerSettings(c.name); err != nil {
+			return fmt.Errorf("failed to load project configuration: %w", err)
+		}
+		issues, err := lintDiff(c.oldText, c.newText, *contextLines, changeRules, &config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+		applyDismissals(issues, dismissals, time.Now())
+		linted = append(linted, LintedFile{Name: c.name, Text: c.newText, Issues: issues})
+	}
+
+	run := ReportRun{Files: linted, Rules: rules, Single: len(linted) == 1, ForceColor: *forceColor, NoColor: *noColor}
+	if err := writeOutput(context.Background(), Output{Reporter: reporter, Destination: "stdout"}, run); err != nil {
+		return err
+	}
+	printHeuristicNotice(&config)
+	return checkFailOn(failOn, linted)
+}
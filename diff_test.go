@@ -0,0 +1,266 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// renderOps renders the diff like a unified diff without hunk headers
+func renderOps(ops []diffOp) string {
+	var sb strings.Builder
+	for _, op := range ops {
+		sb.WriteString([]string{" ", "-", "+"}[op.Kind] + op.Line + "\n")
+	}
+	return sb.String()
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb", "a\nb", " a\n b\n"},
+		{"empty old", "", "a\nb", "+a\n+b\n"},
+		{"empty new", "a\nb", "", "-a\n-b\n"},
+		{"insert in the middle", "a\nc", "a\nb\nc", " a\n+b\n c\n"},
+		{"replace", "a\nb\nc", "a\nx\nc", " a\n-b\n+x\n c\n"},
+		{"indented lines", "list:\n  - a\n  - b", "list:\n  - a\n    - nested\n  - b", " list:\n   - a\n+    - nested\n   - b\n"},
+		{"indentation change", "  a", "    a", "-  a\n+    a\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderOps(diffLines(splitLines(tt.a), splitLines(tt.b))); got != tt.want {
+				t.Errorf("diffLines() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildHunks(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10"
+	tests := []struct {
+		name    string
+		new     string
+		context int
+		want    []string
+	}{
+		{"no changes", old, 2, nil},
+		{"one change", "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10", 1, []string{"@@ -4,3 +4,3 @@\n 4\n-5\n+five\n 6\n"}},
+		{"change at the start", "zero\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10", 2, []string{"@@ -1,2 +1,3 @@\n+zero\n 1\n 2\n"}},
+		{"change at the end", "1\n2\n3\n4\n5\n6\n7\n8\n9", 1, []string{"@@ -9,2 +9,1 @@\n 9\n-10\n"}},
+		{"overlapping context is merged", "1\n2\n3\nfour\n5\nsix\n7\n8\n9\n10", 1, []string{"@@ -3,5 +3,5 @@\n 3\n-4\n+four\n 5\n-6\n+six\n 7\n"}},
+		{"distant changes are separate", "one\n2\n3\n4\n5\n6\n7\n8\n9\nten", 1, []string{"@@ -1,2 +1,2 @@\n-1\n+one\n 2\n", "@@ -9,2 +9,2 @@\n 9\n-10\n+ten\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, hunk := range buildHunks(diffLines(splitLines(old), splitLines(tt.new)), tt.context) {
+				got = append(got, hunk.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildHunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddedLines(t *testing.T) {
+	hunks := buildHunks(diffLines(splitLines("a\nb\nc\nd"), splitLines("a\nnew\nb\nc\nd\nlast")), 1)
+	want := []addedLine{{Number: 2, Text: "new"}, {Number: 6, Text: "last"}}
+	if got := addedLines(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("addedLines() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStripDiffMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"added line", "+Always answer in English.", "Always answer in English."},
+		{"added lines with context", " Rules:\n+- Be brief.\n+- Be polite.", "Rules:\n- Be brief.\n- Be polite."},
+		{"added indented line", "+    return nil", "    return nil"},
+		{"empty lines are kept", "+a\n\n+b", "a\n\nb"},
+		{"indented line", "    return nil", "    return nil"},
+		{"indented lines", "  - a\n  - b", "  - a\n  - b"},
+		{"markdown bullets", "- Be brief.\n- Be polite.", "- Be brief.\n- Be polite."},
+		{"plain text", "Always answer in English.", "Always answer in English."},
+		{"lines without markers", "+a\nb", "+a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripDiffMarkers(tt.snippet); got != tt.want {
+				t.Errorf("stripDiffMarkers(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterIntroducedIssues(t *testing.T) {
+	lines := []addedLine{{Number: 3, Text: "- Be brief."}, {Number: 4, Text: "  Never apologize."}}
+	issues := filterIntroducedIssues([]Issue{
+		{RuleName: "a", OriginalSnippet: "- Be brief."},
+		{RuleName: "b", OriginalSnippet: "+  Never apologize."},
+		{RuleName: "c", OriginalSnippet: "Unchanged line."},
+	}, lines)
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2: %+v", len(issues), issues)
+	}
+	if issues[0].OriginalSnippet != "- Be brief." || issues[0].Line != 3 {
+		t.Errorf("issue a = %q at line %d, want %q at line 3", issues[0].OriginalSnippet, issues[0].Line, "- Be brief.")
+	}
+	if issues[1].OriginalSnippet != "  Never apologize." || issues[1].Line != 4 {
+		t.Errorf("issue b = %q at line %d, want %q at line 4", issues[1].OriginalSnippet, issues[1].Line, "  Never apologize.")
+	}
+}
+
+func TestStagedPromptFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("prompts/old.md", "Be brief.\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	write("prompts/old.md", "Be brief.\nBe polite.\n")
+	write("prompts/new.md", "Answer in English.\n")
+	write("notes.go", "package notes\n")
+	git("add", ".")
+	t.Chdir(filepath.Join(root, "prompts"))
+
+	files, err := stagedPromptFiles([]string{".md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []stagedFile{{Path: "prompts/new.md", Added: true}, {Path: "prompts/old.md"}}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("stagedPromptFiles() = %+v, want %+v", files, want)
+	}
+
+	oldText, newText, err := stagedVersions(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldText != "Be brief.\n" || newText != "Be brief.\nBe polite.\n" {
+		t.Errorf("stagedVersions(old.md) = %q, %q", oldText, newText)
+	}
+	if oldText, _, err = stagedVersions(files[0]); err != nil || oldText != "" {
+		t.Errorf("stagedVersions(new.md) = %q, %v, want no HEAD version", oldText, err)
+	}
+	// A modified file without a HEAD version is an error, not an empty old version
+	if _, _, err := stagedVersions(stagedFile{Path: "prompts/deleted.md"}); err == nil {
+		t.Error("stagedVersions of a file missing in HEAD succeeded")
+	}
+
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := worktreePath(resolved, files[0].Path); got != "new.md" {
+		t.Errorf("worktreePath() = %q, want new.md", got)
+	}
+}
+
+func TestLintDiffSuppressions(t *testing.T) {
+	ruleSet, err := rules.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		newText string
+		want    int
+	}{
+		{"introduced negative instructions", "Be brief.\nNever do X. Do not do Y.\n", 2},
+		{"suppressed by a block", "Be brief.\n<!-- promptlint-disable Use Positive Instructions -->\nNever do X. Do not do Y.\n", 0},
+		{"suppressed on the next line", "Be brief.\n<!-- promptlint-disable-next-line use-positive-instructions -->\nNever do X. Do not do Y.\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := lintDiff("Be brief.\n", tt.newText, 2, ruleSet, &LLMConfig{Heuristic: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != tt.want {
+				t.Errorf("got %d issues, want %d: %+v", len(issues), tt.want, issues)
+			}
+		})
+	}
+}
+
+func TestLintDiffLocalIssues(t *testing.T) {
+	ruleSet, err := rules.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name             string
+		oldText, newText string
+		want             []int
+	}{
+		{"added line", "Be brief.\n", "Be brief.\nAs of 2023 we ship.\n", []int{2}},
+		{"unchanged line", "As of 2023 we ship.\n", "As of 2023 we ship.\nBe brief.\n", nil},
+		{"moved by an added line", "Be brief.\nAs of 2023 we ship.\n", "Be polite.\nBe brief.\nAs of 2023 we ship.\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := lintDiff(tt.oldText, tt.newText, 2, ruleSet, &LLMConfig{Heuristic: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lines []int
+			for _, issue := range issues {
+				if issue.RuleName == "Date and Locale Assumptions" {
+					lines = append(lines, issue.Line)
+				}
+			}
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("date issues on lines %v, want %v: %+v", lines, tt.want, issues)
+			}
+		})
+	}
+}
+
+func TestIntroducedLocalIssues(t *testing.T) {
+	lines := []addedLine{{Number: 2, Text: "Be brief."}}
+	issues := introducedLocalIssues([]Issue{
+		{RuleName: "a", Line: 2},
+		{RuleName: "b", Line: 3},
+		{RuleName: "Token Budget", Description: "over budget"},
+	}, "", nil, lines)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.RuleName)
+	}
+	// The empty old version has no issues, the prompt-wide issue is new
+	if want := []string{"a", "Token Budget"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("introducedLocalIssues() = %q, want %q", got, want)
+	}
+}
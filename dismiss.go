@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/report"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultDismissalsFile = ".promptlint-dismissals.yaml"
+	dismissalDateLayout   = "2006-01-02"
+)
+
+// Dismissal records an accepted issue together with the reason and optional expiry date
+type Dismissal struct {
+	Fingerprint string `yaml:"fingerprint"`
+	Rule        string `yaml:"rule,omitempty"`
+	Reason      string `yaml:"reason"`
+	DismissedBy string `yaml:"dismissedBy,omitempty"`
+	DismissedAt string `yaml:"dismissedAt"`
+	Expires     string `yaml:"expires,omitempty"`
+}
+
+// Dismissals is the content of the dismissals file
+type Dismissals struct {
+	Dismissals []Dismissal `yaml:"dismissals"`
+}
+
+// LoadDismissals reads the dismissals file, a missing file means no dismissals
+func LoadDismissals(path string) (*Dismissals, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Dismissals{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dismissals file: %w", err)
+	}
+
+	var dismissals Dismissals
+	if err := yaml.Unmarshal(data, &dismissals); err != nil {
+		return nil, fmt.Errorf("error parsing dismissals file %s: %w", path, err)
+	}
+	return &dismissals, nil
+}
+
+// Save writes the dismissals file
+func (d *Dismissals) Save(path string) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(d); err != nil {
+		return fmt.Errorf("dismissals serialization error: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write dismissals file: %w", err)
+	}
+	return nil
+}
+
+// expired reports whether the dismissal is past its expiry date
+func (d Dismissal) expired(now time.Time) bool {
+	if d.Expires == "" {
+		return false
+	}
+	expires, err := time.Parse(dismissalDateLayout, d.Expires)
+	if err != nil {
+		// An unparsable expiry date must not silence issues forever
+		return true
+	}
+	return !now.Before(expires.AddDate(0, 0, 1))
+}
+
+// applyDismissals marks issues covered by active dismissals.
+// Expired dismissals are reported so the waivers get revisited.
+func applyDismissals(issues []Issue, dismissals *Dismissals, now time.Time) {
+	byFingerprint := make(map[string]Dismissal, len(dismissals.Dismissals))
+	for _, d := range dismissals.Dismissals {
+		byFingerprint[d.Fingerprint] = d
+	}
+
+	for i := range issues {
+		d, ok := byFingerprint[issues[i].Fingerprint]
+		for _, alias := range issues[i].RuleAliases {
+			if ok {
+				break
+			}
+			d, ok = byFingerprint[linter.Fingerprint(Issue{RuleName: alias, OriginalSnippet: issues[i].OriginalSnippet})]
+		}
+		if !ok {
+			continue
+		}
+		if d.expired(now) {
+			printProgress(fmt.Sprintf("Dismissal of %s expired on %s, issue is active again", d.Fingerprint, d.Expires))
+			continue
+		}
+		issues[i].Dismissed = true
+		issues[i].DismissReason = d.Reason
+	}
+}
+
+// countActive returns the number of issues that are not dismissed
+func countActive(issues []Issue) int {
+	return report.Active(issues)
+}
+
+// runDismissCommand implements `promptlint dismiss <fingerprint> --reason="..."`
+func runDismissCommand(args []string) error {
+	fs := flag.NewFlagSet("dismiss", flag.ExitOnError)
+	reason := fs.String("reason", "", "Why the issue is accepted (required)")
+	expires := fs.String("expires", "", "Expiry date of the dismissal (YYYY-MM-DD)")
+	rule := fs.String("rule", "", "Name of the dismissed rule (informational)")
+	file := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s dismiss <fingerprint> --reason=\"...\" [--expires=YYYY-MM-DD]\n\nOptions:\n", appName)
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("exactly one fingerprint is required")
+	}
+	if strings.TrimSpace(*reason) == "" {
+		return fmt.Errorf("--reason is required")
+	}
+	if *expires != "" {
+		if _, err := time.Parse(dismissalDateLayout, *expires); err != nil {
+			return fmt.Errorf("invalid --expires date %q, expected YYYY-MM-DD", *expires)
+		}
+	}
+
+	dismissals, err := LoadDismissals(*file)
+	if err != nil {
+		return err
+	}
+
+	dismissal := Dismissal{
+		Fingerprint: positional[0],
+		Rule:        *rule,
+		Reason:      *reason,
+		DismissedBy: os.Getenv("USER"),
+		DismissedAt: time.Now().Format(dismissalDateLayout),
+		Expires:     *expires,
+	}
+
+	// Replace an existing dismissal of the same issue
+	replaced := false
+	for i := range dismissals.Dismissals {
+		if dismissals.Dismissals[i].Fingerprint == dismissal.Fingerprint {
+			dismissals.Dismissals[i] = dismissal
+			replaced = true
+		}
+	}
+	if !replaced {
+		dismissals.Dismissals = append(dismissals.Dismissals, dismissal)
+	}
+
+	if err := dismissals.Save(*file); err != nil {
+		return err
+	}
+	printProgress(fmt.Sprintf("Dismissed %s in %s", dismissal.Fingerprint, *file))
+	return nil
+}
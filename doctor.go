@@ -0,0 +1,306 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+)
+
+// Statuses of doctor checks
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+	doctorSkip = "skip"
+)
+
+// stateDir holds history and feedback of the current project
+const stateDir = ".promptlint"
+
+// doctorCheck is the result of one environment check, Hint tells how to fix a problem
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// cacheDir returns the user-level cache directory of promptlint
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user cache directory: %w", err)
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// maskSecret keeps only the ends of a secret for display
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:3] + "…" + secret[len(secret)-4:]
+}
+
+// checkWritable creates and removes a file in the directory, a directory created for the check is removed as well
+func checkWritable(dir string) error {
+	_, statErr := os.Stat(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if errors.Is(statErr, os.ErrNotExist) {
+		defer os.Remove(dir)
+	}
+	file, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// providerEndpointHint tells the expected endpoint of the provider
+func providerEndpointHint(provider Provider) string {
+	if provider.DefaultEndpoint() == "" {
+		return fmt.Sprintf("PROMPTLINT_API_ENDPOINT must be the URL of your %s resource", provider.Name())
+	}
+	return fmt.Sprintf("PROMPTLINT_API_ENDPOINT must be the full %s API URL, e.g. %s", provider.Name(), provider.DefaultEndpoint())
+}
+
+// probeLLM sends a minimal request that forces a tool call and interprets the answer as endpoint, key, model and tool calling checks
+func probeLLM(config LLMConfig) []doctorCheck {
+	endpoint := doctorCheck{Name: "Endpoint", Status: doctorOK, Detail: config.APIEndpoint}
+	key := doctorCheck{Name: "API key", Status: doctorOK, Detail: "accepted"}
+	model := doctorCheck{Name: "Model", Status: doctorOK, Detail: config.ModelName}
+	tools := doctorCheck{Name: "Tool calling", Status: doctorOK, Detail: "supported"}
+	skip := func(checks ...*doctorCheck) {
+		for _, check := range checks {
+			check.Status, check.Detail, check.Hint = doctorSkip, "endpoint check failed", ""
+		}
+	}
+
+	if u, err := url.Parse(config.APIEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		endpoint.Status = doctorFail
+		endpoint.Detail = fmt.Sprintf("%q is not an http(s) URL", config.APIEndpoint)
+		endpoint.Hint = providerEndpointHint(config.Provider)
+		skip(&key, &model, &tools)
+		return []doctorCheck{endpoint, key, model, tools}
+	}
+
+	req, err := config.Provider.NewRequest(&config, ToolRequest{
+		Messages: []string{"Call the ping tool."},
+		Tool: ToolSpec{
+			Name:        "ping",
+			Description: "Confirms that tool calling works",
+			Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		},
+	})
+	if err != nil {
+		endpoint.Status, endpoint.Detail = doctorFail, err.Error()
+		skip(&key, &model, &tools)
+		return []doctorCheck{endpoint, key, model, tools}
+	}
+
+	start := time.Now()
+	resp, err := (&http.Client{Timeout: config.Timeout}).Do(req)
+	if err != nil {
+		endpoint.Status, endpoint.Detail = doctorFail, "unreachable: "+err.Error()
+		endpoint.Hint = "Check the URL in PROMPTLINT_API_ENDPOINT, the network and proxy settings (HTTPS_PROXY)"
+		skip(&key, &model, &tools)
+		return []doctorCheck{endpoint, key, model, tools}
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	endpoint.Detail += fmt.Sprintf(" (HTTP %d in %s)", resp.StatusCode, time.Since(start).Round(time.Millisecond))
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200] + "…"
+	}
+	lower := strings.ToLower(message)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		key.Status, key.Detail = doctorFail, fmt.Sprintf("rejected with HTTP %d: %s", resp.StatusCode, message)
+		key.Hint = "Check " + llm.KeyHint(config.Provider) + ", it must be a valid key for this endpoint"
+		model.Status, model.Detail = doctorSkip, "API key check failed"
+		tools.Status, tools.Detail = doctorSkip, "API key check failed"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		key.Status, key.Detail = doctorWarn, "accepted, but rate limited or out of quota: "+message
+		key.Hint = "Check the billing and rate limits of the account"
+		tools.Status, tools.Detail = doctorSkip, "rate limited"
+	case resp.StatusCode == http.StatusNotFound && !strings.Contains(lower, "model"):
+		endpoint.Status, endpoint.Detail = doctorFail, endpoint.Detail+": "+message
+		endpoint.Hint = providerEndpointHint(config.Provider)
+		skip(&key, &model, &tools)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && strings.Contains(lower, "tool"):
+		tools.Status, tools.Detail = doctorFail, message
+		tools.Hint = "Set PROMPTLINT_MODEL_NAME to a model with function calling support"
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		model.Status, model.Detail = doctorFail, fmt.Sprintf("%s rejected with HTTP %d: %s", config.ModelName, resp.StatusCode, message)
+		model.Hint = "Check PROMPTLINT_MODEL_NAME, the model must be available to this key"
+		tools.Status, tools.Detail = doctorSkip, "model check failed"
+	case resp.StatusCode != http.StatusOK:
+		endpoint.Status, endpoint.Detail = doctorWarn, endpoint.Detail+": "+message
+		endpoint.Hint = "The provider has a server error, retry later"
+		key.Status, key.Detail = doctorSkip, "server error"
+		model.Status, model.Detail = doctorSkip, "server error"
+		tools.Status, tools.Detail = doctorSkip, "server error"
+	default:
+		if response, err := config.Provider.ParseResponse(body); err != nil {
+			endpoint.Status, endpoint.Detail = doctorFail, endpoint.Detail+" but the answer is not a "+config.Provider.Name()+" response: "+message
+			endpoint.Hint = providerEndpointHint(config.Provider)
+			model.Status, model.Detail = doctorSkip, "unexpected response"
+			tools.Status, tools.Detail = doctorSkip, "unexpected response"
+		} else if len(response.Calls) == 0 || response.Calls[0].Name != "ping" {
+			tools.Status, tools.Detail = doctorWarn, "the model answered without the requested tool call"
+			tools.Hint = "Results fall back to parsing JSON from text, use a model with function calling for reliable reports"
+		}
+	}
+	return []doctorCheck{endpoint, key, model, tools}
+}
+
+// runDoctorChecks validates the environment, network checks are skipped without an API key and in offline mode
+func runDoctorChecks(timeout time.Duration) []doctorCheck {
+	var checks []doctorCheck
+	if policy.active() {
+		checks = append(checks, doctorCheck{Name: "Policy", Status: doctorOK, Detail: policy.path + ": " + policy.summary()})
+	}
+
+	config, err := setupLLMConfig()
+	if err != nil {
+		checks = append(checks, doctorCheck{Name: "Configuration", Status: doctorFail, Detail: err.Error(), Hint: "Fix the LLM settings in " + configFileName})
+	} else if offline {
+		checks = append(checks, doctorCheck{Name: "Offline mode", Status: doctorOK, Detail: "network access is disabled, prompts are checked with the local heuristic judge"})
+	} else if config.Heuristic {
+		checks = append(checks, doctorCheck{
+			Name:   "API key",
+			Status: doctorWarn,
+			Detail: llm.KeyHint(config.Provider) + " is not set, only the local heuristic judge is available",
+			Hint:   "Set " + llm.KeyHint(config.Provider) + " for full LLM reviews",
+		})
+	} else {
+		config.Timeout = timeout
+		checks = append(checks, doctorCheck{Name: "Configuration", Status: doctorOK, Detail: fmt.Sprintf("provider %s, key %s, model %s", config.Provider.Name(), maskSecret(config.APIKey), config.ModelName)})
+		checks = append(checks, probeLLM(config)...)
+	}
+
+	rules := doctorCheck{Name: "Rules", Status: doctorOK}
+	if base, err := LoadRules(); err != nil {
+		rules.Status, rules.Detail = doctorFail, err.Error()
+		rules.Hint = "The built-in rules are broken, reinstall " + appName
+	} else if project, err := rulesForPath(base, ""); err != nil {
+		rules.Status, rules.Detail = doctorFail, err.Error()
+		rules.Hint = "Fix the syntax of " + configFileName
+	} else {
+		rules.Detail = fmt.Sprintf("version %s, %d rules, %d active in this directory, %d analyzers", base.Version, len(base.PromptRules), len(project.PromptRules), len(analyzerNames()))
+	}
+	checks = append(checks, rules)
+
+	// Project settings are validated by loading them like a lint run does
+	settings := doctorCheck{Name: "Project settings", Status: doctorOK, Detail: "valid"}
+	for _, load := range []func(string) error{
+		func(path string) error { _, err := loadSectionOrder(path); return err },
+		func(path string) error { _, err := loadEmojiPolicy(path); return err },
+		func(path string) error { _, err := loadToneWordsPattern(path); return err },
+		func(path string) error { _, err := loadReadingLevel(path); return err },
+		func(path string) error { _, err := loadCognitiveLoad(path); return err },
+		func(path string) error { _, err := loadTokenBudget(path); return err },
+		func(path string) error { _, err := loadFailIf(path); return err },
+		func(path string) error { _, err := loadRunSettings(filepath.Dir(path)); return err },
+		func(path string) error { _, err := configuredFiles(filepath.Dir(path)); return err },
+	} {
+		if err := load(""); err != nil {
+			settings.Status, settings.Detail = doctorFail, err.Error()
+			settings.Hint = "Fix the value in " + configFileName
+			break
+		}
+	}
+	if _, err := LoadDismissals(defaultDismissalsFile); err != nil {
+		settings.Status, settings.Detail = doctorFail, err.Error()
+		settings.Hint = "Fix or remove " + defaultDismissalsFile
+	}
+	checks = append(checks, settings)
+
+	cache := doctorCheck{Name: "Cache", Status: doctorOK}
+	if dir, err := cacheDir(); err != nil {
+		cache.Status, cache.Detail = doctorFail, err.Error()
+		cache.Hint = "Set HOME or XDG_CACHE_HOME (LocalAppData on Windows)"
+	} else if err := checkWritable(dir); err != nil {
+		cache.Status, cache.Detail = doctorFail, fmt.Sprintf("%s is not writable: %v", dir, err)
+		cache.Hint = "Fix the permissions or point XDG_CACHE_HOME to a writable directory"
+	} else {
+		cache.Detail = dir + " is writable"
+	}
+	checks = append(checks, cache)
+
+	state := doctorCheck{Name: "State directory", Status: doctorOK, Detail: stateDir + " is writable"}
+	if err := checkWritable(stateDir); err != nil {
+		state.Status, state.Detail = doctorWarn, fmt.Sprintf("%s is not writable: %v", stateDir, err)
+		state.Hint = "History of cron and --collect-feedback can't be saved, run from a writable directory"
+	}
+	checks = append(checks, state)
+
+	return checks
+}
+
+// formatDoctorChecks renders the checks, one per line with the hint below problems
+func formatDoctorChecks(checks []doctorCheck, useColor bool) string {
+	colors := map[string]string{doctorOK: colorGreen, doctorWarn: colorYellow, doctorFail: colorRed, doctorSkip: colorDim}
+	var sb strings.Builder
+	for _, check := range checks {
+		status := fmt.Sprintf("%-6s", "["+check.Status+"]")
+		if useColor {
+			status = colors[check.Status] + status + colorReset
+		}
+		sb.WriteString(fmt.Sprintf("%s %-17s %s\n", status, check.Name, check.Detail))
+		if check.Hint != "" {
+			sb.WriteString(fmt.Sprintf("       %-17s → %s\n", "", check.Hint))
+		}
+	}
+	return sb.String()
+}
+
+// runDoctorCommand implements `promptlint doctor`
+func runDoctorCommand(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	timeout := fs.Duration("timeout", 30*time.Second, "Timeout of the LLM API check")
+	forceColor := fs.Bool("force-color", false, "Force colored output even when stdout is not a terminal")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s doctor\n\nValidates the API key, endpoint, model tool calling support, rules, project configuration and cache.\n\nOptions:\n", appName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *forceColor {
+		useColorForProgress = true
+	} else if *noColor {
+		useColorForProgress = false
+	}
+
+	checks := runDoctorChecks(*timeout)
+	useColor := *forceColor || (!*noColor && isColorTerminal())
+	fmt.Print(formatDoctorChecks(checks, useColor))
+
+	failed := 0
+	for _, check := range checks {
+		if check.Status == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Embedder converts texts to vectors for similarity comparison
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// EmbeddingsConfig configures the embeddings provider independently from the chat provider
+type EmbeddingsConfig struct {
+	Provider string
+	Model    string
+	Endpoint string
+	APIKey   string
+}
+
+// setupEmbeddingsConfig reads the embeddings configuration from the environment.
+// Without an explicit provider OpenAI is used when an API key is available, otherwise the local embedder.
+func setupEmbeddingsConfig() EmbeddingsConfig {
+	config := EmbeddingsConfig{
+		Provider: os.Getenv("PROMPTLINT_EMBEDDINGS_PROVIDER"),
+		Model:    os.Getenv("PROMPTLINT_EMBEDDINGS_MODEL"),
+		Endpoint: os.Getenv("PROMPTLINT_EMBEDDINGS_ENDPOINT"),
+		APIKey:   os.Getenv("PROMPTLINT_EMBEDDINGS_API_KEY"),
+	}
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("PROMPTLINT_API_KEY")
+	}
+	if config.Provider == "" {
+		config.Provider = "local"
+		if config.APIKey != "" && !offline {
+			config.Provider = "openai"
+		}
+	}
+	return config
+}
+
+// newEmbedder creates the embedder of the configured provider
+func newEmbedder(config EmbeddingsConfig) (Embedder, error) {
+	if !strings.EqualFold(config.Provider, "local") {
+		if err := policy.checkPrivacy(config.Provider + " embeddings"); err != nil {
+			return nil, err
+		}
+	}
+	switch strings.ToLower(config.Provider) {
+	case "openai":
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("openai embeddings require PROMPTLINT_EMBEDDINGS_API_KEY or PROMPTLINT_API_KEY")
+		}
+		if config.Endpoint == "" {
+			config.Endpoint = "https://api.openai.com/v1/embeddings"
+		}
+		if config.Model == "" {
+			config.Model = "text-embedding-3-small"
+		}
+		return openAIEmbedder{config: config}, nil
+	case "ollama":
+		if config.Endpoint == "" {
+			config.Endpoint = "http://localhost:11434/api/embed"
+		}
+		if config.Model == "" {
+			config.Model = "nomic-embed-text"
+		}
+		return ollamaEmbedder{config: config}, nil
+	case "local":
+		return localEmbedder{dimensions: localEmbeddingDimensions}, nil
+	case "onnx":
+		return nil, fmt.Errorf("onnx embeddings are not supported in this build, use local, ollama or openai")
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q, supported: openai, ollama, local", config.Provider)
+	}
+}
+
+// openAIEmbedder uses the OpenAI-compatible embeddings API
+type openAIEmbedder struct {
+	config EmbeddingsConfig
+}
+
+func (e openAIEmbedder) Name() string { return "openai:" + e.config.Model }
+func (e openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	payload := map[string]interface{}{"model": e.config.Model, "input": texts}
+	if err := postJSON(ctx, e.config.Endpoint, map[string]string{"Authorization": "Bearer " + e.config.APIKey}, payload, &response); err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings response has unexpected index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("embeddings response has no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// ollamaEmbedder uses the embed API of a local Ollama server
+type ollamaEmbedder struct {
+	config EmbeddingsConfig
+}
+
+func (e ollamaEmbedder) Name() string { return "ollama:" + e.config.Model }
+func (e ollamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var response struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	payload := map[string]interface{}{"model": e.config.Model, "input": texts}
+	if err := postJSON(ctx, e.config.Endpoint, nil, payload, &response); err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	if len(response.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embeddings response has %d vectors for %d inputs", len(response.Embeddings), len(texts))
+	}
+	return response.Embeddings, nil
+}
+
+// localEmbeddingDimensions is the vector size of the local embedder
+const localEmbeddingDimensions = 512
+
+var embeddingTokenPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// localEmbedder hashes words and word bigrams into a fixed-size vector, it works offline
+// and catches near-duplicates but not paraphrases
+type localEmbedder struct {
+	dimensions int
+}
+
+func (e localEmbedder) Name() string { return "local" }
+func (e localEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, e.dimensions)
+		words := embeddingTokenPattern.FindAllString(strings.ToLower(text), -1)
+		for j, word := range words {
+			e.add(vector, word, 1)
+			if j > 0 {
+				e.add(vector, words[j-1]+" "+word, 0.5)
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// add accumulates a hashed feature with a random sign to reduce collisions bias
+func (e localEmbedder) add(vector []float64, feature string, weight float64) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum&1 == 1 {
+		weight = -weight
+	}
+	vector[(sum>>1)%uint64(e.dimensions)] += weight
+}
+
+// cosineSimilarity returns the cosine of the angle between vectors
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// SimilarPair is a pair of prompts with similar content
+type SimilarPair struct {
+	A, B       string
+	Similarity float64
+}
+
+// findSimilarPrompts returns pairs with similarity at or above the threshold, most similar first
+func findSimilarPrompts(names []string, vectors [][]float64, threshold float64) []SimilarPair {
+	var pairs []SimilarPair
+	for i := range vectors {
+		for j := i + 1; j < len(vectors); j++ {
+			if similarity := cosineSimilarity(vectors[i], vectors[j]); similarity >= threshold {
+				pairs = append(pairs, SimilarPair{A: names[i], B: names[j], Similarity: similarity})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Similarity > pairs[j].Similarity })
+	return pairs
+}
+
+// runSimilarCommand implements `promptlint similar <file>...`
+func runSimilarCommand(args []string) error {
+	fs := flag.NewFlagSet("similar", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	threshold := fs.Float64("threshold", 0.9, "Minimal cosine similarity of reported pairs (0-1)")
+	provider := fs.String("provider", "", "Embeddings provider: openai, ollama, local (default PROMPTLINT_EMBEDDINGS_PROVIDER)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s similar [--threshold=0.9] <file>...\n\nReports duplicate or near-duplicate prompts.\n\nOptions:\n", appName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) < 2 {
+		fs.Usage()
+		return fmt.Errorf("at least two prompt files are required")
+	}
+	if *threshold < 0 || *threshold > 1 {
+		return fmt.Errorf("--threshold must be between 0 and 1")
+	}
+
+	config := setupEmbeddingsConfig()
+	if *provider != "" {
+		config.Provider = *provider
+	}
+	embedder, err := newEmbedder(config)
+	if err != nil {
+		return err
+	}
+
+	texts := make([]string, 0, len(files))
+	for _, file := range files {
+		input, err := readFromFile(file)
+		if err != nil {
+			return err
+		}
+		doc, err := loadDocument(file, []byte(input), "auto")
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		texts = append(texts, doc.Text)
+	}
+
+	printProgress(fmt.Sprintf("Computing embeddings of %d prompts with %s", len(texts), embedder.Name()))
+	vectors, err := embedder.Embed(context.Background(), texts)
+	if err != nil {
+		return err
+	}
+
+	pairs := findSimilarPrompts(files, vectors, *threshold)
+	if len(pairs) == 0 {
+		fmt.Printf("No similar prompts found (threshold %.2f)\n", *threshold)
+		return nil
+	}
+	fmt.Printf("Found %d similar prompt pairs:\n", len(pairs))
+	for _, pair := range pairs {
+		fmt.Printf("  %.3f  %s  %s\n", pair.Similarity, pair.A, pair.B)
+	}
+	return nil
+}
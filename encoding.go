@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// defaultMaxInputSize is the default limit of the prompt size in bytes
+const defaultMaxInputSize = 1 << 20
+
+// maxInputSize is the maximum accepted input size, configured with --max-size
+var maxInputSize int64 = defaultMaxInputSize
+
+// parseSize parses sizes like "1048576", "512KB" or "2MB"
+func parseSize(input string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(input))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.size
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid size %q", input)
+	}
+	return n * multiplier, nil
+}
+
+// checkInputSize returns an error if the input exceeds the maximum size
+func checkInputSize(size int64) error {
+	if size > maxInputSize {
+		return fmt.Errorf("input is %d bytes, exceeds the maximum of %d bytes (use --max-size to raise the limit)", size, maxInputSize)
+	}
+	return nil
+}
+
+// inputEncoding is how the raw input was encoded, fixed prompts are written back with it
+type inputEncoding struct {
+	// Charset is "UTF-8", "UTF-16LE", "UTF-16BE" or "Windows-1252"
+	Charset string
+	BOM     bool
+	// LineEnding is the most common line ending of the input, "\n" without line breaks
+	LineEnding string
+}
+
+// normalizeInput decodes the input to UTF-8 with LF line endings, see decodeInput
+func normalizeInput(data []byte) (string, error) {
+	text, _, err := decodeInput(data)
+	return text, err
+}
+
+// decodeInput decodes the input to UTF-8 with LF line endings and returns the original encoding.
+// Supports UTF-8 with or without BOM, UTF-16 LE/BE and falls back to Windows-1252 for other 8-bit text.
+// Binary content is rejected.
+func decodeInput(data []byte) (string, inputEncoding, error) {
+	var text string
+	encoding := inputEncoding{Charset: "UTF-8"}
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		text, encoding.BOM = string(data[3:]), true
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		text, encoding = decodeUTF16(data[2:], false), inputEncoding{Charset: "UTF-16LE", BOM: true}
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		text, encoding = decodeUTF16(data[2:], true), inputEncoding{Charset: "UTF-16BE", BOM: true}
+	case looksLikeUTF16(data):
+		text, encoding.Charset = decodeUTF16(data, data[0] == 0), "UTF-16LE"
+		if data[0] == 0 {
+			encoding.Charset = "UTF-16BE"
+		}
+	default:
+		if isBinary(data) {
+			return "", encoding, fmt.Errorf("input looks like a binary file")
+		}
+		if utf8.Valid(data) {
+			text = string(data)
+		} else {
+			printProgress("Warning: input is not valid UTF-8, decoding it as Windows-1252")
+			text, encoding.Charset = decodeWindows1252(data), "Windows-1252"
+		}
+	}
+
+	if strings.ContainsRune(text, 0) {
+		return "", encoding, fmt.Errorf("input looks like a binary file")
+	}
+
+	encoding.LineEnding = dominantLineEnding(text)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return text, encoding, nil
+}
+
+// dominantLineEnding returns the most common line ending of the text, LF on ties
+func dominantLineEnding(text string) string {
+	crlf := strings.Count(text, "\r\n")
+	cr := strings.Count(text, "\r") - crlf
+	lf := strings.Count(text, "\n") - crlf
+	switch {
+	case crlf > lf && crlf >= cr:
+		return "\r\n"
+	case cr > lf && cr > crlf:
+		return "\r"
+	}
+	return "\n"
+}
+
+// encodeOutput encodes the text with LF line endings in the encoding of the input. Mixed line endings of
+// the input are written as the most common one.
+func encodeOutput(text string, encoding inputEncoding) ([]byte, error) {
+	if encoding.LineEnding != "" && encoding.LineEnding != "\n" {
+		text = strings.ReplaceAll(text, "\n", encoding.LineEnding)
+	}
+	switch encoding.Charset {
+	case "UTF-16LE", "UTF-16BE":
+		bigEndian := encoding.Charset == "UTF-16BE"
+		units := utf16.Encode([]rune(text))
+		if encoding.BOM {
+			units = append([]uint16{0xFEFF}, units...)
+		}
+		data := make([]byte, 0, 2*len(units))
+		for _, unit := range units {
+			if bigEndian {
+				data = append(data, byte(unit>>8), byte(unit))
+			} else {
+				data = append(data, byte(unit), byte(unit>>8))
+			}
+		}
+		return data, nil
+	case "Windows-1252":
+		return encodeWindows1252(text)
+	}
+	if encoding.BOM {
+		return append([]byte{0xEF, 0xBB, 0xBF}, text...), nil
+	}
+	return []byte(text), nil
+}
+
+// isBinary reports whether data contains NUL bytes or too many control characters
+func isBinary(data []byte) bool {
+	sample := data
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	control := 0
+	for _, b := range sample {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != '\v' && b != 0x1B {
+			control++
+		}
+	}
+	return len(sample) > 0 && control*10 > len(sample)
+}
+
+// looksLikeUTF16 detects BOM-less UTF-16 by the share of NUL bytes at even or odd positions
+func looksLikeUTF16(data []byte) bool {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return false
+	}
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			evenZeros++
+		}
+		if data[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	half := len(data) / 2
+	return (evenZeros*10 > half*9 && oddZeros == 0) || (oddZeros*10 > half*9 && evenZeros == 0)
+}
+
+// decodeUTF16 converts UTF-16 bytes to a UTF-8 string
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// windows1252 maps bytes 0x80-0x9F of Windows-1252 to Unicode, other bytes match Latin-1
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// decodeWindows1252 converts Windows-1252 bytes to a UTF-8 string
+func decodeWindows1252(data []byte) string {
+	var sb strings.Builder
+	for _, b := range data {
+		if b >= 0x80 && b < 0xA0 {
+			sb.WriteRune(windows1252[b-0x80])
+		} else {
+			sb.WriteRune(rune(b))
+		}
+	}
+	return sb.String()
+}
+
+// encodeWindows1252 converts a UTF-8 string to Windows-1252 bytes
+func encodeWindows1252(text string) ([]byte, error) {
+	data := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			data = append(data, byte(r))
+		default:
+			index := -1
+			for i, special := range windows1252 {
+				if special == r {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				return nil, fmt.Errorf("character %q can't be written in the Windows-1252 encoding of the input", r)
+			}
+			data = append(data, byte(0x80+index))
+		}
+	}
+	return data, nil
+}
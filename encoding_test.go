@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeOutputRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"UTF-8 with LF", []byte("Be brief.\nBe polite.\n")},
+		{"UTF-8 with CRLF", []byte("Be brief.\r\nBe polite.\r\n")},
+		{"UTF-8 with CR", []byte("Be brief.\rBe polite.\r")},
+		{"UTF-8 with BOM", []byte("\xEF\xBB\xBFBe brief.\r\n")},
+		{"UTF-16LE with BOM", []byte{0xFF, 0xFE, 'H', 0, 'i', 0, '\r', 0, '\n', 0}},
+		{"UTF-16BE without BOM", []byte{0, 'H', 0, 'i', 0, '\r', 0, '\n'}},
+		{"Windows-1252", []byte("Caf\xe9 \x93quoted\x94\r\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, encoding, err := decodeInput(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.ContainsRune(text, '\r') {
+				t.Errorf("decoded text %q has CR line endings", text)
+			}
+			got, err := encodeOutput(text, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("encodeOutput(decodeInput(%q)) = %q", tt.data, got)
+			}
+		})
+	}
+}
+
+func TestEncodeOutputFixedText(t *testing.T) {
+	_, encoding, err := decodeInput([]byte("Be brief.\r\nBe polite.\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mixed line endings are written as the most common one, added lines get it too
+	got, err := encodeOutput("Be brief.\nBe polite.\nAnswer in English.\n", encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Be brief.\r\nBe polite.\r\nAnswer in English.\r\n"; string(got) != want {
+		t.Errorf("encodeOutput() = %q, want %q", got, want)
+	}
+
+	// Windows-1252 can't encode every character a fix may add
+	if _, err := encodeOutput("Answer in 日本語", inputEncoding{Charset: "Windows-1252"}); err == nil {
+		t.Error("encodeOutput of characters outside Windows-1252 succeeded")
+	}
+}
+
+func TestDecodeInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		want     string
+		encoding inputEncoding
+		wantErr  bool
+	}{
+		{"UTF-8", []byte("Café\n"), "Café\n", inputEncoding{Charset: "UTF-8", LineEnding: "\n"}, false},
+		{"UTF-8 with BOM and CRLF", []byte("\xEF\xBB\xBFa\r\nb"), "a\nb", inputEncoding{Charset: "UTF-8", BOM: true, LineEnding: "\r\n"}, false},
+		{"UTF-16LE with BOM", []byte{0xFF, 0xFE, 'H', 0, 'i', 0}, "Hi", inputEncoding{Charset: "UTF-16LE", BOM: true, LineEnding: "\n"}, false},
+		{"UTF-16BE with BOM", []byte{0xFE, 0xFF, 0, 'H', 0, 'i'}, "Hi", inputEncoding{Charset: "UTF-16BE", BOM: true, LineEnding: "\n"}, false},
+		{"UTF-16LE without BOM", []byte{'H', 0, 'i', 0, '\r', 0}, "Hi\n", inputEncoding{Charset: "UTF-16LE", LineEnding: "\r"}, false},
+		{"UTF-16 surrogate pair", []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x00, 0xDE}, "\U0001F600", inputEncoding{Charset: "UTF-16LE", BOM: true, LineEnding: "\n"}, false},
+		{"Windows-1252", []byte("\x80 5\x85"), "€ 5…", inputEncoding{Charset: "Windows-1252", LineEnding: "\n"}, false},
+		{"empty", nil, "", inputEncoding{Charset: "UTF-8", LineEnding: "\n"}, false},
+		{"NUL bytes", []byte("a\x00b\x00c"), "", inputEncoding{}, true},
+		{"control characters", []byte("\x01\x02\x03abc"), "", inputEncoding{}, true},
+		{"UTF-16 with NUL characters", []byte{0xFF, 0xFE, 0, 0, 'a', 0}, "", inputEncoding{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, encoding, err := decodeInput(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("decodeInput(%q) = %q, want an error", tt.data, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || encoding != tt.encoding {
+				t.Errorf("decodeInput(%q) = %q, %+v, want %q, %+v", tt.data, got, encoding, tt.want, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestLooksLikeUTF16(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"little endian", []byte{'a', 0, 'b', 0}, true},
+		{"big endian", []byte{0, 'a', 0, 'b'}, true},
+		{"odd length", []byte{'a', 0, 'b', 0, 'c'}, false},
+		{"too short", []byte{'a', 0}, false},
+		{"ASCII", []byte("abcd"), false},
+		{"zeros on both sides", []byte{0, 0, 'a', 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := looksLikeUTF16(tt.data); got != tt.want {
+				t.Errorf("looksLikeUTF16(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"text with whitespace and escape sequences", []byte("a\tb\r\n\f\v\x1b[1m"), false},
+		{"NUL byte", []byte("text\x00"), true},
+		{"many control characters", []byte("ab\x01\x02"), true},
+		{"few control characters", []byte("abcdefghijklmnopqrs\x01"), false},
+		{"empty", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinary(tt.data); got != tt.want {
+				t.Errorf("isBinary(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"1048576", 1048576, false},
+		{"512KB", 512 << 10, false},
+		{" 2 mb ", 2 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"100B", 100, false},
+		{"0", 0, true},
+		{"-1KB", 0, true},
+		{"1.5MB", 0, true},
+		{"MB", 0, true},
+		{"9223372036854775807KB", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSize(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseSize(%q) = %d, %v, want %d, error %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+	if _, err := parseSize("1.5MB"); err == nil || !strings.Contains(err.Error(), `"1.5MB"`) {
+		t.Errorf("parseSize() error = %v, want the value as given", err)
+	}
+}
+
+func TestCheckInputSize(t *testing.T) {
+	limit := maxInputSize
+	maxInputSize = 10
+	t.Cleanup(func() { maxInputSize = limit })
+	if err := checkInputSize(10); err != nil {
+		t.Errorf("checkInputSize(10) = %v, want nil", err)
+	}
+	if err := checkInputSize(11); err == nil || !strings.Contains(err.Error(), "--max-size") {
+		t.Errorf("checkInputSize(11) = %v, want an error mentioning --max-size", err)
+	}
+}
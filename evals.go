@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Verdicts of graded eval examples
+const (
+	verdictViolated = "violated"
+	verdictOK       = "ok"
+)
+
+// EvalExample is a graded example in the OpenAI Evals JSONL format
+type EvalExample struct {
+	Input    []ChatMessage `json:"input"`
+	Ideal    string        `json:"ideal"`
+	Metadata EvalMetadata  `json:"metadata"`
+}
+
+// EvalMetadata describes the origin of an eval example
+type EvalMetadata struct {
+	Rule       string   `json:"rule"`
+	Prompt     string   `json:"prompt"`
+	PromptHash string   `json:"promptHash"`
+	Issues     []string `json:"issues,omitempty"`
+}
+
+// judgeInstruction asks a judge model whether the prompt violates a single rule
+func judgeInstruction(rule PromptRule) string {
+	return fmt.Sprintf("You are a prompt quality judge. Decide whether the prompt from the user violates the rule %q: %s\nAnswer with a single word: %q if the rule is violated, %q otherwise.",
+		rule.Name, rule.Rule, verdictViolated, verdictOK)
+}
+
+// buildEvalExamples grades the prompt against every rule using the reported issues as verdicts
+func buildEvalExamples(name, prompt string, rules *Rules, issues []Issue) []EvalExample {
+	violations := map[string][]string{}
+	for _, issue := range issues {
+		if issue.Dismissed {
+			continue
+		}
+		key := strings.ToLower(issue.RuleName)
+		violations[key] = append(violations[key], issue.Description)
+	}
+
+	active := rules.Active()
+	examples := make([]EvalExample, 0, len(active))
+	for _, rule := range active {
+		descriptions := violations[strings.ToLower(rule.Name)]
+		ideal := verdictOK
+		if len(descriptions) > 0 {
+			ideal = verdictViolated
+		}
+		examples = append(examples, EvalExample{
+			Input: []ChatMessage{
+				{Role: "system", Content: judgeInstruction(rule)},
+				{Role: "user", Content: prompt},
+			},
+			Ideal: ideal,
+			Metadata: EvalMetadata{
+				Rule:       rule.Name,
+				Prompt:     name,
+				PromptHash: promptHash(prompt),
+				Issues:     descriptions,
+			},
+		})
+	}
+	return examples
+}
+
+// dropIncorrectIssues removes issues that users labeled as incorrect
+func dropIncorrectIssues(issues []Issue, hash string, labels map[string]string) []Issue {
+	var result []Issue
+	for _, issue := range issues {
+		if labels[hash+"/"+issue.Fingerprint] != labelIncorrect {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
+
+// writeEvalExamples writes examples as JSON lines
+func writeEvalExamples(w io.Writer, examples []EvalExample) error {
+	encoder := json.NewEncoder(w)
+	for _, example := range examples {
+		if err := encoder.Encode(example); err != nil {
+			return fmt.Errorf("failed to write eval example: %w", err)
+		}
+	}
+	return nil
+}
+
+// runExportEvalCommand implements `promptlint export-eval <file>...`
+func runExportEvalCommand(args []string) error {
+	fs := flag.NewFlagSet("export-eval", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	output := fs.String("output", "", "Path to the JSONL dataset (default stdout)")
+	dismissalsFile := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file, dismissed issues are graded as not violated")
+	feedbackFile := fs.String("feedback", defaultFeedbackFile, "Path to the labeled issues, issues labeled incorrect are graded as not violated")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s export-eval [--output=evals.jsonl] <file>...\n\nLints the prompts and writes one graded example per prompt and rule in the OpenAI Evals format.\n\nOptions:\n", appName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one prompt file is required")
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	dismissals, err := LoadDismissals(*dismissalsFile)
+	if err != nil {
+		return err
+	}
+	labels, err := loadFeedbackLabels(*feedbackFile)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create dataset file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	total := 0
+	for _, file := range files {
+		input, err := readFromFile(file)
+		if err != nil {
+			return err
+		}
+		doc, err := loadDocument(file, []byte(input), "auto")
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		fileRules, err := rulesForPath(rules, file)
+		if err != nil {
+			return err
+		}
+		issues, err := checkDocumentWithLLM(doc, fileRules, &config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		applyDismissals(issues, dismissals, time.Now())
+		issues = dropIncorrectIssues(issues, promptHash(doc.Text), labels)
+
+		examples := buildEvalExamples(file, doc.Text, fileRules, issues)
+		if err := writeEvalExamples(w, examples); err != nil {
+			return err
+		}
+		total += len(examples)
+	}
+
+	printProgress(fmt.Sprintf("Exported %d graded examples from %d prompts", total, len(files)))
+	return nil
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMaxCombinations bounds the number of prompts instantiated from a matrix
+const defaultMaxCombinations = 64
+
+// VariableMatrix maps template variables to the values to try
+type VariableMatrix map[string][]string
+
+// UnmarshalYAML accepts a single scalar as a list of one value
+func (m *VariableMatrix) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*m = VariableMatrix{}
+	for name, value := range raw {
+		var values []string
+		if value.Kind == yaml.ScalarNode {
+			values = []string{value.Value}
+		} else if err := value.Decode(&values); err != nil {
+			return fmt.Errorf("variable %s: %w", name, err)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("variable %s has no values", name)
+		}
+		(*m)[name] = values
+	}
+	return nil
+}
+
+// LoadVariableMatrix reads a matrix file like `tone: [formal, casual]`
+func LoadVariableMatrix(path string) (VariableMatrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read matrix file: %w", err)
+	}
+	var matrix VariableMatrix
+	if err := yaml.Unmarshal(data, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse matrix file %s: %w", path, err)
+	}
+	if len(matrix) == 0 {
+		return nil, fmt.Errorf("matrix file %s defines no variables", path)
+	}
+	return matrix, nil
+}
+
+// Names returns the sorted variable names
+func (m VariableMatrix) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Size returns the number of combinations
+func (m VariableMatrix) Size() int {
+	size := 1
+	for _, values := range m {
+		size *= len(values)
+	}
+	return size
+}
+
+// Combination is one assignment of values to all variables of a matrix
+type Combination map[string]string
+
+// String returns the assignment as "name=value" pairs in name order
+func (c Combination) String() string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + c[name]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Combinations returns up to limit combinations, the last variable changes fastest
+func (m VariableMatrix) Combinations(limit int) []Combination {
+	names := m.Names()
+	indexes := make([]int, len(names))
+	var result []Combination
+	for len(result) < limit {
+		combination := Combination{}
+		for i, name := range names {
+			combination[name] = m[name][indexes[i]]
+		}
+		result = append(result, combination)
+
+		// Advance the indexes like an odometer
+		i := len(names) - 1
+		for ; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(m[names[i]]) {
+				break
+			}
+			indexes[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+	return result
+}
+
+// instantiateTemplate replaces placeholders of all syntaxes with the values of the combination, unknown placeholders are kept
+func instantiateTemplate(text string, values Combination) string {
+	for _, p := range linter.PlaceholderPatterns {
+		var sb strings.Builder
+		last := 0
+		for _, loc := range p.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			value, ok := values[text[loc[2]:loc[3]]]
+			if !ok {
+				continue
+			}
+			start, end := loc[0], loc[1]
+			if p.Syntax == "{}" {
+				start, end = loc[2]-1, loc[3]+1
+			}
+			sb.WriteString(text[last:start])
+			sb.WriteString(value)
+			last = end
+		}
+		sb.WriteString(text[last:])
+		text = sb.String()
+	}
+	return text
+}
+
+// templateSnippet puts the variable names back into a snippet of an instantiated prompt, so the same problem matches across combinations
+func templateSnippet(snippet string, values Combination) string {
+	names := make([]string, 0, len(values))
+	for name, value := range values {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	// Longer values first, so a value containing another one is replaced whole
+	sort.Slice(names, func(i, j int) bool { return len(values[names[i]]) > len(values[names[j]]) })
+	for _, name := range names {
+		snippet = strings.ReplaceAll(snippet, values[name], "{{"+name+"}}")
+	}
+	return snippet
+}
+
+// matrixIssue is an issue found in some combinations of the matrix
+type matrixIssue struct {
+	Issue        Issue
+	Combinations []int
+}
+
+// explainCombinations describes the set of combinations by the values they share, it returns "" when the shared values don't select exactly this set
+func explainCombinations(set []int, combinations []Combination) string {
+	// Variables with a single value don't tell combinations apart
+	shared := Combination{}
+	for name, value := range combinations[set[0]] {
+		for _, combination := range combinations {
+			if combination[name] != value {
+				shared[name] = value
+				break
+			}
+		}
+	}
+	for _, index := range set[1:] {
+		for name, value := range combinations[index] {
+			if v, ok := shared[name]; ok && v != value {
+				delete(shared, name)
+			}
+		}
+	}
+	if len(shared) == 0 {
+		return ""
+	}
+
+	matching := 0
+	for _, combination := range combinations {
+		all := true
+		for name, value := range shared {
+			if combination[name] != value {
+				all = false
+				break
+			}
+		}
+		if all {
+			matching++
+		}
+	}
+	if matching != len(set) {
+		return ""
+	}
+	return shared.String()
+}
+
+// lintMatrix lints every combination and groups issues by the combinations they appear in
+func lintMatrix(template string, combinations []Combination, lint func(string) ([]Issue, error)) ([]matrixIssue, error) {
+	var result []matrixIssue
+	byKey := map[string]int{}
+	for i, combination := range combinations {
+		printProgress(fmt.Sprintf("Linting combination %d/%d: %s", i+1, len(combinations), combination))
+		issues, err := lint(instantiateTemplate(template, combination))
+		if err != nil {
+			return nil, fmt.Errorf("combination %s: %w", combination, err)
+		}
+		for _, issue := range issues {
+			key := linter.Fingerprint(Issue{RuleName: issue.RuleName, OriginalSnippet: templateSnippet(issue.OriginalSnippet, combination)})
+			if issue.OriginalSnippet == "" {
+				key += "\x00" + issue.Description
+			}
+			index, ok := byKey[key]
+			if !ok {
+				index = len(result)
+				byKey[key] = index
+				result = append(result, matrixIssue{Issue: issue})
+			}
+			// An issue reported twice for the same prompt counts once
+			if n := len(result[index].Combinations); n == 0 || result[index].Combinations[n-1] != i {
+				result[index].Combinations = append(result[index].Combinations, i)
+			}
+		}
+	}
+	return result, nil
+}
+
+// runExpandCommand implements `promptlint expand --matrix=vars.yaml <file>`
+func runExpandCommand(args []string) error {
+	fs := flag.NewFlagSet("expand", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	matrixFile := fs.String("matrix", "", "YAML file mapping template variables to lists of values")
+	maxCombinations := fs.Int("max-combinations", defaultMaxCombinations, "Maximum number of combinations to lint")
+	dismissalsFile := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	forceColor := fs.Bool("force-color", false, "Force colored output even when stdout is not a terminal")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s expand --matrix=vars.yaml <file>
+
+Instantiates the template prompt with every combination of variable values,
+lints each of them and reports issues that appear only for specific values.
+
+Matrix file:
+  tone: [formal, casual]
+  language: [English, German]
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *matrixFile == "" || len(files) != 1 {
+		fs.Usage()
+		return fmt.Errorf("a matrix file and one prompt file are required")
+	}
+	if *maxCombinations < 1 {
+		return fmt.Errorf("--max-combinations must be positive")
+	}
+	if *forceColor {
+		useColorForProgress = true
+	} else if *noColor {
+		useColorForProgress = false
+	}
+
+	matrix, err := LoadVariableMatrix(*matrixFile)
+	if err != nil {
+		return err
+	}
+	input, err := readFromFile(files[0])
+	if err != nil {
+		return err
+	}
+	doc, err := loadDocument(files[0], []byte(input), "auto")
+	if err != nil {
+		return err
+	}
+
+	// Variables missing on either side are likely typos
+	used := map[string]bool{}
+	for _, placeholder := range linter.ParsePrompt(doc.Text).Placeholders {
+		used[placeholder.Name] = true
+		if _, ok := matrix[placeholder.Name]; !ok {
+			printProgress(fmt.Sprintf("Placeholder %s has no values in the matrix and stays as is", placeholder.Name))
+		}
+	}
+	for _, name := range matrix.Names() {
+		if !used[name] {
+			printProgress(fmt.Sprintf("Matrix variable %s is not used in %s", name, files[0]))
+		}
+	}
+
+	if size := matrix.Size(); size > *maxCombinations {
+		printProgress(fmt.Sprintf("The matrix has %d combinations, linting the first %d (--max-combinations)", size, *maxCombinations))
+	}
+	combinations := matrix.Combinations(*maxCombinations)
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = rulesForPath(rules, files[0]); err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	dismissals, err := LoadDismissals(*dismissalsFile)
+	if err != nil {
+		return err
+	}
+
+	found, err := lintMatrix(doc.Text, combinations, func(text string) ([]Issue, error) {
+		issues, err := checkPromptWithLLM(text, rules, &config)
+		if err != nil {
+			return nil, err
+		}
+		linter.AssignFingerprints(issues)
+		applyDismissals(issues, dismissals, time.Now())
+		return issues, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var common, specific []Issue
+	for _, f := range found {
+		if len(f.Combinations) == len(combinations) {
+			common = append(common, f.Issue)
+			continue
+		}
+		issue := f.Issue
+		if explanation := explainCombinations(f.Combinations, combinations); explanation != "" {
+			issue.Description += fmt.Sprintf(" (only when %s)", explanation)
+		} else {
+			labels := make([]string, len(f.Combinations))
+			for i, index := range f.Combinations {
+				labels[i] = combinations[index].String()
+			}
+			issue.Description += fmt.Sprintf(" (only for %d of %d combinations: %s)", len(f.Combinations), len(combinations), strings.Join(labels, "; "))
+		}
+		specific = append(specific, issue)
+	}
+
+	fmt.Printf("%s: %d combinations of %s\n\n", files[0], len(combinations), strings.Join(matrix.Names(), ", "))
+	fmt.Printf("Issues for specific values:\n%s\n", Report(specific, *forceColor, *noColor))
+	fmt.Printf("Issues in every combination:\n%s\n", Report(common, *forceColor, *noColor))
+	printHeuristicNotice(&config)
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadVariableMatrix(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    VariableMatrix
+		wantErr string
+	}{
+		{"lists and scalars", "tone: [formal, casual]\nlanguage: English\n", VariableMatrix{"tone": {"formal", "casual"}, "language": {"English"}}, ""},
+		{"variable without values", "tone: []\n", nil, "variable tone has no values"},
+		{"no variables", "", nil, "defines no variables"},
+		{"not a mapping", "- formal\n", nil, "failed to parse matrix file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vars.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadVariableMatrix(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadVariableMatrix() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadVariableMatrix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	matrix := VariableMatrix{"tone": {"formal", "casual"}, "language": {"English", "German", "French"}}
+	if size := matrix.Size(); size != 6 {
+		t.Errorf("Size() = %d, want 6", size)
+	}
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{100, []string{
+			"language=English, tone=formal", "language=English, tone=casual",
+			"language=German, tone=formal", "language=German, tone=casual",
+			"language=French, tone=formal", "language=French, tone=casual",
+		}},
+		{3, []string{"language=English, tone=formal", "language=English, tone=casual", "language=German, tone=formal"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %d", tt.limit), func(t *testing.T) {
+			var got []string
+			for _, combination := range matrix.Combinations(tt.limit) {
+				got = append(got, combination.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Combinations(%d) = %q, want %q", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	values := Combination{"tone": "formal", "name": "Ann"}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"double braces", "Be {{ tone }}.", "Be formal."},
+		{"dollar braces", "Greet ${name}.", "Greet Ann."},
+		{"single braces keep the preceding character", "Greet {name}, be {tone}.", "Greet Ann, be formal."},
+		{"percent format", "Greet %(name)s.", "Greet Ann."},
+		{"unknown placeholders are kept", "Answer {{question}} for {name}.", "Answer {{question}} for Ann."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := instantiateTemplate(tt.text, values); got != tt.want {
+				t.Errorf("instantiateTemplate(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		values  Combination
+		want    string
+	}{
+		{"values become placeholders", "Be formal with Ann.", Combination{"tone": "formal", "name": "Ann"}, "Be {{tone}} with {{name}}."},
+		{"longer values first", "Speak German English.", Combination{"a": "English", "b": "German English"}, "Speak {{b}}."},
+		{"empty values are skipped", "Be brief.", Combination{"tone": ""}, "Be brief."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := templateSnippet(tt.snippet, tt.values); got != tt.want {
+				t.Errorf("templateSnippet(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainCombinations(t *testing.T) {
+	combinations := VariableMatrix{"tone": {"formal", "casual"}, "language": {"English", "German"}, "model": {"gpt"}}.Combinations(100)
+	tests := []struct {
+		name string
+		set  []int
+		want string
+	}{
+		{"one shared value", []int{2, 3}, "language=German"},
+		{"single combination", []int{1}, "language=English, tone=casual"},
+		{"no shared value", []int{0, 3}, ""},
+		{"value of another variable", []int{0, 2}, "tone=formal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := explainCombinations(tt.set, combinations); got != tt.want {
+				t.Errorf("explainCombinations(%v) = %q, want %q", tt.set, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintMatrix(t *testing.T) {
+	combinations := VariableMatrix{"tone": {"formal", "casual"}}.Combinations(100)
+	found, err := lintMatrix("Be {{tone}}.", combinations, func(text string) ([]Issue, error) {
+		issues := []Issue{{RuleName: "Be Specific", OriginalSnippet: text}}
+		if strings.Contains(text, "casual") {
+			issues = append(issues, Issue{RuleName: "Tone", Description: "Too casual"}, Issue{RuleName: "Tone", Description: "Too casual"})
+		}
+		return issues, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range found {
+		got = append(got, fmt.Sprintf("%s %v", f.Issue.RuleName, f.Combinations))
+	}
+	// The snippet differs by the value but matches as the template, a repeated issue counts once
+	if want := []string{"Be Specific [0 1]", "Tone [1]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lintMatrix() = %q, want %q", got, want)
+	}
+
+	if _, err := lintMatrix("Be {{tone}}.", combinations, func(string) ([]Issue, error) {
+		return nil, fmt.Errorf("timeout")
+	}); err == nil || !strings.Contains(err.Error(), "combination tone=formal: timeout") {
+		t.Errorf("lintMatrix() error = %v, want the combination", err)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/report"
+)
+
+// qualityScore rates a prompt from 0 to 100 by the number of active issues
+func qualityScore(issues []Issue) int {
+	return report.Score(issues)
+}
+
+// scoreForIssues returns the quality score of a prompt with the given number of active issues
+func scoreForIssues(active int) int {
+	return report.ScoreFor(active)
+}
+
+// promptHash returns a short content hash identifying the prompt version
+func promptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// LintRun is a summary of a lint run sent to experiment trackers
+type LintRun struct {
+	Name       string    `json:"name"`
+	PromptHash string    `json:"promptHash"`
+	Prompt     string    `json:"prompt"`
+	Model      string    `json:"model"`
+	Score      int       `json:"score"`
+	Issues     []Issue   `json:"issues"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// newLintRun builds the run summary of a linted prompt
+func newLintRun(name, prompt, model string, issues []Issue) LintRun {
+	if name == "" {
+		name = "stdin"
+	}
+	return LintRun{
+		Name:       name,
+		PromptHash: promptHash(prompt),
+		Prompt:     prompt,
+		Model:      model,
+		Score:      qualityScore(issues),
+		Issues:     issues,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// ruleCounts returns the number of active issues per rule
+func (r LintRun) ruleCounts() map[string]int {
+	counts := map[string]int{}
+	for _, issue := range r.Issues {
+		if !issue.Dismissed {
+			counts[issue.RuleName]++
+		}
+	}
+	return counts
+}
+
+// Exporter records lint runs in an experiment tracking service
+type Exporter interface {
+	Name() string
+	Export(ctx context.Context, run LintRun) error
+}
+
+// exporters contains registered exporters by name
+var exporters = map[string]Exporter{}
+
+// RegisterExporter makes an exporter available for --export
+func RegisterExporter(exporter Exporter) {
+	exporters[exporter.Name()] = exporter
+}
+
+func init() {
+	RegisterExporter(braintrustExporter{})
+	RegisterExporter(wandbExporter{})
+}
+
+// exporterNames returns sorted names of registered exporters
+func exporterNames() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseExporters resolves a comma-separated list of exporter names
+func parseExporters(value string) ([]Exporter, error) {
+	var result []Exporter
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		exporter, ok := exporters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown exporter %q, supported: %s", name, strings.Join(exporterNames(), ", "))
+		}
+		result = append(result, exporter)
+	}
+	return result, nil
+}
+
+// exportRun sends the run to all exporters, failures are reported without stopping the others
+func exportRun(ctx context.Context, run LintRun, targets []Exporter) error {
+	var failed []string
+	for _, exporter := range targets {
+		printProgress(fmt.Sprintf("Exporting run to %s (score %d)", exporter.Name(), run.Score))
+		if err := exporter.Export(ctx, run); err != nil {
+			printProgress(fmt.Sprintf("Export to %s failed: %v", exporter.Name(), err))
+			failed = append(failed, exporter.Name())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("export failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// postJSON sends a JSON request and decodes the JSON response if result is not nil
+func postJSON(ctx context.Context, endpoint string, headers map[string]string, payload, result interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("request serialization error: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("invalid API response: %w", err)
+	}
+	return nil
+}
+
+// braintrustExporter logs runs as experiment events in Braintrust
+type braintrustExporter struct{}
+
+func (braintrustExporter) Name() string { return "braintrust" }
+func (braintrustExporter) Export(ctx context.Context, run LintRun) error {
+	apiKey, err := requireEnv("BRAINTRUST_API_KEY")
+	if err != nil {
+		return err
+	}
+	baseURL := envOrDefault("BRAINTRUST_API_URL", "https://api.braintrust.dev")
+	headers := map[string]string{"Authorization": "Bearer " + apiKey}
+
+	// Creating a project or an experiment with an existing name returns the existing one
+	var project struct {
+		ID string `json:"id"`
+	}
+	if err := postJSON(ctx, baseURL+"/v1/project", headers, map[string]string{"name": envOrDefault("BRAINTRUST_PROJECT", appName)}, &project); err != nil {
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+	var experiment struct {
+		ID string `json:"id"`
+	}
+	experimentRequest := map[string]interface{}{"project_id": project.ID, "name": envOrDefault("BRAINTRUST_EXPERIMENT", appName), "ensure_new": false}
+	if err := postJSON(ctx, baseURL+"/v1/experiment", headers, experimentRequest, &experiment); err != nil {
+		return fmt.Errorf("failed to get experiment: %w", err)
+	}
+
+	event := map[string]interface{}{
+		"input":    map[string]string{"name": run.Name, "prompt": run.Prompt},
+		"output":   map[string]interface{}{"issues": run.Issues},
+		"scores":   map[string]float64{"prompt_quality": float64(run.Score) / 100},
+		"metrics":  map[string]interface{}{"issues": countActive(run.Issues)},
+		"metadata": map[string]interface{}{"promptHash": run.PromptHash, "model": run.Model, "rules": run.ruleCounts(), "linter": appName + " " + appVersion},
+		"tags":     []string{appName},
+	}
+	payload := map[string]interface{}{"events": []interface{}{event}}
+	return postJSON(ctx, baseURL+"/v1/experiment/"+experiment.ID+"/insert", headers, payload, nil)
+}
+
+// wandbExporter logs every run as a Weights & Biases run with summary metrics
+type wandbExporter struct{}
+
+// wandbUpsertRun creates a run in a project
+const wandbUpsertRun = `mutation UpsertBucket($name: String, $project: String, $entity: String, $config: JSONString, $tags: [String!]) {
+  upsertBucket(input: {name: $name, modelName: $project, entityName: $entity, config: $config, tags: $tags}) { bucket { id name } }
+}`
+
+func (wandbExporter) Name() string { return "wandb" }
+func (wandbExporter) Export(ctx context.Context, run LintRun) error {
+	apiKey, err := requireEnv("WANDB_API_KEY")
+	if err != nil {
+		return err
+	}
+	entity, err := requireEnv("WANDB_ENTITY")
+	if err != nil {
+		return err
+	}
+	baseURL := envOrDefault("WANDB_BASE_URL", "https://api.wandb.ai")
+	project := envOrDefault("WANDB_PROJECT", appName)
+	runID := fmt.Sprintf("%s-%d", run.PromptHash[:8], run.Timestamp.Unix())
+	headers := map[string]string{"Authorization": basicAuth("api", apiKey)}
+
+	config, err := json.Marshal(map[string]interface{}{
+		"prompt":     map[string]string{"value": run.Name},
+		"promptHash": map[string]string{"value": run.PromptHash},
+		"model":      map[string]string{"value": run.Model},
+	})
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	query := map[string]interface{}{
+		"query": wandbUpsertRun,
+		"variables": map[string]interface{}{
+			"name": runID, "project": project, "entity": entity, "config": string(config), "tags": []string{appName},
+		},
+	}
+	if err := postJSON(ctx, baseURL+"/graphql", headers, query, &response); err != nil {
+		return fmt.Errorf("failed to create run: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		return fmt.Errorf("failed to create run: %s", response.Errors[0].Message)
+	}
+
+	metrics := map[string]interface{}{"prompt_quality": run.Score, "issues": countActive(run.Issues), "_step": 0, "_timestamp": run.Timestamp.Unix()}
+	for rule, count := range run.ruleCounts() {
+		metrics["rules/"+rule] = count
+	}
+	line, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	stream := map[string]interface{}{
+		"files": map[string]interface{}{
+			"wandb-history.jsonl": map[string]interface{}{"offset": 0, "content": []string{string(line)}},
+			"wandb-summary.json":  map[string]interface{}{"offset": 0, "content": []string{string(line)}},
+		},
+		"complete": true,
+		"exitcode": 0,
+	}
+	endpoint := fmt.Sprintf("%s/files/%s/%s/%s/file_stream", baseURL, entity, project, runID)
+	return postJSON(ctx, endpoint, headers, stream, nil)
+}
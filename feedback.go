@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultFeedbackFile stores issues labeled by users with --collect-feedback
+const defaultFeedbackFile = ".promptlint/feedback.jsonl"
+
+// Feedback labels
+const (
+	labelCorrect   = "correct"
+	labelIncorrect = "incorrect"
+)
+
+// FeedbackExample is a reported issue labeled by a user
+type FeedbackExample struct {
+	Prompt          string    `json:"prompt"`
+	PromptHash      string    `json:"promptHash"`
+	PromptText      string    `json:"promptText"`
+	Rule            string    `json:"rule"`
+	Description     string    `json:"description"`
+	OriginalSnippet string    `json:"originalSnippet,omitempty"`
+	Fingerprint     string    `json:"fingerprint"`
+	Model           string    `json:"model"`
+	Label           string    `json:"label"`
+	LabeledAt       time.Time `json:"labeledAt"`
+}
+
+// collectFeedback asks the user to label every active issue as correct or incorrect
+func collectFeedback(in *bufio.Reader, name, prompt, model string, issues []Issue) []FeedbackExample {
+	var examples []FeedbackExample
+	active := countActive(issues)
+	n := 0
+	for _, issue := range issues {
+		if issue.Dismissed {
+			continue
+		}
+		n++
+		fmt.Fprintf(os.Stderr, "\n(%d/%d) [%s] %s\n", n, active, issue.RuleName, issue.Description)
+		if issue.OriginalSnippet != "" {
+			fmt.Fprintf(os.Stderr, "Original snippet:\n%s\n", indentSnippet(issue.OriginalSnippet))
+		}
+
+		label, quit := askLabel(in)
+		if quit {
+			break
+		}
+		if label == "" {
+			continue
+		}
+		examples = append(examples, FeedbackExample{
+			Prompt:          name,
+			PromptHash:      promptHash(prompt),
+			PromptText:      prompt,
+			Rule:            issue.RuleName,
+			Description:     issue.Description,
+			OriginalSnippet: issue.OriginalSnippet,
+			Fingerprint:     issue.Fingerprint,
+			Model:           model,
+			Label:           label,
+			LabeledAt:       time.Now().UTC(),
+		})
+	}
+	return examples
+}
+
+// askLabel reads a label for one issue, an empty label means the issue is skipped
+func askLabel(in *bufio.Reader) (string, bool) {
+	for {
+		fmt.Fprintf(os.Stderr, "Is this issue correct? [y]es/[n]o/[s]kip/[q]uit: ")
+		answer, err := in.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "" && err != nil {
+			// Input is closed, stop asking
+			return "", true
+		}
+		switch answer {
+		case "y", "yes":
+			return labelCorrect, false
+		case "n", "no":
+			return labelIncorrect, false
+		case "s", "skip", "":
+			return "", false
+		case "q", "quit":
+			return "", true
+		}
+		fmt.Fprintf(os.Stderr, "Invalid choice: %s\n", answer)
+	}
+}
+
+// appendFeedback appends labeled examples to the feedback file
+func appendFeedback(path string, examples []FeedbackExample) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create feedback directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open feedback file: %w", err)
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, example := range examples {
+		if err := encoder.Encode(example); err != nil {
+			return fmt.Errorf("failed to write feedback: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadFeedback reads the labeled examples of the feedback file in the order they were labeled, none without the file
+func loadFeedback(path string) ([]FeedbackExample, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open feedback file: %w", err)
+	}
+	defer f.Close()
+
+	var examples []FeedbackExample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var example FeedbackExample
+		if err := json.Unmarshal(scanner.Bytes(), &example); err != nil {
+			return nil, fmt.Errorf("error parsing feedback file %s: %w", path, err)
+		}
+		examples = append(examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read feedback file: %w", err)
+	}
+	return examples, nil
+}
+
+// feedbackKey identifies a labeled issue by prompt hash and fingerprint
+func feedbackKey(example FeedbackExample) string {
+	return example.PromptHash + "/" + example.Fingerprint
+}
+
+// loadFeedbackLabels returns the latest label of every issue by prompt hash and fingerprint
+func loadFeedbackLabels(path string) (map[string]string, error) {
+	examples, err := loadFeedback(path)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{}
+	for _, example := range examples {
+		labels[feedbackKey(example)] = example.Label
+	}
+	return labels, nil
+}
+
+// runCollectFeedback labels the issues interactively and stores them in the feedback file
+func runCollectFeedback(path, name, prompt, model string, issues []Issue) error {
+	if countActive(issues) == 0 {
+		return nil
+	}
+	tty, err := openTerminalInput()
+	if err != nil {
+		return fmt.Errorf("feedback collection requires a terminal: %w", err)
+	}
+	defer tty.Close()
+
+	examples := collectFeedback(bufio.NewReader(tty), name, prompt, model, issues)
+	if len(examples) == 0 {
+		return nil
+	}
+	if err := appendFeedback(path, examples); err != nil {
+		return err
+	}
+	printProgress(fmt.Sprintf("Saved %d labeled issues to %s", len(examples), path))
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IssueFilter keeps the issues of the listed categories that reach the minimum severity, the zero value keeps all
+type IssueFilter struct {
+	Categories  map[string]bool
+	MinSeverity string
+}
+
+// parseIssueFilter validates --only-category and --min-severity, categories are comma-separated
+func parseIssueFilter(categories, minSeverity string) (IssueFilter, error) {
+	if !validSeverity(minSeverity) {
+		return IssueFilter{}, fmt.Errorf("unknown severity %q, supported: %s", minSeverity, strings.Join(severityLevels, ", "))
+	}
+	filter := IssueFilter{MinSeverity: minSeverity}
+	for _, category := range strings.Split(categories, ",") {
+		if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+			if filter.Categories == nil {
+				filter.Categories = map[string]bool{}
+			}
+			filter.Categories[category] = true
+		}
+	}
+	return filter, nil
+}
+
+// knownCategories returns the categories of the rules and the analyzers, issues without one are "other"
+func knownCategories(rules *Rules) []string {
+	seen := map[string]bool{"other": true}
+	for _, rule := range rules.PromptRules {
+		if rule.Category != "" {
+			seen[strings.ToLower(rule.Category)] = true
+		}
+	}
+	for _, category := range analyzerCategories {
+		seen[category] = true
+	}
+	categories := make([]string, 0, len(seen))
+	for category := range seen {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// unknownCategories returns the categories of the filter no rule or analyzer has, they are likely typos
+func (f IssueFilter) unknownCategories(rules *Rules) []string {
+	known := map[string]bool{}
+	for _, category := range knownCategories(rules) {
+		known[category] = true
+	}
+	var unknown []string
+	for category := range f.Categories {
+		if !known[category] {
+			unknown = append(unknown, category)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// Keep reports whether the issue passes the filter
+func (f IssueFilter) Keep(issue Issue) bool {
+	if f.MinSeverity != "" && severityRank(issue.Severity) < severityRank(f.MinSeverity) {
+		return false
+	}
+	if len(f.Categories) == 0 {
+		return true
+	}
+	category := strings.ToLower(issue.Category)
+	if category == "" {
+		category = "other"
+	}
+	return f.Categories[category]
+}
+
+// Apply drops the issues that don't pass the filter
+func (f IssueFilter) Apply(issues []Issue) []Issue {
+	if f.MinSeverity == "" && len(f.Categories) == 0 {
+		return issues
+	}
+	kept := issues[:0]
+	for _, issue := range issues {
+		if f.Keep(issue) {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// FixIteration records the outcome of a single lint → fix pass
+type FixIteration struct {
+	Number       int
+	IssuesFound  int
+	FixesApplied int
+}
+
+// FixResult contains the outcome of the fix pipeline
+type FixResult struct {
+	Prompt    string
+	History   []FixIteration
+	Remaining []Issue
+	Clean     bool
+}
+
+// fixChooser selects the replacement snippet for an issue, ok is false when the issue must be skipped
+type fixChooser func(issue Issue) (replacement string, ok bool)
+
+// suggestedFix always selects the suggested fixed snippet
+func suggestedFix(issue Issue) (string, bool) {
+	return issue.FixedSnippet, true
+}
+
+// applyFixes replaces original snippets of the issues with the fixes selected by choose.
+// Returns the updated prompt and the issues that were not fixed, dismissed ones included.
+func applyFixes(prompt string, issues []Issue, choose fixChooser) (string, []Issue) {
+	var unfixed []Issue
+	for _, issue := range issues {
+		if fixed, ok := applyFix(prompt, issue, choose); ok {
+			prompt = fixed
+		} else {
+			unfixed = append(unfixed, issue)
+		}
+	}
+	return prompt, unfixed
+}
+
+// applyFix replaces the original snippet of the issue with the fix selected by choose, ok is false when
+// the issue is dismissed, its snippet is not in the prompt or no fix is selected
+func applyFix(prompt string, issue Issue, choose fixChooser) (string, bool) {
+	if issue.Dismissed {
+		return prompt, false
+	}
+	original := strings.TrimSpace(issue.OriginalSnippet)
+	if original == "" || !strings.Contains(prompt, original) {
+		return prompt, false
+	}
+	replacement, ok := choose(issue)
+	if !ok {
+		return prompt, false
+	}
+	fixed := strings.TrimSpace(replacement)
+	if fixed == "" || original == fixed {
+		return prompt, false
+	}
+	return strings.Replace(prompt, original, fixed, 1), true
+}
+
+// runFixPipeline lints the prompt and applies suggested fixes.
+// In untilClean mode the prompt is re-linted after every pass until no issues remain,
+// no fix can be applied, or maxIterations fix passes were made. Passes run one after another,
+// each lints the prompt fixed by the previous one. Remaining are the issues of the last lint
+// that were not fixed.
+func runFixPipeline(prompt string, maxIterations int, untilClean bool, lint func(string) ([]Issue, error), choose fixChooser) (*FixResult, error) {
+	result := &FixResult{Prompt: prompt}
+
+	for i := 1; ; i++ {
+		printProgress(fmt.Sprintf("Processing fix iteration %d", i))
+		issues, err := lint(result.Prompt)
+		if err != nil {
+			return nil, fmt.Errorf("fix iteration %d failed: %w", i, err)
+		}
+
+		iteration := FixIteration{Number: i, IssuesFound: countActive(issues)}
+		result.Remaining = issues
+		if iteration.IssuesFound == 0 {
+			result.History = append(result.History, iteration)
+			result.Clean = true
+			return result, nil
+		}
+		if i > maxIterations {
+			result.History = append(result.History, iteration)
+			return result, nil
+		}
+
+		fixed, unfixed := applyFixes(result.Prompt, issues, choose)
+		applied := len(issues) - len(unfixed)
+		iteration.FixesApplied = applied
+		result.History = append(result.History, iteration)
+		result.Prompt = fixed
+		result.Remaining = unfixed
+
+		if !untilClean || applied == 0 {
+			return result, nil
+		}
+	}
+}
+
+// limitReached reports whether the pipeline stopped at the iteration limit with issues remaining
+func (r *FixResult) limitReached(maxIterations int) bool {
+	return !r.Clean && r.History[len(r.History)-1].Number > maxIterations
+}
+
+// ReportFixHistory formats the iteration history of the fix pipeline
+func ReportFixHistory(result *FixResult, maxIterations int, untilClean bool) string {
+	var sb strings.Builder
+
+	sb.WriteString("Fix history:\n")
+	for _, it := range result.History {
+		sb.WriteString(fmt.Sprintf("  Iteration %d: %d issues found, %d fixes applied\n", it.Number, it.IssuesFound, it.FixesApplied))
+	}
+
+	last := result.History[len(result.History)-1]
+	switch {
+	case result.Clean:
+		sb.WriteString("Result: prompt is clean\n")
+	case !untilClean:
+		sb.WriteString(fmt.Sprintf("Result: %d of %d issues fixed\n", last.FixesApplied, last.IssuesFound))
+	case result.limitReached(maxIterations):
+		sb.WriteString(fmt.Sprintf("Result: iteration limit (%d) reached, %d issues remaining\n", maxIterations, last.IssuesFound))
+	default:
+		sb.WriteString(fmt.Sprintf("Result: no applicable fixes for %d remaining issues\n", last.IssuesFound))
+	}
+
+	return sb.String()
+}
+
+// openTerminalInput opens the controlling terminal for interactive input,
+// stdin can't be used because it may contain the prompt itself
+func openTerminalInput() (*os.File, error) {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	return os.Open(name)
+}
+
+// interactiveFix asks the user to pick the suggested fix, one of the alternatives, or to skip the issue
+func interactiveFix(in *bufio.Reader) fixChooser {
+	return func(issue Issue) (string, bool) {
+		options := []string{issue.FixedSnippet}
+		for _, alternative := range issue.Alternatives {
+			options = append(options, alternative.Snippet)
+		}
+
+		fmt.Fprintf(os.Stderr, "\n[%s] %s\n", issue.RuleName, issue.Description)
+		fmt.Fprintf(os.Stderr, "Original snippet:\n%s\n", indentSnippet(issue.OriginalSnippet))
+		fmt.Fprintf(os.Stderr, "  1) suggested fix:\n%s\n", indentSnippet(issue.FixedSnippet))
+		for i, alternative := range issue.Alternatives {
+			fmt.Fprintf(os.Stderr, "  %d) alternative #%d:\n%s\n", i+2, alternative.Rank, indentSnippet(alternative.Snippet))
+			fmt.Fprintf(os.Stderr, "     Pros: %s\n     Cons: %s\n", alternative.Pros, alternative.Cons)
+		}
+		fmt.Fprintf(os.Stderr, "  s) skip\n")
+
+		for {
+			fmt.Fprintf(os.Stderr, "Choose fix [1-%d/s] (default 1): ", len(options))
+			answer, err := in.ReadString('\n')
+			answer = strings.TrimSpace(answer)
+			if answer == "" && err != nil {
+				// Input is closed, fall back to skipping
+				return "", false
+			}
+			switch answer {
+			case "":
+				return options[0], true
+			case "s", "S":
+				return "", false
+			}
+			if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(options) {
+				return options[n-1], true
+			}
+			fmt.Fprintf(os.Stderr, "Invalid choice: %s\n", answer)
+		}
+	}
+}
+
+// runFix executes the fix pipeline and writes the fixed prompt in the line endings and encoding of the input.
+// A file is rewritten in place; for stdin input the fixed prompt goes to stdout and the report to stderr.
+// The result holds the issues left for the quality gates.
+func runFix(input string, encoding inputEncoding, filePath string, maxIterations int, untilClean, interactive bool, lint func(string) ([]Issue, error), forceColor, noColor bool) (*FixResult, error) {
+	choose := fixChooser(suggestedFix)
+	if interactive {
+		tty, err := openTerminalInput()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open terminal for interactive mode: %w", err)
+		}
+		defer tty.Close()
+		choose = interactiveFix(bufio.NewReader(tty))
+	}
+
+	result, err := runFixPipeline(input, maxIterations, untilClean, lint, choose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fix prompt: %w", err)
+	}
+
+	report := ReportFixHistory(result, maxIterations, untilClean) + "\n" + Report(result.Remaining, forceColor, noColor)
+
+	output, err := encodeOutput(result.Prompt, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fixed prompt: %w", err)
+	}
+	if filePath == "" {
+		fmt.Fprintln(os.Stderr, report)
+		if _, err := os.Stdout.Write(output); err != nil {
+			return nil, fmt.Errorf("failed to write fixed prompt: %w", err)
+		}
+		return result, nil
+	}
+
+	if result.Prompt != input {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file info: %w", err)
+		}
+		if err := os.WriteFile(filePath, output, info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("failed to write fixed prompt: %w", err)
+		}
+		printProgress("Fixed prompt written to " + filePath)
+	}
+	fmt.Println(report)
+	return result, nil
+}
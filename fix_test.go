@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeLint reports a Use Positive Instructions issue for every line starting with "Never"
+func fakeLint(passes *int) func(string) ([]Issue, error) {
+	return func(prompt string) ([]Issue, error) {
+		*passes++
+		var issues []Issue
+		for _, line := range strings.Split(prompt, "\n") {
+			if strings.HasPrefix(line, "Never ") {
+				issues = append(issues, Issue{RuleName: "Use Positive Instructions", OriginalSnippet: line, FixedSnippet: "Always " + strings.TrimPrefix(line, "Never ")})
+			}
+		}
+		return issues, nil
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	prompt := "You help.\nNever guess.\nBe brief."
+	tests := []struct {
+		name    string
+		issue   Issue
+		want    string
+		unfixed bool
+	}{
+		{"fixed", Issue{OriginalSnippet: " Never guess. ", FixedSnippet: "Ask when unsure."}, "You help.\nAsk when unsure.\nBe brief.", false},
+		{"dismissed", Issue{OriginalSnippet: "Never guess.", FixedSnippet: "Ask.", Dismissed: true}, prompt, true},
+		{"snippet not in the prompt", Issue{OriginalSnippet: "Never lie.", FixedSnippet: "Be honest."}, prompt, true},
+		{"without a fix", Issue{OriginalSnippet: "Never guess."}, prompt, true},
+		{"fix equals the snippet", Issue{OriginalSnippet: "Never guess.", FixedSnippet: "Never guess."}, prompt, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unfixed := applyFixes(prompt, []Issue{tt.issue}, suggestedFix)
+			if got != tt.want || (len(unfixed) == 1) != tt.unfixed {
+				t.Errorf("applyFixes() = %q with %d unfixed, want %q", got, len(unfixed), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunFixPipeline(t *testing.T) {
+	prompt := "You help.\nNever guess.\nNever lie.\nUse bullet points."
+	// The second fix of a pass targets a snippet the chooser skips
+	skipLie := func(issue Issue) (string, bool) {
+		return issue.FixedSnippet, !strings.Contains(issue.OriginalSnippet, "lie")
+	}
+
+	t.Run("single pass", func(t *testing.T) {
+		passes := 0
+		result, err := runFixPipeline(prompt, 3, false, fakeLint(&passes), skipLie)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if passes != 1 || len(result.History) != 1 || result.History[0].IssuesFound != 2 || result.History[0].FixesApplied != 1 {
+			t.Fatalf("%d passes, history %+v, want one pass with 1 of 2 issues fixed", passes, result.History)
+		}
+		// Only the skipped issue remains, the applied fix is not reported again
+		if len(result.Remaining) != 1 || result.Remaining[0].OriginalSnippet != "Never lie." {
+			t.Errorf("remaining %+v, want only the skipped issue", result.Remaining)
+		}
+		if result.Clean || !strings.Contains(result.Prompt, "Always guess.") {
+			t.Errorf("prompt %q, clean %v", result.Prompt, result.Clean)
+		}
+	})
+
+	t.Run("until clean", func(t *testing.T) {
+		passes := 0
+		result, err := runFixPipeline(prompt, 3, true, fakeLint(&passes), suggestedFix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Clean || len(result.Remaining) != 0 || len(result.History) != 2 || passes != 2 {
+			t.Errorf("clean %v after %d passes, history %+v, remaining %+v", result.Clean, passes, result.History, result.Remaining)
+		}
+		if !strings.HasSuffix(ReportFixHistory(result, 3, true), "Result: prompt is clean\n") {
+			t.Errorf("report:\n%s", ReportFixHistory(result, 3, true))
+		}
+	})
+
+	t.Run("no applicable fixes", func(t *testing.T) {
+		passes := 0
+		result, err := runFixPipeline(prompt, 3, true, fakeLint(&passes), skipLie)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := result.History[len(result.History)-1]
+		if result.Clean || len(result.History) != 2 || last.IssuesFound != 1 || last.FixesApplied != 0 || len(result.Remaining) != 1 {
+			t.Errorf("history %+v, remaining %+v, want a second pass without fixes", result.History, result.Remaining)
+		}
+		if result.limitReached(3) {
+			t.Error("limitReached() = true without reaching the limit")
+		}
+		if !strings.Contains(ReportFixHistory(result, 3, true), "no applicable fixes for 1 remaining issues") {
+			t.Errorf("report:\n%s", ReportFixHistory(result, 3, true))
+		}
+	})
+
+	t.Run("iteration limit", func(t *testing.T) {
+		// Every fix adds a new negative instruction, the prompt never gets clean
+		passes := 0
+		endless := func(issue Issue) (string, bool) {
+			return issue.FixedSnippet + "\nNever stop" + strings.Repeat(".", passes), true
+		}
+		result, err := runFixPipeline("Never stop.", 2, true, fakeLint(&passes), endless)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Clean || len(result.History) != 3 || passes != 3 || len(result.Remaining) != 1 {
+			t.Errorf("history %+v after %d passes, want 2 fix passes and a final lint", result.History, passes)
+		}
+		if !result.limitReached(2) {
+			t.Error("limitReached() = false, want true")
+		}
+		if !strings.Contains(ReportFixHistory(result, 2, true), "iteration limit (2) reached, 1 issues remaining") {
+			t.Errorf("report:\n%s", ReportFixHistory(result, 2, true))
+		}
+	})
+}
+
+func TestFixQualityGates(t *testing.T) {
+	t.Cleanup(func() { loadAnalyzerSettings("") })
+	chdir(t, t.TempDir())
+	t.Setenv("PROMPTLINT_API_KEY", "")
+	previous := policy
+	t.Cleanup(func() { policy = previous })
+
+	tests := []struct {
+		name    string
+		args    []string
+		policy  Policy
+		config  string
+		wantErr string
+	}{
+		{"no gates", nil, Policy{}, "", ""},
+		{"issues without fixes stay below the policy score", nil, Policy{MinScore: 100, path: "policy.yaml"}, "", "below the minimum 100 of the policy"},
+		{"fail_if sees the remaining issues", nil, Policy{}, "fail_if: issues > 0\n", "fail_if"},
+		{"fail-on sees the remaining issues", []string{"--fail-on=info"}, Policy{}, "", "failed on --fail-on=info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy = tt.policy
+			writeConfig(t, tt.config)
+			if err := os.WriteFile("prompt.md", []byte("You are a helper.\nNever do X. Do not do Y.\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := runLintCommand(append(append([]string{"--fix"}, tt.args...), "prompt.md"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("check --fix = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("check --fix = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
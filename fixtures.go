@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+)
+
+// replayDir is the directory LLM responses are replayed from, empty when not replaying
+var replayDir string
+
+// Fixture is a recorded LLM API exchange, API keys in headers are never recorded
+type Fixture struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Request is the JSON body of the request, RequestText a body that isn't JSON
+	Request     json.RawMessage `json:"request,omitempty"`
+	RequestText string          `json:"requestText,omitempty"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"contentType,omitempty"`
+	// Response is the JSON body of the response, ResponseText a body that isn't JSON
+	Response     json.RawMessage `json:"response,omitempty"`
+	ResponseText string          `json:"responseText,omitempty"`
+}
+
+// fixtureBody splits a body into its JSON or text form
+func fixtureBody(data []byte) (json.RawMessage, string) {
+	if json.Valid(data) {
+		return json.RawMessage(data), ""
+	}
+	return nil, string(data)
+}
+
+// fixtureKey identifies a request by its method, URL and body, so the same request replays the same response
+// whichever order parallel requests are sent in
+func fixtureKey(method, url string, body []byte) string {
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) != nil {
+		compact.Reset()
+		compact.Write(body)
+	}
+	sum := sha256.Sum256([]byte(method + " " + url + "\n" + compact.String()))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// readRequestBody reads the body of the request and restores it for the transport
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// recordingTransport sends requests through the next transport and writes the responses to the directory
+type recordingTransport struct {
+	next http.RoundTripper
+	dir  string
+}
+
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// Rate limits and server errors are retried, only the answers the run goes on with are recorded
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return resp, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	fixture := Fixture{Method: req.Method, URL: req.URL.Redacted(), Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")}
+	fixture.Request, fixture.RequestText = fixtureBody(body)
+	fixture.Response, fixture.ResponseText = fixtureBody(data)
+	encoded, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	path := filepath.Join(t.dir, fixtureKey(req.Method, fixture.URL, body)+".json")
+	if err := os.WriteFile(path, append(encoded, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+// replayTransport answers requests with the responses recorded in the directory and never connects,
+// a request without a recorded response fails
+type replayTransport struct {
+	dir string
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	url := req.URL.Redacted()
+	path := filepath.Join(t.dir, fixtureKey(req.Method, url, body)+".json")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response to %s %s in %s, the request changed since it was recorded, record it again with --record", req.Method, url, t.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("error parsing fixture %s: %w", path, err)
+	}
+	content := []byte(fixture.ResponseText)
+	if len(fixture.Response) > 0 {
+		content = fixture.Response
+	}
+	header := http.Header{}
+	if fixture.ContentType != "" {
+		header.Set("Content-Type", fixture.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(content)),
+		ContentLength: int64(len(content)),
+		Request:       req,
+	}, nil
+}
+
+// configuredFixtures returns the directories of PROMPTLINT_RECORD and PROMPTLINT_REPLAY
+func configuredFixtures() (record, replay string) {
+	return os.Getenv("PROMPTLINT_RECORD"), os.Getenv("PROMPTLINT_REPLAY")
+}
+
+// enableFixtures records LLM API responses to the record directory or replays them from the replay directory.
+// Only requests to the LLM API are recorded, replays need no API key and make no network access to it.
+func enableFixtures(record, replay string) error {
+	switch {
+	case record != "" && replay != "":
+		return fmt.Errorf("responses can't be recorded and replayed at once")
+	case (record != "" || replay != "") && offline:
+		return fmt.Errorf("offline mode uses the heuristic judge, which makes no API requests")
+	case record != "":
+		if err := os.MkdirAll(record, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", record, err)
+		}
+		llm.SetTransport(recordingTransport{next: llm.Transport(), dir: record})
+		printProgress("Recording LLM responses to " + record)
+	case replay != "":
+		info, err := os.Stat(replay)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("fixtures directory %s doesn't exist, record it with --record", replay)
+		}
+		replayDir = replay
+		llm.SetTransport(replayTransport{dir: replay})
+		printProgress("Replaying LLM responses from " + replay)
+	}
+	return nil
+}
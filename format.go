@@ -0,0 +1,281 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+var (
+	bulletMarkerPattern  = regexp.MustCompile(`^(\s*)[*+]\s+`)
+	orderedMarkerPattern = regexp.MustCompile(`^(\s*)(\d+)\)\s+`)
+	thematicBreakPattern = regexp.MustCompile(`^\s*(?:(?:\*\s*){3,}|(?:_\s*){3,}|(?:-\s*){3,})$`)
+)
+
+// normalizePlaceholders rewrites all placeholders of the line to the syntax
+func normalizePlaceholders(line, syntax string) string {
+	template, ok := linter.PlaceholderStyles[syntax]
+	if !ok {
+		return line
+	}
+	for _, p := range linter.PlaceholderPatterns {
+		if p.Syntax == syntax {
+			continue
+		}
+		var sb strings.Builder
+		last := 0
+		for _, loc := range p.Pattern.FindAllStringSubmatchIndex(line, -1) {
+			// The single brace pattern includes the preceding character
+			start := loc[0]
+			if p.Syntax == "{}" {
+				start = loc[2] - 1
+			}
+			sb.WriteString(line[last:start])
+			sb.WriteString(fmt.Sprintf(template, line[loc[2]:loc[3]]))
+			last = loc[1]
+		}
+		sb.WriteString(line[last:])
+		line = sb.String()
+	}
+	return line
+}
+
+// normalizeHeadingLevels maps heading levels so the top level is 1 and nested levels don't skip
+func normalizeHeadingLevels(levels []int) []int {
+	if len(levels) == 0 {
+		return nil
+	}
+	minLevel := levels[0]
+	for _, level := range levels {
+		if level < minLevel {
+			minLevel = level
+		}
+	}
+
+	result := make([]int, len(levels))
+	previous := 0
+	for i, level := range levels {
+		normalized := level - minLevel + 1
+		if normalized > previous+1 {
+			normalized = previous + 1
+		}
+		result[i] = normalized
+		previous = normalized
+	}
+	return result
+}
+
+// formatPrompt normalizes the formatting of a Markdown or plain text prompt deterministically.
+// Frontmatter is kept verbatim, code fence contents are only cleaned of invisible characters.
+func formatPrompt(text, placeholderSyntax string) string {
+	var header string
+	if _, body, ok := splitFrontmatter([]byte(text)); ok {
+		header = strings.TrimSuffix(text[:len(text)-len(body)], "\n") + "\n"
+		text = body
+	}
+	if placeholderSyntax == "" {
+		placeholderSyntax = linter.ParsePrompt(text).PlaceholderSyntax()
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	// Heading levels are normalized over the whole document
+	var levels []int
+	inFence := ""
+	for _, line := range lines {
+		if inFence != "" || linter.FencePattern.MatchString(line) {
+			inFence = toggleFence(inFence, line)
+			continue
+		}
+		if match := linter.HeadingPattern.FindStringSubmatch(line); match != nil {
+			levels = append(levels, len(match[1]))
+		}
+	}
+	levels = normalizeHeadingLevels(levels)
+
+	var out []string
+	heading := 0
+	inFence = ""
+	for _, line := range lines {
+		if inFence != "" || linter.FencePattern.MatchString(line) {
+			opening := inFence == ""
+			inFence = toggleFence(inFence, line)
+			switch {
+			case opening:
+				match := linter.FencePattern.FindStringSubmatch(line)
+				out = append(out, "```"+match[2])
+			case inFence == "":
+				out = append(out, "```")
+			default:
+				out = append(out, analyzers.CleanInvisible(line))
+			}
+			continue
+		}
+
+		line = strings.TrimRight(analyzers.CleanInvisible(line), " \t")
+		if !analyzers.IsTableRow(line) {
+			line = analyzers.CollapseSpaces(line)
+		}
+		if line == "" {
+			// Collapse blank lines and drop them at the start
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			continue
+		}
+
+		switch match := linter.HeadingPattern.FindStringSubmatch(line); {
+		case match != nil:
+			line = strings.Repeat("#", levels[heading]) + " " + match[2]
+			heading++
+			// Headings are separated from the previous block
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+		case thematicBreakPattern.MatchString(line):
+			line = "---"
+		default:
+			line = bulletMarkerPattern.ReplaceAllString(line, "${1}- ")
+			line = orderedMarkerPattern.ReplaceAllString(line, "${1}${2}. ")
+		}
+		out = append(out, normalizePlaceholders(line, placeholderSyntax))
+	}
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return header
+	}
+	return header + strings.Join(out, "\n") + "\n"
+}
+
+// toggleFence returns the fence marker that stays open after the line, "" when outside of fences
+func toggleFence(open, line string) string {
+	if open == "" {
+		return linter.FencePattern.FindStringSubmatch(line)[1]
+	}
+	if strings.HasPrefix(strings.TrimSpace(line), open) {
+		return ""
+	}
+	return open
+}
+
+// runFmtCommand implements `promptlint fmt [-w|-l|-d] [file...]`
+func runFmtCommand(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("w", false, "Write the result to the file instead of stdout")
+	list := fs.Bool("l", false, "List files whose formatting differs")
+	showDiff := fs.Bool("d", false, "Print diffs instead of the formatted prompt")
+	reorder := fs.Bool("reorder", false, "Reorder top-level sections into the canonical order (section_order in "+configFileName+")")
+	placeholders := fs.String("placeholders", "", "Placeholder syntax: {{}}, {}, ${} or %() (default: the most used in the file)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s fmt [-w|-l|-d] [--reorder] [file...]\n\nNormalizes headings, list markers, delimiters, whitespace, invisible characters and placeholders without LLM calls.\nWithout files the prompt is read from stdin.\n\nOptions:\n", appName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if _, ok := linter.PlaceholderStyles[*placeholders]; *placeholders != "" && !ok {
+		return fmt.Errorf("unsupported placeholder syntax %q, use {{}}, {}, ${} or %%()", *placeholders)
+	}
+	if len(files) == 0 {
+		if *write || *list {
+			return fmt.Errorf("-w and -l require files")
+		}
+		input, err := readFromStdin()
+		if err != nil {
+			return err
+		}
+		formatted, err := formatWithOrder(input, "", *placeholders, *reorder)
+		if err != nil {
+			return err
+		}
+		if *showDiff {
+			fmt.Print(formatDiff("stdin", input, formatted))
+		} else {
+			fmt.Print(formatted)
+		}
+		return nil
+	}
+
+	for _, file := range files {
+		input, err := readFromFile(file)
+		if err != nil {
+			return err
+		}
+		if doc, err := loadDocument(file, []byte(input), "auto"); err != nil || (doc.Format != "text" && doc.Format != "markdown") {
+			printProgress(fmt.Sprintf("Skipping %s: fmt supports text and markdown prompts", file))
+			continue
+		}
+		formatted, err := formatWithOrder(input, file, *placeholders, *reorder)
+		if err != nil {
+			return err
+		}
+		changed := formatted != input
+
+		if *list && changed {
+			fmt.Println(file)
+		}
+		if *showDiff && changed {
+			fmt.Print(formatDiff(file, input, formatted))
+		}
+		if *write && changed {
+			info, err := os.Stat(file)
+			if err != nil {
+				return fmt.Errorf("failed to stat %s: %w", file, err)
+			}
+			if err := os.WriteFile(file, []byte(formatted), info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to write %s: %w", file, err)
+			}
+		}
+		if !*list && !*showDiff && !*write {
+			fmt.Print(formatted)
+		}
+	}
+	return nil
+}
+
+// formatWithOrder formats the prompt and reorders its sections when requested,
+// without reordering it only suggests the reorder
+func formatWithOrder(input, path, placeholders string, reorder bool) (string, error) {
+	formatted := formatPrompt(input, placeholders)
+	order, err := loadSectionOrder(path)
+	if err != nil {
+		return "", err
+	}
+	reordered, changed := analyzers.ReorderSections(formatted, order)
+	if !changed {
+		return formatted, nil
+	}
+	name := path
+	if name == "" {
+		name = "stdin"
+	}
+	if !reorder {
+		printProgress(fmt.Sprintf("%s: sections are not in the canonical order (%s), use --reorder -d to see the suggested order", name, strings.Join(order, " → ")))
+		return formatted, nil
+	}
+	return formatPrompt(reordered, placeholders), nil
+}
+
+// formatDiff renders the changes made by formatting as a unified diff
+func formatDiff(name, before, after string) string {
+	hunks := buildHunks(diffLines(splitLines(before), splitLines(after)), 3)
+	if len(hunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s (formatted)\n", name, name))
+	for _, h := range hunks {
+		sb.WriteString(h.String())
+	}
+	return sb.String()
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gateVariables are the run results available in fail_if expressions
+var gateVariables = map[string]string{
+	"score":                   "quality score from 0 to 100",
+	"issues":                  "number of active issues",
+	"issues.severity.error":   "active issues with severity error",
+	"issues.severity.warning": "active issues with severity warning, issues without a severity count as warnings",
+	"issues.severity.info":    "active issues with severity info",
+	"dismissed":               "number of dismissed issues",
+	"new_issues":              "active issues missing from the latest history entry of the prompt, all of them without history",
+}
+
+// GateResults are the values of the gate variables after a run
+type GateResults map[string]float64
+
+// gateResults computes the gate variables, known lists fingerprints of the previous run or is nil without one
+func gateResults(issues []Issue, known []string) GateResults {
+	seen := map[string]bool{}
+	for _, fingerprint := range known {
+		seen[fingerprint] = true
+	}
+	results := GateResults{"score": float64(qualityScore(issues))}
+	for name := range gateVariables {
+		if _, ok := results[name]; !ok {
+			results[name] = 0
+		}
+	}
+	for _, issue := range issues {
+		if issue.Dismissed {
+			results["dismissed"]++
+			continue
+		}
+		results["issues"]++
+		results["issues.severity."+severityLevels[severityRank(issue.Severity)]]++
+		if known == nil || !seen[issue.Fingerprint] {
+			results["new_issues"]++
+		}
+	}
+	return results
+}
+
+// String lists the values in name order for gate failure messages
+func (r GateResults) String() string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.FormatFloat(r[name], 'f', -1, 64)
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// GateExpr is a parsed fail_if expression
+type GateExpr struct {
+	source string
+	root   gateNode
+}
+
+// gateNode evaluates to a float64 or a bool, check returns which one without evaluating
+type gateNode interface {
+	eval(results GateResults) (interface{}, error)
+	check() (bool, error)
+}
+
+type gateNumber float64
+
+func (n gateNumber) eval(GateResults) (interface{}, error) { return float64(n), nil }
+
+func (n gateNumber) check() (bool, error) { return false, nil }
+
+type gateBool bool
+
+func (b gateBool) eval(GateResults) (interface{}, error) { return bool(b), nil }
+
+func (b gateBool) check() (bool, error) { return true, nil }
+
+type gateVariable string
+
+func (v gateVariable) eval(results GateResults) (interface{}, error) { return results[string(v)], nil }
+
+func (v gateVariable) check() (bool, error) { return false, nil }
+
+type gateNot struct{ operand gateNode }
+
+func (n gateNot) check() (bool, error) {
+	if err := checkBool(n.operand, "!"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (n gateNot) eval(results GateResults) (interface{}, error) {
+	value, err := evalBool(n.operand, results, "!")
+	if err != nil {
+		return nil, err
+	}
+	return !value, nil
+}
+
+type gateBinary struct {
+	op          string
+	left, right gateNode
+}
+
+func (b gateBinary) eval(results GateResults) (interface{}, error) {
+	switch b.op {
+	case "&&", "||":
+		left, err := evalBool(b.left, results, b.op)
+		if err != nil {
+			return nil, err
+		}
+		// Short-circuit like in Go
+		if (b.op == "&&" && !left) || (b.op == "||" && left) {
+			return left, nil
+		}
+		return evalBool(b.right, results, b.op)
+	}
+
+	left, err := b.left.eval(results)
+	if err != nil {
+		return nil, err
+	}
+	right, err := b.right.eval(results)
+	if err != nil {
+		return nil, err
+	}
+	if b.op == "==" || b.op == "!=" {
+		if fmt.Sprintf("%T", left) != fmt.Sprintf("%T", right) {
+			return nil, fmt.Errorf("%s compares a number with a boolean", b.op)
+		}
+		return (left == right) == (b.op == "=="), nil
+	}
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s requires numbers", b.op)
+	}
+	switch b.op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", b.op)
+}
+
+// check type-checks both operands, so operands skipped by short-circuiting are checked too
+func (b gateBinary) check() (bool, error) {
+	switch b.op {
+	case "&&", "||":
+		if err := checkBool(b.left, b.op); err != nil {
+			return false, err
+		}
+		return true, checkBool(b.right, b.op)
+	}
+
+	left, err := b.left.check()
+	if err != nil {
+		return false, err
+	}
+	right, err := b.right.check()
+	if err != nil {
+		return false, err
+	}
+	if b.op == "==" || b.op == "!=" {
+		if left != right {
+			return false, fmt.Errorf("%s compares a number with a boolean", b.op)
+		}
+		return true, nil
+	}
+	if left || right {
+		return false, fmt.Errorf("%s requires numbers", b.op)
+	}
+	return true, nil
+}
+
+// checkBool type-checks an operand of a logical operator
+func checkBool(node gateNode, op string) error {
+	isBool, err := node.check()
+	if err != nil {
+		return err
+	}
+	if !isBool {
+		return fmt.Errorf("%s requires boolean operands", op)
+	}
+	return nil
+}
+
+// evalBool evaluates an operand of a logical operator
+func evalBool(node gateNode, results GateResults, op string) (bool, error) {
+	value, err := node.eval(results)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s requires boolean operands", op)
+	}
+	return b, nil
+}
+
+// gateParser is a recursive descent parser of fail_if expressions:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = primary [ ("<" | "<=" | ">" | ">=" | "==" | "!=") primary ]
+//	primary = number | "true" | "false" | variable | "(" or ")"
+type gateParser struct {
+	tokens []string
+	pos    int
+}
+
+// tokenizeGate splits an expression into numbers, identifiers, operators and parentheses
+func tokenizeGate(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(expr[i:], "&&") || strings.HasPrefix(expr[i:], "||") ||
+			strings.HasPrefix(expr[i:], "<=") || strings.HasPrefix(expr[i:], ">=") ||
+			strings.HasPrefix(expr[i:], "==") || strings.HasPrefix(expr[i:], "!="):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case c == '<' || c == '>' || c == '!':
+			tokens = append(tokens, string(c))
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(expr) && (expr[j] == '_' || expr[j] == '.' || expr[j] >= 'a' && expr[j] <= 'z' ||
+				expr[j] >= 'A' && expr[j] <= 'Z' || expr[j] >= '0' && expr[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+func (p *gateParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *gateParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *gateParser) parseOr() (gateNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = gateBinary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *gateParser) parseAnd() (gateNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = gateBinary{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *gateParser) parseUnary() (gateNode, error) {
+	if p.peek() == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return gateNot{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *gateParser) parseCompare() (gateNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek(); op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return gateBinary{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *gateParser) parsePrimary() (gateNode, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return node, nil
+	case token == "true" || token == "false":
+		return gateBool(token == "true"), nil
+	case token[0] >= '0' && token[0] <= '9' || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token)
+		}
+		return gateNumber(value), nil
+	}
+	if _, ok := gateVariables[token]; !ok {
+		names := make([]string, 0, len(gateVariables))
+		for name := range gateVariables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown variable %q, available: %s", token, strings.Join(names, ", "))
+	}
+	return gateVariable(token), nil
+}
+
+// ParseGateExpr parses a fail_if expression like `score < 80 || issues.severity.error > 0`
+func ParseGateExpr(expr string) (*GateExpr, error) {
+	tokens, err := tokenizeGate(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fail_if %q: %w", expr, err)
+	}
+	p := &gateParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(tokens) {
+		err = fmt.Errorf("unexpected %q", tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid fail_if %q: %w", expr, err)
+	}
+	// Type errors are reported before linting
+	isBool, err := root.check()
+	if err != nil {
+		return nil, fmt.Errorf("fail_if %q: %w", expr, err)
+	}
+	if !isBool {
+		return nil, fmt.Errorf("fail_if %q must be a condition, not a number", expr)
+	}
+	return &GateExpr{source: expr, root: root}, nil
+}
+
+// String returns the source of the expression
+func (g *GateExpr) String() string {
+	return g.source
+}
+
+// Eval reports whether the gate fails for the results
+func (g *GateExpr) Eval(results GateResults) (bool, error) {
+	value, err := g.root.eval(results)
+	if err != nil {
+		return false, fmt.Errorf("fail_if %q: %w", g.source, err)
+	}
+	failed, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("fail_if %q must be a condition, not a number", g.source)
+	}
+	return failed, nil
+}
+
+// loadFailIf returns the gate of the configuration files that apply to the path, nil when none is set
+func loadFailIf(path string) (*GateExpr, error) {
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+	_, configs, err := findConfigFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	expr := strings.TrimSpace(mergeConfigs(configs).FailIf)
+	if expr == "" {
+		return nil, nil
+	}
+	return ParseGateExpr(expr)
+}
+
+// previousFingerprints returns fingerprints of the latest history entry of the prompt, nil without one
+func previousFingerprints(historyPath, name string) ([]string, error) {
+	history, err := loadHistory(historyPath)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := history[name]
+	if !ok {
+		return nil, nil
+	}
+	if entry.Fingerprints == nil {
+		return []string{}, nil
+	}
+	return entry.Fingerprints, nil
+}
+
+// checkGate evaluates the gate against the issues of a run, it returns an error when the gate fails
+func checkGate(gate *GateExpr, issues []Issue, name string) error {
+	if gate == nil {
+		return nil
+	}
+	known, err := previousFingerprints(defaultHistoryFile, name)
+	if err != nil {
+		return err
+	}
+	results := gateResults(issues, known)
+	failed, err := gate.Eval(results)
+	if err != nil {
+		return err
+	}
+	if failed {
+		return fmt.Errorf("fail_if %q is true (%s)", gate, results)
+	}
+	printProgress(fmt.Sprintf("Quality gate passed: fail_if %q is false", gate))
+	return nil
+}
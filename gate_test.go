@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGateExprThresholds(t *testing.T) {
+	tests := []struct {
+		expr    string
+		results GateResults
+		want    bool
+	}{
+		{"score < 80", GateResults{"score": 79}, true},
+		{"score < 80", GateResults{"score": 80}, false},
+		{"score <= 80", GateResults{"score": 80}, true},
+		{"score >= 80.5", GateResults{"score": 80}, false},
+		{"issues.severity.error > 0", GateResults{"issues.severity.error": 1}, true},
+		{"issues.severity.error > 0", GateResults{}, false},
+		{"score < 50 || issues.severity.error > 0", GateResults{"score": 90, "issues.severity.error": 2}, true},
+		{"score < 50 && issues.severity.error > 0", GateResults{"score": 90, "issues.severity.error": 2}, false},
+		{"!(new_issues == 0)", GateResults{"new_issues": 3}, true},
+		{"(score < 80 || issues > 5) && dismissed != 0", GateResults{"score": 95, "issues": 6, "dismissed": 1}, true},
+		{"(issues > 5) == true", GateResults{"issues": 5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			gate, err := ParseGateExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseGateExpr: %v", err)
+			}
+			got, err := gate.Eval(tt.results)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%v) = %v, want %v", tt.results, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGateExprErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"score <", "unexpected end of expression"},
+		{"score < 80 )", `unexpected ")"`},
+		{"(score < 80", "missing closing parenthesis"},
+		{"score", "must be a condition"},
+		{"points < 80", `unknown variable "points"`},
+		{"score < 80 && 5", "&& requires boolean operands"},
+		{"score < 80 || 5", "|| requires boolean operands"},
+		{"score > 80 && issues", "&& requires boolean operands"},
+		{"!score", "! requires boolean operands"},
+		{"(score < 80) < 5", "< requires numbers"},
+		{"score == true", "compares a number with a boolean"},
+		{"score < 80 % 2", "unexpected character"},
+		{"score < 1.2.3", `invalid number "1.2.3"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseGateExpr(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseGateExpr(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGateResults(t *testing.T) {
+	issues := []Issue{
+		{RuleName: "A", Severity: "error", Fingerprint: "a"},
+		{RuleName: "B", Fingerprint: "b"},
+		{RuleName: "C", Severity: "info", Fingerprint: "c"},
+		{RuleName: "D", Severity: "error", Fingerprint: "d", Dismissed: true},
+	}
+	tests := []struct {
+		name  string
+		known []string
+		want  GateResults
+	}{
+		{"without history", nil, GateResults{"issues": 3, "issues.severity.error": 1, "issues.severity.warning": 1, "issues.severity.info": 1, "dismissed": 1, "new_issues": 3}},
+		{"with history", []string{"a", "c"}, GateResults{"issues": 3, "new_issues": 1}},
+		{"with empty history", []string{}, GateResults{"new_issues": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gateResults(issues, tt.known)
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s = %v, want %v", name, got[name], want)
+				}
+			}
+			if got["score"] != float64(qualityScore(issues)) {
+				t.Errorf("score = %v, want %d", got["score"], qualityScore(issues))
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// githubCommands maps issue severities to workflow commands, unknown severities are warnings
+var githubCommands = map[string]string{"error": "error", "warning": "warning", "info": "notice"}
+
+// githubActions reports whether the run is a GitHub Actions job, where --format defaults to github
+func githubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// githubEscapeData escapes the message of a workflow command
+func githubEscapeData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+// githubEscapeProperty escapes a property value of a workflow command
+func githubEscapeProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
+
+// githubPosition returns the 1-based line and column of the issue in the file, 0 when it can't be located.
+// Lines of issues are lines of the prompt text, they are mapped to the file like in editors.
+func githubPosition(file LintedFile, issue Issue) (int, int) {
+	if file.Content == "" {
+		return issue.Line, issue.Column
+	}
+	start, end, exact := locateInFile(file.Content, file.Text, issue)
+	if column, ok := columnInFile(file.Content, file.Text, issue); ok {
+		start, exact = column, true
+	}
+	if !exact && start == end {
+		return 0, 0
+	}
+	lineStart := strings.LastIndexByte(file.Content[:start], '\n') + 1
+	return strings.Count(file.Content[:start], "\n") + 1, utf8.RuneCountInString(file.Content[lineStart:start]) + 1
+}
+
+// ReportGitHub formats the active issues as GitHub Actions workflow commands, shown as annotations on pull requests
+func ReportGitHub(files []LintedFile) string {
+	var sb strings.Builder
+	for _, file := range files {
+		for _, issue := range file.Issues {
+			if issue.Dismissed {
+				continue
+			}
+			command, ok := githubCommands[issue.Severity]
+			if !ok {
+				command = "warning"
+			}
+			var properties []string
+			if file.Name != "" {
+				properties = append(properties, "file="+githubEscapeProperty(filepath.ToSlash(filepath.Clean(file.Name))))
+				if line, column := githubPosition(file, issue); line > 0 {
+					properties = append(properties, fmt.Sprintf("line=%d", line))
+					if column > 0 {
+						properties = append(properties, fmt.Sprintf("col=%d", column))
+					}
+				}
+			}
+			title := appName + ": " + issue.RuleName
+			if issue.Category != "" {
+				title += " (" + issue.Category + ")"
+			}
+			properties = append(properties, "title="+githubEscapeProperty(title))
+
+			message := issue.Description
+			if issue.Fix != "" {
+				message += "\n\nFix: " + issue.Fix
+			}
+			if issue.RuleLink != "" {
+				message += "\n\n" + issue.RuleLink
+			}
+			sb.WriteString(fmt.Sprintf("::%s %s::%s\n", command, strings.Join(properties, ","), githubEscapeData(message)))
+		}
+	}
+	return sb.String()
+}
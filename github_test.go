@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGitHubPosition(t *testing.T) {
+	file := LintedFile{
+		Name:    "prompts/support.md",
+		Content: "---\ntitle: Support\n---\nBe brief.\tPlease\u200b help.\nДай ответ 😀 сразу.\n",
+		Text:    "Be brief.\tPlease\u200b help.\nДай ответ 😀 сразу.\n",
+	}
+	tests := []struct {
+		name       string
+		file       LintedFile
+		issue      Issue
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name:     "reported position without the file content",
+			file:     LintedFile{Name: "prompt.txt"},
+			issue:    Issue{Line: 2, Column: 5},
+			wantLine: 2, wantColumn: 5,
+		},
+		{
+			name:     "snippet without a column",
+			file:     file,
+			issue:    Issue{Line: 1, OriginalSnippet: "Please"},
+			wantLine: 4, wantColumn: 11,
+		},
+		{
+			name:     "analyzer column inside the snippet line",
+			file:     file,
+			issue:    Issue{RuleName: "Invisible Characters", Line: 1, Column: 17, OriginalSnippet: "Be brief.\tPlease\u200b help."},
+			wantLine: 4, wantColumn: 17,
+		},
+		{
+			name:     "column counted in characters",
+			file:     file,
+			issue:    Issue{RuleName: "Emoji Policy", Line: 2, Column: 11, OriginalSnippet: "Дай ответ 😀 сразу."},
+			wantLine: 5, wantColumn: 11,
+		},
+		{
+			name:     "column past the end of the line",
+			file:     file,
+			issue:    Issue{Line: 1, Column: 99, OriginalSnippet: "Please"},
+			wantLine: 4, wantColumn: 11,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, column := githubPosition(tt.file, tt.issue)
+			if line != tt.wantLine || column != tt.wantColumn {
+				t.Errorf("githubPosition() = %d:%d, want %d:%d", line, column, tt.wantLine, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestReportVSCodeColumn(t *testing.T) {
+	files := []LintedFile{{
+		Name:    "prompts/support.md",
+		Content: "---\ntitle: Support\n---\nBe brief.\tPlease\u200b help.\n",
+		Text:    "Be brief.\tPlease\u200b help.\n",
+		Issues:  []Issue{{RuleName: "Invisible Characters", Line: 1, Column: 17, OriginalSnippet: "Be brief.\tPlease\u200b help.", FixedSnippet: "Be brief. Please help."}},
+	}}
+	output, err := ReportVSCode(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report VSCodeReport
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatal(err)
+	}
+	diagnostic := report.Files[0].Diagnostics[0]
+	want := lspRange{Start: lspPosition{Line: 3, Character: 16}, End: lspPosition{Line: 3, Character: 23}}
+	if diagnostic.Range != want {
+		t.Errorf("ReportVSCode() range = %+v, want %+v", diagnostic.Range, want)
+	}
+	// The fix still replaces the whole snippet
+	if len(diagnostic.Fixes) == 0 || diagnostic.Fixes[0].Edits[0].Range.Start != (lspPosition{Line: 3}) {
+		t.Errorf("ReportVSCode() fixes = %+v, want an edit of the whole line", diagnostic.Fixes)
+	}
+}
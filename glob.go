@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// expandGlobs returns files below base matching the patterns in walk order. Patterns are relative to base,
+// use slashes and may contain "**" for any number of directories; a "!" prefix excludes matches.
+// Hidden directories, node_modules and vendor are skipped. Paths are relative to the working directory when possible.
+func expandGlobs(base string, patterns []string) ([]string, error) {
+	var include, exclude [][]string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if strings.HasPrefix(pattern, "!") {
+			exclude = append(exclude, strings.Split(path.Clean(strings.TrimPrefix(pattern, "!")), "/"))
+		} else if pattern != "" {
+			include = append(include, strings.Split(path.Clean(pattern), "/"))
+		}
+	}
+	for _, parts := range append(include, exclude...) {
+		for _, part := range parts {
+			if _, err := path.Match(part, ""); err != nil {
+				return nil, fmt.Errorf("invalid file pattern %q: %w", strings.Join(parts, "/"), err)
+			}
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	var files []string
+	err := filepath.WalkDir(base, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if p != base && (strings.HasPrefix(name, ".") || inventorySkipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if !matchAnyPattern(include, parts) || matchAnyPattern(exclude, parts) {
+			return nil
+		}
+		if cwd != "" {
+			if abs, err := filepath.Abs(p); err == nil {
+				if rel, err := filepath.Rel(cwd, abs); err == nil {
+					p = rel
+				}
+			}
+		}
+		files = append(files, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand file patterns in %s: %w", base, err)
+	}
+	return files, nil
+}
+
+// matchAnyPattern reports whether the path parts match one of the split patterns
+func matchAnyPattern(patterns [][]string, parts []string) bool {
+	for _, pattern := range patterns {
+		if matchWholePath(pattern, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWholePath matches all parts of the path, unlike matchPathParts which accepts a matching prefix
+func matchWholePath(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchWholePath(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchWholePath(pattern[1:], parts[1:])
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// defaultGraphExtensions are the files scanned for the graph, YAML files are workflows or chat prompts
+const defaultGraphExtensions = defaultInventoryExtensions + ",.yaml,.yml"
+
+// Kinds of graph nodes
+const (
+	graphPrompt   = "prompt"
+	graphFragment = "fragment"
+	graphWorkflow = "workflow"
+	graphMissing  = "missing"
+)
+
+// Lint statuses of graph nodes besides the worst severity of the active issues
+const (
+	graphClean     = "clean"
+	graphUnchecked = "unchecked"
+)
+
+var (
+	// partialPattern matches Handlebars partials of dotprompt, {{> name}}
+	partialPattern = regexp.MustCompile(`\{\{~?>\s*([\w./-]+)`)
+	// jinjaIncludePattern matches Jinja includes, {% include "file" %}, extends, import and from
+	jinjaIncludePattern = regexp.MustCompile(`\{%-?\s*(?:include|extends|import|from)\s+["']([^"']+)["']`)
+)
+
+// graphStatusColors are the fill and border colors of the lint statuses
+var graphStatusColors = map[string][2]string{
+	"error":        {"#f4cccc", "#cc0000"},
+	"warning":      {"#fff2cc", "#bf9000"},
+	"info":         {"#cfe2f3", "#3d85c6"},
+	graphClean:     {"#d9ead3", "#38761d"},
+	graphUnchecked: {"#eeeeee", "#999999"},
+	graphMissing:   {"#ffffff", "#cc0000"},
+}
+
+// GraphNode is a prompt, a fragment included by prompts, a workflow of prompt steps or a reference that doesn't resolve
+type GraphNode struct {
+	ID     string
+	Path   string
+	Kind   string
+	Status string
+	Issues int
+}
+
+// GraphEdge is an include of a fragment or a step of a workflow, steps are labeled with their number
+type GraphEdge struct {
+	From, To string
+	Label    string
+}
+
+// PromptGraph is the dependency graph of the prompts of a project
+type PromptGraph struct {
+	Nodes []*GraphNode
+	Edges []GraphEdge
+}
+
+// graphReference is a file a prompt or workflow depends on
+type graphReference struct {
+	name  string
+	step  bool
+	paths []string
+}
+
+// workflowSteps returns the prompt files of the steps of a workflow: paths or mappings with prompt or file
+func workflowSteps(fields map[string]interface{}) []string {
+	items, _ := fields["steps"].([]interface{})
+	var steps []string
+	for _, item := range items {
+		switch step := item.(type) {
+		case string:
+			steps = append(steps, step)
+		case map[string]interface{}:
+			if path := getStringValue(step, "prompt"); path != "" {
+				steps = append(steps, path)
+			} else if path := getStringValue(step, "file"); path != "" {
+				steps = append(steps, path)
+			}
+		}
+	}
+	return steps
+}
+
+// loadGraphFile reads a file of the graph: its prompt, nil for workflows without one, and the steps of a workflow.
+// ok is false when the file holds neither.
+func loadGraphFile(path string) (*Document, []string, bool, error) {
+	if hasExtension(path, ".yaml", ".yml") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var fields map[string]interface{}
+		if yaml.Unmarshal(data, &fields) != nil {
+			return nil, nil, false, nil
+		}
+		steps := workflowSteps(fields)
+		if _, _, err := parseChatYAML(data); err != nil {
+			return nil, steps, len(steps) > 0, nil
+		}
+	}
+	_, doc, ok, err := loadPromptFile(path)
+	if err != nil || !ok {
+		return nil, nil, false, err
+	}
+	return doc, workflowSteps(doc.Frontmatter), true, nil
+}
+
+// graphReferences returns the partials, includes and steps of a file with the candidate paths of each,
+// relative to the file and then to the roots of the graph
+func graphReferences(path string, doc *Document, steps []string, roots []string) []graphReference {
+	dir := pathDir(path)
+	candidates := func(name string, partial bool) []string {
+		var paths []string
+		for _, base := range append([]string{dir}, roots...) {
+			if !partial {
+				paths = append(paths, filepath.Join(base, name))
+				continue
+			}
+			// Dotprompt looks partials up as _name.prompt, next to the prompt or in a partials directory
+			for _, sub := range []string{"", "partials"} {
+				nameDir, file := filepath.Split(name)
+				folder := filepath.Join(base, sub, nameDir)
+				paths = append(paths, filepath.Join(folder, "_"+file+".prompt"), filepath.Join(folder, file+".prompt"), filepath.Join(folder, file))
+			}
+		}
+		return paths
+	}
+
+	var references []graphReference
+	for _, step := range steps {
+		references = append(references, graphReference{name: step, step: true, paths: candidates(step, false)})
+	}
+	if doc == nil {
+		return references
+	}
+	for _, match := range partialPattern.FindAllStringSubmatch(doc.Text, -1) {
+		references = append(references, graphReference{name: match[1], paths: candidates(match[1], true)})
+	}
+	for _, match := range jinjaIncludePattern.FindAllStringSubmatch(doc.Text, -1) {
+		references = append(references, graphReference{name: match[1], paths: candidates(match[1], false)})
+	}
+	return references
+}
+
+// resolveReference returns the first candidate path that is a file, empty when none is
+func resolveReference(reference graphReference) string {
+	for _, path := range reference.paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// buildPromptGraph scans the roots for prompts and workflows and follows their references, files outside the roots
+// are added when referenced. The status of a node is the worst severity of its active issues in the results by path,
+// or of the local analyzers without results.
+func buildPromptGraph(roots []string, extensions []string, results map[string][]Issue) (*PromptGraph, error) {
+	var queue []string
+	var dirs []string
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", root, err)
+		}
+		if !info.IsDir() {
+			queue = append(queue, root)
+			dirs = append(dirs, pathDir(root))
+			continue
+		}
+		files, err := scanPrompts(root, extensions)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, files...)
+		dirs = append(dirs, root)
+	}
+	dirs = appendUnique(nil, dirs...)
+
+	graph := &PromptGraph{}
+	nodes := map[string]*GraphNode{}
+	included := map[string]bool{}
+	stepped := map[string]bool{}
+	node := func(key, path, kind string) *GraphNode {
+		if existing, ok := nodes[key]; ok {
+			return existing
+		}
+		created := &GraphNode{Path: path, Kind: kind}
+		nodes[key] = created
+		graph.Nodes = append(graph.Nodes, created)
+		return created
+	}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		key := mergeKey(path)
+		if _, ok := nodes[key]; ok {
+			continue
+		}
+		doc, steps, ok, err := loadGraphFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// A referenced file that holds no prompt, like an empty fragment, stays in the graph
+			if included[key] || stepped[key] {
+				node(key, key, graphPrompt).Status = graphUnchecked
+			}
+			continue
+		}
+		current := node(key, key, graphPrompt)
+		if len(steps) > 0 {
+			current.Kind = graphWorkflow
+		}
+		current.Status, current.Issues = graphStatus(path, doc, results)
+
+		for i, reference := range graphReferences(path, doc, steps, dirs) {
+			target := resolveReference(reference)
+			edge := GraphEdge{From: key, Label: "includes"}
+			if reference.step {
+				edge.Label = fmt.Sprintf("step %d", i+1)
+			}
+			if target == "" {
+				edge.To = graphMissing + ":" + reference.name
+				missing := node(edge.To, reference.name, graphMissing)
+				missing.Status = graphMissing
+			} else {
+				edge.To = mergeKey(target)
+				queue = append(queue, target)
+			}
+			if reference.step {
+				stepped[edge.To] = true
+			} else {
+				included[edge.To] = true
+			}
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	// Files only included by others are fragments, a step or a file nobody references is a prompt
+	for key, current := range nodes {
+		if current.Kind == graphPrompt && included[key] && !stepped[key] {
+			current.Kind = graphFragment
+		}
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Path < graph.Nodes[j].Path })
+	for i, current := range graph.Nodes {
+		current.ID = fmt.Sprintf("n%d", i)
+	}
+	for i := range graph.Edges {
+		graph.Edges[i].From, graph.Edges[i].To = nodes[graph.Edges[i].From].ID, nodes[graph.Edges[i].To].ID
+	}
+	return graph, nil
+}
+
+// graphStatus returns the lint status and the number of active issues of a file, workflows without a prompt are unchecked
+func graphStatus(path string, doc *Document, results map[string][]Issue) (string, int) {
+	var issues []Issue
+	switch {
+	case results != nil:
+		found, ok := results[mergeKey(path)]
+		if !ok {
+			return graphUnchecked, 0
+		}
+		issues = found
+	case doc == nil:
+		return graphUnchecked, 0
+	default:
+		if err := loadAnalyzerSettings(path); err != nil {
+			printProgress(fmt.Sprintf("Failed to load the configuration of %s: %v", path, err))
+		}
+		model := linter.ParsePrompt(doc.Text)
+		issues = runAnalyzers(model)
+		locateIssues(issues, model)
+		issues = linter.ApplySuppressions(issues, model)
+	}
+	status, count := graphClean, 0
+	for _, issue := range issues {
+		if issue.Dismissed {
+			continue
+		}
+		count++
+		severity := issue.Severity
+		if severity == "" {
+			severity = "warning"
+		}
+		if status == graphClean || severityRank(severity) > severityRank(status) {
+			status = severity
+		}
+	}
+	return status, count
+}
+
+// graphLabel names the node and its lint status
+func graphLabel(node *GraphNode) string {
+	switch {
+	case node.Kind == graphMissing:
+		return node.Path + " (not found)"
+	case node.Issues == 1:
+		return node.Path + " (1 issue)"
+	case node.Issues > 1:
+		return fmt.Sprintf("%s (%d issues)", node.Path, node.Issues)
+	case node.Status == graphClean:
+		return node.Path + " (clean)"
+	}
+	return node.Path
+}
+
+// FormatGraphDOT formats the graph for Graphviz: workflows are folders, fragments notes, unresolved references dashed
+func FormatGraphDOT(graph *PromptGraph) string {
+	shapes := map[string]string{graphPrompt: "box", graphFragment: "note", graphWorkflow: "folder", graphMissing: "box"}
+	quote := func(value string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+	var sb strings.Builder
+	sb.WriteString("digraph prompts {\n  rankdir=LR;\n  node [style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	for _, node := range graph.Nodes {
+		colors := graphStatusColors[node.Status]
+		style := ""
+		if node.Kind == graphMissing {
+			style = `, style="dashed,filled"`
+		}
+		sb.WriteString(fmt.Sprintf("  %s [label=%s, shape=%s, fillcolor=%s, color=%s%s];\n",
+			node.ID, quote(graphLabel(node)), shapes[node.Kind], quote(colors[0]), quote(colors[1]), style))
+	}
+	for _, edge := range graph.Edges {
+		sb.WriteString(fmt.Sprintf("  %s -> %s [label=%s];\n", edge.From, edge.To, quote(edge.Label)))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// FormatGraphMermaid formats the graph as a Mermaid flowchart: workflows are subroutines, fragments rounded,
+// statuses are classes
+func FormatGraphMermaid(graph *PromptGraph) string {
+	shapes := map[string][2]string{graphPrompt: {"[", "]"}, graphFragment: {"(", ")"}, graphWorkflow: {"[[", "]]"}, graphMissing: {"[", "]"}}
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	byStatus := map[string][]string{}
+	for _, node := range graph.Nodes {
+		shape := shapes[node.Kind]
+		label := strings.ReplaceAll(graphLabel(node), `"`, "#quot;")
+		sb.WriteString(fmt.Sprintf("  %s%s\"%s\"%s\n", node.ID, shape[0], label, shape[1]))
+		byStatus[node.Status] = append(byStatus[node.Status], node.ID)
+	}
+	for _, edge := range graph.Edges {
+		sb.WriteString(fmt.Sprintf("  %s -->|%s| %s\n", edge.From, edge.Label, edge.To))
+	}
+	statuses := make([]string, 0, len(byStatus))
+	for status := range byStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		colors := graphStatusColors[status]
+		definition := fmt.Sprintf("  classDef %s fill:%s,stroke:%s", status, colors[0], colors[1])
+		if status == graphMissing {
+			definition += ",stroke-dasharray:4"
+		}
+		sb.WriteString(definition + "\n")
+		sb.WriteString(fmt.Sprintf("  class %s %s\n", strings.Join(byStatus[status], ","), status))
+	}
+	return sb.String()
+}
+
+// runGraphCommand implements `promptlint graph [dir|file...]`
+func runGraphCommand(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "Output format: dot (Graphviz), mermaid")
+	output := fs.String("output", "", "Write the graph to the file instead of stdout")
+	extensions := fs.String("ext", defaultGraphExtensions, "Comma-separated extensions of prompt and workflow files")
+	var resultFiles stringsFlag
+	fs.Var(&resultFiles, "results", "JSON result of check (--format=json) that colors the nodes, repeatable, default: local analyzers")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s graph [--format=dot|mermaid] [--results=result.json] [dir|file...]
+
+Prints the dependency graph of the prompts of the directories (default:
+current) or files: fragments included with dotprompt partials ({{> name}},
+looked up as _name.prompt) or Jinja include, extends, import and from, and
+workflows listing prompts as steps (steps in YAML files or the frontmatter,
+paths or mappings with prompt). Nodes are colored by lint status: the worst
+severity of their active issues, clean, unchecked or not found. Statuses come
+from --results of an earlier check run, or from the local analyzers without
+LLM calls.
+
+  %s graph --format=mermaid prompts/ > graph.mmd
+  %s graph prompts/ | dot -Tsvg > graph.svg
+
+Options:
+`, appName, appName, appName)
+		fs.PrintDefaults()
+	}
+
+	roots, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *format != "dot" && *format != "mermaid" {
+		return fmt.Errorf("--format must be dot or mermaid")
+	}
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	var results map[string][]Issue
+	if len(resultFiles) > 0 {
+		var files []LintedFile
+		for _, path := range resultFiles {
+			read, err := readResultFile(path)
+			if err != nil {
+				return err
+			}
+			files = append(files, read...)
+		}
+		merged, _ := mergeResults(files)
+		results = map[string][]Issue{}
+		for _, file := range merged {
+			results[mergeKey(file.Name)] = file.Issues
+		}
+	}
+
+	graph, err := buildPromptGraph(roots, parseExtensions(*extensions), results)
+	if err != nil {
+		return err
+	}
+	printProgress(fmt.Sprintf("Found %d nodes and %d dependencies", len(graph.Nodes), len(graph.Edges)))
+
+	text := FormatGraphDOT(graph)
+	if *format == "mermaid" {
+		text = FormatGraphMermaid(graph)
+	}
+	if *output == "" {
+		fmt.Print(text)
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	printProgress("Wrote graph to " + *output)
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+)
+
+// heuristicModelName marks results of the local heuristic judge
+const heuristicModelName = "heuristic"
+
+// heuristicNotice is shown with every report produced without an LLM, it names the key variables of the provider
+const heuristicNotice = "Heuristic-only results: %s is not set, so issues were found by local pattern checks that approximate the most common rule violations. Set the API key for a full LLM review."
+
+// offlineNotice replaces heuristicNotice in offline mode, where the API key doesn't matter
+const offlineNotice = "Heuristic-only results: offline mode, so issues were found by local pattern checks that approximate the most common rule violations."
+
+// heuristicMessage returns the notice of the current mode for the provider
+func heuristicMessage(provider Provider) string {
+	if offline {
+		return offlineNotice
+	}
+	return fmt.Sprintf(heuristicNotice, llm.KeyHint(provider))
+}
+
+// printHeuristicNotice warns on stderr that the results don't come from an LLM
+func printHeuristicNotice(config *LLMConfig) {
+	if !config.Heuristic || ruleEngine == "static" {
+		return
+	}
+	notice := heuristicMessage(config.Provider)
+	switch {
+	case accessibleOutput:
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", notice)
+	case useColorForProgress:
+		fmt.Fprintf(os.Stderr, "%s%s%s\n", colorYellow, notice, colorReset)
+	default:
+		fmt.Fprintln(os.Stderr, notice)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "github.com/korchasa/promptlint/pkg/rules"
+
+// ruleLocale is the language code rule texts are rendered in, set from --locale or the locale of the project configuration
+var ruleLocale = ""
+
+// overrideLocalized overrides a localized text. A text replaces it with its translations; translations without
+// an English text are added to the existing ones and keep the default text.
+func overrideLocalized(text string, texts map[string]string, overrideText string, overrideTexts map[string]string) (string, map[string]string) {
+	if overrideTexts == nil {
+		return overrideText, nil
+	}
+	if _, ok := overrideTexts[rules.DefaultLanguage]; ok {
+		return overrideText, overrideTexts
+	}
+	merged := map[string]string{}
+	for code, value := range texts {
+		merged[code] = value
+	}
+	for code, value := range overrideTexts {
+		merged[code] = value
+	}
+	return text, merged
+}
+
+// configuredLocale returns the locale of the project configuration of the working directory, empty on errors
+// that are reported when the configuration is loaded for linting
+func configuredLocale() string {
+	settings, err := loadRunSettings(".")
+	if err != nil {
+		return ""
+	}
+	return settings.Locale
+}
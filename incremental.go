@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+const (
+	// analysisCacheVersion invalidates cached results when the chunk request changes
+	analysisCacheVersion = "1"
+	// minChunkLength is the size paragraphs of prompts without sections are grouped up to
+	minChunkLength = 800
+)
+
+// chunkCheckInstruction introduces a chunk of a larger prompt in incremental mode
+const chunkCheckInstruction = "Analyze the following part of a larger prompt against the specified rules. " +
+	"Report only problems inside this part, not content that may be missing here but present in other parts:"
+
+// analysisCacheEntry is the stored result of checking one chunk
+type analysisCacheEntry struct {
+	Issues    []Issue   `json:"issues"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// splitPromptChunks splits the prompt at top-level sections, prompts without sections are split into
+// groups of paragraphs, so an edit invalidates only the chunk it touches
+func splitPromptChunks(model *PromptModel) []string {
+	var starts []int
+	end := -1
+	for _, section := range model.Sections {
+		if section.Start.Offset >= end {
+			starts = append(starts, section.Start.Offset)
+			end = section.End.Offset
+		}
+	}
+
+	var chunks []string
+	if len(starts) > 0 {
+		if starts[0] > 0 {
+			starts = append([]int{0}, starts...)
+		}
+		for i, start := range starts {
+			stop := len(model.Text)
+			if i+1 < len(starts) {
+				stop = starts[i+1]
+			}
+			if chunk := strings.TrimSpace(model.Text[start:stop]); chunk != "" {
+				chunks = append(chunks, chunk)
+			}
+		}
+		return chunks
+	}
+
+	var current strings.Builder
+	for _, paragraph := range strings.Split(model.Text, "\n\n") {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(strings.TrimSpace(paragraph))
+		if current.Len() >= minChunkLength {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// analysisCacheKey identifies the result of checking a chunk with the rules and the model
+func analysisCacheKey(chunk, rulesHash string, config *LLMConfig) string {
+	parts := []string{
+		analysisCacheVersion,
+		chunkCheckInstruction,
+		judgePrompt,
+		rulesHash,
+		config.APIEndpoint,
+		config.ModelName,
+		fmt.Sprint(config.Alternatives, config.Seed),
+		chunk,
+	}
+	return sha256Hex([]byte(strings.Join(parts, "\x00")))
+}
+
+// analysisCachePath returns the file of a cache entry, entries are spread over subdirectories by key prefix
+func analysisCachePath(key string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "analysis", key[:2], key+".json"), nil
+}
+
+// loadCachedAnalysis returns the cached issues of a chunk, ok is false on a cache miss
+func loadCachedAnalysis(key string) ([]Issue, bool, error) {
+	path, err := analysisCachePath(key)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read analysis cache: %w", err)
+	}
+	var entry analysisCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// A corrupted entry is a miss, it is overwritten by the next result
+		return nil, false, nil
+	}
+	return entry.Issues, true, nil
+}
+
+// storeCachedAnalysis saves the issues of a chunk
+func storeCachedAnalysis(key string, issues []Issue) error {
+	path, err := analysisCachePath(key)
+	if err != nil {
+		return err
+	}
+	if issues == nil {
+		issues = []Issue{}
+	}
+	data, err := json.Marshal(analysisCacheEntry{Issues: issues, CheckedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create analysis cache: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write analysis cache: %w", err)
+	}
+	return nil
+}
+
+// checkPromptIncremental checks the prompt chunk by chunk and reuses cached results of unchanged chunks.
+// Static rules and analyzers are local and always run on the whole prompt.
+func checkPromptIncremental(prompt string, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	// Heuristics and static rules judge the whole prompt and cost nothing to rerun
+	if config.Heuristic || ruleEngine == "static" {
+		return checkPromptWithLLM(prompt, rules, config)
+	}
+	model := linter.ParsePrompt(prompt)
+	hash, err := rulesHash(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := splitPromptChunks(model)
+	var issues []Issue
+	seen := map[string]bool{}
+	cached := 0
+	for i, chunk := range chunks {
+		key := analysisCacheKey(chunk, hash, config)
+		chunkIssues, ok, err := loadCachedAnalysis(key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cached++
+		} else {
+			printProgress(fmt.Sprintf("Checking changed chunk %d/%d", i+1, len(chunks)))
+			if chunkIssues, err = checkContentWithLLM(chunkCheckInstruction, chunk, rules, config); err != nil {
+				return nil, err
+			}
+			if err := storeCachedAnalysis(key, chunkIssues); err != nil {
+				return nil, err
+			}
+		}
+		// Positions are relative to the chunk, they are located again in the whole prompt
+		for j := range chunkIssues {
+			chunkIssues[j].Line, chunkIssues[j].Column = 0, 0
+		}
+		linter.AttachRuleDetails(chunkIssues, rules, ruleLocale)
+		// A problem quoted identically from several chunks is reported once
+		for _, issue := range chunkIssues {
+			if issue.Fingerprint != "" && seen[issue.Fingerprint] {
+				continue
+			}
+			seen[issue.Fingerprint] = true
+			issues = append(issues, issue)
+		}
+	}
+	printProgress(fmt.Sprintf("Incremental analysis: %d chunks, %d from cache, %d checked", len(chunks), cached, len(chunks)-cached))
+
+	issues = append(issues, localIssues(model, rules)...)
+	locateIssues(issues, model)
+	return linter.ApplySuppressions(issues, model), nil
+}
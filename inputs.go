@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LintedFile is a prompt linted in a run, Name is empty for stdin without --stdin-filename
+type LintedFile struct {
+	Name string
+	Text string
+	// Content is the file as read, Text is the prompt extracted from it
+	Content       string
+	Issues        []Issue
+	Disagreements []Disagreement
+}
+
+// MultiJSONReport is the JSON report of a run over several files
+type MultiJSONReport struct {
+	Files     []JSONReport `json:"files"`
+	Issues    int          `json:"issues"`
+	Dismissed int          `json:"dismissed"`
+}
+
+// hasGlobMeta reports whether the argument is a glob pattern rather than a path
+func hasGlobMeta(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// splitGlobBase splits a pattern into the directory before the first wildcard and the pattern below it
+func splitGlobBase(pattern string) (string, string) {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(parts)-1 && !hasGlobMeta(parts[i]) {
+		i++
+	}
+	base := strings.Join(parts[:i], "/")
+	if base == "" && strings.HasPrefix(pattern, "/") {
+		base = "/"
+	} else if base == "" {
+		base = "."
+	}
+	return filepath.FromSlash(base), strings.Join(parts[i:], "/")
+}
+
+// resolveInputs expands glob arguments, quoted patterns like 'prompts/**/*.md' are expanded by
+// promptlint itself, and removes duplicates keeping the first occurrence
+func resolveInputs(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		matches := []string{arg}
+		if hasGlobMeta(arg) {
+			base, pattern := splitGlobBase(arg)
+			if _, err := os.Stat(base); errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("%s matches no files", arg)
+			}
+			expanded, err := expandGlobs(base, []string{pattern})
+			if err != nil {
+				return nil, err
+			}
+			if len(expanded) == 0 {
+				return nil, fmt.Errorf("%s matches no files", arg)
+			}
+			matches = expanded
+		}
+		files = appendUnique(files, matches...)
+	}
+	return files, nil
+}
+
+// appendUnique appends the files missing from the list, paths are compared cleaned
+func appendUnique(list []string, files ...string) []string {
+	seen := map[string]bool{}
+	for _, file := range list {
+		seen[filepath.Clean(file)] = true
+	}
+	for _, file := range files {
+		if key := filepath.Clean(file); !seen[key] {
+			seen[key] = true
+			list = append(list, file)
+		}
+	}
+	return list
+}
+
+// matchesPatterns applies gitignore-like patterns to a relative path: the last matching pattern decides
+// and a "!" prefix negates it
+func matchesPatterns(patterns []string, rel string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		if matchCodeOwners(strings.TrimPrefix(pattern, "!"), rel) {
+			matched = !negated
+		}
+	}
+	return matched
+}
+
+// scanInputDir finds the prompt files of a directory tree for --dir. Include patterns, when given, select files;
+// exclude patterns drop them. Both are gitignore-like and relative to the directory.
+func scanInputDir(dir string, extensions, include, exclude []string) ([]string, error) {
+	candidates, err := scanPrompts(dir, extensions)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range candidates {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+		rel = filepath.ToSlash(rel)
+		if len(include) > 0 && !matchesPatterns(include, rel) {
+			continue
+		}
+		if matchesPatterns(exclude, rel) {
+			continue
+		}
+		// Data files sharing an extension with prompts, like JSON without chat messages, are skipped
+		if _, _, ok, err := loadPromptFile(file); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// ReportFilesSummary lists the issue counts and scores of the files of a multi-file run
+func ReportFilesSummary(files []LintedFile, forceColor, noColor bool) string {
+	useColor := !accessibleOutput && (forceColor || (!noColor && isColorTerminal()))
+	total, dismissed := 0, 0
+	for _, file := range files {
+		active := countActive(file.Issues)
+		total += active
+		dismissed += len(file.Issues) - active
+	}
+	title := fmt.Sprintf("Summary: %d files, %d issues", len(files), total)
+	if dismissed > 0 {
+		title += fmt.Sprintf(" (%d dismissed)", dismissed)
+	}
+	var sb strings.Builder
+	if useColor {
+		sb.WriteString(colorBold + title + colorReset + "\n")
+	} else {
+		sb.WriteString(title + "\n")
+	}
+	for _, file := range files {
+		sb.WriteString(fmt.Sprintf("  %s: %d issues, score %d\n", file.Name, countActive(file.Issues), qualityScore(file.Issues)))
+	}
+	return sb.String()
+}
+
+// ReportFilesJSON formats the results of several files as one JSON document
+func ReportFilesJSON(files []LintedFile) (string, error) {
+	report := MultiJSONReport{Files: make([]JSONReport, 0, len(files))}
+	for _, file := range files {
+		fileReport := newJSONReport(file.Name, file.Issues, file.Disagreements)
+		report.Files = append(report.Files, fileReport)
+		report.Issues += len(fileReport.Issues) - fileReport.Dismissed
+		report.Dismissed += fileReport.Dismissed
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+)
+
+// defaultInventoryExtensions are the extensions of files considered prompts by `inventory`
+const defaultInventoryExtensions = ".md,.txt,.prompt,.prompty,.json"
+
+// inventorySkipDirs are directories that never contain project prompts
+var inventorySkipDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// inventorySkipNames are documentation files that share extensions with prompts
+var inventorySkipNames = map[string]bool{
+	"readme": true, "changelog": true, "license": true, "contributing": true,
+	"code_of_conduct": true, "security": true, "codeowners": true,
+}
+
+// codeOwnersFiles are the CODEOWNERS locations in the order GitHub looks them up
+var codeOwnersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Inventory lists the prompts of a repository for governance tracking
+type Inventory struct {
+	Tool        string           `json:"tool"`
+	Version     string           `json:"version"`
+	Root        string           `json:"root"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+	Prompts     []InventoryEntry `json:"prompts"`
+}
+
+// InventoryEntry describes a prompt file, Score and LintedAt come from the cron history
+type InventoryEntry struct {
+	Path     string     `json:"path"`
+	SHA256   string     `json:"sha256"`
+	Size     int        `json:"size"`
+	Tokens   int        `json:"tokens"`
+	Format   string     `json:"format"`
+	Model    string     `json:"model,omitempty"`
+	Owners   []string   `json:"owners"`
+	Score    *int       `json:"score,omitempty"`
+	LintedAt *time.Time `json:"lintedAt,omitempty"`
+}
+
+// codeOwnersRule is a line of a CODEOWNERS file
+type codeOwnersRule struct {
+	Pattern string
+	Owners  []string
+}
+
+// loadCodeOwners reads the first CODEOWNERS file of the repository, nil when there is none
+func loadCodeOwners(root string) ([]codeOwnersRule, error) {
+	for _, name := range codeOwnersFiles {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		var rules []codeOwnersRule
+		for _, line := range splitLines(string(data)) {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			rules = append(rules, codeOwnersRule{Pattern: fields[0], Owners: fields[1:]})
+		}
+		return rules, nil
+	}
+	return nil, nil
+}
+
+// matchCodeOwners reports whether a CODEOWNERS pattern matches the slash-separated path relative to the root.
+// Patterns follow gitignore rules: a leading or inner slash anchors to the root, a trailing slash or a
+// directory name matches everything below, "**" matches any number of directories.
+func matchCodeOwners(pattern, file string) bool {
+	if pattern == "*" {
+		return true
+	}
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimSuffix(pattern, "/**")
+
+	parts := strings.Split(file, "/")
+	starts := []int{0}
+	if !anchored {
+		starts = starts[:0]
+		for i := range parts {
+			starts = append(starts, i)
+		}
+	}
+	for _, start := range starts {
+		if matchPathParts(strings.Split(pattern, "/"), parts[start:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathParts matches pattern segments against a prefix of the path segments, the rest of the path is below the match
+func matchPathParts(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchPathParts(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchPathParts(pattern[1:], parts[1:])
+}
+
+// codeOwners returns the owners of the last matching rule, like GitHub does
+func codeOwners(rules []codeOwnersRule, file string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matchCodeOwners(rules[i].Pattern, file) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// frontmatterOwners returns owners declared in the prompt header as `owner` or `owners`
+func frontmatterOwners(frontmatter map[string]interface{}) []string {
+	var owners []string
+	for _, key := range []string{"owner", "owners"} {
+		switch value := frontmatter[key].(type) {
+		case string:
+			owners = append(owners, strings.Fields(strings.ReplaceAll(value, ",", " "))...)
+		case []interface{}:
+			for _, item := range value {
+				if s, ok := item.(string); ok && s != "" {
+					owners = append(owners, s)
+				}
+			}
+		}
+	}
+	return owners
+}
+
+// targetModel returns the model the prompt is written for: `model` of the frontmatter (a name or a
+// Prompty model configuration) or of a chat JSON request
+func targetModel(doc *Document, data []byte) string {
+	model := doc.Frontmatter["model"]
+	if model == nil && doc.Format == "chat-json" {
+		var request map[string]interface{}
+		if json.Unmarshal(data, &request) == nil {
+			model = request["model"]
+		}
+	}
+	switch value := model.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		if configuration, ok := value["configuration"].(map[string]interface{}); ok {
+			value = configuration
+		}
+		for _, key := range []string{"name", "model", "azure_deployment"} {
+			if name, ok := value[key].(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// isInventoryCandidate reports whether a file may be a prompt by its name
+func isInventoryCandidate(name string, extensions []string) bool {
+	base := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	if inventorySkipNames[base] {
+		return false
+	}
+	return hasExtension(name, extensions...)
+}
+
+// scanPrompts finds prompt files below the root, hidden and dependency directories are skipped
+func scanPrompts(root string, extensions []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if p != root && (strings.HasPrefix(name, ".") || inventorySkipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() && isInventoryCandidate(name, extensions) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// parseExtensions splits a comma-separated list of file extensions
+func parseExtensions(value string) []string {
+	var exts []string
+	for _, ext := range strings.Split(value, ",") {
+		if ext = strings.ToLower(strings.TrimSpace(ext)); ext != "" {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// loadPromptFile reads a scanned file and extracts its prompt, ok is false when the file doesn't hold a prompt
+func loadPromptFile(file string) ([]byte, *Document, bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if isBinary(data) {
+		return nil, nil, false, nil
+	}
+	// JSON files are prompts only when they hold chat messages
+	if hasExtension(file, ".json") {
+		if _, err := parseChatJSON(data); err != nil {
+			return nil, nil, false, nil
+		}
+	}
+	doc, err := loadDocument(file, data, "auto")
+	if err != nil || strings.TrimSpace(doc.Text) == "" {
+		return nil, nil, false, nil
+	}
+	return data, doc, true, nil
+}
+
+// inventoryEntry describes a prompt file, ok is false when the file doesn't hold a prompt
+func inventoryEntry(root, file string, owners []codeOwnersRule) (InventoryEntry, bool, error) {
+	data, doc, ok, err := loadPromptFile(file)
+	if err != nil || !ok {
+		return InventoryEntry{}, false, err
+	}
+
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return InventoryEntry{}, false, err
+	}
+	rel = filepath.ToSlash(rel)
+	entry := InventoryEntry{
+		Path:   rel,
+		SHA256: sha256Hex(data),
+		Size:   len(data),
+		Tokens: analyzers.EstimateTokens(doc.Text),
+		Format: doc.Format,
+		Model:  targetModel(doc, data),
+		Owners: frontmatterOwners(doc.Frontmatter),
+	}
+	if len(entry.Owners) == 0 {
+		entry.Owners = codeOwners(owners, rel)
+	}
+	if entry.Owners == nil {
+		entry.Owners = []string{}
+	}
+	return entry, true, nil
+}
+
+// historyNames maps prompt paths relative to the root to their names in the cron history
+func historyNames(root, catalogPath string) (map[string]string, error) {
+	names := map[string]string{}
+	if catalogPath == "" {
+		return names, nil
+	}
+	catalog, err := loadCatalog(catalogPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, prompt := range catalog.Prompts {
+		if strings.Contains(prompt.Source, "://") {
+			continue
+		}
+		if rel, err := filepath.Rel(root, prompt.Source); err == nil {
+			names[filepath.ToSlash(rel)] = prompt.Name
+		}
+	}
+	return names, nil
+}
+
+// buildInventory scans the root and attaches the latest lint results of the history
+func buildInventory(root string, extensions []string, historyPath, catalogPath string) (*Inventory, error) {
+	files, err := scanPrompts(root, extensions)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := loadCodeOwners(root)
+	if err != nil {
+		return nil, err
+	}
+	history, err := loadHistory(historyPath)
+	if err != nil {
+		return nil, err
+	}
+	names, err := historyNames(root, catalogPath)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &Inventory{Tool: appName, Version: appVersion, Root: root, GeneratedAt: time.Now().UTC(), Prompts: []InventoryEntry{}}
+	for _, file := range files {
+		entry, ok, err := inventoryEntry(root, file, owners)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		name, ok := names[entry.Path]
+		if !ok {
+			name = entry.Path
+		}
+		if last, ok := history[name]; ok {
+			score := scoreForIssues(last.Issues)
+			checkedAt := last.CheckedAt
+			entry.Score, entry.LintedAt = &score, &checkedAt
+		}
+		inventory.Prompts = append(inventory.Prompts, entry)
+	}
+	sort.Slice(inventory.Prompts, func(i, j int) bool { return inventory.Prompts[i].Path < inventory.Prompts[j].Path })
+	return inventory, nil
+}
+
+// writeInventoryCSV writes one row per prompt, owners are separated by spaces
+func writeInventoryCSV(w io.Writer, inventory *Inventory) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"path", "sha256", "size", "tokens", "format", "model", "owners", "score", "linted_at"}); err != nil {
+		return err
+	}
+	for _, entry := range inventory.Prompts {
+		score, lintedAt := "", ""
+		if entry.Score != nil {
+			score = strconv.Itoa(*entry.Score)
+			lintedAt = entry.LintedAt.Format(time.RFC3339)
+		}
+		row := []string{entry.Path, entry.SHA256, strconv.Itoa(entry.Size), strconv.Itoa(entry.Tokens), entry.Format, entry.Model, strings.Join(entry.Owners, " "), score, lintedAt}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// runInventoryCommand implements `promptlint inventory [dir]`
+func runInventoryCommand(args []string) error {
+	fs := flag.NewFlagSet("inventory", flag.ExitOnError)
+	format := fs.String("format", "json", "Output format: json, csv")
+	output := fs.String("output", "", "Write the inventory to the file instead of stdout")
+	extensions := fs.String("ext", defaultInventoryExtensions, "Comma-separated extensions of prompt files")
+	historyPath := fs.String("history", defaultHistoryFile, "Cron history with the latest lint results")
+	catalogPath := fs.String("catalog", "", "Catalog mapping prompt names of the history to files")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s inventory [--format=json|csv] [--output=file] [dir]
+
+Scans the directory (default: current) for prompts and lists their path, hash,
+size, estimated tokens, target model, owners and the latest lint score of the
+cron history. Owners come from owner/owners in the prompt frontmatter or from
+CODEOWNERS. No LLM calls are made.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	dirs, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(dirs) > 1 {
+		fs.Usage()
+		return fmt.Errorf("at most one directory is allowed")
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("--format must be json or csv")
+	}
+	root := "."
+	if len(dirs) == 1 {
+		root = dirs[0]
+	}
+
+	inventory, err := buildInventory(root, parseExtensions(*extensions), *historyPath, *catalogPath)
+	if err != nil {
+		return err
+	}
+	printProgress(fmt.Sprintf("Found %d prompts in %s", len(inventory.Prompts), root))
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if *format == "csv" {
+		if err := writeInventoryCSV(out, inventory); err != nil {
+			return fmt.Errorf("failed to write inventory: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory: %w", err)
+	}
+	if _, err := fmt.Fprintln(out, string(data)); err != nil {
+		return fmt.Errorf("failed to write inventory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// judgePrompt is the version of the judge system message, set from --judge-prompt or the project configuration
+var judgePrompt = ""
+
+// configuredJudgePrompt returns the judge prompt version of the project configuration of the working directory,
+// empty on errors that are reported when the configuration is loaded for linting
+func configuredJudgePrompt() string {
+	settings, err := loadRunSettings(".")
+	if err != nil {
+		return ""
+	}
+	return settings.JudgePrompt
+}
+
+// JudgeScore is how well a judge prompt version agrees with the labels of the corpus.
+// Found counts issues labeled correct the judge reports, Repeated issues labeled incorrect it reports again.
+type JudgeScore struct {
+	Version   string  `json:"version"`
+	Found     int     `json:"found"`
+	Repeated  int     `json:"repeated"`
+	Accuracy  float64 `json:"accuracy"`
+	Unlabeled int     `json:"unlabeled"`
+	Errors    int     `json:"errors"`
+}
+
+// JudgeRuleScore compares the accuracy of both versions on the labels of a rule
+type JudgeRuleScore struct {
+	Rule      string  `json:"rule"`
+	Labeled   int     `json:"labeled"`
+	AccuracyA float64 `json:"accuracyA"`
+	AccuracyB float64 `json:"accuracyB"`
+}
+
+// JudgeABReport is the result of `judge-ab`
+type JudgeABReport struct {
+	Model     string           `json:"model"`
+	Prompts   int              `json:"prompts"`
+	Correct   int              `json:"correct"`
+	Incorrect int              `json:"incorrect"`
+	Skipped   int              `json:"skipped"`
+	A         JudgeScore       `json:"a"`
+	B         JudgeScore       `json:"b"`
+	Rules     []JudgeRuleScore `json:"rules"`
+}
+
+// judgeCorpusPrompt is a labeled prompt of the corpus with the latest labels of its issues
+type judgeCorpusPrompt struct {
+	name   string
+	text   string
+	labels []FeedbackExample
+}
+
+// judgeCorpus groups the latest labels by prompt in the order prompts were first labeled. Labels of rules
+// outside the rule set are skipped, analyzers don't depend on the judge prompt.
+func judgeCorpus(examples []FeedbackExample, rules *Rules) ([]*judgeCorpusPrompt, int) {
+	latest := map[string]int{}
+	var labeled []FeedbackExample
+	for _, example := range examples {
+		if example.Label != labelCorrect && example.Label != labelIncorrect {
+			continue
+		}
+		if i, ok := latest[feedbackKey(example)]; ok {
+			labeled[i] = example
+			continue
+		}
+		latest[feedbackKey(example)] = len(labeled)
+		labeled = append(labeled, example)
+	}
+
+	var corpus []*judgeCorpusPrompt
+	byHash := map[string]*judgeCorpusPrompt{}
+	skipped := 0
+	for _, example := range labeled {
+		rule := rules.FindRule(example.Rule)
+		if rule == nil || example.PromptText == "" {
+			skipped++
+			continue
+		}
+		example.Rule = rule.Name
+		prompt, ok := byHash[example.PromptHash]
+		if !ok {
+			prompt = &judgeCorpusPrompt{name: example.Prompt, text: example.PromptText}
+			byHash[example.PromptHash] = prompt
+			corpus = append(corpus, prompt)
+		}
+		prompt.labels = append(prompt.labels, example)
+	}
+	return corpus, skipped
+}
+
+// judgeReports reports whether one of the issues is the labeled issue: the same rule with the same fingerprint
+// or overlapping snippets, labels without a snippet match any issue of the rule
+func judgeReports(issues []Issue, label FeedbackExample) bool {
+	labelSnippet := strings.ToLower(strings.Join(strings.Fields(label.OriginalSnippet), " "))
+	for _, issue := range issues {
+		if !strings.EqualFold(issue.RuleName, label.Rule) {
+			continue
+		}
+		if issue.Fingerprint == label.Fingerprint || labelSnippet == "" {
+			return true
+		}
+		snippet := strings.ToLower(strings.Join(strings.Fields(issue.OriginalSnippet), " "))
+		if snippet != "" && (strings.Contains(snippet, labelSnippet) || strings.Contains(labelSnippet, snippet)) {
+			return true
+		}
+	}
+	return false
+}
+
+// judgeUnlabeled counts the issues that match none of the labels of the prompt
+func judgeUnlabeled(issues []Issue, labels []FeedbackExample) int {
+	unlabeled := 0
+	for _, issue := range issues {
+		matched := false
+		for _, label := range labels {
+			matched = matched || judgeReports([]Issue{issue}, label)
+		}
+		if !matched {
+			unlabeled++
+		}
+	}
+	return unlabeled
+}
+
+// add counts a labeled issue the version reported or missed and returns whether the version agrees with the label
+func (s *JudgeScore) add(reported, correct bool) bool {
+	switch {
+	case correct && reported:
+		s.Found++
+	case !correct && reported:
+		s.Repeated++
+	}
+	return reported == correct
+}
+
+// judgeRun lints the prompt with the judge prompt version, it selects the version through the global setting
+func judgeRun(version, prompt string, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	previous := judgePrompt
+	judgePrompt = version
+	defer func() { judgePrompt = previous }()
+	return checkContentWithLLM(promptCheckInstruction, prompt, rules, config)
+}
+
+// compareJudgePrompts lints every prompt of the corpus with both versions and scores them against the labels
+func compareJudgePrompts(corpus []*judgeCorpusPrompt, versionA, versionB string, rules *Rules, config *LLMConfig) JudgeABReport {
+	report := JudgeABReport{Model: config.ModelName, Prompts: len(corpus), A: JudgeScore{Version: versionA}, B: JudgeScore{Version: versionB}, Rules: []JudgeRuleScore{}}
+	type ruleCounts struct{ labeled, agreeA, agreeB int }
+	byRule := map[string]*ruleCounts{}
+	var ruleOrder []string
+	agreeA, agreeB, labeled := 0, 0, 0
+
+	for i, prompt := range corpus {
+		printProgress(fmt.Sprintf("Judging %s (%d/%d)", prompt.name, i+1, len(corpus)))
+		issuesA, errA := judgeRun(versionA, prompt.text, rules, config)
+		if errA != nil {
+			report.A.Errors++
+			printProgress(fmt.Sprintf("Warning: %s with %s: %v", prompt.name, versionA, errA))
+		}
+		issuesB, errB := judgeRun(versionB, prompt.text, rules, config)
+		if errB != nil {
+			report.B.Errors++
+			printProgress(fmt.Sprintf("Warning: %s with %s: %v", prompt.name, versionB, errB))
+		}
+		if errA != nil || errB != nil {
+			// Both versions are scored on the same labels
+			continue
+		}
+
+		for _, label := range prompt.labels {
+			correct := label.Label == labelCorrect
+			counts, ok := byRule[label.Rule]
+			if !ok {
+				counts = &ruleCounts{}
+				byRule[label.Rule] = counts
+				ruleOrder = append(ruleOrder, label.Rule)
+			}
+			counts.labeled++
+			labeled++
+			if correct {
+				report.Correct++
+			} else {
+				report.Incorrect++
+			}
+			if report.A.add(judgeReports(issuesA, label), correct) {
+				agreeA++
+				counts.agreeA++
+			}
+			if report.B.add(judgeReports(issuesB, label), correct) {
+				agreeB++
+				counts.agreeB++
+			}
+		}
+		report.A.Unlabeled += judgeUnlabeled(issuesA, prompt.labels)
+		report.B.Unlabeled += judgeUnlabeled(issuesB, prompt.labels)
+	}
+
+	if labeled > 0 {
+		report.A.Accuracy = float64(agreeA) / float64(labeled)
+		report.B.Accuracy = float64(agreeB) / float64(labeled)
+	}
+	for _, rule := range ruleOrder {
+		counts := byRule[rule]
+		report.Rules = append(report.Rules, JudgeRuleScore{
+			Rule:      rule,
+			Labeled:   counts.labeled,
+			AccuracyA: float64(counts.agreeA) / float64(counts.labeled),
+			AccuracyB: float64(counts.agreeB) / float64(counts.labeled),
+		})
+	}
+	// Rules the versions disagree on the most first
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		di := report.Rules[i].AccuracyB - report.Rules[i].AccuracyA
+		dj := report.Rules[j].AccuracyB - report.Rules[j].AccuracyA
+		if di < 0 {
+			di = -di
+		}
+		if dj < 0 {
+			dj = -dj
+		}
+		return di > dj
+	})
+	return report
+}
+
+// FormatJudgeABReport formats the comparison for humans
+func FormatJudgeABReport(report JudgeABReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Judge prompts %s (A) and %s (B) with %s over %d prompts, %d issues labeled correct and %d incorrect\n\n",
+		report.A.Version, report.B.Version, report.Model, report.Prompts, report.Correct, report.Incorrect))
+	sb.WriteString(fmt.Sprintf("  %-34s %10s %10s\n", "", "A", "B"))
+	sb.WriteString(fmt.Sprintf("  %-34s %10s %10s\n", "Correct issues found", fmt.Sprintf("%d/%d", report.A.Found, report.Correct), fmt.Sprintf("%d/%d", report.B.Found, report.Correct)))
+	sb.WriteString(fmt.Sprintf("  %-34s %10s %10s\n", "Incorrect issues reported again", fmt.Sprintf("%d/%d", report.A.Repeated, report.Incorrect), fmt.Sprintf("%d/%d", report.B.Repeated, report.Incorrect)))
+	sb.WriteString(fmt.Sprintf("  %-34s %9.0f%% %9.0f%%\n", "Agreement with labels", report.A.Accuracy*100, report.B.Accuracy*100))
+	sb.WriteString(fmt.Sprintf("  %-34s %10d %10d\n", "Unlabeled issues", report.A.Unlabeled, report.B.Unlabeled))
+	if report.A.Errors > 0 || report.B.Errors > 0 {
+		sb.WriteString(fmt.Sprintf("  %-34s %10d %10d\n", "Failed prompts", report.A.Errors, report.B.Errors))
+	}
+
+	if len(report.Rules) > 0 {
+		sb.WriteString("\nAgreement by rule:\n")
+		for _, rule := range report.Rules {
+			sb.WriteString(fmt.Sprintf("  %s: %.0f%% -> %.0f%% (%d labels)\n", rule.Rule, rule.AccuracyA*100, rule.AccuracyB*100, rule.Labeled))
+		}
+	}
+	if report.Skipped > 0 {
+		sb.WriteString(fmt.Sprintf("\nSkipped %d labels of analyzers, unknown rules or without prompt text\n", report.Skipped))
+	}
+
+	diff := (report.B.Accuracy - report.A.Accuracy) * 100
+	switch {
+	case report.Correct+report.Incorrect == 0:
+		sb.WriteString("\nNo labels were compared\n")
+	case diff > 0:
+		sb.WriteString(fmt.Sprintf("\n%s agrees with the labels more often (+%.0f points)\n", report.B.Version, diff))
+	case diff < 0:
+		sb.WriteString(fmt.Sprintf("\n%s agrees with the labels more often (+%.0f points)\n", report.A.Version, -diff))
+	default:
+		sb.WriteString("\nBoth versions agree with the labels equally often\n")
+	}
+	return sb.String()
+}
+
+// runJudgeABCommand implements `promptlint judge-ab`
+func runJudgeABCommand(args []string) error {
+	versions := linter.JudgePrompts()
+	fs := flag.NewFlagSet("judge-ab", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	versionA := fs.String("a", linter.DefaultJudgePrompt, "Judge prompt version A: "+strings.Join(versions, ", "))
+	versionB := fs.String("b", versions[len(versions)-1], "Judge prompt version B")
+	feedbackFile := fs.String("feedback", defaultFeedbackFile, "Path to the labeled issues collected with --collect-feedback")
+	format := fs.String("format", "text", "Output format: text, json")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s judge-ab [--a=%s] [--b=%s] [--feedback=file] [--format=text|json]
+
+Lints every prompt labeled with --collect-feedback with two versions of the
+judge system message and compares how often each agrees with the labels:
+issues labeled correct should be reported, issues labeled incorrect should
+not. Select the winner with judge_prompt in %s or --judge-prompt.
+Every prompt is checked twice, the run requires an API key.
+
+Options:
+`, appName, linter.DefaultJudgePrompt, versions[len(versions)-1], configFileName)
+		fs.PrintDefaults()
+	}
+
+	if _, err := parseFlagsWithArgs(fs, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	for _, version := range []string{*versionA, *versionB} {
+		if _, err := linter.JudgePrompt(version); err != nil {
+			return err
+		}
+	}
+	if *versionA == *versionB {
+		return fmt.Errorf("--a and --b must be different versions")
+	}
+
+	examples, err := loadFeedback(*feedbackFile)
+	if err != nil {
+		return err
+	}
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	if rules, err = rulesForPath(rules, ""); err != nil {
+		return err
+	}
+	corpus, skipped := judgeCorpus(examples, rules)
+	if len(corpus) == 0 {
+		return fmt.Errorf("no labeled issues of rules in %s, label issues with --collect-feedback first", *feedbackFile)
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	if config.Heuristic {
+		return fmt.Errorf("judge-ab requires an API key, the heuristic judge has no system message")
+	}
+
+	report := compareJudgePrompts(corpus, *versionA, *versionB, rules, &config)
+	report.Skipped = skipped
+	if report.A.Errors == len(corpus) || report.B.Errors == len(corpus) {
+		return fmt.Errorf("the judge failed on every prompt")
+	}
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Print(FormatJudgeABReport(report))
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// JUnitTestSuites is the root of a JUnit XML report, CI test views show every prompt as a suite
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds the test cases of a prompt
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a rule or analyzer checked on the prompt, it fails with every active issue
+type JUnitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	Failures  []JUnitFailure `xml:"failure"`
+	Skipped   *JUnitSkipped  `xml:"skipped"`
+}
+
+// JUnitFailure is an issue, the type is its severity
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitSkipped marks rules whose issues are all dismissed
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// junitFailure describes the issue for test views, which show the message and the text
+func junitFailure(file LintedFile, issue Issue) JUnitFailure {
+	severity := issue.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+	var text strings.Builder
+	if line, _ := githubPosition(file, issue); line > 0 {
+		text.WriteString(fmt.Sprintf("Line: %d\n", line))
+	}
+	if issue.Role != "" {
+		text.WriteString(fmt.Sprintf("Message: %d (%s)\n", issue.Message, issue.Role))
+	}
+	for _, field := range []struct{ name, value string }{
+		{"Reason", issue.Reason},
+		{"Fix", issue.Fix},
+		{"Original", issue.OriginalSnippet},
+		{"Suggested", issue.FixedSnippet},
+		{"Fingerprint", issue.Fingerprint},
+		{"Rule", issue.RuleLink},
+	} {
+		if strings.TrimSpace(field.value) != "" {
+			text.WriteString(field.name + ": " + field.value + "\n")
+		}
+	}
+	return JUnitFailure{Message: issue.Description, Type: severity, Text: text.String()}
+}
+
+// ReportJUnit formats the results as JUnit XML: a suite per prompt with a test case per checked rule and analyzer,
+// rules reported by the judge outside the rule set are added. Every active issue is a failure of its rule,
+// test cases with only dismissed issues are skipped.
+func ReportJUnit(files []LintedFile, rules *Rules) (string, error) {
+	var checked []string
+	if rules != nil {
+		for _, rule := range rules.Active() {
+			checked = append(checked, rule.Name)
+		}
+	}
+	for _, name := range analyzerNames() {
+		if !disabledAnalyzers[strings.ToLower(name)] {
+			checked = append(checked, name)
+		}
+	}
+
+	report := JUnitTestSuites{Name: appName}
+	for _, file := range files {
+		name := markdownFile(file)
+		suite := JUnitTestSuite{Name: name, Time: "0"}
+		cases := map[string]*JUnitTestCase{}
+		var order []string
+		testCase := func(rule string) *JUnitTestCase {
+			key := strings.ToLower(rule)
+			if _, ok := cases[key]; !ok {
+				cases[key] = &JUnitTestCase{Name: rule, ClassName: name, Time: "0"}
+				order = append(order, key)
+			}
+			return cases[key]
+		}
+		for _, rule := range checked {
+			testCase(rule)
+		}
+		dismissed := map[string]int{}
+		for _, issue := range file.Issues {
+			current := testCase(issue.RuleName)
+			if issue.Dismissed {
+				dismissed[strings.ToLower(issue.RuleName)]++
+				continue
+			}
+			current.Failures = append(current.Failures, junitFailure(file, issue))
+		}
+
+		for _, key := range order {
+			current := cases[key]
+			if len(current.Failures) == 0 && dismissed[key] > 0 {
+				current.Skipped = &JUnitSkipped{Message: fmt.Sprintf("%d dismissed issues", dismissed[key])}
+				suite.Skipped++
+			}
+			if len(current.Failures) > 0 {
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, *current)
+		}
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	return xml.Header + string(data), nil
+}
+
+// junitReporter formats JUnit XML for CI test views
+type junitReporter struct{}
+
+func (junitReporter) Name() string        { return "junit" }
+func (junitReporter) ContentType() string { return "application/xml" }
+func (junitReporter) Report(run ReportRun) (string, error) {
+	report, err := ReportJUnit(run.Files, run.Rules)
+	return report + "\n", err
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJUnitFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  LintedFile
+		issue Issue
+		want  JUnitFailure
+	}{
+		{
+			name:  "severity defaults to warning",
+			issue: Issue{Description: "Vague instruction"},
+			want:  JUnitFailure{Message: "Vague instruction", Type: "warning"},
+		},
+		{
+			name:  "line and fields",
+			issue: Issue{Description: "Vague instruction", Severity: "error", Line: 3, Reason: "Too broad", FixedSnippet: "Be brief.", Fingerprint: "abc"},
+			want:  JUnitFailure{Message: "Vague instruction", Type: "error", Text: "Line: 3\nReason: Too broad\nSuggested: Be brief.\nFingerprint: abc\n"},
+		},
+		{
+			name:  "line in the file of a chat message",
+			file:  LintedFile{Content: "{\n  \"content\": \"Do it well.\"\n}", Text: "Do it well."},
+			issue: Issue{Description: "Vague instruction", Severity: "info", Message: 1, Role: "user", OriginalSnippet: "Do it well."},
+			want:  JUnitFailure{Message: "Vague instruction", Type: "info", Text: "Line: 2\nMessage: 1 (user)\nOriginal: Do it well.\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := junitFailure(tt.file, tt.issue); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("junitFailure() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportJUnit(t *testing.T) {
+	disabled := disabledAnalyzers
+	disabledAnalyzers = map[string]bool{}
+	for _, name := range analyzerNames() {
+		disabledAnalyzers[strings.ToLower(name)] = true
+	}
+	t.Cleanup(func() { disabledAnalyzers = disabled })
+
+	rules := &Rules{PromptRules: []PromptRule{{Name: "Be Specific"}, {Name: "Avoid Jargon"}}}
+	files := []LintedFile{
+		{
+			Name: "prompts/support.md",
+			Issues: []Issue{
+				{RuleName: "be specific", Description: "Vague <goal>", Severity: "error"},
+				{RuleName: "Avoid Jargon", Description: "Jargon", Dismissed: true},
+				{RuleName: "Judge Rule", Description: "Outside the rule set"},
+			},
+		},
+		{Issues: nil},
+	}
+	output, err := ReportJUnit(files, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, xml.Header) {
+		t.Errorf("report doesn't start with the XML header:\n%s", output)
+	}
+	var report JUnitTestSuites
+	if err := xml.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Tests != 5 || report.Failures != 2 || report.Skipped != 1 {
+		t.Errorf("report counts tests=%d failures=%d skipped=%d, want 5, 2, 1", report.Tests, report.Failures, report.Skipped)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "prompts/support.md" || report.Suites[1].Name != "stdin" {
+		t.Fatalf("got suites %+v, want prompts/support.md and stdin", report.Suites)
+	}
+
+	tests := []struct {
+		name     string
+		failures []string
+		skipped  string
+	}{
+		{name: "Be Specific", failures: []string{"error: Vague <goal>"}},
+		{name: "Avoid Jargon", skipped: "1 dismissed issues"},
+		{name: "Judge Rule", failures: []string{"warning: Outside the rule set"}},
+	}
+	suite := report.Suites[0]
+	if len(suite.Cases) != len(tests) {
+		t.Fatalf("got %d test cases, want %d: %+v", len(suite.Cases), len(tests), suite.Cases)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := suite.Cases[i]
+			if current.Name != tt.name || current.ClassName != suite.Name {
+				t.Errorf("test case %q of class %q, want %q of %q", current.Name, current.ClassName, tt.name, suite.Name)
+			}
+			var failures []string
+			for _, failure := range current.Failures {
+				failures = append(failures, failure.Type+": "+failure.Message)
+			}
+			if !reflect.DeepEqual(failures, tt.failures) {
+				t.Errorf("failures = %q, want %q", failures, tt.failures)
+			}
+			skipped := ""
+			if current.Skipped != nil {
+				skipped = current.Skipped.Message
+			}
+			if skipped != tt.skipped {
+				t.Errorf("skipped = %q, want %q", skipped, tt.skipped)
+			}
+		})
+	}
+}
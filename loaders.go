@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a prompt extracted from an input file by a loader
+type Document struct {
+	// Format is the name of the loader that produced the document
+	Format string
+	// Text is the prompt text sent for checking
+	Text string
+	// Messages contains the chat messages for chat-based formats
+	Messages []ChatMessage
+	// Frontmatter contains metadata from the file header, if any
+	Frontmatter map[string]interface{}
+}
+
+// Loader extracts prompts from a specific input format
+type Loader interface {
+	// Name returns the format name used by --input-format
+	Name() string
+	// Detect reports whether the file looks like this format, path may be empty for stdin
+	Detect(path string, data []byte) bool
+	// Load extracts the prompt from the file content
+	Load(data []byte) (*Document, error)
+}
+
+// loaders contains registered loaders in detection order, the last one is the fallback
+var loaders []Loader
+
+// RegisterLoader adds a loader before the plain text fallback
+func RegisterLoader(loader Loader) {
+	if len(loaders) > 0 && loaders[len(loaders)-1].Name() == "text" {
+		loaders = append(loaders[:len(loaders)-1], loader, loaders[len(loaders)-1])
+		return
+	}
+	loaders = append(loaders, loader)
+}
+
+func init() {
+	loaders = []Loader{textLoader{}}
+	RegisterLoader(promptyLoader{})
+	RegisterLoader(chatJSONLoader{})
+	RegisterLoader(chatYAMLLoader{})
+	RegisterLoader(codeLoader{})
+	RegisterLoader(dotpromptLoader{})
+	RegisterLoader(markdownLoader{})
+}
+
+// loaderNames returns names of all registered loaders
+func loaderNames() []string {
+	names := make([]string, 0, len(loaders))
+	for _, loader := range loaders {
+		names = append(names, loader.Name())
+	}
+	return names
+}
+
+// loadDocument extracts the prompt using the given format or the first loader that detects the content
+func loadDocument(path string, data []byte, format string) (*Document, error) {
+	var selected Loader
+	if format != "" && format != "auto" {
+		for _, loader := range loaders {
+			if loader.Name() == format {
+				selected = loader
+				break
+			}
+		}
+		if selected == nil {
+			return nil, fmt.Errorf("unknown input format %q, supported: %s", format, strings.Join(loaderNames(), ", "))
+		}
+	} else {
+		for _, loader := range loaders {
+			if loader.Detect(path, data) {
+				selected = loader
+				break
+			}
+		}
+	}
+
+	doc, err := selected.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s input: %w", selected.Name(), err)
+	}
+	doc.Format = selected.Name()
+	return doc, nil
+}
+
+// hasExtension reports whether the path has one of the extensions
+func hasExtension(path string, extensions ...string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// splitFrontmatter separates a leading YAML frontmatter block delimited by "---" lines
+func splitFrontmatter(data []byte) (map[string]interface{}, string, bool) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, text, false
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return nil, text, false
+	}
+	header := text[4 : 4+end]
+	body := strings.TrimPrefix(text[4+end+4:], "\n")
+
+	var frontmatter map[string]interface{}
+	if err := yaml.Unmarshal([]byte(header), &frontmatter); err != nil {
+		return nil, text, false
+	}
+	return frontmatter, body, true
+}
+
+// textLoader passes the content through unchanged
+type textLoader struct{}
+
+func (textLoader) Name() string               { return "text" }
+func (textLoader) Detect(string, []byte) bool { return true }
+func (textLoader) Load(data []byte) (*Document, error) {
+	return &Document{Text: string(data)}, nil
+}
+
+// markdownLoader strips the YAML frontmatter of Markdown prompts
+type markdownLoader struct{}
+
+var markdownHeadingPattern = regexp.MustCompile(`(?m)^#{1,6} \S`)
+
+func (markdownLoader) Name() string { return "markdown" }
+func (markdownLoader) Detect(path string, data []byte) bool {
+	if hasExtension(path, ".md", ".markdown", ".mdx") {
+		return true
+	}
+	return path == "" && markdownHeadingPattern.Match(data)
+}
+func (markdownLoader) Load(data []byte) (*Document, error) {
+	frontmatter, body, _ := splitFrontmatter(data)
+	return &Document{Text: body, Frontmatter: frontmatter}, nil
+}
+
+// chatJSONLoader reads OpenAI-style chat messages: a messages array or an object with a "messages" field
+type chatJSONLoader struct{}
+
+func (chatJSONLoader) Name() string { return "chat-json" }
+func (chatJSONLoader) Detect(path string, data []byte) bool {
+	if hasExtension(path, ".json") {
+		return true
+	}
+	_, err := parseChatJSON(data)
+	return err == nil
+}
+func (chatJSONLoader) Load(data []byte) (*Document, error) {
+	messages, err := parseChatJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Text: renderMessages(messages), Messages: messages, Frontmatter: chatJSONFields(data)}, nil
+}
+
+// chatJSONFields returns the fields of a chat request object besides its messages, like the model and its parameters
+func chatJSONFields(data []byte) map[string]interface{} {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &fields); err != nil {
+		return nil
+	}
+	delete(fields, "messages")
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// parseChatJSON parses chat messages from JSON
+func parseChatJSON(data []byte) ([]ChatMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	var raw []map[string]interface{}
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return nil, fmt.Errorf("invalid messages array: %w", err)
+		}
+	} else {
+		var wrapper struct {
+			Messages []map[string]interface{} `json:"messages"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapper); err != nil {
+			return nil, fmt.Errorf("invalid chat JSON: %w", err)
+		}
+		raw = wrapper.Messages
+	}
+	return chatMessages(raw)
+}
+
+// chatMessages converts decoded OpenAI-style messages, content is a string or an array of content parts
+// whose text parts are joined
+func chatMessages(raw []map[string]interface{}) ([]ChatMessage, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no messages found")
+	}
+	messages := make([]ChatMessage, len(raw))
+	for i, message := range raw {
+		role, _ := message["role"].(string)
+		if role == "" {
+			return nil, fmt.Errorf("message %d has no role", i)
+		}
+		var content string
+		switch value := message["content"].(type) {
+		case string:
+			content = value
+		case []interface{}:
+			var texts []string
+			for _, part := range value {
+				if fields, ok := part.(map[string]interface{}); ok {
+					if text, ok := fields["text"].(string); ok {
+						texts = append(texts, text)
+					}
+				}
+			}
+			content = strings.Join(texts, "\n\n")
+		case nil:
+		default:
+			return nil, fmt.Errorf("message %d has unsupported content", i)
+		}
+		messages[i] = ChatMessage{Role: role, Content: content}
+	}
+	return messages, nil
+}
+
+// chatYAMLLoader reads chat messages stored as YAML: a messages list or a mapping with a "messages" field
+type chatYAMLLoader struct{}
+
+func (chatYAMLLoader) Name() string { return "chat-yaml" }
+func (chatYAMLLoader) Detect(path string, data []byte) bool {
+	if path != "" && !hasExtension(path, ".yaml", ".yml") {
+		return false
+	}
+	_, _, err := parseChatYAML(data)
+	return err == nil
+}
+func (chatYAMLLoader) Load(data []byte) (*Document, error) {
+	messages, fields, err := parseChatYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Text: renderMessages(messages), Messages: messages, Frontmatter: fields}, nil
+}
+
+// parseChatYAML parses chat messages from YAML and returns the other fields of a mapping
+func parseChatYAML(data []byte) ([]ChatMessage, map[string]interface{}, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("invalid chat YAML: %w", err)
+	}
+	var list interface{}
+	var fields map[string]interface{}
+	switch value := document.(type) {
+	case []interface{}:
+		list = value
+	case map[string]interface{}:
+		list = value["messages"]
+		delete(value, "messages")
+		if len(value) > 0 {
+			fields = value
+		}
+	}
+	items, ok := list.([]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("no messages found")
+	}
+	raw := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		if raw[i], ok = item.(map[string]interface{}); !ok {
+			return nil, nil, fmt.Errorf("message %d is not a mapping", i)
+		}
+	}
+	messages, err := chatMessages(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return messages, fields, nil
+}
+
+// renderMessages renders chat messages as text with role headers
+func renderMessages(messages []ChatMessage) string {
+	var sb strings.Builder
+	for i, message := range messages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(message.Role + ":\n" + message.Content)
+	}
+	return sb.String()
+}
+
+// promptyLoader reads .prompty files: YAML frontmatter followed by a template with role markers
+type promptyLoader struct{}
+
+func (promptyLoader) Name() string { return "prompty" }
+func (promptyLoader) Detect(path string, data []byte) bool {
+	if hasExtension(path, ".prompty") {
+		return true
+	}
+	frontmatter, body, ok := splitFrontmatter(data)
+	if !ok {
+		return false
+	}
+	_, hasModel := frontmatter["model"]
+	return hasModel && linter.RoleMarkerPattern.MatchString(body)
+}
+func (promptyLoader) Load(data []byte) (*Document, error) {
+	frontmatter, body, _ := splitFrontmatter(data)
+	return &Document{Text: body, Messages: splitRoleMarkers(body), Frontmatter: frontmatter}, nil
+}
+
+// splitRoleMarkers splits a template into messages by "role:" marker lines
+func splitRoleMarkers(body string) []ChatMessage {
+	locations := linter.RoleMarkerPattern.FindAllStringSubmatchIndex(body, -1)
+	messages := make([]ChatMessage, 0, len(locations))
+	for i, loc := range locations {
+		end := len(body)
+		if i+1 < len(locations) {
+			end = locations[i+1][0]
+		}
+		messages = append(messages, ChatMessage{
+			Role:    strings.ToLower(body[loc[2]:loc[3]]),
+			Content: strings.TrimSpace(body[loc[1]:end]),
+		})
+	}
+	return messages
+}
+
+// dotpromptLoader reads .prompt files of dotprompt: YAML frontmatter followed by a Handlebars template
+// with {{role "..."}} markers
+type dotpromptLoader struct{}
+
+var dotpromptRolePattern = regexp.MustCompile(`\{\{\s*role\s+"(\w+)"\s*\}\}`)
+
+func (dotpromptLoader) Name() string { return "dotprompt" }
+func (dotpromptLoader) Detect(path string, data []byte) bool {
+	if hasExtension(path, ".prompt") {
+		return true
+	}
+	return dotpromptRolePattern.Match(data)
+}
+func (dotpromptLoader) Load(data []byte) (*Document, error) {
+	frontmatter, body, _ := splitFrontmatter(data)
+	return &Document{Text: body, Messages: splitDotpromptRoles(body), Frontmatter: frontmatter}, nil
+}
+
+// splitDotpromptRoles splits a template into messages by role markers, text before the first marker is
+// a user message like in dotprompt
+func splitDotpromptRoles(body string) []ChatMessage {
+	locations := dotpromptRolePattern.FindAllStringSubmatchIndex(body, -1)
+	if len(locations) == 0 {
+		return nil
+	}
+	var messages []ChatMessage
+	if preamble := strings.TrimSpace(body[:locations[0][0]]); preamble != "" {
+		messages = append(messages, ChatMessage{Role: "user", Content: preamble})
+	}
+	for i, loc := range locations {
+		end := len(body)
+		if i+1 < len(locations) {
+			end = locations[i+1][0]
+		}
+		messages = append(messages, ChatMessage{
+			Role:    strings.ToLower(body[loc[2]:loc[3]]),
+			Content: strings.TrimSpace(body[loc[1]:end]),
+		})
+	}
+	return messages
+}
+
+// codeLoader extracts prompt-like multi-line string literals from source code
+type codeLoader struct{}
+
+var (
+	// Python triple-quoted strings, JS/TS template literals and Go raw strings
+	codeStringPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)"""(.*?)"""`),
+		regexp.MustCompile(`(?s)'''(.*?)'''`),
+		regexp.MustCompile("(?s)`([^`]*)`"),
+	}
+	codeExtensions = []string{".py", ".js", ".jsx", ".ts", ".tsx", ".go", ".rb", ".java", ".kt"}
+)
+
+// minCodePromptLength is the minimal length of a string literal considered a prompt
+const minCodePromptLength = 40
+
+func (codeLoader) Name() string { return "code" }
+func (codeLoader) Detect(path string, _ []byte) bool {
+	return hasExtension(path, codeExtensions...)
+}
+func (codeLoader) Load(data []byte) (*Document, error) {
+	var prompts []string
+	for _, pattern := range codeStringPatterns {
+		for _, match := range pattern.FindAllSubmatch(data, -1) {
+			literal := strings.TrimSpace(string(match[1]))
+			if len(literal) >= minCodePromptLength && strings.Contains(literal, " ") {
+				prompts = append(prompts, literal)
+			}
+		}
+	}
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("no prompt-like string literals found")
+	}
+	return &Document{Text: strings.Join(prompts, "\n\n---\n\n")}, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoaderDetection(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data string
+		want string
+	}{
+		{"markdown by extension", "prompts/a.MD", "Be brief.", "markdown"},
+		{"markdown heading on stdin", "", "# Role\nBe brief.", "markdown"},
+		{"heading in a text file", "a.txt", "# Role\nBe brief.", "text"},
+		{"chat JSON by extension", "a.json", `{"messages": [{"role": "user", "content": "Hi"}]}`, "chat-json"},
+		{"chat JSON array on stdin", "", `[{"role": "user", "content": "Hi"}]`, "chat-json"},
+		{"chat YAML", "a.yaml", "messages:\n  - role: user\n    content: Hi\n", "chat-yaml"},
+		{"chat YAML on stdin", "", "- role: user\n  content: Hi\n", "chat-yaml"},
+		{"YAML without messages", "a.yml", "name: Ann\n", "text"},
+		{"prompty by extension", "a.prompty", "Be brief.", "prompty"},
+		{"prompty by frontmatter and role markers", "", "---\nmodel: gpt-4o\n---\nsystem:\nBe brief.\n", "prompty"},
+		{"frontmatter without a model", "", "---\ntitle: A\n---\nsystem:\nBe brief.\n", "text"},
+		{"dotprompt by extension", "a.prompt", "Be brief.", "dotprompt"},
+		{"dotprompt role markers", "", "{{role \"system\"}}\nBe brief.", "dotprompt"},
+		{"source code", "app/prompts.py", `PROMPT = """You are a helpful assistant for our customers."""`, "code"},
+		{"plain text", "", "Be brief.", "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := loadDocument(tt.path, []byte(tt.data), "auto")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Format != tt.want {
+				t.Errorf("loadDocument(%q) format = %q, want %q", tt.path, doc.Format, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDocument(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   Document
+	}{
+		{
+			name:   "markdown frontmatter with CRLF",
+			format: "markdown",
+			data:   "---\r\ntitle: Support\r\n---\r\nBe brief.\r\n",
+			want:   Document{Format: "markdown", Text: "Be brief.\n", Frontmatter: map[string]interface{}{"title": "Support"}},
+		},
+		{
+			name:   "markdown with invalid frontmatter",
+			format: "markdown",
+			data:   "---\n[\n---\nBe brief.",
+			want:   Document{Format: "markdown", Text: "---\n[\n---\nBe brief."},
+		},
+		{
+			name:   "chat JSON with content parts and request fields",
+			format: "chat-json",
+			data:   `{"model": "gpt-4o", "messages": [{"role": "system", "content": [{"type": "text", "text": "Be brief."}, {"type": "image_url"}, {"type": "text", "text": "Be polite."}]}, {"role": "user", "content": null}]}`,
+			want: Document{
+				Format:      "chat-json",
+				Text:        "system:\nBe brief.\n\nBe polite.\n\nuser:\n",
+				Messages:    []ChatMessage{{Role: "system", Content: "Be brief.\n\nBe polite."}, {Role: "user"}},
+				Frontmatter: map[string]interface{}{"model": "gpt-4o"},
+			},
+		},
+		{
+			name:   "chat YAML with fields",
+			format: "chat-yaml",
+			data:   "model: gpt-4o\nmessages:\n  - role: user\n    content: Hi\n",
+			want: Document{
+				Format:      "chat-yaml",
+				Text:        "user:\nHi",
+				Messages:    []ChatMessage{{Role: "user", Content: "Hi"}},
+				Frontmatter: map[string]interface{}{"model": "gpt-4o"},
+			},
+		},
+		{
+			name:   "prompty role markers",
+			format: "prompty",
+			data:   "---\nmodel: gpt-4o\n---\nsystem:\nBe brief.\n\nUser:\n{{question}}\n",
+			want: Document{
+				Format:      "prompty",
+				Text:        "system:\nBe brief.\n\nUser:\n{{question}}\n",
+				Messages:    []ChatMessage{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "{{question}}"}},
+				Frontmatter: map[string]interface{}{"model": "gpt-4o"},
+			},
+		},
+		{
+			name:   "dotprompt text before the first role is a user message",
+			format: "dotprompt",
+			data:   "---\nmodel: gemini\n---\nContext first.\n{{role \"system\"}}\nBe brief.\n",
+			want: Document{
+				Format:      "dotprompt",
+				Text:        "Context first.\n{{role \"system\"}}\nBe brief.\n",
+				Messages:    []ChatMessage{{Role: "user", Content: "Context first."}, {Role: "system", Content: "Be brief."}},
+				Frontmatter: map[string]interface{}{"model": "gemini"},
+			},
+		},
+		{
+			name:   "code keeps long literals with spaces",
+			format: "code",
+			data:   "a = \"\"\"short\"\"\"\nb = '''You are a helpful assistant for our customers.'''\nc = `https://example.com/a-very-long-url-without-any-spaces`\n",
+			want:   Document{Format: "code", Text: "You are a helpful assistant for our customers."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := loadDocument("", []byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*doc, tt.want) {
+				t.Errorf("loadDocument() = %+v, want %+v", *doc, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		format  string
+		wantErr string
+	}{
+		{"unknown format", "", "Be brief.", "html", `unknown input format "html"`},
+		{"invalid JSON file", "a.json", "{", "auto", "failed to load chat-json input: invalid chat JSON"},
+		{"message without a role", "", `[{"content": "Hi"}]`, "chat-json", "message 0 has no role"},
+		{"unsupported content", "", `[{"role": "user", "content": 1}]`, "chat-json", "message 0 has unsupported content"},
+		{"no messages", "", `{"messages": []}`, "chat-json", "no messages found"},
+		{"YAML message is not a mapping", "", "messages:\n  - Hi\n", "chat-yaml", "message 0 is not a mapping"},
+		{"code without prompts", "a.go", "package a\n", "auto", "no prompt-like string literals found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadDocument(tt.path, []byte(tt.data), tt.format); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadDocument() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
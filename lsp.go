@@ -0,0 +1,825 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// LSP diagnostic severities and message types
+const (
+	lspSeverityError       = 1
+	lspSeverityWarning     = 2
+	lspSeverityInformation = 3
+
+	lspMessageError   = 1
+	lspMessageWarning = 2
+)
+
+// JSON-RPC error codes used by the server
+const (
+	rpcParseError     = -32700
+	rpcInvalidParams  = -32602
+	rpcMethodNotFound = -32601
+)
+
+// Code action kinds offered by the server
+const (
+	lspKindQuickFix = "quickfix"
+	lspKindFixAll   = "source.fixAll"
+)
+
+// lspPosition is a 0-based line and UTF-16 character offset
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspWorkspaceEdit struct {
+	Changes map[string][]lspTextEdit `json:"changes"`
+}
+
+type lspCodeDescription struct {
+	Href string `json:"href"`
+}
+
+type lspDiagnostic struct {
+	Range           lspRange            `json:"range"`
+	Severity        int                 `json:"severity"`
+	Code            string              `json:"code,omitempty"`
+	CodeDescription *lspCodeDescription `json:"codeDescription,omitempty"`
+	Source          string              `json:"source"`
+	Message         string              `json:"message"`
+}
+
+type lspCodeAction struct {
+	Title       string           `json:"title"`
+	Kind        string           `json:"kind"`
+	Diagnostics []lspDiagnostic  `json:"diagnostics,omitempty"`
+	IsPreferred bool             `json:"isPreferred,omitempty"`
+	Edit        lspWorkspaceEdit `json:"edit"`
+}
+
+// lspMessage is an incoming request or notification, notifications have no id
+type lspMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcError is a JSON-RPC error returned by a request handler
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// lspIssue is an issue located in the file content by byte offsets, Exact is set when the range is the original snippet
+type lspIssue struct {
+	Issue      Issue
+	Start, End int
+	Exact      bool
+	Diagnostic lspDiagnostic
+}
+
+// lspDocument is an open document, Issues are the published results located in the current text
+type lspDocument struct {
+	URI     string
+	Path    string
+	Text    string
+	Issues  []Issue
+	Located []lspIssue
+	// Revision counts changes, LLM results of an older revision are dropped
+	Revision int
+	// LLMIssues are the issues of the last completed LLM check, kept while their snippets stay in the text
+	LLMIssues []Issue
+
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+// stop cancels the pending and in-flight LLM check of the document
+func (d *lspDocument) stop() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// lspCheck is a check of one revision of a document: local results are ready at once, the LLM runs later
+type lspCheck struct {
+	rules      *Rules
+	model      *PromptModel
+	local      []Issue
+	dismissals *Dismissals
+}
+
+// results combines the LLM issues with the local ones like a lint run does
+func (c *lspCheck) results(llm []Issue) []Issue {
+	issues := append([]Issue{}, llm...)
+	locateIssues(issues, c.model)
+	issues = linter.ApplySuppressions(append(issues, c.local...), c.model)
+	applyDismissals(issues, c.dismissals, time.Now())
+	return issues
+}
+
+// lspServer publishes issues of open prompt files as diagnostics and offers fixes as code actions
+type lspServer struct {
+	in    *bufio.Reader
+	out   io.Writer
+	outMu sync.Mutex
+
+	rules          *Rules
+	config         LLMConfig
+	dismissalsFile string
+	// debounce is the quiet time after a change before the LLM check starts
+	debounce time.Duration
+
+	// mu guards the documents, LLM checks finish in their own goroutines
+	mu       sync.Mutex
+	docs     map[string]*lspDocument
+	shutdown bool
+}
+
+// readLSPMessage reads a message framed by the Content-Length header
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", strings.TrimSpace(value))
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	return body, nil
+}
+
+// write sends a message framed by the Content-Length header
+func (s *lspServer) write(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// notify sends a notification to the client
+func (s *lspServer) notify(method string, params interface{}) error {
+	return s.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// showMessage asks the client to display a message, LSP has no other channel for errors of notifications
+func (s *lspServer) showMessage(kind int, message string) {
+	if err := s.notify("window/showMessage", map[string]interface{}{"type": kind, "message": message}); err != nil {
+		printProgress(err.Error())
+	}
+}
+
+// serve handles messages until the client sends exit or closes the input
+func (s *lspServer) serve() error {
+	for {
+		data, err := readLSPMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var message lspMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			if err := s.write(map[string]interface{}{"jsonrpc": "2.0", "id": nil, "error": rpcError{Code: rpcParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if message.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown request")
+			}
+			return nil
+		}
+
+		s.mu.Lock()
+		result, err := s.handle(message)
+		s.mu.Unlock()
+		if len(message.ID) == 0 {
+			if err != nil {
+				s.showMessage(lspMessageError, err.Error())
+			}
+			continue
+		}
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": message.ID}
+		var rpcErr *rpcError
+		switch {
+		case errors.As(err, &rpcErr):
+			response["error"] = rpcErr
+		case err != nil:
+			response["error"] = rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		default:
+			response["result"] = result
+		}
+		if err := s.write(response); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a message by method, the result of notifications is ignored
+func (s *lspServer) handle(message lspMessage) (interface{}, error) {
+	switch message.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    1,
+					"save":      map[string]bool{"includeText": true},
+				},
+				"codeActionProvider": map[string]interface{}{
+					"codeActionKinds": []string{lspKindQuickFix, lspKindFixAll},
+				},
+			},
+			"serverInfo": map[string]string{"name": appName, "version": appVersion},
+		}, nil
+	case "initialized":
+		if s.config.Heuristic {
+			s.showMessage(lspMessageWarning, heuristicMessage(s.config.Provider))
+		}
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		for _, doc := range s.docs {
+			doc.stop()
+		}
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		path, err := uriToPath(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		doc := &lspDocument{URI: params.TextDocument.URI, Path: path, Text: params.TextDocument.Text}
+		if previous, ok := s.docs[doc.URI]; ok {
+			previous.stop()
+		}
+		s.docs[doc.URI] = doc
+		return nil, s.lint(doc, 0)
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// Full synchronization: the last change is the whole text
+		doc.Text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.lint(doc, s.debounce)
+	case "textDocument/didSave":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			Text *string `json:"text"`
+		}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		if params.Text != nil {
+			doc.Text = *params.Text
+		}
+		return nil, s.lint(doc, 0)
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		if doc, ok := s.docs[params.TextDocument.URI]; ok {
+			doc.stop()
+			delete(s.docs, params.TextDocument.URI)
+		}
+		return nil, s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": params.TextDocument.URI, "diagnostics": []lspDiagnostic{}})
+	case "textDocument/codeAction":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			Range   lspRange `json:"range"`
+			Context struct {
+				Only []string `json:"only"`
+			} `json:"context"`
+		}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return []lspCodeAction{}, nil
+		}
+		return s.codeActions(doc, params.Range, params.Context.Only), nil
+	}
+	if len(message.ID) > 0 {
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "method not supported: " + message.Method}
+	}
+	return nil, nil
+}
+
+// lint publishes the static-mode results of the document at once, with LLM issues of earlier revisions whose
+// snippets are still in the text, and starts the LLM check after the delay. A newer revision cancels the check.
+func (s *lspServer) lint(doc *lspDocument, delay time.Duration) error {
+	doc.stop()
+	doc.Revision++
+
+	check, err := s.checkLocal(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", doc.Path, err)
+	}
+	var kept []Issue
+	for _, issue := range doc.LLMIssues {
+		if snippet := strings.TrimSpace(issue.OriginalSnippet); snippet != "" && strings.Contains(check.model.Text, snippet) {
+			issue.Line, issue.Column = 0, 0
+			kept = append(kept, issue)
+		}
+	}
+	doc.LLMIssues = kept
+	// Static rules stand in for the LLM until it answers, --engine=both/static already reports them as local issues
+	if ruleEngine == "llm" {
+		kept = append(kept, checkStaticRules(check.model, check.rules)...)
+	}
+	doc.Issues = check.results(kept)
+	if err := s.publish(doc); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	revision := doc.Revision
+	doc.cancel = cancel
+	doc.timer = time.AfterFunc(delay, func() { s.checkLLM(ctx, doc, check, revision) })
+	return nil
+}
+
+// checkLocal resolves the settings of the document path and runs the analyzers, it runs on the message loop
+// because analyzer settings are global
+func (s *lspServer) checkLocal(doc *lspDocument) (*lspCheck, error) {
+	rules, err := rulesForPath(s.rules, doc.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateStaticRules(rules); err != nil {
+		return nil, err
+	}
+	if err := loadAnalyzerSettings(doc.Path); err != nil {
+		return nil, err
+	}
+	dismissals, err := LoadDismissals(s.dismissalsFile)
+	if err != nil {
+		return nil, err
+	}
+	document, err := loadDocument(doc.Path, []byte(doc.Text), "")
+	if err != nil {
+		return nil, err
+	}
+	model := linter.ParsePrompt(document.Text)
+	local := localIssues(model, rules)
+	locateIssues(local, model)
+	return &lspCheck{rules: rules, model: model, local: local, dismissals: dismissals}, nil
+}
+
+// checkLLM checks the revision with the LLM and publishes the complete results unless the document changed since
+func (s *lspServer) checkLLM(ctx context.Context, doc *lspDocument, check *lspCheck, revision int) {
+	var issues []Issue
+	var err error
+	if ruleEngine != "static" {
+		printProgress("Linting " + doc.Path)
+		config := s.config
+		config.Context = ctx
+		issues, err = checkContentWithLLM(promptCheckInstruction, check.model.Text, check.rules, &config)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if doc.Revision != revision || s.docs[doc.URI] != doc {
+		return
+	}
+	if err != nil {
+		s.showMessage(lspMessageError, fmt.Sprintf("%s: %v", doc.Path, err))
+		return
+	}
+	doc.LLMIssues = issues
+	doc.Issues = check.results(issues)
+	if err := s.publish(doc); err != nil {
+		printProgress(err.Error())
+	}
+}
+
+// publish locates the active issues in the current text and sends them as diagnostics
+func (s *lspServer) publish(doc *lspDocument) error {
+	prompt := ""
+	if document, err := loadDocument(doc.Path, []byte(doc.Text), ""); err == nil {
+		prompt = document.Text
+	}
+	doc.Located = nil
+	diagnostics := []lspDiagnostic{}
+	for _, issue := range doc.Issues {
+		if issue.Dismissed {
+			continue
+		}
+		start, end, exact := locateInFile(doc.Text, prompt, issue)
+		located := lspIssue{Issue: issue, Start: start, End: end, Exact: exact}
+		from, to := diagnosticRange(doc.Text, prompt, located)
+		located.Diagnostic = issueDiagnostic(issue, lspRange{Start: offsetToLSP(doc.Text, from), End: offsetToLSP(doc.Text, to)})
+		doc.Located = append(doc.Located, located)
+		diagnostics = append(diagnostics, located.Diagnostic)
+	}
+	return s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": doc.URI, "diagnostics": diagnostics})
+}
+
+// issueDiagnostic converts an issue to a diagnostic, the code is the rule name
+func issueDiagnostic(issue Issue, r lspRange) lspDiagnostic {
+	severity := lspSeverityWarning
+	switch issue.Severity {
+	case "error":
+		severity = lspSeverityError
+	case "info":
+		severity = lspSeverityInformation
+	}
+	message := issue.Description
+	if issue.Fix != "" {
+		message += "\nFix: " + issue.Fix
+	}
+	diagnostic := lspDiagnostic{Range: r, Severity: severity, Code: issue.RuleName, Source: appName, Message: message}
+	if issue.RuleLink != "" {
+		diagnostic.CodeDescription = &lspCodeDescription{Href: issue.RuleLink}
+	}
+	return diagnostic
+}
+
+// codeActions returns fixes and suppressions of the issues in the range and the rewrite of the whole prompt
+func (s *lspServer) codeActions(doc *lspDocument, r lspRange, only []string) []lspCodeAction {
+	// Issues on the lines of the range apply, editors usually send the cursor position
+	from, _, _ := lineBounds(doc.Text, r.Start.Line+1)
+	_, to, ok := lineBounds(doc.Text, r.End.Line+1)
+	if !ok {
+		from, to = lspToOffset(doc.Text, r.Start), len(doc.Text)
+	}
+
+	actions := []lspCodeAction{}
+	var active []Issue
+	for _, located := range doc.Located {
+		active = append(active, located.Issue)
+		if located.Start > to || located.End < from {
+			continue
+		}
+		for _, fix := range issueFixes(doc.Text, located) {
+			actions = append(actions, lspCodeAction{
+				Title:       fix.Title,
+				Kind:        lspKindQuickFix,
+				Diagnostics: []lspDiagnostic{located.Diagnostic},
+				IsPreferred: fix.IsPreferred,
+				Edit:        lspWorkspaceEdit{Changes: map[string][]lspTextEdit{doc.URI: fix.Edits}},
+			})
+		}
+	}
+	fixed, unfixed := applyFixes(doc.Text, active, suggestedFix)
+	if applied := len(active) - len(unfixed); applied > 0 {
+		actions = append(actions, lspCodeAction{
+			Title: fmt.Sprintf("Rewrite the prompt with all suggested fixes (%d)", applied),
+			Kind:  lspKindFixAll,
+			Edit:  lspWorkspaceEdit{Changes: map[string][]lspTextEdit{doc.URI: {textEdit(doc.Text, 0, len(doc.Text), fixed)}}},
+		})
+	}
+
+	if len(only) == 0 {
+		return actions
+	}
+	filtered := []lspCodeAction{}
+	for _, action := range actions {
+		for _, kind := range only {
+			if action.Kind == kind || strings.HasPrefix(action.Kind, kind+".") {
+				filtered = append(filtered, action)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// issueFix is a quick fix of a single issue
+type issueFix struct {
+	Title       string        `json:"title"`
+	IsPreferred bool          `json:"isPreferred,omitempty"`
+	Edits       []lspTextEdit `json:"edits"`
+}
+
+// textEdit replaces the byte range of the text
+func textEdit(text string, start, end int, newText string) lspTextEdit {
+	return lspTextEdit{Range: lspRange{Start: offsetToLSP(text, start), End: offsetToLSP(text, end)}, NewText: newText}
+}
+
+// issueFixes returns the suggested fix (preferred) and the alternatives of an issue located by its snippet,
+// and the suppression of its rule on the line
+func issueFixes(text string, located lspIssue) []issueFix {
+	issue := located.Issue
+	var fixes []issueFix
+	if located.Exact {
+		original := text[located.Start:located.End]
+		if fixed := strings.TrimSpace(issue.FixedSnippet); fixed != "" && fixed != original {
+			fixes = append(fixes, issueFix{
+				Title:       fmt.Sprintf("Apply the suggested fix (%s)", issue.RuleName),
+				IsPreferred: true,
+				Edits:       []lspTextEdit{textEdit(text, located.Start, located.End, fixed)},
+			})
+		}
+		for _, alternative := range issue.Alternatives {
+			if fixed := strings.TrimSpace(alternative.Snippet); fixed != "" && fixed != original {
+				fixes = append(fixes, issueFix{
+					Title: fmt.Sprintf("Apply alternative fix %d (%s)", alternative.Rank, issue.RuleName),
+					Edits: []lspTextEdit{textEdit(text, located.Start, located.End, fixed)},
+				})
+			}
+		}
+	}
+	lineStart := strings.LastIndex(text[:located.Start], "\n") + 1
+	indent := leadingWhitespacePattern.FindString(text[lineStart:])
+	fixes = append(fixes, issueFix{
+		Title: fmt.Sprintf("Suppress %s on this line", issue.RuleName),
+		Edits: []lspTextEdit{textEdit(text, lineStart, lineStart, linter.SuppressionComment(issue.RuleName, indent))},
+	})
+	return fixes
+}
+
+// leadingWhitespacePattern matches the indentation of a line
+var leadingWhitespacePattern = regexp.MustCompile(`^[ \t]*`)
+
+// locateInFile finds the issue in the file content: the original snippet from its reported line on, anywhere in
+// the file, or the reported line. Lines are mapped to the file when the loader kept the prompt text verbatim,
+// prompt is empty when it is unknown.
+func locateInFile(content, prompt string, issue Issue) (start, end int, exact bool) {
+	hint, lineStart, lineEnd := 0, -1, -1
+	if prompt != "" && issue.Line > 0 {
+		if base := strings.Index(content, prompt); base >= 0 {
+			if s, e, ok := lineBounds(prompt, issue.Line); ok {
+				hint, lineStart, lineEnd = base+s, base+s, base+e
+			}
+		}
+	}
+	if snippet := strings.TrimSpace(issue.OriginalSnippet); snippet != "" {
+		if i := strings.Index(content[hint:], snippet); i >= 0 {
+			return hint + i, hint + i + len(snippet), true
+		}
+		if i := strings.Index(content, snippet); i >= 0 {
+			return i, i + len(snippet), true
+		}
+	}
+	if lineStart >= 0 {
+		return lineStart, lineEnd, false
+	}
+	return 0, 0, false
+}
+
+// columnInFile returns the offset of the reported line and column of the issue in the file content, false when
+// the issue has no column or its line can't be mapped to the file
+func columnInFile(content, prompt string, issue Issue) (int, bool) {
+	if prompt == "" || issue.Line <= 0 || issue.Column <= 0 {
+		return 0, false
+	}
+	base := strings.Index(content, prompt)
+	if base < 0 {
+		return 0, false
+	}
+	start, end, ok := lineBounds(prompt, issue.Line)
+	if !ok {
+		return 0, false
+	}
+	column := 1
+	for offset := range prompt[start:end] {
+		if column == issue.Column {
+			return base + start + offset, true
+		}
+		column++
+	}
+	if column == issue.Column {
+		return base + end, true
+	}
+	return 0, false
+}
+
+// diagnosticRange returns the range shown for the located issue, starting at its reported column when it has one
+func diagnosticRange(content, prompt string, located lspIssue) (int, int) {
+	start, end := located.Start, located.End
+	if column, ok := columnInFile(content, prompt, located.Issue); ok {
+		start = column
+		if end < start {
+			end = start
+		}
+	}
+	return start, end
+}
+
+// lineBounds returns the byte offsets of the start and end of a 1-based line
+func lineBounds(text string, line int) (int, int, bool) {
+	start := 0
+	for i := 1; i < line; i++ {
+		next := strings.IndexByte(text[start:], '\n')
+		if next < 0 {
+			return 0, 0, false
+		}
+		start += next + 1
+	}
+	end := strings.IndexByte(text[start:], '\n')
+	if end < 0 {
+		return start, len(text), true
+	}
+	return start, start + end, true
+}
+
+// utf16Units is the length of the rune in UTF-16 code units, LSP counts characters in them
+func utf16Units(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// offsetToLSP converts a byte offset to an LSP position
+func offsetToLSP(text string, offset int) lspPosition {
+	lineStart := strings.LastIndex(text[:offset], "\n") + 1
+	character := 0
+	for _, r := range text[lineStart:offset] {
+		character += utf16Units(r)
+	}
+	return lspPosition{Line: strings.Count(text[:offset], "\n"), Character: character}
+}
+
+// lspToOffset converts an LSP position to a byte offset, positions past the end of a line are clamped to it
+func lspToOffset(text string, position lspPosition) int {
+	start, end, ok := lineBounds(text, position.Line+1)
+	if !ok {
+		return len(text)
+	}
+	units := 0
+	for i, r := range text[start:end] {
+		if units >= position.Character {
+			return start + i
+		}
+		units += utf16Units(r)
+	}
+	return end
+}
+
+// windowsDrivePattern matches the path of a file URI on Windows, e.g. /C:/prompts
+var windowsDrivePattern = regexp.MustCompile(`^/[A-Za-z]:`)
+
+// uriToPath converts a file URI to a local path
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid document URI %q: %w", uri, err)
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported document URI %q, only file URIs are supported", uri)
+	}
+	path := u.Path
+	if windowsDrivePattern.MatchString(path) {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// runLSPCommand implements `promptlint lsp`
+func runLSPCommand(args []string) error {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	dismissalsFile := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	debounce := fs.Duration("debounce", 500*time.Millisecond, "Quiet time after a change before the LLM check starts")
+	stdio := fs.Bool("stdio", true, "Communicate over stdin/stdout, the only transport, accepted for editor clients that pass it")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s lsp [--stdio] [--rules=pack.yaml] [--dismissals=file] [--debounce=500ms]
+
+Runs a Language Server Protocol server over stdin/stdout. Open prompt files are
+linted as they change: analyzer and static rule results are published at once,
+LLM results follow when typing pauses for --debounce, a newer change cancels
+the running LLM check. Code actions
+apply the suggested fix or an alternative, rewrite the prompt with all fixes,
+or suppress the rule on the line with a promptlint-disable-next-line comment.
+Capabilities and messages are documented in docs/editor-integration.md.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *debounce < 0 {
+		return fmt.Errorf("--debounce must not be negative")
+	}
+	if !*stdio {
+		return fmt.Errorf("stdio is the only supported transport")
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+
+	server := &lspServer{
+		in:             bufio.NewReader(os.Stdin),
+		out:            os.Stdout,
+		rules:          rules,
+		config:         config,
+		dismissalsFile: *dismissalsFile,
+		debounce:       *debounce,
+		docs:           map[string]*lspDocument{},
+	}
+	printProgress("Language server started")
+	return server.serve()
+}
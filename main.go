@@ -1,72 +1,50 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"embed"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/korchasa/promptlint/pkg/analyzers"
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/llm"
+	"github.com/korchasa/promptlint/pkg/report"
+	"github.com/korchasa/promptlint/pkg/rules"
 )
 
 const (
-	appName    = "promptlint"
-	appVersion = "0.1.0"
+	appName = "promptlint"
 
 	// ANSI color codes
-	colorReset  = "\033[0m"
-	colorRed    = "\033[31m"
-	colorGreen  = "\033[32m"
-	colorYellow = "\033[33m"
-	colorBlue   = "\033[34m"
-	colorBold   = "\033[1m"
+	colorReset  = report.ColorReset
+	colorRed    = report.ColorRed
+	colorGreen  = report.ColorGreen
+	colorYellow = report.ColorYellow
+	colorBlue   = report.ColorBlue
+	colorBold   = report.ColorBold
+	colorDim    = report.ColorDim
 )
 
-//go:embed prompt_rules.yaml
-var embeddedRules embed.FS
-
-// PromptRule represents a rule structure for prompt checking
-type PromptRule struct {
-	Name        string `yaml:"name"`
-	Rule        string `yaml:"rule"`
-	Reason      string `yaml:"reason"`
-	Fix         string `yaml:"fix"`
-	BadExample  string `yaml:"badExample"`
-	GoodExample string `yaml:"goodExample"`
-	Pattern     string `yaml:"pattern,omitempty"`
-	MinLength   int    `yaml:"minLength,omitempty"`
-	MaxLength   int    `yaml:"maxLength,omitempty"`
-}
-
-// Rules contains a list of rules for linting
-type Rules struct {
-	PromptRules []PromptRule `yaml:"prompt_rules"`
-}
-
-// Issue represents a problem found during linting
-type Issue struct {
-	RuleName        string
-	Description     string
-	Reason          string
-	Fix             string
-	OriginalSnippet string
-	FixedSnippet    string
-}
-
-// LLMConfig contains settings for LLM API interaction
-type LLMConfig struct {
-	APIKey      string
-	APIEndpoint string
-	ModelName   string
-	Timeout     time.Duration
-}
+// Rule, issue, prompt model and LLM configuration types are defined by the importable packages
+type (
+	PromptRule     = rules.Rule
+	Rules          = rules.Rules
+	Issue          = linter.Issue
+	FixAlternative = linter.FixAlternative
+	LLMConfig      = llm.Config
+	PromptModel    = linter.PromptModel
+	ChatMessage    = linter.ChatMessage
+	Position       = linter.Position
+	Section        = linter.Section
+	Sentence       = linter.Sentence
+	Placeholder    = linter.Placeholder
+	CodeFence      = linter.CodeFence
+)
 
 // LLMRequest represents a request to the LLM API
 type LLMRequest struct {
@@ -90,7 +68,9 @@ var (
 func printProgress(message string) {
 	messageFormatted := message
 
-	if useColorForProgress {
+	if accessibleOutput {
+		fmt.Fprintf(os.Stderr, "[%s] %s%s\n", appName, progressMarker(message), message)
+	} else if useColorForProgress {
 		appNameFormatted := fmt.Sprintf("%s%s%s%s", colorBlue, colorBold, appName, colorReset)
 
 		// Add color to specific message types
@@ -108,61 +88,51 @@ func printProgress(message string) {
 	}
 }
 
-// LoadRules loads rules from the embedded YAML file
+// LoadRules loads the rules downloaded by `rules update` when they are usable, the embedded rules otherwise.
+// rules_version in the project configuration pins the version.
 func LoadRules() (*Rules, error) {
-	data, err := embeddedRules.ReadFile("prompt_rules.yaml")
-	if err != nil {
-		return nil, fmt.Errorf("failed to read embedded rules file: %w", err)
-	}
-
-	var rules Rules
-	err = yaml.Unmarshal(data, &rules)
+	pinned, err := loadRulesVersion("")
 	if err != nil {
-		return nil, fmt.Errorf("error parsing embedded YAML file: %w", err)
+		return nil, err
 	}
-
-	printProgress(fmt.Sprintf("Loaded %d built-in rules successfully", len(rules.PromptRules)))
-	return &rules, nil
+	return loadRules(pinned)
 }
 
-// isColorTerminal returns true if the terminal supports color output
-func isColorTerminal() bool {
-	// Check if stdout is a terminal
-	fileInfo, _ := os.Stdout.Stat()
-	if (fileInfo.Mode() & os.ModeCharDevice) == 0 {
-		// Stdout is not a terminal (probably a pipe or a file)
-		return false
+// loadRules loads the downloaded or embedded rules, a pinned version must be one of them
+func loadRules(pinnedVersion string) (*Rules, error) {
+	embedded, err := rules.Embedded()
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if TERM environment variable indicates color support
-	term := os.Getenv("TERM")
-	if term == "dumb" {
-		return false
+	// Broken downloads never stop linting, the embedded rules are always available
+	cached, meta, err := loadCachedRules(embedded.Version, pinnedVersion)
+	if err != nil {
+		printProgress(fmt.Sprintf("Failed to load updated rules, using built-in rules: %v", err))
+	} else if cached != nil {
+		printProgress(fmt.Sprintf("Loaded %d rules of version %s from %s", len(cached.PromptRules), cached.Version, meta.Source))
+		return policy.enforceRules(cached), nil
 	}
 
-	// Default to true for most modern terminals
-	return true
-}
-
-// formatOriginalSnippet highlights the problematic parts of an example
-func formatOriginalSnippet(snippet string, useColor bool) string {
-	if !useColor {
-		return snippet
+	if pinnedVersion != "" && compareVersions(embedded.Version, pinnedVersion) != 0 {
+		return nil, fmt.Errorf("rules_version %s is pinned in %s, but the installed rules are %s: run `%s rules update`", pinnedVersion, configFileName, embedded.Version, appName)
 	}
-	return colorRed + snippet + colorReset
+	printProgress(fmt.Sprintf("Loaded %d built-in rules successfully", len(embedded.PromptRules)))
+	return policy.enforceRules(embedded), nil
 }
 
-// formatFixedSnippet highlights the fixed parts of an example
-func formatFixedSnippet(snippet string, useColor bool) string {
-	if !useColor {
-		return snippet
-	}
-	return colorGreen + snippet + colorReset
+// isColorTerminal returns true if stdout supports color output
+func isColorTerminal() bool {
+	return colorEnabled(os.Stdout)
 }
 
 // Report formats the found issues into a report.
 // If there are no issues, returns a message about the absence of problems.
 func Report(issues []Issue, forceColor bool, noColor bool) string {
+	if accessibleOutput {
+		return report.Accessible(issues)
+	}
+
 	useColor := false
 
 	// Determine color usage based on flags and terminal capabilities
@@ -174,372 +144,239 @@ func Report(issues []Issue, forceColor bool, noColor bool) string {
 		useColor = isColorTerminal()
 	}
 
-	if len(issues) == 0 {
-		if useColor {
-			return fmt.Sprintf("%s%sNo issues found!%s\n", colorGreen, colorBold, colorReset)
-		}
-		return "No issues found!\n"
-	}
-
-	var sb strings.Builder
-
-	// Output the number of issues found
-	if useColor {
-		sb.WriteString(fmt.Sprintf("Found %s%d issues%s:\n\n", colorBold, len(issues), colorReset))
-	} else {
-		sb.WriteString(fmt.Sprintf("Found %d issues:\n\n", len(issues)))
-	}
-
-	for i, issue := range issues {
-		// Issue header with number and name
-		if useColor {
-			sb.WriteString(fmt.Sprintf("%s%s[Issue %d] %s%s\n", colorBlue, colorBold, i+1, issue.Description, colorReset))
-		} else {
-			sb.WriteString(fmt.Sprintf("[Issue %d] %s\n", i+1, issue.Description))
-		}
-
-		// Problem reason
-		if useColor {
-			sb.WriteString(fmt.Sprintf("%sReason:%s %s\n", colorBold, colorReset, issue.Reason))
-		} else {
-			sb.WriteString(fmt.Sprintf("Reason: %s\n", issue.Reason))
-		}
-
-		// Fix recommendation
-		if useColor {
-			sb.WriteString(fmt.Sprintf("%sFix:%s %s\n", colorBold, colorReset, issue.Fix))
-		} else {
-			sb.WriteString(fmt.Sprintf("Fix: %s\n", issue.Fix))
-		}
-
-		// Examples if available
-		if issue.OriginalSnippet != "" && issue.FixedSnippet != "" {
-			sb.WriteString("\n")
+	return report.Text(issues, report.TextOptions{Color: useColor, Hyperlinks: useColor && hyperlinksEnabled(os.Stdout)})
+}
 
-			// Format original snippet - display with indentation for multiline snippets
-			if useColor {
-				sb.WriteString(fmt.Sprintf("%sOriginal snippet:%s\n", colorBold, colorReset))
-				sb.WriteString(formatOriginalSnippet(indentSnippet(issue.OriginalSnippet), useColor))
-				sb.WriteString("\n")
-			} else {
-				sb.WriteString("Original snippet:\n")
-				sb.WriteString(indentSnippet(issue.OriginalSnippet))
-				sb.WriteString("\n")
-			}
+// JSONReport is the report printed with --format=json
+type JSONReport struct {
+	report.File
+	// Disagreements are set in consensus mode
+	Disagreements []Disagreement `json:"disagreements,omitempty"`
+}
 
-			// Format fixed snippet - display with indentation for multiline snippets
-			if useColor {
-				sb.WriteString(fmt.Sprintf("%sFixed snippet:%s\n", colorBold, colorReset))
-				sb.WriteString(formatFixedSnippet(indentSnippet(issue.FixedSnippet), useColor))
-				sb.WriteString("\n")
-			} else {
-				sb.WriteString("Fixed snippet:\n")
-				sb.WriteString(indentSnippet(issue.FixedSnippet))
-				sb.WriteString("\n")
-			}
-		}
+// newJSONReport builds the JSON report of a file
+func newJSONReport(source string, issues []Issue, disagreements []Disagreement) JSONReport {
+	return JSONReport{File: report.NewFile(source, issues), Disagreements: disagreements}
+}
 
-		// Separator between issues
-		if i < len(issues)-1 {
-			sb.WriteString("\n" + strings.Repeat("─", 60) + "\n\n")
-		}
+// ReportJSON formats the found issues as JSON, dismissed issues are included with their dismissal
+func ReportJSON(source string, issues []Issue, disagreements []Disagreement) (string, error) {
+	data, err := json.MarshalIndent(newJSONReport(source, issues, disagreements), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
 	}
+	return string(data), nil
+}
 
-	return sb.String()
+// issueLocation formats the line of the issue with the column when it is known
+func issueLocation(issue Issue) string {
+	return report.Location(issue)
 }
 
 // indentSnippet adds indentation to each line of a multiline snippet
 func indentSnippet(snippet string) string {
-	lines := strings.Split(snippet, "\n")
-	for i := range lines {
-		lines[i] = "    " + lines[i]
-	}
-	return strings.Join(lines, "\n")
+	return report.IndentSnippet(snippet)
 }
 
 // errHandler processes errors and outputs a message to the user
 func errHandler(err error, message string) {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", message, err)
+		stopProfiling()
 		os.Exit(1)
 	}
 }
 
-// readFromFile reads file contents
+// readFromFile reads file contents decoded to UTF-8 with LF line endings
 func readFromFile(filePath string) (string, error) {
+	text, _, err := readFileEncoded(filePath)
+	return text, err
+}
+
+// readFileEncoded reads file contents decoded to UTF-8 with LF line endings and the encoding of the file
+func readFileEncoded(filePath string) (string, inputEncoding, error) {
 	printProgress(fmt.Sprintf("Reading prompt from file: %s", filePath))
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", inputEncoding{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := checkInputSize(info.Size()); err != nil {
+		return "", inputEncoding{}, err
+	}
+
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return "", inputEncoding{}, fmt.Errorf("failed to read file: %w", err)
 	}
-	return string(data), nil
+	return decodeInput(data)
 }
 
-// readFromStdin reads all input from stdin
+// readFromStdin reads all input from stdin without line limits, decoded to UTF-8 with LF line endings
 func readFromStdin() (string, error) {
-	printProgress("Reading prompt from stdin")
-	scanner := bufio.NewScanner(os.Stdin)
-	var sb strings.Builder
+	text, _, err := readStdinEncoded()
+	return text, err
+}
 
-	for scanner.Scan() {
-		sb.WriteString(scanner.Text())
-		sb.WriteString("\n")
+// readStdinEncoded reads all input from stdin decoded to UTF-8 with LF line endings and the encoding of the
+// input, so fixed prompts are written back in the line endings and encoding they came in
+func readStdinEncoded() (string, inputEncoding, error) {
+	printProgress("Reading prompt from stdin")
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, maxInputSize+1))
+	if err != nil {
+		return "", inputEncoding{}, fmt.Errorf("error reading from stdin: %w", err)
 	}
-
-	if scanner.Err() != nil {
-		return "", fmt.Errorf("error reading from stdin: %w", scanner.Err())
+	if err := checkInputSize(int64(len(data))); err != nil {
+		return "", inputEncoding{}, err
 	}
 
 	printProgress("Stdin read successfully")
-	return sb.String(), nil
+	return decodeInput(data)
 }
 
-// printUsage prints usage information
+// parseFlagsWithArgs parses flags that may be interspersed with positional arguments
+func parseFlagsWithArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// printUsage prints usage information, the commands are listed from the registry
 func printUsage() {
 	fmt.Fprintf(os.Stderr, `Usage of %s:
+  %s [command] [options] [file...]
   %s -file=your-prompt.txt   Check prompt in file
+  %s prompts/*.md other.md  Check several files, quoted globs like 'prompts/**/*.md' are expanded
+  %s --dir=prompts --exclude='drafts/'
+                             Check all prompt files of a directory tree
   cat prompt.txt | %s        Check prompt from stdin
   %s -version                Show version information
 
-Options:
+Without a command the prompts are checked as by check. Run '%s help <command>'
+for the options of a command.
+
+Commands:
+`, appName, appName, appName, appName, appName, appName, appName, appName)
+	printCommandList(os.Stderr, "", commands)
+	fmt.Fprintf(os.Stderr, `
+Options of check:
   -file string           Path to file with prompt
   -version               Show version information
   --force-color          Force colored output
   --no-color             Disable colored output
-`, appName, appName, appName, appName)
+  --accessible           Use textual markers instead of colors and box-drawing separators
+  --fix                  Apply suggested fixes to the prompt
+  --until-clean          Re-lint and fix until no issues remain (requires --fix)
+  --max-iterations int   Maximum number of fix iterations (default 3)
+  --alternatives int     Number of alternative fixes to request per issue (0-3)
+  --interactive          Choose which fix to apply for every issue (requires --fix)
+  -rules-doc             Print Markdown documentation for the built-in rules
+  --input-format string  Input format: auto (default), text, markdown, chat-json, prompty, code,
+                         chat-yaml, dotprompt
+  --stdin-filename string
+                         Name of the stdin input used in reports, format detection and config lookup
+  --max-size string      Maximum input size, e.g. 512KB or 2MB (default 1MB)
+  --engine string        Rule engine: llm (default), static (rule pattern/minLength/maxLength checks without LLM calls),
+                         both
+  --incremental          Check the prompt section by section, unchanged sections reuse cached results
+  --judges string        Comma-separated models judging the prompt independently, issues need a quorum (consensus mode)
+                         and issues of a single judge are listed as disagreements
+  --quorum int           Number of judges that must report an issue (default: majority)
+  --judge-prompt string  Version of the judge system message (default: judge_prompt from .promptlint.yaml or v1),
+                         compare versions with judge-ab
+  --provider string      LLM API: openai (default, OpenAI-compatible chat completions), anthropic (Messages API,
+                         key from ANTHROPIC_API_KEY), azure (Azure OpenAI resource URL as endpoint, deployment from
+                         PROMPTLINT_AZURE_DEPLOYMENT, key from AZURE_OPENAI_API_KEY)
+  --offline              Forbid network access for air-gapped environments: the local heuristic judge replaces the LLM
+                         and any attempted connection is an error (env PROMPTLINT_OFFLINE, offline in .promptlint.yaml)
+  --record string        Write the LLM API responses of the run to the fixtures directory (env PROMPTLINT_RECORD)
+  --replay string        Answer LLM API requests with the responses recorded in the fixtures directory, no API key
+                         or network access needed, unrecorded requests fail (env PROMPTLINT_REPLAY)
+  --rules string         Rules file in the prompt_rules.yaml format merged into the built-in rules (repeatable,
+                         "replace: true" in the file replaces the rules loaded before it)
+  --dismissals string    Path to the dismissals file (default .promptlint-dismissals.yaml)
+  --disable string       Comma-separated rules and analyzers not checked, added to disable in .promptlint.yaml
+  --enable-only string   Comma-separated rules and analyzers checked, all others are skipped (enable_only in
+                         .promptlint.yaml); both restrict the rules sent to the LLM and the reported issues
+  --only-category string Report only issues of the comma-separated categories, e.g. clarity,context
+  --min-severity string  Report only issues with the severity or above: info, warning, error
+  --collect-feedback     Label every reported issue as correct or incorrect, stored in .promptlint/feedback.jsonl
+  --feedback-file string Path to the file with labeled issues
+  --format string        Output format: text (default), json (issues as JSON), sarif (SARIF 2.1.0 for code scanning),
+                         vscode (stable diagnostics JSON for editor extensions), github (annotations, default
+                         when GITHUB_ACTIONS=true), markdown (GitHub-flavored report with collapsible issues for
+                         pull request comments), junit (JUnit XML for CI test views: a suite per prompt, a test
+                         case per rule and analyzer, a failure per issue), ast (parsed prompt model as JSON)
+  --output value         Write the report in a format to a destination instead of --format, repeatable:
+                         format[,stdout|stderr|path|http(s) URL], e.g. --output text --output sarif,out.sarif
+                         --output markdown,https://bot.example/comment (default destination stdout)
+  --export string        Record the run in experiment trackers: braintrust, wandb
+  --check-urls           Check that links in the prompt resolve
+  --stats                Report prompt tokens and the estimated lint call cost for the judge model, with token
+                         budgets; printed after text reports and to stderr with other formats
+  --pin                  Record model snapshot, seed and rules hash in the lockfile, later runs warn on changes
+  --lockfile string      Path to the lockfile of pinned runs (default .promptlint.lock)
+  --manifest string      Write a JSON run manifest: versions, rules hashes, model, flags, file hashes, timings
+  --url-timeout duration Timeout of a single link check (default 5s)
+  --baseline string      Report only issues missing from the baseline file; without the file the run records the
+                         current issues in it, --update-baseline rewrites it
+  --watch                Lint again whenever the prompts, their configuration, variables or rules files change,
+                         clearing the terminal for every run (stop with Ctrl+C)
+  --vars string          YAML file with the template variables the prompts are rendered with, reports
+                         undefined and unused variables and positional placeholders
+  --lines string         Lint only the line range of the prompt (e.g. 40-80, 40-), issues keep the line numbers
+                         of the whole prompt; the LLM sees only the range
+  --section string       Lint only the section with the heading or tag title (e.g. "Output format"), like --lines
+  --shard string         Lint only part i of n of the input files (e.g. 2/4) in CI matrix jobs, combine
+                         their --format=json results with the merge command
+`)
 }
 
-// checkPromptWithLLM checks the prompt using LLM API
-func checkPromptWithLLM(prompt string, rules *Rules, config *LLMConfig) ([]Issue, error) {
-	printProgress("Starting LLM-based prompt validation")
-
-	if config.APIKey == "" {
-		return nil, fmt.Errorf("API key is missing, set PROMPTLINT_API_KEY")
-	}
-
-	if config.APIEndpoint == "" {
-		return nil, fmt.Errorf("API endpoint is missing, set PROMPTLINT_API_ENDPOINT")
-	}
-
-	// Format rules as text for LLM
-	var rulesDescription strings.Builder
-	rulesDescription.WriteString("List of prompt checking rules:\n\n")
-
-	for i, rule := range rules.PromptRules {
-		rulesDescription.WriteString(fmt.Sprintf("%d. Rule: %s\n", i+1, rule.Name))
-		rulesDescription.WriteString(fmt.Sprintf("   Description: %s\n", rule.Rule))
-		rulesDescription.WriteString(fmt.Sprintf("   Reason: %s\n", rule.Reason))
-		if rule.BadExample != "" {
-			rulesDescription.WriteString(fmt.Sprintf("   Original snippet: %s\n", rule.BadExample))
-		}
-		if rule.GoodExample != "" {
-			rulesDescription.WriteString(fmt.Sprintf("   Fixed snippet: %s\n", rule.GoodExample))
-		}
-		rulesDescription.WriteString("\n")
-	}
-
-	// Prepare request to LLM API
-	systemMessage := `You are a prompt evaluation expert. Your task is to analyze a prompt and determine if it follows the provided rules.
-
-Analyze the prompt against each rule and identify violations. The rules are provided in a separate message.
-
-Use the find_prompt_issues tool to return the issues found in the prompt. If there are no issues, return an empty array.`
-
-	// Define a tool for finding prompt issues
-	tools := []map[string]interface{}{
-		{
-			"type": "function",
-			"function": map[string]interface{}{
-				"name":        "find_prompt_issues",
-				"description": "Reports issues found in a prompt based on predefined rules",
-				"parameters": map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"issues": map[string]interface{}{
-							"type":        "array",
-							"description": "List of issues found in the prompt",
-							"items": map[string]interface{}{
-								"type": "object",
-								"properties": map[string]interface{}{
-									"name": map[string]interface{}{
-										"type":        "string",
-										"description": "Name of the violated rule",
-									},
-									"description": map[string]interface{}{
-										"type":        "string",
-										"description": "Description of the problem",
-									},
-									"reason": map[string]interface{}{
-										"type":        "string",
-										"description": "Why this is a problem (from the rules)",
-									},
-									"fix": map[string]interface{}{
-										"type":        "string",
-										"description": "Recommendation for fixing",
-									},
-									"originalSnippet": map[string]interface{}{
-										"type":        "string",
-										"description": "Problematic part of the prompt (if applicable)",
-									},
-									"fixedSnippet": map[string]interface{}{
-										"type":        "string",
-										"description": "Improved version of the snippet (if applicable)",
-									},
-								},
-								"required": []string{"name", "description", "reason", "fix", "originalSnippet", "fixedSnippet"},
-							},
-						},
-					},
-					"required": []string{"issues"},
-				},
-			},
-		},
-	}
-
-	requestBody := map[string]interface{}{
-		"model": config.ModelName,
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": systemMessage,
-			},
-			{
-				"role":    "user",
-				"content": rulesDescription.String(),
-			},
-			{
-				"role":    "user",
-				"content": "Analyze the following prompt against the specified rules:\n\n" + prompt,
-			},
-		},
-		"tools": tools,
-		"tool_choice": map[string]interface{}{
-			"type": "function",
-			"function": map[string]string{
-				"name": "find_prompt_issues",
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("request serialization error: %w", err)
-	}
-
-	// Prepare HTTP request
-	client := &http.Client{
-		Timeout: config.Timeout,
-	}
-
-	req, err := http.NewRequest("POST", config.APIEndpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
+// promptCheckInstruction introduces the prompt in the request to the LLM API
+const promptCheckInstruction = linter.DefaultInstruction
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+config.APIKey)
-
-	// Execute request
-	printProgress("Sending request to LLM API")
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error executing request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	// Process response
-	var responseData map[string]interface{}
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&responseData); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
-	}
-
-	// Extract tool call results
+// checkPromptWithLLM checks the prompt using LLM API (skipped by --engine=static and without enabled rules),
+// static rules and the registered analyzers
+func checkPromptWithLLM(prompt string, rules *Rules, config *LLMConfig) ([]Issue, error) {
 	var issues []Issue
-
-	// Navigate through the response structure to extract tool calls
-	if choices, ok := responseData["choices"].([]interface{}); ok && len(choices) > 0 {
-		if choice, ok := choices[0].(map[string]interface{}); ok {
-			if message, ok := choice["message"].(map[string]interface{}); ok {
-				if toolCalls, ok := message["tool_calls"].([]interface{}); ok && len(toolCalls) > 0 {
-					// We found tool calls, extract the function arguments
-					for _, tc := range toolCalls {
-						if toolCall, ok := tc.(map[string]interface{}); ok {
-							if function, ok := toolCall["function"].(map[string]interface{}); ok {
-								if args, ok := function["arguments"].(string); ok {
-									// Parse the arguments as JSON
-									var toolResponse map[string]interface{}
-									if err := json.Unmarshal([]byte(args), &toolResponse); err != nil {
-										return nil, fmt.Errorf("error parsing tool response: %w", err)
-									}
-
-									// Extract issues from the tool response
-									if issuesData, ok := toolResponse["issues"].([]interface{}); ok {
-										printProgress(fmt.Sprintf("Found %d issues", len(issuesData)))
-										for _, issueData := range issuesData {
-											if issueMap, ok := issueData.(map[string]interface{}); ok {
-												issue := Issue{
-													RuleName:        getStringValue(issueMap, "name"),
-													Description:     getStringValue(issueMap, "description"),
-													Reason:          getStringValue(issueMap, "reason"),
-													Fix:             getStringValue(issueMap, "fix"),
-													OriginalSnippet: getStringValue(issueMap, "originalSnippet"),
-													FixedSnippet:    getStringValue(issueMap, "fixedSnippet"),
-												}
-												issues = append(issues, issue)
-											}
-										}
-									}
-								}
-							}
-						}
-					}
-				} else {
-					printProgress("No tool calls found in response, trying legacy format")
-					// Fallback to content-based response (older model or API version)
-					if content, ok := message["content"].(string); ok && content != "" {
-						var legacyIssues []map[string]string
-						// Try to parse JSON array from the content
-						jsonStartIdx := strings.Index(content, "[")
-						jsonEndIdx := strings.LastIndex(content, "]")
-
-						if jsonStartIdx >= 0 && jsonEndIdx > jsonStartIdx {
-							jsonContent := content[jsonStartIdx : jsonEndIdx+1]
-							if err := json.Unmarshal([]byte(jsonContent), &legacyIssues); err != nil {
-								return nil, fmt.Errorf("error parsing legacy response: %w", err)
-							}
-						} else {
-							// Try to parse the entire content
-							if err := json.Unmarshal([]byte(content), &legacyIssues); err != nil {
-								return nil, fmt.Errorf("failed to parse legacy response as JSON: %w\nResponse: %s", err, content)
-							}
-						}
-
-						// Convert legacy format to Issue structure
-						for _, issueMap := range legacyIssues {
-							issue := Issue{
-								RuleName:        issueMap["name"],
-								Description:     issueMap["description"],
-								Reason:          issueMap["reason"],
-								Fix:             issueMap["fix"],
-								OriginalSnippet: issueMap["originalSnippet"],
-								FixedSnippet:    issueMap["fixedSnippet"],
-							}
-							issues = append(issues, issue)
-						}
-					}
-				}
-			}
+	if ruleEngine != "static" && len(rules.PromptRules) > 0 {
+		var err error
+		if issues, err = checkContentWithLLM(promptCheckInstruction, prompt, rules, config); err != nil {
+			return nil, err
 		}
 	}
+	model := linter.ParsePrompt(prompt)
+	issues = append(issues, localIssues(model, rules)...)
+	locateIssues(issues, model)
+	return linter.ApplySuppressions(issues, model), nil
+}
 
-	printProgress("Validation completed")
-	return issues, nil
+// checkContentWithLLM checks the content using LLM API, the instruction tells the model how to treat the content
+func checkContentWithLLM(instruction, content string, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	result, err := linter.Check(ctx, content, linter.Options{
+		Rules:       rules,
+		LLM:         config,
+		Instruction: instruction,
+		Locale:      ruleLocale,
+		JudgePrompt: judgePrompt,
+		Progress:    printProgress,
+		// Static rules and analyzers run with the local checks, on the whole prompt and with the settings of the file
+		JudgeOnly: true,
+	})
+	config.ServedModel, config.SystemFingerprint = result.ServedModel, result.SystemFingerprint
+	return result.Issues, err
 }
 
 // getStringValue safely extracts a string value from a map
@@ -552,28 +389,108 @@ func getStringValue(m map[string]interface{}, key string) string {
 	return ""
 }
 
+// configuredProvider resolves the provider: --provider, PROMPTLINT_PROVIDER, the project configuration, the default
+func configuredProvider(settings *ProjectConfig) (llm.Provider, error) {
+	name := providerName
+	if name == "" {
+		name = os.Getenv("PROMPTLINT_PROVIDER")
+	}
+	if name == "" {
+		name = settings.Provider
+	}
+	if name == "" {
+		name = defaultProviderName
+	}
+	provider, err := llm.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.checkProvider(provider.Name()); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// configuredModel resolves the model of the provider, isDefault is true when nothing configures it
+func configuredModel(provider llm.Provider, settings *ProjectConfig) (model string, isDefault bool) {
+	if provider.ModelEnv() != "" {
+		model = os.Getenv(provider.ModelEnv())
+	}
+	if model == "" {
+		model = os.Getenv("PROMPTLINT_MODEL_NAME")
+	}
+	if model == "" {
+		model = settings.Model
+	}
+	if model == "" {
+		return provider.DefaultModel(), true
+	}
+	return model, false
+}
+
 // setupLLMConfig configures the LLM API settings
 func setupLLMConfig() (LLMConfig, error) {
 	printProgress("Setting up LLM API configuration")
 
-	apiKey := os.Getenv("PROMPTLINT_API_KEY")
+	// Environment variables override the project configuration of the working directory
+	settings, err := loadRunSettings(".")
+	if err != nil {
+		return LLMConfig{}, fmt.Errorf("failed to load project configuration: %w", err)
+	}
+
+	provider, err := configuredProvider(settings)
+	if err != nil {
+		return LLMConfig{}, err
+	}
+
+	var apiKey string
+	if provider.KeyEnv() != "" {
+		apiKey = os.Getenv(provider.KeyEnv())
+	}
 	if apiKey == "" {
-		return LLMConfig{}, fmt.Errorf("API key not specified, set PROMPTLINT_API_KEY environment variable")
+		apiKey = os.Getenv("PROMPTLINT_API_KEY")
+	}
+	if offline {
+		printProgress("Offline mode, using the local heuristic judge")
+		return LLMConfig{ModelName: heuristicModelName, Heuristic: true, Provider: provider}, nil
+	}
+	// Replayed requests never reach the API, the key only keeps the LLM judge
+	if apiKey == "" && replayDir != "" {
+		apiKey = "replay"
+	}
+	if apiKey == "" {
+		printProgress("API key not specified, falling back to the local heuristic judge")
+		return LLMConfig{ModelName: heuristicModelName, Heuristic: true, Provider: provider}, nil
 	}
 
 	apiEndpoint := os.Getenv("PROMPTLINT_API_ENDPOINT")
 	if apiEndpoint == "" {
-		apiEndpoint = "https://api.openai.com/v1/chat/completions" // Default value
+		apiEndpoint = settings.Endpoint
+	}
+	if apiEndpoint == "" {
+		apiEndpoint = provider.DefaultEndpoint() // Default value
+		if apiEndpoint == "" {
+			return LLMConfig{}, fmt.Errorf("API endpoint is missing, set PROMPTLINT_API_ENDPOINT for the %s provider", provider.Name())
+		}
 		printProgress("Using default API endpoint: " + apiEndpoint)
 	}
+	if err := policy.checkEndpoint(apiEndpoint); err != nil {
+		return LLMConfig{}, err
+	}
 
-	modelName := os.Getenv("PROMPTLINT_MODEL_NAME")
-	if modelName == "" {
-		modelName = "o3-mini" // Default value
+	modelName, isDefault := configuredModel(provider, settings)
+	if isDefault {
 		printProgress("Using default model: " + modelName)
 	}
 
 	timeout := 300 * time.Second
+	if settings.Timeout > 0 {
+		timeout = settings.Timeout
+	}
+	retry, err := configuredRetryPolicy(settings)
+	if err != nil {
+		return LLMConfig{}, err
+	}
 	printProgress("Configuration completed")
 
 	return LLMConfig{
@@ -581,19 +498,120 @@ func setupLLMConfig() (LLMConfig, error) {
 		APIEndpoint: apiEndpoint,
 		ModelName:   modelName,
 		Timeout:     timeout,
+		Provider:    provider,
+		Retry:       retry,
 	}, nil
 }
 
 func main() {
-	printProgress("Starting " + appName + " v" + appVersion)
+	// The policy applies to every command, a broken policy file stops them instead of allowing everything
+	loaded, err := loadPolicy(defaultPolicyPath())
+	errHandler(err, "Error loading policy")
+	policy = loaded
+
+	// Dispatch commands, arguments naming no command are options and prompts of check
+	if len(os.Args) > 1 {
+		if command := findCommand(commands, os.Args[1]); command != nil {
+			useColorForProgress = colorEnabled(os.Stderr)
+			ruleLocale = configuredLocale()
+			judgePrompt = configuredJudgePrompt()
+			if configuredOffline() {
+				enableOffline()
+			}
+			errHandler(enableFixtures(configuredFixtures()), "Error: invalid PROMPTLINT_RECORD/PROMPTLINT_REPLAY")
+			errHandler(command.dispatch(command.Name, os.Args[2:]), "Error")
+			return
+		}
+		errHandler(unknownCommandError(os.Args[1]), "Error")
+	}
+	errHandler(runLintCommand(os.Args[1:]), "Error")
+}
 
+// runLintCommand implements `promptlint check`, also run by invocations without a command
+func runLintCommand(args []string) error {
 	// Parse command line arguments
-	fileFlag := flag.String("file", "", "Path to file with prompt")
-	versionFlag := flag.Bool("version", false, "Show version information")
-	forceColorFlag := flag.Bool("force-color", false, "Force colored output even when stdout is not a terminal")
-	noColorFlag := flag.Bool("no-color", false, "Disable colored output")
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	fileFlag := fs.String("file", "", "Path to file with prompt")
+	versionFlag := fs.Bool("version", false, "Show version information")
+	forceColorFlag := fs.Bool("force-color", false, "Force colored output even when stdout is not a terminal")
+	noColorFlag := fs.Bool("no-color", false, "Disable colored output")
+	fixFlag := fs.Bool("fix", false, "Apply suggested fixes to the prompt")
+	untilCleanFlag := fs.Bool("until-clean", false, "Re-lint and fix until no issues remain (requires --fix)")
+	maxIterationsFlag := fs.Int("max-iterations", 3, "Maximum number of fix iterations")
+	alternativesFlag := fs.Int("alternatives", 0, "Number of alternative fixes to request per issue (0-3)")
+	dismissalsFlag := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	rulesDocFlag := fs.Bool("rules-doc", false, "Print Markdown documentation for the built-in rules")
+	inputFormatFlag := fs.String("input-format", "auto", "Input format: auto, "+strings.Join(loaderNames(), ", "))
+	stdinFilenameFlag := fs.String("stdin-filename", "", "Name of the stdin input used in reports, format detection and config lookup")
+	maxSizeFlag := fs.String("max-size", "1MB", "Maximum input size, e.g. 512KB or 2MB")
+	accessibleFlag := fs.Bool("accessible", false, "Use textual markers instead of colors and box-drawing separators")
+	interactiveFlag := fs.Bool("interactive", false, "Choose which fix to apply for every issue (requires --fix)")
+	collectFeedbackFlag := fs.Bool("collect-feedback", false, "Label every reported issue as correct or incorrect to build a judge dataset")
+	feedbackFileFlag := fs.String("feedback-file", defaultFeedbackFile, "Path to the file with labeled issues")
+	formatFlag := fs.String("format", "text", "Output format: text, json (issues as JSON), sarif (SARIF 2.1.0 for code scanning), vscode (stable diagnostics JSON for editor extensions), github (GitHub Actions annotations, default when GITHUB_ACTIONS=true), markdown (report for pull request comments), junit (JUnit XML, a test case per rule), ast (parsed prompt model as JSON, no LLM calls)")
+	var outputFlag stringsFlag
+	fs.Var(&outputFlag, "output", "Write the report in a format to a destination, format[,stdout|stderr|path|http(s) URL], repeatable, replaces --format")
+	statsFlag := fs.Bool("stats", false, "Report token counts of the prompts and the estimated cost of the lint calls")
+	checkURLsFlag := fs.Bool("check-urls", false, "Check that links in the prompt resolve (HEAD requests)")
+	urlTimeoutFlag := fs.Duration("url-timeout", analyzers.DefaultURLTimeout, "Timeout of a single link check")
+	exportFlag := fs.String("export", "", "Comma-separated experiment trackers to record the run in: "+strings.Join(exporterNames(), ", "))
+	pinFlag := fs.Bool("pin", false, "Record the model snapshot, seed and rules hash of the run in the lockfile")
+	lockFileFlag := fs.String("lockfile", defaultLockFile, "Path to the lockfile of pinned runs")
+	engineFlag := fs.String("engine", "llm", "Rule engine: llm, static (rule pattern and length checks without LLM calls), both")
+	incrementalFlag := fs.Bool("incremental", false, "Check the prompt section by section and reuse cached results of unchanged sections")
+	judgesFlag := fs.String("judges", "", "Comma-separated models that judge the prompt independently (consensus mode)")
+	quorumFlag := fs.Int("quorum", 0, "Number of judges that must report an issue in consensus mode (default: majority)")
+	dirFlag := fs.String("dir", "", "Lint all prompt files of the directory tree")
+	extFlag := fs.String("ext", defaultInventoryExtensions, "Comma-separated extensions of prompt files for --dir")
+	var includeFlag, excludeFlag stringsFlag
+	fs.Var(&includeFlag, "include", "Gitignore-like pattern of files to lint in --dir, repeatable")
+	fs.Var(&excludeFlag, "exclude", "Gitignore-like pattern of files to skip in --dir, \"!\" re-includes, repeatable")
+	offlineFlag := fs.Bool("offline", false, "Forbid network access: local heuristic judge, any attempted connection is an error (env PROMPTLINT_OFFLINE)")
+	recordFlag := fs.String("record", "", "Write the LLM API responses of the run to the fixtures directory (env PROMPTLINT_RECORD)")
+	replayFlag := fs.String("replay", "", "Answer LLM API requests with the responses recorded in the fixtures directory (env PROMPTLINT_REPLAY)")
+	providerFlag := fs.String("provider", "", "LLM API: "+strings.Join(llm.Names(), ", ")+" (default: PROMPTLINT_PROVIDER, provider from "+configFileName+" or "+defaultProviderName+")")
+	judgePromptFlag := fs.String("judge-prompt", "", "Version of the judge system message: "+strings.Join(linter.JudgePrompts(), ", ")+" (default: judge_prompt from "+configFileName+" or "+linter.DefaultJudgePrompt+")")
+	localeFlag := fs.String("locale", "", "Language code of rule reasons and fixes in localized rule packs (default: locale from "+configFileName+")")
+	disableFlag := fs.String("disable", "", "Comma-separated rules and analyzers not checked, in addition to disable in "+configFileName)
+	enableOnlyFlag := fs.String("enable-only", "", "Comma-separated rules and analyzers checked, all others are skipped")
+	onlyCategoryFlag := fs.String("only-category", "", "Report only issues of the comma-separated rule categories, e.g. clarity,context")
+	minSeverityFlag := fs.String("min-severity", "", "Report only issues with the severity or above: info, warning, error")
+	failOnFlag := fs.String("fail-on", "", "Exit with status 1 when active issues reach the severity: info, warning, error (default: never)")
+	var rulesFlag stringsFlag
+	fs.Var(&rulesFlag, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	manifestFlag := fs.String("manifest", "", "Write a JSON manifest of the run (versions, rules hashes, model, flags, file hashes, timings) to the path")
+	baselineFlag := fs.String("baseline", "", "Report only issues missing from the baseline file, a missing file is created with the current issues")
+	updateBaselineFlag := fs.Bool("update-baseline", false, "Rewrite the baseline file with the current issues (requires --baseline)")
+	varsFlag := fs.String("vars", "", "YAML file with the template variables the prompts are rendered with, reports undefined and unused variables")
+	linesFlag := fs.String("lines", "", "Lint only the line range of the prompt, e.g. 40-80, issues keep their line numbers")
+	sectionFlag := fs.String("section", "", "Lint only the section with the heading or tag title, e.g. \"Output format\"")
+	shardFlag := fs.String("shard", "", "Lint only the i-th of n deterministic parts of the input files, e.g. 2/4, for CI matrix jobs")
+	watchFlag := fs.Bool("watch", false, "Lint again whenever the prompts, their configuration, variables or rules files change")
+	cpuProfileFlag := fs.String("cpuprofile", "", "Write a CPU profile of the run to the path")
+	memProfileFlag := fs.String("memprofile", "", "Write a heap profile at the end of the run to the path")
+	fs.Usage = func() { printVisibleDefaults(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := startProfiling(*cpuProfileFlag, *memProfileFlag); err != nil {
+		return err
+	}
+	defer stopProfiling()
+
+	// The manifest is nil without --manifest, recording into it is a no-op then
+	var manifest *RunManifest
+	if *manifestFlag != "" {
+		manifest = newRunManifest()
+		manifest.SetFlags(fs)
+	}
 
-	flag.Parse()
+	// Accessible output never uses colors
+	accessibleOutput = *accessibleFlag
+	updateAnalyzerSettings(func(settings *analyzers.Settings) {
+		settings.CheckURLs, settings.URLTimeout = *checkURLsFlag, *urlTimeoutFlag
+	})
 
 	// Configure color settings based on flags
 	if *forceColorFlag {
@@ -601,64 +619,684 @@ func main() {
 	} else if *noColorFlag {
 		useColorForProgress = false
 	} else {
-		useColorForProgress = isColorTerminal()
+		useColorForProgress = colorEnabled(os.Stderr)
 	}
 
+	printProgress("Starting " + appName + " v" + appVersion)
+
 	// Display version information
 	if *versionFlag {
-		fmt.Printf("%s version %s\n", appName, appVersion)
-		return
+		fmt.Print(FormatVersion(versionInfo()))
+		return nil
+	}
+
+	if *untilCleanFlag && !*fixFlag {
+		printUsage()
+		return fmt.Errorf("--until-clean requires --fix")
+	}
+
+	if *interactiveFlag && !*fixFlag {
+		printUsage()
+		return fmt.Errorf("--interactive requires --fix")
+	}
+
+	if *alternativesFlag < 0 || *alternativesFlag > 3 {
+		return fmt.Errorf("--alternatives must be between 0 and 3")
+	}
+
+	if *maxIterationsFlag < 1 {
+		return fmt.Errorf("--max-iterations must be at least 1")
+	}
+
+	// The project configuration sets the output format unless --format is given
+	settings, err := loadRunSettings(".")
+	if err != nil {
+		return fmt.Errorf("failed to load project configuration: %w", err)
+	}
+	formatSet := false
+	fs.Visit(func(f *flag.Flag) { formatSet = formatSet || f.Name == "format" })
+	if !formatSet && len(outputFlag) == 0 {
+		if settings.Format != "" {
+			*formatFlag = settings.Format
+		} else if githubActions() && !*fixFlag && !*collectFeedbackFlag {
+			// Findings of GitHub Actions jobs become annotations on pull requests
+			*formatFlag = "github"
+		}
+	}
+
+	// --output writes several reports in one run, --format is the single report on stdout
+	var outputs []Output
+	switch {
+	case len(outputFlag) > 0 && formatSet:
+		return fmt.Errorf("invalid --output: --format and --output are mutually exclusive")
+	case len(outputFlag) > 0:
+		outputs, err = parseOutputs(outputFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --output: %w", err)
+		}
+	case *formatFlag != "ast":
+		reporter, err := lookupReporter(*formatFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --format: %w", err)
+		}
+		outputs = []Output{{Reporter: reporter, Destination: "stdout"}}
+	}
+
+	for _, output := range outputs {
+		if output.Reporter.Name() != "text" && (*fixFlag || *collectFeedbackFlag) {
+			return fmt.Errorf("%s reports can't be combined with --fix or --collect-feedback", output.Reporter.Name())
+		}
+	}
+
+	providerName = *providerFlag
+	ruleLocale = *localeFlag
+	if ruleLocale == "" {
+		ruleLocale = settings.Locale
+	}
+	judgePrompt = *judgePromptFlag
+	if judgePrompt == "" {
+		judgePrompt = settings.JudgePrompt
+	}
+	_, err = linter.JudgePrompt(judgePrompt)
+	if err != nil {
+		return fmt.Errorf("invalid --judge-prompt: %w", err)
+	}
+
+	failOn, err := parseFailOn(*failOnFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --fail-on: %w", err)
+	}
+
+	issueFilter, err := parseIssueFilter(*onlyCategoryFlag, *minSeverityFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --min-severity: %w", err)
+	}
+
+	shard, err := parseShard(*shardFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --shard: %w", err)
+	}
+
+	engine, err := parseRuleEngine(*engineFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --engine: %w", err)
+	}
+	ruleEngine = engine
+
+	exportTargets, err := parseExporters(*exportFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --export: %w", err)
+	}
+
+	// Offline mode rejects features that exist only to reach the network instead of failing on every request
+	if *offlineFlag || configuredOffline() {
+		enableOffline()
+		switch {
+		case *checkURLsFlag:
+			return fmt.Errorf("offline mode: --check-urls requests the links over the network")
+		case len(exportTargets) > 0:
+			return fmt.Errorf("offline mode: --export sends the run to experiment trackers over the network")
+		}
+	}
+	record, replay := configuredFixtures()
+	if *recordFlag != "" || *replayFlag != "" {
+		record, replay = *recordFlag, *replayFlag
+	}
+	if err := enableFixtures(record, replay); err != nil {
+		return fmt.Errorf("invalid --record/--replay: %w", err)
+	}
+	for _, output := range outputs {
+		if !output.webhook() {
+			continue
+		}
+		if offline {
+			return fmt.Errorf("offline mode: --output %s posts the report over the network", output.Destination)
+		}
+		if err := policy.checkPrivacy("--output to webhooks"); err != nil {
+			return err
+		}
+	}
+	if *checkURLsFlag {
+		if err := policy.checkPrivacy("--check-urls"); err != nil {
+			return err
+		}
+	}
+	if len(exportTargets) > 0 {
+		if err := policy.checkPrivacy("--export"); err != nil {
+			return err
+		}
+	}
+
+	size, err := parseSize(*maxSizeFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --max-size: %w", err)
+	}
+	maxInputSize = size
+
+	if *varsFlag != "" {
+		variables, err := analyzers.LoadTemplateVariables(*varsFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --vars: %w", err)
+		}
+		updateAnalyzerSettings(func(settings *analyzers.Settings) {
+			settings.Variables, settings.VariablesFile = variables, *varsFlag
+		})
 	}
 
 	// Load built-in rules
 	rules, err := LoadRules()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to load built-in rules: %v\n", err)
-		os.Exit(1)
-		return
+		return fmt.Errorf("failed to load built-in rules: %w", err)
+	}
+	rules, err = applyCustomRules(rules, rulesFlag)
+	if err != nil {
+		return fmt.Errorf("failed to load custom rules: %w", err)
+	}
+	ruleSelection, err = parseRuleSelection(rules, *disableFlag, *enableOnlyFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --disable/--enable-only: %w", err)
+	}
+	if unknown := issueFilter.unknownCategories(rules); len(unknown) > 0 {
+		printProgress(fmt.Sprintf("Warning: --only-category %s matches no rule, known categories: %s", strings.Join(unknown, ", "), strings.Join(knownCategories(rules), ", ")))
+	}
+
+	// Print rules documentation
+	if *rulesDocFlag {
+		fmt.Print(GenerateRulesDocs(rules))
+		return nil
 	}
 
 	// Check if there's data on stdin
 	stdinInfo, _ := os.Stdin.Stat()
 	hasStdin := (stdinInfo.Mode() & os.ModeCharDevice) == 0
 
+	// --file and positional arguments are linted one after another, glob patterns are expanded
+	var sources []string
+	if *fileFlag != "" {
+		sources = append(sources, *fileFlag)
+	}
+	inputs, err := resolveInputs(append(sources, fs.Args()...))
+	if err != nil {
+		return fmt.Errorf("failed to resolve input files: %w", err)
+	}
+
+	// --dir adds the prompt files of a directory tree
+	if *dirFlag != "" {
+		files, err := scanInputDir(*dirFlag, parseExtensions(*extFlag), includeFlag, excludeFlag)
+		if err != nil {
+			return fmt.Errorf("failed to scan directory: %w", err)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("failed to scan directory: no prompt files in %s", *dirFlag)
+		}
+		printProgress(fmt.Sprintf("Found %d prompt files in %s", len(files), *dirFlag))
+		inputs = appendUnique(inputs, files...)
+	} else if len(includeFlag) > 0 || len(excludeFlag) > 0 {
+		return fmt.Errorf("failed to resolve input files: --include and --exclude require --dir")
+	}
+
+	// Without files or stdin the prompts come from the files patterns of the project configuration
+	if len(inputs) == 0 && !hasStdin && len(settings.Files) > 0 {
+		inputs, err = configuredFiles(".")
+		if err != nil {
+			return fmt.Errorf("failed to load project configuration: %w", err)
+		}
+		if len(inputs) == 0 {
+			return fmt.Errorf("failed to load project configuration: files %s match no files", strings.Join(settings.Files, ", "))
+		}
+	}
+
 	// Check if application is launched correctly
-	if *fileFlag == "" && !hasStdin {
-		fmt.Fprintf(os.Stderr, "Error: No input provided. Please specify a file or pipe data to stdin.\n\n")
+	if len(inputs) == 0 && !hasStdin {
 		printUsage()
-		os.Exit(1)
-		return
+		return fmt.Errorf("no input provided, specify a file or pipe data to stdin")
 	}
 
-	// Read prompt from file or stdin
-	var input string
-	if *fileFlag != "" {
-		input, err = readFromFile(*fileFlag)
-		errHandler(err, "Error reading file")
-	} else {
-		input, err = readFromStdin()
-		errHandler(err, "Error reading from stdin")
+	// --shard keeps the files of this CI matrix job, a job without files reports an empty result for merge
+	if shard != nil {
+		if len(inputs) == 0 {
+			return fmt.Errorf("invalid --shard: stdin can't be sharded")
+		}
+		total := len(inputs)
+		inputs = shardFiles(inputs, *shard)
+		printProgress(fmt.Sprintf("Shard %s: %d of %d files", shard, len(inputs), total))
+	}
+
+	// --watch runs the check again on every save, files are resolved again to see new prompts
+	if *watchFlag {
+		switch {
+		case len(inputs) == 0:
+			return fmt.Errorf("invalid --watch: stdin can't be watched, name the prompt files")
+		case *fixFlag || *collectFeedbackFlag:
+			return fmt.Errorf("invalid --watch: --watch can't be combined with --fix or --collect-feedback")
+		}
+		return watchLint(args, func() []string {
+			files, _ := resolveInputs(append(sources, fs.Args()...))
+			if *dirFlag != "" {
+				found, _ := scanInputDir(*dirFlag, parseExtensions(*extFlag), includeFlag, excludeFlag)
+				files = appendUnique(files, found...)
+			}
+			if len(sources) == 0 && len(fs.Args()) == 0 && *dirFlag == "" {
+				files, _ = configuredFiles(".")
+			}
+			// Input directories are watched for new prompts, watchState skips them
+			extra := append([]string{*varsFlag, *dirFlag}, fs.Args()...)
+			return watchedFiles(files, append(extra, rulesFlag...))
+		})
+	}
+
+	// Read prompts from files, prompt stores or stdin, the name of stdin is used for reports, format detection
+	// and configuration lookup. Prompts of stores use the configuration of the working directory.
+	type promptInput struct {
+		name, content string
+		encoding      inputEncoding
+		doc           *Document
+		// configName is the path configurations are looked up for
+		configName string
+	}
+	var prompts []promptInput
+	if len(inputs) > 0 {
+		for _, name := range inputs {
+			if ref, ok := parsePromptRef(name); ok {
+				if *fixFlag {
+					return fmt.Errorf("%s: prompts of stores can't be fixed in place", name)
+				}
+				doc, err := fetchFromStore(context.Background(), ref)
+				if err != nil {
+					return fmt.Errorf("failed to fetch prompt: %w", err)
+				}
+				prompts = append(prompts, promptInput{name: name, content: doc.Text, doc: doc})
+				continue
+			}
+			content, encoding, err := readFileEncoded(name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			prompts = append(prompts, promptInput{name: name, content: content, encoding: encoding, configName: name})
+		}
+	} else if shard == nil {
+		content, encoding, err := readStdinEncoded()
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		prompts = append(prompts, promptInput{name: *stdinFilenameFlag, content: content, encoding: encoding, configName: *stdinFilenameFlag})
 	}
 
-	// Check if input is empty
-	if strings.TrimSpace(input) == "" {
-		fmt.Fprintf(os.Stderr, "Error: Empty input. Please provide a prompt to check.\n\n")
+	// Extract the prompts with the loader of the input format, stores return loaded prompts
+	for i := range prompts {
+		if prompts[i].doc != nil {
+			printProgress("Input format: " + prompts[i].doc.Format)
+			manifest.AddFile(prompts[i].name, prompts[i].content, prompts[i].doc.Format)
+			continue
+		}
+		doc, err := loadDocument(prompts[i].name, []byte(prompts[i].content), *inputFormatFlag)
+		if err != nil {
+			return fmt.Errorf("failed to load prompt: %w", err)
+		}
+		printProgress("Input format: " + doc.Format)
+		manifest.AddFile(prompts[i].name, prompts[i].content, doc.Format)
+		prompts[i].doc = doc
+	}
+
+	if *formatFlag == "ast" {
+		var models []*PromptModel
+		for _, prompt := range prompts {
+			models = append(models, ParseDocument(prompt.doc))
+		}
+		var data []byte
+		if len(models) == 1 {
+			data, err = json.MarshalIndent(models[0], "", "  ")
+		} else {
+			data, err = json.MarshalIndent(models, "", "  ")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to serialize prompt model: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// Check if input is empty, empty files of a multi-file run are skipped
+	if len(prompts) == 1 && strings.TrimSpace(prompts[0].doc.Text) == "" {
 		printUsage()
-		os.Exit(1)
-		return
+		return fmt.Errorf("empty input, provide a prompt to check")
 	}
+	nonEmpty := prompts[:0]
+	for _, prompt := range prompts {
+		if strings.TrimSpace(prompt.doc.Text) == "" {
+			printProgress("Warning: skipping empty prompt " + prompt.name)
+			continue
+		}
+		nonEmpty = append(nonEmpty, prompt)
+	}
+	prompts = nonEmpty
 
 	// Setup LLM configuration
 	llmConfig, err := setupLLMConfig()
-	errHandler(err, "Error setting up LLM API")
+	if err != nil {
+		return fmt.Errorf("failed to set up LLM API: %w", err)
+	}
+	llmConfig.Alternatives = *alternativesFlag
+
+	// Consensus mode asks several models and keeps the issues a quorum of them reports
+	judges := parseJudges(*judgesFlag)
+	quorum := *quorumFlag
+	if len(judges) > 0 {
+		if len(judges) < 2 {
+			return fmt.Errorf("invalid --judges: at least two models are required")
+		}
+		if llmConfig.Heuristic {
+			return fmt.Errorf("invalid --judges: consensus mode requires an API key")
+		}
+		if quorum == 0 {
+			quorum = defaultQuorum(len(judges))
+		}
+		if quorum < 1 || quorum > len(judges) {
+			return fmt.Errorf("invalid --quorum: must be between 1 and %d", len(judges))
+		}
+		llmConfig.ModelName = strings.Join(judges, ",")
+		if *linesFlag != "" || *sectionFlag != "" {
+			return fmt.Errorf("invalid --judges: --lines and --section can't be combined with --judges")
+		}
+		if *incrementalFlag {
+			return fmt.Errorf("invalid --incremental: can't be combined with --judges")
+		}
+		if ruleEngine == "static" {
+			return fmt.Errorf("invalid --judges: the static engine makes no LLM calls")
+		}
+	}
 
-	// Check prompt using only LLM API
-	issues, err := checkPromptWithLLM(input, rules, &llmConfig)
-	errHandler(err, "Error checking prompt with LLM API")
+	// Line ranges differ between prompts, sections are looked up in every prompt
+	if *linesFlag != "" && len(prompts) > 1 {
+		return fmt.Errorf("invalid --lines: --lines requires a single prompt, use --section for several")
+	}
+	if (*linesFlag != "" || *sectionFlag != "") && *incrementalFlag {
+		return fmt.Errorf("invalid --incremental: --lines and --section can't be combined with --incremental")
+	}
+
+	dismissals, err := LoadDismissals(*dismissalsFlag)
+	if err != nil {
+		return fmt.Errorf("failed to load dismissals: %w", err)
+	}
+
+	// Without the baseline file the run records one, as does --update-baseline
+	var baseline *Baseline
+	recordBaseline := false
+	if *updateBaselineFlag && *baselineFlag == "" {
+		return fmt.Errorf("invalid --update-baseline: --update-baseline requires --baseline")
+	}
+	if *baselineFlag != "" {
+		baseline, err = LoadBaseline(*baselineFlag)
+		if err != nil {
+			return fmt.Errorf("failed to load baseline: %w", err)
+		}
+		recordBaseline = baseline == nil || *updateBaselineFlag
+		if baseline == nil {
+			baseline = &Baseline{}
+		}
+	}
+	baselined := 0
+
+	// A pinned run fixes the sampling seed of later runs
+	runLock, err := LoadRunLock(*lockFileFlag)
+	if err != nil {
+		return fmt.Errorf("failed to load lockfile: %w", err)
+	}
+	if runLock != nil {
+		llmConfig.Seed = runLock.Seed
+	} else if *pinFlag {
+		llmConfig.Seed = newSeed()
+	}
+
+	baseRules := rules
+	var linted []LintedFile
+	var tokenReports []PromptTokenReport
+	var gateErrors []string
+	// unclean are the prompts --until-clean left with issues at the iteration limit
+	var unclean []string
+	for _, prompt := range prompts {
+		sourceName := prompt.name
+		if len(prompts) > 1 {
+			printProgress("Linting " + sourceName)
+		}
 
-	// Format and output report
-	report := Report(issues, *forceColorFlag, *noColorFlag)
-	fmt.Println(report)
+		// Resolve rules with project configuration files of the prompt directory
+		rules, err = rulesForPath(baseRules, prompt.configName)
+		if err != nil {
+			return fmt.Errorf("failed to load project configuration: %w", err)
+		}
+		if err := validateStaticRules(rules); err != nil {
+			return fmt.Errorf("invalid rules: %w", err)
+		}
+		if err := loadAnalyzerSettings(prompt.configName); err != nil {
+			return fmt.Errorf("failed to load project configuration: %w", err)
+		}
+		failIf, err := loadFailIf(prompt.configName)
+		if err != nil {
+			return fmt.Errorf("failed to load project configuration: %w", err)
+		}
+
+		if err := manifest.SetRules(rules); err != nil {
+			return fmt.Errorf("failed to write run manifest: %w", err)
+		}
+		if err := manifest.AddConfig(prompt.configName, *dismissalsFlag); err != nil {
+			return fmt.Errorf("failed to write run manifest: %w", err)
+		}
+		manifest.Phase("setup")
 
+		scope, err := resolveLintScope(linter.ParsePrompt(prompt.doc.Text), *linesFlag, *sectionFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --lines/--section: %w", err)
+		}
+		if scope != nil {
+			printProgress("Linting " + scope.String())
+		}
+
+		// Fixes are applied to the raw file content, so every pass extracts the prompt again.
+		// The policy scores the issues before report filters and baselines hide any of them.
+		var disagreements []Disagreement
+		var scored []Issue
+		loaded := prompt.doc
+		lint := func(content string) ([]Issue, error) {
+			doc := loaded
+			if content != prompt.content {
+				var err error
+				if doc, err = loadDocument(sourceName, []byte(content), loaded.Format); err != nil {
+					return nil, err
+				}
+			}
+			// Fixes shift lines, the scope is resolved in every pass
+			scope, err := resolveLintScope(linter.ParsePrompt(doc.Text), *linesFlag, *sectionFlag)
+			if err != nil {
+				return nil, err
+			}
+			var issues []Issue
+			if scope != nil {
+				issues, err = checkScopeWithLLM(doc.Text, scope, rules, &llmConfig)
+			} else if len(judges) > 0 {
+				issues, disagreements, err = checkPromptWithJudges(doc.Text, rules, &llmConfig, judges, quorum)
+			} else if *incrementalFlag {
+				issues, err = checkPromptIncremental(doc.Text, rules, &llmConfig)
+			} else {
+				issues, err = checkDocumentWithLLM(doc, rules, &llmConfig)
+			}
+			if err != nil {
+				return nil, err
+			}
+			applyDismissals(issues, dismissals, time.Now())
+			scored = ruleSelection.filter(issues)
+			// Filters drop issues in place, the policy still needs them
+			return issueFilter.Apply(append([]Issue(nil), scored...)), nil
+		}
+		// checkQuality records the failures of fail_if, which sees the reported issues, and of the policy
+		checkQuality := func(issues, scored []Issue) {
+			for _, err := range []error{checkGate(failIf, issues, sourceName), policy.checkScore(scored)} {
+				if err == nil {
+					continue
+				}
+				if len(prompts) > 1 {
+					err = fmt.Errorf("%s: %w", sourceName, err)
+				}
+				gateErrors = append(gateErrors, err.Error())
+			}
+		}
+
+		if *fixFlag {
+			file := ""
+			if len(inputs) > 0 {
+				file = sourceName
+			}
+			result, err := runFix(prompt.content, prompt.encoding, file, *maxIterationsFlag, *untilCleanFlag, *interactiveFlag, lint, *forceColorFlag, *noColorFlag)
+			if err != nil {
+				return err
+			}
+			manifest.Phase("fix")
+			// The gates judge the fixed prompt: the unfixed issues and those the report filters hid from the fixes
+			remaining := append([]Issue(nil), result.Remaining...)
+			for _, issue := range scored {
+				if !issueFilter.Keep(issue) {
+					remaining = append(remaining, issue)
+				}
+			}
+			checkQuality(result.Remaining, remaining)
+			linted = append(linted, LintedFile{Name: sourceName, Text: prompt.doc.Text, Issues: result.Remaining})
+			if *untilCleanFlag && result.limitReached(*maxIterationsFlag) {
+				unclean = append(unclean, markdownFile(LintedFile{Name: sourceName}))
+			}
+			continue
+		}
+
+		// Check prompt using only LLM API
+		issues, err := lint(prompt.content)
+		if err != nil {
+			return fmt.Errorf("failed to check prompt with LLM API: %w", err)
+		}
+		manifest.Phase("lint")
+		if baseline != nil {
+			if recordBaseline {
+				baseline.Add(sourceName, issues)
+			}
+			var dropped int
+			issues, dropped = baseline.Filter(sourceName, issues)
+			baselined += dropped
+		}
+		linted = append(linted, LintedFile{Name: sourceName, Text: prompt.doc.Text, Content: prompt.content, Issues: issues, Disagreements: disagreements})
+		if *statsFlag {
+			model, err := statsModel(&llmConfig)
+			if err != nil {
+				return fmt.Errorf("failed to count tokens: %w", err)
+			}
+			prices, err := configuredPrices(pathDir(prompt.configName))
+			if err != nil {
+				return fmt.Errorf("failed to load project configuration: %w", err)
+			}
+			budget := 0
+			if tokenBudget := analyzers.Current().TokenBudget; tokenBudget != nil {
+				budget = tokenBudget.MaxTokens
+			}
+			report, err := promptTokenReport(sourceName, prompt.doc.Text, []string{model}, rules, budget, prices)
+			if err != nil {
+				return fmt.Errorf("failed to count tokens: %w", err)
+			}
+			tokenReports = append(tokenReports, report)
+		}
+
+		if len(exportTargets) > 0 {
+			if err := exportRun(context.Background(), newLintRun(sourceName, prompt.doc.Text, llmConfig.ModelName, issues), exportTargets); err != nil {
+				return fmt.Errorf("failed to export run: %w", err)
+			}
+		}
+		checkQuality(issues, scored)
+	}
+
+	if *fixFlag {
+		printHeuristicNotice(&llmConfig)
+		if err := checkRunLock(*lockFileFlag, runLock, *pinFlag, &llmConfig, rules); err != nil {
+			return fmt.Errorf("failed to check lockfile: %w", err)
+		}
+		manifest.SetProvider(llmConfig)
+		if err := manifest.Write(*manifestFlag); err != nil {
+			return fmt.Errorf("failed to write run manifest: %w", err)
+		}
+		if len(gateErrors) > 0 {
+			return fmt.Errorf("quality gate failed: %s", strings.Join(gateErrors, "; "))
+		}
+		if err := checkFailOn(failOn, linted); err != nil {
+			return fmt.Errorf("failed on --fail-on=%s: %w", failOn, err)
+		}
+		if len(unclean) > 0 {
+			return fmt.Errorf("iteration limit (%d) reached with issues remaining: %s", *maxIterationsFlag, strings.Join(unclean, ", "))
+		}
+		printProgress("Finished")
+		return nil
+	}
+
+	switch {
+	case recordBaseline:
+		if err := baseline.Save(*baselineFlag); err != nil {
+			return fmt.Errorf("failed to write baseline: %w", err)
+		}
+		printProgress(fmt.Sprintf("Recorded %d issues in the baseline %s", len(baseline.Issues), *baselineFlag))
+	case baseline != nil:
+		printProgress(fmt.Sprintf("Skipped %d issues of the baseline %s", baselined, *baselineFlag))
+		if stale := baseline.Stale(); stale > 0 {
+			printProgress(fmt.Sprintf("%d baseline issues no longer occur, run with --update-baseline to remove them", stale))
+		}
+	}
+
+	var allIssues []Issue
+	for _, file := range linted {
+		allIssues = append(allIssues, file.Issues...)
+	}
+	manifest.SetResult(allIssues)
+	manifest.SetProvider(llmConfig)
+	if err := checkRunLock(*lockFileFlag, runLock, *pinFlag, &llmConfig, rules); err != nil {
+		return fmt.Errorf("failed to check lockfile: %w", err)
+	}
+
+	// Write the reports, several files are reported one after another with a summary
+	run := ReportRun{
+		Files:      linted,
+		Rules:      rules,
+		Single:     len(linted) == 1 && shard == nil,
+		Consensus:  len(judges) > 0,
+		ForceColor: *forceColorFlag,
+		NoColor:    *noColorFlag,
+	}
+	textOnStdout := false
+	for _, output := range outputs {
+		if err := writeOutput(context.Background(), output, run); err != nil {
+			return fmt.Errorf("failed to write the %s report: %w", output.Reporter.Name(), err)
+		}
+		textOnStdout = textOnStdout || (output.Reporter.Name() == "text" && output.Destination == "stdout")
+	}
+	if len(tokenReports) > 0 {
+		stats := FormatTokenReports(tokenReports)
+		if textOnStdout {
+			fmt.Print(stats)
+		} else {
+			fmt.Fprint(os.Stderr, stats)
+		}
+	}
+	printHeuristicNotice(&llmConfig)
+
+	if *collectFeedbackFlag {
+		for _, file := range linted {
+			if err := runCollectFeedback(*feedbackFileFlag, file.Name, file.Text, llmConfig.ModelName, file.Issues); err != nil {
+				return fmt.Errorf("failed to collect feedback: %w", err)
+			}
+		}
+	}
+
+	if err := manifest.Write(*manifestFlag); err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	if len(gateErrors) > 0 {
+		return fmt.Errorf("quality gate failed: %s", strings.Join(gateErrors, "; "))
+	}
+	if err := checkFailOn(failOn, linted); err != nil {
+		return fmt.Errorf("failed on --fail-on=%s: %w", failOn, err)
+	}
 	printProgress("Finished")
+	return nil
 }
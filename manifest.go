@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// RunManifest records everything that influenced a lint run, so results can be reproduced and cached results invalidated
+type RunManifest struct {
+	Tool     ManifestTool      `json:"tool"`
+	Rules    ManifestRules     `json:"rules"`
+	Provider ManifestProvider  `json:"provider"`
+	Flags    map[string]string `json:"flags"`
+	// Config lists project configuration and dismissals files that were applied
+	Config  []ManifestFile  `json:"config"`
+	Files   []ManifestFile  `json:"files"`
+	Timings ManifestTimings `json:"timings"`
+	Result  *ManifestResult `json:"result,omitempty"`
+
+	started, lastMark time.Time
+}
+
+// ManifestTool identifies the binary
+type ManifestTool struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// ManifestRules identifies the rule set, Hash covers all active rules and their order
+type ManifestRules struct {
+	Version   string            `json:"version,omitempty"`
+	Hash      string            `json:"hash"`
+	Rules     map[string]string `json:"rules"`
+	Analyzers []string          `json:"analyzers"`
+}
+
+// ManifestProvider identifies the judge, the endpoint never includes credentials
+type ManifestProvider struct {
+	Name              string `json:"name,omitempty"`
+	Endpoint          string `json:"endpoint,omitempty"`
+	Model             string `json:"model"`
+	Snapshot          string `json:"snapshot,omitempty"`
+	SystemFingerprint string `json:"systemFingerprint,omitempty"`
+	Seed              int64  `json:"seed,omitempty"`
+	Heuristic         bool   `json:"heuristic,omitempty"`
+	// JudgePrompt is the version of the judge system message
+	JudgePrompt string `json:"judgePrompt,omitempty"`
+}
+
+// ManifestFile is an input of the run identified by its content hash
+type ManifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+	Format string `json:"format,omitempty"`
+}
+
+// ManifestTimings holds the wall time of the run and its phases in milliseconds
+type ManifestTimings struct {
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	TotalMs    int64            `json:"totalMs"`
+	PhasesMs   map[string]int64 `json:"phasesMs"`
+}
+
+// ManifestResult summarizes the outcome of the run
+type ManifestResult struct {
+	Issues    int `json:"issues"`
+	Dismissed int `json:"dismissed"`
+	Score     int `json:"score"`
+}
+
+// newRunManifest starts recording a run
+func newRunManifest() *RunManifest {
+	now := time.Now()
+	return &RunManifest{
+		Tool: ManifestTool{
+			Name:      appName,
+			Version:   appVersion,
+			GoVersion: runtime.Version(),
+			Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		},
+		Flags:    map[string]string{},
+		Timings:  ManifestTimings{StartedAt: now.UTC(), PhasesMs: map[string]int64{}},
+		started:  now,
+		lastMark: now,
+	}
+}
+
+// sha256Hex returns the hex-encoded SHA-256 of the data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Phase records the time since the previous phase under the name, a nil manifest records nothing
+func (m *RunManifest) Phase(name string) {
+	if m == nil {
+		return
+	}
+	now := time.Now()
+	m.Timings.PhasesMs[name] += now.Sub(m.lastMark).Milliseconds()
+	m.lastMark = now
+}
+
+// SetFlags records the flags set on the command line
+func (m *RunManifest) SetFlags(fs *flag.FlagSet) {
+	if m == nil {
+		return
+	}
+	fs.Visit(func(f *flag.Flag) {
+		m.Flags[f.Name] = f.Value.String()
+	})
+}
+
+// SetRules records the version and hashes of the active rules and the analyzers
+func (m *RunManifest) SetRules(rules *Rules) error {
+	if m == nil {
+		return nil
+	}
+	hash, err := rulesHash(rules)
+	if err != nil {
+		return err
+	}
+	m.Rules = ManifestRules{Version: rules.Version, Hash: hash, Rules: map[string]string{}, Analyzers: analyzerNames()}
+	for _, rule := range rules.Active() {
+		data, err := json.Marshal(rule)
+		if err != nil {
+			return fmt.Errorf("failed to hash rule %s: %w", rule.Name, err)
+		}
+		m.Rules.Rules[rule.Name] = sha256Hex(data)
+	}
+	return nil
+}
+
+// SetProvider records the judge without the API key and URL credentials, after linting it includes the served snapshot
+func (m *RunManifest) SetProvider(config LLMConfig) {
+	if m == nil {
+		return
+	}
+	m.Provider = ManifestProvider{
+		Model:             config.ModelName,
+		Snapshot:          config.ServedModel,
+		SystemFingerprint: config.SystemFingerprint,
+		Seed:              config.Seed,
+		Heuristic:         config.Heuristic,
+	}
+	if config.Provider != nil && !config.Heuristic {
+		m.Provider.Name = config.Provider.Name()
+		m.Provider.JudgePrompt = judgePrompt
+		if m.Provider.JudgePrompt == "" {
+			m.Provider.JudgePrompt = linter.DefaultJudgePrompt
+		}
+	}
+	if u, err := url.Parse(config.APIEndpoint); err == nil && config.APIEndpoint != "" {
+		u.User, u.RawQuery, u.Fragment = nil, "", ""
+		m.Provider.Endpoint = u.String()
+	}
+}
+
+// AddConfig records the configuration files that apply to the path and the dismissals file, missing files are skipped
+func (m *RunManifest) AddConfig(path, dismissalsFile string) error {
+	if m == nil {
+		return nil
+	}
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+	paths, _, err := findConfigFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, p := range append(paths, dismissalsFile) {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		m.Config = append(m.Config, ManifestFile{Path: p, SHA256: sha256Hex(data), Size: len(data)})
+	}
+	return nil
+}
+
+// AddFile records a linted input
+func (m *RunManifest) AddFile(path, content, format string) {
+	if m == nil {
+		return
+	}
+	if path == "" {
+		path = "stdin"
+	}
+	m.Files = append(m.Files, ManifestFile{Path: path, SHA256: sha256Hex([]byte(content)), Size: len(content), Format: format})
+}
+
+// SetResult records the outcome of the run
+func (m *RunManifest) SetResult(issues []Issue) {
+	if m == nil {
+		return
+	}
+	active := countActive(issues)
+	m.Result = &ManifestResult{Issues: active, Dismissed: len(issues) - active, Score: qualityScore(issues)}
+}
+
+// Write finishes the run and saves the manifest as JSON
+func (m *RunManifest) Write(path string) error {
+	if m == nil {
+		return nil
+	}
+	m.Phase("report")
+	now := time.Now()
+	m.Timings.FinishedAt = now.UTC()
+	m.Timings.TotalMs = now.Sub(m.started).Milliseconds()
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	printProgress("Wrote run manifest to " + path)
+	return nil
+}
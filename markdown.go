@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+)
+
+// markdownReportMarker starts Markdown reports, bots find their previous comment by it to update it
+const markdownReportMarker = "<!-- " + appName + "-report -->"
+
+// markdownFile returns the display name of a linted file
+func markdownFile(file LintedFile) string {
+	if file.Name == "" {
+		return "stdin"
+	}
+	return filepath.ToSlash(filepath.Clean(file.Name))
+}
+
+// markdownCode formats the text as inline code, the delimiter is longer than any backtick run in it
+func markdownCode(text string) string {
+	delimiter := "`"
+	for strings.Contains(text, delimiter) {
+		delimiter += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return delimiter + text + delimiter
+}
+
+// markdownFence formats the snippet as a fenced code block, the fence is longer than any backtick run in it
+func markdownFence(snippet string) string {
+	fence := "```"
+	for strings.Contains(snippet, fence) {
+		fence += "`"
+	}
+	return fence + "text\n" + strings.TrimRight(snippet, "\n") + "\n" + fence + "\n"
+}
+
+// markdownCell escapes a table cell
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(text)
+}
+
+// ReportMarkdown formats the results as GitHub-flavored Markdown for pull request comments: a summary table
+// and a collapsible section per active issue with its original and fixed snippets
+func ReportMarkdown(files []LintedFile) string {
+	var sb strings.Builder
+	sb.WriteString(markdownReportMarker + "\n")
+	sb.WriteString(fmt.Sprintf("## %s report\n\n", appName))
+
+	total, dismissed := 0, 0
+	for _, file := range files {
+		active := countActive(file.Issues)
+		total += active
+		dismissed += len(file.Issues) - active
+	}
+	summary := fmt.Sprintf("**%d issues** in %d files", total, len(files))
+	if total == 0 {
+		summary = fmt.Sprintf("**No issues** in %d files", len(files))
+	}
+	if dismissed > 0 {
+		summary += fmt.Sprintf(" (%d dismissed)", dismissed)
+	}
+	sb.WriteString(summary + "\n\n")
+
+	sb.WriteString("| File | Errors | Warnings | Info | Score |\n|------|-------:|---------:|-----:|------:|\n")
+	for _, file := range files {
+		counts := map[string]int{}
+		for _, issue := range file.Issues {
+			if !issue.Dismissed {
+				counts[severityLevels[severityRank(issue.Severity)]]++
+			}
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d |\n", markdownCell(markdownCode(markdownFile(file))),
+			counts["error"], counts["warning"], counts["info"], qualityScore(file.Issues)))
+	}
+
+	for _, file := range files {
+		if countActive(file.Issues) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n### %s\n", markdownCode(markdownFile(file))))
+		for _, issue := range file.Issues {
+			if issue.Dismissed {
+				continue
+			}
+			sb.WriteString("\n" + markdownIssue(file, issue))
+		}
+	}
+	return sb.String()
+}
+
+// markdownIssue formats an issue as a <details> block, the summary line shows severity, rule, line and description
+func markdownIssue(file LintedFile, issue Issue) string {
+	severity := issue.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+	title := fmt.Sprintf("<b>%s</b> · %s", html.EscapeString(severity), html.EscapeString(issue.RuleName))
+	if line, _ := githubPosition(file, issue); line > 0 {
+		title += fmt.Sprintf(" · line %d", line)
+	}
+	if issue.Role != "" {
+		title += fmt.Sprintf(" · message %d (%s)", issue.Message, html.EscapeString(issue.Role))
+	}
+	title += ": " + html.EscapeString(issue.Description)
+
+	var sb strings.Builder
+	sb.WriteString("<details>\n<summary>" + title + "</summary>\n\n")
+	if issue.Category != "" {
+		sb.WriteString("**Category:** " + issue.Category + "  \n")
+	}
+	if issue.Reason != "" {
+		sb.WriteString("**Reason:** " + issue.Reason + "  \n")
+	}
+	if issue.Fix != "" {
+		sb.WriteString("**Fix:** " + issue.Fix + "  \n")
+	}
+	if strings.TrimSpace(issue.OriginalSnippet) != "" {
+		sb.WriteString("\nOriginal:\n\n" + markdownFence(issue.OriginalSnippet))
+	}
+	if strings.TrimSpace(issue.FixedSnippet) != "" {
+		sb.WriteString("\nSuggested:\n\n" + markdownFence(issue.FixedSnippet))
+	}
+	for i, alternative := range issue.Alternatives {
+		sb.WriteString(fmt.Sprintf("\nAlternative %d (%s / %s):\n\n", i+1, alternative.Pros, alternative.Cons) + markdownFence(alternative.Snippet))
+	}
+	if issue.RuleLink != "" {
+		sb.WriteString(fmt.Sprintf("\n[Rule guidance](%s)\n", issue.RuleLink))
+	}
+	sb.WriteString("\n</details>\n")
+	return sb.String()
+}
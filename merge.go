@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// readResultFile reads a --format=json result: the report of a single file or of several files
+func readResultFile(path string) ([]LintedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON result: %w", path, err)
+	}
+
+	var reports []JSONReport
+	if _, ok := fields["files"]; ok {
+		var multi MultiJSONReport
+		if err := json.Unmarshal(data, &multi); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		reports = multi.Files
+	} else if _, ok := fields["issues"]; ok {
+		var single JSONReport
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		reports = []JSONReport{single}
+	} else {
+		return nil, fmt.Errorf("%s is not a %s JSON result, it has neither files nor issues", path, appName)
+	}
+
+	files := make([]LintedFile, 0, len(reports))
+	for _, report := range reports {
+		files = append(files, LintedFile{Name: report.File.File, Issues: report.Issues, Disagreements: report.Disagreements})
+	}
+	return files, nil
+}
+
+// mergeKey identifies a file across results, paths are compared cleaned with forward slashes
+func mergeKey(name string) string {
+	if name == "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(name))
+}
+
+// issueKey identifies an issue of a file across results by its fingerprint, so the same finding of repeated runs
+// matches even when the judge words it differently
+func issueKey(issue Issue) string {
+	if issue.Fingerprint != "" {
+		return issue.Fingerprint
+	}
+	return linter.Fingerprint(issue)
+}
+
+// mergeResults combines the files of several results sorted by path. Files with the same path are merged, issues
+// with the same fingerprint are reported once and a dismissal recorded in any result is kept. It returns the
+// number of dropped duplicate issues.
+func mergeResults(files []LintedFile) ([]LintedFile, int) {
+	var merged []LintedFile
+	byFile := map[string]int{}
+	seenIssues := map[string]map[string]int{}
+	seenDisagreements := map[string]bool{}
+	duplicates := 0
+	for _, file := range files {
+		key := mergeKey(file.Name)
+		index, ok := byFile[key]
+		if !ok {
+			index = len(merged)
+			byFile[key] = index
+			seenIssues[key] = map[string]int{}
+			merged = append(merged, LintedFile{Name: file.Name, Issues: []Issue{}})
+		}
+		target := &merged[index]
+		for _, issue := range file.Issues {
+			id := issueKey(issue)
+			if existing, ok := seenIssues[key][id]; ok {
+				duplicates++
+				if issue.Dismissed && !target.Issues[existing].Dismissed {
+					target.Issues[existing].Dismissed = true
+					target.Issues[existing].DismissReason = issue.DismissReason
+				}
+				continue
+			}
+			seenIssues[key][id] = len(target.Issues)
+			target.Issues = append(target.Issues, issue)
+		}
+		for _, disagreement := range file.Disagreements {
+			id := key + "\x00" + disagreement.Judge + "\x00" + issueKey(disagreement.Issue)
+			if !seenDisagreements[id] {
+				seenDisagreements[id] = true
+				target.Disagreements = append(target.Disagreements, disagreement)
+			}
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return mergeKey(merged[i].Name) < mergeKey(merged[j].Name) })
+	return merged, duplicates
+}
+
+// runMergeCommand implements `promptlint merge <result.json>...`
+func runMergeCommand(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	format := fs.String("format", "json", "Output format: text, json, sarif (SARIF 2.1.0 for code scanning), github (GitHub Actions annotations), markdown (pull request comment), junit (JUnit XML for CI test views), vscode")
+	failOnFlag := fs.String("fail-on", "", "Exit with status 1 when active issues of the merged report reach the severity: info, warning, error")
+	forceColor := fs.Bool("force-color", false, "Force colored output even when stdout is not a terminal")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable, describes custom rules in SARIF")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s merge [--format=text|json|sarif|github|markdown|junit|vscode] [--fail-on=error] <result.json>...
+
+Combines results written with --format=json, for example by the jobs of a
+--shard=i/n CI matrix, of several repositories or of repeated runs, into one
+report. Files with the same path are merged and issues with the same
+fingerprint are reported once.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one result file is required")
+	}
+	reporter, err := lookupReporter(*format)
+	if err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+	failOn, err := parseFailOn(*failOnFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --fail-on: %w", err)
+	}
+
+	var results []LintedFile
+	for _, path := range paths {
+		files, err := readResultFile(path)
+		if err != nil {
+			return err
+		}
+		printProgress(fmt.Sprintf("Read %d files from %s", len(files), path))
+		results = append(results, files...)
+	}
+	merged, duplicates := mergeResults(results)
+	if duplicates > 0 {
+		printProgress(fmt.Sprintf("Dropped %d duplicate issues", duplicates))
+	}
+
+	run := ReportRun{Files: merged, ForceColor: *forceColor, NoColor: *noColor}
+	if reporter.Name() == "sarif" || reporter.Name() == "junit" {
+		// SARIF describes and JUnit lists the checked rules, custom rules come from the packs
+		if run.Rules, err = LoadRules(); err != nil {
+			return err
+		}
+		if run.Rules, err = applyCustomRules(run.Rules, rulesFiles); err != nil {
+			return err
+		}
+	}
+	if err := writeOutput(context.Background(), Output{Reporter: reporter, Destination: "stdout"}, run); err != nil {
+		return err
+	}
+	return checkFailOn(failOn, merged)
+}
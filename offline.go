@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+)
+
+// offline forbids network access: the heuristic judge replaces the LLM and every connection attempt fails
+var offline bool
+
+// errOffline is the cause of every network access attempted in offline mode
+const errOffline = "network access is disabled by --offline"
+
+// offlineTransport fails every HTTP request instead of connecting
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), errOffline)
+}
+
+// configuredOffline reports whether the policy, PROMPTLINT_OFFLINE or offline in the configuration of the working directory enable offline mode
+func configuredOffline() bool {
+	if policy.Offline {
+		return true
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("PROMPTLINT_OFFLINE")); err == nil {
+		return enabled
+	}
+	settings, err := loadRunSettings(".")
+	if err != nil {
+		return false
+	}
+	return settings.Offline
+}
+
+// offlineFlag is the --offline flag of commands that may access the network, setting it enables offline mode
+type offlineFlag struct{}
+
+func (offlineFlag) String() string {
+	return "false"
+}
+
+func (offlineFlag) Set(value string) error {
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		enableOffline()
+	}
+	return nil
+}
+
+func (offlineFlag) IsBoolFlag() bool {
+	return true
+}
+
+// registerOfflineFlag adds --offline to the flags of a command that may access the network
+func registerOfflineFlag(fs *flag.FlagSet) {
+	fs.Var(offlineFlag{}, "offline", "Forbid network access: local heuristic judge, any attempted connection is an error (env PROMPTLINT_OFFLINE)")
+}
+
+// enableOffline replaces the transports of all HTTP clients, so a network access anywhere fails loudly instead of leaking data
+func enableOffline() {
+	offline = true
+	http.DefaultTransport = offlineTransport{}
+	llm.SetTransport(offlineTransport{})
+}
+
+// checkWebhook rejects a feature that posts prompt content to a webhook, offline mode forbids the network
+// and privacy mode sending prompts to third parties
+func checkWebhook(feature string) error {
+	if offline {
+		return fmt.Errorf("%s posts over the network: %s", feature, errOffline)
+	}
+	return policy.checkPrivacy(feature)
+}
+
+// dialContext connects the clients of queue brokers and databases of workers, it fails in offline mode
+func dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if offline {
+		return nil, fmt.Errorf("dial %s: %s", address, errOffline)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, address)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportRun is the result of a lint run formatted by reporters
+type ReportRun struct {
+	Files []LintedFile
+	Rules *Rules
+	// Single reports a run over one prompt, JSON reports it without the files wrapper
+	Single bool
+	// Consensus adds the disagreements of the judges to text reports
+	Consensus bool
+	// ForceColor and NoColor apply to text reports, outputs other than stdout are never colored
+	ForceColor, NoColor bool
+}
+
+// Reporter formats the results of a run in an output format
+type Reporter interface {
+	// Name returns the format name used by --format and --output
+	Name() string
+	// ContentType returns the media type of reports posted to webhooks
+	ContentType() string
+	// Report formats the run, the report ends with a newline
+	Report(run ReportRun) (string, error)
+}
+
+// reporters contains registered reporters by format name
+var reporters = map[string]Reporter{}
+
+// RegisterReporter makes an output format available for --format and --output
+func RegisterReporter(reporter Reporter) {
+	reporters[reporter.Name()] = reporter
+}
+
+// reporterAliases are alternative names of output formats
+var reporterAliases = map[string]string{"human": "text"}
+
+func init() {
+	RegisterReporter(textReporter{})
+	RegisterReporter(jsonReporter{})
+	RegisterReporter(sarifReporter{})
+	RegisterReporter(vscodeReporter{})
+	RegisterReporter(githubReporter{})
+	RegisterReporter(markdownReporter{})
+	RegisterReporter(junitReporter{})
+}
+
+// reporterNames returns sorted names of all registered reporters
+func reporterNames() []string {
+	names := make([]string, 0, len(reporters))
+	for name := range reporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupReporter returns the reporter of the format name or alias
+func lookupReporter(name string) (Reporter, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := reporterAliases[name]; ok {
+		name = alias
+	}
+	reporter, ok := reporters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, supported: %s", name, strings.Join(reporterNames(), ", "))
+	}
+	return reporter, nil
+}
+
+// textReporter formats the human-readable report, several files end with a summary
+type textReporter struct{}
+
+func (textReporter) Name() string        { return "text" }
+func (textReporter) ContentType() string { return "text/plain; charset=utf-8" }
+func (textReporter) Report(run ReportRun) (string, error) {
+	var sb strings.Builder
+	for _, file := range run.Files {
+		report := Report(file.Issues, run.ForceColor, run.NoColor)
+		if file.Name != "" {
+			report = file.Name + ":\n" + report
+		}
+		sb.WriteString(report + "\n")
+		if run.Consensus {
+			sb.WriteString(ReportDisagreements(file.Disagreements, run.ForceColor, run.NoColor) + "\n")
+		}
+	}
+	if !run.Single {
+		sb.WriteString(ReportFilesSummary(run.Files, run.ForceColor, run.NoColor) + "\n")
+	}
+	return sb.String(), nil
+}
+
+// jsonReporter formats the issues of a single prompt as JSONReport, of several as MultiJSONReport
+type jsonReporter struct{}
+
+func (jsonReporter) Name() string        { return "json" }
+func (jsonReporter) ContentType() string { return "application/json" }
+func (jsonReporter) Report(run ReportRun) (string, error) {
+	if run.Single && len(run.Files) == 1 {
+		report, err := ReportJSON(run.Files[0].Name, run.Files[0].Issues, run.Files[0].Disagreements)
+		return report + "\n", err
+	}
+	report, err := ReportFilesJSON(run.Files)
+	return report + "\n", err
+}
+
+// sarifReporter formats a SARIF 2.1.0 log for code scanning
+type sarifReporter struct{}
+
+func (sarifReporter) Name() string        { return "sarif" }
+func (sarifReporter) ContentType() string { return "application/sarif+json" }
+func (sarifReporter) Report(run ReportRun) (string, error) {
+	report, err := ReportSARIF(run.Files, run.Rules)
+	return report + "\n", err
+}
+
+// vscodeReporter formats the diagnostics JSON of editor extensions
+type vscodeReporter struct{}
+
+func (vscodeReporter) Name() string        { return "vscode" }
+func (vscodeReporter) ContentType() string { return "application/json" }
+func (vscodeReporter) Report(run ReportRun) (string, error) {
+	report, err := ReportVSCode(run.Files)
+	return report + "\n", err
+}
+
+// githubReporter formats GitHub Actions workflow commands
+type githubReporter struct{}
+
+func (githubReporter) Name() string        { return "github" }
+func (githubReporter) ContentType() string { return "text/plain; charset=utf-8" }
+func (githubReporter) Report(run ReportRun) (string, error) {
+	return ReportGitHub(run.Files), nil
+}
+
+// markdownReporter formats a pull request comment
+type markdownReporter struct{}
+
+func (markdownReporter) Name() string        { return "markdown" }
+func (markdownReporter) ContentType() string { return "text/markdown; charset=utf-8" }
+func (markdownReporter) Report(run ReportRun) (string, error) {
+	return ReportMarkdown(run.Files), nil
+}
+
+// Output is a report format written to a destination: stdout, stderr, an http(s) webhook or a file path
+type Output struct {
+	Reporter    Reporter
+	Destination string
+}
+
+// String describes the output in progress messages
+func (o Output) String() string {
+	return o.Reporter.Name() + " to " + o.Destination
+}
+
+// webhook reports whether the output posts the report to a URL
+func (o Output) webhook() bool {
+	return strings.HasPrefix(o.Destination, "http://") || strings.HasPrefix(o.Destination, "https://")
+}
+
+// parseOutputs parses --output values "format[,destination]", the destination defaults to stdout.
+// Two outputs can't write to the same destination.
+func parseOutputs(values []string) ([]Output, error) {
+	var outputs []Output
+	seen := map[string]bool{}
+	for _, value := range values {
+		name, destination, _ := strings.Cut(value, ",")
+		reporter, err := lookupReporter(name)
+		if err != nil {
+			return nil, err
+		}
+		destination = strings.TrimSpace(destination)
+		switch destination {
+		case "", "-":
+			destination = "stdout"
+		}
+		key := destination
+		if key != "stdout" && key != "stderr" && !strings.Contains(key, "://") {
+			key = filepath.Clean(key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("several outputs write to %s", destination)
+		}
+		seen[key] = true
+		outputs = append(outputs, Output{Reporter: reporter, Destination: destination})
+	}
+	return outputs, nil
+}
+
+// writeOutput formats the run and writes the report to the destination of the output
+func writeOutput(ctx context.Context, output Output, run ReportRun) error {
+	if output.Destination != "stdout" {
+		run.ForceColor, run.NoColor = false, true
+	}
+	report, err := output.Reporter.Report(run)
+	if err != nil {
+		return err
+	}
+	switch {
+	case output.Destination == "stdout":
+		_, err = fmt.Fprint(os.Stdout, report)
+		return err
+	case output.Destination == "stderr":
+		_, err = fmt.Fprint(os.Stderr, report)
+		return err
+	case output.webhook():
+		return postReport(ctx, output.Destination, output.Reporter.ContentType(), report)
+	}
+	if dir := filepath.Dir(output.Destination); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(output.Destination, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	printProgress(fmt.Sprintf("Wrote %s report to %s", output.Reporter.Name(), output.Destination))
+	return nil
+}
+
+// postReport posts the report to a webhook
+func postReport(ctx context.Context, url, contentType, report string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader([]byte(report)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("report webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("report webhook returned status %d", resp.StatusCode)
+	}
+	printProgress("Posted report to " + url)
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultLockFile stores the judge and rules of a pinned run
+const defaultLockFile = ".promptlint.lock"
+
+// RunLock pins what determines lint results, so later score changes can be attributed to a judge or rules change
+type RunLock struct {
+	ToolVersion string `yaml:"tool_version"`
+	// Model is the requested model, Snapshot the exact version the API served
+	Model    string `yaml:"model"`
+	Snapshot string `yaml:"snapshot,omitempty"`
+	// SystemFingerprint identifies the backend configuration of the provider when it reports one
+	SystemFingerprint string `yaml:"system_fingerprint,omitempty"`
+	Seed              int64  `yaml:"seed"`
+	RulesVersion      string `yaml:"rules_version,omitempty"`
+	RulesHash         string `yaml:"rules_hash"`
+	PinnedAt          string `yaml:"pinned_at"`
+}
+
+// rulesHash returns the SHA-256 of the active rules in their order
+func rulesHash(rules *Rules) (string, error) {
+	data, err := json.Marshal(rules.Active())
+	if err != nil {
+		return "", fmt.Errorf("failed to hash rules: %w", err)
+	}
+	return sha256Hex(data), nil
+}
+
+// newSeed returns a random seed for a new pin
+func newSeed() int64 {
+	return rand.New(rand.NewSource(time.Now().UnixNano())).Int63n(1 << 31)
+}
+
+// LoadRunLock reads the lockfile, a missing file means the run is not pinned
+func LoadRunLock(path string) (*RunLock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	var lock RunLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("error parsing lockfile %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+// currentRunLock describes the run that just finished
+func currentRunLock(config *LLMConfig, rules *Rules) (RunLock, error) {
+	hash, err := rulesHash(rules)
+	if err != nil {
+		return RunLock{}, err
+	}
+	return RunLock{
+		ToolVersion:       appVersion,
+		Model:             config.ModelName,
+		Snapshot:          config.ServedModel,
+		SystemFingerprint: config.SystemFingerprint,
+		Seed:              config.Seed,
+		RulesVersion:      rules.Version,
+		RulesHash:         hash,
+		PinnedAt:          time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Differences lists what the current run does differently from the pinned one.
+// Values the provider didn't report in either run are not compared.
+func (l *RunLock) Differences(current RunLock) []string {
+	var diffs []string
+	compare := func(what, pinned, now string) {
+		if pinned != "" && now != "" && pinned != now {
+			diffs = append(diffs, fmt.Sprintf("%s changed from %s to %s", what, pinned, now))
+		}
+	}
+	compare("tool version", l.ToolVersion, current.ToolVersion)
+	compare("model", l.Model, current.Model)
+	compare("model snapshot", l.Snapshot, current.Snapshot)
+	compare("provider system fingerprint", l.SystemFingerprint, current.SystemFingerprint)
+	compare("rules version", l.RulesVersion, current.RulesVersion)
+	if l.RulesHash != current.RulesHash {
+		diffs = append(diffs, "active rules changed")
+	}
+	return diffs
+}
+
+// writeRunLock saves the lockfile
+func writeRunLock(path string, lock RunLock) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Pinned judge and rules of promptlint runs, update with --pin\n")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(lock); err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// checkRunLock warns about differences from the pinned run and, with pin, records the current run
+func checkRunLock(path string, lock *RunLock, pin bool, config *LLMConfig, rules *Rules) error {
+	current, err := currentRunLock(config, rules)
+	if err != nil {
+		return err
+	}
+	if lock != nil {
+		for _, diff := range lock.Differences(current) {
+			printProgress(fmt.Sprintf("Warning: %s since the run pinned in %s, score changes may come from it", diff, path))
+		}
+	}
+	if !pin {
+		return nil
+	}
+	if err := writeRunLock(path, current); err != nil {
+		return err
+	}
+	printProgress(fmt.Sprintf("Pinned %s (seed %d) to %s", current.Model, current.Seed, path))
+	return nil
+}
@@ -0,0 +1,135 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// minBlobLength is the number of encoded characters that makes a blob worth reporting
+const minBlobLength = 256
+
+// Encoded data tokenizes far worse than text, these are rough averages for common tokenizers
+const (
+	base64CharsPerToken = 3
+	hexCharsPerToken    = 2
+)
+
+var (
+	// dataURIPattern matches data URIs with base64 content
+	dataURIPattern = regexp.MustCompile(`data:([\w.+-]+/[\w.+-]+)?(?:;[\w-]+=[\w-]+)*;base64,([A-Za-z0-9+/]+={0,2})`)
+	// base64Pattern matches base64 runs, including ones wrapped into lines like PEM or MIME bodies
+	base64Pattern = regexp.MustCompile(`[A-Za-z0-9+/]{40,}(?:\r?\n[A-Za-z0-9+/]{40,})*={0,2}`)
+	// hexPattern matches long hexadecimal runs, optionally split by spaces or colons into bytes
+	hexPattern = regexp.MustCompile(`(?i)(?:0x)?[0-9a-f]{2}(?:[ :]?[0-9a-f]{2}){63,}`)
+	// base64Digit and base64Case tell encoded data from long identifiers of letters only
+	base64Digit = regexp.MustCompile(`[0-9]`)
+	base64Case  = regexp.MustCompile(`[a-z].*[A-Z]|[A-Z].*[a-z]`)
+)
+
+// encodedBlob is a run of encoded binary data in the prompt
+type encodedBlob struct {
+	Start, End int
+	// Encoding is base64 or hex
+	Encoding string
+	// MediaType is set for data URIs
+	MediaType string
+	// Chars is the number of encoded characters without separators
+	Chars int
+}
+
+// Tokens estimates the token cost of the blob
+func (b encodedBlob) Tokens() int {
+	if b.Encoding == "hex" {
+		return (b.Chars + hexCharsPerToken - 1) / hexCharsPerToken
+	}
+	return (b.Chars + base64CharsPerToken - 1) / base64CharsPerToken
+}
+
+// Bytes returns the size of the decoded data
+func (b encodedBlob) Bytes() int {
+	if b.Encoding == "hex" {
+		return b.Chars / 2
+	}
+	return b.Chars * 3 / 4
+}
+
+// findBlobs returns encoded blobs of at least minBlobLength characters in the text
+func findBlobs(text string) []encodedBlob {
+	var blobs []encodedBlob
+	var taken [][]int
+	add := func(loc []int, blob encodedBlob) {
+		if blob.Chars < minBlobLength || overlapsAny(loc, taken) {
+			return
+		}
+		taken = append(taken, loc)
+		blob.Start, blob.End = loc[0], loc[1]
+		blobs = append(blobs, blob)
+	}
+
+	for _, loc := range dataURIPattern.FindAllStringSubmatchIndex(text, -1) {
+		blob := encodedBlob{Encoding: "base64", Chars: loc[5] - loc[4]}
+		if loc[2] >= 0 {
+			blob.MediaType = text[loc[2]:loc[3]]
+		}
+		add(loc[:2], blob)
+	}
+	for _, loc := range hexPattern.FindAllStringIndex(text, -1) {
+		digits := strings.NewReplacer(" ", "", ":", "", "0x", "", "0X", "").Replace(text[loc[0]:loc[1]])
+		add(loc, encodedBlob{Encoding: "hex", Chars: len(digits)})
+	}
+	for _, loc := range base64Pattern.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		if !base64Digit.MatchString(match) || !base64Case.MatchString(match) {
+			continue
+		}
+		chars := len(strings.NewReplacer("\r", "", "\n", "").Replace(match))
+		add(loc, encodedBlob{Encoding: "base64", Chars: chars})
+	}
+	return blobs
+}
+
+// blobAnalyzer reports large encoded blobs with their token cost
+type blobAnalyzer struct{}
+
+func (blobAnalyzer) Name() string { return "Encoded Blobs" }
+func (blobAnalyzer) Analyze(model *PromptModel) []Issue {
+	blobs := findBlobs(model.Text)
+	if len(blobs) == 0 {
+		return nil
+	}
+
+	// The prompt cost counts blobs at their real rate, not at the rate of text
+	total := EstimateTokens(model.Text)
+	for _, blob := range blobs {
+		total += blob.Tokens() - EstimateTokens(model.Text[blob.Start:blob.End])
+	}
+
+	var issues []Issue
+	for _, blob := range blobs {
+		kind := blob.Encoding
+		if blob.MediaType != "" {
+			kind += " " + blob.MediaType
+		}
+		position := model.Position(blob.Start)
+		snippet := model.Text[blob.Start:blob.End]
+		if len(snippet) > 60 {
+			snippet = snippet[:60] + "…"
+		}
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("Embedded %s blob of %d characters (%d bytes decoded) costs about %d tokens, %d%% of the prompt", kind, blob.Chars, blob.Bytes(), blob.Tokens(), blob.Tokens()*100/total),
+			Reason:          "Encoded data tokenizes poorly, silently eats the context budget and the model can't read it reliably anyway.",
+			Fix:             "Pass the data as a tool input or a file/image attachment, or reference it by name and load it only when needed.",
+			OriginalSnippet: snippet,
+			Line:            position.Line,
+			Column:          position.Column,
+		})
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(blobAnalyzer{})
+}
@@ -0,0 +1,103 @@
+package analyzers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// TokenBudget limits the estimated tokens of prompts whose path matches the pattern
+type TokenBudget struct {
+	// Path is a gitignore-like pattern relative to the configuration file: "prompts/system/**", "*.tool.md"
+	Path      string `yaml:"path"`
+	MaxTokens int    `yaml:"max_tokens"`
+	// Severity of overage issues: error (default) or warning
+	Severity string `yaml:"severity,omitempty"`
+}
+
+// maxBudgetSections is the number of largest sections named in overage reports
+const maxBudgetSections = 3
+
+// Validate checks the path, the token limit and the severity of the budget
+func (b TokenBudget) Validate() error {
+	if strings.TrimSpace(b.Path) == "" {
+		return fmt.Errorf("budget without path")
+	}
+	if b.MaxTokens <= 0 {
+		return fmt.Errorf("budget %q needs a positive max_tokens", b.Path)
+	}
+	if b.Severity != "" && b.Severity != "error" && b.Severity != "warning" {
+		return fmt.Errorf("budget %q has severity %q, expected error or warning", b.Path, b.Severity)
+	}
+	return nil
+}
+
+// sectionTokens is the estimated size of a top-level section
+type sectionTokens struct {
+	Title  string
+	Line   int
+	Tokens int
+}
+
+// largestSections returns the top-level sections of the prompt by estimated tokens, largest first
+func largestSections(model *PromptModel, limit int) []sectionTokens {
+	var sections []sectionTokens
+	end := -1
+	for _, section := range model.Sections {
+		if section.Start.Offset < end {
+			continue
+		}
+		end = section.End.Offset
+		sections = append(sections, sectionTokens{
+			Title:  section.Title,
+			Line:   section.Start.Line,
+			Tokens: EstimateTokens(model.Text[section.Start.Offset:section.End.Offset]),
+		})
+	}
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Tokens > sections[j].Tokens })
+	if len(sections) > limit {
+		sections = sections[:limit]
+	}
+	return sections
+}
+
+// tokenBudgetAnalyzer reports prompts exceeding the token budget configured for their path
+type tokenBudgetAnalyzer struct{}
+
+func (tokenBudgetAnalyzer) Name() string { return "Token Budget" }
+func (tokenBudgetAnalyzer) Analyze(model *PromptModel) []Issue {
+	tokenBudget := Current().TokenBudget
+	if tokenBudget == nil {
+		return nil
+	}
+	tokens := EstimateTokens(model.Text)
+	if tokens <= tokenBudget.MaxTokens {
+		return nil
+	}
+	over := tokens - tokenBudget.MaxTokens
+	fix := fmt.Sprintf("Cut at least %d tokens: remove repetition, shorten examples or move reference material out of the prompt.", over)
+	if sections := largestSections(model, maxBudgetSections); len(sections) > 0 {
+		parts := make([]string, len(sections))
+		for i, section := range sections {
+			parts[i] = fmt.Sprintf("%q (line %d, about %d tokens)", section.Title, section.Line, section.Tokens)
+		}
+		fix += " Largest sections: " + strings.Join(parts, ", ") + "."
+	}
+	severity := tokenBudget.Severity
+	if severity == "" {
+		severity = "error"
+	}
+	return []Issue{{
+		Description: fmt.Sprintf("About %d tokens, the budget for %s is %d: %d tokens (%d%%) over",
+			tokens, tokenBudget.Path, tokenBudget.MaxTokens, over, over*100/tokenBudget.MaxTokens),
+		Reason:   "Prompts over their budget raise cost and latency of every call and crowd out the context left for input.",
+		Fix:      fix,
+		Severity: severity,
+	}}
+}
+
+func init() {
+	linter.RegisterAnalyzer(tokenBudgetAnalyzer{})
+}
@@ -0,0 +1,112 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// CognitiveLoadConfig configures instruction density limits in .promptlint.yaml
+type CognitiveLoadConfig struct {
+	// MaxDensity is the maximal number of instructions per 100 tokens, 8 when 0
+	MaxDensity float64 `yaml:"max_density,omitempty"`
+	// MaxNesting is the maximal number of conditions in one instruction, 2 when 0
+	MaxNesting int `yaml:"max_nesting,omitempty"`
+}
+
+// Default cognitive load limits
+const (
+	defaultMaxDensity = 8
+	defaultMaxNesting = 2
+	// minDensityInstructions is the minimal number of instructions for the density check
+	minDensityInstructions = 10
+)
+
+var (
+	// instructionPattern matches modal and imperative markers of instructions
+	instructionPattern = regexp.MustCompile(`(?i)\b(?:must|should|shall|always|never|do not|don't|make sure|ensure|avoid|need to|have to)\b|^(?:use|write|return|answer|respond|include|keep|list|provide|add|check|follow|format|explain|summarize|ask|output|give|create|generate|describe|identify|extract|translate|reply|start|end|mention|refer|limit|focus|prefer|stop|remove)\b`)
+	// conditionPattern matches words that open a condition
+	conditionPattern = regexp.MustCompile(`(?i)\b(?:only if|except when|except if|if|unless|whenever|when|except|otherwise|in case|provided that|as long as)\b`)
+)
+
+// EstimateTokens approximates the token count of the text, about four characters per token
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// isInstruction reports whether the sentence tells the model what to do
+func isInstruction(sentence string) bool {
+	return instructionPattern.MatchString(strings.TrimSpace(sentence))
+}
+
+// withDefaults returns the limits with the defaults of unset ones
+func (c CognitiveLoadConfig) withDefaults() CognitiveLoadConfig {
+	if c.MaxDensity == 0 {
+		c.MaxDensity = defaultMaxDensity
+	}
+	if c.MaxNesting == 0 {
+		c.MaxNesting = defaultMaxNesting
+	}
+	return c
+}
+
+// instructionDensityAnalyzer reports prompts with too many instructions per token and deeply nested conditions
+type instructionDensityAnalyzer struct{}
+
+func (instructionDensityAnalyzer) Name() string { return "Instruction Density" }
+func (instructionDensityAnalyzer) Analyze(model *PromptModel) []Issue {
+	cognitiveLoad := Current().CognitiveLoad.withDefaults()
+	var issues []Issue
+	instructions := 0
+	perSection := map[int]int{}
+	for _, sentence := range model.Sentences {
+		// Conditional sentences are checked even without imperative markers
+		if nesting := len(conditionPattern.FindAllString(sentence.Text, -1)); nesting > cognitiveLoad.MaxNesting {
+			issues = append(issues, Issue{
+				Description:     fmt.Sprintf("Instruction nests %d conditions, the limit is %d", nesting, cognitiveLoad.MaxNesting),
+				Reason:          "Models lose track of nested conditions (\"if X then Y unless Z\") and apply the wrong branch.",
+				Fix:             "Split the instruction into ordered steps with one condition each, or use a list of cases.",
+				OriginalSnippet: sentence.Text,
+				Line:            sentence.Start.Line,
+				Column:          sentence.Start.Column,
+			})
+		}
+		if isInstruction(sentence.Text) {
+			instructions++
+			perSection[sentence.Section]++
+		}
+	}
+
+	tokens := EstimateTokens(model.Text)
+	if instructions < minDensityInstructions || tokens == 0 {
+		return issues
+	}
+	density := float64(instructions) * 100 / float64(tokens)
+	if density <= cognitiveLoad.MaxDensity {
+		return issues
+	}
+
+	fix := "Group the instructions into numbered steps and move independent tasks into separate prompts."
+	densest, most := -1, 0
+	for section, count := range perSection {
+		if section >= 0 && (count > most || (count == most && section < densest)) {
+			densest, most = section, count
+		}
+	}
+	if densest >= 0 {
+		fix += fmt.Sprintf(" Section %q has the most instructions (%d).", model.Sections[densest].Title, most)
+	}
+	issues = append(issues, Issue{
+		Description: fmt.Sprintf("%d instructions in about %d tokens: %.1f per 100 tokens, the limit is %.0f", instructions, tokens, density, cognitiveLoad.MaxDensity),
+		Reason:      "A dense list of instructions overloads the model, instructions in the middle are followed less reliably.",
+		Fix:         fix,
+	})
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(instructionDensityAnalyzer{})
+}
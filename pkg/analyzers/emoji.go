@@ -0,0 +1,138 @@
+package analyzers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// Emoji policies of a message role
+const (
+	EmojiAllow  = "allow"
+	EmojiWarn   = "warn"
+	EmojiForbid = "forbid"
+)
+
+// defaultEmojiPolicy warns about decorative characters in system prompts and allows them elsewhere
+var defaultEmojiPolicy = map[string]string{"system": EmojiWarn}
+
+// decorativeRanges are Unicode ranges of emoji, pictographs, dingbats and decorative shapes
+var decorativeRanges = [][2]rune{
+	{0x1F000, 0x1FAFF}, // Emoji, pictographs, transport and map symbols
+	{0x2600, 0x27BF},   // Miscellaneous symbols and dingbats
+	{0x2B00, 0x2BFF},   // Miscellaneous symbols and arrows
+	{0x25A0, 0x25FF},   // Geometric shapes
+}
+
+// isDecorative reports whether the rune is an emoji or a decorative symbol
+func isDecorative(r rune) bool {
+	for _, rng := range decorativeRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmojiModifier reports whether the rune continues an emoji sequence: joiner, variation selector, keycap or skin tone
+func isEmojiModifier(r rune) bool {
+	return r == '\u200d' || r == '\ufe0f' || r == '\u20e3' || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// removeDecorative removes emoji sequences from the line and the spaces they leave behind
+func removeDecorative(line string) string {
+	var out []rune
+	inEmoji := false
+	for _, r := range line {
+		switch {
+		case inEmoji && isEmojiModifier(r):
+		case isDecorative(r):
+			inEmoji = true
+		default:
+			// Punctuation after a removed emoji joins the previous word
+			if inEmoji && strings.ContainsRune(".,;:!?", r) && len(out) > 0 && out[len(out)-1] == ' ' {
+				out = out[:len(out)-1]
+			}
+			inEmoji = false
+			out = append(out, r)
+		}
+	}
+
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	rest := strings.TrimLeft(strings.TrimPrefix(string(out), indent), " ")
+	return strings.TrimRight(CollapseSpaces(indent+rest), " ")
+}
+
+// EmojiPolicyOf returns the emoji policy of the role: the configured one, the default one or the configured
+// "default" policy. Prompts without role markers are checked as system prompts.
+func (s Settings) EmojiPolicyOf(role string) string {
+	if policy, ok := s.EmojiPolicy[role]; ok {
+		return policy
+	}
+	if policy, ok := defaultEmojiPolicy[role]; ok {
+		return policy
+	}
+	if policy, ok := s.EmojiPolicy["default"]; ok {
+		return policy
+	}
+	return EmojiAllow
+}
+
+// emojiPolicyAnalyzer reports emoji and decorative characters in roles where the policy doesn't allow them
+type emojiPolicyAnalyzer struct{}
+
+func (emojiPolicyAnalyzer) Name() string { return "Emoji Policy" }
+func (emojiPolicyAnalyzer) Analyze(model *PromptModel) []Issue {
+	settings := Current()
+	var issues []Issue
+	inFence := model.FenceLines()
+	roles := model.LineRoles()
+	for i, line := range model.Lines {
+		// Example outputs in code fences may contain emoji on purpose
+		if inFence[i+1] || linter.RoleMarkerPattern.MatchString(line) {
+			continue
+		}
+		role := roles[i]
+		policy := settings.EmojiPolicyOf(role)
+		if policy == EmojiAllow {
+			continue
+		}
+
+		var found []string
+		column := 0
+		for offset, r := range line {
+			if !isDecorative(r) || isEmojiModifier(r) {
+				continue
+			}
+			position := model.Position(model.LineOffset(i) + offset)
+			if column == 0 {
+				column = position.Column
+			}
+			found = append(found, fmt.Sprintf("%c at %d:%d", r, position.Line, position.Column))
+		}
+		if len(found) == 0 {
+			continue
+		}
+
+		severity := "warning"
+		if policy == EmojiForbid {
+			severity = "error"
+		}
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("Emoji or decorative characters in a %s message: %s", role, strings.Join(found, ", ")),
+			Reason:          "Emoji and decorative symbols cost several tokens each, carry no instructions and set an informal tone the model tends to copy.",
+			Fix:             "Remove them or state the meaning in words, `--fix` removes them. Allow them per role with emoji in " + configFileName + ".",
+			OriginalSnippet: line,
+			FixedSnippet:    removeDecorative(line),
+			Line:            i + 1,
+			Column:          column,
+			Severity:        severity,
+		})
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(emojiPolicyAnalyzer{})
+}
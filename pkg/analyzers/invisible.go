@@ -0,0 +1,142 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// invisibleChar describes a character that looks like ordinary text or nothing at all
+type invisibleChar struct {
+	Name string
+	// Replacement is the plain ASCII equivalent, "" removes the character
+	Replacement string
+}
+
+// invisibleChars contains characters that break exact-match parsing and waste tokens.
+// Zero-width joiners are not included because emoji sequences and some scripts need them.
+var invisibleChars = map[rune]invisibleChar{
+	'\u200b': {"zero-width space", ""},
+	'\u2060': {"word joiner", ""},
+	'\ufeff': {"zero-width no-break space", ""},
+	'\u00ad': {"soft hyphen", ""},
+	'\u00a0': {"non-breaking space", " "},
+	'\u202f': {"narrow non-breaking space", " "},
+	'\u2007': {"figure space", " "},
+	'\u2009': {"thin space", " "},
+	'\u200a': {"hair space", " "},
+	'\u200e': {"left-to-right mark", ""},
+	'\u200f': {"right-to-left mark", ""},
+	'\u202a': {"left-to-right embedding", ""},
+	'\u202b': {"right-to-left embedding", ""},
+	'\u202c': {"pop directional formatting", ""},
+	'\u202d': {"left-to-right override", ""},
+	'\u202e': {"right-to-left override", ""},
+	'\u2066': {"left-to-right isolate", ""},
+	'\u2067': {"right-to-left isolate", ""},
+	'\u2068': {"first strong isolate", ""},
+	'\u2069': {"pop directional isolate", ""},
+	'\u2018': {"left single quotation mark", "'"},
+	'\u2019': {"right single quotation mark", "'"},
+	'\u201c': {"left double quotation mark", `"`},
+	'\u201d': {"right double quotation mark", `"`},
+}
+
+// duplicateSpacePattern matches runs of spaces after a word
+var duplicateSpacePattern = regexp.MustCompile(`\S( {2,})`)
+
+// duplicateSpaces returns byte ranges of space runs between words, indentation and trailing spaces are skipped
+func duplicateSpaces(line string) [][2]int {
+	var runs [][2]int
+	for _, loc := range duplicateSpacePattern.FindAllStringSubmatchIndex(line, -1) {
+		if loc[3] < len(line) {
+			runs = append(runs, [2]int{loc[2], loc[3]})
+		}
+	}
+	return runs
+}
+
+// CleanInvisible replaces invisible characters and smart quotes with their plain equivalents
+func CleanInvisible(line string) string {
+	return strings.Map(func(r rune) rune {
+		if c, ok := invisibleChars[r]; ok {
+			if c.Replacement == "" {
+				return -1
+			}
+			return []rune(c.Replacement)[0]
+		}
+		return r
+	}, line)
+}
+
+// CollapseSpaces replaces runs of spaces between words with a single space
+func CollapseSpaces(line string) string {
+	runs := duplicateSpaces(line)
+	for i := len(runs) - 1; i >= 0; i-- {
+		line = line[:runs[i][0]] + " " + line[runs[i][1]:]
+	}
+	return line
+}
+
+// IsTableRow reports whether the line is a Markdown table row, where spaces align columns
+func IsTableRow(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+// invisibleCharsAnalyzer reports invisible characters, smart quotes and duplicate spaces line by line
+type invisibleCharsAnalyzer struct{}
+
+func (invisibleCharsAnalyzer) Name() string { return "Invisible Characters" }
+func (invisibleCharsAnalyzer) Analyze(model *PromptModel) []Issue {
+	// Spaces in code fences are significant, invisible characters are reported everywhere
+	inFence := model.FenceLines()
+
+	var issues []Issue
+	for i, line := range model.Lines {
+		var found []string
+		column := 0
+		for offset, r := range line {
+			c, ok := invisibleChars[r]
+			if !ok {
+				continue
+			}
+			position := model.Position(model.LineOffset(i) + offset)
+			if column == 0 {
+				column = position.Column
+			}
+			found = append(found, fmt.Sprintf("%s (U+%04X) at %d:%d", c.Name, r, position.Line, position.Column))
+		}
+
+		fixed := CleanInvisible(line)
+		if !inFence[i+1] && !IsTableRow(line) {
+			for _, run := range duplicateSpaces(line) {
+				position := model.Position(model.LineOffset(i) + run[0])
+				if column == 0 || position.Column < column {
+					column = position.Column
+				}
+				found = append(found, fmt.Sprintf("%d spaces at %d:%d", run[1]-run[0], position.Line, position.Column))
+			}
+			fixed = CollapseSpaces(fixed)
+		}
+		if len(found) == 0 {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Description:     "Invisible or look-alike characters: " + strings.Join(found, ", "),
+			Reason:          "Zero-width, non-breaking and bidirectional characters, smart quotes and repeated spaces look like plain text but break exact matching of delimiters and keywords and waste tokens.",
+			Fix:             "Replace them with plain spaces and ASCII quotes or remove them, `promptlint fmt -w` and `--fix` clean them up.",
+			OriginalSnippet: line,
+			FixedSnippet:    fixed,
+			Line:            i + 1,
+			Column:          column,
+		})
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(invisibleCharsAnalyzer{})
+}
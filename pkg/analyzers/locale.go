@@ -0,0 +1,148 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+var (
+	// hardcodedYearPattern matches statements about the present pinned to a year
+	hardcodedYearPattern = regexp.MustCompile(`(?i)\b(?:as of|as at|up to|knowledge cutoff(?: is| of)?|it is(?: currently)?|the current year is)\s+(?:(?:january|february|march|april|may|june|july|august|september|october|november|december)\s+)?((?:19|20)\d{2})\b`)
+	// hardcodedTodayPattern matches a fixed value of the current date
+	hardcodedTodayPattern = regexp.MustCompile(`(?i)\b(?:today is|the current date is|current date:|the date is|it is now)\s+([A-Za-z]*\s*\d[\w ,./-]*\d)`)
+	// ambiguousDatePattern matches numeric dates like 03/04/2024
+	ambiguousDatePattern = regexp.MustCompile(`\b(\d{1,2})[/.](\d{1,2})[/.](\d{2}|\d{4})\b`)
+	// relativeTimePattern matches references to the present that go stale
+	relativeTimePattern = regexp.MustCompile(`(?i)\b(?:today|tomorrow|yesterday|(?:this|next|last) (?:year|month|week|quarter)|right now|the current (?:year|month|date|president|ceo|version|price|prices|season)|the latest (?:version|release|model|news|data|prices?))\b`)
+	// currencyPattern matches amounts in currencies with an ambiguous sign or name
+	currencyPattern = regexp.MustCompile(`(?i)\$\s?\d(?:[\d,.]*\d)?|\b\d(?:[\d,.]*\d)?\s?(?:dollars?|pounds?|bucks)\b`)
+	// currencyCodePattern matches explicit currency codes that settle the currency
+	currencyCodePattern = regexp.MustCompile(`\b(?:USD|CAD|AUD|NZD|HKD|SGD|GBP|EUR)\b|(?i)\bcurrency\b`)
+	// ambiguousUnitPattern matches units whose value depends on the locale
+	ambiguousUnitPattern = regexp.MustCompile(`(?i)\b\d(?:[\d,.]*\d)?\s?(?:°|degrees?)(?:\s?(C|F|Celsius|Fahrenheit)\b)?|\b\d(?:[\d,.]*\d)?\s?(?:gallons?|tons?|ounces?|oz)\b`)
+	// datePlaceholderPattern matches placeholder names that provide the current date
+	datePlaceholderPattern = regexp.MustCompile(`(?i)date|time|today|now|year`)
+)
+
+// hasDatePlaceholder reports whether the prompt gets the current date from a template variable
+func hasDatePlaceholder(model *PromptModel) bool {
+	for _, placeholder := range model.Placeholders {
+		if datePlaceholderPattern.MatchString(placeholder.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsAny reports whether the byte range overlaps any of the ranges
+func overlapsAny(loc []int, ranges [][]int) bool {
+	for _, r := range ranges {
+		if loc[0] < r[1] && r[0] < loc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// localeAnalyzer reports hardcoded dates, ambiguous date formats, currencies and units without locale context
+type localeAnalyzer struct{}
+
+func (localeAnalyzer) Name() string { return "Date and Locale Assumptions" }
+func (localeAnalyzer) Analyze(model *PromptModel) []Issue {
+	syntax := model.PlaceholderSyntax()
+	if syntax == "" {
+		syntax = "{{}}"
+	}
+	datePlaceholder := fmt.Sprintf(linter.PlaceholderStyles[syntax], "current_date")
+	withDate := hasDatePlaceholder(model)
+	withCurrency := currencyCodePattern.MatchString(model.Text)
+	inFence := model.FenceLines()
+
+	var issues []Issue
+	add := func(i int, loc []int, issue Issue) {
+		position := model.Position(model.LineOffset(i) + loc[0])
+		issue.OriginalSnippet = model.Lines[i][loc[0]:loc[1]]
+		issue.Line, issue.Column = position.Line, position.Column
+		issues = append(issues, issue)
+	}
+
+	for i, line := range model.Lines {
+		if inFence[i+1] {
+			continue
+		}
+
+		for _, loc := range hardcodedYearPattern.FindAllStringSubmatchIndex(line, -1) {
+			match := line[loc[0]:loc[1]]
+			add(i, loc[:2], Issue{
+				Description:  fmt.Sprintf("Hardcoded year %s in a statement about the present", line[loc[2]:loc[3]]),
+				Reason:       "Statements pinned to a year silently go stale when the prompt is used later.",
+				Fix:          "Pass the current date as a template variable.",
+				FixedSnippet: strings.TrimSpace(match[:loc[2]-loc[0]]) + " " + datePlaceholder,
+			})
+		}
+		// Relative references inside a hardcoded date are reported once
+		var hardcoded [][]int
+		for _, loc := range hardcodedTodayPattern.FindAllStringSubmatchIndex(line, -1) {
+			hardcoded = append(hardcoded, loc)
+			match := line[loc[0]:loc[1]]
+			add(i, loc[:2], Issue{
+				Description:  "Hardcoded current date",
+				Reason:       "A fixed \"today\" is wrong on every other day and breaks date calculations.",
+				Fix:          "Pass the current date as a template variable.",
+				FixedSnippet: strings.TrimSpace(match[:loc[2]-loc[0]]) + " " + datePlaceholder,
+			})
+		}
+		for _, loc := range ambiguousDatePattern.FindAllStringSubmatchIndex(line, -1) {
+			first, _ := strconv.Atoi(line[loc[2]:loc[3]])
+			second, _ := strconv.Atoi(line[loc[4]:loc[5]])
+			if first > 12 || second > 12 || first == second {
+				continue
+			}
+			add(i, loc[:2], Issue{
+				Description: fmt.Sprintf("Ambiguous date %s reads as month/day or day/month depending on the locale", line[loc[0]:loc[1]]),
+				Reason:      "The model picks one interpretation and doesn't say so, dates become wrong for the other half of the world.",
+				Fix:         "Use ISO 8601 dates (YYYY-MM-DD) or spell out the month.",
+			})
+		}
+		if !withDate {
+			for _, loc := range relativeTimePattern.FindAllStringIndex(line, -1) {
+				if overlapsAny(loc, hardcoded) {
+					continue
+				}
+				add(i, loc, Issue{
+					Description: fmt.Sprintf("%q refers to the present, but the prompt doesn't provide the current date", line[loc[0]:loc[1]]),
+					Reason:      "The model only knows its training cutoff, relative time references are resolved against a stale date.",
+					Fix:         fmt.Sprintf("Add the current date to the prompt, e.g. \"Today is %s.\"", datePlaceholder),
+				})
+			}
+		}
+		if !withCurrency {
+			for _, loc := range currencyPattern.FindAllStringIndex(line, -1) {
+				add(i, loc, Issue{
+					Description: fmt.Sprintf("Amount %q without a currency code", line[loc[0]:loc[1]]),
+					Reason:      "\"$\", dollars and pounds mean different currencies (and pounds also a weight) in different locales.",
+					Fix:         "State the currency with an ISO 4217 code (USD, CAD, GBP) or pass it as a template variable.",
+				})
+			}
+		}
+		for _, loc := range ambiguousUnitPattern.FindAllStringSubmatchIndex(line, -1) {
+			if loc[2] >= 0 {
+				continue
+			}
+			add(i, loc[:2], Issue{
+				Description: fmt.Sprintf("Measurement %q without a unit system", line[loc[0]:loc[1]]),
+				Reason:      "Degrees, gallons, tons and ounces have different values in metric, US and imperial systems.",
+				Fix:         "Name the unit system (Celsius/Fahrenheit, US/imperial gallons, metric tons) or use SI units.",
+			})
+		}
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(localeAnalyzer{})
+}
@@ -0,0 +1,106 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// minNegationCluster is the number of negative instructions that makes a cluster
+const minNegationCluster = 3
+
+// maxNegationGap is the number of other sentences allowed between negative instructions of a cluster
+const maxNegationGap = 1
+
+// positiveRewrites maps common negative instructions to positive reformulations
+var positiveRewrites = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) be (?:verbose|wordy|long-winded)`), "Be concise"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) (?:make (?:things |anything )?up|invent (?:facts|information|anything)|guess|hallucinate)`), "Use only facts from the provided context and say that you don't know when it has no answer"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never|avoid) (?:use |using )?(?:jargon|technical terms)`), "Use plain language"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) repeat (?:yourself|the question)`), "State each point once"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) (?:reveal|share|disclose) ([^.!?]+)`), "Keep $1 confidential"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) apologi[sz]e`), "Answer directly"},
+	{regexp.MustCompile(`(?i)^avoid long (sentences|paragraphs|answers|responses)`), "Keep $1 short"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) use markdown`), "Reply in plain text"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) use emojis?`), "Use text only"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) ask (?:follow-up |clarifying )?questions`), "Make reasonable assumptions and state them"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) (?:use|write in) (?:the )?passive voice`), "Use active voice"},
+	{regexp.MustCompile(`(?i)^(?:don't|do not|never) (?:include|add) (?:any )?(?:explanations?|commentary)`), "Return only the result"},
+}
+
+// positiveRewrite returns a positive reformulation of a negative instruction, "" if none is known
+func positiveRewrite(sentence string) string {
+	text := strings.TrimSpace(sentence)
+	for _, rewrite := range positiveRewrites {
+		if loc := rewrite.pattern.FindStringSubmatchIndex(text); loc != nil {
+			result := string(rewrite.pattern.ExpandString(nil, rewrite.replacement, text, loc))
+			return result + text[loc[1]:]
+		}
+	}
+	return ""
+}
+
+// negationAnalyzer reports clusters of negative instructions with positive reformulations
+type negationAnalyzer struct{}
+
+func (negationAnalyzer) Name() string { return "Negation Clusters" }
+func (negationAnalyzer) Analyze(model *PromptModel) []Issue {
+	var issues []Issue
+	var cluster []Sentence
+	gap := 0
+
+	flush := func() {
+		if len(cluster) >= minNegationCluster {
+			issues = append(issues, negationIssue(cluster))
+		}
+		cluster, gap = nil, 0
+	}
+	for _, sentence := range model.Sentences {
+		if linter.NegativePattern.MatchString(sentence.Text) {
+			cluster = append(cluster, sentence)
+			gap = 0
+			continue
+		}
+		if len(cluster) > 0 {
+			gap++
+			if gap > maxNegationGap {
+				flush()
+			}
+		}
+	}
+	flush()
+	return issues
+}
+
+// negationIssue describes a cluster of negative instructions
+func negationIssue(cluster []Sentence) Issue {
+	var suggestions []string
+	for _, sentence := range cluster {
+		if rewrite := positiveRewrite(sentence.Text); rewrite != "" {
+			suggestions = append(suggestions, fmt.Sprintf("%q → %q", sentence.Text, rewrite))
+		}
+	}
+	fix := "Rephrase each prohibition as the behavior you want: say what to do instead of what not to do, and keep a negative form only for hard limits."
+	if len(suggestions) > 0 {
+		fix += " Suggested: " + strings.Join(suggestions, "; ")
+	}
+
+	first, last := cluster[0], cluster[len(cluster)-1]
+	return Issue{
+		Description:     fmt.Sprintf("%d negative instructions close together (lines %d-%d)", len(cluster), first.Start.Line, last.Start.Line),
+		Reason:          "Models follow positive instructions more reliably, a run of prohibitions primes the forbidden behavior and doesn't say what to do instead.",
+		Fix:             fix,
+		OriginalSnippet: first.Text,
+		Line:            first.Start.Line,
+		Column:          first.Start.Column,
+	}
+}
+
+func init() {
+	linter.RegisterAnalyzer(negationAnalyzer{})
+}
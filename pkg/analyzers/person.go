@@ -0,0 +1,155 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// Styles of addressing the model
+const (
+	firstPerson  = "first person"
+	secondPerson = "second person"
+	thirdPerson  = "third person"
+)
+
+// modelNouns are nouns prompts use for the model in the third person
+const modelNouns = `assistant|model|AI|bot|chatbot|agent`
+
+var (
+	firstPersonPattern  = regexp.MustCompile(`(?i)^(?:I am|I'm|I will|I must|I should|I can|as an? [a-z ]+, I)\b`)
+	secondPersonPattern = regexp.MustCompile(`(?i)\b(?:you|you're|your|yours|yourself)\b`)
+	thirdPersonPattern  = regexp.MustCompile(`(?i)\bthe (` + modelNouns + `)\b`)
+)
+
+// personRewrite is a replacement applied to normalize a sentence to a style
+type personRewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// toSecondPerson rewrites first and third person references to the model into the second person
+var toSecondPerson = []personRewrite{
+	{regexp.MustCompile(`(?i)\bthe (?:` + modelNouns + `)'s\b`), "your"},
+	{regexp.MustCompile(`(?i)\bthe (?:` + modelNouns + `) is\b`), "you are"},
+	{regexp.MustCompile(`(?i)\bthe (?:` + modelNouns + `) was\b`), "you were"},
+	{regexp.MustCompile(`(?i)\bthe (?:` + modelNouns + `) has\b`), "you have"},
+	{regexp.MustCompile(`(?i)\bthe (?:` + modelNouns + `) does\b`), "you do"},
+	{regexp.MustCompile(`(?i)\bthe (?:` + modelNouns + `)\b`), "you"},
+	{regexp.MustCompile(`\b(?:I am|I'm)\b`), "you are"},
+	{regexp.MustCompile(`\bI\b`), "you"},
+	{regexp.MustCompile(`(?i)\bmy\b`), "your"},
+	{regexp.MustCompile(`(?i)\bmyself\b`), "yourself"},
+}
+
+// toThirdPerson returns rewrites of first and second person references into the third person with the noun
+func toThirdPerson(noun string) []personRewrite {
+	the := "the " + noun
+	return []personRewrite{
+		{regexp.MustCompile(`(?i)\b(?:you are|you're|I am|I'm)\b`), the + " is"},
+		{regexp.MustCompile(`(?i)\b(?:you were)\b`), the + " was"},
+		{regexp.MustCompile(`(?i)\b(?:you have|I have)\b`), the + " has"},
+		{regexp.MustCompile(`(?i)\b(?:your|my)\b`), the + "'s"},
+		{regexp.MustCompile(`(?i)\b(?:yourself|myself)\b`), "itself"},
+		{regexp.MustCompile(`(?i)\byou\b|\bI\b`), the},
+	}
+}
+
+// toFirstPerson rewrites second and third person references to the model into the first person
+var toFirstPerson = []personRewrite{
+	{regexp.MustCompile(`(?i)\b(?:you are|you're|the (?:` + modelNouns + `) is)\b`), "I am"},
+	{regexp.MustCompile(`(?i)\b(?:your|the (?:` + modelNouns + `)'s)\b`), "my"},
+	{regexp.MustCompile(`(?i)\byourself\b`), "myself"},
+	{regexp.MustCompile(`(?i)^(?:you|the (?:` + modelNouns + `))\b`), "I"},
+	{regexp.MustCompile(`(?i)\b(?:you|the (?:` + modelNouns + `))\b`), "me"},
+}
+
+// addressingStyle returns how the sentence refers to the model, "" if it doesn't
+func addressingStyle(sentence string) string {
+	switch {
+	case firstPersonPattern.MatchString(sentence):
+		return firstPerson
+	case thirdPersonPattern.MatchString(sentence):
+		return thirdPerson
+	case secondPersonPattern.MatchString(sentence):
+		return secondPerson
+	}
+	return ""
+}
+
+// normalizePerson rewrites the sentence into the style
+func normalizePerson(sentence, style, noun string) string {
+	rewrites := toSecondPerson
+	switch style {
+	case firstPerson:
+		rewrites = toFirstPerson
+	case thirdPerson:
+		rewrites = toThirdPerson(noun)
+	}
+	for _, rewrite := range rewrites {
+		sentence = rewrite.pattern.ReplaceAllString(sentence, rewrite.replacement)
+	}
+	return strings.ToUpper(sentence[:1]) + sentence[1:]
+}
+
+// personAnalyzer reports sentences of the system prompt that address the model differently from the rest
+type personAnalyzer struct{}
+
+func (personAnalyzer) Name() string { return "Consistent Addressing" }
+func (personAnalyzer) Analyze(model *PromptModel) []Issue {
+	roles := model.LineRoles()
+	counts := map[string]int{}
+	styles := make([]string, len(model.Sentences))
+	noun := "assistant"
+	for i, sentence := range model.Sentences {
+		// Users address the model in their own way, only the system prompt is checked
+		if roles[sentence.Start.Line-1] != "system" {
+			continue
+		}
+		styles[i] = addressingStyle(sentence.Text)
+		if styles[i] != "" {
+			counts[styles[i]]++
+		}
+		if match := thirdPersonPattern.FindStringSubmatch(sentence.Text); match != nil {
+			noun = strings.ToLower(match[1])
+			if noun == "ai" {
+				noun = "AI"
+			}
+		}
+	}
+
+	// The most used style wins, the second person on ties as the most common convention
+	dominant, total := "", 0
+	for _, style := range []string{secondPerson, thirdPerson, firstPerson} {
+		if counts[style] > counts[dominant] {
+			dominant = style
+		}
+		total += counts[style]
+	}
+	if counts[dominant] == total {
+		return nil
+	}
+
+	var issues []Issue
+	for i, sentence := range model.Sentences {
+		if styles[i] == "" || styles[i] == dominant {
+			continue
+		}
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("The sentence addresses the model in the %s, %d of %d sentences use the %s", styles[i], counts[dominant], total, dominant),
+			Reason:          "Switching between \"you\", \"the assistant\" and \"I\" makes it unclear whether instructions apply to the model or describe someone else.",
+			Fix:             "Address the model the same way throughout the prompt, usually in the second person (\"You are…\", \"You should…\").",
+			OriginalSnippet: sentence.Text,
+			FixedSnippet:    normalizePerson(sentence.Text, dominant, noun),
+			Line:            sentence.Start.Line,
+			Column:          sentence.Start.Column,
+		})
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(personAnalyzer{})
+}
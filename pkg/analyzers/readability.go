@@ -0,0 +1,167 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// ReadingLevelConfig configures reading level targeting in .promptlint.yaml
+type ReadingLevelConfig struct {
+	// Grade is the target Flesch-Kincaid grade level, 0 disables the check
+	Grade float64 `yaml:"grade,omitempty"`
+	// Tolerance is the allowed deviation from the target grade, 2 when 0
+	Tolerance float64 `yaml:"tolerance,omitempty"`
+	// Sections limits the check to user-facing sections by title or kind (output, examples, ...), all sections if empty
+	Sections []string `yaml:"sections,omitempty"`
+}
+
+// defaultReadingTolerance is the allowed grade deviation when tolerance is not configured
+const defaultReadingTolerance = 2
+
+// wordPattern matches the words of a sentence
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// minReadabilityWords is the minimal size of a section for a meaningful readability score
+const minReadabilityWords = 30
+
+// ReadingLevelRuleName is the name of the LLM rule generated from the configured reading level
+const ReadingLevelRuleName = "Match Reading Level"
+
+// ReadingLevelRule builds the rule the LLM suggests simplifications with
+func ReadingLevelRule(config ReadingLevelConfig) rules.Rule {
+	scope := "User-facing text of the prompt"
+	if len(config.Sections) > 0 {
+		scope = "Text of the sections " + strings.Join(config.Sections, ", ")
+	}
+	return rules.Rule{
+		Name:   ReadingLevelRuleName,
+		Rule:   fmt.Sprintf("%s must be readable at grade level %.0f: short sentences, common words, one idea per sentence.", scope, config.Grade),
+		Reason: "Text above the reading level of its audience is misunderstood, text far below it reads as patronizing.",
+		Fix:    "Rewrite the snippet at the target reading level without losing information.",
+	}
+}
+
+// countSyllables estimates English syllables as groups of vowels without a silent final e
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// readabilityScore is the Flesch-Kincaid grade of a text with its size
+type readabilityScore struct {
+	Grade     float64
+	Words     int
+	Sentences int
+}
+
+// fleschKincaidGrade computes the grade level of sentences
+func fleschKincaidGrade(sentences []string) readabilityScore {
+	score := readabilityScore{Sentences: len(sentences)}
+	syllables := 0
+	for _, sentence := range sentences {
+		for _, word := range wordPattern.FindAllString(sentence, -1) {
+			score.Words++
+			syllables += countSyllables(word)
+		}
+	}
+	if score.Words == 0 || score.Sentences == 0 {
+		return score
+	}
+	score.Grade = 0.39*float64(score.Words)/float64(score.Sentences) + 11.8*float64(syllables)/float64(score.Words) - 15.59
+	return score
+}
+
+// readingLevelAnalyzer reports sections that read significantly above or below the target grade
+type readingLevelAnalyzer struct{}
+
+func (readingLevelAnalyzer) Name() string { return "Reading Level" }
+func (readingLevelAnalyzer) Analyze(model *PromptModel) []Issue {
+	readingLevel := Current().ReadingLevel
+	if readingLevel.Grade == 0 {
+		return nil
+	}
+	if readingLevel.Tolerance == 0 {
+		readingLevel.Tolerance = defaultReadingTolerance
+	}
+
+	// Sentences are grouped by their innermost section, -1 collects text outside sections
+	groups := map[int][]string{}
+	var order []int
+	for _, sentence := range model.Sentences {
+		if _, ok := groups[sentence.Section]; !ok {
+			order = append(order, sentence.Section)
+		}
+		groups[sentence.Section] = append(groups[sentence.Section], sentence.Text)
+	}
+
+	var issues []Issue
+	for _, index := range order {
+		title, line := "Prompt", 1
+		if index >= 0 {
+			title, line = model.Sections[index].Title, model.Sections[index].Start.Line
+		}
+		if !readingLevel.applies(title) {
+			continue
+		}
+
+		score := fleschKincaidGrade(groups[index])
+		if score.Words < minReadabilityWords {
+			continue
+		}
+		var description, fix string
+		switch {
+		case score.Grade > readingLevel.Grade+readingLevel.Tolerance:
+			description = fmt.Sprintf("Section %q reads at grade %.1f, above the target grade %.0f", title, score.Grade, readingLevel.Grade)
+			fix = "Split long sentences and replace long words with common ones."
+		case score.Grade < readingLevel.Grade-readingLevel.Tolerance:
+			description = fmt.Sprintf("Section %q reads at grade %.1f, below the target grade %.0f", title, score.Grade, readingLevel.Grade)
+			fix = "Join fragmented sentences and use the precise terms the audience knows."
+		default:
+			continue
+		}
+		issues = append(issues, Issue{
+			Description: fmt.Sprintf("%s (%d words in %d sentences)", description, score.Words, score.Sentences),
+			Reason:      "Text for users should match their reading level, the model also copies the complexity of its instructions into responses.",
+			Fix:         fix + " With an API key the " + ReadingLevelRuleName + " rule suggests simplified rewrites.",
+			Line:        line,
+		})
+	}
+	return issues
+}
+
+// applies reports whether a section with the title is checked for its reading level
+func (c ReadingLevelConfig) applies(title string) bool {
+	if len(c.Sections) == 0 {
+		return true
+	}
+	kind := classifySection(title)
+	for _, section := range c.Sections {
+		if strings.EqualFold(section, title) || (kind != "" && strings.EqualFold(section, kind)) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	linter.RegisterAnalyzer(readingLevelAnalyzer{})
+}
@@ -0,0 +1,410 @@
+package analyzers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+var (
+	exampleContextPattern = regexp.MustCompile(`(?i)\bexamples?\b|\bsample\b|\be\.g\.|\bfor instance\b`)
+	schemaContextPattern  = regexp.MustCompile(`(?i)\bschema\b|\bformat\b|\bstructure\b|\bfields\b|\bcolumns\b|\btemplate\b`)
+)
+
+// SchemaTypeNames maps type names used in schemas and templates to JSON types
+var SchemaTypeNames = map[string]string{
+	"string": "string", "str": "string", "text": "string", "date": "string", "datetime": "string",
+	"number": "number", "float": "number", "double": "number", "decimal": "number",
+	"integer": "integer", "int": "integer",
+	"boolean": "boolean", "bool": "boolean",
+	"array": "array", "list": "array",
+	"object": "object", "dict": "object", "map": "object",
+	"null": "null",
+}
+
+// SchemaField is a field of the described output, Type is "" when any value fits
+type SchemaField struct {
+	Type     string
+	Required bool
+	Fields   map[string]*SchemaField
+}
+
+// OutputBlock is a code fence with structured data of the prompt
+type OutputBlock struct {
+	Fence CodeFence
+	// Format is json, yaml or csv
+	Format string
+	Data   interface{}
+}
+
+// fenceFormat returns the data format of the fence by its language or content, "" if it is not data
+func fenceFormat(fence CodeFence) string {
+	switch strings.ToLower(fence.Language) {
+	case "json", "jsonc":
+		return "json"
+	case "yaml", "yml":
+		return "yaml"
+	case "csv":
+		return "csv"
+	case "":
+	default:
+		return ""
+	}
+	content := strings.TrimSpace(fence.Content)
+	switch {
+	case strings.HasPrefix(content, "{") || strings.HasPrefix(content, "["):
+		return "json"
+	case strings.Contains(strings.SplitN(content, "\n", 2)[0], ",") && strings.Contains(content, "\n"):
+		return "csv"
+	case strings.Contains(content, ":"):
+		return "yaml"
+	}
+	return ""
+}
+
+// ParseOutputBlock decodes the fence content, it returns false when the content is not valid data
+func ParseOutputBlock(fence CodeFence) (OutputBlock, bool) {
+	block := OutputBlock{Fence: fence, Format: fenceFormat(fence)}
+	switch block.Format {
+	case "json":
+		if err := json.Unmarshal([]byte(fence.Content), &block.Data); err != nil {
+			return block, false
+		}
+	case "yaml":
+		if err := yaml.Unmarshal([]byte(fence.Content), &block.Data); err != nil {
+			return block, false
+		}
+		if _, ok := block.Data.(map[string]interface{}); !ok {
+			return block, false
+		}
+	case "csv":
+		reader := csv.NewReader(strings.NewReader(strings.TrimSpace(fence.Content)))
+		// Rows of a wrong length are reported as mismatches, not parse errors
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil || len(records) == 0 {
+			return block, false
+		}
+		block.Data = records
+	default:
+		return block, false
+	}
+	return block, true
+}
+
+// IsJSONSchema reports whether the data is a JSON Schema of an object
+func IsJSONSchema(data interface{}) bool {
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasProperties := object["properties"].(map[string]interface{})
+	return hasProperties || object["$schema"] != nil
+}
+
+// FieldsFromJSONSchema converts JSON Schema properties to fields
+func FieldsFromJSONSchema(schema map[string]interface{}) map[string]*SchemaField {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	fields := map[string]*SchemaField{}
+	for name, raw := range properties {
+		property, _ := raw.(map[string]interface{})
+		field := &SchemaField{Required: required[name]}
+		if t, ok := property["type"].(string); ok {
+			field.Type = t
+		}
+		if field.Type == "object" {
+			if _, ok := property["properties"]; ok {
+				field.Fields = FieldsFromJSONSchema(property)
+			}
+		}
+		if items, ok := property["items"].(map[string]interface{}); ok && field.Type == "array" {
+			if _, ok := items["properties"]; ok {
+				field.Fields = FieldsFromJSONSchema(items)
+			}
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// FieldsFromTemplate converts a template object like {"name": "string"} to fields, all of them required
+func FieldsFromTemplate(template map[string]interface{}) map[string]*SchemaField {
+	fields := map[string]*SchemaField{}
+	for name, value := range template {
+		field := &SchemaField{Required: true}
+		switch v := value.(type) {
+		case string:
+			field.Type = SchemaTypeNames[strings.ToLower(strings.TrimSpace(v))]
+			if field.Type == "" {
+				field.Type = "string"
+			}
+		case map[string]interface{}:
+			field.Type = "object"
+			field.Fields = FieldsFromTemplate(v)
+		case []interface{}:
+			field.Type = "array"
+			if len(v) > 0 {
+				if item, ok := v[0].(map[string]interface{}); ok {
+					field.Fields = FieldsFromTemplate(item)
+				}
+			}
+		default:
+			field.Type = ValueType(v)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// ValueType returns the JSON type of a decoded value
+func ValueType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// typeMatches reports whether a value of the actual type fits the expected type
+func typeMatches(expected, actual string) bool {
+	return expected == "" || expected == actual || (expected == "number" && actual == "integer")
+}
+
+// CompareFields returns mismatches between the example object and the schema fields,
+// fields missing from the schema are mismatches when strict
+func CompareFields(prefix string, fields map[string]*SchemaField, example map[string]interface{}, strict bool) []string {
+	var problems []string
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := fields[name]
+		value, ok := example[name]
+		switch {
+		case !ok && field.Required:
+			problems = append(problems, fmt.Sprintf("missing field %q", prefix+name))
+		case !ok:
+		case !typeMatches(field.Type, ValueType(value)):
+			problems = append(problems, fmt.Sprintf("field %q is %s, the schema says %s", prefix+name, ValueType(value), field.Type))
+		case field.Fields != nil:
+			switch v := value.(type) {
+			case map[string]interface{}:
+				problems = append(problems, CompareFields(prefix+name+".", field.Fields, v, strict)...)
+			case []interface{}:
+				if len(v) > 0 {
+					if item, ok := v[0].(map[string]interface{}); ok {
+						problems = append(problems, CompareFields(prefix+name+"[].", field.Fields, item, strict)...)
+					}
+				}
+			}
+		}
+	}
+
+	if !strict {
+		return problems
+	}
+	var unknown []string
+	for name := range example {
+		if _, ok := fields[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("field %q is not in the schema", prefix+name))
+	}
+	return problems
+}
+
+// CompareCSV returns mismatches between the example header and the described columns
+func CompareCSV(schema, example [][]string) []string {
+	expected, actual := schema[0], example[0]
+	var problems []string
+	for _, column := range expected {
+		if !containsString(actual, column) {
+			problems = append(problems, fmt.Sprintf("missing column %q", column))
+		}
+	}
+	for _, column := range actual {
+		if !containsString(expected, column) {
+			problems = append(problems, fmt.Sprintf("column %q is not in the schema", column))
+		}
+	}
+	if len(problems) == 0 {
+		for i, column := range expected {
+			if i < len(actual) && !containsString(actual[i:i+1], column) {
+				problems = append(problems, fmt.Sprintf("columns are ordered %s, the schema says %s", strings.Join(actual, ","), strings.Join(expected, ",")))
+				break
+			}
+		}
+	}
+	for i, row := range example[1:] {
+		if len(row) != len(actual) {
+			problems = append(problems, fmt.Sprintf("row %d has %d values for %d columns", i+2, len(row), len(actual)))
+		}
+	}
+	return problems
+}
+
+// containsString reports whether the slice contains the value, ignoring case and surrounding spaces
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fenceContext returns the text that introduces the fence: up to three lines before it and the titles of the enclosing sections
+func fenceContext(model *PromptModel, fence CodeFence) (lead, titles string) {
+	start := fence.Start.Line - 4
+	if start < 0 {
+		start = 0
+	}
+	lead = strings.Join(model.Lines[start:fence.Start.Line-1], "\n")
+	for _, section := range model.Sections {
+		if section.Start.Line < fence.Start.Line && section.End.Line >= fence.End.Line {
+			titles += "\n" + section.Title
+		}
+	}
+	return lead, titles
+}
+
+// FenceRole classifies a data fence as a "schema" or an "example", the lines before the fence win over section titles
+func FenceRole(model *PromptModel, block OutputBlock) string {
+	if IsJSONSchema(block.Data) {
+		return "schema"
+	}
+	lead, titles := fenceContext(model, block.Fence)
+	for _, context := range []string{lead, titles} {
+		switch {
+		case exampleContextPattern.MatchString(context):
+			return "example"
+		case schemaContextPattern.MatchString(context):
+			return "schema"
+		}
+	}
+	return ""
+}
+
+// schemaFor returns the schema an example illustrates: the closest one of the same kind (table or structure), the first one if none matches
+func schemaFor(example OutputBlock, schemas []OutputBlock) OutputBlock {
+	result, distance := schemas[0], -1
+	for _, schema := range schemas {
+		if (schema.Format == "csv") != (example.Format == "csv") {
+			continue
+		}
+		d := example.Fence.Start.Line - schema.Fence.Start.Line
+		if d < 0 {
+			d = -d
+		}
+		if distance < 0 || d < distance {
+			result, distance = schema, d
+		}
+	}
+	return result
+}
+
+// schemaExampleAnalyzer validates example outputs against the output schema described in the prompt
+type schemaExampleAnalyzer struct{}
+
+func (schemaExampleAnalyzer) Name() string { return "Example Matches Schema" }
+func (schemaExampleAnalyzer) Analyze(model *PromptModel) []Issue {
+	var schemas, examples []OutputBlock
+	for _, fence := range model.CodeFences {
+		block, ok := ParseOutputBlock(fence)
+		if !ok {
+			continue
+		}
+		switch FenceRole(model, block) {
+		case "schema":
+			schemas = append(schemas, block)
+		case "example":
+			examples = append(examples, block)
+		}
+	}
+	if len(schemas) == 0 || len(examples) == 0 {
+		return nil
+	}
+
+	var issues []Issue
+	for _, example := range examples {
+		schema := schemaFor(example, schemas)
+		var problems []string
+		switch {
+		case schema.Format == "csv" && example.Format == "csv":
+			problems = CompareCSV(schema.Data.([][]string), example.Data.([][]string))
+		case schema.Format == "csv" || example.Format == "csv":
+			problems = []string{fmt.Sprintf("the example is %s, the schema is %s", example.Format, schema.Format)}
+		default:
+			var fields map[string]*SchemaField
+			if IsJSONSchema(schema.Data) {
+				fields = FieldsFromJSONSchema(schema.Data.(map[string]interface{}))
+			} else if template, ok := schema.Data.(map[string]interface{}); ok {
+				fields = FieldsFromTemplate(template)
+			} else {
+				continue
+			}
+			// An array example is checked by its first item
+			data := example.Data
+			if items, ok := data.([]interface{}); ok && len(items) > 0 {
+				data = items[0]
+			}
+			object, ok := data.(map[string]interface{})
+			if !ok {
+				problems = []string{"the example is not an object"}
+				break
+			}
+			problems = CompareFields("", fields, object, true)
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		issues = append(issues, Issue{
+			Description: fmt.Sprintf("Example output doesn't match the output schema at line %d: %s", schema.Fence.Start.Line, strings.Join(problems, "; ")),
+			Reason:      "When the example and the schema disagree, the model copies whichever it weighs more and the output breaks parsing.",
+			Fix:         "Update the example so its fields, types and columns follow the schema exactly.",
+			Line:        example.Fence.Start.Line,
+		})
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(schemaExampleAnalyzer{})
+}
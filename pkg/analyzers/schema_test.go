@@ -0,0 +1,138 @@
+package analyzers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+func TestFenceFormat(t *testing.T) {
+	tests := []struct {
+		language, content string
+		want              string
+	}{
+		{"json", `{"a": 1}`, "json"},
+		{"JSONC", `{"a": 1}`, "json"},
+		{"yml", "a: 1", "yaml"},
+		{"csv", "a,b\n1,2", "csv"},
+		{"python", "print(1)", ""},
+		{"", `[{"a": 1}]`, "json"},
+		{"", "name,age\nAnn,42", "csv"},
+		{"", "name: Ann", "yaml"},
+		{"", "just text", ""},
+	}
+	for _, tt := range tests {
+		if got := fenceFormat(CodeFence{Language: tt.language, Content: tt.content}); got != tt.want {
+			t.Errorf("fenceFormat(%q, %q) = %q, want %q", tt.language, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestCompareFields(t *testing.T) {
+	schema := FieldsFromJSONSchema(map[string]interface{}{
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string"},
+			"score": map[string]interface{}{"type": "number"},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"properties": map[string]interface{}{"id": map[string]interface{}{"type": "integer"}}, "required": []interface{}{"id"}}},
+		},
+		"required": []interface{}{"name", "score"},
+	})
+	tests := []struct {
+		name    string
+		example map[string]interface{}
+		strict  bool
+		want    []string
+	}{
+		{"valid, integers are numbers", map[string]interface{}{"name": "a", "score": float64(3)}, true, nil},
+		{"missing required field", map[string]interface{}{"name": "a"}, true, []string{`missing field "score"`}},
+		{"wrong type", map[string]interface{}{"name": true, "score": 1.5}, true, []string{`field "name" is boolean, the schema says string`}},
+		{"nested array item", map[string]interface{}{"name": "a", "score": 1.0, "tags": []interface{}{map[string]interface{}{"id": "x"}}}, true, []string{`field "tags[].id" is string, the schema says integer`}},
+		{"unknown field when strict", map[string]interface{}{"name": "a", "score": 1.0, "extra": 1.0}, true, []string{`field "extra" is not in the schema`}},
+		{"unknown field when not strict", map[string]interface{}{"name": "a", "score": 1.0, "extra": 1.0}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareFields("", schema, tt.example, tt.strict); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareFields() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareCSV(t *testing.T) {
+	schema := [][]string{{"name", "age"}}
+	tests := []struct {
+		name    string
+		example [][]string
+		want    []string
+	}{
+		{"same columns", [][]string{{"Name", " age"}, {"Ann", "42"}}, nil},
+		{"missing and extra column", [][]string{{"name", "city"}}, []string{`missing column "age"`, `column "city" is not in the schema`}},
+		{"other order", [][]string{{"age", "name"}}, []string{"columns are ordered age,name, the schema says name,age"}},
+		{"short row", [][]string{{"name", "age"}, {"Ann"}}, []string{"row 2 has 1 values for 2 columns"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareCSV(schema, tt.example); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaExampleAnalyzer(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{
+			name:   "example matches the template",
+			prompt: "Reply in this format:\n```json\n{\"name\": \"string\", \"age\": \"int\"}\n```\nExample:\n```json\n{\"name\": \"Ann\", \"age\": 42}\n```\n",
+		},
+		{
+			name:   "example misses a field of the template",
+			prompt: "Reply in this format:\n```json\n{\"name\": \"string\", \"age\": \"int\"}\n```\nExample:\n```json\n{\"name\": \"Ann\"}\n```\n",
+			want:   `Example output doesn't match the output schema at line 2: missing field "age"`,
+		},
+		{
+			name:   "JSON Schema without context",
+			prompt: "```json\n{\"properties\": {\"ok\": {\"type\": \"boolean\"}}, \"required\": [\"ok\"]}\n```\nFor example:\n```json\n{\"ok\": \"yes\"}\n```\n",
+			want:   `field "ok" is string, the schema says boolean`,
+		},
+		{
+			name:   "array example is checked by its first item",
+			prompt: "## Output format\n```yaml\nid: int\n```\nSample output:\n```json\n[{\"id\": 1}, {\"id\": \"two\"}]\n```\n",
+		},
+		{
+			name:   "csv columns",
+			prompt: "Columns:\n```csv\nname,age\n```\nExample:\n```csv\nname,city\nAnn,Oslo\n```\n",
+			want:   `missing column "age"; column "city" is not in the schema`,
+		},
+		{
+			name:   "example of another kind",
+			prompt: "Columns:\n```csv\nname,age\n```\nExample:\n```json\n{\"name\": \"Ann\"}\n```\n",
+			want:   "the example is json, the schema is csv",
+		},
+		{
+			name:   "no schema",
+			prompt: "Example:\n```json\n{\"name\": \"Ann\"}\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := schemaExampleAnalyzer{}.Analyze(linter.ParsePrompt(tt.prompt))
+			if tt.want == "" {
+				if len(issues) > 0 {
+					t.Errorf("got issues %+v, want none", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || !strings.Contains(issues[0].Description, tt.want) {
+				t.Errorf("got issues %+v, want one with %q", issues, tt.want)
+			}
+		})
+	}
+}
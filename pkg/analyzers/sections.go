@@ -0,0 +1,178 @@
+package analyzers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// DefaultSectionOrder is the canonical order of prompt sections
+var DefaultSectionOrder = []string{"role", "context", "instructions", "constraints", "examples", "output"}
+
+// sectionKeywords maps section kinds to words of section titles
+var sectionKeywords = []struct {
+	kind     string
+	keywords []string
+}{
+	{"output", []string{"output", "format", "response", "deliverable", "answer"}},
+	{"examples", []string{"example", "sample", "demonstration", "few-shot", "shot"}},
+	{"constraints", []string{"constraint", "rule", "guideline", "requirement", "limitation", "restriction", "policy", "don't"}},
+	{"instructions", []string{"instruction", "task", "step", "goal", "objective", "what to do"}},
+	{"context", []string{"context", "background", "situation", "about", "input", "data"}},
+	{"role", []string{"role", "persona", "identity", "who you are", "system"}},
+}
+
+// classifySection returns the kind of a section by its title, "" if unknown
+func classifySection(title string) string {
+	title = strings.ToLower(title)
+	for _, entry := range sectionKeywords {
+		for _, keyword := range entry.keywords {
+			if strings.Contains(title, keyword) {
+				return entry.kind
+			}
+		}
+	}
+	return ""
+}
+
+// sectionBlock is a top-level section with its lines up to the next top-level section
+type sectionBlock struct {
+	Kind  string
+	Title string
+	Lines []string
+}
+
+// topLevelBlocks splits the prompt into the preamble and top-level section blocks
+func topLevelBlocks(model *PromptModel) ([]string, []sectionBlock) {
+	minLevel := 0
+	for _, section := range model.Sections {
+		if section.Kind == "heading" && (minLevel == 0 || section.Level < minLevel) {
+			minLevel = section.Level
+		}
+	}
+
+	var starts []Section
+	for _, section := range model.Sections {
+		switch {
+		case minLevel > 0 && section.Kind == "heading" && section.Level == minLevel:
+			starts = append(starts, section)
+		case minLevel == 0 && section.Kind == "tag" && (len(starts) == 0 || section.Start.Line > starts[len(starts)-1].End.Line):
+			starts = append(starts, section)
+		}
+	}
+	if len(starts) == 0 {
+		return model.Lines, nil
+	}
+
+	preamble := model.Lines[:starts[0].Start.Line-1]
+	blocks := make([]sectionBlock, 0, len(starts))
+	for i, section := range starts {
+		end := len(model.Lines)
+		if i+1 < len(starts) {
+			end = starts[i+1].Start.Line - 1
+		}
+		blocks = append(blocks, sectionBlock{
+			Kind:  classifySection(section.Title),
+			Title: section.Title,
+			Lines: model.Lines[section.Start.Line-1 : end],
+		})
+	}
+	return preamble, blocks
+}
+
+// orderIndex returns the position of the kind in the order, -1 for unknown kinds
+func orderIndex(order []string, kind string) int {
+	for i, k := range order {
+		if strings.EqualFold(k, kind) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sectionsInOrder reports whether known sections follow the order, it returns the first misplaced block title
+func sectionsInOrder(blocks []sectionBlock, order []string) (bool, string) {
+	last := -1
+	for _, block := range blocks {
+		index := orderIndex(order, block.Kind)
+		if index < 0 {
+			continue
+		}
+		if index < last {
+			return false, block.Title
+		}
+		last = index
+	}
+	return true, ""
+}
+
+// ReorderSections sorts known top-level sections into the order, unknown sections keep their positions.
+// It returns the text unchanged and false when the sections are already in order.
+func ReorderSections(text string, order []string) (string, bool) {
+	model := linter.ParsePrompt(text)
+	preamble, blocks := topLevelBlocks(model)
+	if ok, _ := sectionsInOrder(blocks, order); ok {
+		return text, false
+	}
+
+	var slots []int
+	var known []sectionBlock
+	for i, block := range blocks {
+		if orderIndex(order, block.Kind) >= 0 {
+			slots = append(slots, i)
+			known = append(known, block)
+		}
+	}
+	sort.SliceStable(known, func(i, j int) bool {
+		return orderIndex(order, known[i].Kind) < orderIndex(order, known[j].Kind)
+	})
+	for i, slot := range slots {
+		blocks[slot] = known[i]
+	}
+
+	// Blocks are separated by exactly one blank line
+	var parts []string
+	if head := strings.TrimRight(strings.Join(preamble, "\n"), "\n"); head != "" {
+		parts = append(parts, head)
+	}
+	for _, block := range blocks {
+		parts = append(parts, strings.TrimRight(strings.Join(block.Lines, "\n"), "\n"))
+	}
+	return strings.Join(parts, "\n\n") + "\n", true
+}
+
+// sectionOrderAnalyzer reports sections ordered against the canonical order
+type sectionOrderAnalyzer struct{}
+
+func (sectionOrderAnalyzer) Name() string { return "Canonical Section Order" }
+func (sectionOrderAnalyzer) Analyze(model *PromptModel) []Issue {
+	canonicalSectionOrder := Current().SectionOrder
+	if len(canonicalSectionOrder) == 0 {
+		canonicalSectionOrder = DefaultSectionOrder
+	}
+	_, blocks := topLevelBlocks(model)
+	ok, misplaced := sectionsInOrder(blocks, canonicalSectionOrder)
+	if ok {
+		return nil
+	}
+
+	var line int
+	for _, section := range model.Sections {
+		if section.Title == misplaced {
+			line = section.Start.Line
+			break
+		}
+	}
+	return []Issue{{
+		Description: fmt.Sprintf("Section %q is placed against the canonical order", misplaced),
+		Reason:      "A consistent order (" + strings.Join(canonicalSectionOrder, " → ") + ") helps the model and reviewers find the role, context and instructions before examples and the output format.",
+		Fix:         "Reorder the sections, `promptlint fmt --reorder -d` shows the suggested order as a diff and `-w` applies it.",
+		Line:        line,
+	}}
+}
+
+func init() {
+	linter.RegisterAnalyzer(sectionOrderAnalyzer{})
+}
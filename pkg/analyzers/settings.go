@@ -0,0 +1,82 @@
+// Package analyzers contains the built-in static analyzers of promptlint. Importing the package registers them,
+// linter.Lint runs them with the current settings:
+//
+//	import _ "github.com/korchasa/promptlint/pkg/analyzers"
+package analyzers
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// Aliases of the linter types the analyzers work with
+type (
+	Issue       = linter.Issue
+	PromptModel = linter.PromptModel
+	Position    = linter.Position
+	Sentence    = linter.Sentence
+	Placeholder = linter.Placeholder
+	CodeFence   = linter.CodeFence
+	Section     = linter.Section
+)
+
+// configFileName is the project configuration file the analyzers are configured with in the CLI
+const configFileName = ".promptlint.yaml"
+
+// Settings configure the built-in analyzers, the zero value of a field selects its default
+type Settings struct {
+	// SectionOrder is the canonical order of section kinds, DefaultSectionOrder when empty
+	SectionOrder []string
+	// EmojiPolicy maps message roles to EmojiAllow, EmojiWarn or EmojiForbid, the "default" key applies to
+	// unlisted roles. Without a policy decorative characters are reported in system prompts only.
+	EmojiPolicy map[string]string
+	// ToneWords matches additional words that don't fit the brand voice, see WordsPattern
+	ToneWords *regexp.Regexp
+	// ReadingLevel is the target reading level, the check is disabled when Grade is 0
+	ReadingLevel ReadingLevelConfig
+	// CognitiveLoad limits the instruction density and nesting
+	CognitiveLoad CognitiveLoadConfig
+	// TokenBudget is the budget of the linted prompt, nil when no budget applies
+	TokenBudget *TokenBudget
+	// Variables are the template variables the prompts are rendered with, nil doesn't check them.
+	// VariablesFile names their file in issues.
+	Variables     map[string]interface{}
+	VariablesFile string
+	// CheckURLs requests the links of prompts to report dead ones, every request waits up to URLTimeout
+	CheckURLs  bool
+	URLTimeout time.Duration
+	// UserAgent is sent with link checks, "promptlint" when empty
+	UserAgent string
+	// Progress receives progress messages, nil discards them
+	Progress func(message string)
+}
+
+// current holds the settings the analyzers run with
+var current struct {
+	sync.RWMutex
+	settings Settings
+}
+
+// Configure replaces the settings at once, analyzers running meanwhile finish with the previous ones
+func Configure(settings Settings) {
+	current.Lock()
+	defer current.Unlock()
+	current.settings = settings
+}
+
+// Current returns the settings the analyzers run with
+func Current() Settings {
+	current.RLock()
+	defer current.RUnlock()
+	return current.settings
+}
+
+// progress reports a progress message to the configured receiver
+func (s Settings) progress(message string) {
+	if s.Progress != nil {
+		s.Progress(message)
+	}
+}
@@ -0,0 +1,40 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+func TestLintRunsBuiltinAnalyzers(t *testing.T) {
+	t.Cleanup(func() { Configure(Settings{}) })
+	prompt := "You are a support agent.\nAnswer in the ACME voice.\n"
+	tests := []struct {
+		name     string
+		settings Settings
+		want     []string
+	}{
+		{"default settings", Settings{}, nil},
+		{"configured tone words", Settings{ToneWords: WordsPattern([]string{"ACME"})}, []string{"Tone:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Configure(tt.settings)
+			issues, err := linter.Lint(context.Background(), prompt, linter.Options{Rules: &rules.Rules{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, fmt.Sprintf("%s:%d", issue.RuleName, issue.Line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package analyzers
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// TemplateKeywords are words of template languages that look like placeholders
+var TemplateKeywords = map[string]bool{"else": true, "this": true}
+
+var (
+	// printfPattern matches positional printf directives like %s, %d or %-10.2f
+	printfPattern = regexp.MustCompile(`%(?:\d+\$)?[-+#0]*\d*(?:\.\d+)?[sdifr]`)
+	// strftimePattern tells date formats like %Y-%m-%d from printf directives
+	strftimePattern = regexp.MustCompile(`%[YmHMSbBaAjZzpIy]`)
+	// formatFieldPattern matches the content of a str.format replacement field: {}, {0}, {name.attr[0]!r:>10}
+	formatFieldPattern = regexp.MustCompile(`^(?:[A-Za-z_]\w*|\d*)(?:[.\[][^{}!:]*)?(?:![rsa])?(?::[^{}]*)?$`)
+	// jinjaForPattern and jinjaSetPattern match statements defining template-local variables
+	jinjaForPattern = regexp.MustCompile(`\{%-?\s*for\s+([A-Za-z_]\w*)(?:\s*,\s*([A-Za-z_]\w*))?\s+in\s+([A-Za-z_][\w.]*)`)
+	jinjaSetPattern = regexp.MustCompile(`\{%-?\s*set\s+([A-Za-z_]\w*)\s*=`)
+	// handlebarsBlockPattern matches block helpers, placeholders inside each and with blocks refer to the item
+	handlebarsBlockPattern = regexp.MustCompile(`\{\{#(each|with|if|unless)\s+([A-Za-z_][\w.]*)[^}]*\}\}|\{\{/(each|with)\s*\}\}`)
+	// malformedPlaceholderPatterns match placeholders with unbalanced braces: {{name}, {name}}, ${name
+	malformedPlaceholderPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\{\{\s*[A-Za-z_][\w.]*\s*\}(?:[^}]|$)`),
+		regexp.MustCompile(`(?:^|[^{])\{\s*[A-Za-z_][\w.]*\s*\}\}`),
+		regexp.MustCompile(`\$\{[A-Za-z_][\w.]*(?:[^\w.}]|$)`),
+	}
+)
+
+// LoadTemplateVariables reads a YAML mapping of variable names to values, nested mappings are
+// addressed with dots like {{user.name}}
+func LoadTemplateVariables(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variables file: %w", err)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("error parsing variables file %s: %w", path, err)
+	}
+	variables := map[string]interface{}{}
+	if len(node.Content) == 0 {
+		return variables, nil
+	}
+	if node.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("variables file %s must be a mapping of variable names to values", path)
+	}
+	if err := node.Content[0].Decode(&variables); err != nil {
+		return nil, fmt.Errorf("error parsing variables file %s: %w", path, err)
+	}
+	return variables, nil
+}
+
+// lookupVariable reports whether the dotted name resolves in the variables
+func lookupVariable(variables map[string]interface{}, name string) bool {
+	_, ok := VariableValue(variables, name)
+	return ok
+}
+
+// VariableValue resolves the dotted name in the variables, numeric parts index lists
+func VariableValue(variables map[string]interface{}, name string) (interface{}, bool) {
+	var value interface{} = variables
+	for _, part := range strings.Split(name, ".") {
+		switch current := value.(type) {
+		case map[string]interface{}:
+			next, ok := current[part]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil, false
+			}
+			value = current[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// variableRoot returns the top-level variable of a dotted name
+func variableRoot(name string) string {
+	return strings.SplitN(name, ".", 2)[0]
+}
+
+// templateUsage is what a template does with its variables besides plain placeholders
+type templateUsage struct {
+	// positional are offsets of printf directives and {} or {0} fields, they have no name to check
+	positional []int
+	// locals are variables the template defines itself, loop variables and assignments
+	locals map[string]bool
+	// references are top-level variables used by statements and block helpers, mapped to the first statement
+	references map[string]string
+	// itemScopes are ranges of each and with blocks, where names refer to the current item
+	itemScopes [][2]int
+}
+
+// inItemScope reports whether the offset is inside an each or with block
+func (u templateUsage) inItemScope(offset int) bool {
+	for _, scope := range u.itemScopes {
+		if offset >= scope[0] && offset < scope[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// findTemplateUsage collects positional placeholders, template-local variables and block references
+func findTemplateUsage(model *PromptModel) templateUsage {
+	usage := templateUsage{locals: map[string]bool{}, references: map[string]string{}}
+	text := model.Text
+
+	for _, loc := range printfPattern.FindAllStringIndex(text, -1) {
+		if loc[0] > 0 && text[loc[0]-1] == '%' {
+			continue
+		}
+		// A date format like %Y-%m-%d is literal text for the model
+		wordStart := strings.LastIndexAny(text[:loc[0]], " \t\n") + 1
+		wordEnd := len(text)
+		if i := strings.IndexAny(text[loc[1]:], " \t\n"); i >= 0 {
+			wordEnd = loc[1] + i
+		}
+		if strftimePattern.MatchString(text[wordStart:wordEnd]) {
+			continue
+		}
+		usage.positional = append(usage.positional, loc[0])
+	}
+	if model.PlaceholderSyntax() == "{}" {
+		for _, field := range formatFields(text) {
+			if name := text[field[0]+1 : field[1]-1]; name == "" || name[0] >= '0' && name[0] <= '9' {
+				usage.positional = append(usage.positional, field[0])
+			}
+		}
+	}
+	sort.Ints(usage.positional)
+
+	reference := func(name, statement string) {
+		if _, ok := usage.references[name]; !ok {
+			usage.references[name] = statement
+		}
+	}
+	for _, match := range jinjaForPattern.FindAllStringSubmatch(text, -1) {
+		usage.locals[match[1]] = true
+		if match[2] != "" {
+			usage.locals[match[2]] = true
+		}
+		reference(variableRoot(match[3]), match[0])
+	}
+	for _, match := range jinjaSetPattern.FindAllStringSubmatch(text, -1) {
+		usage.locals[match[1]] = true
+	}
+
+	var open []int
+	for _, loc := range handlebarsBlockPattern.FindAllStringSubmatchIndex(text, -1) {
+		if loc[2] >= 0 {
+			reference(variableRoot(text[loc[4]:loc[5]]), text[loc[0]:loc[1]])
+			if helper := text[loc[2]:loc[3]]; helper == "each" || helper == "with" {
+				open = append(open, loc[1])
+			}
+			continue
+		}
+		if len(open) > 0 {
+			usage.itemScopes = append(usage.itemScopes, [2]int{open[len(open)-1], loc[0]})
+			open = open[:len(open)-1]
+		}
+	}
+	for _, start := range open {
+		usage.itemScopes = append(usage.itemScopes, [2]int{start, len(text)})
+	}
+	return usage
+}
+
+// formatFields returns the ranges of str.format replacement fields, escaped {{ and }} are skipped
+func formatFields(text string) [][2]int {
+	var fields [][2]int
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' {
+			continue
+		}
+		if strings.HasPrefix(text[i:], "{{") {
+			i++
+			continue
+		}
+		end := strings.IndexAny(text[i+1:], "{}")
+		if end < 0 || text[i+1+end] != '}' {
+			continue
+		}
+		if formatFieldPattern.MatchString(text[i+1 : i+1+end]) {
+			fields = append(fields, [2]int{i, i + end + 2})
+			i += end + 1
+		}
+	}
+	return fields
+}
+
+// templateVariablesAnalyzer checks placeholders against the variables and reports mixed placeholder syntaxes
+type templateVariablesAnalyzer struct{}
+
+func (templateVariablesAnalyzer) Name() string { return "Template Variables" }
+func (templateVariablesAnalyzer) Analyze(model *PromptModel) []Issue {
+	settings := Current()
+	templateVariables, templateVariablesFile := settings.Variables, settings.VariablesFile
+	usage := findTemplateUsage(model)
+	var issues []Issue
+
+	// In str.format templates {{name}} is an escaped literal, not a placeholder
+	var placeholders []Placeholder
+	formatStyle := model.PlaceholderSyntax() == "{}"
+	for _, placeholder := range model.Placeholders {
+		if !formatStyle || placeholder.Syntax != "{{}}" {
+			placeholders = append(placeholders, placeholder)
+		}
+	}
+
+	// Template engines render one syntax, placeholders of the others reach the model verbatim
+	syntaxes := map[string]Placeholder{}
+	var order []string
+	for _, placeholder := range placeholders {
+		if _, ok := syntaxes[placeholder.Syntax]; !ok {
+			syntaxes[placeholder.Syntax] = placeholder
+			order = append(order, placeholder.Syntax)
+		}
+	}
+	for _, offset := range usage.positional {
+		if model.Text[offset] == '%' && len(order) > 0 {
+			syntaxes["%s"] = Placeholder{Syntax: "%s", Start: model.Position(offset)}
+			order = append(order, "%s")
+			break
+		}
+	}
+	if len(order) > 1 {
+		var examples []string
+		for _, syntax := range order {
+			examples = append(examples, placeholderExample(model, syntaxes[syntax]))
+		}
+		second := syntaxes[order[1]]
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("Placeholders mix %d syntaxes: %s", len(order), strings.Join(examples, ", ")),
+			Reason:          "A template engine substitutes only its own syntax, the other placeholders reach the model as literal text.",
+			Fix:             "Use the syntax of your template engine for all placeholders, `promptlint fmt --placeholders` rewrites them.",
+			OriginalSnippet: strings.TrimSpace(model.Lines[second.Start.Line-1]),
+			Line:            second.Start.Line,
+			Column:          second.Start.Column,
+		})
+	}
+
+	if templateVariables == nil {
+		return issues
+	}
+
+	// Undefined variables are reported once at their first use
+	used := map[string]bool{}
+	for name := range usage.references {
+		used[name] = true
+	}
+	reported := map[string]bool{}
+	for _, placeholder := range placeholders {
+		root := variableRoot(placeholder.Name)
+		if TemplateKeywords[placeholder.Name] || usage.locals[root] || usage.inItemScope(placeholder.Start.Offset) {
+			continue
+		}
+		used[root] = true
+		if lookupVariable(templateVariables, placeholder.Name) || reported[placeholder.Name] {
+			continue
+		}
+		reported[placeholder.Name] = true
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("Template variable %s is not defined in %s", placeholder.Name, templateVariablesFile),
+			Reason:          "An undefined variable renders as an empty string, the literal placeholder or a template error, depending on the engine.",
+			Fix:             fmt.Sprintf("Fix the name or define %s in the variables of the prompt.", placeholder.Name),
+			Severity:        "error",
+			OriginalSnippet: placeholderExample(model, placeholder),
+			Line:            placeholder.Start.Line,
+			Column:          placeholder.Start.Column,
+		})
+	}
+	references := make([]string, 0, len(usage.references))
+	for name := range usage.references {
+		references = append(references, name)
+	}
+	sort.Strings(references)
+	for _, name := range references {
+		if usage.locals[name] || lookupVariable(templateVariables, name) || reported[name] {
+			continue
+		}
+		reported[name] = true
+		statement := usage.references[name]
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("Template variable %s is not defined in %s", name, templateVariablesFile),
+			Reason:          "An undefined variable renders as an empty string, the literal placeholder or a template error, depending on the engine.",
+			Fix:             fmt.Sprintf("Fix the name or define %s in the variables of the prompt.", name),
+			Severity:        "error",
+			OriginalSnippet: statement,
+			Line:            model.LineOf(statement),
+		})
+	}
+
+	if len(usage.positional) > 0 {
+		position := model.Position(usage.positional[0])
+		snippet := positionalSnippet(model.Text, usage.positional[0])
+		description := fmt.Sprintf("Positional placeholder %s can't be checked against %s", snippet, templateVariablesFile)
+		if len(usage.positional) > 1 {
+			description = fmt.Sprintf("%d positional placeholders like %s can't be checked against %s", len(usage.positional), snippet, templateVariablesFile)
+		}
+		issues = append(issues, Issue{
+			Description:     description,
+			Reason:          "Positional placeholders depend on the order of arguments, swapping two of them silently swaps the values.",
+			Fix:             "Use named placeholders like {{name}} or %(name)s.",
+			OriginalSnippet: snippet,
+			Line:            position.Line,
+			Column:          position.Column,
+		})
+	}
+
+	names := make([]string, 0, len(templateVariables))
+	for name := range templateVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var unused []string
+	for _, name := range names {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		issues = append(issues, Issue{
+			Description: fmt.Sprintf("Variables of %s are not used by the prompt: %s", templateVariablesFile, strings.Join(unused, ", ")),
+			Reason:      "An unused variable usually means a misspelled or deleted placeholder, the value never reaches the model.",
+			Fix:         "Add the placeholders for the variables or remove them from the variables file.",
+		})
+	}
+	return issues
+}
+
+// positionalSnippet returns the printf directive or format field at the offset
+func positionalSnippet(text string, offset int) string {
+	if text[offset] == '{' {
+		return text[offset : offset+strings.IndexByte(text[offset:], '}')+1]
+	}
+	return printfPattern.FindString(text[offset:])
+}
+
+// placeholderExample returns the placeholder as written in the prompt
+func placeholderExample(model *PromptModel, placeholder Placeholder) string {
+	if placeholder.Syntax == "%s" {
+		return positionalSnippet(model.Text, placeholder.Start.Offset)
+	}
+	return model.Text[placeholder.Start.Offset:placeholder.End.Offset]
+}
+
+// unescapedBracesAnalyzer reports placeholders with unbalanced braces and, in str.format templates,
+// literal braces that are not doubled
+type unescapedBracesAnalyzer struct{}
+
+func (unescapedBracesAnalyzer) Name() string { return "Unescaped Braces" }
+func (unescapedBracesAnalyzer) Analyze(model *PromptModel) []Issue {
+	var issues []Issue
+	reported := map[int]bool{}
+	add := func(offset int, snippet, description, reason, fix string) {
+		position := model.Position(offset)
+		reported[position.Line] = true
+		issues = append(issues, Issue{
+			Description:     description,
+			Reason:          reason,
+			Fix:             fix,
+			Severity:        "error",
+			OriginalSnippet: snippet,
+			Line:            position.Line,
+			Column:          position.Column,
+		})
+	}
+
+	for _, pattern := range malformedPlaceholderPatterns {
+		for _, loc := range pattern.FindAllStringIndex(model.Text, -1) {
+			start := loc[0] + strings.IndexAny(model.Text[loc[0]:loc[1]], "{$")
+			snippet := strings.TrimRight(model.Text[start:loc[1]], " \t\n.,;:!?)")
+			add(start, snippet, fmt.Sprintf("Placeholder %s has unbalanced braces", snippet),
+				"The template engine doesn't recognize the placeholder, the model gets the braces and the name instead of the value.",
+				"Balance the braces of the placeholder.")
+		}
+	}
+
+	// str.format fails on single braces that don't start or end a replacement field
+	if model.PlaceholderSyntax() != "{}" {
+		sort.Slice(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+		return issues
+	}
+	fields := formatFields(model.Text)
+	inField := func(offset int) bool {
+		for _, field := range fields {
+			if offset >= field[0] && offset < field[1] {
+				return true
+			}
+		}
+		return false
+	}
+	text := model.Text
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' && text[i] != '}' {
+			continue
+		}
+		if i+1 < len(text) && text[i+1] == text[i] {
+			i++
+			continue
+		}
+		// One literal brace per line is enough to point at a JSON example or a code block
+		if inField(i) || reported[model.Position(i).Line] {
+			continue
+		}
+		end := i + 1
+		for end < len(text) && (end-i < 40 || !utf8.RuneStart(text[end])) && text[end] != '\n' {
+			end++
+		}
+		add(i, strings.TrimSpace(text[i:end]), fmt.Sprintf("Literal %q in a template with {name} placeholders is not escaped", text[i]),
+			"str.format and f-strings treat single braces as replacement fields, the prompt fails to render with a KeyError or ValueError.",
+			"Double literal braces ({{ and }}), for example in JSON examples, or switch to {{name}} placeholders.")
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(templateVariablesAnalyzer{})
+	linter.RegisterAnalyzer(unescapedBracesAnalyzer{})
+}
@@ -0,0 +1,121 @@
+package analyzers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// setTemplateVariables sets the variables the prompts are rendered with for the test
+func setTemplateVariables(t *testing.T, variables map[string]interface{}) {
+	t.Helper()
+	Configure(Settings{Variables: variables, VariablesFile: "vars.yaml"})
+	t.Cleanup(func() { Configure(Settings{}) })
+}
+
+func TestTemplateVariablesAnalyzer(t *testing.T) {
+	tests := []struct {
+		name      string
+		prompt    string
+		variables map[string]interface{}
+		want      []Issue
+	}{
+		{
+			name:      "undefined variable is reported as written",
+			prompt:    "Greet the user.\nHello {{ missing }} and {{ name }}.",
+			variables: map[string]interface{}{"name": "Ann"},
+			want:      []Issue{{Description: "Template variable missing is not defined in vars.yaml", Severity: "error", OriginalSnippet: "{{ missing }}", Line: 2, Column: 7}},
+		},
+		{
+			name:      "undefined variable is reported once",
+			prompt:    "Use ${topic}, then ${topic} again.",
+			variables: map[string]interface{}{},
+			want:      []Issue{{Description: "Template variable topic is not defined in vars.yaml", Severity: "error", OriginalSnippet: "${topic}", Line: 1, Column: 5}},
+		},
+		{
+			name:      "nested variable",
+			prompt:    "Hello {{user.name}}, your plan is {{user.plan}}.",
+			variables: map[string]interface{}{"user": map[string]interface{}{"name": "Ann"}},
+			want:      []Issue{{Description: "Template variable user.plan is not defined in vars.yaml", Severity: "error", OriginalSnippet: "{{user.plan}}", Line: 1, Column: 35}},
+		},
+		{
+			name:      "unused variables",
+			prompt:    "Hello {{name}}.",
+			variables: map[string]interface{}{"name": "Ann", "role": "admin", "age": 42},
+			want:      []Issue{{Description: "Variables of vars.yaml are not used by the prompt: age, role"}},
+		},
+		{
+			name:      "loop variables are local",
+			prompt:    "{% for item in items %}- {{ item.title }}\n{% endfor %}",
+			variables: map[string]interface{}{"items": []interface{}{map[string]interface{}{"title": "a"}}},
+		},
+		{
+			name:      "each blocks refer to the item",
+			prompt:    "{{#each users}}- {{name}}\n{{/each}}",
+			variables: map[string]interface{}{"users": []interface{}{map[string]interface{}{"name": "Ann"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTemplateVariables(t, tt.variables)
+			got := templateVariablesAnalyzer{}.Analyze(linter.ParsePrompt(tt.prompt))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d issues, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				issue := got[i]
+				if issue.Description != want.Description || issue.Severity != want.Severity || issue.OriginalSnippet != want.OriginalSnippet ||
+					issue.Line != want.Line || issue.Column != want.Column {
+					t.Errorf("issue %d = %q %s %q at %d:%d, want %q %s %q at %d:%d", i,
+						issue.Description, issue.Severity, issue.OriginalSnippet, issue.Line, issue.Column,
+						want.Description, want.Severity, want.OriginalSnippet, want.Line, want.Column)
+				}
+			}
+		})
+	}
+}
+
+func TestMixedPlaceholderSyntaxes(t *testing.T) {
+	issues := templateVariablesAnalyzer{}.Analyze(linter.ParsePrompt("Write about {{ topic }}\nfor ${audience}."))
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1: %+v", len(issues), issues)
+	}
+	if want := "Placeholders mix 2 syntaxes: {{ topic }}, ${audience}"; issues[0].Description != want {
+		t.Errorf("description = %q, want %q", issues[0].Description, want)
+	}
+	if issues[0].Line != 2 {
+		t.Errorf("line = %d, want 2", issues[0].Line)
+	}
+}
+
+func TestUnescapedBracesAnalyzer(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		want   []string
+	}{
+		{"closing brace missing", "Hello {{name}!", []string{"{{name}"}},
+		{"opening brace missing", "Hello {name}}!", []string{"{name}}"}},
+		{"dollar placeholder not closed", "Hello ${name and welcome.", []string{"${name"}},
+		{"balanced placeholders", "Hello {{name}} and ${place}.", nil},
+		{"literal brace in a str.format template", "Hello {name}.\nAnswer with {\"ok\": true}", []string{`{"ok": true}`}},
+		{"doubled braces in a str.format template", "Hello {name}.\nAnswer with {{\"ok\": true}}", nil},
+		{"literal braces without str.format placeholders", "Answer with {\"ok\": true}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := unescapedBracesAnalyzer{}.Analyze(linter.ParsePrompt(tt.prompt))
+			var got []string
+			for _, issue := range issues {
+				if issue.Severity != "error" {
+					t.Errorf("severity of %q = %q, want error", issue.OriginalSnippet, issue.Severity)
+				}
+				got = append(got, issue.OriginalSnippet)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("snippets = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
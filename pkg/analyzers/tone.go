@@ -0,0 +1,163 @@
+package analyzers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// BrandVoiceRuleName is the name of the LLM rule generated from the configured brand voice
+const BrandVoiceRuleName = "Match Brand Voice"
+
+// BrandVoiceRule builds the rule the LLM judges the tone of the prompt with
+func BrandVoiceRule(voice string) rules.Rule {
+	return rules.Rule{
+		Name:   BrandVoiceRuleName,
+		Rule:   "The tone of the prompt must be consistent with the brand voice: " + voice,
+		Reason: "The model mirrors the tone of its instructions, so a prompt written in another voice produces off-brand responses.",
+		Fix:    "Rewrite the snippet in the brand voice without changing its meaning.",
+	}
+}
+
+// profanityWords maps profane words to neutral replacements, "" removes an intensifier
+var profanityWords = map[string]string{
+	"the hell": "",
+	"fuck":     "",
+	"fucking":  "",
+	"freaking": "",
+	"damn":     "",
+	"damned":   "",
+	"goddamn":  "",
+	"bloody":   "",
+	"shit":     "nonsense",
+	"shitty":   "poor",
+	"bullshit": "nonsense",
+	"crap":     "nonsense",
+	"crappy":   "poor",
+	"wtf":      "",
+	"pissed":   "annoyed",
+}
+
+var (
+	profanityPattern   = WordsPattern(profanityKeys())
+	insultPattern      = regexp.MustCompile(`(?i)\b(?:stupid|idiot|idiotic|dumb|moron|pathetic|incompetent)\b`)
+	threatPattern      = regexp.MustCompile(`(?i),?\s*\b(?:or else|you will be (?:fired|punished|penalized|shut down|deleted)|i will (?:fire|punish|delete) you)\b`)
+	shoutingPattern    = regexp.MustCompile(`\b[A-Z]{3,}(?:[ ,]+[A-Z]{3,}){2,}\b`)
+	exclamationPattern = regexp.MustCompile(`!{2,}`)
+	looseSpacePattern  = regexp.MustCompile(` +([.,;:!?])`)
+)
+
+// profanityKeys returns profane words, longer first so alternations prefer them
+func profanityKeys() []string {
+	keys := make([]string, 0, len(profanityWords))
+	for key := range profanityWords {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// WordsPattern compiles a case-insensitive pattern matching any of the whole words
+func WordsPattern(words []string) *regexp.Regexp {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// matchCase capitalizes the replacement when the original word is capitalized
+func matchCase(original, replacement string) string {
+	if replacement == "" || !unicode.IsUpper([]rune(original)[0]) {
+		return replacement
+	}
+	return strings.ToUpper(replacement[:1]) + replacement[1:]
+}
+
+// toneCheck finds a kind of tone problem and rewrites it
+type toneCheck struct {
+	kind    string
+	pattern *regexp.Regexp
+	rewrite func(match string) string
+}
+
+// toneChecks returns the checks of the tone analyzer in reporting order
+func toneChecks(words *regexp.Regexp) []toneCheck {
+	remove := func(string) string { return "" }
+	checks := []toneCheck{
+		{"profanity", profanityPattern, func(match string) string {
+			return matchCase(match, profanityWords[strings.ToLower(match)])
+		}},
+		{"insult", insultPattern, remove},
+		{"threat", threatPattern, remove},
+		{"shouting", shoutingPattern, strings.ToLower},
+		{"repeated exclamation marks", exclamationPattern, func(string) string { return "!" }},
+	}
+	if words != nil {
+		// Configured words have no neutral replacement, the LLM review of the brand voice suggests one
+		checks = append(checks, toneCheck{"word outside the brand voice", words, func(match string) string { return match }})
+	}
+	return checks
+}
+
+// toneAnalyzer reports profanity, insults, threats and shouting with rewrites of the affected lines
+type toneAnalyzer struct{}
+
+func (toneAnalyzer) Name() string { return "Tone" }
+func (toneAnalyzer) Analyze(model *PromptModel) []Issue {
+	var issues []Issue
+	inFence := model.FenceLines()
+	checks := toneChecks(Current().ToneWords)
+	for i, line := range model.Lines {
+		if inFence[i+1] {
+			continue
+		}
+
+		var found []string
+		column := 0
+		fixed := line
+		for _, check := range checks {
+			for _, loc := range check.pattern.FindAllStringIndex(line, -1) {
+				match := strings.TrimLeft(line[loc[0]:loc[1]], ", ")
+				position := model.Position(model.LineOffset(i) + loc[1] - len(match))
+				if column == 0 || position.Column < column {
+					column = position.Column
+				}
+				found = append(found, fmt.Sprintf("%s %q at %d:%d", check.kind, match, position.Line, position.Column))
+			}
+			fixed = check.pattern.ReplaceAllStringFunc(fixed, check.rewrite)
+		}
+		if len(found) == 0 {
+			continue
+		}
+		fixed = strings.TrimRight(CollapseSpaces(looseSpacePattern.ReplaceAllString(fixed, "$1")), " ")
+		if fixed == line {
+			fixed = ""
+		}
+
+		issues = append(issues, Issue{
+			Description:     "Tone: " + strings.Join(found, ", "),
+			Reason:          "Profanity, insults, threats and shouting don't make the model more accurate, they set a hostile tone it may copy and can trigger safety refusals.",
+			Fix:             "State the requirement calmly and explain why it matters. Configure tone.voice in " + configFileName + " for an LLM check of the brand voice.",
+			OriginalSnippet: line,
+			FixedSnippet:    fixed,
+			Line:            i + 1,
+			Column:          column,
+		})
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(toneAnalyzer{})
+}
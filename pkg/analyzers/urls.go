@@ -0,0 +1,191 @@
+package analyzers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+var (
+	// URLPattern matches http and https links
+	URLPattern = regexp.MustCompile("https?://[^\\s<>()\"'`\\]\\[]+")
+	// referenceInstructionPattern matches instructions to read the content behind a link
+	referenceInstructionPattern = regexp.MustCompile(`(?i)\b(?:read|see|refer to|consult|follow|check|visit|open|browse|based on|according to|described (?:at|in)|documented (?:at|in))\b`)
+)
+
+// DefaultURLTimeout is the timeout of a link check when Settings.URLTimeout is 0
+const DefaultURLTimeout = 5 * time.Second
+
+// maxURLChecks is the number of concurrent URL checks
+const maxURLChecks = 4
+
+// promptURL is a link found in the prompt
+type promptURL struct {
+	URL      string
+	Position Position
+	Line     string
+}
+
+// findURLs extracts links outside code fences, trailing punctuation is not part of the link
+func findURLs(model *PromptModel) []promptURL {
+	inFence := model.FenceLines()
+	var urls []promptURL
+	for i, line := range model.Lines {
+		if inFence[i+1] {
+			continue
+		}
+		for _, loc := range URLPattern.FindAllStringIndex(line, -1) {
+			url := strings.TrimRight(line[loc[0]:loc[1]], ".,;:!?")
+			urls = append(urls, promptURL{URL: url, Position: model.Position(model.LineOffset(i) + loc[0]), Line: line})
+		}
+	}
+	return urls
+}
+
+// urlStatus is the result of a liveness check, Err is set when the server didn't answer
+type urlStatus struct {
+	Code int
+	Err  error
+}
+
+// checkURL requests the headers of the link, servers that don't support HEAD are asked with GET
+func checkURL(ctx context.Context, client *http.Client, url, userAgent string) urlStatus {
+	var status urlStatus
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return urlStatus{Err: err}
+		}
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := client.Do(req)
+		if err != nil {
+			return urlStatus{Err: err}
+		}
+		resp.Body.Close()
+		status = urlStatus{Code: resp.StatusCode}
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+	return status
+}
+
+// checkURLsLiveness checks unique links concurrently
+func checkURLsLiveness(urls []promptURL, settings Settings) map[string]urlStatus {
+	var unique []string
+	seen := map[string]bool{}
+	for _, u := range urls {
+		if !seen[u.URL] {
+			seen[u.URL] = true
+			unique = append(unique, u.URL)
+		}
+	}
+
+	timeout, userAgent := settings.URLTimeout, settings.UserAgent
+	if timeout == 0 {
+		timeout = DefaultURLTimeout
+	}
+	if userAgent == "" {
+		userAgent = "promptlint"
+	}
+	client := &http.Client{Timeout: timeout}
+	results := map[string]urlStatus{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxURLChecks)
+	for _, url := range unique {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			status := checkURL(context.Background(), client, url, userAgent)
+			mu.Lock()
+			results[url] = status
+			mu.Unlock()
+		}(url)
+	}
+	wg.Wait()
+	return results
+}
+
+// isOffline reports whether every check failed to reach the network, in that case no link is reported as dead
+func isOffline(results map[string]urlStatus) bool {
+	for _, status := range results {
+		var dnsErr *net.DNSError
+		if status.Err == nil || (errors.As(status.Err, &dnsErr) && dnsErr.IsNotFound) {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// urlAnalyzer reports links the model is told to read and, with CheckURLs, dead links
+type urlAnalyzer struct{}
+
+func (urlAnalyzer) Name() string { return "URL References" }
+func (urlAnalyzer) Analyze(model *PromptModel) []Issue {
+	urls := findURLs(model)
+	if len(urls) == 0 {
+		return nil
+	}
+
+	var issues []Issue
+	for _, u := range urls {
+		if !referenceInstructionPattern.MatchString(u.Line) {
+			continue
+		}
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("The prompt asks the model to use the content of %s", u.URL),
+			Reason:          "Models don't open links from the prompt unless a browsing tool is available, they guess the content from the address instead.",
+			Fix:             "Inline the relevant part of the referenced content, or a summary of it for long documents, and keep the link only as a source for the user.",
+			OriginalSnippet: u.Line,
+			Line:            u.Position.Line,
+			Column:          u.Position.Column,
+		})
+	}
+
+	settings := Current()
+	if !settings.CheckURLs {
+		return issues
+	}
+	settings.progress(fmt.Sprintf("Checking %d links", len(urls)))
+	results := checkURLsLiveness(urls, settings)
+	if isOffline(results) {
+		settings.progress("Network is unavailable, skipping link checks")
+		return issues
+	}
+	for _, u := range urls {
+		status := results[u.URL]
+		var problem string
+		switch {
+		case status.Err != nil:
+			problem = "doesn't respond: " + status.Err.Error()
+		case status.Code >= 400:
+			problem = fmt.Sprintf("returns HTTP %d", status.Code)
+		default:
+			continue
+		}
+		issues = append(issues, Issue{
+			Description:     fmt.Sprintf("Dead link %s %s", u.URL, problem),
+			Reason:          "Dead links in a prompt point the model and users to content that no longer exists.",
+			Fix:             "Update or remove the link, or inline the content it referred to.",
+			OriginalSnippet: u.Line,
+			Line:            u.Position.Line,
+			Column:          u.Position.Column,
+		})
+	}
+	return issues
+}
+
+func init() {
+	linter.RegisterAnalyzer(urlAnalyzer{})
+}
@@ -0,0 +1,75 @@
+package linter
+
+import (
+	"sort"
+	"strings"
+)
+
+// Analyzer is a static check that runs locally alongside the LLM review.
+// Issues returned by an analyzer get its name as the rule name when RuleName is empty.
+//
+// Analyzers register themselves in init, so importing their package is enough to run them:
+//
+//	type todoAnalyzer struct{}
+//
+//	func (todoAnalyzer) Name() string { return "No TODOs" }
+//
+//	func (todoAnalyzer) Analyze(model *linter.PromptModel) []linter.Issue {
+//		var issues []linter.Issue
+//		for i, line := range model.Lines {
+//			if strings.Contains(line, "TODO") {
+//				issues = append(issues, linter.Issue{Description: "Unfinished TODO", Line: i + 1, OriginalSnippet: line})
+//			}
+//		}
+//		return issues
+//	}
+//
+//	func init() {
+//		linter.RegisterAnalyzer(todoAnalyzer{})
+//	}
+type Analyzer interface {
+	Name() string
+	Analyze(model *PromptModel) []Issue
+}
+
+// analyzers contains registered analyzers by lowercase name
+var analyzers = map[string]Analyzer{}
+
+// RegisterAnalyzer adds a compiled-in analyzer, registering a name twice replaces the previous analyzer
+func RegisterAnalyzer(analyzer Analyzer) {
+	analyzers[strings.ToLower(analyzer.Name())] = analyzer
+}
+
+// AnalyzerNames returns sorted names of registered analyzers
+func AnalyzerNames() []string {
+	names := make([]string, 0, len(analyzers))
+	for _, analyzer := range analyzers {
+		names = append(names, analyzer.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupAnalyzer resolves an analyzer name case-insensitively
+func LookupAnalyzer(name string) (Analyzer, bool) {
+	analyzer, ok := analyzers[strings.ToLower(strings.TrimSpace(name))]
+	return analyzer, ok
+}
+
+// RunAnalyzers runs the registered analyzers in name order, skipping those whose lowercase name is disabled
+func RunAnalyzers(model *PromptModel, disabled map[string]bool) []Issue {
+	var issues []Issue
+	for _, name := range AnalyzerNames() {
+		if disabled[strings.ToLower(name)] {
+			continue
+		}
+		found := analyzers[strings.ToLower(name)].Analyze(model)
+		for i := range found {
+			if found[i].RuleName == "" {
+				found[i].RuleName = name
+			}
+		}
+		issues = append(issues, found...)
+	}
+	return issues
+}
@@ -0,0 +1,151 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// heuristicCheck detects violations of a rule from text features, it returns descriptions and snippets of found issues
+type heuristicCheck func(text string, stats textStats) []heuristicFinding
+
+// heuristicFinding is an issue found by a heuristic check
+type heuristicFinding struct {
+	Description string
+	Snippet     string
+}
+
+// textStats contains statistical features of a prompt
+type textStats struct {
+	Words         int
+	Sentences     []string
+	AvgSentence   float64
+	HasStructure  bool
+	FirstSentence string
+}
+
+// NegativePattern matches negative instructions like "don't" or "never"
+var NegativePattern = regexp.MustCompile(`(?i)\b(don't|do not|never|avoid|must not|shouldn't|should not)\b`)
+
+var (
+	sentenceSplitPattern = regexp.MustCompile(`[^.!?\n]+[.!?]?`)
+	personaPattern       = regexp.MustCompile(`(?i)\b(you are|you're|act as|acting as|your role|as an? [a-z]+ (expert|engineer|developer|assistant|specialist|analyst|writer|editor|teacher))\b`)
+	vagueWordsPattern    = regexp.MustCompile(`(?i)\b(fast|quickly|good|nice|better|best|some|etc|stuff|things|properly|appropriate|appropriately|as needed|somehow|various|a lot)\b`)
+	examplePattern       = regexp.MustCompile(`(?i)(\bexamples?\b|\be\.g\.|\bfor instance\b|\binput:|\boutput:|\bsample\b)`)
+	structurePattern     = regexp.MustCompile(`(?m)(^#{1,6} |^\s*[-*] |^\s*\d+[.)] |^###|"""|^<[a-zA-Z_-]+>|^[A-Z][A-Za-z ]{2,30}:\s*$)`)
+	edgeCasePattern      = regexp.MustCompile(`(?i)(edge case|corner case|\bif\b.*\b(empty|missing|invalid|unknown|unclear|fails?)\b|\botherwise\b|\bexception|\berror)`)
+	stepsPattern         = regexp.MustCompile(`(?i)(step[- ]by[- ]step|\bsteps?\b|\bfirst\b|\bthen\b|\bfinally\b|(?m)^\s*\d+[.)] )`)
+)
+
+// heuristicChecks maps rule names to their heuristic approximations
+var heuristicChecks = map[string]heuristicCheck{
+	"assign persona": func(text string, stats textStats) []heuristicFinding {
+		if personaPattern.MatchString(text) {
+			return nil
+		}
+		return []heuristicFinding{{Description: "The prompt does not define a role or persona for the model", Snippet: stats.FirstSentence}}
+	},
+	"be specific and clear": func(text string, stats textStats) []heuristicFinding {
+		var findings []heuristicFinding
+		for _, sentence := range stats.Sentences {
+			if word := vagueWordsPattern.FindString(sentence); word != "" {
+				findings = append(findings, heuristicFinding{Description: fmt.Sprintf("Vague wording %q without a measurable criterion", word), Snippet: sentence})
+			}
+			if len(findings) == 3 {
+				break
+			}
+		}
+		if stats.AvgSentence > 35 {
+			findings = append(findings, heuristicFinding{Description: fmt.Sprintf("Sentences are long (%.0f words on average), which makes instructions ambiguous", stats.AvgSentence)})
+		}
+		return findings
+	},
+	"include examples": func(text string, stats textStats) []heuristicFinding {
+		if stats.Words < 40 || examplePattern.MatchString(text) {
+			return nil
+		}
+		return []heuristicFinding{{Description: "The prompt has no examples of the expected output"}}
+	},
+	"use positive instructions": func(text string, stats textStats) []heuristicFinding {
+		var findings []heuristicFinding
+		for _, sentence := range stats.Sentences {
+			if NegativePattern.MatchString(sentence) {
+				findings = append(findings, heuristicFinding{Description: "Negative instruction: states what not to do instead of what to do", Snippet: sentence})
+			}
+			if len(findings) == 3 {
+				break
+			}
+		}
+		return findings
+	},
+	"balance length": func(text string, stats textStats) []heuristicFinding {
+		switch {
+		case stats.Words < 8:
+			return []heuristicFinding{{Description: fmt.Sprintf("The prompt is very short (%d words) to describe the task", stats.Words), Snippet: strings.TrimSpace(text)}}
+		case stats.Words > 2000:
+			return []heuristicFinding{{Description: fmt.Sprintf("The prompt is very long (%d words)", stats.Words)}}
+		}
+		return nil
+	},
+	"structure complex prompts": func(text string, stats textStats) []heuristicFinding {
+		if stats.Words < 300 || stats.HasStructure {
+			return nil
+		}
+		return []heuristicFinding{{Description: fmt.Sprintf("A long prompt (%d words) has no sections, lists or delimiters", stats.Words)}}
+	},
+	"include edge cases": func(text string, stats textStats) []heuristicFinding {
+		if stats.Words < 50 || edgeCasePattern.MatchString(text) {
+			return nil
+		}
+		return []heuristicFinding{{Description: "The prompt does not say how to handle edge cases or invalid input"}}
+	},
+	"use step-by-step approach": func(text string, stats textStats) []heuristicFinding {
+		if stats.Words < 150 || stepsPattern.MatchString(text) {
+			return nil
+		}
+		return []heuristicFinding{{Description: "A complex task is not divided into steps"}}
+	},
+}
+
+// computeTextStats extracts statistical features of the text
+func computeTextStats(text string) textStats {
+	stats := textStats{Words: len(strings.Fields(text)), HasStructure: structurePattern.MatchString(text)}
+	for _, match := range sentenceSplitPattern.FindAllString(text, -1) {
+		if sentence := strings.TrimSpace(match); sentence != "" {
+			stats.Sentences = append(stats.Sentences, sentence)
+		}
+	}
+	if len(stats.Sentences) > 0 {
+		stats.FirstSentence = stats.Sentences[0]
+		stats.AvgSentence = float64(stats.Words) / float64(len(stats.Sentences))
+	}
+	return stats
+}
+
+// CheckHeuristics approximates the LLM review with local checks of the active rules, used by Check without an API key
+func CheckHeuristics(prompt string, ruleSet *rules.Rules, locale string) []Issue {
+	stats := computeTextStats(prompt)
+
+	var issues []Issue
+	for _, rule := range ruleSet.Active() {
+		check, ok := heuristicChecks[strings.ToLower(rule.Name)]
+		if !ok {
+			continue
+		}
+		for _, finding := range check(prompt, stats) {
+			issues = append(issues, Issue{
+				RuleName:        rule.Name,
+				Description:     finding.Description,
+				Reason:          rule.ReasonIn(locale),
+				Fix:             rule.FixIn(locale),
+				OriginalSnippet: finding.Snippet,
+			})
+		}
+	}
+
+	AttachRuleDetails(issues, ruleSet, locale)
+	AssignFingerprints(issues)
+	return issues
+}
@@ -0,0 +1,50 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultJudgePrompt is the version of the judge system message used when none is selected
+const DefaultJudgePrompt = "v1"
+
+// judgePrompts are the system messages of the judge by version. A released version never changes,
+// improvements are added as new versions so that results of a version stay comparable.
+var judgePrompts = map[string]string{
+	"v1": `You are a prompt evaluation expert. Your task is to analyze a prompt and determine if it follows the provided rules.
+
+Analyze the prompt against each rule and identify violations. The rules are provided in a separate message.
+
+Use the find_prompt_issues tool to return the issues found in the prompt. If there are no issues, return an empty array.`,
+
+	"v2": `You are a prompt evaluation expert. Your task is to analyze a prompt and determine if it follows the provided rules.
+
+The rules are provided in a separate message. Check the prompt against each rule in turn and report a violation only when the prompt clearly fails the rule as written, when in doubt don't report it. Judge the prompt by its apparent purpose: a rule that doesn't matter for that kind of prompt is not violated.
+
+Report every violation once. Quote the problematic part of the prompt exactly in originalSnippet and keep fixedSnippet a minimal change of it.
+
+Use the find_prompt_issues tool to return the issues found in the prompt. If there are no issues, return an empty array.`,
+}
+
+// JudgePrompts returns the known versions of the judge system message in order
+func JudgePrompts() []string {
+	versions := make([]string, 0, len(judgePrompts))
+	for version := range judgePrompts {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// JudgePrompt returns the system message of the version, DefaultJudgePrompt when the version is empty
+func JudgePrompt(version string) (string, error) {
+	if version == "" {
+		version = DefaultJudgePrompt
+	}
+	message, ok := judgePrompts[version]
+	if !ok {
+		return "", fmt.Errorf("unknown judge prompt %q, supported: %s", version, strings.Join(JudgePrompts(), ", "))
+	}
+	return message, nil
+}
@@ -0,0 +1,518 @@
+// Package linter checks prompts against rules with an LLM judge and the registered static analyzers.
+// Without an API key local heuristic checks approximate the judge.
+//
+// A minimal embedding, the blank import registers the built-in analyzers:
+//
+//	import _ "github.com/korchasa/promptlint/pkg/analyzers"
+//
+//	provider, _ := llm.Lookup("openai")
+//	config := &llm.Config{APIKey: key, APIEndpoint: provider.DefaultEndpoint(), ModelName: provider.DefaultModel(), Provider: provider}
+//	issues, err := linter.Lint(ctx, prompt, linter.Options{LLM: config})
+//
+// Lint and Check don't change their options and are safe for concurrent calls with the same options.
+package linter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// DefaultInstruction introduces the prompt in the request to the LLM API
+const DefaultInstruction = "Analyze the following prompt against the specified rules:"
+
+// builtin is the rule set of calls without rules, shared so that its description is built once
+var builtin struct {
+	once  sync.Once
+	rules *rules.Rules
+	err   error
+}
+
+// Issue represents a problem found during linting
+type Issue struct {
+	RuleName        string           `json:"rule"`
+	RuleText        string           `json:"ruleText,omitempty"`
+	RuleLink        string           `json:"ruleLink,omitempty"`
+	Description     string           `json:"description"`
+	Reason          string           `json:"reason"`
+	Fix             string           `json:"fix"`
+	OriginalSnippet string           `json:"originalSnippet,omitempty"`
+	FixedSnippet    string           `json:"fixedSnippet,omitempty"`
+	Alternatives    []FixAlternative `json:"alternatives,omitempty"`
+	Fingerprint     string           `json:"fingerprint,omitempty"`
+	Dismissed       bool             `json:"dismissed,omitempty"`
+	DismissReason   string           `json:"dismissReason,omitempty"`
+	Line            int              `json:"line,omitempty"`
+	Column          int              `json:"column,omitempty"`
+	// Severity is "error", "warning" or "info", LLM issues take it from their rule; empty counts as warning
+	Severity string `json:"severity,omitempty"`
+	// Category groups issues by the kind of problem, from the rule or analyzer: clarity, context, examples, ...
+	Category string `json:"category,omitempty"`
+	// Message is the 1-based index of the chat message the issue is in and Role its role, 0 for prompts
+	// without messages
+	Message int    `json:"message,omitempty"`
+	Role    string `json:"role,omitempty"`
+	// RuleAliases are names of deprecated rules replaced by the issue rule, dismissals recorded under them still apply
+	RuleAliases []string `json:"-"`
+}
+
+// FixAlternative represents an alternative fixed snippet with its trade-offs
+type FixAlternative struct {
+	Snippet string `json:"snippet"`
+	Pros    string `json:"pros"`
+	Cons    string `json:"cons"`
+	Rank    int    `json:"rank"`
+}
+
+// Options configure a Lint call
+type Options struct {
+	// Rules to check, the built-in rule set when nil. The rule set must not change after the first call,
+	// its description for the LLM is built once.
+	Rules *rules.Rules
+	// LLM is the judge configuration, read only, the served model is reported in the Result of Check.
+	// Without it, without an API key or with Heuristic set, local heuristic checks approximate the review.
+	LLM *llm.Config
+	// Engine checks the rules with the LLM judge ("llm", the default), with their patterns and length bounds
+	// ("static") or both
+	Engine string
+	// Instruction tells the model how to treat the content, DefaultInstruction when empty
+	Instruction string
+	// Locale selects translations of rule reasons and fixes, empty keeps the texts of the judge
+	Locale string
+	// JudgePrompt is the version of the judge system message, DefaultJudgePrompt when empty
+	JudgePrompt string
+	// Progress receives progress messages, nil discards them
+	Progress func(message string)
+	// DisabledAnalyzers are lowercase names of registered analyzers not to run
+	DisabledAnalyzers map[string]bool
+	// JudgeOnly returns the issues of the judge alone, without static rules, analyzers, lines and inline
+	// suppressions, for callers that run the local checks themselves
+	JudgeOnly bool
+}
+
+// Result is the outcome of a Check call
+type Result struct {
+	Issues []Issue
+	// ServedModel and SystemFingerprint identify the exact judge version when the API reports them
+	ServedModel       string
+	SystemFingerprint string
+	// Heuristic is true when local heuristic checks replaced the judge
+	Heuristic bool
+}
+
+// Lint checks the prompt against the rules with the LLM judge, the static rules of Engine and the registered
+// analyzers. Issues carry rule details, fingerprints and the lines of their snippets, issues suppressed by
+// promptlint-disable comments of the prompt are dropped. The built-in analyzers register when
+// github.com/korchasa/promptlint/pkg/analyzers is imported.
+func Lint(ctx context.Context, prompt string, opts Options) ([]Issue, error) {
+	result, err := Check(ctx, prompt, opts)
+	return result.Issues, err
+}
+
+// Check is Lint that also returns the judge version reported by the API
+func Check(ctx context.Context, prompt string, opts Options) (Result, error) {
+	var result Result
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string) {}
+	}
+	ruleSet, instruction, systemMessage, err := opts.resolve()
+	if err != nil {
+		return result, err
+	}
+	if err := checkEngine(opts.Engine); err != nil {
+		return result, err
+	}
+
+	// Suppression comments are instructions for the linter, not part of the prompt
+	content := StripSuppressions(prompt)
+	var issues []Issue
+	switch {
+	case opts.Engine == "static" || len(ruleSet.PromptRules) == 0:
+	case opts.LLM == nil || opts.LLM.Heuristic || opts.LLM.APIKey == "":
+		progress("Starting heuristic prompt validation (no LLM)")
+		issues = CheckHeuristics(content, ruleSet, opts.Locale)
+		result.Heuristic = true
+		progress(fmt.Sprintf("Found %d issues", len(issues)))
+	default:
+		if issues, err = opts.judge(ctx, &result, content, ruleSet, instruction, systemMessage, progress); err != nil {
+			return result, err
+		}
+	}
+	if opts.JudgeOnly {
+		result.Issues = issues
+		return result, nil
+	}
+
+	model := ParsePrompt(prompt)
+	if opts.Engine == "static" || opts.Engine == "both" {
+		issues = append(issues, CheckStaticRules(model, ruleSet, opts.Locale)...)
+	}
+	found := RunAnalyzers(model, opts.DisabledAnalyzers)
+	if len(found) > 0 {
+		AssignFingerprints(found)
+		progress(fmt.Sprintf("Static analyzers found %d issues", len(found)))
+	}
+	issues = append(issues, found...)
+	for i := range issues {
+		if issues[i].Line == 0 {
+			issues[i].Line = model.LineOf(issues[i].OriginalSnippet)
+		}
+	}
+	result.Issues = ApplySuppressions(issues, model)
+	return result, nil
+}
+
+// judge checks the content with the LLM, the served model is reported in the result
+func (opts Options) judge(ctx context.Context, result *Result, content string, ruleSet *rules.Rules, instruction, systemMessage string, progress func(string)) ([]Issue, error) {
+	progress("Starting LLM-based prompt validation")
+
+	// The API reports the served model into the configuration, every call sends with its own copy
+	config := *opts.LLM
+	if config.APIEndpoint == "" {
+		return nil, fmt.Errorf("API endpoint is missing, set PROMPTLINT_API_ENDPOINT")
+	}
+	if config.Provider == nil {
+		return nil, fmt.Errorf("LLM provider is missing, supported: %s", strings.Join(llm.Names(), ", "))
+	}
+	config.Context = ctx
+
+	progress("Sending request to LLM API")
+	response, err := llm.Send(&config, newRequest(systemMessage, instruction, content, ruleSet, config.Alternatives))
+	result.ServedModel, result.SystemFingerprint = config.ServedModel, config.SystemFingerprint
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := parseIssues(response, progress)
+	if err != nil {
+		return nil, err
+	}
+	AttachRuleDetails(issues, ruleSet, opts.Locale)
+	AssignFingerprints(issues)
+	progress("Validation completed")
+	return issues, nil
+}
+
+// resolve returns the rule set, the instruction and the judge system message of the options with their defaults
+func (opts Options) resolve() (*rules.Rules, string, string, error) {
+	ruleSet := opts.Rules
+	if ruleSet == nil {
+		builtin.once.Do(func() { builtin.rules, builtin.err = rules.Embedded() })
+		if builtin.err != nil {
+			return nil, "", "", builtin.err
+		}
+		ruleSet = builtin.rules
+	}
+	instruction := opts.Instruction
+	if instruction == "" {
+		instruction = DefaultInstruction
+	}
+	systemMessage, err := JudgePrompt(opts.JudgePrompt)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return ruleSet, instruction, systemMessage, nil
+}
+
+// RequestMessages returns the texts Lint sends for the prompt: the judge system message, the rules description,
+// the instruction with the prompt and the tool definition as JSON, e.g. to count the tokens of a call
+func RequestMessages(prompt string, opts Options) ([]string, error) {
+	ruleSet, instruction, systemMessage, err := opts.resolve()
+	if err != nil {
+		return nil, err
+	}
+	alternatives := 0
+	if opts.LLM != nil {
+		alternatives = opts.LLM.Alternatives
+	}
+	request := newRequest(systemMessage, instruction, prompt, ruleSet, alternatives)
+	tool, err := json.Marshal(request.Tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the tool definition: %w", err)
+	}
+	return append(append([]string{request.System}, request.Messages...), string(tool)), nil
+}
+
+// newRequest builds the request with the judge system message, the rules description and the find_prompt_issues tool
+func newRequest(systemMessage, instruction, content string, ruleSet *rules.Rules, alternatives int) llm.ToolRequest {
+	issueProperties := map[string]interface{}{
+		"name": map[string]interface{}{
+			"type":        "string",
+			"description": "Name of the violated rule",
+		},
+		"description": map[string]interface{}{
+			"type":        "string",
+			"description": "Description of the problem",
+		},
+		"reason": map[string]interface{}{
+			"type":        "string",
+			"description": "Why this is a problem (from the rules)",
+		},
+		"fix": map[string]interface{}{
+			"type":        "string",
+			"description": "Recommendation for fixing",
+		},
+		"originalSnippet": map[string]interface{}{
+			"type":        "string",
+			"description": "Problematic part of the prompt (if applicable)",
+		},
+		"fixedSnippet": map[string]interface{}{
+			"type":        "string",
+			"description": "Improved version of the snippet (if applicable)",
+		},
+	}
+	issueRequired := []string{"name", "description", "reason", "fix", "originalSnippet", "fixedSnippet"}
+
+	// Request alternative fixes with trade-offs and ranking if enabled
+	if alternatives > 0 {
+		systemMessage += fmt.Sprintf("\n\nFor each issue also provide %d alternative fixed snippets that differ from fixedSnippet, with their pros and cons, ranked from the best (1) to the worst.", alternatives)
+		issueProperties["alternatives"] = map[string]interface{}{
+			"type":        "array",
+			"description": "Alternative fixed snippets ranked from the best to the worst",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"snippet": map[string]interface{}{
+						"type":        "string",
+						"description": "Alternative improved version of the snippet",
+					},
+					"pros": map[string]interface{}{
+						"type":        "string",
+						"description": "Advantages of this alternative",
+					},
+					"cons": map[string]interface{}{
+						"type":        "string",
+						"description": "Disadvantages of this alternative",
+					},
+					"rank": map[string]interface{}{
+						"type":        "integer",
+						"description": "Rank of this alternative, 1 is the best",
+					},
+				},
+				"required": []string{"snippet", "pros", "cons", "rank"},
+			},
+		}
+		issueRequired = append(issueRequired, "alternatives")
+	}
+
+	// Define a tool for finding prompt issues
+	return llm.ToolRequest{
+		System:   systemMessage,
+		Messages: []string{describe(ruleSet), instruction + "\n\n" + content},
+		Tool: llm.ToolSpec{
+			Name:        "find_prompt_issues",
+			Description: "Reports issues found in a prompt based on predefined rules",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"issues": map[string]interface{}{
+						"type":        "array",
+						"description": "List of issues found in the prompt",
+						"items": map[string]interface{}{
+							"type":       "object",
+							"properties": issueProperties,
+							"required":   issueRequired,
+						},
+					},
+				},
+				"required": []string{"issues"},
+			},
+		},
+	}
+}
+
+// maxDescriptions limits the rule sets whose descriptions are cached, servers create rule sets per request
+const maxDescriptions = 256
+
+// descriptions caches the rules descriptions by rule set, rule sets are not changed once they are linted with.
+// The cache is reset when it is full, so rule sets of finished requests and reloads don't stay in memory.
+var descriptions = struct {
+	sync.Mutex
+	entries map[*rules.Rules]string
+}{entries: map[*rules.Rules]string{}}
+
+// describe formats the active rules as text for the LLM, once per rule set
+func describe(ruleSet *rules.Rules) string {
+	descriptions.Lock()
+	description, ok := descriptions.entries[ruleSet]
+	descriptions.Unlock()
+	if ok {
+		return description
+	}
+
+	var rulesDescription strings.Builder
+	rulesDescription.WriteString("List of prompt checking rules:\n\n")
+
+	for i, rule := range ruleSet.Active() {
+		rulesDescription.WriteString(fmt.Sprintf("%d. Rule: %s\n", i+1, rule.Name))
+		rulesDescription.WriteString(fmt.Sprintf("   Description: %s\n", rule.Rule))
+		rulesDescription.WriteString(fmt.Sprintf("   Reason: %s\n", rule.Reason))
+		if rule.BadExample != "" {
+			rulesDescription.WriteString(fmt.Sprintf("   Original snippet: %s\n", rule.BadExample))
+		}
+		if rule.GoodExample != "" {
+			rulesDescription.WriteString(fmt.Sprintf("   Fixed snippet: %s\n", rule.GoodExample))
+		}
+		rulesDescription.WriteString("\n")
+	}
+
+	description = rulesDescription.String()
+	descriptions.Lock()
+	defer descriptions.Unlock()
+	if len(descriptions.entries) >= maxDescriptions {
+		descriptions.entries = map[*rules.Rules]string{}
+	}
+	descriptions.entries[ruleSet] = description
+	return description
+}
+
+// parseIssues extracts issues from the tool calls or, without them, from a JSON array in the text
+func parseIssues(response llm.ToolResponse, progress func(string)) ([]Issue, error) {
+	var issues []Issue
+	if len(response.Calls) > 0 {
+		for _, call := range response.Calls {
+			// Extract issues from the tool response
+			if issuesData, ok := call.Arguments["issues"].([]interface{}); ok {
+				progress(fmt.Sprintf("Found %d issues", len(issuesData)))
+				for _, issueData := range issuesData {
+					if issueMap, ok := issueData.(map[string]interface{}); ok {
+						issue := Issue{
+							RuleName:        stringValue(issueMap, "name"),
+							Description:     stringValue(issueMap, "description"),
+							Reason:          stringValue(issueMap, "reason"),
+							Fix:             stringValue(issueMap, "fix"),
+							OriginalSnippet: stringValue(issueMap, "originalSnippet"),
+							FixedSnippet:    stringValue(issueMap, "fixedSnippet"),
+							Alternatives:    alternativesValue(issueMap),
+						}
+						issues = append(issues, issue)
+					}
+				}
+			}
+		}
+	} else if response.Text != "" {
+		progress("No tool calls found in response, trying legacy format")
+		// Fallback to content-based response (older model or API version)
+		content := response.Text
+		var legacyIssues []map[string]string
+		// Try to parse JSON array from the content
+		jsonStartIdx := strings.Index(content, "[")
+		jsonEndIdx := strings.LastIndex(content, "]")
+
+		if jsonStartIdx >= 0 && jsonEndIdx > jsonStartIdx {
+			jsonContent := content[jsonStartIdx : jsonEndIdx+1]
+			if err := json.Unmarshal([]byte(jsonContent), &legacyIssues); err != nil {
+				return nil, fmt.Errorf("error parsing legacy response: %w", err)
+			}
+		} else {
+			// Try to parse the entire content
+			if err := json.Unmarshal([]byte(content), &legacyIssues); err != nil {
+				return nil, fmt.Errorf("failed to parse legacy response as JSON: %w\nResponse: %s", err, content)
+			}
+		}
+
+		// Convert legacy format to Issue structure
+		for _, issueMap := range legacyIssues {
+			issue := Issue{
+				RuleName:        issueMap["name"],
+				Description:     issueMap["description"],
+				Reason:          issueMap["reason"],
+				Fix:             issueMap["fix"],
+				OriginalSnippet: issueMap["originalSnippet"],
+				FixedSnippet:    issueMap["fixedSnippet"],
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// stringValue safely extracts a string value from a map
+func stringValue(m map[string]interface{}, key string) string {
+	if val, ok := m[key]; ok {
+		if str, ok := val.(string); ok {
+			return str
+		}
+	}
+	return ""
+}
+
+// alternativesValue extracts ranked alternative fixes from an issue map
+func alternativesValue(m map[string]interface{}) []FixAlternative {
+	items, ok := m["alternatives"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var alternatives []FixAlternative
+	for _, item := range items {
+		altMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		alternative := FixAlternative{
+			Snippet: stringValue(altMap, "snippet"),
+			Pros:    stringValue(altMap, "pros"),
+			Cons:    stringValue(altMap, "cons"),
+		}
+		if rank, ok := altMap["rank"].(float64); ok {
+			alternative.Rank = int(rank)
+		}
+		if alternative.Snippet != "" {
+			alternatives = append(alternatives, alternative)
+		}
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return alternatives[i].Rank < alternatives[j].Rank
+	})
+	return alternatives
+}
+
+// AttachRuleDetails adds verbatim rule text, documentation links, severities and localized texts to the issues
+func AttachRuleDetails(issues []Issue, ruleSet *rules.Rules, locale string) {
+	for i := range issues {
+		rule := ruleSet.FindRule(issues[i].RuleName)
+		if rule == nil {
+			continue
+		}
+		issues[i].RuleName = rule.Name
+		issues[i].RuleText = rule.Rule
+		issues[i].RuleLink = rule.Link()
+		if issues[i].Severity == "" {
+			issues[i].Severity = rule.Severity
+		}
+		issues[i].Category = rule.Category
+		// Localized rule packs replace the reason and fix written by the judge with the texts of the locale
+		if text := rules.Translation(rule.ReasonTranslations, locale); text != "" {
+			issues[i].Reason = text
+		}
+		if text := rules.Translation(rule.FixTranslations, locale); text != "" {
+			issues[i].Fix = text
+		}
+		issues[i].RuleAliases = ruleSet.Aliases(rule.Name)
+	}
+}
+
+// Fingerprint returns a stable identifier of an issue based on the rule and the problematic snippet
+func Fingerprint(issue Issue) string {
+	snippet := strings.ToLower(strings.Join(strings.Fields(issue.OriginalSnippet), " "))
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(issue.RuleName)) + "\x00" + snippet))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// AssignFingerprints sets fingerprints for all issues
+func AssignFingerprints(issues []Issue) {
+	for i := range issues {
+		issues[i].Fingerprint = Fingerprint(issues[i])
+	}
+}
@@ -0,0 +1,127 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// judgeTransport answers every request with one Assign Persona issue
+type judgeTransport struct{}
+
+func (judgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := `{"model": "gpt-4o-mini-2024-07-18", "system_fingerprint": "fp_1",
+		"choices": [{"message": {"tool_calls": [{"function": {"name": "find_prompt_issues",
+		"arguments": "{\"issues\": [{\"name\": \"Assign Persona\", \"description\": \"No role\", \"reason\": \"r\", \"fix\": \"f\"}]}"}}]}}]}`
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}},
+		Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestCheckConcurrent(t *testing.T) {
+	previous := llm.Transport()
+	llm.SetTransport(judgeTransport{})
+	t.Cleanup(func() { llm.SetTransport(previous) })
+
+	provider, err := llm.Lookup("openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{LLM: &llm.Config{APIKey: "test", APIEndpoint: "https://api.openai.com/v1/chat/completions", ModelName: "gpt-4o-mini", Provider: provider}}
+
+	// The options are shared by all calls, go test -race reports calls that write to them
+	var wg sync.WaitGroup
+	results := make([]Result, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = Check(context.Background(), "Answer questions.", opts)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if result.ServedModel != "gpt-4o-mini-2024-07-18" || result.SystemFingerprint != "fp_1" {
+			t.Errorf("judge version %q %q, want the reported one", result.ServedModel, result.SystemFingerprint)
+		}
+		if len(result.Issues) == 0 || result.Issues[0].RuleName != "Assign Persona" || result.Issues[0].Fingerprint == "" {
+			t.Errorf("issues %+v, want the Assign Persona issue with its fingerprint", result.Issues)
+		}
+	}
+	if opts.LLM.ServedModel != "" {
+		t.Errorf("Check recorded the served model %q in the options", opts.LLM.ServedModel)
+	}
+}
+
+func TestDescribeCacheIsBounded(t *testing.T) {
+	builtin, err := rules.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := describe(builtin)
+	// Servers build a rule set per request with inline rules
+	for i := 0; i < 2*maxDescriptions; i++ {
+		ruleSet := *builtin
+		describe(&ruleSet)
+	}
+	descriptions.Lock()
+	size := len(descriptions.entries)
+	descriptions.Unlock()
+	if size > maxDescriptions {
+		t.Errorf("%d cached descriptions, want at most %d", size, maxDescriptions)
+	}
+	if got := describe(builtin); got != want {
+		t.Errorf("describe() after a reset = %q, want %q", got, want)
+	}
+}
+
+func TestLintWithoutKey(t *testing.T) {
+	ruleSet := &rules.Rules{PromptRules: []rules.Rule{
+		{Name: "Assign Persona", Rule: "Define a role"},
+		{Name: "No TODO", Rule: "Finish the prompt", Pattern: `TODO`},
+	}}
+	prompt := "Answer questions. TODO add examples.\n<!-- promptlint-disable-next-line no-todo -->\nTODO remove.\n"
+	tests := []struct {
+		name   string
+		engine string
+		want   []string
+	}{
+		{"heuristic judge", "", []string{"Assign Persona:1"}},
+		{"heuristic judge and static rules", "both", []string{"Assign Persona:1", "No TODO:1"}},
+		{"static rules", "static", []string{"No TODO:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(context.Background(), prompt, Options{Rules: ruleSet, Engine: tt.engine})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range result.Issues {
+				got = append(got, fmt.Sprintf("%s:%d", issue.RuleName, issue.Line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() issues = %q, want %q", got, tt.want)
+			}
+			if result.Heuristic != (tt.engine != "static") {
+				t.Errorf("Check() heuristic = %v, want %v", result.Heuristic, tt.engine != "static")
+			}
+		})
+	}
+
+	if _, err := Lint(context.Background(), prompt, Options{Rules: ruleSet, Engine: "regex"}); err == nil || !strings.Contains(err.Error(), `unknown engine "regex"`) {
+		t.Errorf("Lint() error = %v, want the unknown engine", err)
+	}
+}
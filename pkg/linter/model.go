@@ -0,0 +1,318 @@
+package linter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Position is a location in the prompt text, line and column are 1-based, offset is in bytes
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Section is a part of the prompt started by a Markdown heading or wrapped in an XML-like tag
+type Section struct {
+	// Kind is "heading" or "tag"
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	// Level is the heading level, 0 for tags
+	Level int      `json:"level"`
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Sentence is a sentence of prose outside code fences
+type Sentence struct {
+	Text  string   `json:"text"`
+	Start Position `json:"start"`
+	// Section is the index of the innermost enclosing section, -1 outside sections
+	Section int `json:"section"`
+}
+
+// Placeholder is a template variable like {{name}}, {name}, ${name} or %(name)s
+type Placeholder struct {
+	Name   string   `json:"name"`
+	Syntax string   `json:"syntax"`
+	Start  Position `json:"start"`
+	End    Position `json:"end"`
+}
+
+// CodeFence is a fenced code block
+type CodeFence struct {
+	Language string   `json:"language,omitempty"`
+	Content  string   `json:"content"`
+	Start    Position `json:"start"`
+	End      Position `json:"end"`
+}
+
+// ChatMessage is a single message of a chat prompt
+type ChatMessage struct {
+	Role    string `json:"role" yaml:"role"`
+	Content string `json:"content" yaml:"content"`
+}
+
+// PromptModel is the parsed representation of a prompt passed to analyzers
+type PromptModel struct {
+	// Text is the full prompt text
+	Text string `json:"text"`
+	// Lines are the lines of the text without line endings
+	Lines []string `json:"-"`
+	// Format is the input format of the document, if known
+	Format       string                 `json:"format,omitempty"`
+	Sections     []Section              `json:"sections"`
+	Sentences    []Sentence             `json:"sentences"`
+	Placeholders []Placeholder          `json:"placeholders"`
+	CodeFences   []CodeFence            `json:"codeFences"`
+	Messages     []ChatMessage          `json:"messages,omitempty"`
+	Frontmatter  map[string]interface{} `json:"frontmatter,omitempty"`
+
+	// lineOffsets are byte offsets of line starts
+	lineOffsets []int
+}
+
+// RoleMarkerPattern matches a line that starts the message of a chat role, like "user:"
+var RoleMarkerPattern = regexp.MustCompile(`(?mi)^\s*(system|user|assistant):\s*$`)
+
+var (
+	// HeadingPattern matches a Markdown heading line, the groups are the marker and the title
+	HeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	// FencePattern matches the opening line of a code fence, the groups are the marker and the language
+	FencePattern = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+-]*)")
+
+	openTagPattern    = regexp.MustCompile(`^\s*<([A-Za-z_][\w-]*)>\s*$`)
+	sentencePattern   = regexp.MustCompile(`[^.!?]+(?:[.!?]+|$)`)
+	listMarkerPattern = regexp.MustCompile(`^\s*(?:[-*+>]|\d+[.)])\s+`)
+)
+
+// PlaceholderPattern matches placeholders of a template syntax, the first group is the name
+type PlaceholderPattern struct {
+	Syntax  string
+	Pattern *regexp.Regexp
+}
+
+// PlaceholderPatterns are the supported placeholder syntaxes, the "{}" pattern includes the preceding character
+var PlaceholderPatterns = []PlaceholderPattern{
+	{"{{}}", regexp.MustCompile(`\{\{\s*([A-Za-z_][\w.]*)\s*\}\}`)},
+	{"${}", regexp.MustCompile(`\$\{([A-Za-z_][\w.]*)\}`)},
+	{"{}", regexp.MustCompile(`(?:^|[^{$])\{([A-Za-z_][\w.]*)\}`)},
+	{"%()", regexp.MustCompile(`%\(([A-Za-z_]\w*)\)[-+#0]*\d*(?:\.\d+)?[sdifr]`)},
+}
+
+// PlaceholderStyles maps placeholder syntaxes to their format templates
+var PlaceholderStyles = map[string]string{
+	"{{}}": "{{%s}}",
+	"${}":  "${%s}",
+	"{}":   "{%s}",
+	"%()":  "%%(%s)s",
+}
+
+// ParsePrompt builds the prompt model of the text
+func ParsePrompt(text string) *PromptModel {
+	model := &PromptModel{
+		Text:         text,
+		Lines:        strings.Split(text, "\n"),
+		Sections:     []Section{},
+		Sentences:    []Sentence{},
+		Placeholders: []Placeholder{},
+		CodeFences:   []CodeFence{},
+	}
+	offset := 0
+	for _, line := range model.Lines {
+		model.lineOffsets = append(model.lineOffsets, offset)
+		offset += len(line) + 1
+	}
+
+	model.parseBlocks()
+	model.parsePlaceholders()
+	return model
+}
+
+// Position converts a byte offset to a position
+func (m *PromptModel) Position(offset int) Position {
+	line := sort.Search(len(m.lineOffsets), func(i int) bool { return m.lineOffsets[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+	return Position{Line: line + 1, Column: offset - m.lineOffsets[line] + 1, Offset: offset}
+}
+
+// LineOf returns the 1-based line where the snippet starts, 0 if it is not found.
+// Whitespace differences are ignored when the exact snippet is not present.
+func (m *PromptModel) LineOf(snippet string) int {
+	snippet = strings.TrimSpace(snippet)
+	if snippet == "" {
+		return 0
+	}
+	if i := strings.Index(m.Text, snippet); i >= 0 {
+		return m.Position(i).Line
+	}
+	first := normalizeSpaces(strings.SplitN(snippet, "\n", 2)[0])
+	for i, line := range m.Lines {
+		if first != "" && strings.Contains(normalizeSpaces(line), first) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// LineOffset returns the byte offset of the start of the 0-based line
+func (m *PromptModel) LineOffset(i int) int {
+	return m.lineOffsets[i]
+}
+
+// FenceLines returns 1-based numbers of lines inside code fences, including the fence lines
+func (m *PromptModel) FenceLines() map[int]bool {
+	lines := map[int]bool{}
+	for _, fence := range m.CodeFences {
+		for line := fence.Start.Line; line <= fence.End.Line; line++ {
+			lines[line] = true
+		}
+	}
+	return lines
+}
+
+// PlaceholderSyntax returns the most used placeholder syntax of the prompt, "" without placeholders
+func (m *PromptModel) PlaceholderSyntax() string {
+	counts := map[string]int{}
+	best := ""
+	for _, placeholder := range m.Placeholders {
+		counts[placeholder.Syntax]++
+		if best == "" || counts[placeholder.Syntax] > counts[best] {
+			best = placeholder.Syntax
+		}
+	}
+	return best
+}
+
+// LineRoles returns the chat role of every line by role marker lines ("system:", "user:"),
+// text before the first marker and prompts without markers belong to the system role
+func (m *PromptModel) LineRoles() []string {
+	roles := make([]string, len(m.Lines))
+	role := "system"
+	inFence := m.FenceLines()
+	for i, line := range m.Lines {
+		if match := RoleMarkerPattern.FindStringSubmatch(line); match != nil && !inFence[i+1] {
+			role = strings.ToLower(match[1])
+		}
+		roles[i] = role
+	}
+	return roles
+}
+
+// parseBlocks finds sections, code fences and sentences line by line
+func (m *PromptModel) parseBlocks() {
+	var openTags, openHeadings []int
+	fenceStart, fenceMarker, fenceLanguage := -1, "", ""
+	var fenceContent []string
+
+	// current returns the innermost open section, the one opened last
+	current := func() int {
+		innermost := -1
+		if len(openTags) > 0 {
+			innermost = openTags[len(openTags)-1]
+		}
+		if len(openHeadings) > 0 && openHeadings[len(openHeadings)-1] > innermost {
+			innermost = openHeadings[len(openHeadings)-1]
+		}
+		return innermost
+	}
+	// lineEnd returns the position after the last character of the line
+	lineEnd := func(i int) Position {
+		return m.Position(m.lineOffsets[i] + len(m.Lines[i]))
+	}
+	// closeHeadings ends open headings of the level or deeper at the line before i
+	closeHeadings := func(level, i int) {
+		for len(openHeadings) > 0 && m.Sections[openHeadings[len(openHeadings)-1]].Level >= level {
+			m.Sections[openHeadings[len(openHeadings)-1]].End = lineEnd(i - 1)
+			openHeadings = openHeadings[:len(openHeadings)-1]
+		}
+	}
+
+	for i, line := range m.Lines {
+		start := m.Position(m.lineOffsets[i])
+		end := lineEnd(i)
+
+		if fenceStart >= 0 {
+			if strings.HasPrefix(strings.TrimSpace(line), fenceMarker) {
+				m.CodeFences = append(m.CodeFences, CodeFence{
+					Language: fenceLanguage,
+					Content:  strings.Join(fenceContent, "\n"),
+					Start:    m.Position(m.lineOffsets[fenceStart]),
+					End:      end,
+				})
+				fenceStart, fenceContent = -1, nil
+			} else {
+				fenceContent = append(fenceContent, line)
+			}
+			continue
+		}
+		if match := FencePattern.FindStringSubmatch(line); match != nil {
+			fenceStart, fenceMarker, fenceLanguage = i, match[1], match[2]
+			continue
+		}
+
+		if match := HeadingPattern.FindStringSubmatch(line); match != nil {
+			closeHeadings(len(match[1]), i)
+			m.Sections = append(m.Sections, Section{Kind: "heading", Title: match[2], Level: len(match[1]), Start: start, End: end})
+			openHeadings = append(openHeadings, len(m.Sections)-1)
+			continue
+		}
+		if match := openTagPattern.FindStringSubmatch(line); match != nil {
+			m.Sections = append(m.Sections, Section{Kind: "tag", Title: match[1], Start: start, End: end})
+			openTags = append(openTags, len(m.Sections)-1)
+			continue
+		}
+		if len(openTags) > 0 && strings.TrimSpace(line) == "</"+m.Sections[openTags[len(openTags)-1]].Title+">" {
+			m.Sections[openTags[len(openTags)-1]].End = end
+			openTags = openTags[:len(openTags)-1]
+			continue
+		}
+
+		// List and quote markers are not part of sentences
+		base := 0
+		if loc := listMarkerPattern.FindStringIndex(line); loc != nil {
+			base = loc[1]
+		}
+		for _, loc := range sentencePattern.FindAllStringIndex(line[base:], -1) {
+			raw := line[base+loc[0] : base+loc[1]]
+			text := strings.TrimSpace(raw)
+			if text == "" {
+				continue
+			}
+			offset := m.lineOffsets[i] + base + loc[0] + strings.Index(raw, text)
+			m.Sentences = append(m.Sentences, Sentence{Text: text, Start: m.Position(offset), Section: current()})
+		}
+	}
+
+	// Unclosed fences, headings and tags extend to the end of the text
+	end := lineEnd(len(m.Lines) - 1)
+	if fenceStart >= 0 {
+		m.CodeFences = append(m.CodeFences, CodeFence{Language: fenceLanguage, Content: strings.Join(fenceContent, "\n"), Start: m.Position(m.lineOffsets[fenceStart]), End: end})
+	}
+	closeHeadings(1, len(m.Lines))
+	for _, index := range openTags {
+		m.Sections[index].End = end
+	}
+}
+
+// parsePlaceholders finds template variables in all supported syntaxes
+func (m *PromptModel) parsePlaceholders() {
+	for _, s := range PlaceholderPatterns {
+		for _, loc := range s.Pattern.FindAllStringSubmatchIndex(m.Text, -1) {
+			start := loc[0]
+			if s.Syntax == "{}" {
+				start = loc[2] - 1
+			}
+			m.Placeholders = append(m.Placeholders, Placeholder{Name: m.Text[loc[2]:loc[3]], Syntax: s.Syntax, Start: m.Position(start), End: m.Position(loc[1])})
+		}
+	}
+	sort.Slice(m.Placeholders, func(i, j int) bool { return m.Placeholders[i].Start.Offset < m.Placeholders[j].Start.Offset })
+}
+
+// normalizeSpaces collapses runs of whitespace to single spaces
+func normalizeSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
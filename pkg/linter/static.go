@@ -0,0 +1,91 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// Engines are the values of Options.Engine: the LLM judge, the static rule engine or both
+var Engines = []string{"llm", "static", "both"}
+
+// maxStaticMatches limits the issues a single pattern rule reports
+const maxStaticMatches = 10
+
+// compiledPatterns caches rule patterns by source, the lint server compiles patterns of reloaded rules
+// while requests are checked
+var compiledPatterns = struct {
+	sync.Mutex
+	entries map[string]*regexp.Regexp
+}{entries: map[string]*regexp.Regexp{}}
+
+// CompilePattern compiles a rule pattern once
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	compiledPatterns.Lock()
+	defer compiledPatterns.Unlock()
+	if re, ok := compiledPatterns.entries[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiledPatterns.entries[pattern] = re
+	return re, nil
+}
+
+// CheckStaticRules reports matches of rule patterns and prompts outside the length bounds of rules.
+// A pattern describes a violation: every match is an issue, invalid patterns are skipped.
+func CheckStaticRules(model *PromptModel, ruleSet *rules.Rules, locale string) []Issue {
+	var issues []Issue
+	length := utf8.RuneCountInString(model.Text)
+	for _, rule := range ruleSet.Active() {
+		issue := Issue{RuleName: rule.Name, Reason: rule.ReasonIn(locale), Fix: rule.FixIn(locale)}
+		if rule.MinLength > 0 && length < rule.MinLength {
+			issue.Description = fmt.Sprintf("The prompt is %d characters long, %s requires at least %d", length, rule.Name, rule.MinLength)
+			issues = append(issues, issue)
+		}
+		if rule.MaxLength > 0 && length > rule.MaxLength {
+			issue.Description = fmt.Sprintf("The prompt is %d characters long, %s allows at most %d", length, rule.Name, rule.MaxLength)
+			issues = append(issues, issue)
+		}
+		if rule.Pattern == "" {
+			continue
+		}
+		re, err := CompilePattern(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		for _, loc := range re.FindAllStringIndex(model.Text, maxStaticMatches) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			position := model.Position(loc[0])
+			match := issue
+			match.Description = fmt.Sprintf("%q matches the pattern of %s", model.Text[loc[0]:loc[1]], rule.Name)
+			match.OriginalSnippet = model.Text[loc[0]:loc[1]]
+			match.Line, match.Column = position.Line, position.Column
+			issues = append(issues, match)
+		}
+	}
+	AttachRuleDetails(issues, ruleSet, locale)
+	AssignFingerprints(issues)
+	return issues
+}
+
+// checkEngine validates the engine of the options, empty is the LLM judge
+func checkEngine(engine string) error {
+	if engine == "" {
+		return nil
+	}
+	for _, known := range Engines {
+		if engine == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown engine %q, supported: %s", engine, strings.Join(Engines, ", "))
+}
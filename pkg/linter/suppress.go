@@ -0,0 +1,145 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// Suppression directives: the next line, or the lines up to the matching enable directive or the end of the file
+const (
+	suppressNextLineDirective = "promptlint-disable-next-line"
+	suppressDisableDirective  = "promptlint-disable"
+	suppressEnableDirective   = "promptlint-enable"
+)
+
+// suppressDirectivePattern matches a directive with comma-separated rule names up to the end of the comment
+var suppressDirectivePattern = regexp.MustCompile(`promptlint-(disable-next-line|disable|enable)(?:[ \t]+([^\n]*?))?[ \t]*(?:-->|\*/|$)`)
+
+// suppressCommentPattern matches whole comments holding a directive: HTML, block and line comments
+var suppressCommentPattern = regexp.MustCompile(`[ \t]*(?:<!--[ \t]*promptlint-(?:disable-next-line|disable|enable)\b[^\n]*?-->|/\*[ \t]*promptlint-(?:disable-next-line|disable|enable)\b[^\n]*?\*/|(?://|#)[ \t]*promptlint-(?:disable-next-line|disable|enable)\b[^\n]*)`)
+
+// SuppressionComment returns the comment that suppresses the rule on the next line, indented like that line
+func SuppressionComment(ruleName, indent string) string {
+	return fmt.Sprintf("%s<!-- %s %s -->\n", indent, suppressNextLineDirective, rules.Anchor(ruleName))
+}
+
+// StripSuppressions removes suppression comments before the prompt is sent to the LLM, lines are kept
+// so that reported line numbers still match the file
+func StripSuppressions(text string) string {
+	if !strings.Contains(text, "promptlint-") {
+		return text
+	}
+	return suppressCommentPattern.ReplaceAllString(text, "")
+}
+
+// suppressionRules parses the rule list of a directive into docs anchors, empty means all rules
+func suppressionRules(list string) map[string]bool {
+	anchors := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		if anchor := rules.Anchor(name); anchor != "" {
+			anchors[anchor] = true
+		}
+	}
+	return anchors
+}
+
+// suppressionScope is the set of suppressed rules of a line: all rules but the excepted ones, or the listed ones
+type suppressionScope struct {
+	all    bool
+	rules  map[string]bool
+	except map[string]bool
+}
+
+// covers reports whether the scope suppresses the rule anchor
+func (s *suppressionScope) covers(anchor string) bool {
+	if s == nil {
+		return false
+	}
+	if s.all {
+		return !s.except[anchor]
+	}
+	return s.rules[anchor]
+}
+
+// apply returns the scope after a disable or enable directive, the scope itself is never changed
+func (s suppressionScope) apply(directive string, rules map[string]bool) *suppressionScope {
+	if len(rules) == 0 {
+		return &suppressionScope{all: directive == suppressDisableDirective}
+	}
+	next := suppressionScope{all: s.all, rules: map[string]bool{}, except: map[string]bool{}}
+	for rule := range s.rules {
+		next.rules[rule] = true
+	}
+	for rule := range s.except {
+		next.except[rule] = true
+	}
+	for rule := range rules {
+		switch {
+		case directive == suppressDisableDirective && next.all:
+			delete(next.except, rule)
+		case directive == suppressDisableDirective:
+			next.rules[rule] = true
+		case next.all:
+			next.except[rule] = true
+		default:
+			delete(next.rules, rule)
+		}
+	}
+	return &next
+}
+
+// suppressionScopes returns the scopes of disable/enable blocks and next-line directives by 1-based line.
+// A block starts on the line of its directive.
+func suppressionScopes(lines []string) (blocks []*suppressionScope, nextLine map[int]*suppressionScope) {
+	blocks = make([]*suppressionScope, len(lines)+1)
+	nextLine = map[int]*suppressionScope{}
+	current := &suppressionScope{}
+	for i, line := range lines {
+		for _, match := range suppressDirectivePattern.FindAllStringSubmatch(line, -1) {
+			directive, rules := "promptlint-"+match[1], suppressionRules(match[2])
+			if directive == suppressNextLineDirective {
+				nextLine[i+2] = &suppressionScope{all: len(rules) == 0, rules: rules}
+				continue
+			}
+			current = current.apply(directive, rules)
+		}
+		blocks[i+1] = current
+	}
+	return blocks, nextLine
+}
+
+// ApplySuppressions drops issues suppressed by comments: promptlint-disable-next-line covers the next line,
+// promptlint-disable covers the lines up to promptlint-enable or the end of the file. Rules are listed by name
+// or docs anchor ("Assign Persona" or assign-persona), a directive without rules applies to all of them.
+// Issues without a line are dropped only when their rule is disabled on every line.
+func ApplySuppressions(issues []Issue, model *PromptModel) []Issue {
+	if !strings.Contains(model.Text, "promptlint-") {
+		return issues
+	}
+	blocks, nextLine := suppressionScopes(model.Lines)
+
+	kept := issues[:0]
+	for _, issue := range issues {
+		anchor := rules.Anchor(issue.RuleName)
+		suppressed := false
+		switch {
+		case issue.Line > 0 && issue.Line < len(blocks):
+			suppressed = blocks[issue.Line].covers(anchor) || nextLine[issue.Line].covers(anchor)
+		case issue.Line == 0:
+			suppressed = len(model.Lines) > 0
+			for _, scope := range blocks[1:] {
+				if !scope.covers(anchor) {
+					suppressed = false
+					break
+				}
+			}
+		}
+		if !suppressed {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
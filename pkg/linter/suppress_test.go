@@ -0,0 +1,89 @@
+package linter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuppressionRules(t *testing.T) {
+	tests := []struct {
+		list string
+		want map[string]bool
+	}{
+		{"", map[string]bool{}},
+		{"Assign Persona", map[string]bool{"assign-persona": true}},
+		{"assign-persona, Use Positive Instructions", map[string]bool{"assign-persona": true, "use-positive-instructions": true}},
+		{" , ", map[string]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			if got := suppressionRules(tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suppressionRules(%q) = %v, want %v", tt.list, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySuppressions(t *testing.T) {
+	prompt := strings.Join([]string{
+		"<!-- promptlint-disable-next-line assign-persona -->",
+		"Line two.",
+		"/* promptlint-disable */",
+		"Line four.",
+		"# promptlint-enable Use Positive Instructions",
+		"Line six.",
+		"// promptlint-enable",
+		"Line eight.",
+	}, "\n")
+	tests := []struct {
+		name  string
+		issue Issue
+		want  bool
+	}{
+		{"next line, listed rule", Issue{RuleName: "Assign Persona", Line: 2}, false},
+		{"next line, other rule", Issue{RuleName: "Use Positive Instructions", Line: 2}, true},
+		{"disabled block", Issue{RuleName: "Use Positive Instructions", Line: 4}, false},
+		{"rule enabled in the block", Issue{RuleName: "Use Positive Instructions", Line: 6}, true},
+		{"other rules stay disabled", Issue{RuleName: "Assign Persona", Line: 6}, false},
+		{"after enable", Issue{RuleName: "Assign Persona", Line: 8}, true},
+		{"without a line", Issue{RuleName: "Assign Persona"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplySuppressions([]Issue{tt.issue}, ParsePrompt(prompt))
+			if kept := len(got) == 1; kept != tt.want {
+				t.Errorf("kept = %v, want %v", kept, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySuppressionsWholeFile(t *testing.T) {
+	model := ParsePrompt("<!-- promptlint-disable Assign Persona -->\nYou help.\n")
+	issues := []Issue{{RuleName: "Assign Persona"}, {RuleName: "Use Positive Instructions"}}
+	got := ApplySuppressions(issues, model)
+	if len(got) != 1 || got[0].RuleName != "Use Positive Instructions" {
+		t.Errorf("ApplySuppressions() = %v, want only the issue of the enabled rule", got)
+	}
+}
+
+func TestStripSuppressions(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"No directives.", "No directives."},
+		{"Be brief. <!-- promptlint-disable-next-line -->\nLine.", "Be brief.\nLine."},
+		{"/* promptlint-disable assign-persona */\nLine.", "\nLine."},
+		{"Line. # promptlint-enable\nNext.", "Line.\nNext."},
+		{"<!-- a normal comment -->", "<!-- a normal comment -->"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := StripSuppressions(tt.text); got != tt.want {
+				t.Errorf("StripSuppressions(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// anthropicVersion is the Messages API version sent with every request
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens limits the answer, the Messages API requires an explicit limit
+	anthropicMaxTokens = 8192
+)
+
+// Anthropic talks to the Anthropic Messages API
+type Anthropic struct{}
+
+func (Anthropic) Name() string            { return "anthropic" }
+func (Anthropic) DefaultEndpoint() string { return "https://api.anthropic.com/v1/messages" }
+func (Anthropic) DefaultModel() string    { return "claude-sonnet-4-5" }
+func (Anthropic) KeyEnv() string          { return "ANTHROPIC_API_KEY" }
+func (Anthropic) ModelEnv() string        { return "" }
+
+// NewRequest sends the user messages as text blocks of one user turn after the turns of the history,
+// the API doesn't support seeds
+func (Anthropic) NewRequest(config *Config, request ToolRequest) (*http.Request, error) {
+	content := make([]map[string]string, 0, len(request.Messages))
+	for _, message := range request.Messages {
+		content = append(content, map[string]string{"type": "text", "text": message})
+	}
+	messages := make([]map[string]interface{}, 0, len(request.History)+1)
+	for _, turn := range request.History {
+		messages = append(messages, map[string]interface{}{
+			"role":    turn.Role,
+			"content": []map[string]string{{"type": "text", "text": turn.Content}},
+		})
+	}
+	messages = append(messages, map[string]interface{}{"role": "user", "content": content})
+
+	requestBody := map[string]interface{}{
+		"model":      config.ModelName,
+		"max_tokens": anthropicMaxTokens,
+		"messages":   messages,
+	}
+	if request.Tool.Name != "" {
+		requestBody["tools"] = []map[string]interface{}{
+			{
+				"name":         request.Tool.Name,
+				"description":  request.Tool.Description,
+				"input_schema": request.Tool.Parameters,
+			},
+		}
+		requestBody["tool_choice"] = map[string]string{
+			"type": "tool",
+			"name": request.Tool.Name,
+		}
+	}
+	if request.System != "" {
+		requestBody["system"] = request.System
+	}
+
+	req, err := NewJSONRequest(config.APIEndpoint, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", config.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	return req, nil
+}
+
+func (Anthropic) ParseResponse(body []byte) (ToolResponse, error) {
+	var responseData struct {
+		Type    string `json:"type"`
+		Model   string `json:"model"`
+		Content []struct {
+			Type  string                 `json:"type"`
+			Text  string                 `json:"text"`
+			Name  string                 `json:"name"`
+			Input map[string]interface{} `json:"input"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &responseData); err != nil {
+		return ToolResponse{}, fmt.Errorf("error decoding response: %w", err)
+	}
+	if responseData.Type != "message" {
+		return ToolResponse{}, fmt.Errorf("error decoding response: expected a message, got %q", responseData.Type)
+	}
+
+	response := ToolResponse{Model: responseData.Model, InputTokens: responseData.Usage.InputTokens, OutputTokens: responseData.Usage.OutputTokens}
+	var text []string
+	for _, block := range responseData.Content {
+		switch block.Type {
+		case "tool_use":
+			arguments := block.Input
+			if arguments == nil {
+				arguments = map[string]interface{}{}
+			}
+			response.Calls = append(response.Calls, ToolCall{Name: block.Name, Arguments: arguments})
+		case "text":
+			text = append(text, block.Text)
+		}
+	}
+	response.Text = strings.Join(text, "\n")
+	return response, nil
+}
@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// defaultAzureAPIVersion is the Azure OpenAI API version used when PROMPTLINT_AZURE_API_VERSION is not set
+const defaultAzureAPIVersion = "2024-10-21"
+
+// Azure talks to Azure OpenAI deployments, the request body is the chat completions one
+type Azure struct{}
+
+func (Azure) Name() string { return "azure" }
+
+// DefaultEndpoint is empty, every Azure resource has its own URL
+func (Azure) DefaultEndpoint() string { return "" }
+
+// DefaultModel is the deployment name used when neither PROMPTLINT_AZURE_DEPLOYMENT nor the model is set
+func (Azure) DefaultModel() string { return "gpt-4o" }
+func (Azure) KeyEnv() string       { return "AZURE_OPENAI_API_KEY" }
+func (Azure) ModelEnv() string     { return "PROMPTLINT_AZURE_DEPLOYMENT" }
+
+// NewRequest authenticates with the api-key header, the model name is the deployment
+func (Azure) NewRequest(config *Config, request ToolRequest) (*http.Request, error) {
+	endpoint, err := azureDeploymentURL(config.APIEndpoint, config.ModelName, os.Getenv("PROMPTLINT_AZURE_API_VERSION"))
+	if err != nil {
+		return nil, err
+	}
+	req, err := NewJSONRequest(endpoint, ChatCompletionsBody(config, request))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("api-key", config.APIKey)
+	return req, nil
+}
+
+func (Azure) ParseResponse(body []byte) (ToolResponse, error) {
+	return OpenAI{}.ParseResponse(body)
+}
+
+// azureDeploymentURL builds the chat completions URL of the deployment from the resource URL,
+// e.g. https://my-resource.openai.azure.com. A URL that already names a deployment is kept.
+// The api-version query parameter is added unless the URL has one.
+func azureDeploymentURL(endpoint, deployment, apiVersion string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid Azure OpenAI endpoint %q, expected the resource URL, e.g. https://my-resource.openai.azure.com", endpoint)
+	}
+	if !strings.Contains(u.Path, "/deployments/") {
+		if deployment == "" {
+			return "", fmt.Errorf("Azure OpenAI deployment is missing, set PROMPTLINT_AZURE_DEPLOYMENT")
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/openai/deployments/" + deployment + "/chat/completions"
+		u.RawPath = ""
+	}
+	query := u.Query()
+	if query.Get("api-version") == "" {
+		if apiVersion == "" {
+			apiVersion = defaultAzureAPIVersion
+		}
+		query.Set("api-version", apiVersion)
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
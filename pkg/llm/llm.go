@@ -0,0 +1,247 @@
+// Package llm sends tool calling requests to LLM APIs through pluggable providers.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config contains settings for LLM API interaction
+type Config struct {
+	APIKey      string
+	APIEndpoint string
+	ModelName   string
+	Timeout     time.Duration
+	// Alternatives is the number of alternative fixes requested per issue (0 disables)
+	Alternatives int
+	// Heuristic replaces the LLM with local pattern checks, linter.Check also uses them without an API key
+	Heuristic bool
+	// Seed is sent to the API for reproducible sampling, 0 leaves it unset
+	Seed int64
+	// ServedModel and SystemFingerprint are reported by the API and identify the exact judge version
+	ServedModel       string
+	SystemFingerprint string
+	// Provider formats requests and responses of the API
+	Provider Provider
+	// Context cancels requests to the API, nil never cancels
+	Context context.Context
+	// Retry repeats requests failed by network errors, rate limits and server errors, the zero value never retries
+	Retry RetryPolicy
+}
+
+// ToolSpec describes the single tool the model is forced to call
+type ToolSpec struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the tool arguments
+	Parameters map[string]interface{}
+}
+
+// Turn is an earlier message of a conversation, Role is user or assistant
+type Turn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ToolRequest is a provider-neutral request: a system message, user messages in order and the tool to call,
+// a request without a tool name asks for a text answer
+type ToolRequest struct {
+	System string
+	// History are earlier turns of a conversation, sent before the user messages
+	History  []Turn
+	Messages []string
+	Tool     ToolSpec
+}
+
+// ToolCall is a tool call of the model with decoded arguments
+type ToolCall struct {
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// ToolResponse is the normalized answer of a provider
+type ToolResponse struct {
+	// Model and SystemFingerprint identify the exact judge version when the API reports them
+	Model             string
+	SystemFingerprint string
+	Calls             []ToolCall
+	// Text is the text content of the answer, used when the model answered without a tool call
+	Text string
+	// InputTokens and OutputTokens are the usage reported by the API, 0 when it reports none
+	InputTokens  int
+	OutputTokens int
+}
+
+// Provider formats requests for an LLM API and normalizes its responses
+type Provider interface {
+	Name() string
+	DefaultEndpoint() string
+	DefaultModel() string
+	// KeyEnv is the provider's own API key variable, checked before PROMPTLINT_API_KEY; empty for none
+	KeyEnv() string
+	// ModelEnv is the provider's own model variable, checked before PROMPTLINT_MODEL_NAME; empty for none
+	ModelEnv() string
+	NewRequest(config *Config, request ToolRequest) (*http.Request, error)
+	ParseResponse(body []byte) (ToolResponse, error)
+}
+
+// maxIdleConnsPerHost lets parallel requests to one API keep their connections between requests
+const maxIdleConnsPerHost = 16
+
+// client is shared by all requests, its transport keeps connections to the API alive across files and requests
+var client = &http.Client{Transport: newTransport()}
+
+// newTransport returns the default transport with more idle connections per host
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return transport
+}
+
+// SetTransport replaces the transport of API requests, e.g. to forbid network access
+func SetTransport(transport http.RoundTripper) {
+	client.Transport = transport
+}
+
+// Transport returns the transport of API requests, wrappers like recorders send through it
+func Transport() http.RoundTripper {
+	return client.Transport
+}
+
+// providers contains registered providers by name
+var providers = map[string]Provider{}
+
+// Register makes a provider available by its name
+func Register(provider Provider) {
+	providers[provider.Name()] = provider
+}
+
+func init() {
+	Register(OpenAI{})
+	Register(Anthropic{})
+	Register(Azure{})
+}
+
+// Names returns sorted names of registered providers
+func Names() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup resolves a provider name
+func Lookup(name string) (Provider, error) {
+	provider, ok := providers[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q, supported: %s", name, strings.Join(Names(), ", "))
+	}
+	return provider, nil
+}
+
+// KeyHint names the environment variables the API key of the provider is read from
+func KeyHint(provider Provider) string {
+	if provider != nil && provider.KeyEnv() != "" {
+		return provider.KeyEnv() + " or PROMPTLINT_API_KEY"
+	}
+	return "PROMPTLINT_API_KEY"
+}
+
+// Send sends the request to the configured provider and records the served model in the config,
+// transient failures are retried by the retry policy of the config
+func Send(config *Config, request ToolRequest) (ToolResponse, error) {
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var body []byte
+	var err error
+	for attempt := 0; ; attempt++ {
+		body, err = sendOnce(ctx, config, request)
+		if err == nil {
+			break
+		}
+		if attempt >= config.Retry.MaxRetries || !retryable(ctx, err) {
+			if attempt > 0 {
+				return ToolResponse{}, fmt.Errorf("%w (gave up after %d attempts)", err, attempt+1)
+			}
+			return ToolResponse{}, err
+		}
+		delay, ok := config.Retry.backoff(attempt, err)
+		if !ok {
+			return ToolResponse{}, fmt.Errorf("%w (the API asks to retry later than the maximum backoff)", err)
+		}
+		if config.Retry.OnRetry != nil {
+			config.Retry.OnRetry(attempt+1, delay, err)
+		}
+		if waitErr := wait(ctx, delay); waitErr != nil {
+			return ToolResponse{}, err
+		}
+	}
+
+	response, err := config.Provider.ParseResponse(body)
+	if err != nil {
+		return ToolResponse{}, err
+	}
+	config.ServedModel = response.Model
+	config.SystemFingerprint = response.SystemFingerprint
+	return response, nil
+}
+
+// sendOnce makes a single attempt and returns the body of a successful response
+func sendOnce(ctx context.Context, config *Config, request ToolRequest) ([]byte, error) {
+	// The request is built for every attempt, its body can't be read twice
+	req, err := config.Provider.NewRequest(config, request)
+	if err != nil {
+		return nil, err
+	}
+	// The timeout covers reading the body like http.Client.Timeout, the shared client has none
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return body, nil
+}
+
+// NewJSONRequest creates a POST request with the JSON body
+func NewJSONRequest(endpoint string, body interface{}) (*http.Request, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("request serialization error: %w", err)
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OpenAI talks to OpenAI-compatible chat completions APIs
+type OpenAI struct{}
+
+func (OpenAI) Name() string            { return "openai" }
+func (OpenAI) DefaultEndpoint() string { return "https://api.openai.com/v1/chat/completions" }
+func (OpenAI) DefaultModel() string    { return "o3-mini" }
+func (OpenAI) KeyEnv() string          { return "" }
+func (OpenAI) ModelEnv() string        { return "" }
+
+func (OpenAI) NewRequest(config *Config, request ToolRequest) (*http.Request, error) {
+	req, err := NewJSONRequest(config.APIEndpoint, ChatCompletionsBody(config, request))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+config.APIKey)
+	return req, nil
+}
+
+// ChatCompletionsBody formats the request for chat completions APIs
+func ChatCompletionsBody(config *Config, request ToolRequest) map[string]interface{} {
+	messages := []map[string]string{}
+	if request.System != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": request.System})
+	}
+	for _, turn := range request.History {
+		messages = append(messages, map[string]string{"role": turn.Role, "content": turn.Content})
+	}
+	for _, message := range request.Messages {
+		messages = append(messages, map[string]string{"role": "user", "content": message})
+	}
+
+	requestBody := map[string]interface{}{
+		"model":    config.ModelName,
+		"messages": messages,
+	}
+	if request.Tool.Name != "" {
+		requestBody["tools"] = []map[string]interface{}{
+			{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        request.Tool.Name,
+					"description": request.Tool.Description,
+					"parameters":  request.Tool.Parameters,
+				},
+			},
+		}
+		requestBody["tool_choice"] = map[string]interface{}{
+			"type": "function",
+			"function": map[string]string{
+				"name": request.Tool.Name,
+			},
+		}
+	}
+	if config.Seed != 0 {
+		requestBody["seed"] = config.Seed
+	}
+	return requestBody
+}
+
+func (OpenAI) ParseResponse(body []byte) (ToolResponse, error) {
+	var responseData struct {
+		Model             string `json:"model"`
+		SystemFingerprint string `json:"system_fingerprint"`
+		Choices           []struct {
+			Message struct {
+				Content   *string `json:"content"`
+				ToolCalls []struct {
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &responseData); err != nil {
+		return ToolResponse{}, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	response := ToolResponse{
+		Model:             responseData.Model,
+		SystemFingerprint: responseData.SystemFingerprint,
+		InputTokens:       responseData.Usage.PromptTokens,
+		OutputTokens:      responseData.Usage.CompletionTokens,
+	}
+	if len(responseData.Choices) == 0 {
+		return response, nil
+	}
+	message := responseData.Choices[0].Message
+	for _, toolCall := range message.ToolCalls {
+		arguments := map[string]interface{}{}
+		// Tools without parameters may be called with empty arguments
+		if strings.TrimSpace(toolCall.Function.Arguments) != "" {
+			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &arguments); err != nil {
+				return ToolResponse{}, fmt.Errorf("error parsing tool response: %w", err)
+			}
+		}
+		response.Calls = append(response.Calls, ToolCall{Name: toolCall.Function.Name, Arguments: arguments})
+	}
+	if message.Content != nil {
+		response.Text = *message.Content
+	}
+	return response, nil
+}
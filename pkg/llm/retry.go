@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy retries transient failures: network errors, 408, 429 and 5xx responses
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, 0 disables retries
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled for every next one up to MaxBackoff
+	InitialBackoff time.Duration
+	// MaxBackoff limits the delay, a longer Retry-After of the API stops retrying
+	MaxBackoff time.Duration
+	// OnRetry is called before waiting for the next attempt, e.g. to report progress
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// DefaultRetryPolicy rides out network blips and short rate limits, about a minute and a half in the worst case
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, InitialBackoff: 2 * time.Second, MaxBackoff: 60 * time.Second}
+
+// StatusError is an unsuccessful response of the API
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the Retry-After header, 0 without it
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned error %d: %s", e.StatusCode, e.Body)
+}
+
+// Transient reports whether the request may succeed when repeated: timeouts, rate limits and server errors
+func (e *StatusError) Transient() bool {
+	switch {
+	case e.StatusCode == http.StatusRequestTimeout, e.StatusCode == http.StatusTooManyRequests:
+		return true
+	case e.StatusCode == http.StatusNotImplemented, e.StatusCode == http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return e.StatusCode >= 500
+}
+
+// parseRetryAfter reads the Retry-After header in seconds or as an HTTP date, 0 when it is absent or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// retryable reports whether the error of an attempt is transient, cancellation of the caller's context is not
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Transient()
+	}
+	// url.Error implements net.Error itself, the cause decides: a refused transport like --offline is final
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+var (
+	// jitter spreads retries of parallel requests and CI jobs, the global source is not seeded before Go 1.20
+	jitter      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	jitterMutex sync.Mutex
+)
+
+// backoff returns the delay before the retry after the attempt (0-based): exponential with equal jitter,
+// at least the Retry-After of the response
+func (p RetryPolicy) backoff(attempt int, err error) (time.Duration, bool) {
+	initial, limit := p.InitialBackoff, p.MaxBackoff
+	if initial <= 0 {
+		initial = DefaultRetryPolicy.InitialBackoff
+	}
+	if limit <= 0 {
+		limit = DefaultRetryPolicy.MaxBackoff
+	}
+	delay := initial
+	for i := 0; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	jitterMutex.Lock()
+	delay = delay/2 + time.Duration(jitter.Int63n(int64(delay/2)+1))
+	jitterMutex.Unlock()
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		if statusErr.RetryAfter > limit {
+			return 0, false
+		}
+		delay = statusErr.RetryAfter
+	}
+	return delay, true
+}
+
+// wait sleeps for the delay unless the context is done first
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
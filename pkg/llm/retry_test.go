@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempt  int
+		err      error
+		min, max time.Duration
+		retry    bool
+	}{
+		{"first retry", policy, 0, errors.New("reset"), 500 * time.Millisecond, time.Second, true},
+		{"doubles", policy, 2, errors.New("reset"), 2 * time.Second, 4 * time.Second, true},
+		{"capped", policy, 10, errors.New("reset"), 5 * time.Second, 10 * time.Second, true},
+		{"defaults", RetryPolicy{}, 0, errors.New("reset"), time.Second, 2 * time.Second, true},
+		{"retry after", policy, 0, &StatusError{StatusCode: 429, RetryAfter: 7 * time.Second}, 7 * time.Second, 7 * time.Second, true},
+		{"shorter retry after", policy, 3, &StatusError{StatusCode: 429, RetryAfter: time.Second}, 4 * time.Second, 8 * time.Second, true},
+		{"retry after beyond the limit", policy, 0, &StatusError{StatusCode: 429, RetryAfter: 11 * time.Second}, 0, 0, false},
+		{"wrapped retry after", policy, 0, fmt.Errorf("send: %w", &StatusError{StatusCode: 503, RetryAfter: 3 * time.Second}), 3 * time.Second, 3 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Jitter makes every run different, the delay has to stay within its bounds each time
+			for i := 0; i < 50; i++ {
+				delay, retry := tt.policy.backoff(tt.attempt, tt.err)
+				if retry != tt.retry {
+					t.Fatalf("backoff(%d) retry = %v, want %v", tt.attempt, retry, tt.retry)
+				}
+				if delay < tt.min || delay > tt.max {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, delay, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{" 30 ", 30 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusErrorTransient(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusNotImplemented, false},
+		{http.StatusBadGateway, true},
+		{http.StatusHTTPVersionNotSupported, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			if got := (&StatusError{StatusCode: tt.status}).Transient(); got != tt.want {
+				t.Errorf("Transient() for %d = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// Package report formats lint issues: colored or plain text, screen reader friendly text and JSON.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// ANSI color codes
+const (
+	ColorReset  = "\033[0m"
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+	ColorBold   = "\033[1m"
+	ColorDim    = "\033[2m"
+)
+
+// Hyperlink wraps the text into an OSC 8 terminal hyperlink to the URL
+func Hyperlink(url, text string) string {
+	return "\033]8;;" + url + "\033\\" + text + "\033]8;;\033\\"
+}
+
+// IssuePenalty is the number of quality score points deducted for every active issue
+const IssuePenalty = 5
+
+// Score rates a prompt from 0 to 100 by the number of active issues
+func Score(issues []linter.Issue) int {
+	return ScoreFor(Active(issues))
+}
+
+// ScoreFor returns the quality score of a prompt with the given number of active issues
+func ScoreFor(active int) int {
+	score := 100 - IssuePenalty*active
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// Active counts issues that are not dismissed
+func Active(issues []linter.Issue) int {
+	count := 0
+	for _, issue := range issues {
+		if !issue.Dismissed {
+			count++
+		}
+	}
+	return count
+}
+
+// File is the JSON report of a file, dismissed issues are included with their dismissal
+type File struct {
+	File      string         `json:"file,omitempty"`
+	Score     int            `json:"score"`
+	Issues    []linter.Issue `json:"issues"`
+	Dismissed int            `json:"dismissed"`
+}
+
+// NewFile builds the JSON report of a file, Issues is an empty array when there are none
+func NewFile(source string, issues []linter.Issue) File {
+	if issues == nil {
+		issues = []linter.Issue{}
+	}
+	return File{
+		File:      source,
+		Score:     Score(issues),
+		Issues:    issues,
+		Dismissed: len(issues) - Active(issues),
+	}
+}
+
+// JSON formats the found issues of a file as JSON
+func JSON(source string, issues []linter.Issue) (string, error) {
+	data, err := json.MarshalIndent(NewFile(source, issues), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	return string(data), nil
+}
+
+// OriginalSnippet highlights the problematic parts of an example
+func OriginalSnippet(snippet string, useColor bool) string {
+	if !useColor {
+		return snippet
+	}
+	return ColorRed + snippet + ColorReset
+}
+
+// FixedSnippet highlights the fixed parts of an example
+func FixedSnippet(snippet string, useColor bool) string {
+	if !useColor {
+		return snippet
+	}
+	return ColorGreen + snippet + ColorReset
+}
+
+// TextOptions control the terminal features of a text report
+type TextOptions struct {
+	// Color highlights the report with ANSI codes
+	Color bool
+	// Hyperlinks wraps rule names and links of colored reports in OSC 8 hyperlinks, terminals without
+	// support show the plain text
+	Hyperlinks bool
+}
+
+// Text formats the found issues into a report.
+// If there are no issues, returns a message about the absence of problems.
+func Text(issues []linter.Issue, opts TextOptions) string {
+	useColor := opts.Color
+	if len(issues) == 0 {
+		if useColor {
+			return fmt.Sprintf("%s%sNo issues found!%s\n", ColorGreen, ColorBold, ColorReset)
+		}
+		return "No issues found!\n"
+	}
+
+	var sb strings.Builder
+
+	// Output the number of issues found
+	dismissedSuffix := ""
+	if dismissed := len(issues) - Active(issues); dismissed > 0 {
+		dismissedSuffix = fmt.Sprintf(" (%d dismissed)", dismissed)
+	}
+	if useColor {
+		sb.WriteString(fmt.Sprintf("Found %s%d issues%s%s:\n\n", ColorBold, len(issues), ColorReset, dismissedSuffix))
+	} else {
+		sb.WriteString(fmt.Sprintf("Found %d issues%s:\n\n", len(issues), dismissedSuffix))
+	}
+
+	for i, issue := range issues {
+		// Dismissed issues are muted and shown without details
+		if issue.Dismissed {
+			muted := fmt.Sprintf("[Issue %d] [dismissed] %s\nDismissed: %s\nFingerprint: %s\n", i+1, issue.Description, issue.DismissReason, issue.Fingerprint)
+			if useColor {
+				muted = ColorDim + strings.TrimSuffix(muted, "\n") + ColorReset + "\n"
+			}
+			sb.WriteString(muted)
+			if i < len(issues)-1 {
+				sb.WriteString("\n" + strings.Repeat("─", 60) + "\n\n")
+			}
+			continue
+		}
+
+		// Issue header with number and name
+		severity := ""
+		if issue.Severity != "" {
+			severity = "[" + issue.Severity + "] "
+		}
+		if issue.Category != "" {
+			severity += "[" + issue.Category + "] "
+		}
+		if useColor {
+			sb.WriteString(fmt.Sprintf("%s%s[Issue %d] %s%s%s\n", ColorBlue, ColorBold, i+1, severity, issue.Description, ColorReset))
+		} else {
+			sb.WriteString(fmt.Sprintf("[Issue %d] %s%s\n", i+1, severity, issue.Description))
+		}
+
+		// Violated rule with its verbatim text and documentation link
+		if issue.RuleText != "" {
+			if useColor {
+				name, link := issue.RuleName, issue.RuleLink
+				if opts.Hyperlinks && link != "" {
+					name, link = Hyperlink(link, name), Hyperlink(link, link)
+				}
+				sb.WriteString(fmt.Sprintf("%sRule:%s %s — %s\n", ColorBold, ColorReset, name, issue.RuleText))
+				sb.WriteString(fmt.Sprintf("%sDocs:%s %s\n", ColorBold, ColorReset, link))
+			} else {
+				sb.WriteString(fmt.Sprintf("Rule: %s — %s\n", issue.RuleName, issue.RuleText))
+				sb.WriteString(fmt.Sprintf("Docs: %s\n", issue.RuleLink))
+			}
+		}
+
+		// Problem reason
+		if useColor {
+			sb.WriteString(fmt.Sprintf("%sReason:%s %s\n", ColorBold, ColorReset, issue.Reason))
+		} else {
+			sb.WriteString(fmt.Sprintf("Reason: %s\n", issue.Reason))
+		}
+
+		// Fix recommendation
+		if useColor {
+			sb.WriteString(fmt.Sprintf("%sFix:%s %s\n", ColorBold, ColorReset, issue.Fix))
+		} else {
+			sb.WriteString(fmt.Sprintf("Fix: %s\n", issue.Fix))
+		}
+
+		// Location of the problematic snippet
+		if issue.Line > 0 {
+			if useColor {
+				sb.WriteString(fmt.Sprintf("%sLine:%s %s\n", ColorBold, ColorReset, Location(issue)))
+			} else {
+				sb.WriteString(fmt.Sprintf("Line: %s\n", Location(issue)))
+			}
+		}
+
+		// Chat message the issue is in
+		if issue.Message > 0 {
+			if useColor {
+				sb.WriteString(fmt.Sprintf("%sMessage:%s %s\n", ColorBold, ColorReset, MessageLabel(issue)))
+			} else {
+				sb.WriteString(fmt.Sprintf("Message: %s\n", MessageLabel(issue)))
+			}
+		}
+
+		// Fingerprint used to dismiss the issue
+		if issue.Fingerprint != "" {
+			if useColor {
+				sb.WriteString(fmt.Sprintf("%sFingerprint:%s %s\n", ColorBold, ColorReset, issue.Fingerprint))
+			} else {
+				sb.WriteString(fmt.Sprintf("Fingerprint: %s\n", issue.Fingerprint))
+			}
+		}
+
+		// Examples if available
+		if issue.OriginalSnippet != "" && issue.FixedSnippet != "" {
+			sb.WriteString("\n")
+
+			// Format original snippet - display with indentation for multiline snippets
+			if useColor {
+				sb.WriteString(fmt.Sprintf("%sOriginal snippet:%s\n", ColorBold, ColorReset))
+				sb.WriteString(OriginalSnippet(IndentSnippet(issue.OriginalSnippet), useColor))
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("Original snippet:\n")
+				sb.WriteString(IndentSnippet(issue.OriginalSnippet))
+				sb.WriteString("\n")
+			}
+
+			// Format fixed snippet - display with indentation for multiline snippets
+			if useColor {
+				sb.WriteString(fmt.Sprintf("%sFixed snippet:%s\n", ColorBold, ColorReset))
+				sb.WriteString(FixedSnippet(IndentSnippet(issue.FixedSnippet), useColor))
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("Fixed snippet:\n")
+				sb.WriteString(IndentSnippet(issue.FixedSnippet))
+				sb.WriteString("\n")
+			}
+		}
+
+		// Alternative fixes if requested
+		if len(issue.Alternatives) > 0 {
+			sb.WriteString("\n")
+			if useColor {
+				sb.WriteString(fmt.Sprintf("%sAlternatives:%s\n", ColorBold, ColorReset))
+			} else {
+				sb.WriteString("Alternatives:\n")
+			}
+			for _, alternative := range issue.Alternatives {
+				sb.WriteString(fmt.Sprintf("  #%d\n", alternative.Rank))
+				sb.WriteString(FixedSnippet(IndentSnippet(alternative.Snippet), useColor))
+				sb.WriteString("\n")
+				sb.WriteString(fmt.Sprintf("    Pros: %s\n", alternative.Pros))
+				sb.WriteString(fmt.Sprintf("    Cons: %s\n", alternative.Cons))
+			}
+		}
+
+		// Separator between issues
+		if i < len(issues)-1 {
+			sb.WriteString("\n" + strings.Repeat("─", 60) + "\n\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// Accessible formats the issues for screen readers and logs without ANSI support:
+// every issue is announced with a textual status marker and no decorative separators are used
+func Accessible(issues []linter.Issue) string {
+	if len(issues) == 0 {
+		return "OK: No issues found!\n"
+	}
+
+	var sb strings.Builder
+	active := Active(issues)
+	sb.WriteString(fmt.Sprintf("Found %d issues: %d active, %d dismissed.\n", len(issues), active, len(issues)-active))
+
+	for i, issue := range issues {
+		sb.WriteString("\n")
+		marker := "ISSUE"
+		if issue.Dismissed {
+			marker = "DISMISSED"
+		}
+		// The severity is read with the headline, colors of the text report don't reach screen readers
+		severity := ""
+		if issue.Severity != "" {
+			severity = ", " + strings.ToUpper(issue.Severity)
+		}
+		sb.WriteString(fmt.Sprintf("%s %d of %d%s: %s\n", marker, i+1, len(issues), severity, issue.Description))
+
+		if issue.Dismissed {
+			sb.WriteString(fmt.Sprintf("Dismissal reason: %s\n", issue.DismissReason))
+			sb.WriteString(fmt.Sprintf("Fingerprint: %s\n", issue.Fingerprint))
+			continue
+		}
+
+		if issue.RuleText != "" {
+			sb.WriteString(fmt.Sprintf("Rule: %s. %s\n", issue.RuleName, issue.RuleText))
+			sb.WriteString(fmt.Sprintf("Docs: %s\n", issue.RuleLink))
+		}
+		sb.WriteString(fmt.Sprintf("Reason: %s\n", issue.Reason))
+		sb.WriteString(fmt.Sprintf("Fix: %s\n", issue.Fix))
+		if issue.Category != "" {
+			sb.WriteString(fmt.Sprintf("Category: %s\n", issue.Category))
+		}
+		if issue.Line > 0 {
+			sb.WriteString(fmt.Sprintf("Line: %s\n", Location(issue)))
+		}
+		if issue.Message > 0 {
+			sb.WriteString(fmt.Sprintf("Message: %s\n", MessageLabel(issue)))
+		}
+		if issue.Fingerprint != "" {
+			sb.WriteString(fmt.Sprintf("Fingerprint: %s\n", issue.Fingerprint))
+		}
+
+		if issue.OriginalSnippet != "" && issue.FixedSnippet != "" {
+			sb.WriteString("Problem snippet:\n")
+			sb.WriteString(IndentSnippet(issue.OriginalSnippet) + "\n")
+			sb.WriteString("Suggested snippet:\n")
+			sb.WriteString(IndentSnippet(issue.FixedSnippet) + "\n")
+		}
+
+		for j, alternative := range issue.Alternatives {
+			sb.WriteString(fmt.Sprintf("Alternative %d of %d, rank %d:\n", j+1, len(issue.Alternatives), alternative.Rank))
+			sb.WriteString(IndentSnippet(alternative.Snippet) + "\n")
+			sb.WriteString(fmt.Sprintf("Pros: %s\nCons: %s\n", alternative.Pros, alternative.Cons))
+		}
+		sb.WriteString(fmt.Sprintf("End of issue %d.\n", i+1))
+	}
+
+	return sb.String()
+}
+
+// Location formats the line of the issue with the column when it is known
+func Location(issue linter.Issue) string {
+	if issue.Column > 0 {
+		return fmt.Sprintf("%d, column %d", issue.Line, issue.Column)
+	}
+	return fmt.Sprint(issue.Line)
+}
+
+// MessageLabel formats the chat message of the issue: its 1-based index and role
+func MessageLabel(issue linter.Issue) string {
+	if issue.Role == "" {
+		return fmt.Sprint(issue.Message)
+	}
+	return fmt.Sprintf("%d (%s)", issue.Message, issue.Role)
+}
+
+// IndentSnippet adds indentation to each line of a multiline snippet
+func IndentSnippet(snippet string) string {
+	lines := strings.Split(snippet, "\n")
+	for i := range lines {
+		lines[i] = "    " + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,92 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLanguage is the language whose text is used when a rule has no translation for the locale
+const DefaultLanguage = "en"
+
+// UnmarshalYAML accepts reason and fix either as text or as a map of texts by language code
+func (r *Rule) UnmarshalYAML(node *yaml.Node) error {
+	type plain Rule
+	translations := map[string]map[string]string{}
+	if node.Kind == yaml.MappingNode {
+		copied := *node
+		copied.Content = append([]*yaml.Node(nil), node.Content...)
+		for i := 0; i+1 < len(copied.Content); i += 2 {
+			key, value := copied.Content[i].Value, copied.Content[i+1]
+			if (key != "reason" && key != "fix") || value.Kind != yaml.MappingNode {
+				continue
+			}
+			var texts map[string]string
+			if err := value.Decode(&texts); err != nil {
+				return fmt.Errorf("line %d: %s must be a text or a map of texts by language code: %w", value.Line, key, err)
+			}
+			if len(texts) == 0 {
+				return fmt.Errorf("line %d: %s has no texts", value.Line, key)
+			}
+			translations[key] = texts
+			// The rule keeps the default text, so code that doesn't render reports sees a plain rule
+			copied.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: DefaultTranslation(texts)}
+		}
+		node = &copied
+	}
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.ReasonTranslations, r.FixTranslations = translations["reason"], translations["fix"]
+	return nil
+}
+
+// DefaultTranslation returns the English text or, without one, the text of the first language code
+func DefaultTranslation(texts map[string]string) string {
+	if text, ok := texts[DefaultLanguage]; ok {
+		return text
+	}
+	languages := make([]string, 0, len(texts))
+	for language := range texts {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return texts[languages[0]]
+}
+
+// Translation returns the text for the locale: the exact code ("pt-BR"), then the language ("pt"); empty without one
+func Translation(texts map[string]string, locale string) string {
+	if locale == "" || len(texts) == 0 {
+		return ""
+	}
+	normalized := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	language := strings.SplitN(normalized, "-", 2)[0]
+	var fallback string
+	for code, text := range texts {
+		switch strings.ToLower(strings.ReplaceAll(code, "_", "-")) {
+		case normalized:
+			return text
+		case language:
+			fallback = text
+		}
+	}
+	return fallback
+}
+
+// ReasonIn returns the reason in the locale, the default text without a translation
+func (r Rule) ReasonIn(locale string) string {
+	if text := Translation(r.ReasonTranslations, locale); text != "" {
+		return text
+	}
+	return r.Reason
+}
+
+// FixIn returns the fix in the locale, the default text without a translation
+func (r Rule) FixIn(locale string) string {
+	if text := Translation(r.FixTranslations, locale); text != "" {
+		return text
+	}
+	return r.Fix
+}
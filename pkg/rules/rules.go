@@ -0,0 +1,207 @@
+// Package rules loads prompt checking rules: the built-in rule set, downloaded and custom rule files
+// in the prompt_rules.yaml format.
+package rules
+
+import (
+	"embed"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DocsURL points to the generated rule documentation
+const DocsURL = "https://github.com/korchasa/promptlint/blob/main/docs/rules.md"
+
+//go:embed prompt_rules.yaml
+var embeddedRules embed.FS
+
+// embedded is the built-in rule set, parsed on first use
+var embedded struct {
+	once  sync.Once
+	rules *Rules
+	err   error
+}
+
+// Rule represents a rule structure for prompt checking
+type Rule struct {
+	Name string `yaml:"name"`
+	Rule string `yaml:"rule"`
+	// Category groups rules in score explanations: clarity, context, examples, structure, reasoning, robustness
+	Category string `yaml:"category,omitempty"`
+	// Severity of issues of the rule: error, warning (default) or info
+	Severity    string `yaml:"severity,omitempty"`
+	Reason      string `yaml:"reason"`
+	Fix         string `yaml:"fix"`
+	BadExample  string `yaml:"badExample"`
+	GoodExample string `yaml:"goodExample"`
+	// ReasonTranslations and FixTranslations are texts by language code of rules with localized reason or fix maps
+	ReasonTranslations map[string]string `yaml:"-" json:",omitempty"`
+	FixTranslations    map[string]string `yaml:"-" json:",omitempty"`
+	Pattern            string            `yaml:"pattern,omitempty"`
+	MinLength          int               `yaml:"minLength,omitempty"`
+	MaxLength          int               `yaml:"maxLength,omitempty"`
+	// Deprecated rules are reported in configurations that reference them, ReplacedBy names the successor
+	Deprecated bool   `yaml:"deprecated,omitempty"`
+	ReplacedBy string `yaml:"replacedBy,omitempty"`
+	// DocsURL points to the remediation guidance of the rule, an internal wiki page for example;
+	// without it issues link to the rule section of the generated docs
+	DocsURL string `yaml:"docsUrl,omitempty"`
+}
+
+// Rules contains a list of rules for linting
+type Rules struct {
+	// Version of the rule set, compared to decide whether downloaded rules are newer than embedded ones
+	Version     string `yaml:"version,omitempty"`
+	PromptRules []Rule `yaml:"prompt_rules"`
+}
+
+// Embedded returns the rule set built into the binary. The YAML is parsed once, every call returns a copy
+// the caller may change.
+func Embedded() (*Rules, error) {
+	embedded.once.Do(func() {
+		data, err := embeddedRules.ReadFile("prompt_rules.yaml")
+		if err != nil {
+			embedded.err = fmt.Errorf("failed to read embedded rules file: %w", err)
+			return
+		}
+		if embedded.rules, err = Parse(data); err != nil {
+			embedded.err = fmt.Errorf("error parsing embedded YAML file: %w", err)
+		}
+	})
+	if embedded.err != nil {
+		return nil, embedded.err
+	}
+	return &Rules{Version: embedded.rules.Version, PromptRules: append([]Rule(nil), embedded.rules.PromptRules...)}, nil
+}
+
+// Parse decodes a rule set and checks that it is usable
+func Parse(data []byte) (*Rules, error) {
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	if len(rules.PromptRules) == 0 {
+		return nil, fmt.Errorf("the rule set has no rules")
+	}
+	for i, rule := range rules.PromptRules {
+		if strings.TrimSpace(rule.Name) == "" || strings.TrimSpace(rule.Rule) == "" {
+			return nil, fmt.Errorf("rule %d has no name or description", i+1)
+		}
+		if err := CheckDocsURL(rule.DocsURL); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+	}
+	return &rules, nil
+}
+
+// FindRule returns the rule with the given name (case-insensitive) or nil, deprecated names resolve to their replacements
+func (r *Rules) FindRule(name string) *Rule {
+	replacement, _ := r.Replacement(name)
+	if rule := r.FindExact(replacement); rule != nil {
+		return rule
+	}
+	return r.FindExact(name)
+}
+
+// FindExact returns the rule with the given name (case-insensitive) without following replacements
+func (r *Rules) FindExact(name string) *Rule {
+	name = strings.TrimSpace(name)
+	for i := range r.PromptRules {
+		if strings.EqualFold(r.PromptRules[i].Name, name) {
+			return &r.PromptRules[i]
+		}
+	}
+	return nil
+}
+
+// Replacement follows replacedBy links of deprecated rules and returns the name to use instead of the given one.
+// Deprecated is true when the name refers to a deprecated rule, the name is returned unchanged when there is no replacement.
+func (r *Rules) Replacement(name string) (replacement string, deprecated bool) {
+	replacement = strings.TrimSpace(name)
+	seen := map[string]bool{}
+	for {
+		rule := r.FindExact(replacement)
+		if rule == nil || !rule.Deprecated {
+			return replacement, deprecated
+		}
+		deprecated = true
+		key := strings.ToLower(rule.Name)
+		if rule.ReplacedBy == "" || seen[key] {
+			return rule.Name, true
+		}
+		seen[key] = true
+		replacement = rule.ReplacedBy
+	}
+}
+
+// Aliases returns names of deprecated rules replaced by the rule
+func (r *Rules) Aliases(name string) []string {
+	var aliases []string
+	for _, rule := range r.PromptRules {
+		if !rule.Deprecated || strings.EqualFold(rule.Name, name) {
+			continue
+		}
+		if replacement, _ := r.Replacement(rule.Name); strings.EqualFold(replacement, name) {
+			aliases = append(aliases, rule.Name)
+		}
+	}
+	return aliases
+}
+
+// Active returns the rules to check: deprecated rules with a replacement are covered by it
+func (r *Rules) Active() []Rule {
+	active := make([]Rule, 0, len(r.PromptRules))
+	for _, rule := range r.PromptRules {
+		if rule.Deprecated && rule.ReplacedBy != "" {
+			if replacement, _ := r.Replacement(rule.Name); r.FindExact(replacement) != nil && !strings.EqualFold(replacement, rule.Name) {
+				continue
+			}
+		}
+		active = append(active, rule)
+	}
+	return active
+}
+
+// Anchor converts a rule name into a Markdown heading anchor
+func Anchor(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+// DocLink returns a link to the rule section in the generated docs
+func DocLink(name string) string {
+	return DocsURL + "#" + Anchor(name)
+}
+
+// Link returns the remediation guidance of the rule: its docsUrl or its section in the generated docs
+func (r Rule) Link() string {
+	if r.DocsURL != "" {
+		return r.DocsURL
+	}
+	return DocLink(r.Name)
+}
+
+// CheckDocsURL accepts an empty value and absolute http(s) URLs, reports render other links as plain text
+// and terminals refuse to open them
+func CheckDocsURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("docsUrl %q is not an absolute http(s) URL", value)
+	}
+	return nil
+}
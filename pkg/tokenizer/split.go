@@ -0,0 +1,213 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The pre-tokenizers reproduce the splitting patterns of tiktoken by hand, Go regular expressions lack
+// the lookahead of "\s+(?!\S)". Alternatives are tried in the order of the patterns at every position.
+
+// cl100k_base:
+// (?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitCL100K(text string) []string {
+	return split(text, func(text string, pos int) int {
+		if end := contraction(text, pos); end > pos {
+			return end
+		}
+		if end := prefixed(text, pos, letters); end > pos {
+			return end
+		}
+		if end := numbers(text, pos); end > pos {
+			return end
+		}
+		if end := punctuation(text, pos, "\r\n"); end > pos {
+			return end
+		}
+		return whitespace(text, pos)
+	})
+}
+
+// o200k_base:
+// [^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+// [^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+// \p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitO200K(text string) []string {
+	return split(text, func(text string, pos int) int {
+		if end := prefixed(text, pos, lowerWord); end > pos {
+			return end
+		}
+		if end := prefixed(text, pos, upperWord); end > pos {
+			return end
+		}
+		if end := numbers(text, pos); end > pos {
+			return end
+		}
+		if end := punctuation(text, pos, "\r\n/"); end > pos {
+			return end
+		}
+		return whitespace(text, pos)
+	})
+}
+
+// split cuts the text into the pieces matched from every position, match returns the end of the piece
+func split(text string, match func(text string, pos int) int) []string {
+	var pieces []string
+	for pos := 0; pos < len(text); {
+		end := match(text, pos)
+		if end <= pos {
+			// Every rune matches one of the alternatives, this only guards against an endless loop
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			end = pos + size
+		}
+		pieces = append(pieces, text[pos:end])
+		pos = end
+	}
+	return pieces
+}
+
+// runeAt decodes the rune at the position, size 0 at the end of the text
+func runeAt(text string, pos int) (rune, int) {
+	if pos >= len(text) {
+		return 0, 0
+	}
+	return utf8.DecodeRuneInString(text[pos:])
+}
+
+// span returns the end of the longest run of runes from the position that satisfy the predicate
+func span(text string, pos int, predicate func(rune) bool) int {
+	for pos < len(text) {
+		r, size := runeAt(text, pos)
+		if !predicate(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+// contraction matches (?i:'s|'t|'re|'ve|'m|'ll|'d), returning the position itself without a match
+func contraction(text string, pos int) int {
+	if pos >= len(text) || text[pos] != '\'' {
+		return pos
+	}
+	rest := strings.ToLower(text[pos+1 : minInt(len(text), pos+3)])
+	for _, suffix := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+		if strings.HasPrefix(rest, suffix) {
+			return pos + 1 + len(suffix)
+		}
+	}
+	return pos
+}
+
+// minInt returns the smaller of the numbers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// isLetterOrNumber is \p{L} or \p{N}
+func isLetterOrNumber(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// prefixed matches [^\r\n\p{L}\p{N}]?word: the optional prefix is tried first, like a greedy regular expression
+func prefixed(text string, pos int, word func(text string, pos int) int) int {
+	if r, size := runeAt(text, pos); size > 0 && r != '\r' && r != '\n' && !isLetterOrNumber(r) {
+		if end := word(text, pos+size); end > pos+size {
+			return end
+		}
+	}
+	return word(text, pos)
+}
+
+// letters matches \p{L}+
+func letters(text string, pos int) int {
+	return span(text, pos, unicode.IsLetter)
+}
+
+// isUpper is [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]
+func isUpper(r rune) bool {
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// isLower is [\p{Ll}\p{Lm}\p{Lo}\p{M}]
+func isLower(r rune) bool {
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// lowerWord matches upper*lower+contraction? with backtracking: the upper run gives back runes until a lower
+// run can start
+func lowerWord(text string, pos int) int {
+	upperEnd := span(text, pos, isUpper)
+	starts := []int{pos}
+	for i := pos; i < upperEnd; {
+		_, size := runeAt(text, i)
+		i += size
+		starts = append(starts, i)
+	}
+	for i := len(starts) - 1; i >= 0; i-- {
+		if end := span(text, starts[i], isLower); end > starts[i] {
+			return contraction(text, end)
+		}
+	}
+	return pos
+}
+
+// upperWord matches upper+lower*contraction?
+func upperWord(text string, pos int) int {
+	end := span(text, pos, isUpper)
+	if end == pos {
+		return pos
+	}
+	return contraction(text, span(text, end, isLower))
+}
+
+// numbers matches \p{N}{1,3}
+func numbers(text string, pos int) int {
+	end := pos
+	for i := 0; i < 3; i++ {
+		r, size := runeAt(text, end)
+		if size == 0 || !unicode.IsNumber(r) {
+			break
+		}
+		end += size
+	}
+	return end
+}
+
+// punctuation matches " ?[^\s\p{L}\p{N}]+[trailing]*"
+func punctuation(text string, pos int, trailing string) int {
+	isPunctuation := func(r rune) bool { return !unicode.IsSpace(r) && !isLetterOrNumber(r) }
+	start := pos
+	if start < len(text) && text[start] == ' ' {
+		start++
+	}
+	end := span(text, start, isPunctuation)
+	if end == start {
+		return pos
+	}
+	return span(text, end, func(r rune) bool { return strings.ContainsRune(trailing, r) })
+}
+
+// whitespace matches \s*[\r\n]+|\s+(?!\S)|\s+
+func whitespace(text string, pos int) int {
+	end := span(text, pos, unicode.IsSpace)
+	if end == pos {
+		return pos
+	}
+	// \s*[\r\n]+ ends after the last line break of the run
+	if last := strings.LastIndexAny(text[pos:end], "\r\n"); last >= 0 {
+		return pos + last + 1
+	}
+	// \s+(?!\S) leaves the last space to the following word, \s+ takes a single space before it
+	if end < len(text) {
+		if _, size := utf8.DecodeLastRuneInString(text[pos:end]); end-size > pos {
+			return end - size
+		}
+	}
+	return end
+}
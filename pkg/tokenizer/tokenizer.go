@@ -0,0 +1,217 @@
+// Package tokenizer counts tokens like OpenAI's tiktoken: byte pair encoding with the rank files of the
+// cl100k_base and o200k_base encodings. The official rank files are built in gzip compressed, so exact
+// counts need neither a download nor the network.
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ranks contains the official rank files of the encodings
+//
+//go:embed ranks/*.tiktoken.gz
+var ranks embed.FS
+
+// Info describes an encoding and where its rank file is published
+type Info struct {
+	Name string
+	// URL is the official rank file, SHA256 its checksum
+	URL    string
+	SHA256 string
+	split  func(text string) []string
+}
+
+// encodings contains the supported encodings by name
+var encodings = map[string]Info{
+	"cl100k_base": {
+		Name:   "cl100k_base",
+		URL:    "https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken",
+		SHA256: "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7",
+		split:  splitCL100K,
+	},
+	"o200k_base": {
+		Name:   "o200k_base",
+		URL:    "https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken",
+		SHA256: "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d",
+		split:  splitO200K,
+	},
+}
+
+// modelEncodings maps model name prefixes to encodings, the longest matching prefix wins
+var modelEncodings = map[string]string{
+	"gpt-4o":                 "o200k_base",
+	"chatgpt-4o":             "o200k_base",
+	"gpt-4.1":                "o200k_base",
+	"gpt-4.5":                "o200k_base",
+	"gpt-5":                  "o200k_base",
+	"o1":                     "o200k_base",
+	"o3":                     "o200k_base",
+	"o4":                     "o200k_base",
+	"gpt-4":                  "cl100k_base",
+	"gpt-3.5":                "cl100k_base",
+	"gpt-35":                 "cl100k_base",
+	"text-embedding-3":       "cl100k_base",
+	"text-embedding-ada-002": "cl100k_base",
+}
+
+// Names returns sorted names of the supported encodings
+func Names() []string {
+	names := make([]string, 0, len(encodings))
+	for name := range encodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the encoding with the name
+func Lookup(name string) (Info, error) {
+	info, ok := encodings[name]
+	if !ok {
+		return Info{}, fmt.Errorf("unknown encoding %q, supported: %s", name, strings.Join(Names(), ", "))
+	}
+	return info, nil
+}
+
+// ForModel returns the encoding of an OpenAI model, empty for models of other vendors
+func ForModel(model string) string {
+	model = strings.ToLower(strings.TrimSpace(model))
+	// Azure deployments and gateways often prefix the model name, e.g. openai/gpt-4o
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+	best := ""
+	for prefix := range modelEncodings {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	return modelEncodings[best]
+}
+
+// Encoding splits text into pieces and encodes every piece with byte pair merges of its ranks
+type Encoding struct {
+	info  Info
+	ranks map[string]int
+}
+
+// Name returns the name of the encoding
+func (e *Encoding) Name() string {
+	return e.info.Name
+}
+
+// Parse reads a rank file: one base64 encoded token and its rank per line
+func Parse(name string, r io.Reader) (*Encoding, error) {
+	info, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	ranks := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s line %d: expected a token and its rank", name, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid token: %w", name, line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid rank: %w", name, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("%s has no tokens", name)
+	}
+	return &Encoding{info: info, ranks: ranks}, nil
+}
+
+// Load reads the built-in rank file of the encoding and verifies its checksum
+func Load(name string) (*Encoding, error) {
+	info, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := ranks.ReadFile("ranks/" + name + ".tiktoken.gz")
+	if err != nil {
+		return nil, fmt.Errorf("rank file of %s is not built in: %w", name, err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the rank file of %s: %w", name, err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the rank file of %s: %w", name, err)
+	}
+	if err := Verify(info, data); err != nil {
+		return nil, err
+	}
+	return Parse(name, bytes.NewReader(data))
+}
+
+// Verify checks the rank file against the checksum of the official file
+func Verify(info Info, data []byte) error {
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != info.SHA256 {
+		return fmt.Errorf("%s rank file doesn't match the checksum of %s", info.Name, info.URL)
+	}
+	return nil
+}
+
+// Count returns the number of tokens of the text, special tokens are counted as ordinary text
+func (e *Encoding) Count(text string) int {
+	count := 0
+	for _, piece := range e.info.split(text) {
+		if _, ok := e.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += e.mergedLength(piece)
+	}
+	return count
+}
+
+// mergedLength merges the adjacent parts of the piece with the lowest rank until no pair has a rank
+// and returns the number of remaining parts, like tiktoken's byte_pair_merge
+func (e *Encoding) mergedLength(piece string) int {
+	// bounds are the starts of the parts followed by the end of the piece
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := e.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
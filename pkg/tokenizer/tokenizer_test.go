@@ -0,0 +1,127 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		split func(string) []string
+		text  string
+		want  []string
+	}{
+		{"cl100k words", splitCL100K, "Hello world's 12345 !!\n\n  end", []string{"Hello", " world", "'s", " ", "123", "45", " !!\n\n", " ", " end"}},
+		{"cl100k contractions ignore case", splitCL100K, "WE'LL go", []string{"WE", "'LL", " go"}},
+		{"cl100k trailing spaces", splitCL100K, "a  \n", []string{"a", "  \n"}},
+		{"o200k camel case", splitO200K, "HelloWorld's ABCdef", []string{"Hello", "World's", " ABCdef"}},
+		{"o200k slash after punctuation", splitO200K, "a+/b", []string{"a", "+/", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.split(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			if got := strings.Join(tt.split(tt.text), ""); got != tt.text {
+				t.Errorf("pieces join to %q, want the text", got)
+			}
+		})
+	}
+}
+
+// rankFile encodes the tokens with their ranks in the order given
+func rankFile(tokens ...string) string {
+	var sb strings.Builder
+	for rank, token := range tokens {
+		sb.WriteString(fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank))
+	}
+	return sb.String()
+}
+
+func TestCount(t *testing.T) {
+	encoding, err := Parse("cl100k_base", strings.NewReader(rankFile("a", "b", "c", " ", "ab", "abc", " a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		// ab + c + ab, then abc + ab
+		{"abcab", 2},
+		{"ba", 2},
+		{"abc abc", 3},
+		{"ab\nab", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := encoding.Count(tt.text); got != tt.want {
+				t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{"", "YQ==\n", "!!! 1\n", "YQ== one\n"} {
+		if _, err := Parse("cl100k_base", strings.NewReader(data)); err == nil {
+			t.Errorf("Parse(%q) succeeded", data)
+		}
+	}
+	if _, err := Parse("p50k_base", strings.NewReader(rankFile("a"))); err == nil {
+		t.Error("unknown encoding is accepted")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		want     int
+	}{
+		{"cl100k_base", "tiktoken is great!", 6},
+		{"cl100k_base", "Hello, world!", 4},
+		{"o200k_base", "Hello, world!", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding+" "+tt.text, func(t *testing.T) {
+			encoding, err := Load(tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := encoding.Count(tt.text); got != tt.want {
+				t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+	if _, err := Load("p50k_base"); err == nil {
+		t.Error("Load() of an unknown encoding succeeded")
+	}
+	info, _ := Lookup("o200k_base")
+	if err := Verify(info, []byte(rankFile("a", "b"))); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("Verify() of a modified rank file = %v, want a checksum error", err)
+	}
+}
+
+func TestForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o-mini":       "o200k_base",
+		"openai/GPT-4o":     "o200k_base",
+		"gpt-4.1-nano":      "o200k_base",
+		"gpt-4-turbo":       "cl100k_base",
+		"gpt-3.5-turbo":     "cl100k_base",
+		"o3-mini":           "o200k_base",
+		"claude-sonnet-4-0": "",
+	}
+	for model, want := range tests {
+		if got := ForModel(model); got != want {
+			t.Errorf("ForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+	"github.com/korchasa/promptlint/pkg/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// policyPath is the system-level policy file, packagers may move it with -ldflags "-X main.policyPath=..."
+var policyPath = ""
+
+// Policy is the system-level configuration of centrally managed installations. Its keys can't be overridden
+// by project configurations, environment variables or flags.
+type Policy struct {
+	// Providers are the allowed LLM providers, empty allows all
+	Providers []string `yaml:"providers,omitempty"`
+	// Endpoints are the allowed URL prefixes of LLM APIs, empty allows all
+	Endpoints []string `yaml:"endpoints,omitempty"`
+	// Offline forces offline mode
+	Offline bool `yaml:"offline,omitempty"`
+	// Privacy keeps prompts away from services other than the LLM judge: no exports, link checks or embeddings
+	// other than local
+	Privacy bool `yaml:"privacy,omitempty"`
+	// RulePacks are rules files applied over all other rules, relative to the policy file. Their rules can't be
+	// disabled and the fields they set can't be changed.
+	RulePacks []string `yaml:"rule_packs,omitempty"`
+	// MinScore fails runs with a prompt scoring lower, report filters and baselines don't raise the score
+	MinScore int `yaml:"min_score,omitempty"`
+
+	// path is the loaded file, empty without a policy
+	path string
+	// rules are the rules of the packs in order, mandatory their lower-cased names
+	rules     []PromptRule
+	mandatory map[string]bool
+}
+
+// policy is the loaded system-level policy, the zero value allows everything
+var policy Policy
+
+// defaultPolicyPath returns the location of the policy file on the platform
+func defaultPolicyPath() string {
+	if policyPath != "" {
+		return policyPath
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), appName, "policy.yaml")
+	}
+	return "/etc/" + appName + "/policy.yaml"
+}
+
+// loadPolicy reads the policy file, a missing file is no policy. Unknown keys are errors, a misspelled
+// restriction must not silently allow everything.
+func loadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy: %w", err)
+	}
+	var loaded Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&loaded); err != nil && !errors.Is(err, io.EOF) {
+		return Policy{}, fmt.Errorf("error parsing policy %s: %w", path, err)
+	}
+	loaded.path = path
+
+	for i, name := range loaded.Providers {
+		provider, err := llm.Lookup(name)
+		if err != nil {
+			return Policy{}, fmt.Errorf("policy %s: %w", path, err)
+		}
+		loaded.Providers[i] = provider.Name()
+	}
+	for _, endpoint := range loaded.Endpoints {
+		if _, err := parseEndpoint(endpoint); err != nil {
+			return Policy{}, fmt.Errorf("policy %s: invalid endpoint %q: %w", path, endpoint, err)
+		}
+	}
+	if loaded.MinScore < 0 || loaded.MinScore > 100 {
+		return Policy{}, fmt.Errorf("policy %s: min_score must be between 0 and 100, got %d", path, loaded.MinScore)
+	}
+
+	embedded, err := rules.Embedded()
+	if err != nil {
+		return Policy{}, err
+	}
+	loaded.mandatory = map[string]bool{}
+	for _, pack := range loaded.RulePacks {
+		if !filepath.IsAbs(pack) {
+			pack = filepath.Join(filepath.Dir(path), pack)
+		}
+		file, err := loadCustomRulesFile(pack, embedded)
+		if err != nil {
+			return Policy{}, fmt.Errorf("policy %s: %w", path, err)
+		}
+		if file.Replace {
+			return Policy{}, fmt.Errorf("policy %s: rule pack %s can't replace the rules", path, pack)
+		}
+		for _, rule := range file.PromptRules {
+			loaded.rules = append(loaded.rules, rule)
+			loaded.mandatory[strings.ToLower(strings.TrimSpace(rule.Name))] = true
+		}
+	}
+	return loaded, nil
+}
+
+// active reports whether a policy file is loaded
+func (p Policy) active() bool {
+	return p.path != ""
+}
+
+// summary lists the restrictions of the policy
+func (p Policy) summary() string {
+	var parts []string
+	if len(p.Providers) > 0 {
+		parts = append(parts, "providers "+strings.Join(p.Providers, ", "))
+	}
+	if len(p.Endpoints) > 0 {
+		parts = append(parts, "endpoints "+strings.Join(p.Endpoints, ", "))
+	}
+	if p.Offline {
+		parts = append(parts, "offline")
+	}
+	if p.Privacy {
+		parts = append(parts, "privacy mode")
+	}
+	if len(p.rules) > 0 {
+		parts = append(parts, fmt.Sprintf("%d mandatory rules", len(p.mandatory)))
+	}
+	if p.MinScore > 0 {
+		parts = append(parts, fmt.Sprintf("minimum score %d", p.MinScore))
+	}
+	if len(parts) == 0 {
+		return "no restrictions"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// checkProvider rejects providers the policy doesn't allow
+func (p Policy) checkProvider(name string) error {
+	if len(p.Providers) == 0 {
+		return nil
+	}
+	for _, allowed := range p.Providers {
+		if allowed == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("provider %s is not allowed by the policy %s, allowed: %s", name, p.path, strings.Join(p.Providers, ", "))
+}
+
+// parseEndpoint parses an absolute http(s) URL
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("scheme and host are required")
+	}
+	return parsed, nil
+}
+
+// endpointAllowed reports whether the endpoint has the scheme and host of the allowed URL and its path
+// continues the allowed path at a "/" boundary, so https://llm.example doesn't allow https://llm.example.attacker.net
+func endpointAllowed(endpoint, allowed *url.URL) bool {
+	if !strings.EqualFold(endpoint.Scheme, allowed.Scheme) || !strings.EqualFold(endpoint.Host, allowed.Host) {
+		return false
+	}
+	prefix := strings.TrimSuffix(allowed.Path, "/")
+	return prefix == "" || endpoint.Path == prefix || strings.HasPrefix(endpoint.Path, prefix+"/")
+}
+
+// checkEndpoint rejects LLM API endpoints outside the allowed URL prefixes
+func (p Policy) checkEndpoint(endpoint string) error {
+	if len(p.Endpoints) == 0 {
+		return nil
+	}
+	parsed, err := parseEndpoint(endpoint)
+	if err != nil {
+		return fmt.Errorf("endpoint %s is not allowed by the policy %s: %w", endpoint, p.path, err)
+	}
+	for _, prefix := range p.Endpoints {
+		if allowed, err := parseEndpoint(prefix); err == nil && endpointAllowed(parsed, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("endpoint %s is not allowed by the policy %s", endpoint, p.path)
+}
+
+// checkPrivacy rejects a feature that sends prompt content to third parties in privacy mode
+func (p Policy) checkPrivacy(feature string) error {
+	if !p.Privacy {
+		return nil
+	}
+	return fmt.Errorf("%s is disabled by the privacy mode of the policy %s", feature, p.path)
+}
+
+// enforceRules applies the rule packs over the rules again, so later rules files and project
+// configurations can neither drop mandatory rules nor change the fields the packs set
+func (p Policy) enforceRules(base *Rules) *Rules {
+	if len(p.rules) == 0 {
+		return base
+	}
+	result := &Rules{Version: base.Version, PromptRules: append([]PromptRule(nil), base.PromptRules...)}
+	for _, rule := range p.rules {
+		result.PromptRules = overrideRule(result.PromptRules, rule)
+	}
+	return result
+}
+
+// checkScore rejects prompts scoring below the minimum score
+func (p Policy) checkScore(issues []Issue) error {
+	if p.MinScore == 0 {
+		return nil
+	}
+	if score := qualityScore(issues); score < p.MinScore {
+		return fmt.Errorf("score %d is below the minimum %d of the policy %s", score, p.MinScore, p.path)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// hiddenFlags are accepted by the lint command but left out of its help, they are meant for developers
+var hiddenFlags = map[string]bool{"cpuprofile": true, "memprofile": true}
+
+// stopProfiling writes the started profiles, errHandler calls it before exiting
+var stopProfiling = func() {}
+
+// startProfiling starts a CPU profile to cpuFile and arranges a heap profile to memFile, empty paths are skipped.
+// The profiles are complete only after stopProfiling.
+func startProfiling(cpuFile, memFile string) error {
+	var cpu *os.File
+	if cpuFile != "" {
+		var err error
+		if cpu, err = os.Create(cpuFile); err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpu); err != nil {
+			cpu.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+	}
+
+	stopProfiling = func() {
+		stopProfiling = func() {}
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			if err := cpu.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing CPU profile: %v\n", err)
+			}
+		}
+		if memFile != "" {
+			if err := writeHeapProfile(memFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing memory profile: %v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeHeapProfile writes the allocations since the start of the program
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	// Up-to-date statistics include the garbage of the last lint
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// printVisibleDefaults prints the defaults of the flags without the hidden ones
+func printVisibleDefaults(fs *flag.FlagSet) {
+	visible := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	visible.SetOutput(fs.Output())
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+			visible.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	fmt.Fprintf(visible.Output(), "Usage of %s:\n", os.Args[0])
+	visible.PrintDefaults()
+}
@@ -0,0 +1,19 @@
+package main
+
+import "github.com/korchasa/promptlint/pkg/llm"
+
+// defaultProviderName is the provider used when neither --provider, PROMPTLINT_PROVIDER nor the config sets one
+const defaultProviderName = "openai"
+
+// providerName is the provider selected by --provider, empty falls back to PROMPTLINT_PROVIDER and the config
+var providerName string
+
+// Provider and request types are defined by the llm package, providers are registered there
+type (
+	Provider     = llm.Provider
+	ToolSpec     = llm.ToolSpec
+	ToolRequest  = llm.ToolRequest
+	Turn         = llm.Turn
+	ToolCall     = llm.ToolCall
+	ToolResponse = llm.ToolResponse
+)
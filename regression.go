@@ -0,0 +1,394 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// regressionSidecarSuffix marks the expected issues of a corpus prompt: prompt.md has prompt.md.expected.yaml
+const regressionSidecarSuffix = ".expected.yaml"
+
+// RegressionExpectation is the content of a sidecar file
+type RegressionExpectation struct {
+	// Issues are the issues the prompt must be reported with, an empty list expects a clean prompt
+	Issues []ExpectedIssue `yaml:"issues" json:"issues"`
+	// Ignore lists rules whose reports are not scored, e.g. rules the labelers disagree on
+	Ignore []string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+}
+
+// ExpectedIssue is an issue the prompt must be reported with, the snippet must be part of the reported snippet
+// or contain it
+type ExpectedIssue struct {
+	Rule    string `yaml:"rule" json:"rule"`
+	Snippet string `yaml:"snippet,omitempty" json:"snippet,omitempty"`
+}
+
+// RegressionRule counts the matches of a rule over the corpus. Precision is 1 when the rule reported nothing,
+// recall is 1 when no issue of the rule was expected.
+type RegressionRule struct {
+	Rule           string  `json:"rule"`
+	TruePositives  int     `json:"truePositives"`
+	FalsePositives int     `json:"falsePositives"`
+	FalseNegatives int     `json:"falseNegatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+}
+
+// RegressionCase lists the mismatches of a corpus prompt
+type RegressionCase struct {
+	File       string          `json:"file"`
+	Missed     []ExpectedIssue `json:"missed,omitempty"`
+	Unexpected []ExpectedIssue `json:"unexpected,omitempty"`
+}
+
+// RegressionReport is the accuracy of the engine over the corpus
+type RegressionReport struct {
+	Corpus    string           `json:"corpus"`
+	Prompts   int              `json:"prompts"`
+	Expected  int              `json:"expected"`
+	Reported  int              `json:"reported"`
+	Precision float64          `json:"precision"`
+	Recall    float64          `json:"recall"`
+	Rules     []RegressionRule `json:"rules"`
+	Cases     []RegressionCase `json:"cases"`
+}
+
+// loadRegressionSidecar reads the expected issues of a corpus prompt
+func loadRegressionSidecar(path string) (*RegressionExpectation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidecar: %w", err)
+	}
+	var expectation RegressionExpectation
+	if err := yaml.Unmarshal(data, &expectation); err != nil {
+		return nil, fmt.Errorf("error parsing sidecar %s: %w", path, err)
+	}
+	for _, issue := range expectation.Issues {
+		if strings.TrimSpace(issue.Rule) == "" {
+			return nil, fmt.Errorf("sidecar %s: an expected issue has no rule", path)
+		}
+	}
+	return &expectation, nil
+}
+
+// scanRegressionCorpus returns the sidecar files of the corpus in path order
+func scanRegressionCorpus(root string) ([]string, error) {
+	var sidecars []string
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if p != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), regressionSidecarSuffix) {
+			sidecars = append(sidecars, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan corpus: %w", err)
+	}
+	sort.Strings(sidecars)
+	return sidecars, nil
+}
+
+// regressionRuleName resolves a rule or analyzer name to its canonical name, empty for unknown names
+func regressionRuleName(name string, rules *Rules) string {
+	if rule := rules.FindRule(name); rule != nil {
+		return rule.Name
+	}
+	for _, analyzer := range analyzerNames() {
+		if strings.EqualFold(analyzer, strings.TrimSpace(name)) {
+			return analyzer
+		}
+	}
+	return ""
+}
+
+// regressionMatches reports whether the reported issue is the expected one: the same rule and, when the expected
+// issue has a snippet, overlapping snippets
+func regressionMatches(issue Issue, expected ExpectedIssue) bool {
+	if !strings.EqualFold(issue.RuleName, expected.Rule) {
+		return false
+	}
+	want := strings.ToLower(strings.Join(strings.Fields(expected.Snippet), " "))
+	if want == "" {
+		return true
+	}
+	got := strings.ToLower(strings.Join(strings.Fields(issue.OriginalSnippet), " "))
+	return got != "" && (strings.Contains(got, want) || strings.Contains(want, got))
+}
+
+// regressionCounter collects matches by lowercased rule name
+type regressionCounter struct {
+	rules map[string]*RegressionRule
+}
+
+// entry returns the counters of the rule, adding it on first use
+func (c *regressionCounter) entry(name string) *RegressionRule {
+	key := strings.ToLower(name)
+	if rule, ok := c.rules[key]; ok {
+		return rule
+	}
+	rule := &RegressionRule{Rule: name}
+	c.rules[key] = rule
+	return rule
+}
+
+// compare matches the issues of a prompt with the expected ones, every issue matches one expectation,
+// and returns the mismatches
+func (c *regressionCounter) compare(file string, issues []Issue, expectation *RegressionExpectation) RegressionCase {
+	mismatches := RegressionCase{File: file}
+	ignored := map[string]bool{}
+	for _, name := range expectation.Ignore {
+		ignored[strings.ToLower(name)] = true
+	}
+	used := make([]bool, len(issues))
+	for _, expected := range expectation.Issues {
+		matched := false
+		for i, issue := range issues {
+			if !used[i] && regressionMatches(issue, expected) {
+				used[i], matched = true, true
+				break
+			}
+		}
+		if matched {
+			c.entry(expected.Rule).TruePositives++
+			continue
+		}
+		c.entry(expected.Rule).FalseNegatives++
+		mismatches.Missed = append(mismatches.Missed, expected)
+	}
+	for i, issue := range issues {
+		if used[i] || ignored[strings.ToLower(issue.RuleName)] {
+			continue
+		}
+		c.entry(issue.RuleName).FalsePositives++
+		mismatches.Unexpected = append(mismatches.Unexpected, ExpectedIssue{Rule: issue.RuleName, Snippet: issue.OriginalSnippet})
+	}
+	return mismatches
+}
+
+// regressionRatio divides the counts, 1 when there is nothing to divide
+func regressionRatio(part, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(part) / float64(total)
+}
+
+// report computes precision and recall per rule and over the corpus, rules sorted by name
+func (c *regressionCounter) report(root string, prompts int, cases []RegressionCase) RegressionReport {
+	report := RegressionReport{Corpus: root, Prompts: prompts, Rules: []RegressionRule{}, Cases: []RegressionCase{}}
+	truePositives := 0
+	for _, rule := range c.rules {
+		rule.Precision = regressionRatio(rule.TruePositives, rule.TruePositives+rule.FalsePositives)
+		rule.Recall = regressionRatio(rule.TruePositives, rule.TruePositives+rule.FalseNegatives)
+		truePositives += rule.TruePositives
+		report.Expected += rule.TruePositives + rule.FalseNegatives
+		report.Reported += rule.TruePositives + rule.FalsePositives
+		report.Rules = append(report.Rules, *rule)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool { return report.Rules[i].Rule < report.Rules[j].Rule })
+	report.Precision = regressionRatio(truePositives, report.Reported)
+	report.Recall = regressionRatio(truePositives, report.Expected)
+	for _, mismatches := range cases {
+		if len(mismatches.Missed) > 0 || len(mismatches.Unexpected) > 0 {
+			report.Cases = append(report.Cases, mismatches)
+		}
+	}
+	return report
+}
+
+// FormatRegressionReport formats the accuracy per rule and the mismatches for humans
+func FormatRegressionReport(report RegressionReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Corpus %s: %d prompts, %d expected issues, %d reported\n\n", report.Corpus, report.Prompts, report.Expected, report.Reported))
+	width := len("Total")
+	for _, rule := range report.Rules {
+		if len(rule.Rule) > width {
+			width = len(rule.Rule)
+		}
+	}
+	sb.WriteString(fmt.Sprintf("  %-*s %5s %5s %5s %10s %7s\n", width, "Rule", "TP", "FP", "FN", "Precision", "Recall"))
+	for _, rule := range report.Rules {
+		sb.WriteString(fmt.Sprintf("  %-*s %5d %5d %5d %9.0f%% %6.0f%%\n", width, rule.Rule, rule.TruePositives, rule.FalsePositives, rule.FalseNegatives, rule.Precision*100, rule.Recall*100))
+	}
+	truePositives := 0
+	for _, rule := range report.Rules {
+		truePositives += rule.TruePositives
+	}
+	sb.WriteString(fmt.Sprintf("  %-*s %5d %5d %5d %9.0f%% %6.0f%%\n", width, "Total", truePositives, report.Reported-truePositives, report.Expected-truePositives, report.Precision*100, report.Recall*100))
+
+	for _, mismatches := range report.Cases {
+		sb.WriteString("\n" + mismatches.File + ":\n")
+		for _, issue := range mismatches.Missed {
+			sb.WriteString("  missed " + formatExpectedIssue(issue) + "\n")
+		}
+		for _, issue := range mismatches.Unexpected {
+			sb.WriteString("  unexpected " + formatExpectedIssue(issue) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// formatExpectedIssue formats the rule and the snippet of an issue on one line
+func formatExpectedIssue(issue ExpectedIssue) string {
+	snippet := strings.Join(strings.Fields(issue.Snippet), " ")
+	if snippet == "" {
+		return issue.Rule
+	}
+	if len([]rune(snippet)) > 60 {
+		snippet = string([]rune(snippet)[:57]) + "..."
+	}
+	return fmt.Sprintf("%s: %q", issue.Rule, snippet)
+}
+
+// runRegressionCommand implements `promptlint regression --corpus=dir`
+func runRegressionCommand(args []string) error {
+	fs := flag.NewFlagSet("regression", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	corpus := fs.String("corpus", "", "Directory of prompts with "+regressionSidecarSuffix+" sidecars")
+	format := fs.String("format", "text", "Output format: text, json")
+	minPrecision := fs.Float64("min-precision", 0, "Fail when the precision over the corpus is lower, from 0 to 1")
+	minRecall := fs.Float64("min-recall", 0, "Fail when the recall over the corpus is lower, from 0 to 1")
+	engine := fs.String("engine", "llm", "Rule engine: llm, static (rule pattern and length checks without LLM calls), both")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s regression --corpus=dir [--min-precision=0.8] [--min-recall=0.8] [--format=text|json]
+
+Lints every prompt of the corpus that has a sidecar with its expected issues,
+prompt.md is described by prompt.md%s:
+
+  issues:
+    - rule: Assign Persona
+      snippet: Write an application   # optional, part of the reported snippet
+  ignore:
+    - Include Examples                # reports of the rule are not scored
+
+and reports precision and recall per rule, so changes of rules, models and
+judge prompts can be checked in CI. Reported issues match one expected issue
+each, unexpected issues are false positives.
+
+Options:
+`, appName, regressionSidecarSuffix)
+		fs.PrintDefaults()
+	}
+
+	if _, err := parseFlagsWithArgs(fs, args); err != nil {
+		return err
+	}
+	if *corpus == "" {
+		fs.Usage()
+		return fmt.Errorf("--corpus is required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	if *minPrecision < 0 || *minPrecision > 1 || *minRecall < 0 || *minRecall > 1 {
+		return fmt.Errorf("--min-precision and --min-recall must be between 0 and 1")
+	}
+	var err error
+	if ruleEngine, err = parseRuleEngine(*engine); err != nil {
+		return err
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	sidecars, err := scanRegressionCorpus(*corpus)
+	if err != nil {
+		return err
+	}
+	if len(sidecars) == 0 {
+		return fmt.Errorf("no %s sidecars found in %s", regressionSidecarSuffix, *corpus)
+	}
+
+	counter := &regressionCounter{rules: map[string]*RegressionRule{}}
+	var cases []RegressionCase
+	for i, sidecar := range sidecars {
+		file := strings.TrimSuffix(sidecar, regressionSidecarSuffix)
+		expectation, err := loadRegressionSidecar(sidecar)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("prompt of sidecar %s: %w", sidecar, err)
+		}
+		doc, err := loadDocument(file, data, "auto")
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		fileRules, err := rulesForPath(rules, file)
+		if err != nil {
+			return err
+		}
+		if err := validateStaticRules(fileRules); err != nil {
+			return err
+		}
+		if err := loadAnalyzerSettings(file); err != nil {
+			return err
+		}
+		// Typos in sidecars would count as misses forever
+		for j, issue := range expectation.Issues {
+			name := regressionRuleName(issue.Rule, fileRules)
+			if name == "" {
+				return fmt.Errorf("sidecar %s: unknown rule %q", sidecar, issue.Rule)
+			}
+			expectation.Issues[j].Rule = name
+		}
+
+		printProgress(fmt.Sprintf("Checking %s (%d/%d)", filepath.ToSlash(file), i+1, len(sidecars)))
+		issues, err := checkDocumentWithLLM(doc, fileRules, &config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		cases = append(cases, counter.compare(filepath.ToSlash(file), issues, expectation))
+	}
+	printHeuristicNotice(&config)
+
+	report := counter.report(*corpus, len(sidecars), cases)
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode regression report: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(FormatRegressionReport(report))
+	}
+
+	var failures []string
+	if report.Precision < *minPrecision {
+		failures = append(failures, fmt.Sprintf("precision %.2f is below %.2f", report.Precision, *minPrecision))
+	}
+	if report.Recall < *minRecall {
+		failures = append(failures, fmt.Sprintf("recall %.2f is below %.2f", report.Recall, *minRecall))
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, ", "))
+	}
+	return nil
+}
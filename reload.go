@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// liveRules are the rules and analyzer settings of serve and worker: built-in or updated rules merged with the
+// rules files, adjusted by the configuration of the working directory, and the named rule sets of serve.
+// They are loaded again when their files change.
+type liveRules struct {
+	rulesFiles   []string
+	ruleSetFiles map[string]string
+
+	mu       sync.Mutex
+	rules    *Rules
+	ruleSets map[string]*Rules
+}
+
+// newLiveRules loads the rules, the rule sets and the analyzer settings of the working directory
+func newLiveRules(rulesFiles []string, ruleSetFiles map[string]string) (*liveRules, error) {
+	rules, ruleSets, err := loadCommandRules(rulesFiles, ruleSetFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadAnalyzerSettings(""); err != nil {
+		return nil, err
+	}
+	return &liveRules{rulesFiles: rulesFiles, ruleSetFiles: ruleSetFiles, rules: rules, ruleSets: ruleSets}, nil
+}
+
+// loadCommandRules loads the default rules, built-in or updated rules merged with the rules files, and the named
+// rule sets, which merge their file into the default rules. The configuration of the working directory applies
+// to all of them.
+func loadCommandRules(rulesFiles []string, ruleSetFiles map[string]string) (*Rules, map[string]*Rules, error) {
+	base, err := LoadRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	if base, err = applyCustomRules(base, rulesFiles); err != nil {
+		return nil, nil, err
+	}
+	resolve := func(rules *Rules) (*Rules, error) {
+		if rules, err = rulesForPath(rules, ""); err != nil {
+			return nil, err
+		}
+		return rules, validateStaticRules(rules)
+	}
+	rules, err := resolve(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	ruleSets := map[string]*Rules{}
+	for name, path := range ruleSetFiles {
+		set, err := applyCustomRules(base, []string{path})
+		if err == nil {
+			set, err = resolve(set)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("rule set %s: %w", name, err)
+		}
+		ruleSets[name] = set
+	}
+	return rules, ruleSets, nil
+}
+
+// current returns the default rules and the rule sets, prompts checked with them keep them when they are reloaded
+func (l *liveRules) current() (*Rules, map[string]*Rules) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rules, l.ruleSets
+}
+
+// files returns the rules files and the configuration files the rules are loaded from,
+// including the configuration of the working directory that doesn't exist yet
+func (l *liveRules) files() []string {
+	local, _ := filepath.Abs(configFileName)
+	paths, _, _ := findConfigFiles(".")
+	files := append([]string{local}, l.rulesFiles...)
+	for _, path := range l.ruleSetFiles {
+		files = append(files, path)
+	}
+	return appendUnique(files, paths...)
+}
+
+// reload loads the rules and the analyzer settings again and replaces both, a configuration that fails to load
+// leaves the previous rules and settings in place
+func (l *liveRules) reload(changedFiles []string) {
+	printProgress("Changed: " + strings.Join(changedFiles, ", "))
+	rules, ruleSets, err := loadCommandRules(l.rulesFiles, l.ruleSetFiles)
+	if err != nil {
+		printProgress(fmt.Sprintf("Configuration not reloaded, keeping the previous rules and analyzer settings: %v", err))
+		return
+	}
+	settings, err := readAnalyzerSettings("")
+	if err != nil {
+		printProgress(fmt.Sprintf("Configuration not reloaded, keeping the previous rules and analyzer settings: %v", err))
+		return
+	}
+
+	l.mu.Lock()
+	previous, previousSets := l.rules, l.ruleSets
+	l.rules, l.ruleSets = rules, ruleSets
+	settings.apply()
+	l.mu.Unlock()
+	logRuleChanges("Rules", previous, rules)
+	for _, name := range ruleSetNames(ruleSets) {
+		logRuleChanges("Rule set "+name, previousSets[name], ruleSets[name])
+	}
+}
+
+// watch reloads the rules when their files change until the context is done
+func (l *liveRules) watch(ctx context.Context) (io.Closer, error) {
+	watcher, err := newFileWatcher(l.files)
+	if err != nil {
+		return nil, err
+	}
+	go watcher.run(ctx, l.reload)
+	return watcher, nil
+}
+
+// ruleChanges returns the names of rules added, removed and changed between the rule sets
+func ruleChanges(before, after *Rules) (added, removed, changed []string) {
+	previous := map[string]PromptRule{}
+	for _, rule := range before.PromptRules {
+		previous[strings.ToLower(rule.Name)] = rule
+	}
+	for _, rule := range after.PromptRules {
+		old, ok := previous[strings.ToLower(rule.Name)]
+		switch {
+		case !ok:
+			added = append(added, rule.Name)
+		case !reflect.DeepEqual(old, rule):
+			changed = append(changed, rule.Name)
+		}
+		delete(previous, strings.ToLower(rule.Name))
+	}
+	for _, rule := range previous {
+		removed = append(removed, rule.Name)
+	}
+	sort.Strings(removed)
+	return added, removed, changed
+}
+
+// logRuleChanges prints the rules added, removed and changed by a reload
+func logRuleChanges(label string, before, after *Rules) {
+	added, removed, changed := ruleChanges(before, after)
+	if len(added)+len(removed)+len(changed) == 0 {
+		printProgress(label + " reloaded, no rules changed")
+		return
+	}
+	for _, change := range []struct {
+		label string
+		names []string
+	}{{"added", added}, {"removed", removed}, {"changed", changed}} {
+		if len(change.names) > 0 {
+			printProgress(fmt.Sprintf("%s reloaded, %s: %s", label, change.label, strings.Join(change.names, ", ")))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+)
+
+// chdir runs the rest of the test in the directory
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+}
+
+// writeConfig writes the .promptlint.yaml of the working directory
+func writeConfig(t *testing.T, config string) {
+	t.Helper()
+	if err := os.WriteFile(configFileName, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// systemEmojiPolicy returns the configured emoji policy of system messages
+func systemEmojiPolicy() string {
+	return analyzers.Current().EmojiPolicyOf("system")
+}
+
+func TestLiveRulesReload(t *testing.T) {
+	// Cleanups run in reverse, the settings are restored in the directory of the package
+	t.Cleanup(func() { loadAnalyzerSettings("") })
+	chdir(t, t.TempDir())
+
+	live, err := newLiveRules(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &lintServer{rules: live}
+	before, err := server.requestRules(LintRequest{Rules: []string{"Assign Persona"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if systemEmojiPolicy() != analyzers.EmojiWarn {
+		t.Fatalf("system emoji policy %q, want the default %q", systemEmojiPolicy(), analyzers.EmojiWarn)
+	}
+
+	writeConfig(t, "disable:\n  - Use Positive Instructions\nrules:\n  - name: Assign Persona\n    severity: error\nemoji:\n  system: forbid\n")
+	live.reload([]string{configFileName})
+	if rules, _ := live.current(); rules.FindRule("Use Positive Instructions") != nil {
+		t.Error("disabled rule is still active after the reload")
+	}
+	after, err := server.requestRules(LintRequest{Rules: []string{"Assign Persona"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before || after.PromptRules[0].Severity != "error" {
+		t.Errorf("subset after the reload has severity %q, want the reloaded rule", after.PromptRules[0].Severity)
+	}
+	if systemEmojiPolicy() != analyzers.EmojiForbid {
+		t.Errorf("system emoji policy %q after the reload, want %q", systemEmojiPolicy(), analyzers.EmojiForbid)
+	}
+
+	// A configuration that fails to load keeps the rules and the analyzer settings
+	reloaded, _ := live.current()
+	writeConfig(t, "emoji:\n  system: loud\n")
+	live.reload([]string{configFileName})
+	if rules, _ := live.current(); rules != reloaded || systemEmojiPolicy() != analyzers.EmojiForbid {
+		t.Error("invalid configuration replaced the previous rules or analyzer settings")
+	}
+}
+
+func TestRuleChanges(t *testing.T) {
+	before := &Rules{PromptRules: []PromptRule{{Name: "Kept", Rule: "a"}, {Name: "Changed", Rule: "b"}, {Name: "Removed", Rule: "c"}}}
+	after := &Rules{PromptRules: []PromptRule{{Name: "Kept", Rule: "a"}, {Name: "Changed", Rule: "b2"}, {Name: "Added", Rule: "d"}}}
+	added, removed, changed := ruleChanges(before, after)
+	if len(added) != 1 || added[0] != "Added" || len(removed) != 1 || removed[0] != "Removed" || len(changed) != 1 || changed[0] != "Changed" {
+		t.Errorf("ruleChanges() = added %v, removed %v, changed %v", added, removed, changed)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// replayFixtures holds the LLM responses the pipeline tests replay. To record them again, point
+// PROMPTLINT_TEST_RECORD at an OpenAI-compatible API, e.g. https://api.openai.com with PROMPTLINT_API_KEY set.
+const replayFixtures = "testdata/replay"
+
+// replayEndpoint and replayModel are part of the recorded requests, changing them needs new fixtures
+const (
+	replayEndpoint = "https://api.openai.com/v1/chat/completions"
+	replayModel    = "gpt-4o-mini"
+)
+
+// redirectTransport sends requests to the API being recorded, fixtures keep the URL of the original request
+type redirectTransport struct {
+	target *url.URL
+	apiKey string
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	clone.URL.Scheme, clone.URL.Host, clone.Host = t.target.Scheme, t.target.Host, ""
+	if t.apiKey != "" {
+		clone.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+	return http.DefaultTransport.RoundTrip(clone)
+}
+
+// replayLLM replays the recorded responses for the test, or records them when PROMPTLINT_TEST_RECORD is set,
+// and returns the embedded rules with the LLM configuration of the fixtures
+func replayLLM(t *testing.T) (*Rules, *LLMConfig) {
+	t.Helper()
+	previous := llm.Transport()
+	t.Cleanup(func() { llm.SetTransport(previous) })
+	if record := os.Getenv("PROMPTLINT_TEST_RECORD"); record != "" {
+		target, err := url.Parse(record)
+		if err != nil || target.Host == "" {
+			t.Fatalf("PROMPTLINT_TEST_RECORD %q must be the URL of an API", record)
+		}
+		llm.SetTransport(recordingTransport{next: redirectTransport{target: target, apiKey: os.Getenv("PROMPTLINT_API_KEY")}, dir: replayFixtures})
+	} else {
+		llm.SetTransport(replayTransport{dir: replayFixtures})
+	}
+
+	ruleSet, err := rules.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := llm.Lookup("openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ruleSet, &LLMConfig{APIKey: "replay", APIEndpoint: replayEndpoint, ModelName: replayModel, Provider: provider}
+}
+
+// readTestPrompt reads a prompt of testdata/prompts
+func readTestPrompt(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/prompts/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// judgeIssues returns the issues of the rule by line
+func judgeIssues(issues []Issue, ruleName string) map[int]Issue {
+	found := map[int]Issue{}
+	for _, issue := range issues {
+		if issue.RuleName == ruleName {
+			found[issue.Line] = issue
+		}
+	}
+	return found
+}
+
+func TestCheckReplay(t *testing.T) {
+	ruleSet, config := replayLLM(t)
+	issues, err := checkPromptWithLLM(readTestPrompt(t, "support.md"), ruleSet, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := judgeIssues(issues, "Use Positive Instructions")
+	if len(found) != 2 {
+		t.Fatalf("got %d Use Positive Instructions issues, want 2: %+v", len(found), issues)
+	}
+	for _, line := range []int{8, 10} {
+		issue, ok := found[line]
+		if !ok {
+			t.Errorf("no issue on line %d", line)
+			continue
+		}
+		if !strings.HasPrefix(issue.OriginalSnippet, "Never") || issue.FixedSnippet == "" {
+			t.Errorf("line %d: snippets %q → %q", line, issue.OriginalSnippet, issue.FixedSnippet)
+		}
+		if issue.Fingerprint == "" || issue.Reason == "" {
+			t.Errorf("line %d: rule details or fingerprint missing: %+v", line, issue)
+		}
+	}
+	if config.ServedModel != replayModel {
+		t.Errorf("served model %q, want %q", config.ServedModel, replayModel)
+	}
+}
+
+func TestFixReplay(t *testing.T) {
+	ruleSet, config := replayLLM(t)
+	prompt := readTestPrompt(t, "support.md")
+	lint := func(text string) ([]Issue, error) {
+		return checkPromptWithLLM(text, ruleSet, config)
+	}
+	result, err := runFixPipeline(prompt, 1, false, lint, suggestedFix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.History) != 1 || result.History[0].FixesApplied < 2 {
+		t.Fatalf("history %+v, want one pass with the fixes of both Never lines", result.History)
+	}
+	if strings.Contains(result.Prompt, "Never") {
+		t.Errorf("fixed prompt still has negative instructions:\n%s", result.Prompt)
+	}
+	if !strings.Contains(result.Prompt, "Keep answers under 120 words.") {
+		t.Errorf("fixed prompt lost unrelated lines:\n%s", result.Prompt)
+	}
+}
+
+func TestDiffReplay(t *testing.T) {
+	ruleSet, config := replayLLM(t)
+	issues, err := lintDiff(readTestPrompt(t, "support.md"), readTestPrompt(t, "support_v2.md"), 3, ruleSet, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The judge also flags the Never line in the context of the hunk, only the added one is introduced
+	found := judgeIssues(issues, "Use Positive Instructions")
+	issue, ok := found[11]
+	if len(found) != 1 || !ok {
+		t.Fatalf("got issues on lines %v, want only line 11: %+v", found, issues)
+	}
+	if issue.OriginalSnippet != "Never discuss competitors." {
+		t.Errorf("snippet %q still has the diff marker", issue.OriginalSnippet)
+	}
+}
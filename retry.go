@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/llm"
+)
+
+// RetryConfig configures retries of LLM requests failed by network errors, rate limits and server errors
+type RetryConfig struct {
+	// Max is the number of retries after the first attempt, 0 disables retries (default 3)
+	Max *int `yaml:"max,omitempty"`
+	// InitialBackoff is the delay before the first retry, doubled for every next one (default 2s)
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"`
+	// MaxBackoff limits the delay, a longer Retry-After of the API is not waited for (default 60s)
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"`
+}
+
+// validate rejects negative retry settings
+func (c RetryConfig) validate() error {
+	if c.Max != nil && *c.Max < 0 {
+		return fmt.Errorf("retries.max must not be negative, got %d", *c.Max)
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < 0 {
+		return fmt.Errorf("retry backoffs must be positive")
+	}
+	return nil
+}
+
+// configuredRetryPolicy returns the retry policy of LLM requests: PROMPTLINT_MAX_RETRIES, then the config,
+// then llm.DefaultRetryPolicy; every retry is reported as progress
+func configuredRetryPolicy(settings *ProjectConfig) (llm.RetryPolicy, error) {
+	policy := llm.DefaultRetryPolicy
+	if settings.Retries.Max != nil {
+		policy.MaxRetries = *settings.Retries.Max
+	}
+	if value := os.Getenv("PROMPTLINT_MAX_RETRIES"); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return llm.RetryPolicy{}, fmt.Errorf("PROMPTLINT_MAX_RETRIES must be a non-negative number, got %q", value)
+		}
+		policy.MaxRetries = retries
+	}
+	if settings.Retries.InitialBackoff > 0 {
+		policy.InitialBackoff = settings.Retries.InitialBackoff
+	}
+	if settings.Retries.MaxBackoff > 0 {
+		policy.MaxBackoff = settings.Retries.MaxBackoff
+	}
+
+	maxRetries := policy.MaxRetries
+	policy.OnRetry = func(attempt int, delay time.Duration, err error) {
+		printProgress(fmt.Sprintf("LLM request failed (%s), retry %d/%d in %s", retryReason(err), attempt, maxRetries, delay.Round(100*time.Millisecond)))
+	}
+	return policy, nil
+}
+
+// retryReason describes a failed attempt briefly, error responses of APIs are whole JSON documents
+func retryReason(err error) string {
+	var statusErr *llm.StatusError
+	if errors.As(err, &statusErr) {
+		return fmt.Sprintf("HTTP %d", statusErr.StatusCode)
+	}
+	return err.Error()
+}
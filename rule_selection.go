@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// RuleSelection limits the checked rules and analyzers by name, the zero value selects all of them.
+// Names are lower-cased canonical names, deprecated names resolve to their replacements.
+type RuleSelection struct {
+	disabled map[string]bool
+	only     map[string]bool
+}
+
+// ruleSelection is the selection of --disable and --enable-only, it applies on top of the configuration
+var ruleSelection RuleSelection
+
+// disabledAnalyzers are the analyzers not run for the linted file, set by loadAnalyzerSettings
+var disabledAnalyzers map[string]bool
+
+// splitRuleNames splits a comma-separated list of rule names, rule names don't contain commas
+func splitRuleNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// canonicalRuleName resolves a rule or analyzer name, false when neither exists
+func canonicalRuleName(rules *Rules, name string) (string, bool) {
+	if rule := rules.FindRule(name); rule != nil {
+		return strings.ToLower(rule.Name), true
+	}
+	if analyzer, ok := linter.LookupAnalyzer(name); ok {
+		return strings.ToLower(analyzer.Name()), true
+	}
+	return "", false
+}
+
+// newRuleSelection resolves the disabled and the only enabled names against the rules and analyzers,
+// unknown names are returned separately
+func newRuleSelection(rules *Rules, disable, enableOnly []string) (RuleSelection, []string) {
+	var selection RuleSelection
+	var unknown []string
+	resolve := func(names []string) map[string]bool {
+		if len(names) == 0 {
+			return nil
+		}
+		set := map[string]bool{}
+		for _, name := range names {
+			canonical, ok := canonicalRuleName(rules, name)
+			if !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+			set[canonical] = true
+		}
+		return set
+	}
+	selection.disabled = resolve(disable)
+	selection.only = resolve(enableOnly)
+	return selection, unknown
+}
+
+// parseRuleSelection validates --disable and --enable-only against the rules and analyzers
+func parseRuleSelection(rules *Rules, disable, enableOnly string) (RuleSelection, error) {
+	selection, unknown := newRuleSelection(rules, splitRuleNames(disable), splitRuleNames(enableOnly))
+	if len(unknown) > 0 {
+		return RuleSelection{}, fmt.Errorf("unknown rules %s, list the rules with --rules-doc", strings.Join(quoteNames(unknown), ", "))
+	}
+	return selection, nil
+}
+
+// quoteNames quotes every name for messages
+func quoteNames(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return quoted
+}
+
+// allows reports whether the rule or analyzer with the canonical name is checked
+func (s RuleSelection) allows(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if policy.mandatory[name] {
+		return true
+	}
+	if s.disabled[name] {
+		return false
+	}
+	return s.only == nil || s.only[name]
+}
+
+// apply returns the rules without the rules the selection excludes
+func (s RuleSelection) apply(rules *Rules) *Rules {
+	if s.disabled == nil && s.only == nil {
+		return rules
+	}
+	result := &Rules{Version: rules.Version}
+	for _, rule := range rules.PromptRules {
+		if s.allows(rule.Name) {
+			result.PromptRules = append(result.PromptRules, rule)
+		}
+	}
+	return result
+}
+
+// filter drops issues of rules the selection excludes, the judge may report rules it wasn't asked about
+func (s RuleSelection) filter(issues []Issue) []Issue {
+	if s.disabled == nil && s.only == nil {
+		return issues
+	}
+	kept := issues[:0]
+	for _, issue := range issues {
+		if s.allows(issue.RuleName) {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
+
+// loadDisabledAnalyzers returns the analyzers excluded by the configuration files that apply to the path
+// or by --disable and --enable-only
+func loadDisabledAnalyzers(path string) (map[string]bool, error) {
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+	_, configs, err := findConfigFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeConfigs(configs)
+	// Rule names of the configuration are checked by rulesForPath, only analyzer names matter here
+	config, _ := newRuleSelection(&Rules{}, merged.Disable, merged.EnableOnly)
+	disabled := map[string]bool{}
+	for _, name := range analyzerNames() {
+		name = strings.ToLower(name)
+		if !config.allows(name) || !ruleSelection.allows(name) {
+			disabled[name] = true
+		}
+	}
+	return disabled, nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default calibration thresholds: the share of issues of a rule labeled correct below which the rule is
+// disabled or its severity lowered, and above which its severity is raised
+const (
+	defaultCalibrateMinLabels = 5
+	defaultCalibrateDisable   = 0.2
+	defaultCalibrateLower     = 0.5
+	defaultCalibrateRaise     = 0.9
+)
+
+// RuleCalibration is the labeled precision of a rule and the suggested adjustment
+type RuleCalibration struct {
+	Rule      string  `json:"rule"`
+	Correct   int     `json:"correct"`
+	Incorrect int     `json:"incorrect"`
+	Precision float64 `json:"precision"`
+	// Severity is the current severity, empty for analyzers and names outside the active rules
+	Severity string `json:"severity,omitempty"`
+	// Suggested is the suggested severity, empty when the severity is kept
+	Suggested string `json:"suggested,omitempty"`
+	Disable   bool   `json:"disable,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// calibrationThresholds configure when adjustments are suggested
+type calibrationThresholds struct {
+	minLabels int
+	disable   float64
+	lower     float64
+	raise     float64
+}
+
+// calibrationOverrides is the .promptlint.yaml block applying the suggestions
+type calibrationOverrides struct {
+	Disable []string              `yaml:"disable,omitempty"`
+	Rules   []calibrationSeverity `yaml:"rules,omitempty"`
+}
+
+// calibrationSeverity overrides the severity of a rule
+type calibrationSeverity struct {
+	Name     string `yaml:"name"`
+	Severity string `yaml:"severity"`
+}
+
+// calibrateRules counts the latest label of every labeled issue by rule and suggests adjustments of rules with
+// enough labels: rules mostly labeled incorrect are disabled or get a lower severity, precise rules a higher one
+func calibrateRules(examples []FeedbackExample, ruleSet *Rules, thresholds calibrationThresholds) []RuleCalibration {
+	latest := map[string]FeedbackExample{}
+	for _, example := range examples {
+		latest[feedbackKey(example)] = example
+	}
+
+	byRule := map[string]*RuleCalibration{}
+	for _, example := range latest {
+		key := strings.ToLower(strings.TrimSpace(example.Rule))
+		calibration, ok := byRule[key]
+		if !ok {
+			calibration = &RuleCalibration{Rule: strings.TrimSpace(example.Rule)}
+			byRule[key] = calibration
+		}
+		switch example.Label {
+		case labelCorrect:
+			calibration.Correct++
+		case labelIncorrect:
+			calibration.Incorrect++
+		}
+	}
+
+	severities := map[string]string{}
+	for _, rule := range ruleSet.PromptRules {
+		severity := rule.Severity
+		if severity == "" {
+			severity = "warning"
+		}
+		severities[strings.ToLower(rule.Name)] = severity
+	}
+
+	calibrations := make([]RuleCalibration, 0, len(byRule))
+	for key, calibration := range byRule {
+		labeled := calibration.Correct + calibration.Incorrect
+		if labeled == 0 {
+			continue
+		}
+		calibration.Precision = float64(calibration.Correct) / float64(labeled)
+		severity, ok := severities[key]
+		switch {
+		case !ok:
+			calibration.Note = "not an active rule of the rule set"
+		case labeled < thresholds.minLabels:
+			calibration.Severity = severity
+			calibration.Note = fmt.Sprintf("fewer than %d labels", thresholds.minLabels)
+		default:
+			calibration.Severity = severity
+			rank := severityRank(severity)
+			switch {
+			case calibration.Precision < thresholds.disable || (calibration.Precision < thresholds.lower && rank == 0):
+				calibration.Disable = true
+			case calibration.Precision < thresholds.lower:
+				calibration.Suggested = severityLevels[rank-1]
+			case calibration.Precision >= thresholds.raise && rank < len(severityLevels)-1:
+				calibration.Suggested = severityLevels[rank+1]
+			}
+		}
+		calibrations = append(calibrations, *calibration)
+	}
+
+	// Least precise rules first
+	sort.Slice(calibrations, func(i, j int) bool {
+		if calibrations[i].Precision != calibrations[j].Precision {
+			return calibrations[i].Precision < calibrations[j].Precision
+		}
+		return calibrations[i].Rule < calibrations[j].Rule
+	})
+	return calibrations
+}
+
+// calibrationConfig formats the suggested adjustments as a .promptlint.yaml block, empty without suggestions
+func calibrationConfig(calibrations []RuleCalibration) (string, error) {
+	var overrides calibrationOverrides
+	for _, calibration := range calibrations {
+		switch {
+		case calibration.Disable:
+			overrides.Disable = append(overrides.Disable, calibration.Rule)
+		case calibration.Suggested != "":
+			overrides.Rules = append(overrides.Rules, calibrationSeverity{Name: calibration.Rule, Severity: calibration.Suggested})
+		}
+	}
+	if len(overrides.Disable) == 0 && len(overrides.Rules) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(overrides); err != nil {
+		return "", fmt.Errorf("failed to encode overrides: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// FormatCalibration formats the precision of the labeled rules and the override block for humans
+func FormatCalibration(calibrations []RuleCalibration, overrides string) string {
+	var sb strings.Builder
+	for _, calibration := range calibrations {
+		sb.WriteString(fmt.Sprintf("%s: %d correct, %d incorrect, precision %.0f%%", calibration.Rule, calibration.Correct, calibration.Incorrect, calibration.Precision*100))
+		switch {
+		case calibration.Disable:
+			sb.WriteString(" -> disable")
+		case calibration.Suggested != "":
+			sb.WriteString(fmt.Sprintf(" -> severity %s (was %s)", calibration.Suggested, calibration.Severity))
+		case calibration.Note != "":
+			sb.WriteString(" (" + calibration.Note + ")")
+		}
+		sb.WriteString("\n")
+	}
+	if overrides == "" {
+		sb.WriteString(fmt.Sprintf("\nNo adjustments suggested for %d labeled rules\n", len(calibrations)))
+		return sb.String()
+	}
+	sb.WriteString("\nSuggested overrides for " + configFileName + ":\n\n")
+	sb.WriteString(overrides)
+	return sb.String()
+}
+
+// runRulesCalibrateCommand implements `promptlint rules calibrate`
+func runRulesCalibrateCommand(args []string) error {
+	fs := flag.NewFlagSet("rules calibrate", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format: text, json, yaml (only the override block)")
+	feedbackFile := fs.String("feedback", defaultFeedbackFile, "Path to the labeled issues collected with --collect-feedback")
+	minLabels := fs.Int("min-labels", defaultCalibrateMinLabels, "Labels a rule needs before adjustments are suggested")
+	disable := fs.Float64("disable-below", defaultCalibrateDisable, "Precision below which disabling the rule is suggested")
+	lower := fs.Float64("lower-below", defaultCalibrateLower, "Precision below which a lower severity is suggested")
+	raise := fs.Float64("raise-above", defaultCalibrateRaise, "Precision from which a higher severity is suggested")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s rules calibrate [--feedback=file] [--format=text|json|yaml] [--min-labels=n]
+
+Reads issues labeled with --collect-feedback and computes the precision of
+every rule, the share of its issues labeled correct. Rules with enough labels
+and a low precision are suggested to be disabled or get a lower severity,
+precise rules a higher one. The suggestions are printed as a block for
+%s, rule severities include the configuration of the current directory.
+
+Options:
+`, appName, configFileName)
+		fs.PrintDefaults()
+	}
+
+	if _, err := parseFlagsWithArgs(fs, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" && *format != "yaml" {
+		return fmt.Errorf("--format must be text, json or yaml")
+	}
+	if *minLabels < 1 {
+		return fmt.Errorf("--min-labels must be at least 1")
+	}
+	if *disable < 0 || *disable > *lower || *lower > *raise || *raise > 1 {
+		return fmt.Errorf("thresholds must satisfy 0 <= --disable-below <= --lower-below <= --raise-above <= 1")
+	}
+
+	examples, err := loadFeedback(*feedbackFile)
+	if err != nil {
+		return err
+	}
+	if len(examples) == 0 {
+		return fmt.Errorf("no labeled issues in %s, label issues with --collect-feedback first", *feedbackFile)
+	}
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	if rules, err = rulesForPath(rules, ""); err != nil {
+		return err
+	}
+
+	calibrations := calibrateRules(examples, rules, calibrationThresholds{minLabels: *minLabels, disable: *disable, lower: *lower, raise: *raise})
+	overrides, err := calibrationConfig(calibrations)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(calibrations, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode calibration: %w", err)
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		fmt.Print(overrides)
+	default:
+		fmt.Print(FormatCalibration(calibrations, overrides))
+	}
+	return nil
+}
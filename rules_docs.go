@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// ruleAnchor converts a rule name into a Markdown heading anchor
+func ruleAnchor(name string) string {
+	return rules.Anchor(name)
+}
+
+// checkDocsURL validates the docsUrl of a rule
+func checkDocsURL(value string) error {
+	return rules.CheckDocsURL(value)
+}
+
+// GenerateRulesDocs renders the rules as a Markdown document with an anchor per rule
+func GenerateRulesDocs(rules *Rules) string {
+	var sb strings.Builder
+
+	sb.WriteString("# PromptLint Rules\n\n")
+	sb.WriteString("<!-- Generated by `promptlint -rules-doc`, do not edit manually. -->\n\n")
+
+	for _, rule := range rules.PromptRules {
+		sb.WriteString(fmt.Sprintf("- [%s](#%s)\n", rule.Name, ruleAnchor(rule.Name)))
+	}
+
+	for _, rule := range rules.PromptRules {
+		sb.WriteString(fmt.Sprintf("\n## %s\n\n", rule.Name))
+		if rule.Deprecated {
+			if rule.ReplacedBy != "" {
+				sb.WriteString(fmt.Sprintf("**Deprecated:** replaced by [%s](#%s).\n\n", rule.ReplacedBy, ruleAnchor(rule.ReplacedBy)))
+			} else {
+				sb.WriteString("**Deprecated:** will be removed in a future version.\n\n")
+			}
+		}
+		sb.WriteString(fmt.Sprintf("**Rule:** %s\n\n", rule.Rule))
+		if rule.Category != "" {
+			sb.WriteString(fmt.Sprintf("**Category:** %s\n\n", rule.Category))
+		}
+		if rule.Severity != "" {
+			sb.WriteString(fmt.Sprintf("**Severity:** %s\n\n", rule.Severity))
+		}
+		sb.WriteString(fmt.Sprintf("**Reason:** %s\n\n", rule.ReasonIn(ruleLocale)))
+		sb.WriteString(fmt.Sprintf("**Fix:** %s\n", rule.FixIn(ruleLocale)))
+		if rule.DocsURL != "" {
+			sb.WriteString(fmt.Sprintf("\n**Guidance:** <%s>\n", rule.DocsURL))
+		}
+		if rule.BadExample != "" {
+			sb.WriteString("\n**Bad example:**\n\n" + markdownFence(rule.BadExample))
+		}
+		if rule.GoodExample != "" {
+			sb.WriteString("\n**Good example:**\n\n" + markdownFence(rule.GoodExample))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// ruleExampleInstruction introduces a good example of a rule in the request to the LLM API, examples are
+// fragments, so missing parts of a complete prompt must not be reported
+const ruleExampleInstruction = "The following text is a good example fragment from a rule set, not a complete prompt. " +
+	"Report only rules the fragment itself clearly violates, not parts a complete prompt would need:"
+
+// ruleViolationInstruction introduces a bad example checked against its own rule
+const ruleViolationInstruction = "The following text is a bad example fragment from a rule set, not a complete prompt. " +
+	"Report whether it violates the specified rule:"
+
+// RuleFinding is an inconsistency of the examples of a rule found by `rules lint`
+type RuleFinding struct {
+	Rule string `json:"rule"`
+	// Field is the checked field: badExample or goodExample
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Violated is the rule the example violates
+	Violated string `json:"violated,omitempty"`
+}
+
+// lintRuleExamples checks that the examples of the rule exist and differ, that the good example violates no rule
+// of the rule set and that the bad example violates the rule. LLM checks are skipped without a config.
+func lintRuleExamples(rule PromptRule, ruleSet *Rules, config *LLMConfig) ([]RuleFinding, error) {
+	var findings []RuleFinding
+	finding := func(field, severity, message, violated string) {
+		findings = append(findings, RuleFinding{Rule: rule.Name, Field: field, Severity: severity, Message: message, Violated: violated})
+	}
+	good, bad := strings.TrimSpace(rule.GoodExample), strings.TrimSpace(rule.BadExample)
+	if good == "" {
+		finding("goodExample", "warning", "the rule has no good example", "")
+	}
+	if bad == "" {
+		finding("badExample", "warning", "the rule has no bad example", "")
+	}
+	if good != "" && good == bad {
+		finding("goodExample", "error", "the good example is the same as the bad example", "")
+	}
+
+	if good != "" {
+		model := linter.ParsePrompt(good)
+		issues := append(checkStaticRules(model, ruleSet), runAnalyzers(model)...)
+		if config != nil {
+			llmIssues, err := checkContentWithLLM(ruleExampleInstruction, good, ruleSet, config)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+			}
+			issues = append(issues, llmIssues...)
+		}
+		reported := map[string]bool{}
+		for _, issue := range issues {
+			key := strings.ToLower(issue.RuleName)
+			if reported[key] {
+				continue
+			}
+			reported[key] = true
+			message := "the good example violates " + issue.RuleName
+			if strings.EqualFold(issue.RuleName, rule.Name) {
+				message = "the good example violates its own rule"
+			}
+			if issue.Description != "" {
+				message += ": " + issue.Description
+			}
+			finding("goodExample", "error", message, issue.RuleName)
+		}
+	}
+
+	if bad != "" {
+		own := &Rules{PromptRules: []PromptRule{rule}}
+		switch {
+		case hasStaticChecks(rule):
+			if len(checkStaticRules(linter.ParsePrompt(bad), own)) == 0 {
+				finding("badExample", "warning", "the bad example doesn't match the pattern or length bounds of the rule", rule.Name)
+			}
+		case config != nil:
+			issues, err := checkContentWithLLM(ruleViolationInstruction, bad, own, config)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+			}
+			violated := false
+			for _, issue := range issues {
+				violated = violated || strings.EqualFold(issue.RuleName, rule.Name)
+			}
+			if !violated {
+				finding("badExample", "warning", "the LLM finds no violation of the rule in the bad example", rule.Name)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// FormatRuleFindings formats the findings of `rules lint` for humans
+func FormatRuleFindings(findings []RuleFinding, checked int) string {
+	var sb strings.Builder
+	for _, finding := range findings {
+		sb.WriteString(fmt.Sprintf("[%s] %s %s: %s\n", finding.Severity, finding.Rule, finding.Field, finding.Message))
+	}
+	if len(findings) > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("Checked %d rules, %d findings\n", checked, len(findings)))
+	return sb.String()
+}
+
+// runRulesLintCommand implements `promptlint rules lint [pack.yaml...]`
+func runRulesLintCommand(args []string) error {
+	fs := flag.NewFlagSet("rules lint", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	format := fs.String("format", "text", "Output format: text, json")
+	engine := fs.String("engine", "both", "Checks of the examples: static (rule patterns, length bounds and analyzers), llm, both")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s rules lint [--engine=static|llm|both] [--format=text|json] [pack.yaml...]
+
+Lints the badExample and goodExample fields of rules: good examples must not
+violate any rule of the rule set, bad examples must violate their own rule,
+and both must exist and differ. Rules of the given packs are checked against
+the built-in rules merged with the packs, without packs the built-in rules
+are checked. Exits with status 1 when a finding is an error.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	packs, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	if ruleEngine, err = parseRuleEngine(*engine); err != nil {
+		return err
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, packs); err != nil {
+		return err
+	}
+	if err := validateStaticRules(rules); err != nil {
+		return err
+	}
+	if err := loadAnalyzerSettings(""); err != nil {
+		return err
+	}
+
+	// Rules of the packs are checked, the built-in rules only without packs
+	checked := rules.Active()
+	if len(packs) > 0 {
+		names := map[string]bool{}
+		for _, path := range packs {
+			file, err := loadCustomRulesFile(path, rules)
+			if err != nil {
+				return err
+			}
+			for _, rule := range file.PromptRules {
+				names[strings.ToLower(strings.TrimSpace(rule.Name))] = true
+			}
+		}
+		selected := checked[:0]
+		for _, rule := range checked {
+			if names[strings.ToLower(rule.Name)] {
+				selected = append(selected, rule)
+			}
+		}
+		checked = selected
+	}
+
+	var config *LLMConfig
+	if ruleEngine != "static" {
+		llmConfig, err := setupLLMConfig()
+		if err != nil {
+			return err
+		}
+		if llmConfig.Heuristic {
+			printProgress("Warning: no API key, the examples are checked without the LLM")
+		} else {
+			config = &llmConfig
+		}
+	}
+
+	findings := []RuleFinding{}
+	errorCount := 0
+	for _, rule := range checked {
+		printProgress(fmt.Sprintf("Checking examples of %s", rule.Name))
+		ruleFindings, err := lintRuleExamples(rule, rules, config)
+		if err != nil {
+			return err
+		}
+		for _, finding := range ruleFindings {
+			if finding.Severity == "error" {
+				errorCount++
+			}
+		}
+		findings = append(findings, ruleFindings...)
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode findings: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(FormatRuleFindings(findings, len(checked)))
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("%d rule examples are inconsistent", errorCount)
+	}
+	return nil
+}
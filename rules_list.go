@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RuleListing describes a rule or analyzer as configured for the working directory
+type RuleListing struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Category string `json:"category"`
+	Severity string `json:"severity,omitempty"`
+	// Check is llm, static (pattern and length checks) or local for analyzers
+	Check      string `json:"check"`
+	Enabled    bool   `json:"enabled"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	ReplacedBy string `json:"replacedBy,omitempty"`
+	DocsURL    string `json:"docsUrl,omitempty"`
+}
+
+// listRules describes the base rules in order and the analyzers by name. Enabled and severities follow the
+// configuration files of the directory, --disable/--enable-only and the policy.
+func listRules(base *Rules, dir string) ([]RuleListing, error) {
+	// Configurations are looked up from the directory of a path, any file name in dir does
+	path := filepath.Join(dir, configFileName)
+	effective, err := rulesForPath(base, path)
+	if err != nil {
+		return nil, err
+	}
+	disabled, err := loadDisabledAnalyzers(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var listings []RuleListing
+	for _, rule := range base.PromptRules {
+		listing := RuleListing{
+			Name:       rule.Name,
+			Kind:       "rule",
+			Category:   rule.Category,
+			Severity:   rule.Severity,
+			Check:      "llm",
+			Deprecated: rule.Deprecated,
+			ReplacedBy: rule.ReplacedBy,
+			DocsURL:    rule.Link(),
+		}
+		if hasStaticChecks(rule) {
+			listing.Check = "static"
+		}
+		if configured := effective.FindExact(rule.Name); configured != nil {
+			listing.Enabled = !(configured.Deprecated && configured.ReplacedBy != "")
+			listing.Severity = configured.Severity
+			listing.DocsURL = configured.Link()
+		}
+		if listing.Severity == "" {
+			listing.Severity = "warning"
+		}
+		listings = append(listings, listing)
+	}
+	for _, name := range analyzerNames() {
+		listings = append(listings, RuleListing{
+			Name:     name,
+			Kind:     "analyzer",
+			Category: analyzerCategory(name),
+			Check:    "local",
+			Enabled:  !disabled[strings.ToLower(name)],
+		})
+	}
+	return listings, nil
+}
+
+// FormatRuleListings formats the rules and analyzers as a table, guidance links are listed by --format=json
+func FormatRuleListings(listings []RuleListing) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-36s %-9s %-12s %-8s %-7s %s\n", "Name", "Kind", "Category", "Severity", "Check", "Status"))
+	enabled := 0
+	for _, listing := range listings {
+		status := "disabled"
+		switch {
+		case listing.ReplacedBy != "":
+			status = "deprecated, use " + listing.ReplacedBy
+		case listing.Enabled:
+			status = "enabled"
+		}
+		if listing.Enabled {
+			enabled++
+		}
+		if listing.Deprecated && listing.ReplacedBy == "" {
+			status += ", deprecated"
+		}
+		sb.WriteString(fmt.Sprintf("%-36s %-9s %-12s %-8s %-7s %s\n", listing.Name, listing.Kind, listing.Category, listing.Severity, listing.Check, status))
+	}
+	sb.WriteString(fmt.Sprintf("\n%d of %d rules and analyzers enabled\n", enabled, len(listings)))
+	return sb.String()
+}
+
+// runRulesListCommand implements `promptlint rules list`
+func runRulesListCommand(args []string) error {
+	fs := flag.NewFlagSet("rules list", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format: text, json")
+	disable := fs.String("disable", "", "Comma-separated rules and analyzers not checked, in addition to disable in "+configFileName)
+	enableOnly := fs.String("enable-only", "", "Comma-separated rules and analyzers checked, all others are skipped")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s rules list [--format=text|json] [--rules=pack.yaml] [dir]
+
+Lists the rules and analyzers with category, severity and how they are
+checked, and whether the configuration files of the directory (default: the
+working directory), --disable/--enable-only and the policy enable them.
+Deprecated rules name their replacements.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	dirs, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(dirs) > 1 {
+		fs.Usage()
+		return fmt.Errorf("at most one directory is allowed")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	dir := "."
+	if len(dirs) == 1 {
+		dir = dirs[0]
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	if ruleSelection, err = parseRuleSelection(rules, *disable, *enableOnly); err != nil {
+		return err
+	}
+	listings, err := listRules(rules, dir)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(listings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode rules: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Print(FormatRuleListings(listings))
+	return nil
+}
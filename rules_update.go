@@ -0,0 +1,310 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// defaultRulesChannel is the release page that publishes curated rule sets as prompt_rules.yaml assets
+const defaultRulesChannel = "https://github.com/korchasa/promptlint/releases"
+
+// rulesAssetName is the name of the rule set file in releases and in the cache
+const rulesAssetName = "prompt_rules.yaml"
+
+// RulesCacheMeta describes the rule set downloaded by `rules update`
+type RulesCacheMeta struct {
+	Version   string    `json:"version"`
+	Source    string    `json:"source"`
+	SHA256    string    `json:"sha256"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Pinned rule sets are used even when the embedded rules are newer
+	Pinned bool `json:"pinned"`
+}
+
+// rulesCacheDir returns the directory of downloaded rules
+func rulesCacheDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rules"), nil
+}
+
+// compareVersions compares dotted versions like 1.10.0 numerically, a leading "v" is ignored
+func compareVersions(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var pa, pb string
+		if i < len(partsA) {
+			pa = partsA[i]
+		}
+		if i < len(partsB) {
+			pb = partsB[i]
+		}
+		na, errA := strconv.Atoi(pa)
+		nb, errB := strconv.Atoi(pb)
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case (errA != nil || errB != nil) && pa != pb:
+			return strings.Compare(pa, pb)
+		}
+	}
+	return 0
+}
+
+// loadCachedRules returns the downloaded rules, nil when there are none, they aren't the pinned version
+// or the embedded rules are newer
+func loadCachedRules(embeddedVersion, pinnedVersion string) (*Rules, *RulesCacheMeta, error) {
+	dir, err := rulesCacheDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	metaData, err := os.ReadFile(filepath.Join(dir, "rules.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read rules cache: %w", err)
+	}
+	var meta RulesCacheMeta
+	if err := json.Unmarshal(metaData, &meta); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse rules cache: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, rulesAssetName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read cached rules: %w", err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != meta.SHA256 {
+		return nil, nil, fmt.Errorf("cached rules don't match their checksum, run `%s rules update`", appName)
+	}
+	cached, err := rules.Parse(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing cached rules: %w", err)
+	}
+	if pinnedVersion != "" {
+		if compareVersions(cached.Version, pinnedVersion) != 0 {
+			printProgress(fmt.Sprintf("Updated rules %s aren't rules_version %s", cached.Version, pinnedVersion))
+			return nil, nil, nil
+		}
+		return cached, &meta, nil
+	}
+	if !meta.Pinned && compareVersions(cached.Version, embeddedVersion) < 0 {
+		printProgress(fmt.Sprintf("Built-in rules %s are newer than updated rules %s", embeddedVersion, cached.Version))
+		return nil, nil, nil
+	}
+	return cached, &meta, nil
+}
+
+// rulesAssetURL returns the address of the rule set of a release, "latest" is the newest release
+func rulesAssetURL(channel, version string) string {
+	channel = strings.TrimSuffix(channel, "/")
+	if version == "latest" {
+		return channel + "/latest/download/" + rulesAssetName
+	}
+	return channel + "/download/rules-v" + strings.TrimPrefix(version, "v") + "/" + rulesAssetName
+}
+
+// fetchAsset downloads a release asset, found is false when the asset doesn't exist
+func fetchAsset(client *http.Client, url string) (data []byte, found bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", appName+"/"+appVersion)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	return data, true, nil
+}
+
+// fetchRules downloads a rule set, verifies it against the published checksum and checks that a pinned
+// version is the one published
+func fetchRules(channel, version string, timeout time.Duration) (*Rules, []byte, string, error) {
+	client := &http.Client{Timeout: timeout}
+	url := rulesAssetURL(channel, version)
+	data, found, err := fetchAsset(client, url)
+	if err != nil {
+		return nil, nil, url, err
+	}
+	if !found {
+		return nil, nil, url, fmt.Errorf("rule set %s is not published at %s", version, url)
+	}
+
+	// A rule set without its checksum may be truncated or replaced, so it is never installed
+	checksum, found, err := fetchAsset(client, url+".sha256")
+	if err != nil {
+		return nil, nil, url, err
+	}
+	if !found {
+		return nil, nil, url, fmt.Errorf("checksum %s.sha256 is not published", url)
+	}
+	sum := sha256.Sum256(data)
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+		return nil, nil, url, fmt.Errorf("checksum mismatch for %s", url)
+	}
+
+	fetched, err := rules.Parse(data)
+	if err != nil {
+		return nil, nil, url, fmt.Errorf("invalid rule set at %s: %w", url, err)
+	}
+	if version != "latest" && compareVersions(fetched.Version, version) != 0 {
+		return nil, nil, url, fmt.Errorf("rule set at %s is version %q, not the requested %s", url, fetched.Version, version)
+	}
+	return fetched, data, url, nil
+}
+
+// writeFileAtomic replaces the file through a temporary file, so readers never see a partial write
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadRulesVersion returns the rule set version pinned by rules_version in the project configuration, "" if none
+func loadRulesVersion(path string) (string, error) {
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+	_, configs, err := findConfigFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	return mergeConfigs(configs).RulesVersion, nil
+}
+
+// runRulesUpdateCommand implements `promptlint rules update`
+func runRulesUpdateCommand(args []string) error {
+	fs := flag.NewFlagSet("rules update", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	version := fs.String("version", "", "Rule set version to install (default: rules_version from "+configFileName+" or latest)")
+	channel := fs.String("channel", "", "Release channel URL (default: PROMPTLINT_RULES_CHANNEL or "+defaultRulesChannel+")")
+	reset := fs.Bool("reset", false, "Remove downloaded rules and use the built-in rules")
+	timeout := fs.Duration("timeout", 30*time.Second, "Download timeout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s rules update [--version=1.2.0] [--channel=URL] [--reset]
+
+Downloads the curated rule set from the release channel into the user cache,
+verified by the checksum published next to it (prompt_rules.yaml.sha256).
+Linting keeps working offline: without downloaded rules, or when they are
+older than the built-in ones, the rules embedded in the binary are used.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dir, err := rulesCacheDir()
+	if err != nil {
+		return err
+	}
+	if *reset {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove downloaded rules: %w", err)
+		}
+		fmt.Println("Removed downloaded rules, the built-in rules are used")
+		return nil
+	}
+
+	if *version == "" {
+		if *version, err = loadRulesVersion(""); err != nil {
+			return err
+		}
+	}
+	if *version == "" {
+		*version = "latest"
+	}
+	if *channel == "" {
+		*channel = os.Getenv("PROMPTLINT_RULES_CHANNEL")
+	}
+	if *channel == "" {
+		*channel = defaultRulesChannel
+	}
+
+	// The installed rules may not be the pinned version yet, that's what the update fixes
+	current, err := loadRules("")
+	if err != nil {
+		return err
+	}
+
+	printProgress(fmt.Sprintf("Fetching rule set %s from %s", *version, *channel))
+	fetched, data, url, err := fetchRules(*channel, *version, *timeout)
+	if err != nil {
+		return fmt.Errorf("failed to update rules, keeping rules %s: %w", current.Version, err)
+	}
+
+	sum := sha256.Sum256(data)
+	meta := RulesCacheMeta{
+		Version:   fetched.Version,
+		Source:    url,
+		SHA256:    hex.EncodeToString(sum[:]),
+		FetchedAt: time.Now().UTC(),
+		Pinned:    *version != "latest",
+	}
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode rules cache: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create rules cache: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, rulesAssetName), data); err != nil {
+		return fmt.Errorf("failed to write rules: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "rules.json"), metaData); err != nil {
+		return fmt.Errorf("failed to write rules cache: %w", err)
+	}
+
+	if fetched.Version == current.Version {
+		fmt.Printf("Rules are up to date (version %s, %d rules)\n", fetched.Version, len(fetched.PromptRules))
+	} else {
+		fmt.Printf("Updated rules from %s to %s (%d rules)\n", current.Version, fetched.Version, len(fetched.PromptRules))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// ruleSet is a minimal rule set of the version
+func ruleSet(version string) string {
+	return "version: \"" + version + "\"\nprompt_rules:\n  - name: \"Be Specific\"\n    rule: \"Say what you want.\"\n"
+}
+
+// checksumOf returns the .sha256 asset of the data
+func checksumOf(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:]) + "  " + rulesAssetName + "\n"
+}
+
+func TestFetchRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		assets      map[string]string
+		wantErr     string
+		wantVersion string
+	}{
+		{
+			name:    "pinned version",
+			version: "1.3.0",
+			assets: map[string]string{
+				"/download/rules-v1.3.0/prompt_rules.yaml":        ruleSet("1.3.0"),
+				"/download/rules-v1.3.0/prompt_rules.yaml.sha256": checksumOf(ruleSet("1.3.0")),
+			},
+			wantVersion: "1.3.0",
+		},
+		{
+			name:    "latest",
+			version: "latest",
+			assets: map[string]string{
+				"/latest/download/prompt_rules.yaml":        ruleSet("1.4.0"),
+				"/latest/download/prompt_rules.yaml.sha256": checksumOf(ruleSet("1.4.0")),
+			},
+			wantVersion: "1.4.0",
+		},
+		{
+			name:    "missing rule set",
+			version: "1.3.0",
+			assets:  map[string]string{},
+			wantErr: "is not published",
+		},
+		{
+			name:    "missing checksum",
+			version: "1.3.0",
+			assets: map[string]string{
+				"/download/rules-v1.3.0/prompt_rules.yaml": ruleSet("1.3.0"),
+			},
+			wantErr: "prompt_rules.yaml.sha256 is not published",
+		},
+		{
+			name:    "checksum mismatch",
+			version: "1.3.0",
+			assets: map[string]string{
+				"/download/rules-v1.3.0/prompt_rules.yaml":        ruleSet("1.3.0"),
+				"/download/rules-v1.3.0/prompt_rules.yaml.sha256": checksumOf(ruleSet("1.3.1")),
+			},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:    "version differs from the pinned one",
+			version: "v1.3.0",
+			assets: map[string]string{
+				"/download/rules-v1.3.0/prompt_rules.yaml":        ruleSet("1.2.0"),
+				"/download/rules-v1.3.0/prompt_rules.yaml.sha256": checksumOf(ruleSet("1.2.0")),
+			},
+			wantErr: `is version "1.2.0", not the requested v1.3.0`,
+		},
+		{
+			name:    "invalid rule set",
+			version: "1.3.0",
+			assets: map[string]string{
+				"/download/rules-v1.3.0/prompt_rules.yaml":        "version: \"1.3.0\"\n",
+				"/download/rules-v1.3.0/prompt_rules.yaml.sha256": checksumOf("version: \"1.3.0\"\n"),
+			},
+			wantErr: "invalid rule set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				asset, ok := tt.assets[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(asset))
+			}))
+			defer server.Close()
+
+			fetched, data, _, err := fetchRules(server.URL, tt.version, 5*time.Second)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchRules() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fetched.Version != tt.wantVersion || !strings.Contains(string(data), tt.wantVersion) {
+				t.Errorf("fetchRules() = version %s, want %s", fetched.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestLoadRulesPinnedVersion(t *testing.T) {
+	embedded, err := rules.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir, err := rulesCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := ruleSet("99.0.0")
+	sum := sha256.Sum256([]byte(data))
+	meta, err := json.Marshal(RulesCacheMeta{Version: "99.0.0", Source: "test", SHA256: hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, rulesAssetName), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rules.json"), meta, 0644); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, t.TempDir())
+
+	tests := []struct {
+		name        string
+		config      string
+		wantVersion string
+		wantErr     string
+	}{
+		{"newer downloaded rules", "", "99.0.0", ""},
+		{"pinned downloaded rules", "rules_version: 99.0.0\n", "99.0.0", ""},
+		{"pinned built-in rules", "rules_version: " + embedded.Version + "\n", embedded.Version, ""},
+		{"pinned rules are not installed", "rules_version: 98.0.0\n", "", "rules_version 98.0.0 is pinned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfig(t, tt.config)
+			got, err := LoadRules()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRules() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != tt.wantVersion {
+				t.Errorf("LoadRules() = version %s, want %s", got.Version, tt.wantVersion)
+			}
+		})
+	}
+}
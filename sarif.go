@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifFingerprintKey names the issue fingerprint in partialFingerprints, so code scanning tracks issues across runs
+	sarifFingerprintKey = "promptlintFingerprint/v1"
+	// sarifInformationURI is the home page of the tool shown by code scanning
+	sarifInformationURI = "https://github.com/korchasa/promptlint"
+)
+
+// SARIFLog is the root object of a SARIF 2.1.0 file
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun holds the results of one tool run
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the tool and its rules
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the tool component that produced the results
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is the metadata of a rule
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     SARIFText          `json:"shortDescription"`
+	FullDescription      *SARIFText         `json:"fullDescription,omitempty"`
+	Help                 *SARIFText         `json:"help,omitempty"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+	Properties           *SARIFRuleProps    `json:"properties,omitempty"`
+}
+
+// SARIFText is a message with an optional Markdown variant
+type SARIFText struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SARIFConfiguration is the default level of a rule
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFRuleProps holds rule properties understood by code scanning
+type SARIFRuleProps struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+// SARIFResult is a reported issue
+type SARIFResult struct {
+	RuleID              string             `json:"ruleId"`
+	RuleIndex           int                `json:"ruleIndex"`
+	Level               string             `json:"level"`
+	Message             SARIFText          `json:"message"`
+	Locations           []SARIFLocation    `json:"locations"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints,omitempty"`
+	Suppressions        []SARIFSuppression `json:"suppressions,omitempty"`
+}
+
+// SARIFLocation points to the region of the prompt file with the issue
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation is a file and a region in it
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is the file path relative to the repository root
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is the position of the issue, columns are 1-based
+type SARIFRegion struct {
+	StartLine   int        `json:"startLine"`
+	StartColumn int        `json:"startColumn,omitempty"`
+	Snippet     *SARIFText `json:"snippet,omitempty"`
+}
+
+// SARIFSuppression marks a dismissed issue
+type SARIFSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// sarifLevel converts the issue severity, issues without a severity are warnings
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case "note", "info":
+		return "note"
+	}
+	return "warning"
+}
+
+// sarifRuleFor returns the metadata of a rule of the YAML rule set
+func sarifRuleFor(rule PromptRule) SARIFRule {
+	help := rule.Rule
+	var markdown strings.Builder
+	markdown.WriteString(rule.Rule)
+	if reason := rule.ReasonIn(ruleLocale); reason != "" {
+		help += "\n\nWhy: " + reason
+		markdown.WriteString("\n\n**Why:** " + reason)
+	}
+	if fix := rule.FixIn(ruleLocale); fix != "" {
+		help += "\n\nFix: " + fix
+		markdown.WriteString("\n\n**Fix:** " + fix)
+	}
+	if rule.BadExample != "" {
+		markdown.WriteString("\n\n**Bad example:**\n\n" + strings.TrimSuffix(markdownFence(strings.TrimSpace(rule.BadExample)), "\n"))
+	}
+	if rule.GoodExample != "" {
+		markdown.WriteString("\n\n**Good example:**\n\n" + strings.TrimSuffix(markdownFence(strings.TrimSpace(rule.GoodExample)), "\n"))
+	}
+	return SARIFRule{
+		ID:                   ruleAnchor(rule.Name),
+		Name:                 rule.Name,
+		ShortDescription:     SARIFText{Text: rule.Name},
+		FullDescription:      &SARIFText{Text: rule.Rule},
+		Help:                 &SARIFText{Text: help, Markdown: markdown.String()},
+		HelpURI:              rule.Link(),
+		DefaultConfiguration: SARIFConfiguration{Level: sarifLevel(rule.Severity)},
+		Properties:           &SARIFRuleProps{Tags: sarifTags("llm", rule.Category)},
+	}
+}
+
+// sarifTags returns the tags of a rule: prompt, the kind of check and the category when the rule has one
+func sarifTags(kind, category string) []string {
+	tags := []string{"prompt", kind}
+	if category != "" {
+		tags = append(tags, category)
+	}
+	return tags
+}
+
+// sarifRules lists the active YAML rules followed by the analyzers, with the index of every rule id
+func sarifRules(rules *Rules) ([]SARIFRule, map[string]int) {
+	var result []SARIFRule
+	index := map[string]int{}
+	add := func(rule SARIFRule) {
+		if _, ok := index[rule.ID]; ok {
+			return
+		}
+		index[rule.ID] = len(result)
+		result = append(result, rule)
+	}
+	for _, rule := range rules.Active() {
+		add(sarifRuleFor(rule))
+	}
+	for _, name := range analyzerNames() {
+		add(SARIFRule{
+			ID:                   ruleAnchor(name),
+			Name:                 name,
+			ShortDescription:     SARIFText{Text: name},
+			DefaultConfiguration: SARIFConfiguration{Level: "warning"},
+			Properties:           &SARIFRuleProps{Tags: sarifTags("analyzer", analyzerCategory(name))},
+		})
+	}
+	return result, index
+}
+
+// ReportSARIF formats the issues of the linted files as a SARIF 2.1.0 log for code scanning,
+// dismissed issues are suppressed results
+func ReportSARIF(files []LintedFile, rules *Rules) (string, error) {
+	driverRules, index := sarifRules(rules)
+	results := []SARIFResult{}
+	for _, file := range files {
+		uri := "stdin"
+		if file.Name != "" {
+			uri = filepath.ToSlash(filepath.Clean(file.Name))
+		}
+		for _, issue := range file.Issues {
+			id := ruleAnchor(issue.RuleName)
+			ruleIndex, ok := index[id]
+			if !ok {
+				// Rules reported by the LLM outside of the rule set still need metadata
+				ruleIndex = len(driverRules)
+				index[id] = ruleIndex
+				driverRules = append(driverRules, SARIFRule{
+					ID:                   id,
+					Name:                 issue.RuleName,
+					ShortDescription:     SARIFText{Text: issue.RuleName},
+					DefaultConfiguration: SARIFConfiguration{Level: "warning"},
+				})
+			}
+
+			message := issue.Description
+			if issue.Fix != "" {
+				message += "\n\nFix: " + issue.Fix
+			}
+			location := SARIFLocation{PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: uri}}}
+			if issue.Line > 0 {
+				location.PhysicalLocation.Region = &SARIFRegion{StartLine: issue.Line, StartColumn: issue.Column}
+				if issue.OriginalSnippet != "" {
+					location.PhysicalLocation.Region.Snippet = &SARIFText{Text: issue.OriginalSnippet}
+				}
+			}
+			result := SARIFResult{
+				RuleID:    id,
+				RuleIndex: ruleIndex,
+				Level:     sarifLevel(issue.Severity),
+				Message:   SARIFText{Text: message},
+				Locations: []SARIFLocation{location},
+			}
+			if issue.Fingerprint != "" {
+				result.PartialFingerprints = map[string]string{sarifFingerprintKey: issue.Fingerprint}
+			}
+			if issue.Dismissed {
+				result.Suppressions = []SARIFSuppression{{Kind: "external", Justification: issue.DismissReason}}
+			}
+			results = append(results, result)
+		}
+	}
+
+	log := SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{Driver: SARIFDriver{
+				Name:           appName,
+				Version:        appVersion,
+				InformationURI: sarifInformationURI,
+				Rules:          driverRules,
+			}},
+			Results: results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode SARIF report: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSARIFRuleHelpFencesExamples(t *testing.T) {
+	rule := PromptRule{
+		Name:        "Include Examples",
+		Rule:        "Show the expected output.",
+		BadExample:  "Return JSON.",
+		GoodExample: "Return JSON like:\n```json\n{\"name\": \"Ada\"}\n```",
+	}
+	markdown := sarifRuleFor(rule).Help.Markdown
+	// The fence of the good example must outlast the fence inside it
+	want := "**Good example:**\n\n````text\nReturn JSON like:\n```json\n{\"name\": \"Ada\"}\n```\n````"
+	if !strings.HasSuffix(markdown, want) {
+		t.Errorf("help markdown = %q, want it to end with %q", markdown, want)
+	}
+	if !strings.Contains(markdown, "**Bad example:**\n\n```text\nReturn JSON.\n```\n\n") {
+		t.Errorf("help markdown = %q, want the bad example in a plain fence", markdown)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// LintScope limits linting to a line range of the prompt, lines are 1-based and inclusive
+type LintScope struct {
+	From, To int
+	// Section is the title of the section the range is resolved from, empty for --lines
+	Section string
+}
+
+// String describes the scope in progress messages
+func (s LintScope) String() string {
+	if s.Section != "" {
+		return fmt.Sprintf("section %q (lines %d-%d)", s.Section, s.From, s.To)
+	}
+	return fmt.Sprintf("lines %d-%d", s.From, s.To)
+}
+
+// parseLineRange parses --lines: "40-80", "40" for a single line or "40-" up to the end of the prompt (To is 0)
+func parseLineRange(value string) (from, to int, err error) {
+	first, last, isRange := strings.Cut(strings.TrimSpace(value), "-")
+	if from, err = strconv.Atoi(strings.TrimSpace(first)); err != nil || from < 1 {
+		return 0, 0, fmt.Errorf("invalid line range %q, use e.g. 40-80, 40 or 40-", value)
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	if strings.TrimSpace(last) == "" {
+		return from, 0, nil
+	}
+	if to, err = strconv.Atoi(strings.TrimSpace(last)); err != nil || to < from {
+		return 0, 0, fmt.Errorf("invalid line range %q, use e.g. 40-80, 40 or 40-", value)
+	}
+	return from, to, nil
+}
+
+// resolveLintScope returns the lines of the prompt selected by --lines or --section, nil without either.
+// Sections are matched by heading or tag title ignoring case, the first match wins.
+func resolveLintScope(model *PromptModel, lines, section string) (*LintScope, error) {
+	switch {
+	case lines != "" && section != "":
+		return nil, fmt.Errorf("--lines and --section are mutually exclusive")
+	case lines != "":
+		from, to, err := parseLineRange(lines)
+		if err != nil {
+			return nil, err
+		}
+		if to == 0 || to > len(model.Lines) {
+			to = len(model.Lines)
+		}
+		if from > to {
+			return nil, fmt.Errorf("line %d is after the end of the prompt (%d lines)", from, len(model.Lines))
+		}
+		return &LintScope{From: from, To: to}, nil
+	case section != "":
+		title := strings.ToLower(strings.TrimSpace(section))
+		var titles []string
+		for _, s := range model.Sections {
+			if strings.ToLower(strings.TrimSpace(s.Title)) == title {
+				return &LintScope{From: s.Start.Line, To: s.End.Line, Section: s.Title}, nil
+			}
+			titles = append(titles, fmt.Sprintf("%q", s.Title))
+		}
+		if len(titles) == 0 {
+			return nil, fmt.Errorf("section %q not found, the prompt has no sections", section)
+		}
+		return nil, fmt.Errorf("section %q not found, sections: %s", section, strings.Join(titles, ", "))
+	}
+	return nil, nil
+}
+
+// checkScopeWithLLM checks the lines of the scope: the LLM sees only them as a part of a larger prompt,
+// static rules and analyzers run on the whole prompt. Issues keep the line numbers of the whole prompt,
+// issues outside the scope and issues of the whole prompt without a line are dropped.
+func checkScopeWithLLM(prompt string, scope *LintScope, rules *Rules, config *LLMConfig) ([]Issue, error) {
+	model := linter.ParsePrompt(prompt)
+	start := model.LineOffset(scope.From - 1)
+	end := model.LineOffset(scope.To-1) + len(model.Lines[scope.To-1])
+	region := prompt[start:end]
+
+	var issues []Issue
+	if ruleEngine != "static" && len(rules.PromptRules) > 0 && strings.TrimSpace(region) != "" {
+		found, err := checkContentWithLLM(chunkCheckInstruction, region, rules, config)
+		if err != nil {
+			return nil, err
+		}
+		// Positions are relative to the region, snippets quoted in it are located there first
+		regionModel := linter.ParsePrompt(region)
+		for i := range found {
+			found[i].Line, found[i].Column = 0, 0
+			if line := regionModel.LineOf(found[i].OriginalSnippet); line > 0 {
+				found[i].Line = line + scope.From - 1
+			}
+		}
+		issues = found
+	}
+	issues = append(issues, localIssues(model, rules)...)
+	locateIssues(issues, model)
+
+	var scoped []Issue
+	for _, issue := range linter.ApplySuppressions(issues, model) {
+		if issue.Line >= scope.From && issue.Line <= scope.To {
+			scoped = append(scoped, issue)
+		}
+	}
+	return scoped, nil
+}
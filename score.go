@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/report"
+)
+
+// analyzerCategories maps analyzers to the categories of the rule set, analyzers missing here are "other"
+var analyzerCategories = map[string]string{
+	"Instruction Density":         "clarity",
+	"Negation Clusters":           "clarity",
+	"Consistent Addressing":       "clarity",
+	"Reading Level":               "clarity",
+	"URL References":              "context",
+	"Example Matches Schema":      "examples",
+	"Canonical Section Order":     "structure",
+	"Token Budget":                "structure",
+	"Date and Locale Assumptions": "robustness",
+	"Template Variables":          "robustness",
+	"Unescaped Braces":            "robustness",
+	"Encoded Blobs":               "hygiene",
+	"Invisible Characters":        "hygiene",
+	"Emoji Policy":                "style",
+	"Tone":                        "style",
+}
+
+// ScoreExplanation breaks the quality score down by rule category
+type ScoreExplanation struct {
+	File       string          `json:"file,omitempty"`
+	Score      int             `json:"score"`
+	Penalty    int             `json:"penaltyPerIssue"`
+	Active     int             `json:"activeIssues"`
+	Dismissed  int             `json:"dismissedIssues"`
+	Categories []CategoryScore `json:"categories,omitempty"`
+	Gate       *GatePlan       `json:"gate,omitempty"`
+}
+
+// CategoryScore is the points a category costs and the issues contributing to it
+type CategoryScore struct {
+	Category   string       `json:"category"`
+	PointsLost int          `json:"pointsLost"`
+	Issues     []ScoreIssue `json:"issues"`
+}
+
+// ScoreIssue is an active issue with the points it costs
+type ScoreIssue struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Line        int    `json:"line,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Weight      int    `json:"weight"`
+}
+
+// GatePlan tells how many issues, fixed in the order of the explanation, pass the fail_if gate
+type GatePlan struct {
+	Expression string `json:"expression"`
+	Fails      bool   `json:"fails"`
+	// FixesNeeded is -1 when fixing all issues doesn't pass the gate
+	FixesNeeded int `json:"fixesNeeded"`
+	ScoreAfter  int `json:"scoreAfterFixes"`
+}
+
+// analyzerCategory returns the category of the analyzer, empty for analyzers without one
+func analyzerCategory(name string) string {
+	for analyzer, category := range analyzerCategories {
+		if strings.EqualFold(analyzer, name) {
+			return category
+		}
+	}
+	return ""
+}
+
+// issueCategory returns the category of the rule or analyzer that reported the issue, "other" without one
+func issueCategory(issue Issue, rules *Rules) string {
+	if issue.Category != "" {
+		return issue.Category
+	}
+	if rule := rules.FindExact(issue.RuleName); rule != nil && rule.Category != "" {
+		return rule.Category
+	}
+	if category := analyzerCategory(issue.RuleName); category != "" {
+		return category
+	}
+	return "other"
+}
+
+// explainScore groups the active issues by category, categories costing the most points come first
+// and errors come first within a category, so the order is the suggested order of fixes
+func explainScore(source string, issues []Issue, rules *Rules) ScoreExplanation {
+	explanation := ScoreExplanation{File: source, Score: qualityScore(issues), Penalty: report.IssuePenalty, Categories: []CategoryScore{}}
+	index := map[string]int{}
+	for _, issue := range issues {
+		if issue.Dismissed {
+			explanation.Dismissed++
+			continue
+		}
+		explanation.Active++
+		category := issueCategory(issue, rules)
+		i, ok := index[category]
+		if !ok {
+			i = len(explanation.Categories)
+			index[category] = i
+			explanation.Categories = append(explanation.Categories, CategoryScore{Category: category})
+		}
+		explanation.Categories[i].PointsLost += report.IssuePenalty
+		explanation.Categories[i].Issues = append(explanation.Categories[i].Issues, ScoreIssue{
+			Rule:        issue.RuleName,
+			Description: issue.Description,
+			Line:        issue.Line,
+			Severity:    issue.Severity,
+			Fingerprint: issue.Fingerprint,
+			Weight:      report.IssuePenalty,
+		})
+	}
+	for _, category := range explanation.Categories {
+		sort.SliceStable(category.Issues, func(i, j int) bool {
+			return category.Issues[i].Severity == "error" && category.Issues[j].Severity != "error"
+		})
+	}
+	sort.SliceStable(explanation.Categories, func(i, j int) bool {
+		a, b := explanation.Categories[i], explanation.Categories[j]
+		if a.PointsLost != b.PointsLost {
+			return a.PointsLost > b.PointsLost
+		}
+		return a.Category < b.Category
+	})
+	return explanation
+}
+
+// planGate simulates fixing the issues in the order of the explanation until the gate passes
+func planGate(gate *GateExpr, explanation ScoreExplanation, issues []Issue, known []string) (*GatePlan, error) {
+	var order []string
+	for _, category := range explanation.Categories {
+		for _, issue := range category.Issues {
+			order = append(order, issue.Fingerprint)
+		}
+	}
+	plan := &GatePlan{Expression: gate.String(), FixesNeeded: -1}
+	fixed := map[string]bool{}
+	for n := 0; n <= len(order); n++ {
+		if n > 0 {
+			fixed[order[n-1]] = true
+		}
+		var remaining []Issue
+		for _, issue := range issues {
+			if issue.Dismissed || !fixed[issue.Fingerprint] {
+				remaining = append(remaining, issue)
+			}
+		}
+		failed, err := gate.Eval(gateResults(remaining, known))
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			plan.Fails = failed
+		}
+		if !failed {
+			plan.FixesNeeded = n
+			plan.ScoreAfter = qualityScore(remaining)
+			break
+		}
+	}
+	return plan, nil
+}
+
+// FormatScoreExplanation formats the breakdown for humans
+func FormatScoreExplanation(e ScoreExplanation) string {
+	var sb strings.Builder
+	name := e.File
+	if name == "" {
+		name = "stdin"
+	}
+	sb.WriteString(fmt.Sprintf("%s: score %d/100, %d active issues at -%d points each", name, e.Score, e.Active, e.Penalty))
+	if e.Dismissed > 0 {
+		sb.WriteString(fmt.Sprintf(", %d dismissed", e.Dismissed))
+	}
+	sb.WriteString("\n")
+	for _, category := range e.Categories {
+		sb.WriteString(fmt.Sprintf("\n%s: -%d\n", category.Category, category.PointsLost))
+		for _, issue := range category.Issues {
+			location := ""
+			if issue.Line > 0 {
+				location = fmt.Sprintf(" (line %d)", issue.Line)
+			}
+			severity := ""
+			if issue.Severity != "" {
+				severity = " [" + issue.Severity + "]"
+			}
+			sb.WriteString(fmt.Sprintf("  -%d %s%s%s: %s\n", issue.Weight, issue.Rule, severity, location, issue.Description))
+		}
+	}
+	if e.Gate != nil {
+		sb.WriteString("\n")
+		switch {
+		case !e.Gate.Fails:
+			sb.WriteString(fmt.Sprintf("Quality gate: fail_if %q passes\n", e.Gate.Expression))
+		case e.Gate.FixesNeeded < 0:
+			sb.WriteString(fmt.Sprintf("Quality gate: fail_if %q fails and fixing the issues alone doesn't pass it\n", e.Gate.Expression))
+		default:
+			sb.WriteString(fmt.Sprintf("Quality gate: fail_if %q fails, fixing the first %d issues in the order above passes it with score %d\n",
+				e.Gate.Expression, e.Gate.FixesNeeded, e.Gate.ScoreAfter))
+		}
+	}
+	return sb.String()
+}
+
+// runScoreCommand implements `promptlint score [--explain] <file>`
+func runScoreCommand(args []string) error {
+	fs := flag.NewFlagSet("score", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	explain := fs.Bool("explain", false, "Break the score down by rule category with the contributing issues")
+	format := fs.String("format", "text", "Output format: text, json")
+	dismissalsFile := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s score [--explain] [--format=text|json] <file>
+
+Lints the prompt and prints its quality score. With --explain the score is
+broken down by rule category with the issues and points they cost, in the
+order to fix them, and with the number of fixes that pass the fail_if gate.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return fmt.Errorf("exactly one prompt file is required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	source := files[0]
+
+	input, err := readFromFile(source)
+	if err != nil {
+		return err
+	}
+	doc, err := loadDocument(source, []byte(input), "")
+	if err != nil {
+		return err
+	}
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = rulesForPath(rules, source); err != nil {
+		return err
+	}
+	if err := validateStaticRules(rules); err != nil {
+		return err
+	}
+	if err := loadAnalyzerSettings(source); err != nil {
+		return err
+	}
+	gate, err := loadFailIf(source)
+	if err != nil {
+		return err
+	}
+	dismissals, err := LoadDismissals(*dismissalsFile)
+	if err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+
+	issues, err := checkDocumentWithLLM(doc, rules, &config)
+	if err != nil {
+		return err
+	}
+	applyDismissals(issues, dismissals, time.Now())
+	printHeuristicNotice(&config)
+
+	explanation := explainScore(source, issues, rules)
+	if gate != nil {
+		known, err := previousFingerprints(defaultHistoryFile, source)
+		if err != nil {
+			return err
+		}
+		if explanation.Gate, err = planGate(gate, explanation, issues, known); err != nil {
+			return err
+		}
+	}
+
+	if *format == "json" {
+		if !*explain {
+			explanation.Categories = nil
+		}
+		data, err := json.MarshalIndent(explanation, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode score: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if !*explain {
+		fmt.Printf("%s: %d/100\n", source, explanation.Score)
+		return nil
+	}
+	fmt.Print(FormatScoreExplanation(explanation))
+	return nil
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultServeMaxBody limits the size of lint requests
+const defaultServeMaxBody = 1 << 20
+
+// maxCachedRuleSets limits the rule sets of requests the server keeps, inline rule definitions make them unbounded
+const maxCachedRuleSets = 256
+
+// LintRequest is the body of POST /v1/lint
+type LintRequest struct {
+	Prompt string `json:"prompt"`
+	// RuleSet names a rule set of the server (--rule-set) checked instead of its default rules
+	RuleSet string `json:"ruleSet,omitempty"`
+	// RuleDefinitions are rules in the format of a rules file merged into the rules for this request,
+	// a rule with the name of an existing rule overrides its fields
+	RuleDefinitions []PromptRule `json:"ruleDefinitions,omitempty"`
+	// Rules limits the check to the named rules, all rules when empty
+	Rules []string `json:"rules,omitempty"`
+	// Model overrides the model of the server for the request
+	Model string `json:"model,omitempty"`
+}
+
+// LintResponse is the result of POST /v1/lint
+type LintResponse struct {
+	Issues []Issue `json:"issues"`
+	Score  int     `json:"score"`
+	// Model is the model that checked the prompt, "heuristic" without an API key
+	Model string `json:"model"`
+}
+
+// lintServer answers lint requests over HTTP with the rules and LLM configuration of the server
+type lintServer struct {
+	config    LLMConfig
+	token     string
+	maxBody   int64
+	semaphore chan struct{}
+
+	rules *liveRules
+
+	// subsets caches the rules of requests for the rules they were taken from,
+	// the LLM description of a rule set is built once
+	mu          sync.Mutex
+	subsetsBase *Rules
+	subsets     map[string]*Rules
+}
+
+// writeJSON writes the value as the JSON response with the status
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		printProgress(fmt.Sprintf("Failed to write response: %v", err))
+	}
+}
+
+// writeError writes an error response as {"error": "..."}
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// requestRules returns the rules of the request: the named rule set or the default rules of the server,
+// merged with the inline rule definitions and limited to the named rules
+func (s *lintServer) requestRules(request LintRequest) (*Rules, error) {
+	defaults, ruleSets := s.rules.current()
+	base := defaults
+	if request.RuleSet != "" {
+		set, ok := ruleSets[strings.ToLower(strings.TrimSpace(request.RuleSet))]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule set %q, the server has: %s", request.RuleSet, strings.Join(ruleSetNames(ruleSets), ", "))
+		}
+		base = set
+	}
+	if len(request.RuleDefinitions) == 0 && len(request.Rules) == 0 {
+		return base, nil
+	}
+
+	// Requests with the same rule set, definitions and names share their rules
+	definitions, err := json.Marshal(request.RuleDefinitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rule definitions: %w", err)
+	}
+	names := make([]string, 0, len(request.Rules))
+	for _, name := range request.Rules {
+		names = append(names, strings.ToLower(strings.TrimSpace(name)))
+	}
+	sort.Strings(names)
+	cacheKey := strings.Join(append([]string{strings.ToLower(strings.TrimSpace(request.RuleSet)), string(definitions)}, names...), "\x00")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subsetsBase != defaults {
+		s.subsetsBase, s.subsets = defaults, map[string]*Rules{}
+	}
+	if rules, ok := s.subsets[cacheKey]; ok {
+		return rules, nil
+	}
+
+	rules := base
+	if len(request.RuleDefinitions) > 0 {
+		if rules, err = mergeRules(base, request.RuleDefinitions); err != nil {
+			return nil, fmt.Errorf("invalid rule definitions: %w", err)
+		}
+		if err := validateStaticRules(rules); err != nil {
+			return nil, fmt.Errorf("invalid rule definitions: %w", err)
+		}
+	}
+	if rules, err = ruleSubset(rules, request.Rules); err != nil {
+		return nil, err
+	}
+	if len(s.subsets) >= maxCachedRuleSets {
+		s.subsets = map[string]*Rules{}
+	}
+	s.subsets[cacheKey] = rules
+	return rules, nil
+}
+
+// ruleSubset returns the rules limited to the names, deprecated names resolve to their replacements
+func ruleSubset(rules *Rules, names []string) (*Rules, error) {
+	if len(names) == 0 {
+		return rules, nil
+	}
+	selected := map[string]bool{}
+	for _, name := range names {
+		rule := rules.FindRule(name)
+		if rule == nil {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		selected[strings.ToLower(rule.Name)] = true
+	}
+	subset := &Rules{Version: rules.Version}
+	for _, rule := range rules.PromptRules {
+		if selected[strings.ToLower(rule.Name)] {
+			subset.PromptRules = append(subset.PromptRules, rule)
+		}
+	}
+	return subset, nil
+}
+
+// ruleSetNames returns the sorted names of the rule sets
+func ruleSetNames(sets map[string]*Rules) []string {
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseRuleSets reads --rule-set values of the form name=rules.yaml
+func parseRuleSets(values []string) (map[string]string, error) {
+	files := map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if len(parts) != 2 || name == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("--rule-set %q must be name=rules.yaml", value)
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("--rule-set %q is defined twice", name)
+		}
+		files[name] = strings.TrimSpace(parts[1])
+	}
+	return files, nil
+}
+
+// authorized checks the bearer token of the request when the server has one
+func (s *lintServer) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.token)) == 1
+}
+
+// handleLint implements POST /v1/lint
+func (s *lintServer) handleLint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, s.maxBody+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request: "+err.Error())
+		return
+	}
+	if int64(len(data)) > s.maxBody {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", s.maxBody))
+		return
+	}
+	var request LintRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(request.Prompt) == "" {
+		writeError(w, http.StatusBadRequest, "prompt is required")
+		return
+	}
+	rules, err := s.requestRules(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	doc, err := loadDocument("", []byte(request.Prompt), "auto")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Requests beyond the concurrency limit wait for a slot, a client that disconnects gives up its place
+	select {
+	case s.semaphore <- struct{}{}:
+		defer func() { <-s.semaphore }()
+	case <-r.Context().Done():
+		return
+	}
+
+	// Every request gets its own copy of the configuration, the API reports the served model into it
+	config := s.config
+	config.Context = r.Context()
+	if request.Model != "" {
+		config.ModelName = request.Model
+	}
+	issues, err := checkDocumentWithLLM(doc, rules, &config)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		printProgress(fmt.Sprintf("Lint request failed: %v", err))
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if issues == nil {
+		issues = []Issue{}
+	}
+
+	model := config.ModelName
+	switch {
+	case config.Heuristic:
+		model = "heuristic"
+	case ruleEngine == "static":
+		model = "static"
+	case config.ServedModel != "":
+		model = config.ServedModel
+	}
+	writeJSON(w, http.StatusOK, LintResponse{Issues: issues, Score: qualityScore(issues), Model: model})
+}
+
+// handleHealth implements GET /healthz, load balancers check it without a token
+func (s *lintServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	rules, ruleSets := s.rules.current()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "ok",
+		"version":  appVersion,
+		"rules":    len(rules.Active()),
+		"ruleSets": ruleSetNames(ruleSets),
+		"llm":      !s.config.Heuristic && ruleEngine != "static",
+	})
+}
+
+// routes returns the handler of the server
+func (s *lintServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/lint", s.handleLint)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	return mux
+}
+
+// runServeCommand implements `promptlint serve --port 8080`
+func runServeCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	host := fs.String("host", "127.0.0.1", "Interface to listen on, 0.0.0.0 for all interfaces")
+	port := fs.Int("port", 8080, "Port to listen on")
+	concurrency := fs.Int("concurrency", 8, "Number of prompts checked in parallel, further requests wait")
+	maxBody := fs.Int64("max-body", defaultServeMaxBody, "Maximum size of a request body in bytes")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 30*time.Second, "Time running requests get to finish on shutdown")
+	token := fs.String("token", os.Getenv("PROMPTLINT_SERVE_TOKEN"), "Bearer token required by /v1/lint (env PROMPTLINT_SERVE_TOKEN)")
+	engine := fs.String("engine", "both", "Rule engine: static (patterns, length bounds and analyzers only), llm, both")
+	reload := fs.Bool("reload", true, "Reload the rules and analyzer settings when the rules files or .promptlint.yaml change")
+	var rulesFiles, ruleSetFlags stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Var(&ruleSetFlags, "rule-set", "Named rule set requests select with ruleSet, name=rules.yaml merged into the rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s serve [--host=127.0.0.1] [--port=8080] [--rules=pack.yaml] [--rule-set=name=set.yaml] [--token=secret]
+
+Runs an HTTP lint service, so developers don't need their own API keys.
+The LLM configuration and rules are those of the server, including the
+configuration of its working directory.
+
+  POST /v1/lint   {"prompt": "...", "rules": ["Assign Persona"], "model": "..."}
+                  returns {"issues": [...], "score": 80, "model": "..."}
+  GET  /healthz   returns {"status": "ok", ...}
+
+rules and model are optional. A request checks a named rule set with
+"ruleSet": "name" and adds its own rules in the format of a rules file
+with "ruleDefinitions": [{"name": "...", "rule": "...", ...}], a rule with
+the name of an existing rule overrides its fields.
+
+Saving a rules file or .promptlint.yaml reloads the rules and analyzer
+settings without a restart, running requests finish with the previous
+rules. With a token, /v1/lint requires the header
+"Authorization: Bearer <token>". SIGINT and SIGTERM stop accepting requests
+and wait up to --shutdown-timeout for running ones.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *port < 0 || *port > 65535 {
+		return fmt.Errorf("--port must be between 0 and 65535")
+	}
+	if *concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if *maxBody < 1 {
+		return fmt.Errorf("--max-body must be positive")
+	}
+	var err error
+	if ruleEngine, err = parseRuleEngine(*engine); err != nil {
+		return err
+	}
+
+	ruleSetFiles, err := parseRuleSets(ruleSetFlags)
+	if err != nil {
+		return err
+	}
+	rules, err := newLiveRules(rulesFiles, ruleSetFiles)
+	if err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	if config.Heuristic && ruleEngine != "static" {
+		printProgress("Warning: no API key, prompts are checked with heuristics")
+	}
+
+	server := &lintServer{
+		rules:     rules,
+		config:    config,
+		token:     *token,
+		maxBody:   *maxBody,
+		semaphore: make(chan struct{}, *concurrency),
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(*host, strconv.Itoa(*port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	httpServer := &http.Server{Handler: server.routes(), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *reload {
+		watcher, err := rules.watch(ctx)
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.Serve(listener)
+	}()
+	printProgress(fmt.Sprintf("Serving lint API on http://%s", listener.Addr()))
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+	printProgress("Shutting down, waiting for running requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown failed: %w", err)
+	}
+	printProgress("Server stopped")
+	return nil
+}
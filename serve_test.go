@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// testServer returns a server with the built-in rules and a rule set "strict" adding a pattern rule
+func testServer(t *testing.T) *lintServer {
+	t.Helper()
+	chdir(t, t.TempDir())
+	strict := "prompt_rules:\n  - name: No TODO\n    rule: Prompts must not contain TODO markers.\n    pattern: TODO\n"
+	if err := os.WriteFile("strict.yaml", []byte(strict), 0644); err != nil {
+		t.Fatal(err)
+	}
+	live, err := newLiveRules(nil, map[string]string{"strict": "strict.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &lintServer{rules: live}
+}
+
+func TestRequestRules(t *testing.T) {
+	server := testServer(t)
+	defaults, _ := server.rules.current()
+	inline := PromptRule{Name: "No Slang", Rule: "Avoid slang.", Pattern: "(?i)gonna"}
+	tests := []struct {
+		name    string
+		request LintRequest
+		want    []string
+		wantErr string
+	}{
+		{"default rules", LintRequest{}, nil, ""},
+		{"named rules", LintRequest{Rules: []string{"assign persona"}}, []string{"Assign Persona"}, ""},
+		{"rule set", LintRequest{RuleSet: "Strict", Rules: []string{"No TODO"}}, []string{"No TODO"}, ""},
+		{"inline rule", LintRequest{RuleDefinitions: []PromptRule{inline}, Rules: []string{"No Slang"}}, []string{"No Slang"}, ""},
+		{"override", LintRequest{RuleDefinitions: []PromptRule{{Name: "Assign Persona", Severity: "error"}}, Rules: []string{"Assign Persona"}}, []string{"Assign Persona"}, ""},
+		{"unknown rule set", LintRequest{RuleSet: "lenient"}, nil, `unknown rule set "lenient", the server has: strict`},
+		{"unknown rule", LintRequest{Rules: []string{"No TODO"}}, nil, `unknown rule "No TODO"`},
+		{"rule without description", LintRequest{RuleDefinitions: []PromptRule{{Name: "Vague"}}}, nil, `new rule "Vague" has no rule description`},
+		{"invalid pattern", LintRequest{RuleDefinitions: []PromptRule{{Name: "Broken", Rule: "x", Pattern: "("}}}, nil, "invalid rule definitions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := server.requestRules(tt.request)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("requestRules() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("requestRules(): %v", err)
+			}
+			if tt.want == nil {
+				if rules != defaults {
+					t.Errorf("requestRules() = %d rules, want the default rules", len(rules.PromptRules))
+				}
+				return
+			}
+			var names []string
+			for _, rule := range rules.PromptRules {
+				names = append(names, rule.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("requestRules() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestRulesCache(t *testing.T) {
+	server := testServer(t)
+	request := LintRequest{RuleDefinitions: []PromptRule{{Name: "Assign Persona", Severity: "error"}}, Rules: []string{"Assign Persona"}}
+	first, err := server.requestRules(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := server.requestRules(request); again != first {
+		t.Error("the same request built its rules again")
+	}
+	if first.PromptRules[0].Severity != "error" {
+		t.Errorf("inline override severity %q, want error", first.PromptRules[0].Severity)
+	}
+	if defaults, _ := server.rules.current(); defaults.FindRule("Assign Persona").Severity == "error" {
+		t.Error("inline override changed the default rules of the server")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severityLevels are the issue severities from the least to the most severe
+var severityLevels = []string{"info", "warning", "error"}
+
+// severityRank orders severities, issues without a severity are warnings and unknown values rank as warnings
+func severityRank(severity string) int {
+	for i, level := range severityLevels {
+		if severity == level {
+			return i
+		}
+	}
+	return 1
+}
+
+// validSeverity reports whether the value is a known severity or empty
+func validSeverity(severity string) bool {
+	if severity == "" {
+		return true
+	}
+	for _, level := range severityLevels {
+		if severity == level {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFailOn validates the --fail-on threshold, an empty value never fails
+func parseFailOn(value string) (string, error) {
+	if !validSeverity(value) {
+		return "", fmt.Errorf("unknown threshold %q, supported: %s", value, strings.Join(severityLevels, ", "))
+	}
+	return value, nil
+}
+
+// checkFailOn returns an error when active issues of the files reach the severity threshold
+func checkFailOn(threshold string, files []LintedFile) error {
+	if threshold == "" {
+		return nil
+	}
+	count := 0
+	for _, file := range files {
+		for _, issue := range file.Issues {
+			if !issue.Dismissed && severityRank(issue.Severity) >= severityRank(threshold) {
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	if threshold == "error" {
+		return fmt.Errorf("%d issues with severity error", count)
+	}
+	return fmt.Errorf("%d issues with severity %s or above", count, threshold)
+}
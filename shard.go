@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shard selects the part of the discovered files a CI matrix job lints, Index is 1-based
+type Shard struct {
+	Index int
+	Count int
+}
+
+// String formats the shard as in --shard
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// parseShard parses --shard=i/n, an empty value disables sharding
+func parseShard(value string) (*Shard, error) {
+	if value == "" {
+		return nil, nil
+	}
+	index, count, ok := strings.Cut(value, "/")
+	if !ok {
+		return nil, fmt.Errorf("%q must have the form i/n, e.g. 1/4", value)
+	}
+	var shard Shard
+	var err error
+	if shard.Index, err = strconv.Atoi(strings.TrimSpace(index)); err != nil {
+		return nil, fmt.Errorf("%q has an invalid shard index", value)
+	}
+	if shard.Count, err = strconv.Atoi(strings.TrimSpace(count)); err != nil {
+		return nil, fmt.Errorf("%q has an invalid shard count", value)
+	}
+	if shard.Count < 1 || shard.Index < 1 || shard.Index > shard.Count {
+		return nil, fmt.Errorf("%q needs a count of at least 1 and an index from 1 to the count", value)
+	}
+	return &shard, nil
+}
+
+// shardFiles returns the files of the shard. The files are sorted by their cleaned slash paths and dealt out
+// round-robin, so every job of the matrix gets the same partition regardless of discovery order and the shards
+// differ in size by at most one file.
+func shardFiles(files []string, shard Shard) []string {
+	sorted := append([]string(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return filepath.ToSlash(filepath.Clean(sorted[i])) < filepath.ToSlash(filepath.Clean(sorted[j]))
+	})
+	var selected []string
+	for i, file := range sorted {
+		if i%shard.Count == shard.Index-1 {
+			selected = append(selected, file)
+		}
+	}
+	return selected
+}
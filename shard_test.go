@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShardFiles(t *testing.T) {
+	files := []string{"prompts/c.md", "./prompts/a.md", "prompts/b.md", "z.md", "prompts/d.md"}
+	tests := []struct {
+		shard Shard
+		want  []string
+	}{
+		{Shard{Index: 1, Count: 1}, []string{"./prompts/a.md", "prompts/b.md", "prompts/c.md", "prompts/d.md", "z.md"}},
+		{Shard{Index: 1, Count: 2}, []string{"./prompts/a.md", "prompts/c.md", "z.md"}},
+		{Shard{Index: 2, Count: 2}, []string{"prompts/b.md", "prompts/d.md"}},
+		{Shard{Index: 3, Count: 3}, []string{"prompts/c.md"}},
+		{Shard{Index: 5, Count: 6}, []string{"z.md"}},
+		{Shard{Index: 6, Count: 6}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shard.String(), func(t *testing.T) {
+			if got := shardFiles(files, tt.shard); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shardFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardFilesPartition(t *testing.T) {
+	files := []string{"e.md", "b.md", "a.md", "d.md", "c.md", "f.md", "g.md"}
+	reversed := make([]string, len(files))
+	for i, file := range files {
+		reversed[len(files)-1-i] = file
+	}
+	seen := map[string]int{}
+	for index := 1; index <= 3; index++ {
+		shard := Shard{Index: index, Count: 3}
+		got := shardFiles(files, shard)
+		if !reflect.DeepEqual(got, shardFiles(reversed, shard)) {
+			t.Errorf("shard %s depends on the order of the files", shard)
+		}
+		if len(got) < 2 || len(got) > 3 {
+			t.Errorf("shard %s has %d files, want 2 or 3", shard, len(got))
+		}
+		for _, file := range got {
+			seen[file]++
+		}
+	}
+	for _, file := range files {
+		if seen[file] != 1 {
+			t.Errorf("%s is in %d shards, want 1", file, seen[file])
+		}
+	}
+}
+
+func TestParseShard(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *Shard
+		wantErr string
+	}{
+		{"", nil, ""},
+		{"1/4", &Shard{Index: 1, Count: 4}, ""},
+		{" 2 / 2 ", &Shard{Index: 2, Count: 2}, ""},
+		{"3", nil, "must have the form i/n"},
+		{"a/2", nil, "invalid shard index"},
+		{"1/b", nil, "invalid shard count"},
+		{"0/2", nil, "index from 1 to the count"},
+		{"3/2", nil, "index from 1 to the count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseShard(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseShard(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseShard(%q): %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseShard(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,614 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// maxSmokeProblems bounds the schema mismatches listed for a response
+const maxSmokeProblems = 10
+
+// OutputContract is the output a prompt declares: a JSON structure or CSV columns
+type OutputContract struct {
+	// Source tells where the contract is declared
+	Source string
+	// Format is json or csv
+	Format string
+	// Fields are the fields of the object or of every item of a list, nil when any JSON value fits
+	Fields map[string]*analyzers.SchemaField
+	List   bool
+	// Columns is the schema of CSV outputs, the first record is the header
+	Columns [][]string
+}
+
+// SmokeTrial is a call of the target model with the prompt
+type SmokeTrial struct {
+	LatencyMs    int64 `json:"latencyMs"`
+	InputTokens  int   `json:"inputTokens"`
+	OutputTokens int   `json:"outputTokens"`
+	// Estimated is true when the API reported no usage and the tokens are counted locally
+	Estimated bool `json:"estimated,omitempty"`
+	// Cost is the cost of the call in USD, nil for models without a known price
+	Cost *float64 `json:"cost,omitempty"`
+	// Passed is true when the answer meets the output contract
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SmokeProfile summarizes latency, tokens and cost of the answered trials
+type SmokeProfile struct {
+	Calls  int   `json:"calls"`
+	Errors int   `json:"errors"`
+	Passed int   `json:"passed"`
+	MinMs  int64 `json:"minMs"`
+	P50Ms  int64 `json:"p50Ms"`
+	P95Ms  int64 `json:"p95Ms"`
+	MaxMs  int64 `json:"maxMs"`
+	// InputTokens is the mean of the calls, prompts with variables vary little
+	InputTokens     int  `json:"inputTokens"`
+	OutputTokensP50 int  `json:"outputTokensP50"`
+	OutputTokensP95 int  `json:"outputTokensP95"`
+	Estimated       bool `json:"estimated,omitempty"`
+	// CostPerCall is the mean cost of the calls in USD, nil for models without a known price
+	CostPerCall *float64 `json:"costPerCall,omitempty"`
+	TotalCost   *float64 `json:"totalCost,omitempty"`
+}
+
+// SmokeResult is the answer of the target model to the prompt and its checks against the output contract.
+// With several trials Response and Checks are of the first trial that failed, of the first one if all passed.
+type SmokeResult struct {
+	File     string        `json:"file"`
+	Model    string        `json:"model"`
+	Contract string        `json:"contract"`
+	Response string        `json:"response"`
+	Checks   []doctorCheck `json:"checks"`
+	Trials   []SmokeTrial  `json:"trials"`
+	Profile  SmokeProfile  `json:"profile"`
+}
+
+// failed returns the number of failed checks
+func (r SmokeResult) failed() int {
+	failed := 0
+	for _, check := range r.Checks {
+		if check.Status == doctorFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// countPercentile returns the nearest-rank percentile of sorted counts
+func countPercentile(sorted []int, percent int) int {
+	rank := (percent*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// profileTrials summarizes the trials, latency and tokens are of the trials the model answered
+func profileTrials(trials []SmokeTrial) SmokeProfile {
+	profile := SmokeProfile{Calls: len(trials)}
+	var latencies []time.Duration
+	var outputs []int
+	var inputs int
+	var cost float64
+	priced := true
+	for _, trial := range trials {
+		if trial.Passed {
+			profile.Passed++
+		}
+		if trial.Error != "" {
+			profile.Errors++
+			continue
+		}
+		latencies = append(latencies, time.Duration(trial.LatencyMs)*time.Millisecond)
+		outputs = append(outputs, trial.OutputTokens)
+		inputs += trial.InputTokens
+		profile.Estimated = profile.Estimated || trial.Estimated
+		if trial.Cost == nil {
+			priced = false
+		} else {
+			cost += *trial.Cost
+		}
+	}
+	if len(latencies) == 0 {
+		return profile
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	sort.Ints(outputs)
+	profile.MinMs = latencies[0].Milliseconds()
+	profile.P50Ms = latencyPercentile(latencies, 50).Milliseconds()
+	profile.P95Ms = latencyPercentile(latencies, 95).Milliseconds()
+	profile.MaxMs = latencies[len(latencies)-1].Milliseconds()
+	profile.InputTokens = inputs / len(latencies)
+	profile.OutputTokensP50 = countPercentile(outputs, 50)
+	profile.OutputTokensP95 = countPercentile(outputs, 95)
+	if priced {
+		perCall := cost / float64(len(latencies))
+		profile.CostPerCall, profile.TotalCost = &perCall, &cost
+	}
+	return profile
+}
+
+// contractFromData builds the contract of a JSON Schema or of a template object like {"name": "string"},
+// a list of templates describes a list of objects
+func contractFromData(source string, data interface{}) (*OutputContract, error) {
+	contract := &OutputContract{Source: source, Format: "json"}
+	if items, ok := data.([]interface{}); ok && len(items) > 0 {
+		contract.List, data = true, items[0]
+	}
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the output contract of %s is not an object", source)
+	}
+	if !analyzers.IsJSONSchema(object) {
+		contract.Fields = analyzers.FieldsFromTemplate(object)
+		return contract, nil
+	}
+	if object["type"] == "array" {
+		contract.List = true
+		if object, ok = object["items"].(map[string]interface{}); !ok {
+			return contract, nil
+		}
+	}
+	if _, ok := object["properties"]; ok {
+		contract.Fields = analyzers.FieldsFromJSONSchema(object)
+	}
+	return contract, nil
+}
+
+// fieldsFromPicoschema converts a dotprompt Picoschema like {"name": "string", "tags?(array, labels)": "string"} to fields,
+// names ending with ? are optional and descriptions after commas are ignored
+func fieldsFromPicoschema(schema map[string]interface{}) map[string]*analyzers.SchemaField {
+	fields := map[string]*analyzers.SchemaField{}
+	for key, value := range schema {
+		name, kind := key, ""
+		if open := strings.Index(key, "("); open > 0 && strings.HasSuffix(key, ")") {
+			name = key[:open]
+			kind = strings.TrimSpace(strings.SplitN(key[open+1:len(key)-1], ",", 2)[0])
+		}
+		field := &analyzers.SchemaField{Required: !strings.HasSuffix(name, "?")}
+		name = strings.TrimSuffix(name, "?")
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field.Type = "object"
+			field.Fields = fieldsFromPicoschema(v)
+		case string:
+			field.Type = analyzers.SchemaTypeNames[strings.ToLower(strings.TrimSpace(strings.SplitN(v, ",", 2)[0]))]
+		}
+		if kind == "array" {
+			// The value describes the items of arrays
+			field.Type = "array"
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// findOutputContract returns the output contract of the prompt: the --schema file, output.schema of the
+// dotprompt frontmatter or the first schema described in the prompt, nil when the prompt declares none
+func findOutputContract(doc *Document, model *PromptModel, schemaPath string) (*OutputContract, error) {
+	if schemaPath != "" {
+		data, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+		var schema interface{}
+		if err := yaml.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("error parsing schema %s: %w", schemaPath, err)
+		}
+		return contractFromData("schema file "+schemaPath, schema)
+	}
+
+	if output, ok := doc.Frontmatter["output"].(map[string]interface{}); ok {
+		source := "frontmatter output.schema"
+		switch schema := output["schema"].(type) {
+		case map[string]interface{}:
+			if analyzers.IsJSONSchema(schema) {
+				return contractFromData(source, schema)
+			}
+			return &OutputContract{Source: source, Format: "json", Fields: fieldsFromPicoschema(schema)}, nil
+		case nil:
+			if format, _ := output["format"].(string); strings.EqualFold(format, "json") {
+				return &OutputContract{Source: "frontmatter output.format", Format: "json"}, nil
+			}
+		}
+	}
+
+	for _, fence := range model.CodeFences {
+		block, ok := analyzers.ParseOutputBlock(fence)
+		if !ok || analyzers.FenceRole(model, block) != "schema" {
+			continue
+		}
+		source := fmt.Sprintf("schema at line %d", fence.Start.Line)
+		if block.Format == "csv" {
+			return &OutputContract{Source: source, Format: "csv", Columns: block.Data.([][]string)}, nil
+		}
+		return contractFromData(source, block.Data)
+	}
+	return nil, nil
+}
+
+// sampleValues assigns values of the variables to the placeholders of the prompt, placeholders without
+// a value get a sample named after them
+func sampleValues(model *PromptModel, variables map[string]interface{}) (Combination, []string) {
+	values := Combination{}
+	var missing []string
+	for _, placeholder := range model.Placeholders {
+		if _, ok := values[placeholder.Name]; ok || analyzers.TemplateKeywords[placeholder.Name] {
+			continue
+		}
+		value, ok := analyzers.VariableValue(variables, placeholder.Name)
+		switch v := value.(type) {
+		case nil:
+			values[placeholder.Name] = ""
+		case string:
+			values[placeholder.Name] = v
+		case map[string]interface{}, []interface{}:
+			data, _ := json.Marshal(v)
+			values[placeholder.Name] = string(data)
+		default:
+			values[placeholder.Name] = fmt.Sprint(v)
+		}
+		if !ok {
+			values[placeholder.Name] = "sample " + strings.ReplaceAll(placeholder.Name, "_", " ")
+			missing = append(missing, placeholder.Name)
+		}
+	}
+	return values, missing
+}
+
+// smokeRequest renders the prompt with the values: system messages are the system message of the request, other
+// messages and the input are user messages. A prompt without roles is the system message when there is an input.
+// Assistant messages of few-shot examples are sent as user messages, the request has no other roles.
+func smokeRequest(doc *Document, values Combination, input string) ToolRequest {
+	var request ToolRequest
+	if len(doc.Messages) == 0 {
+		prompt := instantiateTemplate(doc.Text, values)
+		if input == "" {
+			request.Messages = []string{prompt}
+			return request
+		}
+		request.System = prompt
+	}
+	var system []string
+	for _, message := range doc.Messages {
+		content := instantiateTemplate(message.Content, values)
+		if strings.EqualFold(message.Role, "system") {
+			system = append(system, content)
+			continue
+		}
+		request.Messages = append(request.Messages, content)
+	}
+	if len(system) > 0 {
+		request.System = strings.Join(system, "\n\n")
+	}
+	if input != "" {
+		request.Messages = append(request.Messages, input)
+	}
+	// APIs expect a user message, a prompt of system messages alone is sent as one
+	if len(request.Messages) == 0 {
+		request.System, request.Messages = "", []string{request.System}
+	}
+	return request
+}
+
+// unfence returns the content of a response wrapped in a single code fence
+func unfence(response string) (string, bool) {
+	trimmed := strings.TrimSpace(response)
+	model := linter.ParsePrompt(trimmed)
+	if len(model.CodeFences) != 1 || !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+		return "", false
+	}
+	return model.CodeFences[0].Content, true
+}
+
+// checkResponse validates the response against the contract
+func checkResponse(response string, contract *OutputContract) []doctorCheck {
+	checks := []doctorCheck{{Name: "Response", Status: doctorOK, Detail: fmt.Sprintf("%d characters", len(response))}}
+	if strings.TrimSpace(response) == "" {
+		checks[0].Status, checks[0].Detail = doctorFail, "the model answered with no text"
+		checks[0].Hint = "Check that the prompt asks for an answer and the model doesn't refuse it"
+		return checks
+	}
+
+	parsed := doctorCheck{Name: "Parses as " + strings.ToUpper(contract.Format), Status: doctorOK, Detail: "valid"}
+	fields := doctorCheck{Name: "Matches contract", Status: doctorOK, Detail: "as in " + contract.Source}
+	var problems []string
+	switch contract.Format {
+	case "csv":
+		reader := csv.NewReader(strings.NewReader(strings.TrimSpace(response)))
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil || len(records) == 0 {
+			parsed.Status, parsed.Detail = doctorFail, fmt.Sprintf("not CSV: %v", err)
+			break
+		}
+		problems = analyzers.CompareCSV(contract.Columns, records)
+	default:
+		var data interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(response)), &data); err != nil {
+			parsed.Status, parsed.Detail = doctorFail, err.Error()
+			parsed.Hint = "Ask for raw JSON only, without prose around it"
+			if content, ok := unfence(response); ok && json.Unmarshal([]byte(content), &data) == nil {
+				parsed.Detail = "the JSON is wrapped in a code fence"
+				parsed.Hint = "Ask for raw JSON without Markdown code fences, parsers of the answer expect bare JSON"
+			}
+			break
+		}
+		problems = matchContract(data, contract)
+	}
+	if parsed.Status == doctorFail {
+		fields.Status, fields.Detail = doctorSkip, "the response doesn't parse"
+		return append(checks, parsed, fields)
+	}
+	if len(problems) > 0 {
+		fields.Status = doctorFail
+		if len(problems) > maxSmokeProblems {
+			problems = append(problems[:maxSmokeProblems], fmt.Sprintf("%d more", len(problems)-maxSmokeProblems))
+		}
+		fields.Detail = strings.Join(problems, "; ")
+		fields.Hint = "Make the output format section explicit about the required fields and types"
+	}
+	return append(checks, parsed, fields)
+}
+
+// matchContract returns mismatches of the decoded response, fields outside the contract are allowed
+func matchContract(data interface{}, contract *OutputContract) []string {
+	if contract.List {
+		items, ok := data.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("the response is %s, the contract says array", analyzers.ValueType(data))}
+		}
+		var problems []string
+		for i, item := range items {
+			for _, problem := range matchContract(item, &OutputContract{Format: contract.Format, Fields: contract.Fields}) {
+				problems = append(problems, fmt.Sprintf("item %d: %s", i+1, problem))
+			}
+		}
+		return problems
+	}
+	if contract.Fields == nil {
+		return nil
+	}
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("the response is %s, the contract says object", analyzers.ValueType(data))}
+	}
+	return analyzers.CompareFields("", contract.Fields, object, false)
+}
+
+// FormatSmokeResult formats the checks, the profile of the calls and the response of the model
+func FormatSmokeResult(result SmokeResult, useColor bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: answer of %s checked against the %s\n\n", result.File, result.Model, result.Contract))
+	sb.WriteString(formatDoctorChecks(result.Checks, useColor))
+
+	profile := result.Profile
+	sb.WriteString(fmt.Sprintf("\nProfile of %d calls", profile.Calls))
+	if profile.Errors > 0 {
+		sb.WriteString(fmt.Sprintf(" (%d failed)", profile.Errors))
+	}
+	sb.WriteString(":\n")
+	if profile.Calls > profile.Errors {
+		ms := func(value int64) time.Duration { return time.Duration(value) * time.Millisecond }
+		sb.WriteString(fmt.Sprintf("  %-14s p50 %s, p95 %s (min %s, max %s)\n", "Latency", ms(profile.P50Ms), ms(profile.P95Ms), ms(profile.MinMs), ms(profile.MaxMs)))
+		sb.WriteString(fmt.Sprintf("  %-14s p50 %d, p95 %d\n", "Output tokens", profile.OutputTokensP50, profile.OutputTokensP95))
+		sb.WriteString(fmt.Sprintf("  %-14s %d per call\n", "Input tokens", profile.InputTokens))
+		if profile.CostPerCall != nil {
+			sb.WriteString(fmt.Sprintf("  %-14s $%.6f per call, $%.6f total\n", "Cost", *profile.CostPerCall, *profile.TotalCost))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %-14s unknown, add the model to pricing in %s\n", "Cost", configFileName))
+		}
+		if profile.Estimated {
+			sb.WriteString("  Tokens are estimated locally, the API reported no usage\n")
+		}
+	}
+
+	if strings.TrimSpace(result.Response) != "" {
+		sb.WriteString("\nResponse:\n")
+		for _, line := range strings.Split(strings.TrimRight(result.Response, "\n"), "\n") {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// runSmokeCommand implements `promptlint smoke <file>`
+func runSmokeCommand(args []string) error {
+	fs := flag.NewFlagSet("smoke", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	varsFile := fs.String("vars", "", "YAML file with sample values of the template variables, missing ones are named after the variable")
+	input := fs.String("input", "", "User message sent after the prompt, @path reads it from a file")
+	schemaFile := fs.String("schema", "", "JSON Schema or template of the expected output, overrides the contract declared by the prompt")
+	model := fs.String("model", "", "Target model the prompt is written for, default: the configured model")
+	trials := fs.Int("trials", 1, "Calls of the model, the profile reports p50/p95 latency, output tokens and cost per call")
+	format := fs.String("format", "text", "Output format: text, json")
+	forceColor := fs.Bool("force-color", false, "Force colored output even when stdout is not a terminal")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s smoke [--vars=vars.yaml] [--input=text|@file] [--schema=schema.json] [--trials=N] <file>
+
+Sends the prompt rendered with sample variables to the target model and
+validates the answer against the output contract: the --schema file, the
+output.schema of a dotprompt frontmatter or the schema described in the
+prompt. JSON answers must parse and contain the required fields with the
+declared types, CSV answers must have the declared columns. Exits with status
+1 when a check fails, catching prompts that lint clean but fail in practice.
+
+With --trials=N the prompt is sent N times: every answer must meet the
+contract, and the profile reports p50/p95 latency, output tokens and the cost
+per call from the usage of the API (estimated when it reports none) and the
+pricing of %s.
+
+Options:
+`, appName, configFileName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return fmt.Errorf("one prompt file is required")
+	}
+	if *trials < 1 {
+		return fmt.Errorf("--trials must be positive")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	if *forceColor {
+		useColorForProgress = true
+	} else if *noColor {
+		useColorForProgress = false
+	}
+
+	content, err := readFromFile(files[0])
+	if err != nil {
+		return err
+	}
+	doc, err := loadDocument(files[0], []byte(content), "auto")
+	if err != nil {
+		return err
+	}
+	promptModel := linter.ParsePrompt(doc.Text)
+	contract, err := findOutputContract(doc, promptModel, *schemaFile)
+	if err != nil {
+		return err
+	}
+	if contract == nil {
+		return fmt.Errorf("%s declares no output contract, describe the output schema in the prompt, set output.schema in the frontmatter or pass --schema", files[0])
+	}
+
+	variables := map[string]interface{}{}
+	if *varsFile != "" {
+		if variables, err = analyzers.LoadTemplateVariables(*varsFile); err != nil {
+			return err
+		}
+	}
+	values, missing := sampleValues(promptModel, variables)
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		printProgress(fmt.Sprintf("No values for %s, sending samples named after them (set them with --vars)", strings.Join(missing, ", ")))
+	}
+	if strings.HasPrefix(*input, "@") {
+		data, err := os.ReadFile(strings.TrimPrefix(*input, "@"))
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		*input = string(data)
+	}
+
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+	if config.Heuristic {
+		return fmt.Errorf("smoke tests need the LLM API, set %s", llm.KeyHint(config.Provider))
+	}
+	if *model != "" {
+		config.ModelName = *model
+	}
+	prices, err := configuredPrices(pathDir(files[0]))
+	if err != nil {
+		return err
+	}
+	counter, err := newTokenCounter(config.ModelName)
+	if err != nil {
+		return err
+	}
+	if *trials > 1 {
+		// Retries would add their backoff to the measured latency, failed calls are counted instead
+		config.Retry = llm.RetryPolicy{}
+	}
+
+	request := smokeRequest(doc, values, *input)
+	result := SmokeResult{File: files[0], Model: config.ModelName, Contract: contract.Source}
+	var lastErr error
+	for i := 0; i < *trials; i++ {
+		if *trials > 1 {
+			printProgress(fmt.Sprintf("Sending %s to %s (trial %d/%d)", files[0], config.ModelName, i+1, *trials))
+		} else {
+			printProgress(fmt.Sprintf("Sending %s to %s", files[0], config.ModelName))
+		}
+		start := time.Now()
+		response, err := llm.Send(&config, request)
+		trial := SmokeTrial{LatencyMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			lastErr = err
+			trial.Error = err.Error()
+			result.Trials = append(result.Trials, trial)
+			continue
+		}
+
+		trial.InputTokens, trial.OutputTokens = response.InputTokens, response.OutputTokens
+		if trial.OutputTokens == 0 {
+			trial.Estimated = true
+			trial.OutputTokens = counter.count(response.Text)
+			trial.InputTokens = 0
+			for _, message := range append([]string{request.System}, request.Messages...) {
+				if message != "" {
+					trial.InputTokens += counter.count(message) + chatMessageOverhead
+				}
+			}
+		}
+		if price, ok := priceFor(config.ModelName, prices); ok {
+			cost := (float64(trial.InputTokens)*price.Input + float64(trial.OutputTokens)*price.Output) / 1e6
+			trial.Cost = &cost
+		}
+		checks := checkResponse(response.Text, contract)
+		trial.Passed = SmokeResult{Checks: checks}.failed() == 0
+		// The report shows the first failed answer, the first answer when all pass
+		if result.Checks == nil || !trial.Passed && result.failed() == 0 {
+			result.Response, result.Checks = response.Text, checks
+		}
+		result.Trials = append(result.Trials, trial)
+	}
+	if result.Checks == nil {
+		return fmt.Errorf("smoke request failed: %w", lastErr)
+	}
+	if config.ServedModel != "" {
+		result.Model = config.ServedModel
+	}
+	result.Profile = profileTrials(result.Trials)
+	if *trials > 1 {
+		check := doctorCheck{Name: "Trials", Status: doctorOK, Detail: fmt.Sprintf("%d of %d answers meet the contract", result.Profile.Passed, *trials)}
+		if result.Profile.Errors > 0 {
+			check.Detail += fmt.Sprintf(", %d calls failed: %s", result.Profile.Errors, lastErr)
+		}
+		if result.Profile.Passed < *trials {
+			check.Status = doctorFail
+			check.Hint = "The model follows the output format only sometimes, make the format instructions stricter or add an example"
+		}
+		result.Checks = append(result.Checks, check)
+	}
+	if *format == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode smoke result: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(FormatSmokeResult(result, *forceColor || (!*noColor && isColorTerminal())))
+	}
+	if failed := result.failed(); failed > 0 {
+		return fmt.Errorf("%d of %d smoke checks failed", failed, len(result.Checks))
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultSnapshotDir holds the golden lint results of prompts, mirroring their paths
+	defaultSnapshotDir = ".promptlint/snapshots"
+	// snapshotVersion is the format version of snapshot files
+	snapshotVersion = 1
+)
+
+// Snapshot is the golden lint result of a prompt. Issues are compared by rule, fingerprint and severity,
+// descriptions only help reviewing the file since judges word them differently between runs.
+type Snapshot struct {
+	Version int             `json:"version"`
+	File    string          `json:"file"`
+	Issues  []SnapshotIssue `json:"issues"`
+}
+
+// SnapshotIssue is an issue recorded in a snapshot
+type SnapshotIssue struct {
+	Rule        string `json:"rule"`
+	Severity    string `json:"severity"`
+	Category    string `json:"category,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	Snippet     string `json:"snippet,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// key identifies the issue across runs
+func (i SnapshotIssue) key() string {
+	return i.Rule + "\x00" + i.Fingerprint
+}
+
+// String describes the issue in snapshot diffs
+func (i SnapshotIssue) String() string {
+	text := fmt.Sprintf("[%s] %s: %s", i.Severity, i.Rule, i.Description)
+	if i.Snippet != "" {
+		text += fmt.Sprintf(" (%q)", i.Snippet)
+	}
+	return text
+}
+
+// newSnapshot records the active issues of the file in a stable order
+func newSnapshot(file string, issues []Issue) Snapshot {
+	snapshot := Snapshot{Version: snapshotVersion, File: baselinePath(file), Issues: []SnapshotIssue{}}
+	for _, issue := range issues {
+		if issue.Dismissed {
+			continue
+		}
+		severity := issue.Severity
+		if severity == "" {
+			severity = "warning"
+		}
+		snapshot.Issues = append(snapshot.Issues, SnapshotIssue{
+			Rule:        issue.RuleName,
+			Severity:    severity,
+			Category:    issue.Category,
+			Fingerprint: issue.Fingerprint,
+			Snippet:     issue.OriginalSnippet,
+			Description: issue.Description,
+		})
+	}
+	sort.SliceStable(snapshot.Issues, func(a, b int) bool {
+		return snapshot.Issues[a].key() < snapshot.Issues[b].key()
+	})
+	return snapshot
+}
+
+// snapshotPath returns the golden file of the prompt, prompts must be inside the working directory
+func snapshotPath(dir, file string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the working directory, snapshots mirror paths below it", file)
+	}
+	return filepath.Join(dir, rel+".json"), nil
+}
+
+// loadSnapshot reads the golden file, nil without the file
+func loadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("error parsing snapshot %s: %w", path, err)
+	}
+	if snapshot.Version > snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has version %d, this %s supports up to %d", path, snapshot.Version, appName, snapshotVersion)
+	}
+	return &snapshot, nil
+}
+
+// save writes the golden file, creating its directories
+func (s Snapshot) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("snapshot serialization error: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// diffSnapshots lists the issues added to and removed from the golden result and changed severities,
+// empty when the results match
+func diffSnapshots(golden, current Snapshot) []string {
+	before := map[string]SnapshotIssue{}
+	for _, issue := range golden.Issues {
+		before[issue.key()] = issue
+	}
+	after := map[string]bool{}
+	var lines []string
+	for _, issue := range current.Issues {
+		after[issue.key()] = true
+		old, ok := before[issue.key()]
+		switch {
+		case !ok:
+			lines = append(lines, "+ "+issue.String())
+		case old.Severity != issue.Severity:
+			lines = append(lines, fmt.Sprintf("~ %s: severity %s → %s", issue.Rule, old.Severity, issue.Severity))
+		}
+	}
+	for _, issue := range golden.Issues {
+		if !after[issue.key()] {
+			lines = append(lines, "- "+issue.String())
+		}
+	}
+	return lines
+}
+
+// runSnapshotCommand implements `promptlint snapshot [--update] [--dir=...] <file|dir>...`
+func runSnapshotCommand(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	registerOfflineFlag(fs)
+	update := fs.Bool("update", false, "Write the current results as the new snapshots")
+	dir := fs.String("dir", defaultSnapshotDir, "Directory of the snapshot files")
+	extensions := fs.String("ext", defaultInventoryExtensions, "Comma-separated extensions of prompt files in directories")
+	engine := fs.String("engine", "llm", "Rule engine: llm, static (rule pattern and length checks without LLM calls), both")
+	dismissalsFile := fs.String("dismissals", defaultDismissalsFile, "Path to the dismissals file")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s snapshot [--update] [--dir=%s] <file|dir>...
+
+Lints the prompts and compares the results with their snapshots, golden files
+that mirror the prompt paths in the snapshot directory. Issues are compared by
+rule, fingerprint and severity; any difference or a missing snapshot fails the
+run. Review the changes and write them with --update. Dismissed issues are not
+recorded. A lightweight alternative to baselines for small repositories, best
+with --engine=static or a pinned seed for stable results.
+
+Options:
+`, appName, defaultSnapshotDir)
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one prompt file or directory is required")
+	}
+	if ruleEngine, err = parseRuleEngine(*engine); err != nil {
+		return err
+	}
+	if paths, err = resolveInputs(paths); err != nil {
+		return err
+	}
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		found, err := scanPrompts(path, parseExtensions(*extensions))
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+	dismissals, err := LoadDismissals(*dismissalsFile)
+	if err != nil {
+		return err
+	}
+	config, err := setupLLMConfig()
+	if err != nil {
+		return err
+	}
+
+	checked, differing, written := 0, 0, 0
+	for _, file := range files {
+		_, doc, ok, err := loadPromptFile(file)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		path, err := snapshotPath(*dir, file)
+		if err != nil {
+			return err
+		}
+		fileRules, err := rulesForPath(rules, file)
+		if err != nil {
+			return err
+		}
+		if err := validateStaticRules(fileRules); err != nil {
+			return err
+		}
+		if err := loadAnalyzerSettings(file); err != nil {
+			return err
+		}
+
+		printProgress(fmt.Sprintf("Checking %s", filepath.ToSlash(file)))
+		issues, err := checkDocumentWithLLM(doc, fileRules, &config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		applyDismissals(issues, dismissals, time.Now())
+		current := newSnapshot(file, issues)
+		checked++
+
+		golden, err := loadSnapshot(path)
+		if err != nil {
+			return err
+		}
+		var changes []string
+		if golden != nil {
+			if changes = diffSnapshots(*golden, current); len(changes) == 0 {
+				continue
+			}
+		}
+		if *update {
+			if err := current.save(path); err != nil {
+				return err
+			}
+			written++
+			continue
+		}
+		differing++
+		if golden == nil {
+			fmt.Printf("%s: no snapshot\n", current.File)
+			continue
+		}
+		fmt.Printf("%s: snapshot changed\n", current.File)
+		for _, change := range changes {
+			fmt.Println("  " + change)
+		}
+	}
+	printHeuristicNotice(&config)
+
+	if *update {
+		printProgress(fmt.Sprintf("%d of %d snapshots written to %s", written, checked, *dir))
+		return nil
+	}
+	if differing > 0 {
+		return fmt.Errorf("%d of %d snapshots differ, review the changes and run `%s snapshot --update`", differing, checked, appName)
+	}
+	printProgress(fmt.Sprintf("%d snapshots match", checked))
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+)
+
+// ruleEngines are the values of --engine: the LLM judge, the static rule engine or both
+var ruleEngines = linter.Engines
+
+// ruleEngine selects how rules are checked, set from --engine
+var ruleEngine = "llm"
+
+// parseRuleEngine validates the --engine value
+func parseRuleEngine(value string) (string, error) {
+	for _, engine := range ruleEngines {
+		if value == engine {
+			return engine, nil
+		}
+	}
+	return "", fmt.Errorf("unknown engine %q, supported: %s", value, strings.Join(ruleEngines, ", "))
+}
+
+// hasStaticChecks reports whether the rule can be checked without an LLM
+func hasStaticChecks(rule PromptRule) bool {
+	return rule.Pattern != "" || rule.MinLength > 0 || rule.MaxLength > 0
+}
+
+// validateStaticRules compiles the patterns of the active rules and checks the length bounds and severities
+func validateStaticRules(rules *Rules) error {
+	for _, rule := range rules.Active() {
+		if !validSeverity(rule.Severity) {
+			return fmt.Errorf("rule %q has severity %q, supported: %s", rule.Name, rule.Severity, strings.Join(severityLevels, ", "))
+		}
+		if rule.Pattern != "" {
+			if _, err := linter.CompilePattern(rule.Pattern); err != nil {
+				return fmt.Errorf("rule %q has an invalid pattern: %w", rule.Name, err)
+			}
+		}
+		if rule.MinLength < 0 || rule.MaxLength < 0 || (rule.MaxLength > 0 && rule.MinLength > rule.MaxLength) {
+			return fmt.Errorf("rule %q has invalid length bounds %d..%d", rule.Name, rule.MinLength, rule.MaxLength)
+		}
+	}
+	return nil
+}
+
+// checkStaticRules reports matches of rule patterns and prompts outside the length bounds of rules
+func checkStaticRules(model *PromptModel, rules *Rules) []Issue {
+	return linter.CheckStaticRules(model, rules, ruleLocale)
+}
+
+// localIssues returns issues found without an LLM: static rules when the engine includes them and analyzers
+func localIssues(model *PromptModel, rules *Rules) []Issue {
+	var issues []Issue
+	if ruleEngine != "llm" {
+		issues = checkStaticRules(model, rules)
+	}
+	return append(issues, runAnalyzers(model)...)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PromptRef identifies a prompt in a prompt store: <scheme>://<path>@<version>
+type PromptRef struct {
+	Scheme  string
+	Path    string
+	Version string
+}
+
+// String returns the reference in URL form
+func (r PromptRef) String() string {
+	if r.Version == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "@" + r.Version
+}
+
+// PromptStore fetches prompts from a prompt management service
+type PromptStore interface {
+	// Scheme returns the URL scheme of the store references
+	Scheme() string
+	// Fetch returns the prompt as a document
+	Fetch(ctx context.Context, ref PromptRef) (*Document, error)
+}
+
+// promptStores contains registered stores by scheme
+var promptStores = map[string]PromptStore{}
+
+// RegisterPromptStore makes a store available for its URL scheme
+func RegisterPromptStore(store PromptStore) {
+	promptStores[store.Scheme()] = store
+}
+
+func init() {
+	RegisterPromptStore(langSmithStore{})
+	RegisterPromptStore(promptLayerStore{})
+	RegisterPromptStore(langfuseStore{})
+}
+
+// parsePromptRef parses a store reference, it returns false for files and other URLs
+func parsePromptRef(source string) (PromptRef, bool) {
+	scheme, rest, ok := strings.Cut(source, "://")
+	if !ok {
+		return PromptRef{}, false
+	}
+	if _, registered := promptStores[scheme]; !registered {
+		return PromptRef{}, false
+	}
+
+	ref := PromptRef{Scheme: scheme, Path: strings.Trim(rest, "/")}
+	if i := strings.LastIndex(ref.Path, "@"); i >= 0 {
+		ref.Version = ref.Path[i+1:]
+		ref.Path = ref.Path[:i]
+	}
+	return ref, true
+}
+
+// fetchFromStore loads a prompt by a store reference
+func fetchFromStore(ctx context.Context, ref PromptRef) (*Document, error) {
+	if ref.Path == "" {
+		return nil, fmt.Errorf("%s: prompt name is required", ref)
+	}
+	printProgress("Fetching prompt " + ref.String())
+	doc, err := promptStores[ref.Scheme].Fetch(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	return doc, nil
+}
+
+// storeRequest performs an API request and decodes the JSON response
+func storeRequest(ctx context.Context, method, endpoint string, body io.Reader, headers map[string]string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxInputSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := checkInputSize(int64(len(data))); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("invalid API response: %w", err)
+	}
+	return nil
+}
+
+// requireEnv returns the value of a required environment variable
+func requireEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%s environment variable is required", name)
+	}
+	return value, nil
+}
+
+// envOrDefault returns the environment variable value or the default without a trailing slash
+func envOrDefault(name, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return strings.TrimSuffix(value, "/")
+	}
+	return defaultValue
+}
+
+// basicAuth returns the value of a basic authorization header
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+// collectTemplates gathers template texts from a serialized prompt, keeping message roles when present
+func collectTemplates(value interface{}) []ChatMessage {
+	var messages []ChatMessage
+	switch v := value.(type) {
+	case map[string]interface{}:
+		role := getStringValue(v, "role")
+		for _, key := range []string{"template", "text", "content", "prompt"} {
+			if text, ok := v[key].(string); ok && strings.TrimSpace(text) != "" {
+				return []ChatMessage{{Role: role, Content: text}}
+			}
+		}
+		// LangChain message templates carry the role in their class id
+		if ids, ok := v["id"].([]interface{}); ok && len(ids) > 0 {
+			if class, ok := ids[len(ids)-1].(string); ok {
+				role = strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(class, "PromptTemplate"), "Message"))
+			}
+		}
+		for _, key := range []string{"manifest", "kwargs", "prompt_template", "prompt", "messages", "content"} {
+			for _, message := range collectTemplates(v[key]) {
+				if message.Role == "" {
+					message.Role = role
+				}
+				messages = append(messages, message)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			messages = append(messages, collectTemplates(item)...)
+		}
+	}
+	return messages
+}
+
+// templatesDocument builds a document from collected templates
+func templatesDocument(messages []ChatMessage) (*Document, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no prompt text found in response")
+	}
+	if len(messages) == 1 && messages[0].Role == "" {
+		return &Document{Text: messages[0].Content}, nil
+	}
+	for i := range messages {
+		if messages[i].Role == "" {
+			messages[i].Role = "user"
+		}
+	}
+	return &Document{Text: renderMessages(messages), Messages: messages}, nil
+}
+
+// langSmithStore fetches prompts from the LangSmith prompt hub: langsmith://[owner/]prompt@commit
+type langSmithStore struct{}
+
+func (langSmithStore) Scheme() string { return "langsmith" }
+func (langSmithStore) Fetch(ctx context.Context, ref PromptRef) (*Document, error) {
+	apiKey, err := requireEnv("LANGSMITH_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	owner, name := "-", ref.Path
+	if i := strings.Index(ref.Path, "/"); i >= 0 {
+		owner, name = ref.Path[:i], ref.Path[i+1:]
+	}
+	commit := ref.Version
+	if commit == "" {
+		commit = "latest"
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/commits/%s/%s/%s", envOrDefault("LANGSMITH_ENDPOINT", "https://api.smith.langchain.com"),
+		url.PathEscape(owner), url.PathEscape(name), url.PathEscape(commit))
+	var response map[string]interface{}
+	if err := storeRequest(ctx, "GET", endpoint, nil, map[string]string{"x-api-key": apiKey}, &response); err != nil {
+		return nil, err
+	}
+	return templatesDocument(collectTemplates(response["manifest"]))
+}
+
+// promptLayerStore fetches prompt templates from PromptLayer: promptlayer://name@version or promptlayer://name@label
+type promptLayerStore struct{}
+
+func (promptLayerStore) Scheme() string { return "promptlayer" }
+func (promptLayerStore) Fetch(ctx context.Context, ref PromptRef) (*Document, error) {
+	apiKey, err := requireEnv("PROMPTLAYER_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{}
+	if version, err := strconv.Atoi(ref.Version); err == nil {
+		params["version"] = version
+	} else if ref.Version != "" {
+		params["label"] = ref.Version
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/prompt-templates/%s", envOrDefault("PROMPTLAYER_ENDPOINT", "https://api.promptlayer.com"), url.PathEscape(ref.Path))
+	var response map[string]interface{}
+	if err := storeRequest(ctx, "POST", endpoint, strings.NewReader(string(body)), map[string]string{"X-API-KEY": apiKey}, &response); err != nil {
+		return nil, err
+	}
+	return templatesDocument(collectTemplates(response["prompt_template"]))
+}
+
+// langfuseStore fetches prompts from Langfuse: langfuse://name@version or langfuse://name@label
+type langfuseStore struct{}
+
+func (langfuseStore) Scheme() string { return "langfuse" }
+func (langfuseStore) Fetch(ctx context.Context, ref PromptRef) (*Document, error) {
+	publicKey, err := requireEnv("LANGFUSE_PUBLIC_KEY")
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := requireEnv("LANGFUSE_SECRET_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if _, err := strconv.Atoi(ref.Version); err == nil {
+		query.Set("version", ref.Version)
+	} else if ref.Version != "" {
+		query.Set("label", ref.Version)
+	}
+	endpoint := fmt.Sprintf("%s/api/public/v2/prompts/%s", envOrDefault("LANGFUSE_HOST", "https://cloud.langfuse.com"), url.PathEscape(ref.Path))
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var response struct {
+		Type   string          `json:"type"`
+		Prompt json.RawMessage `json:"prompt"`
+	}
+	if err := storeRequest(ctx, "GET", endpoint, nil, map[string]string{"Authorization": basicAuth(publicKey, secretKey)}, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Type == "chat" {
+		var messages []ChatMessage
+		if err := json.Unmarshal(response.Prompt, &messages); err != nil {
+			return nil, fmt.Errorf("invalid chat prompt: %w", err)
+		}
+		return templatesDocument(messages)
+	}
+	var text string
+	if err := json.Unmarshal(response.Prompt, &text); err != nil {
+		return nil, fmt.Errorf("invalid text prompt: %w", err)
+	}
+	return templatesDocument([]ChatMessage{{Content: text}})
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/tokenizer"
+)
+
+const (
+	// chatMessageOverhead is the number of tokens chat APIs add around every message
+	chatMessageOverhead = 4
+	// lintOutputTokens estimates the response of a lint call, a tool call with a few issues
+	lintOutputTokens = 600
+)
+
+// ModelPrice is the price of a model in USD per million tokens, Model is a prefix of model names
+type ModelPrice struct {
+	Model  string  `yaml:"model" json:"model"`
+	Input  float64 `yaml:"input" json:"input"`
+	Output float64 `yaml:"output" json:"output"`
+}
+
+// defaultModelPrices are list prices of common judge models, pricing in .promptlint.yaml overrides them
+var defaultModelPrices = []ModelPrice{
+	{Model: "gpt-4o", Input: 2.50, Output: 10},
+	{Model: "gpt-4o-mini", Input: 0.15, Output: 0.60},
+	{Model: "gpt-4.1", Input: 2, Output: 8},
+	{Model: "gpt-4.1-mini", Input: 0.40, Output: 1.60},
+	{Model: "gpt-4.1-nano", Input: 0.10, Output: 0.40},
+	{Model: "gpt-4-turbo", Input: 10, Output: 30},
+	{Model: "gpt-3.5-turbo", Input: 0.50, Output: 1.50},
+	{Model: "o1", Input: 15, Output: 60},
+	{Model: "o1-mini", Input: 1.10, Output: 4.40},
+	{Model: "o3", Input: 2, Output: 8},
+	{Model: "o3-mini", Input: 1.10, Output: 4.40},
+	{Model: "o4-mini", Input: 1.10, Output: 4.40},
+	{Model: "claude-3-5-haiku", Input: 0.80, Output: 4},
+	{Model: "claude-3-5-sonnet", Input: 3, Output: 15},
+	{Model: "claude-3-7-sonnet", Input: 3, Output: 15},
+	{Model: "claude-sonnet-4", Input: 3, Output: 15},
+	{Model: "claude-opus-4", Input: 15, Output: 75},
+}
+
+// priceFor returns the price of the model: configured prices first, the longest matching prefix wins and
+// later entries win ties, so nearer configuration files override
+func priceFor(model string, configured []ModelPrice) (ModelPrice, bool) {
+	model = strings.ToLower(model)
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, prices := range [][]ModelPrice{configured, defaultModelPrices} {
+		var best ModelPrice
+		for _, price := range prices {
+			if strings.HasPrefix(model, strings.ToLower(price.Model)) && len(price.Model) >= len(best.Model) {
+				best = price
+			}
+		}
+		if best.Model != "" {
+			return best, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// loadedEncodings caches the built-in encodings by name, they are parsed once
+var loadedEncodings = struct {
+	sync.Mutex
+	loaded map[string]*tokenizer.Encoding
+}{loaded: map[string]*tokenizer.Encoding{}}
+
+// loadEncoding returns the built-in encoding
+func loadEncoding(name string) (*tokenizer.Encoding, error) {
+	loadedEncodings.Lock()
+	defer loadedEncodings.Unlock()
+	if encoding, ok := loadedEncodings.loaded[name]; ok {
+		return encoding, nil
+	}
+	encoding, err := tokenizer.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+	loadedEncodings.loaded[name] = encoding
+	return encoding, nil
+}
+
+// tokenCounter counts tokens of a model, exactly with its tokenizer or estimated without one
+type tokenCounter struct {
+	encoding *tokenizer.Encoding
+}
+
+// newTokenCounter returns the counter of the model, models without a known tokenizer get estimates
+func newTokenCounter(model string) (tokenCounter, error) {
+	name := tokenizer.ForModel(model)
+	if name == "" {
+		return tokenCounter{}, nil
+	}
+	encoding, err := loadEncoding(name)
+	return tokenCounter{encoding: encoding}, err
+}
+
+// count returns the number of tokens of the text
+func (c tokenCounter) count(text string) int {
+	if c.encoding == nil {
+		return analyzers.EstimateTokens(text)
+	}
+	return c.encoding.Count(text)
+}
+
+// TokenStats are the tokens of a prompt for a model and the estimated cost of linting it
+type TokenStats struct {
+	Model string `json:"model"`
+	// Tokenizer is the encoding the tokens are counted with, empty for estimates
+	Tokenizer string `json:"tokenizer,omitempty"`
+	Exact     bool   `json:"exact"`
+	Tokens    int    `json:"tokens"`
+	// LintInputTokens are the tokens of the lint request: judge message, rules, prompt and tool definition
+	LintInputTokens  int `json:"lintInputTokens"`
+	LintOutputTokens int `json:"lintOutputTokens"`
+	// LintCost is the estimated cost of a lint call in USD, nil for models without a known price
+	LintCost *float64 `json:"lintCost,omitempty"`
+	// Budget is the token budget of the prompt, 0 without one
+	Budget     int  `json:"budget,omitempty"`
+	OverBudget bool `json:"overBudget,omitempty"`
+}
+
+// PromptTokenReport are the token stats of a prompt for the requested models
+type PromptTokenReport struct {
+	File   string       `json:"file,omitempty"`
+	Models []TokenStats `json:"models"`
+}
+
+// tokenStats counts the tokens of the prompt and of its lint request for the model
+func tokenStats(text, model string, rules *Rules, budget int, prices []ModelPrice) (TokenStats, error) {
+	counter, err := newTokenCounter(model)
+	if err != nil {
+		return TokenStats{}, err
+	}
+	stats := TokenStats{Model: model, Exact: counter.encoding != nil, Tokens: counter.count(text), LintOutputTokens: lintOutputTokens, Budget: budget}
+	if counter.encoding != nil {
+		stats.Tokenizer = counter.encoding.Name()
+	}
+	stats.OverBudget = budget > 0 && stats.Tokens > budget
+
+	messages, err := linter.RequestMessages(text, linter.Options{Rules: rules, JudgePrompt: judgePrompt})
+	if err != nil {
+		return TokenStats{}, err
+	}
+	for _, message := range messages {
+		stats.LintInputTokens += counter.count(message) + chatMessageOverhead
+	}
+	if price, ok := priceFor(model, prices); ok {
+		cost := (float64(stats.LintInputTokens)*price.Input + float64(stats.LintOutputTokens)*price.Output) / 1e6
+		stats.LintCost = &cost
+	}
+	return stats, nil
+}
+
+// promptTokenReport collects the token stats of a prompt for the models and warns when it exceeds its budget
+func promptTokenReport(file, text string, models []string, rules *Rules, budget int, prices []ModelPrice) (PromptTokenReport, error) {
+	report := PromptTokenReport{File: file}
+	for _, model := range models {
+		stats, err := tokenStats(text, model, rules, budget, prices)
+		if err != nil {
+			return PromptTokenReport{}, err
+		}
+		if stats.OverBudget {
+			name := file
+			if name == "" {
+				name = "stdin"
+			}
+			printProgress(fmt.Sprintf("Warning: %s has %d %s tokens, %d over the budget of %d", name, stats.Tokens, model, stats.Tokens-budget, budget))
+		}
+		report.Models = append(report.Models, stats)
+	}
+	return report, nil
+}
+
+// FormatTokenReports formats token stats as a table per prompt
+func FormatTokenReports(reports []PromptTokenReport) string {
+	var sb strings.Builder
+	for i, report := range reports {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if report.File != "" {
+			sb.WriteString(report.File + ":\n")
+		}
+		width := 0
+		for _, stats := range report.Models {
+			if len(stats.Model) > width {
+				width = len(stats.Model)
+			}
+		}
+		for _, stats := range report.Models {
+			count := fmt.Sprintf("%d tokens", stats.Tokens)
+			if !stats.Exact {
+				count = "~" + count
+			}
+			tokenizerName := stats.Tokenizer
+			if tokenizerName == "" {
+				tokenizerName = "estimate"
+			}
+			cost := "unknown price"
+			if stats.LintCost != nil {
+				cost = fmt.Sprintf("$%.4f", *stats.LintCost)
+			}
+			sb.WriteString(fmt.Sprintf("  %-*s  %s (%s), lint call %d in + ~%d out tokens, %s", width, stats.Model, count, tokenizerName, stats.LintInputTokens, stats.LintOutputTokens, cost))
+			if stats.Budget > 0 {
+				status := "within"
+				if stats.OverBudget {
+					status = "OVER"
+				}
+				sb.WriteString(fmt.Sprintf(", budget %d %s", stats.Budget, status))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// statsModel returns the model token stats are reported for: the judge model, the configured one for heuristic runs
+func statsModel(config *LLMConfig) (string, error) {
+	if !config.Heuristic && config.ModelName != "" {
+		return config.ModelName, nil
+	}
+	return defaultStatsModel()
+}
+
+// defaultStatsModel returns the model configured for the working directory, set or not an API key
+func defaultStatsModel() (string, error) {
+	settings, err := loadRunSettings(".")
+	if err != nil {
+		return "", err
+	}
+	provider, err := configuredProvider(settings)
+	if err != nil {
+		return "", err
+	}
+	model, _ := configuredModel(provider, settings)
+	return model, nil
+}
+
+// configuredPrices returns the prices of the project configuration of the directory
+func configuredPrices(dir string) ([]ModelPrice, error) {
+	settings, err := loadRunSettings(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, price := range settings.Pricing {
+		if strings.TrimSpace(price.Model) == "" || price.Input < 0 || price.Output < 0 {
+			return nil, fmt.Errorf("pricing needs a model and non-negative input and output prices, got %+v", price)
+		}
+	}
+	return settings.Pricing, nil
+}
+
+// runTokensCommand implements `promptlint tokens [--models=gpt-4o,o3-mini] <file>...`
+func runTokensCommand(args []string) error {
+	fs := flag.NewFlagSet("tokens", flag.ExitOnError)
+	models := fs.String("models", "", "Comma-separated models to count tokens for (default: the configured judge model)")
+	format := fs.String("format", "text", "Output format: text, json")
+	budget := fs.Int("budget", 0, "Token budget, prompts above it are reported (default: budgets from "+configFileName+")")
+	var rulesFiles stringsFlag
+	fs.Var(&rulesFiles, "rules", "Path to a rules file merged into the built-in rules, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s tokens [--models=gpt-4o,o3-mini] [--format=text|json] [--budget=n] <file>...
+
+Counts the tokens of prompts per model and estimates the cost of linting
+them: the judge message, the rules description, the prompt and the tool
+definition are counted as input, about %d tokens as output. OpenAI models
+are counted exactly with the built-in tiktoken-compatible tokenizers (%s),
+also offline, other models are estimated at about four characters per token.
+Prices per million tokens are overridden with pricing in %s.
+
+Options:
+`, appName, lintOutputTokens, strings.Join(tokenizer.Names(), ", "), configFileName)
+		fs.PrintDefaults()
+	}
+
+	files, err := parseFlagsWithArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	if *budget < 0 {
+		return fmt.Errorf("--budget must not be negative")
+	}
+	modelNames := splitRuleNames(*models)
+	if len(modelNames) == 0 {
+		model, err := defaultStatsModel()
+		if err != nil {
+			return err
+		}
+		modelNames = []string{model}
+	}
+	rules, err := LoadRules()
+	if err != nil {
+		return err
+	}
+	if rules, err = applyCustomRules(rules, rulesFiles); err != nil {
+		return err
+	}
+
+	type input struct{ name, text string }
+	var inputs []input
+	if len(files) == 0 {
+		content, err := readFromStdin()
+		if err != nil {
+			return err
+		}
+		doc, err := loadDocument("", []byte(content), "")
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, input{text: doc.Text})
+	} else {
+		if files, err = resolveInputs(files); err != nil {
+			return err
+		}
+		for _, file := range files {
+			content, err := readFromFile(file)
+			if err != nil {
+				return err
+			}
+			doc, err := loadDocument(file, []byte(content), "")
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, input{name: file, text: doc.Text})
+		}
+	}
+
+	var reports []PromptTokenReport
+	for _, in := range inputs {
+		fileRules, err := rulesForPath(rules, in.name)
+		if err != nil {
+			return err
+		}
+		limit := *budget
+		if limit == 0 {
+			configured, err := loadTokenBudget(in.name)
+			if err != nil {
+				return err
+			}
+			if configured != nil {
+				limit = configured.MaxTokens
+			}
+		}
+		prices, err := configuredPrices(pathDir(in.name))
+		if err != nil {
+			return err
+		}
+		report, err := promptTokenReport(in.name, in.text, modelNames, fileRules, limit, prices)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode token stats: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Print(FormatTokenReports(reports))
+	return nil
+}
+
+// pathDir returns the directory whose configuration applies to the path, the working directory for stdin
+func pathDir(path string) string {
+	if path == "" {
+		return "."
+	}
+	return filepath.Dir(path)
+}
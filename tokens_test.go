@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/korchasa/promptlint/pkg/analyzers"
+)
+
+func TestNewTokenCounter(t *testing.T) {
+	// The built-in tokenizers count exactly, offline too
+	offline = true
+	t.Cleanup(func() { offline = false })
+	counter, err := newTokenCounter("gpt-4o")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.encoding == nil || counter.count("Hello, world!") != 4 {
+		t.Errorf("counter of gpt-4o = %+v, want the o200k_base tokenizer", counter.encoding)
+	}
+
+	// Models without a tokenizer are estimated
+	counter, err = newTokenCounter("claude-sonnet-4-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.encoding != nil || counter.count("Answer briefly.") != analyzers.EstimateTokens("Answer briefly.") {
+		t.Error("counter without a tokenizer doesn't estimate")
+	}
+}
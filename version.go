@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/korchasa/promptlint/pkg/linter"
+	"github.com/korchasa/promptlint/pkg/llm"
+	"github.com/korchasa/promptlint/pkg/rules"
+)
+
+// Build metadata, release builds set it with
+// -ldflags "-X main.appVersion=1.2.3 -X main.buildCommit=<sha> -X main.buildDate=<RFC 3339>"
+var (
+	appVersion  = "0.1.0"
+	buildCommit = ""
+	buildDate   = ""
+)
+
+// VersionInfo describes the installed binary for fleet management and package managers
+type VersionInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	// Modified is true for builds of a working tree with uncommitted changes
+	Modified  bool   `json:"modified,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+	// Rules is the version of the embedded rule set, ActiveRules the version used by lint runs after `rules update`
+	Rules       string          `json:"rules"`
+	ActiveRules string          `json:"activeRules"`
+	Features    VersionFeatures `json:"features"`
+}
+
+// VersionFeatures lists the extension points compiled into the binary
+type VersionFeatures struct {
+	Providers    []string `json:"providers"`
+	InputFormats []string `json:"inputFormats"`
+	Analyzers    []string `json:"analyzers"`
+	Exporters    []string `json:"exporters"`
+	JudgePrompts []string `json:"judgePrompts"`
+}
+
+// versionInfo collects the build metadata, the commit of builds without ldflags comes from the Go toolchain
+func versionInfo() VersionInfo {
+	info := VersionInfo{
+		Name:      appName,
+		Version:   appVersion,
+		Commit:    buildCommit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Features: VersionFeatures{
+			Providers:    llm.Names(),
+			InputFormats: loaderNames(),
+			Analyzers:    analyzerNames(),
+			Exporters:    exporterNames(),
+			JudgePrompts: linter.JudgePrompts(),
+		},
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true" && buildCommit == ""
+			}
+		}
+	}
+
+	if embedded, err := rules.Embedded(); err == nil {
+		info.Rules, info.ActiveRules = embedded.Version, embedded.Version
+		pinned, _ := loadRulesVersion("")
+		if cached, _, err := loadCachedRules(embedded.Version, pinned); err == nil && cached != nil {
+			info.ActiveRules = cached.Version
+		}
+	}
+	return info
+}
+
+// FormatVersion formats the version information for humans, the first line is the one package managers test
+func FormatVersion(info VersionInfo) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s version %s\n", info.Name, info.Version))
+	if info.Commit != "" {
+		commit := info.Commit
+		if info.Modified {
+			commit += " (modified)"
+		}
+		sb.WriteString("  commit:   " + commit + "\n")
+	}
+	if info.BuildDate != "" {
+		sb.WriteString("  built:    " + info.BuildDate + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("  go:       %s %s\n", info.GoVersion, info.Platform))
+	rulesVersion := info.Rules
+	if info.ActiveRules != info.Rules {
+		rulesVersion = fmt.Sprintf("%s (embedded %s)", info.ActiveRules, info.Rules)
+	}
+	sb.WriteString("  rules:    " + rulesVersion + "\n")
+	sb.WriteString("  features: providers " + strings.Join(info.Features.Providers, ", ") +
+		"; judge prompts " + strings.Join(info.Features.JudgePrompts, ", ") + "\n")
+	return sb.String()
+}
+
+// runVersionCommand implements `promptlint version [--json]`
+func runVersionCommand(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Print the version information as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s version [--json]
+
+Prints the version, commit, build date, Go version, rule set versions and
+the providers, input formats, analyzers, exporters and judge prompts built
+into the binary.
+
+Options:
+`, appName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	info := versionInfo()
+	if !*jsonOutput {
+		fmt.Print(FormatVersion(info))
+		return nil
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode version information: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// vscodeSchemaVersion is the version of the --format=vscode report. Fields are only added within a version,
+// renaming or removing a field or changing its meaning bumps it.
+const vscodeSchemaVersion = 1
+
+// VSCodeReport is the stable diagnostics report for editor extensions, positions match vscode.Range:
+// 0-based lines and characters in UTF-16 code units of the file content
+type VSCodeReport struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Tool          VSCodeTool   `json:"tool"`
+	Files         []VSCodeFile `json:"files"`
+}
+
+// VSCodeTool identifies the CLI that produced the report
+type VSCodeTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// VSCodeFile holds the diagnostics of an input, Path is empty for stdin without --stdin-filename
+type VSCodeFile struct {
+	Path        string             `json:"path"`
+	Diagnostics []VSCodeDiagnostic `json:"diagnostics"`
+}
+
+// VSCodeDiagnostic is an active issue, Severity is error, warning or information
+type VSCodeDiagnostic struct {
+	Range       lspRange   `json:"range"`
+	Severity    string     `json:"severity"`
+	Code        VSCodeCode `json:"code"`
+	Source      string     `json:"source"`
+	Message     string     `json:"message"`
+	Fix         string     `json:"fix,omitempty"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	// Exact is true when the range covers the quoted snippet, otherwise it is the reported line or the file start
+	Exact bool       `json:"exact"`
+	Fixes []issueFix `json:"fixes"`
+}
+
+// VSCodeCode is the rule name with the link to its documentation, like the code of vscode.Diagnostic
+type VSCodeCode struct {
+	Value  string `json:"value"`
+	Target string `json:"target,omitempty"`
+}
+
+// vscodeSeverities maps issue severities to vscode.DiagnosticSeverity names
+var vscodeSeverities = map[string]string{"error": "error", "warning": "warning", "info": "information", "": "warning"}
+
+// ReportVSCode formats the active issues of the files as the stable diagnostics report
+func ReportVSCode(files []LintedFile) (string, error) {
+	report := VSCodeReport{
+		SchemaVersion: vscodeSchemaVersion,
+		Tool:          VSCodeTool{Name: appName, Version: appVersion},
+		Files:         make([]VSCodeFile, 0, len(files)),
+	}
+	for _, file := range files {
+		vscodeFile := VSCodeFile{Path: file.Name, Diagnostics: []VSCodeDiagnostic{}}
+		for _, issue := range file.Issues {
+			if issue.Dismissed {
+				continue
+			}
+			start, end, exact := locateInFile(file.Content, file.Text, issue)
+			located := lspIssue{Issue: issue, Start: start, End: end, Exact: exact}
+			from, to := diagnosticRange(file.Content, file.Text, located)
+			vscodeFile.Diagnostics = append(vscodeFile.Diagnostics, VSCodeDiagnostic{
+				Range:       lspRange{Start: offsetToLSP(file.Content, from), End: offsetToLSP(file.Content, to)},
+				Severity:    vscodeSeverities[issue.Severity],
+				Code:        VSCodeCode{Value: issue.RuleName, Target: issue.RuleLink},
+				Source:      appName,
+				Message:     issue.Description,
+				Fix:         issue.Fix,
+				Fingerprint: issue.Fingerprint,
+				Category:    issue.Category,
+				Exact:       exact,
+				Fixes:       issueFixes(file.Content, located),
+			})
+		}
+		report.Files = append(report.Files, vscodeFile)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	return string(data), nil
+}
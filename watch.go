@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the file system stays quiet before a run, editors write a save in several steps
+const watchDebounce = 200 * time.Millisecond
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// watchState returns the modification time and size of the files, missing files are absent
+func watchState(paths []string) map[string]string {
+	state := map[string]string{}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			state[path] = fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+		}
+	}
+	return state
+}
+
+// watchedFiles returns the prompts, the configuration files that apply to them and the extra files, empty paths are skipped
+func watchedFiles(prompts, extra []string) []string {
+	watched := []string{configFileName}
+	for _, prompt := range prompts {
+		watched = append(watched, prompt, filepath.Join(pathDir(prompt), configFileName))
+	}
+	for _, file := range extra {
+		if file != "" {
+			watched = append(watched, file)
+		}
+	}
+	return appendUnique(nil, watched...)
+}
+
+// changedFiles returns the sorted files added, removed or modified between the states
+func changedFiles(before, after map[string]string) []string {
+	var changed []string
+	for path, stamp := range after {
+		if before[path] != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// withoutWatchFlag removes --watch from the arguments of the check command
+func withoutWatchFlag(args []string) []string {
+	var result []string
+	for _, arg := range args {
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if strings.HasPrefix(arg, "-") && name == "watch" {
+			continue
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// fileWatcher reports changes of the files returned by paths. It watches the directories of the files, so files
+// replaced by editors and files created later are seen, and compares the files once the events settle.
+type fileWatcher struct {
+	watcher *fsnotify.Watcher
+	paths   func() []string
+	dirs    map[string]bool
+	last    map[string]string
+}
+
+// newFileWatcher starts watching the directories of the files returned by paths
+func newFileWatcher(paths func() []string) (*fileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch files: %w", err)
+	}
+	w := &fileWatcher{watcher: watcher, paths: paths, dirs: map[string]bool{}}
+	files := paths()
+	w.addDirs(files)
+	w.last = watchState(files)
+	return w, nil
+}
+
+// addDirs watches the directories and the directories of the files, those that don't exist yet are tried again later
+func (w *fileWatcher) addDirs(paths []string) {
+	for _, path := range paths {
+		dir := path
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			dir = pathDir(path)
+		}
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err == nil {
+			w.dirs[dir] = true
+		}
+	}
+}
+
+// run calls changed with the files added, removed or modified since the last call until the context is done
+func (w *fileWatcher) run(ctx context.Context, changed func(files []string)) {
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// Directories created in watched ones may receive files matched by patterns and --dir scans
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.addDirs([]string{event.Name})
+				}
+			}
+			settled = time.After(watchDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			printProgress(fmt.Sprintf("Watch error: %v", err))
+		case <-settled:
+			settled = nil
+			files := w.paths()
+			w.addDirs(files)
+			current := watchState(files)
+			if files := changedFiles(w.last, current); len(files) > 0 {
+				w.last = current
+				changed(files)
+			}
+		}
+	}
+}
+
+// Close stops watching
+func (w *fileWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// watchLint runs the check command with the arguments and runs it again whenever the files returned by paths change,
+// until interrupted. Paths are resolved again after every change so new files matching patterns and directories are seen,
+// directories among the paths are watched for new files.
+// Every run is a child process: a failed run doesn't end the watch and each run starts from a clean state.
+func watchLint(args []string, paths func() []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate %s: %w", appName, err)
+	}
+	args = withoutWatchFlag(args)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	run := func(changed []string) {
+		if isTerminal(os.Stdout) {
+			fmt.Print(clearScreen)
+		}
+		if len(changed) > 0 {
+			printProgress("Changed: " + strings.Join(changed, ", "))
+		}
+		cmd := exec.CommandContext(ctx, executable, append([]string{"check"}, args...)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.As(err, &exitErr):
+			printProgress(fmt.Sprintf("Run failed with exit status %d", exitErr.ExitCode()))
+		case err != nil:
+			printProgress(fmt.Sprintf("Run failed: %v", err))
+		}
+		printProgress(fmt.Sprintf("%s Watching %d files for changes, press Ctrl+C to stop", time.Now().Format("15:04:05"), len(watchState(paths()))))
+	}
+
+	watcher, err := newFileWatcher(paths)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	run(nil)
+	watcher.run(ctx, run)
+	return nil
+}